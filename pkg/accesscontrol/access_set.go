@@ -1,6 +1,7 @@
 package accesscontrol
 
 import (
+	"encoding/json"
 	"sort"
 
 	v1 "k8s.io/api/rbac/v1"
@@ -20,6 +21,25 @@ type AccessSet struct {
 
 type resourceAccessSet map[Access]bool
 
+// accessSetJSON is the serialized form of an AccessSet, used to share it through an AccessSetStore
+type accessSetJSON struct {
+	ID           string               `json:"id,omitempty"`
+	Resources    []resourceAccessJSON `json:"resources,omitempty"`
+	NonResources []nonResourceJSON    `json:"nonResources,omitempty"`
+}
+
+type resourceAccessJSON struct {
+	Verb     string   `json:"verb"`
+	Group    string   `json:"group"`
+	Resource string   `json:"resource"`
+	Access   []Access `json:"access"`
+}
+
+type nonResourceJSON struct {
+	Verb string `json:"verb"`
+	URL  string `json:"url"`
+}
+
 type key struct {
 	verb string
 	gr   schema.GroupResource
@@ -30,6 +50,66 @@ type nonResourceKey struct {
 	url  string
 }
 
+func (a *AccessSet) MarshalJSON() ([]byte, error) {
+	result := accessSetJSON{ID: a.ID}
+	for k, accessSet := range a.set {
+		entry := resourceAccessJSON{
+			Verb:     k.verb,
+			Group:    k.gr.Group,
+			Resource: k.gr.Resource,
+		}
+		for access := range accessSet {
+			entry.Access = append(entry.Access, access)
+		}
+		sort.Slice(entry.Access, func(i, j int) bool {
+			if entry.Access[i].Namespace != entry.Access[j].Namespace {
+				return entry.Access[i].Namespace < entry.Access[j].Namespace
+			}
+			return entry.Access[i].ResourceName < entry.Access[j].ResourceName
+		})
+		result.Resources = append(result.Resources, entry)
+	}
+	sort.Slice(result.Resources, func(i, j int) bool {
+		left, right := result.Resources[i], result.Resources[j]
+		if left.Verb != right.Verb {
+			return left.Verb < right.Verb
+		}
+		if left.Group != right.Group {
+			return left.Group < right.Group
+		}
+		return left.Resource < right.Resource
+	})
+	for k := range a.nonResourceSet {
+		result.NonResources = append(result.NonResources, nonResourceJSON{Verb: k.verb, URL: k.url})
+	}
+	sort.Slice(result.NonResources, func(i, j int) bool {
+		if result.NonResources[i].Verb != result.NonResources[j].Verb {
+			return result.NonResources[i].Verb < result.NonResources[j].Verb
+		}
+		return result.NonResources[i].URL < result.NonResources[j].URL
+	})
+	return json.Marshal(result)
+}
+
+func (a *AccessSet) UnmarshalJSON(data []byte) error {
+	var input accessSetJSON
+	if err := json.Unmarshal(data, &input); err != nil {
+		return err
+	}
+
+	*a = AccessSet{ID: input.ID}
+	for _, entry := range input.Resources {
+		gr := schema.GroupResource{Group: entry.Group, Resource: entry.Resource}
+		for _, access := range entry.Access {
+			a.Add(entry.Verb, gr, access)
+		}
+	}
+	for _, entry := range input.NonResources {
+		a.AddNonResourceURLs([]string{entry.Verb}, []string{entry.URL})
+	}
+	return nil
+}
+
 func (a *AccessSet) Namespaces() (result []string) {
 	set := map[string]bool{}
 	for k, as := range a.set {
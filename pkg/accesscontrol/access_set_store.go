@@ -0,0 +1,148 @@
+package accesscontrol
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/binary"
+	"encoding/json"
+	"errors"
+	"time"
+
+	v1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/rbac/v1"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+// AccessSetStore is an external key-value store in which computed access sets are shared, so that multiple steve
+// replicas don't each need to recompute the same RBAC snapshots.
+//
+// The store is trusted to keep entries available, not to keep them intact: access sets decide what users may do, so
+// each entry is signed with an HMAC of a key only the replicas hold, bound to the key of the entry and to when it
+// expires. Entries which fail verification, such as ones written or altered by anyone else with access to the store,
+// moved to the key of another user or kept past their expiry, are rejected and the access set is recomputed. Anyone
+// able to write to the store can still delete entries, or put back an earlier entry of the same key before it
+// expires, which can only restore access the user had within the TTL of access sets.
+type AccessSetStore interface {
+	// Get returns the value stored for key, and whether it was found
+	Get(ctx context.Context, key string) ([]byte, bool, error)
+	// Set stores value for key, expiring it after ttl
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	// Delete removes key from the store
+	Delete(ctx context.Context, key string) error
+}
+
+// ErrAccessSetStoreKeyRequired is returned when access sets are shared through an AccessSetStore without a key to sign
+// them with
+var ErrAccessSetStoreKeyRequired = errors.New("a key is required to sign the access sets of an access set store")
+
+// errInvalidEntry is returned for entries of an AccessSetStore which fail verification
+var errInvalidEntry = errors.New("invalid signature")
+
+// entryHeaderSize is the size of the HMAC and expiry, in Unix seconds, preceding the access set in entries
+const entryHeaderSize = sha256.Size + 8
+
+// backedCache is an accessStoreCache keeping recently used access sets in memory, in front of an AccessSetStore
+type backedCache struct {
+	ctx     context.Context
+	local   accessStoreCache
+	backend AccessSetStore
+	key     []byte
+	now     func() time.Time
+}
+
+// NewAccessStoreWithBackend returns an AccessStore caching computed access sets both in memory and in backend, signed
+// with key, which must be the same for all the replicas sharing backend and kept secret from anyone else with access
+// to it. Failures to reach backend are logged and the access sets are recomputed instead.
+func NewAccessStoreWithBackend(ctx context.Context, rbac v1.Interface, backend AccessSetStore, key []byte) (*AccessStore, error) {
+	if len(key) == 0 {
+		return nil, ErrAccessSetStoreKeyRequired
+	}
+	as := NewAccessStore(ctx, false, rbac)
+	as.cache = &backedCache{
+		ctx:     ctx,
+		local:   cache.NewLRUExpireCache(50),
+		backend: backend,
+		key:     key,
+		now:     time.Now,
+	}
+	return as, nil
+}
+
+// sign returns the entry of an access set encoded as data, for key, expiring at expiry
+func (b *backedCache) sign(key string, data []byte, expiry time.Time) []byte {
+	entry := make([]byte, entryHeaderSize, entryHeaderSize+len(data))
+	binary.BigEndian.PutUint64(entry[sha256.Size:], uint64(expiry.Unix()))
+	entry = append(entry, data...)
+	copy(entry, b.mac(key, entry[sha256.Size:]))
+	return entry
+}
+
+// verify returns the encoded access set of an entry for key, if its HMAC is valid and it hasn't expired
+func (b *backedCache) verify(key string, entry []byte) ([]byte, error) {
+	if len(entry) < entryHeaderSize || !hmac.Equal(entry[:sha256.Size], b.mac(key, entry[sha256.Size:])) {
+		return nil, errInvalidEntry
+	}
+	expiry := time.Unix(int64(binary.BigEndian.Uint64(entry[sha256.Size:entryHeaderSize])), 0)
+	if !b.now().Before(expiry) {
+		return nil, errors.New("expired")
+	}
+	return entry[entryHeaderSize:], nil
+}
+
+// mac returns the HMAC of the expiry and access set of an entry, bound to its key
+func (b *backedCache) mac(key string, signed []byte) []byte {
+	h := hmac.New(sha256.New, b.key)
+	h.Write([]byte(key))
+	h.Write([]byte{0})
+	h.Write(signed)
+	return h.Sum(nil)
+}
+
+func (b *backedCache) Add(key interface{}, value interface{}, ttl time.Duration) {
+	b.local.Add(key, value, ttl)
+
+	data, err := json.Marshal(value)
+	if err != nil {
+		logrus.Errorf("failed to encode access set %v: %v", key, err)
+		return
+	}
+	entry := b.sign(key.(string), data, b.now().Add(ttl))
+	if err := b.backend.Set(b.ctx, key.(string), entry, ttl); err != nil {
+		logrus.Errorf("failed to store access set %v: %v", key, err)
+	}
+}
+
+func (b *backedCache) Get(key interface{}) (interface{}, bool) {
+	if val, ok := b.local.Get(key); ok {
+		return val, true
+	}
+
+	entry, ok, err := b.backend.Get(b.ctx, key.(string))
+	if err != nil {
+		logrus.Errorf("failed to get access set %v: %v", key, err)
+		return nil, false
+	}
+	if !ok {
+		return nil, false
+	}
+	data, err := b.verify(key.(string), entry)
+	if err != nil {
+		logrus.Warnf("rejected access set %v from the access set store: %v", key, err)
+		return nil, false
+	}
+	result := &AccessSet{}
+	if err := json.Unmarshal(data, result); err != nil {
+		logrus.Errorf("failed to decode access set %v: %v", key, err)
+		return nil, false
+	}
+	b.local.Add(key, result, accessSetTTL)
+	return result, true
+}
+
+func (b *backedCache) Remove(key interface{}) {
+	b.local.Remove(key)
+	if err := b.backend.Delete(b.ctx, key.(string)); err != nil {
+		logrus.Errorf("failed to delete access set %v: %v", key, err)
+	}
+}
@@ -0,0 +1,122 @@
+package accesscontrol
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+type fakeAccessSetStore struct {
+	data map[string][]byte
+	err  error
+}
+
+func (f *fakeAccessSetStore) Get(_ context.Context, key string) ([]byte, bool, error) {
+	value, ok := f.data[key]
+	return value, ok, f.err
+}
+
+func (f *fakeAccessSetStore) Set(_ context.Context, key string, value []byte, _ time.Duration) error {
+	if f.err != nil {
+		return f.err
+	}
+	f.data[key] = value
+	return nil
+}
+
+func (f *fakeAccessSetStore) Delete(_ context.Context, key string) error {
+	delete(f.data, key)
+	return f.err
+}
+
+func newBackedCache(backend AccessSetStore, key string) *backedCache {
+	return &backedCache{ctx: context.Background(), local: cache.NewLRUExpireCache(10), backend: backend, key: []byte(key), now: time.Now}
+}
+
+func TestBackedCache(t *testing.T) {
+	accessSet := &AccessSet{ID: "key"}
+	accessSet.Add("list", schema.GroupResource{Resource: "pods"}, Access{Namespace: All, ResourceName: All})
+
+	backend := &fakeAccessSetStore{data: map[string][]byte{}}
+	replica1 := newBackedCache(backend, "secret")
+	replica2 := newBackedCache(backend, "secret")
+
+	replica1.Add("key", accessSet, time.Minute)
+	assert.Contains(t, backend.data, "key")
+
+	// another replica sharing the backend should not need to recompute the access set
+	val, ok := replica2.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, accessSet, val)
+	_, ok = replica2.local.Get("key")
+	assert.True(t, ok, "access set found in the backend should be cached locally")
+
+	replica1.Remove("key")
+	assert.NotContains(t, backend.data, "key")
+	_, ok = replica1.Get("key")
+	assert.False(t, ok)
+}
+
+func TestBackedCache_backendErrors(t *testing.T) {
+	accessSet := &AccessSet{ID: "key"}
+	backend := &fakeAccessSetStore{data: map[string][]byte{}, err: errors.New("unavailable")}
+	c := newBackedCache(backend, "secret")
+
+	// failures to reach the backend don't prevent local caching
+	c.Add("key", accessSet, time.Minute)
+	val, ok := c.Get("key")
+	assert.True(t, ok)
+	assert.Equal(t, accessSet, val)
+
+	_, ok = c.Get("missing")
+	assert.False(t, ok)
+}
+
+func TestBackedCache_verification(t *testing.T) {
+	accessSet := &AccessSet{ID: "alice"}
+	accessSet.Add("list", schema.GroupResource{Resource: "pods"}, Access{Namespace: All, ResourceName: All})
+	backend := &fakeAccessSetStore{data: map[string][]byte{}}
+	replica := newBackedCache(backend, "secret")
+	replica.Add("alice", accessSet, time.Minute)
+
+	// entries written without the key are rejected
+	data, err := json.Marshal(accessSet)
+	require.NoError(t, err)
+	backend.data["bob"] = data
+	_, ok := newBackedCache(backend, "secret").Get("bob")
+	assert.False(t, ok)
+	forged := newBackedCache(backend, "other")
+	forged.Add("bob", accessSet, time.Minute)
+	_, ok = newBackedCache(backend, "secret").Get("bob")
+	assert.False(t, ok)
+
+	// as are entries moved to the key of another user, or altered
+	backend.data["bob"] = backend.data["alice"]
+	_, ok = newBackedCache(backend, "secret").Get("bob")
+	assert.False(t, ok)
+	altered := append([]byte(nil), backend.data["alice"]...)
+	altered[len(altered)-2] ^= 1
+	backend.data["carol"] = altered
+	_, ok = newBackedCache(backend, "secret").Get("carol")
+	assert.False(t, ok)
+
+	// and expired entries
+	expired := newBackedCache(backend, "secret")
+	expired.now = func() time.Time { return time.Now().Add(time.Hour) }
+	_, ok = expired.Get("alice")
+	assert.False(t, ok)
+
+	val, ok := newBackedCache(backend, "secret").Get("alice")
+	assert.True(t, ok)
+	assert.Equal(t, accessSet, val)
+
+	_, err = NewAccessStoreWithBackend(context.Background(), nil, backend, nil)
+	assert.ErrorIs(t, err, ErrAccessSetStoreKeyRequired)
+}
@@ -1,9 +1,11 @@
 package accesscontrol
 
 import (
+	"encoding/json"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 )
 
 func TestAccessSet_AddNonResourceURLs(t *testing.T) {
@@ -210,3 +212,21 @@ func TestAccessSet_Merge(t *testing.T) {
 		})
 	}
 }
+
+func TestAccessSet_JSON(t *testing.T) {
+	accessSet := &AccessSet{ID: "abc"}
+	accessSet.Add("get", schema.GroupResource{Resource: "pods"}, Access{Namespace: "default", ResourceName: All})
+	accessSet.Add("get", schema.GroupResource{Resource: "pods"}, Access{Namespace: "other", ResourceName: "pod"})
+	accessSet.Add("*", schema.GroupResource{Group: "apps", Resource: "deployments"}, Access{Namespace: All, ResourceName: All})
+	accessSet.AddNonResourceURLs([]string{"get"}, []string{"/metrics"})
+
+	data, err := json.Marshal(accessSet)
+	assert.NoError(t, err)
+
+	result := &AccessSet{}
+	assert.NoError(t, json.Unmarshal(data, result))
+	assert.Equal(t, accessSet, result)
+	assert.True(t, result.Grants("get", schema.GroupResource{Resource: "pods"}, "other", "pod"))
+	assert.False(t, result.Grants("get", schema.GroupResource{Resource: "pods"}, "other", "other-pod"))
+	assert.True(t, result.GrantsNonResource("get", "/metrics"))
+}
@@ -4,21 +4,33 @@ import (
 	"context"
 	"slices"
 	"sort"
+	"sync/atomic"
 	"time"
 
 	v1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/rbac/v1"
 	"golang.org/x/sync/singleflight"
+	rbacv1 "k8s.io/api/rbac/v1"
 	"k8s.io/apimachinery/pkg/util/cache"
 	"k8s.io/apiserver/pkg/authentication/user"
 )
 
 //go:generate mockgen --build_flags=--mod=mod -package fake -destination fake/AccessSetLookup.go "github.com/rancher/steve/pkg/accesscontrol" AccessSetLookup
 
+// accessSetTTL is how long computed access sets are cached for
+const accessSetTTL = 24 * time.Hour
+
 type AccessSetLookup interface {
 	AccessFor(user user.Info) *AccessSet
 	PurgeUserData(id string)
 }
 
+// RevisionLookup is an AccessSetLookup whose access sets only change when its revision does, so that what is computed
+// from the access set of a user can be reused without looking it up again while the revision is unchanged
+type RevisionLookup interface {
+	AccessSetLookup
+	Revision() uint64
+}
+
 type policyRules interface {
 	getRoleRefs(subjectName string) subjectGrants
 }
@@ -35,9 +47,11 @@ type AccessStore struct {
 	groupsPolicyRules   policyRules
 	cache               accessStoreCache
 	concurrentAccessFor *singleflight.Group
+	// revision is incremented whenever a role or binding changes
+	revision atomic.Uint64
 }
 
-func NewAccessStore(_ context.Context, cacheResults bool, rbac v1.Interface) *AccessStore {
+func NewAccessStore(ctx context.Context, cacheResults bool, rbac v1.Interface) *AccessStore {
 	as := &AccessStore{
 		usersPolicyRules:    newPolicyRuleIndex(true, rbac),
 		groupsPolicyRules:   newPolicyRuleIndex(false, rbac),
@@ -46,9 +60,35 @@ func NewAccessStore(_ context.Context, cacheResults bool, rbac v1.Interface) *Ac
 	if cacheResults {
 		as.cache = cache.NewLRUExpireCache(50)
 	}
+	as.watchRevision(ctx, rbac)
 	return as
 }
 
+// watchRevision increments the revision of the store whenever a role or binding is changed or removed
+func (l *AccessStore) watchRevision(ctx context.Context, rbac v1.Interface) {
+	rbac.Role().OnChange(ctx, "access-revision", func(_ string, obj *rbacv1.Role) (*rbacv1.Role, error) {
+		l.revision.Add(1)
+		return obj, nil
+	})
+	rbac.RoleBinding().OnChange(ctx, "access-revision", func(_ string, obj *rbacv1.RoleBinding) (*rbacv1.RoleBinding, error) {
+		l.revision.Add(1)
+		return obj, nil
+	})
+	rbac.ClusterRole().OnChange(ctx, "access-revision", func(_ string, obj *rbacv1.ClusterRole) (*rbacv1.ClusterRole, error) {
+		l.revision.Add(1)
+		return obj, nil
+	})
+	rbac.ClusterRoleBinding().OnChange(ctx, "access-revision", func(_ string, obj *rbacv1.ClusterRoleBinding) (*rbacv1.ClusterRoleBinding, error) {
+		l.revision.Add(1)
+		return obj, nil
+	})
+}
+
+// Revision returns the revision of the roles and bindings, which changes whenever one of them does
+func (l *AccessStore) Revision() uint64 {
+	return l.revision.Load()
+}
+
 func (l *AccessStore) AccessFor(user user.Info) *AccessSet {
 	info := l.userGrantsFor(user)
 	if l.cache == nil {
@@ -65,7 +105,7 @@ func (l *AccessStore) AccessFor(user user.Info) *AccessSet {
 
 		result := l.newAccessSet(info)
 		result.ID = cacheKey
-		l.cache.Add(cacheKey, result, 24*time.Hour)
+		l.cache.Add(cacheKey, result, accessSetTTL)
 
 		return result, nil
 	})
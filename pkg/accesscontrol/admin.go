@@ -0,0 +1,19 @@
+package accesscontrol
+
+import (
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+// CheckAdmin returns the user of a request if it is granted every verb on every resource, as administrators are, or a
+// permission denied error saying that action, such as "compacting the cache", requires administrator access.
+func CheckAdmin(request *types.APIRequest, asl AccessSetLookup, action string) (user.Info, error) {
+	info, ok := request.GetUserInfo()
+	if ok && asl.AccessFor(info).Grants(All, schema.GroupResource{Group: All, Resource: All}, All, All) {
+		return info, nil
+	}
+	return nil, apierror.NewAPIError(validation.PermissionDenied, action+" requires administrator access")
+}
@@ -0,0 +1,56 @@
+package accesscontrol
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+type accessSets map[string]*AccessSet
+
+func (a accessSets) AccessFor(user user.Info) *AccessSet {
+	if access, ok := a[user.GetName()]; ok {
+		return access
+	}
+	return &AccessSet{}
+}
+
+func (a accessSets) PurgeUserData(_ string) {}
+
+func TestCheckAdmin(t *testing.T) {
+	all := schema.GroupResource{Group: All, Resource: All}
+	admin := &AccessSet{}
+	admin.Add(All, all, Access{Namespace: All, ResourceName: All})
+	reader := &AccessSet{}
+	reader.Add("list", all, Access{Namespace: All, ResourceName: All})
+	asl := accessSets{"admin": admin, "reader": reader}
+
+	requestFor := func(name string) *types.APIRequest {
+		req := httptest.NewRequest(http.MethodGet, "/", nil)
+		if name != "" {
+			req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: name}))
+		}
+		return &types.APIRequest{Request: req}
+	}
+
+	info, err := CheckAdmin(requestFor("admin"), asl, "compacting the cache")
+	require.NoError(t, err)
+	assert.Equal(t, "admin", info.GetName())
+
+	for _, name := range []string{"reader", ""} {
+		_, err = CheckAdmin(requestFor(name), asl, "compacting the cache")
+		var apiErr *apierror.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, validation.PermissionDenied, apiErr.Code)
+		assert.Equal(t, "compacting the cache requires administrator access", apiErr.Message)
+	}
+}
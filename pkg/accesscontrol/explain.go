@@ -0,0 +1,129 @@
+package accesscontrol
+
+import (
+	"slices"
+	"sort"
+
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+const (
+	clusterRoleBindingKind = "ClusterRoleBinding"
+	roleBindingKind        = "RoleBinding"
+)
+
+// Explanation details how the access set of a user grants, or doesn't grant, a verb on a resource
+type Explanation struct {
+	User      string   `json:"user"`
+	Groups    []string `json:"groups,omitempty"`
+	Verb      string   `json:"verb"`
+	Group     string   `json:"group"`
+	Resource  string   `json:"resource"`
+	Namespace string   `json:"namespace,omitempty"`
+	Name      string   `json:"name,omitempty"`
+	// Allowed is true if the access set of the user grants the verb on the resource in the namespace, with the name,
+	// or on all objects of the namespace without a name. Without a namespace, the verb must be granted in all
+	// namespaces.
+	Allowed bool `json:"allowed"`
+	// Namespaces are the namespaces in which the verb is granted on some or all objects of the resource, "*" for
+	// all namespaces
+	Namespaces []string `json:"namespaces,omitempty"`
+	// Grants are the rules granting the verb on the resource, in any namespace, through the bindings of the user and
+	// their groups
+	Grants []Grant `json:"grants,omitempty"`
+}
+
+// Grant is a rule of a role granting a verb on a resource to a subject through a binding
+type Grant struct {
+	// SubjectKind is User or Group, SubjectName being the name of the user or group
+	SubjectKind      string            `json:"subjectKind"`
+	SubjectName      string            `json:"subjectName"`
+	BindingKind      string            `json:"bindingKind"`
+	BindingName      string            `json:"bindingName"`
+	BindingNamespace string            `json:"bindingNamespace,omitempty"`
+	RoleKind         string            `json:"roleKind"`
+	RoleName         string            `json:"roleName"`
+	Rule             rbacv1.PolicyRule `json:"rule"`
+	// Matches is true if the rule grants the verb in the namespace and with the name of the explanation
+	Matches bool `json:"matches"`
+}
+
+// Explain returns how the roles bound to a user and their groups grant a verb on a resource, in a namespace and with a
+// name if set. Unlike AccessFor, the result isn't cached, and lists the rules of the roles bound to the user rather
+// than merging them.
+func (l *AccessStore) Explain(user user.Info, verb string, gr schema.GroupResource, namespace, name string) *Explanation {
+	info := l.userGrantsFor(user)
+	groups := slices.Clone(user.GetGroups())
+	sort.Strings(groups)
+
+	result := &Explanation{
+		User:      user.GetName(),
+		Groups:    groups,
+		Verb:      verb,
+		Group:     gr.Group,
+		Resource:  gr.Resource,
+		Namespace: namespace,
+		Name:      name,
+	}
+	namespaces := map[string]bool{}
+	explainSubject := func(subjectKind, subjectName string, grants subjectGrants) {
+		for _, binding := range grants.clusterRoleBindings {
+			result.explainBinding(subjectKind, subjectName, clusterRoleBindingKind, All, binding, verb, gr, namespaces)
+		}
+		for _, binding := range grants.roleBindings {
+			result.explainBinding(subjectKind, subjectName, roleBindingKind, binding.namespace, binding, verb, gr, namespaces)
+		}
+	}
+	explainSubject(rbacv1.UserKind, user.GetName(), info.user)
+	for i, group := range info.groups {
+		explainSubject(rbacv1.GroupKind, groups[i], group)
+	}
+
+	for ns := range namespaces {
+		result.Namespaces = append(result.Namespaces, ns)
+	}
+	sort.Strings(result.Namespaces)
+	return result
+}
+
+// explainBinding adds the rules of the role of a binding granting the verb on the resource to the explanation
+func (e *Explanation) explainBinding(subjectKind, subjectName, bindingKind, namespace string, binding roleRef, verb string, gr schema.GroupResource, namespaces map[string]bool) {
+	for _, rule := range binding.rules {
+		if len(rule.Resources) == 0 {
+			continue
+		}
+		access := new(AccessSet)
+		addResourceAccess(access, namespace, rule)
+		list := access.AccessListFor(verb, gr)
+		if len(list) == 0 {
+			continue
+		}
+		for _, a := range list {
+			namespaces[a.Namespace] = true
+		}
+
+		name := e.Name
+		if name == "" {
+			name = All
+		}
+		matches := list.Grants(e.Namespace, name)
+		e.Allowed = e.Allowed || matches
+
+		grant := Grant{
+			SubjectKind: subjectKind,
+			SubjectName: subjectName,
+			BindingKind: bindingKind,
+			BindingName: binding.bindingName,
+			RoleKind:    binding.roleRefKind,
+			RoleName:    binding.roleName,
+			Rule:        rule,
+			Matches:     matches,
+		}
+		if bindingKind == roleBindingKind {
+			grant.BindingNamespace = binding.namespace
+		}
+		e.Grants = append(e.Grants, grant)
+	}
+}
@@ -0,0 +1,83 @@
+package accesscontrol
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	rbacv1 "k8s.io/api/rbac/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func TestExplain(t *testing.T) {
+	readPods := rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods"}, Verbs: []string{"get", "list"}}
+	readOnePod := rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"pods"}, ResourceNames: []string{"web"}, Verbs: []string{"get"}}
+	readSecrets := rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"secrets"}, Verbs: []string{"list"}}
+	nodes := rbacv1.PolicyRule{APIGroups: []string{""}, Resources: []string{"nodes"}, Verbs: []string{"*"}}
+	store := &AccessStore{
+		usersPolicyRules: &policyRulesMock{
+			roleRefs: map[string]subjectGrants{
+				"alice": {
+					roleBindings: []roleRef{
+						{namespace: "dev", roleName: "pod-reader", bindingName: "alice-pods", roleRefKind: roleKind, kind: roleKind, rules: []rbacv1.PolicyRule{readPods, readSecrets}},
+						{namespace: "prod", roleName: "web-reader", bindingName: "alice-web", roleRefKind: roleKind, kind: roleKind, rules: []rbacv1.PolicyRule{readOnePod}},
+					},
+				},
+			},
+		},
+		groupsPolicyRules: &policyRulesMock{
+			roleRefs: map[string]subjectGrants{
+				"ops": {
+					clusterRoleBindings: []roleRef{
+						{roleName: "node-admin", bindingName: "ops-nodes", roleRefKind: clusterRoleKind, kind: clusterRoleKind, rules: []rbacv1.PolicyRule{nodes}},
+					},
+				},
+			},
+		},
+	}
+	alice := &user.DefaultInfo{Name: "alice", Groups: []string{"ops", "devs"}}
+	pods := schema.GroupResource{Resource: "pods"}
+
+	explanation := store.Explain(alice, "list", pods, "dev", "")
+	assert.True(t, explanation.Allowed)
+	assert.Equal(t, []string{"dev"}, explanation.Namespaces)
+	assert.Equal(t, []string{"devs", "ops"}, explanation.Groups)
+	assert.Equal(t, []Grant{
+		{
+			SubjectKind:      rbacv1.UserKind,
+			SubjectName:      "alice",
+			BindingKind:      roleBindingKind,
+			BindingName:      "alice-pods",
+			BindingNamespace: "dev",
+			RoleKind:         roleKind,
+			RoleName:         "pod-reader",
+			Rule:             readPods,
+			Matches:          true,
+		},
+	}, explanation.Grants)
+
+	explanation = store.Explain(alice, "get", pods, "prod", "")
+	assert.False(t, explanation.Allowed, "only some pods of prod can be read")
+	assert.Equal(t, []string{"dev", "prod"}, explanation.Namespaces)
+	assert.Len(t, explanation.Grants, 2)
+	assert.False(t, explanation.Grants[1].Matches)
+
+	explanation = store.Explain(alice, "get", pods, "prod", "web")
+	assert.True(t, explanation.Allowed)
+	assert.True(t, explanation.Grants[1].Matches)
+
+	explanation = store.Explain(alice, "list", pods, "", "")
+	assert.False(t, explanation.Allowed, "pods can't be listed in all namespaces")
+
+	explanation = store.Explain(alice, "delete", schema.GroupResource{Resource: "nodes"}, "", "node1")
+	assert.True(t, explanation.Allowed)
+	assert.Equal(t, []string{All}, explanation.Namespaces)
+	assert.Equal(t, rbacv1.GroupKind, explanation.Grants[0].SubjectKind)
+	assert.Equal(t, "ops", explanation.Grants[0].SubjectName)
+	assert.Equal(t, clusterRoleBindingKind, explanation.Grants[0].BindingKind)
+	assert.Empty(t, explanation.Grants[0].BindingNamespace)
+
+	explanation = store.Explain(alice, "list", schema.GroupResource{Group: "apps", Resource: "deployments"}, "dev", "")
+	assert.False(t, explanation.Allowed)
+	assert.Empty(t, explanation.Grants)
+}
@@ -172,6 +172,8 @@ func (p *policyRuleIndex) getRoleRefs(subjectName string) subjectGrants {
 			resourceVersion: resourceVersion,
 			rules:           rules,
 			kind:            clusterRoleKind,
+			bindingName:     crb.Name,
+			roleRefKind:     crb.RoleRef.Kind,
 		})
 	}
 
@@ -184,6 +186,8 @@ func (p *policyRuleIndex) getRoleRefs(subjectName string) subjectGrants {
 			resourceVersion: resourceVersion,
 			rules:           rules,
 			kind:            roleKind,
+			bindingName:     rb.Name,
+			roleRefKind:     rb.RoleRef.Kind,
 		})
 	}
 
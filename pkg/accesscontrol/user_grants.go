@@ -24,6 +24,9 @@ type subjectGrants struct {
 type roleRef struct {
 	namespace, roleName, resourceVersion, kind string
 	rules                                      []rbacv1.PolicyRule
+	// bindingName and roleRefKind are the name of the binding granting the role and the kind of the role it references,
+	// only used to explain access
+	bindingName, roleRefKind string
 }
 
 // hash calculates a unique identifier from all the grants for a user
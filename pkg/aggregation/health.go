@@ -0,0 +1,108 @@
+package aggregation
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// HealthPath is the path at which steve reports the health of the aggregation connection
+const HealthPath = "/aggregation/healthz"
+
+// State is the state of the aggregation connection
+type State string
+
+const (
+	StateConnecting   State = "connecting"
+	StateConnected    State = "connected"
+	StateDisconnected State = "disconnected"
+)
+
+// Status describes the aggregation connection
+type Status struct {
+	State State `json:"state"`
+	// Since is when the connection entered its state
+	Since time.Time `json:"since"`
+	// Failures is the number of failed connections since the connection was last established
+	Failures int `json:"failures"`
+	// LastError is the error which ended the last connection, if any
+	LastError string `json:"lastError,omitempty"`
+}
+
+// StateListener is notified when the state of the aggregation connection changes
+type StateListener interface {
+	OnStateChange(status Status)
+}
+
+// StateListenerFunc is a function implementing StateListener
+type StateListenerFunc func(status Status)
+
+// OnStateChange implements StateListener
+func (f StateListenerFunc) OnStateChange(status Status) {
+	f(status)
+}
+
+// Health tracks the status of the aggregation connection, notifying listeners of its changes, and serves it as JSON,
+// with a 503 status code unless connected
+type Health struct {
+	lock      sync.Mutex
+	status    Status
+	listeners []StateListener
+}
+
+// NewHealth returns a Health notifying listeners
+func NewHealth(listeners ...StateListener) *Health {
+	return &Health{
+		status: Status{
+			State: StateDisconnected,
+			Since: time.Now(),
+		},
+		listeners: listeners,
+	}
+}
+
+// Status returns the current status of the connection
+func (h *Health) Status() Status {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return h.status
+}
+
+func (h *Health) set(state State, err error) {
+	if h == nil {
+		return
+	}
+
+	h.lock.Lock()
+	status := h.status
+	switch {
+	case state == StateConnected:
+		status.Failures = 0
+		status.LastError = ""
+	case state == StateDisconnected && status.State != StateConnected:
+		status.Failures++
+	}
+	if err != nil {
+		status.LastError = err.Error()
+	}
+	if state != status.State {
+		status.Since = time.Now()
+	}
+	status.State = state
+	h.status = status
+	h.lock.Unlock()
+
+	for _, listener := range h.listeners {
+		listener.OnStateChange(status)
+	}
+}
+
+func (h *Health) ServeHTTP(rw http.ResponseWriter, _ *http.Request) {
+	status := h.Status()
+	rw.Header().Set("Content-Type", "application/json")
+	if status.State != StateConnected {
+		rw.WriteHeader(http.StatusServiceUnavailable)
+	}
+	_ = json.NewEncoder(rw).Encode(status)
+}
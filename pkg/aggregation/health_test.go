@@ -0,0 +1,69 @@
+package aggregation
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestHealth(t *testing.T) {
+	var states []State
+	health := NewHealth(StateListenerFunc(func(status Status) {
+		states = append(states, status.State)
+	}))
+	assert.Equal(t, StateDisconnected, health.Status().State)
+
+	health.set(StateConnecting, nil)
+	health.set(StateDisconnected, errors.New("connection refused"))
+	health.set(StateConnecting, nil)
+	health.set(StateDisconnected, errors.New("connection refused"))
+	status := health.Status()
+	assert.Equal(t, 2, status.Failures)
+	assert.Equal(t, "connection refused", status.LastError)
+
+	rw := httptest.NewRecorder()
+	health.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+	assert.Equal(t, http.StatusServiceUnavailable, rw.Code)
+
+	health.set(StateConnecting, nil)
+	health.set(StateConnected, nil)
+	status = health.Status()
+	assert.Equal(t, 0, status.Failures)
+	assert.Empty(t, status.LastError)
+
+	rw = httptest.NewRecorder()
+	health.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, HealthPath, nil))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	var served Status
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &served))
+	assert.Equal(t, StateConnected, served.State)
+
+	// a connection which was established isn't counted as a failure when it ends
+	health.set(StateDisconnected, errors.New("EOF"))
+	assert.Equal(t, 0, health.Status().Failures)
+
+	assert.Equal(t, []State{
+		StateConnecting, StateDisconnected, StateConnecting, StateDisconnected,
+		StateConnecting, StateConnected, StateDisconnected,
+	}, states)
+}
+
+func TestBackoff(t *testing.T) {
+	backoff := newBackoff()
+	first := backoff.Step()
+	assert.GreaterOrEqual(t, first, time.Second)
+	assert.LessOrEqual(t, first, time.Second+time.Second/2)
+
+	var last time.Duration
+	for i := 0; i < 20; i++ {
+		last = backoff.Step()
+	}
+	assert.GreaterOrEqual(t, last, 2*time.Minute)
+	assert.LessOrEqual(t, last, 3*time.Minute)
+}
@@ -4,6 +4,7 @@ import (
 	"context"
 	"crypto/tls"
 	"crypto/x509"
+	"math"
 	"net"
 	"net/http"
 	"strings"
@@ -13,13 +14,28 @@ import (
 	"github.com/rancher/remotedialer"
 	"github.com/rancher/steve/pkg/auth"
 	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/util/wait"
 )
 
 const (
 	HandshakeTimeOut = 10 * time.Second
 )
 
-func ListenAndServe(ctx context.Context, url string, caCert []byte, token string, handler http.Handler) {
+// newBackoff returns the backoff between connection attempts, which grows exponentially, with jitter so that many
+// clients don't reconnect at the same time
+func newBackoff() wait.Backoff {
+	return wait.Backoff{
+		Duration: time.Second,
+		Factor:   2,
+		Jitter:   0.5,
+		Steps:    math.MaxInt32,
+		Cap:      2 * time.Minute,
+	}
+}
+
+// ListenAndServe connects to the aggregation server at url and serves handler through the connection, reconnecting
+// with backoff until ctx is done. The state of the connection is tracked by health, which can be nil.
+func ListenAndServe(ctx context.Context, url string, caCert []byte, token string, handler http.Handler, health *Health) {
 	dialer := websocket.Dialer{
 		Proxy:            http.ProxyFromEnvironment,
 		HandshakeTimeout: HandshakeTimeOut,
@@ -44,20 +60,35 @@ func ListenAndServe(ctx context.Context, url string, caCert []byte, token string
 	headers := http.Header{}
 	headers.Add("Authorization", "Bearer "+token)
 
+	backoff := newBackoff()
 	for {
-		err := serve(ctx, dialer, url, headers, handler)
+		health.set(StateConnecting, nil)
+		connected := false
+		err := serve(ctx, dialer, url, headers, handler, func() {
+			connected = true
+			health.set(StateConnected, nil)
+		})
+		if ctx.Err() != nil {
+			health.set(StateDisconnected, nil)
+			return
+		}
+		health.set(StateDisconnected, err)
+		if connected {
+			backoff = newBackoff()
+		}
+		delay := backoff.Step()
 		if err != nil {
-			logrus.Errorf("Failed to dial steve aggregation server: %v", err)
+			logrus.Errorf("Failed to dial steve aggregation server, retrying in %s: %v", delay, err)
 		}
 		select {
 		case <-ctx.Done():
 			return
-		case <-time.After(5 * time.Second):
+		case <-time.After(delay):
 		}
 	}
 }
 
-func serve(ctx context.Context, dialer websocket.Dialer, url string, headers http.Header, handler http.Handler) error {
+func serve(ctx context.Context, dialer websocket.Dialer, url string, headers http.Header, handler http.Handler, onConnect func()) error {
 	url = strings.Replace(url, "http://", "ws://", 1)
 	url = strings.Replace(url, "https://", "wss://", 1)
 
@@ -72,6 +103,7 @@ func serve(ctx context.Context, dialer websocket.Dialer, url string, headers htt
 		return err
 	}
 	defer conn.Close()
+	onConnect()
 
 	go func() {
 		<-ctx.Done()
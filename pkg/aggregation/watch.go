@@ -10,7 +10,9 @@ import (
 	corev1 "k8s.io/api/core/v1"
 )
 
-func Watch(ctx context.Context, controller v1.SecretController, secretNamespace, secretName string, httpHandler http.Handler) {
+// Watch connects to the aggregation server set in the secret, reconnecting when it changes. The state of the
+// connection is tracked by health, which can be nil.
+func Watch(ctx context.Context, controller v1.SecretController, secretNamespace, secretName string, httpHandler http.Handler, health *Health) {
 	if secretNamespace == "" || secretName == "" {
 		return
 	}
@@ -19,6 +21,7 @@ func Watch(ctx context.Context, controller v1.SecretController, secretNamespace,
 		handler:   httpHandler,
 		namespace: secretNamespace,
 		name:      secretName,
+		health:    health,
 	}
 	controller.OnChange(ctx, "aggregation-controller", h.OnSecret)
 }
@@ -32,6 +35,7 @@ type handler struct {
 	token  string
 	ctx    context.Context
 	cancel func()
+	health *Health
 }
 
 func (h *handler) OnSecret(key string, secret *corev1.Secret) (*corev1.Secret, error) {
@@ -60,7 +64,7 @@ func (h *handler) OnSecret(key string, secret *corev1.Secret) (*corev1.Secret, e
 	}
 
 	ctx, cancel := context.WithCancel(h.ctx)
-	go ListenAndServe(ctx, url, caCert, token, h.handler)
+	go ListenAndServe(ctx, url, caCert, token, h.handler, h.health)
 
 	h.url = url
 	h.caCert = caCert
@@ -163,6 +163,17 @@ func Columns(s *types.APISchema) interface{} {
 	return s.Attributes["columns"]
 }
 
+// ComputedFields returns the paths of the computed fields of the objects of the schema's type, which the SQL cache can
+// filter and sort on
+func ComputedFields(s *types.APISchema) []string {
+	fields, _ := s.Attributes["computedFields"].([]string)
+	return fields
+}
+
+func SetComputedFields(s *types.APISchema, fields []string) {
+	setVal(s, "computedFields", fields)
+}
+
 func PreferredVersion(s *types.APISchema) string {
 	return convert.ToString(s.Attributes["preferredVersion"])
 }
@@ -184,3 +195,16 @@ func SetPreferredGroup(s *types.APISchema, ver string) {
 	}
 	s.Attributes["preferredGroup"] = ver
 }
+
+// DefaultSort returns the sort applied to lists of the schema's type by the SQL cache when the request has none, in
+// the format of the sort query param.
+func DefaultSort(s *types.APISchema) string {
+	if s == nil {
+		return ""
+	}
+	return str(s, "defaultSort")
+}
+
+func SetDefaultSort(s *types.APISchema, sort string) {
+	setVal(s, "defaultSort", sort)
+}
@@ -0,0 +1,43 @@
+package cli
+
+import (
+	"github.com/rancher/steve/pkg/auth"
+	"github.com/urfave/cli"
+)
+
+type KerberosConfig struct {
+	KeytabFile string
+	Principal  string
+}
+
+func (k *KerberosConfig) KerberosAuthenticator() (auth.Authenticator, error) {
+	if k.KeytabFile == "" {
+		return nil, nil
+	}
+
+	acceptor, err := auth.NewKerberosAcceptor(auth.KerberosConfig{
+		KeytabFile: k.KeytabFile,
+		Principal:  k.Principal,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return auth.NewNegotiateAuthenticator(acceptor), nil
+}
+
+func KerberosFlags(config *KerberosConfig) []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:        "kerberos-keytab",
+			EnvVar:      "KERBEROS_KEYTAB",
+			Usage:       "Keytab of the service principal, enables authentication with Kerberos tickets (SPNEGO)",
+			Destination: &config.KeytabFile,
+		},
+		cli.StringFlag{
+			Name:        "kerberos-principal",
+			EnvVar:      "KERBEROS_PRINCIPAL",
+			Usage:       "Service principal of the keytab tickets must be issued for, such as HTTP/steve.example.com, any of the keytab if empty",
+			Destination: &config.Principal,
+		},
+	}
+}
@@ -0,0 +1,82 @@
+package cli
+
+import (
+	"context"
+
+	"github.com/rancher/steve/pkg/auth"
+	"github.com/urfave/cli"
+)
+
+type OIDCConfig struct {
+	IssuerURL      string
+	ClientID       string
+	CAFile         string
+	UsernameClaim  string
+	UsernamePrefix string
+	GroupsClaim    string
+	GroupsPrefix   string
+}
+
+func (o *OIDCConfig) OIDCAuthenticator(ctx context.Context) (auth.Authenticator, error) {
+	if o.IssuerURL == "" {
+		return nil, nil
+	}
+
+	return auth.NewOIDCAuthenticator(ctx, auth.OIDCConfig{
+		IssuerURL:      o.IssuerURL,
+		ClientID:       o.ClientID,
+		CAFile:         o.CAFile,
+		UsernameClaim:  o.UsernameClaim,
+		UsernamePrefix: o.UsernamePrefix,
+		GroupsClaim:    o.GroupsClaim,
+		GroupsPrefix:   o.GroupsPrefix,
+	})
+}
+
+func OIDCFlags(config *OIDCConfig) []cli.Flag {
+	return []cli.Flag{
+		cli.StringFlag{
+			Name:        "oidc-issuer-url",
+			EnvVar:      "OIDC_ISSUER_URL",
+			Usage:       "https URL of the OpenID Connect provider, enables authentication with its ID tokens",
+			Destination: &config.IssuerURL,
+		},
+		cli.StringFlag{
+			Name:        "oidc-client-id",
+			EnvVar:      "OIDC_CLIENT_ID",
+			Usage:       "Client ID the ID tokens must be issued for",
+			Destination: &config.ClientID,
+		},
+		cli.StringFlag{
+			Name:        "oidc-ca-file",
+			EnvVar:      "OIDC_CA_FILE",
+			Usage:       "PEM bundle used to verify the OpenID Connect provider certificate",
+			Destination: &config.CAFile,
+		},
+		cli.StringFlag{
+			Name:        "oidc-username-claim",
+			EnvVar:      "OIDC_USERNAME_CLAIM",
+			Usage:       "ID token claim used as the user name",
+			Value:       "sub",
+			Destination: &config.UsernameClaim,
+		},
+		cli.StringFlag{
+			Name:        "oidc-username-prefix",
+			EnvVar:      "OIDC_USERNAME_PREFIX",
+			Usage:       "Prefix prepended to user names, oidc: if empty, none if -",
+			Destination: &config.UsernamePrefix,
+		},
+		cli.StringFlag{
+			Name:        "oidc-groups-claim",
+			EnvVar:      "OIDC_GROUPS_CLAIM",
+			Usage:       "ID token claim holding the user groups",
+			Destination: &config.GroupsClaim,
+		},
+		cli.StringFlag{
+			Name:        "oidc-groups-prefix",
+			EnvVar:      "OIDC_GROUPS_PREFIX",
+			Usage:       "Prefix prepended to groups, oidc: if empty, none if -",
+			Destination: &config.GroupsPrefix,
+		},
+	}
+}
@@ -1,11 +1,17 @@
 package cli
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strings"
 	"time"
 
 	"github.com/rancher/steve/pkg/auth"
 	"github.com/urfave/cli"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/rest"
 	"k8s.io/client-go/tools/clientcmd"
 )
 
@@ -14,6 +20,9 @@ type WebhookConfig struct {
 	WebhookKubeconfig     string
 	WebhookURL            string
 	CacheTTLSeconds       int
+	// CacheInvalidationResources are the resources, as group/version/resource, whose objects are named after the users
+	// whose cached authentication they invalidate when changed
+	CacheInvalidationResources cli.StringSlice
 }
 
 func (w *WebhookConfig) MustWebhookMiddleware() auth.Middleware {
@@ -25,6 +34,14 @@ func (w *WebhookConfig) MustWebhookMiddleware() auth.Middleware {
 }
 
 func (w *WebhookConfig) WebhookMiddleware() (auth.Middleware, error) {
+	authenticator, err := w.WebhookAuthenticator()
+	if err != nil || authenticator == nil {
+		return nil, err
+	}
+	return auth.ToMiddleware(authenticator), nil
+}
+
+func (w *WebhookConfig) WebhookAuthenticator() (auth.Authenticator, error) {
 	if !w.WebhookAuthentication {
 		return nil, nil
 	}
@@ -44,7 +61,45 @@ func (w *WebhookConfig) WebhookMiddleware() (auth.Middleware, error) {
 		return nil, err
 	}
 
-	return auth.NewWebhookMiddleware(time.Duration(w.CacheTTLSeconds)*time.Second, kubeConfig)
+	return auth.NewWebhookAuthenticator(time.Duration(w.CacheTTLSeconds)*time.Second, kubeConfig)
+}
+
+// WatchInvalidations invalidates the users cached by authenticator whenever the objects of the cache invalidation
+// resources named after them change
+func (w *WebhookConfig) WatchInvalidations(ctx context.Context, restConfig *rest.Config, authenticator auth.Authenticator) error {
+	if len(w.CacheInvalidationResources) == 0 {
+		return nil
+	}
+	invalidator, ok := authenticator.(auth.Invalidator)
+	if !ok || w.CacheTTLSeconds <= 0 {
+		return fmt.Errorf("cache invalidation resources require webhook authentication with a cache TTL")
+	}
+
+	var sources []auth.InvalidationSource
+	for _, resource := range w.CacheInvalidationResources {
+		gvr, err := parseGVR(resource)
+		if err != nil {
+			return err
+		}
+		sources = append(sources, auth.InvalidationSource{GVR: gvr})
+	}
+	client, err := dynamic.NewForConfig(restConfig)
+	if err != nil {
+		return err
+	}
+	return auth.WatchInvalidations(ctx, client, invalidator, sources...)
+}
+
+// parseGVR parses a resource as group/version/resource, or version/resource for the core group
+func parseGVR(s string) (schema.GroupVersionResource, error) {
+	parts := strings.Split(s, "/")
+	switch {
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return schema.GroupVersionResource{Version: parts[0], Resource: parts[1]}, nil
+	case len(parts) == 3 && parts[0] != "" && parts[1] != "" && parts[2] != "":
+		return schema.GroupVersionResource{Group: parts[0], Version: parts[1], Resource: parts[2]}, nil
+	}
+	return schema.GroupVersionResource{}, fmt.Errorf("invalid resource [%s], expected group/version/resource or version/resource", s)
 }
 
 func Flags(config *WebhookConfig) []cli.Flag {
@@ -69,5 +124,11 @@ func Flags(config *WebhookConfig) []cli.Flag {
 			EnvVar:      "WEBHOOK_CACHE_TTL",
 			Destination: &config.CacheTTLSeconds,
 		},
+		cli.StringSliceFlag{
+			Name:   "webhook-cache-invalidation-resource",
+			EnvVar: "WEBHOOK_CACHE_INVALIDATION_RESOURCES",
+			Usage:  "Resource, as group/version/resource, whose objects are named after users whose cached authentication is invalidated when they change, such as management.cattle.io/v3/userattributes, can be repeated",
+			Value:  &config.CacheInvalidationResources,
+		},
 	}
 }
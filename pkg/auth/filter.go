@@ -1,14 +1,15 @@
 package auth
 
 import (
+	"context"
 	"io/ioutil"
 	"net/http"
 	"strings"
 	"time"
 
+	"golang.org/x/sync/singleflight"
 	"k8s.io/apimachinery/pkg/util/wait"
 	"k8s.io/apiserver/pkg/authentication/authenticator"
-	"k8s.io/apiserver/pkg/authentication/token/cache"
 	"k8s.io/apiserver/pkg/authentication/user"
 	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/apiserver/plugin/pkg/authenticator/token/webhook"
@@ -93,7 +94,8 @@ func NewWebhookAuthenticator(cacheTTL time.Duration, kubeConfig *rest.Config) (A
 
 	if cacheTTL > 0 {
 		return &webhookAuth{
-			auth: cache.New(wh, false, cacheTTL, cacheTTL),
+			auth:  wh,
+			cache: newUserCache(cacheTTL),
 		}, nil
 	}
 
@@ -112,6 +114,16 @@ func NewWebhookMiddleware(cacheTTL time.Duration, kubeConfig *rest.Config) (Midd
 
 type webhookAuth struct {
 	auth authenticator.Token
+	// cache caches the users tokens are authenticated as, if a cache TTL is set
+	cache *userCache
+	// flights authenticates each uncached token once, however many of its requests arrive before it is cached
+	flights singleflight.Group
+}
+
+// authenticated is the result of authenticating a token
+type authenticated struct {
+	user user.Info
+	ok   bool
 }
 
 func (w *webhookAuth) Authenticate(req *http.Request) (user.Info, bool, error) {
@@ -135,6 +147,35 @@ func (w *webhookAuth) Authenticate(req *http.Request) (user.Info, bool, error) {
 		return nil, false, nil
 	}
 
+	if w.cache == nil {
+		return w.authenticateToken(req, token)
+	}
+	info, ok, found, _ := w.cache.get(token)
+	if found {
+		return info, ok, nil
+	}
+	// the token is authenticated for all its waiting requests, so it must not be interrupted when the first one ends
+	ctx := context.WithoutCancel(req.Context())
+	result, err, _ := w.flights.Do(tokenKey(token), func() (interface{}, error) {
+		// the token may have been cached by a flight which ended since it was looked up
+		info, ok, found, generation := w.cache.get(token)
+		if found {
+			return authenticated{user: info, ok: ok}, nil
+		}
+		info, ok, err := w.authenticateToken(req.WithContext(ctx), token)
+		if err != nil {
+			return nil, err
+		}
+		w.cache.add(token, info, ok, generation)
+		return authenticated{user: info, ok: ok}, nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+	return result.(authenticated).user, result.(authenticated).ok, nil
+}
+
+func (w *webhookAuth) authenticateToken(req *http.Request, token string) (user.Info, bool, error) {
 	resp, ok, err := w.auth.AuthenticateToken(req.Context(), token)
 	if resp == nil {
 		return nil, ok, err
@@ -142,6 +183,24 @@ func (w *webhookAuth) Authenticate(req *http.Request) (user.Info, bool, error) {
 	return resp.User, ok, err
 }
 
+func (w *webhookAuth) InvalidateUser(name string) {
+	if w.cache != nil {
+		w.cache.InvalidateUser(name)
+	}
+}
+
+func (w *webhookAuth) InvalidateGroup(group string) {
+	if w.cache != nil {
+		w.cache.InvalidateGroup(group)
+	}
+}
+
+func (w *webhookAuth) InvalidateAll() {
+	if w.cache != nil {
+		w.cache.InvalidateAll()
+	}
+}
+
 func ToMiddleware(auth Authenticator) Middleware {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
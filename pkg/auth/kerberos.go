@@ -0,0 +1,92 @@
+package auth
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/jcmturner/gofork/encoding/asn1"
+	"github.com/jcmturner/gokrb5/v8/gssapi"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/service"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+// KerberosConfig configures an acceptor of Kerberos tickets.
+type KerberosConfig struct {
+	// KeytabFile holds the keys of the service principal the tickets are issued for
+	KeytabFile string
+	// Principal is the service principal of the keytab, such as HTTP/steve.example.com, that of the tickets if empty
+	Principal string
+}
+
+type kerberosAcceptor struct {
+	settings *service.Settings
+	// acceptCompleted is the token telling clients the ticket was accepted
+	acceptCompleted []byte
+}
+
+// NewKerberosAcceptor returns a NegotiateAcceptor validating the Kerberos tickets
+// of SPNEGO tokens with the keys of a keytab. Users are named after the client
+// principal of their ticket, as user@REALM.
+func NewKerberosAcceptor(config KerberosConfig) (NegotiateAcceptor, error) {
+	if config.KeytabFile == "" {
+		return nil, errors.New("kerberos: keytab file is required")
+	}
+	kt, err := keytab.Load(config.KeytabFile)
+	if err != nil {
+		return nil, fmt.Errorf("kerberos: loading keytab: %w", err)
+	}
+	options := []func(*service.Settings){service.DecodePAC(false)}
+	if config.Principal != "" {
+		options = append(options, service.KeytabPrincipal(config.Principal))
+	}
+	return newKerberosAcceptor(kt, options...)
+}
+
+func newKerberosAcceptor(kt *keytab.Keytab, options ...func(*service.Settings)) (*kerberosAcceptor, error) {
+	resp := spnego.NegTokenResp{
+		NegState:      asn1.Enumerated(spnego.NegStateAcceptCompleted),
+		SupportedMech: gssapi.OIDKRB5.OID(),
+	}
+	acceptCompleted, err := resp.Marshal()
+	if err != nil {
+		return nil, err
+	}
+	return &kerberosAcceptor{
+		settings:        service.NewSettings(kt, options...),
+		acceptCompleted: acceptCompleted,
+	}, nil
+}
+
+func (k *kerberosAcceptor) Accept(_ context.Context, token []byte) (user.Info, []byte, error) {
+	// clients send either SPNEGO tokens wrapping a Kerberos token, or the Kerberos token itself
+	mechToken := token
+	var st spnego.SPNEGOToken
+	if err := st.Unmarshal(token); err == nil {
+		if !st.Init {
+			return nil, nil, errors.New("kerberos: unexpected SPNEGO response token")
+		}
+		mechToken = st.NegTokenInit.MechTokenBytes
+	}
+	var krb5Token spnego.KRB5Token
+	if err := krb5Token.Unmarshal(mechToken); err != nil {
+		return nil, nil, fmt.Errorf("kerberos: invalid token: %w", err)
+	}
+	if !krb5Token.IsAPReq() {
+		return nil, nil, errors.New("kerberos: token is not a service ticket request")
+	}
+	ok, creds, err := service.VerifyAPREQ(&krb5Token.APReq, k.settings)
+	if err != nil {
+		return nil, nil, fmt.Errorf("kerberos: %w", err)
+	}
+	if !ok {
+		return nil, nil, errors.New("kerberos: ticket rejected")
+	}
+	info := &user.DefaultInfo{
+		Name:   creds.UserName() + "@" + creds.Realm(),
+		Groups: []string{user.AllAuthenticated},
+	}
+	return info, k.acceptCompleted, nil
+}
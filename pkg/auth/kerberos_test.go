@@ -0,0 +1,62 @@
+package auth
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/jcmturner/gokrb5/v8/client"
+	"github.com/jcmturner/gokrb5/v8/config"
+	"github.com/jcmturner/gokrb5/v8/iana/etypeID"
+	"github.com/jcmturner/gokrb5/v8/iana/nametype"
+	"github.com/jcmturner/gokrb5/v8/keytab"
+	"github.com/jcmturner/gokrb5/v8/messages"
+	"github.com/jcmturner/gokrb5/v8/spnego"
+	"github.com/jcmturner/gokrb5/v8/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+// kerberosTicket returns a SPNEGO token with a ticket of jane for the service of kt, as issued by a KDC
+func kerberosTicket(t *testing.T, kt *keytab.Keytab, endTime time.Time) []byte {
+	now := time.Now().UTC()
+	cname := types.NewPrincipalName(nametype.KRB_NT_PRINCIPAL, "jane")
+	sname := types.NewPrincipalName(nametype.KRB_NT_SRV_INST, "HTTP/steve.example.com")
+	ticket, sessionKey, err := messages.NewTicket(cname, "EXAMPLE.COM", sname, "EXAMPLE.COM", types.NewKrbFlags(), kt, etypeID.AES256_CTS_HMAC_SHA1_96, 1, now, now, endTime, endTime)
+	require.NoError(t, err)
+
+	cl := client.NewWithPassword("jane", "EXAMPLE.COM", "password", config.New())
+	init, err := spnego.NewNegTokenInitKRB5(cl, ticket, sessionKey)
+	require.NoError(t, err)
+	token := spnego.SPNEGOToken{Init: true, NegTokenInit: init}
+	data, err := token.Marshal()
+	require.NoError(t, err)
+	return data
+}
+
+func TestKerberosAcceptor(t *testing.T) {
+	kt := keytab.New()
+	require.NoError(t, kt.AddEntry("HTTP/steve.example.com", "EXAMPLE.COM", "service-password", time.Now(), 1, etypeID.AES256_CTS_HMAC_SHA1_96))
+	acceptor, err := newKerberosAcceptor(kt)
+	require.NoError(t, err)
+
+	info, output, err := acceptor.Accept(context.Background(), kerberosTicket(t, kt, time.Now().Add(time.Hour)))
+	require.NoError(t, err)
+	assert.Equal(t, &user.DefaultInfo{Name: "jane@EXAMPLE.COM", Groups: []string{user.AllAuthenticated}}, info)
+	var resp spnego.SPNEGOToken
+	require.NoError(t, resp.Unmarshal(output))
+	assert.True(t, resp.Resp)
+	assert.EqualValues(t, spnego.NegStateAcceptCompleted, resp.NegTokenResp.NegState, "clients are told the ticket was accepted")
+
+	_, _, err = acceptor.Accept(context.Background(), kerberosTicket(t, kt, time.Now().Add(-time.Hour)))
+	assert.Error(t, err, "expired tickets are rejected")
+
+	other := keytab.New()
+	require.NoError(t, other.AddEntry("HTTP/steve.example.com", "EXAMPLE.COM", "other-password", time.Now(), 1, etypeID.AES256_CTS_HMAC_SHA1_96))
+	_, _, err = acceptor.Accept(context.Background(), kerberosTicket(t, other, time.Now().Add(time.Hour)))
+	assert.Error(t, err, "tickets encrypted with other keys are rejected")
+
+	_, _, err = acceptor.Accept(context.Background(), []byte("forged"))
+	assert.Error(t, err)
+}
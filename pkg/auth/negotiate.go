@@ -0,0 +1,133 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"net/http"
+	"strings"
+
+	utilerrors "k8s.io/apimachinery/pkg/util/errors"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+const negotiateScheme = "Negotiate"
+
+// NegotiateAcceptor accepts the SPNEGO tokens sent by clients with the
+// Negotiate authentication scheme (RFC 4559), typically by validating a
+// Kerberos ticket against a keytab. It returns the authenticated user and an
+// optional token to send back to the client for mutual authentication.
+type NegotiateAcceptor interface {
+	Accept(ctx context.Context, token []byte) (user.Info, []byte, error)
+}
+
+// NegotiateAcceptorFunc is a function implementing NegotiateAcceptor.
+type NegotiateAcceptorFunc func(ctx context.Context, token []byte) (user.Info, []byte, error)
+
+func (n NegotiateAcceptorFunc) Accept(ctx context.Context, token []byte) (user.Info, []byte, error) {
+	return n(ctx, token)
+}
+
+type negotiateAuth struct {
+	acceptor NegotiateAcceptor
+}
+
+// NewNegotiateAuthenticator returns an Authenticator for requests with an
+// "Authorization: Negotiate" header, delegating the token validation to acceptor.
+// Use it with NegotiateChallenge so that clients are asked to negotiate.
+func NewNegotiateAuthenticator(acceptor NegotiateAcceptor) Authenticator {
+	return &negotiateAuth{
+		acceptor: acceptor,
+	}
+}
+
+func (n *negotiateAuth) Authenticate(req *http.Request) (user.Info, bool, error) {
+	header, ok := strings.CutPrefix(req.Header.Get("Authorization"), negotiateScheme+" ")
+	if !ok {
+		return nil, false, nil
+	}
+	token, err := base64.StdEncoding.DecodeString(strings.TrimSpace(header))
+	if err != nil {
+		return nil, false, fmt.Errorf("negotiate: invalid token: %w", err)
+	}
+	info, output, err := n.acceptor.Accept(req.Context(), token)
+	if err != nil {
+		return nil, false, err
+	}
+	if response, ok := req.Context().Value(negotiateResponseKey{}).(*negotiateResponse); ok && info != nil {
+		response.token = output
+	}
+	return info, info != nil, nil
+}
+
+// negotiateResponseKey is the context key of the negotiateResponse of a request
+type negotiateResponseKey struct{}
+
+// negotiateResponse holds the token authenticating the server to the client of a request, sent back in its response
+type negotiateResponse struct {
+	token []byte
+}
+
+// NegotiateChallenge adds a "WWW-Authenticate: Negotiate" header to 401
+// responses, so that browsers and clients configured for Kerberos retry the
+// request with a SPNEGO token. Once a request is authenticated by the
+// Authenticator of NewNegotiateAuthenticator, the output token of its acceptor
+// is sent back in the header of its response for mutual authentication, so
+// the authentication middleware must be wrapped by NegotiateChallenge.
+func NegotiateChallenge(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		response := &negotiateResponse{}
+		req = req.WithContext(context.WithValue(req.Context(), negotiateResponseKey{}, response))
+		next.ServeHTTP(&challengeWriter{ResponseWriter: rw, response: response}, req)
+	})
+}
+
+type challengeWriter struct {
+	http.ResponseWriter
+	response    *negotiateResponse
+	wroteHeader bool
+}
+
+func (c *challengeWriter) WriteHeader(code int) {
+	if !c.wroteHeader {
+		c.wroteHeader = true
+		switch {
+		case len(c.response.token) > 0:
+			c.Header().Set("WWW-Authenticate", negotiateScheme+" "+base64.StdEncoding.EncodeToString(c.response.token))
+		case code == http.StatusUnauthorized:
+			c.Header().Add("WWW-Authenticate", negotiateScheme)
+		}
+	}
+	c.ResponseWriter.WriteHeader(code)
+}
+
+func (c *challengeWriter) Write(data []byte) (int, error) {
+	if !c.wroteHeader {
+		c.WriteHeader(http.StatusOK)
+	}
+	return c.ResponseWriter.Write(data)
+}
+
+func (c *challengeWriter) Unwrap() http.ResponseWriter {
+	return c.ResponseWriter
+}
+
+// Union returns an Authenticator trying each of authenticators in order and
+// returning the first user authenticated. Errors are only returned when no
+// authenticator accepted the request.
+func Union(authenticators ...Authenticator) Authenticator {
+	return AuthenticatorFunc(func(req *http.Request) (user.Info, bool, error) {
+		var errs []error
+		for _, authenticator := range authenticators {
+			info, ok, err := authenticator.Authenticate(req)
+			if err != nil {
+				errs = append(errs, err)
+				continue
+			}
+			if ok {
+				return info, true, nil
+			}
+		}
+		return nil, false, utilerrors.NewAggregate(errs)
+	})
+}
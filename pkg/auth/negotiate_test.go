@@ -0,0 +1,138 @@
+package auth
+
+import (
+	"context"
+	"encoding/base64"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+func TestNegotiateAuthenticator(t *testing.T) {
+	acceptor := NegotiateAcceptorFunc(func(ctx context.Context, token []byte) (user.Info, []byte, error) {
+		if string(token) != "ticket" {
+			return nil, nil, errors.New("invalid ticket")
+		}
+		return &user.DefaultInfo{Name: "jane@EXAMPLE.COM"}, nil, nil
+	})
+	authenticator := NewNegotiateAuthenticator(acceptor)
+
+	tests := []struct {
+		name          string
+		authorization string
+		wantUser      user.Info
+		wantOK        bool
+		wantErr       bool
+	}{
+		{
+			name:          "valid ticket",
+			authorization: "Negotiate " + base64.StdEncoding.EncodeToString([]byte("ticket")),
+			wantUser:      &user.DefaultInfo{Name: "jane@EXAMPLE.COM"},
+			wantOK:        true,
+		},
+		{
+			name:          "invalid ticket",
+			authorization: "Negotiate " + base64.StdEncoding.EncodeToString([]byte("forged")),
+			wantErr:       true,
+		},
+		{
+			name:          "invalid encoding",
+			authorization: "Negotiate !!!",
+			wantErr:       true,
+		},
+		{
+			name:          "other scheme",
+			authorization: "Bearer token",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/v1/pods", nil)
+			req.Header.Set("Authorization", test.authorization)
+			info, ok, err := authenticator.Authenticate(req)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.wantOK, ok)
+			assert.Equal(t, test.wantUser, info)
+		})
+	}
+}
+
+func TestNegotiateChallenge(t *testing.T) {
+	handler := NegotiateChallenge(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/private" {
+			rw.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		rw.WriteHeader(http.StatusOK)
+	}))
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/private", nil))
+	assert.Equal(t, http.StatusUnauthorized, rw.Code)
+	assert.Equal(t, "Negotiate", rw.Header().Get("WWW-Authenticate"))
+
+	rw = httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/public", nil))
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Empty(t, rw.Header().Get("WWW-Authenticate"))
+}
+
+func TestNegotiateMutualAuthentication(t *testing.T) {
+	acceptor := NegotiateAcceptorFunc(func(ctx context.Context, token []byte) (user.Info, []byte, error) {
+		if string(token) != "ticket" {
+			return nil, nil, errors.New("invalid ticket")
+		}
+		return &user.DefaultInfo{Name: "jane@EXAMPLE.COM"}, []byte("accepted"), nil
+	})
+	handler := NegotiateChallenge(ToMiddleware(NewNegotiateAuthenticator(acceptor))(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		_, _ = rw.Write([]byte("ok"))
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/v1/pods", nil)
+	req.Header.Set("Authorization", "Negotiate "+base64.StdEncoding.EncodeToString([]byte("ticket")))
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "Negotiate "+base64.StdEncoding.EncodeToString([]byte("accepted")), rw.Header().Get("WWW-Authenticate"))
+
+	req.Header.Set("Authorization", "Negotiate "+base64.StdEncoding.EncodeToString([]byte("forged")))
+	rw = httptest.NewRecorder()
+	handler.ServeHTTP(rw, req)
+	assert.Empty(t, rw.Header().Get("WWW-Authenticate"), "rejected tickets get no output token")
+}
+
+func TestUnion(t *testing.T) {
+	jane := &user.DefaultInfo{Name: "jane"}
+	failing := AuthenticatorFunc(func(req *http.Request) (user.Info, bool, error) {
+		return nil, false, errors.New("failed")
+	})
+	anonymous := AuthenticatorFunc(func(req *http.Request) (user.Info, bool, error) {
+		return nil, false, nil
+	})
+	accepting := AuthenticatorFunc(func(req *http.Request) (user.Info, bool, error) {
+		return jane, true, nil
+	})
+	req := httptest.NewRequest(http.MethodGet, "/v1/pods", nil)
+
+	info, ok, err := Union(failing, anonymous, accepting).Authenticate(req)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, jane, info)
+
+	_, ok, err = Union(anonymous, failing).Authenticate(req)
+	assert.Error(t, err)
+	assert.False(t, ok)
+
+	_, ok, err = Union(anonymous).Authenticate(req)
+	assert.NoError(t, err)
+	assert.False(t, ok)
+}
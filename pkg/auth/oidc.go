@@ -0,0 +1,205 @@
+package auth
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	jose "gopkg.in/square/go-jose.v2"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/apiserver/pkg/apis/apiserver"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/plugin/pkg/authenticator/token/oidc"
+)
+
+const (
+	// DefaultOIDCPrefix is prepended to the user names and groups of ID tokens by default, so that they can't be
+	// mistaken for Kubernetes users and groups such as system:masters
+	DefaultOIDCPrefix = "oidc:"
+	// NoOIDCPrefix disables the prefix of user names or groups
+	NoOIDCPrefix = "-"
+	// oidcInitTimeout is how long NewOIDCAuthenticator waits for the provider to be discovered
+	oidcInitTimeout = 30 * time.Second
+)
+
+// oidcSigningAlgs are the algorithms ID tokens can be signed with
+var oidcSigningAlgs = []string{"RS256", "RS384", "RS512", "ES256", "ES384", "ES512"}
+
+// OIDCConfig configures an authenticator verifying OpenID Connect ID tokens.
+type OIDCConfig struct {
+	// IssuerURL is the https URL of the provider, it must match the iss claim of the tokens
+	IssuerURL string
+	// ClientID is the audience the tokens must be issued for
+	ClientID string
+	// CAFile is an optional PEM bundle used to verify the provider certificate
+	CAFile string
+	// UsernameClaim is the claim used as the user name, defaults to "sub"
+	UsernameClaim string
+	// UsernamePrefix is prepended to the user name, DefaultOIDCPrefix if empty and none if NoOIDCPrefix
+	UsernamePrefix string
+	// GroupsClaim is the claim holding the user groups, either a string or a list of strings
+	GroupsClaim string
+	// GroupsPrefix is prepended to every group, DefaultOIDCPrefix if empty and none if NoOIDCPrefix
+	GroupsPrefix string
+}
+
+type oidcAuth struct {
+	auth authenticator.Token
+}
+
+// oidcPrefix returns the prefix configured by value
+func oidcPrefix(value string) *string {
+	switch value {
+	case "":
+		value = DefaultOIDCPrefix
+	case NoOIDCPrefix:
+		value = ""
+	}
+	return &value
+}
+
+// NewOIDCAuthenticator discovers the provider at config.IssuerURL and returns
+// an Authenticator accepting its ID tokens as bearer tokens. Tokens are verified
+// by the OIDC authenticator of the Kubernetes API server.
+func NewOIDCAuthenticator(ctx context.Context, config OIDCConfig) (Authenticator, error) {
+	if config.IssuerURL == "" {
+		return nil, errors.New("oidc: issuer URL is required")
+	}
+	if config.ClientID == "" {
+		return nil, errors.New("oidc: client ID is required")
+	}
+	if config.UsernameClaim == "" {
+		config.UsernameClaim = "sub"
+	}
+
+	var tlsConfig *tls.Config
+	if config.CAFile != "" {
+		pem, err := os.ReadFile(config.CAFile)
+		if err != nil {
+			return nil, fmt.Errorf("oidc: reading CA file: %w", err)
+		}
+		pool := x509.NewCertPool()
+		if !pool.AppendCertsFromPEM(pem) {
+			return nil, fmt.Errorf("oidc: no certificates found in %s", config.CAFile)
+		}
+		tlsConfig = &tls.Config{RootCAs: pool}
+	}
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	transport.TLSClientConfig = tlsConfig
+
+	claimMappings := apiserver.ClaimMappings{
+		Username: apiserver.PrefixedClaimOrExpression{
+			Claim:  config.UsernameClaim,
+			Prefix: oidcPrefix(config.UsernamePrefix),
+		},
+		UID: apiserver.ClaimOrExpression{Claim: "sub"},
+	}
+	if config.GroupsClaim != "" {
+		claimMappings.Groups = apiserver.PrefixedClaimOrExpression{
+			Claim:  config.GroupsClaim,
+			Prefix: oidcPrefix(config.GroupsPrefix),
+		}
+	}
+	auth, err := oidc.New(ctx, oidc.Options{
+		JWTAuthenticator: apiserver.JWTAuthenticator{
+			Issuer: apiserver.Issuer{
+				URL:       config.IssuerURL,
+				Audiences: []string{config.ClientID},
+			},
+			ClaimMappings: claimMappings,
+		},
+		Client: &http.Client{
+			Transport: &keySetFilter{base: transport},
+			Timeout:   30 * time.Second,
+		},
+		SupportedSigningAlgs: oidcSigningAlgs,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("oidc: %w", err)
+	}
+
+	// the provider is discovered in the background, tokens can't be verified until it is
+	err = wait.PollUntilContextTimeout(ctx, 100*time.Millisecond, oidcInitTimeout, true, func(context.Context) (bool, error) {
+		return auth.HealthCheck() == nil, nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("oidc: discovering %s: %w", config.IssuerURL, auth.HealthCheck())
+	}
+	return &oidcAuth{auth: auth}, nil
+}
+
+func (o *oidcAuth) Authenticate(req *http.Request) (user.Info, bool, error) {
+	token, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok || token == "" {
+		return nil, false, nil
+	}
+	// tokens which aren't issued by the provider, such as opaque tokens meant for another authenticator, are ignored
+	resp, ok, err := o.auth.AuthenticateToken(req.Context(), token)
+	if !ok || err != nil {
+		return nil, false, err
+	}
+	info := &user.DefaultInfo{
+		Name:   resp.User.GetName(),
+		UID:    resp.User.GetUID(),
+		Groups: append([]string{user.AllAuthenticated}, resp.User.GetGroups()...),
+	}
+	if len(resp.User.GetExtra()) > 0 {
+		info.Extra = resp.User.GetExtra()
+	}
+	return info, true, nil
+}
+
+// keySetFilter drops the keys which can't be parsed, such as those of unsupported curves, from the key sets of
+// providers, since a single one would fail the verification of all tokens
+type keySetFilter struct {
+	base http.RoundTripper
+}
+
+func (k *keySetFilter) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := k.base.RoundTrip(req)
+	if err != nil || resp.StatusCode != http.StatusOK {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	var keySet struct {
+		Keys []json.RawMessage `json:"keys"`
+	}
+	if err := json.Unmarshal(body, &keySet); err != nil || keySet.Keys == nil {
+		// not a key set, such as the discovery document
+		return resp, nil
+	}
+	keys := make([]json.RawMessage, 0, len(keySet.Keys))
+	for _, raw := range keySet.Keys {
+		var key jose.JSONWebKey
+		if err := key.UnmarshalJSON(raw); err != nil {
+			logrus.Debugf("oidc: ignoring key of %s: %v", req.URL, err)
+			continue
+		}
+		keys = append(keys, raw)
+	}
+	filtered, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(filtered))
+	resp.ContentLength = int64(len(filtered))
+	resp.Header.Del("Content-Length")
+	return resp, nil
+}
@@ -0,0 +1,313 @@
+package auth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"encoding/pem"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+type testIssuer struct {
+	server *httptest.Server
+	caFile string
+	rsaKey *rsa.PrivateKey
+	ecKey  *ecdsa.PrivateKey
+}
+
+func newTestIssuer(t *testing.T) *testIssuer {
+	rsaKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	require.NoError(t, err)
+	ecKey, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	issuer := &testIssuer{rsaKey: rsaKey, ecKey: ecKey}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/.well-known/openid-configuration", func(rw http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(rw).Encode(map[string]string{
+			"issuer":   issuer.server.URL,
+			"jwks_uri": issuer.server.URL + "/keys",
+		})
+	})
+	mux.HandleFunc("/keys", func(rw http.ResponseWriter, req *http.Request) {
+		json.NewEncoder(rw).Encode(map[string]interface{}{
+			"keys": []map[string]string{
+				{
+					"kty": "RSA",
+					"kid": "rsa",
+					"use": "sig",
+					"n":   encodeBigInt(rsaKey.N),
+					"e":   encodeBigInt(big.NewInt(int64(rsaKey.E))),
+				},
+				{
+					"kty": "EC",
+					"kid": "ec",
+					"crv": "P-256",
+					"x":   encodeBigInt(ecKey.X),
+					"y":   encodeBigInt(ecKey.Y),
+				},
+				{
+					"kty": "RSA",
+					"kid": "enc",
+					"use": "enc",
+					"n":   encodeBigInt(rsaKey.N),
+					"e":   encodeBigInt(big.NewInt(int64(rsaKey.E))),
+				},
+				{
+					// keys of unsupported curves don't prevent verifying tokens signed with the other keys
+					"kty": "EC",
+					"kid": "secp256k1",
+					"crv": "secp256k1",
+					"x":   encodeBigInt(ecKey.X),
+					"y":   encodeBigInt(ecKey.Y),
+				},
+			},
+		})
+	})
+	issuer.server = httptest.NewTLSServer(mux)
+	t.Cleanup(issuer.server.Close)
+	issuer.caFile = filepath.Join(t.TempDir(), "ca.pem")
+	ca := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: issuer.server.Certificate().Raw})
+	require.NoError(t, os.WriteFile(issuer.caFile, ca, 0600))
+	return issuer
+}
+
+func (i *testIssuer) sign(t *testing.T, alg, kid string, claims map[string]interface{}) string {
+	header, err := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	require.NoError(t, err)
+	payload, err := json.Marshal(claims)
+	require.NoError(t, err)
+	signed := base64.RawURLEncoding.EncodeToString(header) + "." + base64.RawURLEncoding.EncodeToString(payload)
+	digest := sha256.Sum256([]byte(signed))
+
+	var signature []byte
+	switch alg {
+	case "RS256":
+		signature, err = rsa.SignPKCS1v15(rand.Reader, i.rsaKey, crypto.SHA256, digest[:])
+		require.NoError(t, err)
+	case "ES256":
+		r, s, err := ecdsa.Sign(rand.Reader, i.ecKey, digest[:])
+		require.NoError(t, err)
+		signature = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	}
+	return signed + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func encodeBigInt(i *big.Int) string {
+	return base64.RawURLEncoding.EncodeToString(i.Bytes())
+}
+
+func TestOIDCAuthenticator(t *testing.T) {
+	issuer := newTestIssuer(t)
+	now := time.Now()
+	validClaims := func(overrides map[string]interface{}) map[string]interface{} {
+		claims := map[string]interface{}{
+			"iss":    issuer.server.URL,
+			"aud":    "steve",
+			"sub":    "1234",
+			"email":  "jane@example.com",
+			"exp":    now.Add(time.Hour).Unix(),
+			"groups": []string{"admins", "devs"},
+		}
+		for k, v := range overrides {
+			if v == nil {
+				delete(claims, k)
+				continue
+			}
+			claims[k] = v
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name          string
+		config        OIDCConfig
+		authorization string
+		token         func() string
+		wantUser      user.Info
+		wantOK        bool
+		wantErr       bool
+	}{
+		{
+			name:   "rsa signed token",
+			config: OIDCConfig{ClientID: "steve", GroupsClaim: "groups"},
+			token: func() string {
+				return issuer.sign(t, "RS256", "rsa", validClaims(nil))
+			},
+			wantUser: &user.DefaultInfo{
+				Name:   "oidc:1234",
+				UID:    "1234",
+				Groups: []string{user.AllAuthenticated, "oidc:admins", "oidc:devs"},
+			},
+			wantOK: true,
+		},
+		{
+			name:   "kubernetes groups are prefixed by default",
+			config: OIDCConfig{ClientID: "steve", GroupsClaim: "groups", UsernamePrefix: NoOIDCPrefix},
+			token: func() string {
+				return issuer.sign(t, "RS256", "rsa", validClaims(map[string]interface{}{"sub": "system:admin", "groups": []string{"system:masters"}}))
+			},
+			wantUser: &user.DefaultInfo{
+				Name:   "system:admin",
+				UID:    "system:admin",
+				Groups: []string{user.AllAuthenticated, "oidc:system:masters"},
+			},
+			wantOK: true,
+		},
+		{
+			name:   "ecdsa signed token with username claim",
+			config: OIDCConfig{ClientID: "steve", UsernameClaim: "email", UsernamePrefix: "dex:"},
+			token: func() string {
+				return issuer.sign(t, "ES256", "ec", validClaims(map[string]interface{}{"aud": []string{"other", "steve"}}))
+			},
+			wantUser: &user.DefaultInfo{
+				Name:   "dex:jane@example.com",
+				UID:    "1234",
+				Groups: []string{user.AllAuthenticated},
+			},
+			wantOK: true,
+		},
+		{
+			name:   "unverified email",
+			config: OIDCConfig{ClientID: "steve", UsernameClaim: "email"},
+			token: func() string {
+				return issuer.sign(t, "RS256", "rsa", validClaims(map[string]interface{}{"email_verified": false}))
+			},
+			wantErr: true,
+		},
+		{
+			name:   "wrong audience",
+			config: OIDCConfig{ClientID: "steve"},
+			token: func() string {
+				return issuer.sign(t, "RS256", "rsa", validClaims(map[string]interface{}{"aud": "other"}))
+			},
+			wantErr: true,
+		},
+		{
+			name:   "tokens of other issuers are left to other authenticators",
+			config: OIDCConfig{ClientID: "steve"},
+			token: func() string {
+				return issuer.sign(t, "RS256", "rsa", validClaims(map[string]interface{}{"iss": "https://evil.example.com"}))
+			},
+		},
+		{
+			name:   "expired",
+			config: OIDCConfig{ClientID: "steve"},
+			token: func() string {
+				return issuer.sign(t, "RS256", "rsa", validClaims(map[string]interface{}{"exp": now.Add(-time.Minute).Unix()}))
+			},
+			wantErr: true,
+		},
+		{
+			name:   "no expiry",
+			config: OIDCConfig{ClientID: "steve"},
+			token: func() string {
+				return issuer.sign(t, "RS256", "rsa", validClaims(map[string]interface{}{"exp": nil}))
+			},
+			wantErr: true,
+		},
+		{
+			name:   "algorithm not matching the key",
+			config: OIDCConfig{ClientID: "steve"},
+			token: func() string {
+				return issuer.sign(t, "ES256", "rsa", validClaims(nil))
+			},
+			wantErr: true,
+		},
+		{
+			name:   "tampered claims",
+			config: OIDCConfig{ClientID: "steve"},
+			token: func() string {
+				token := issuer.sign(t, "RS256", "rsa", validClaims(nil))
+				other := issuer.sign(t, "RS256", "rsa", validClaims(map[string]interface{}{"sub": "admin"}))
+				return strings.Split(token, ".")[0] + "." + strings.Split(other, ".")[1] + "." + strings.Split(token, ".")[2]
+			},
+			wantErr: true,
+		},
+		{
+			name:   "unknown key",
+			config: OIDCConfig{ClientID: "steve"},
+			token: func() string {
+				return issuer.sign(t, "RS256", "unknown", validClaims(nil))
+			},
+			wantErr: true,
+		},
+		{
+			name:   "unsupported algorithm",
+			config: OIDCConfig{ClientID: "steve"},
+			token: func() string {
+				return issuer.sign(t, "none", "rsa", validClaims(nil))
+			},
+			wantErr: true,
+		},
+		{
+			name:          "opaque token is left to other authenticators",
+			config:        OIDCConfig{ClientID: "steve"},
+			authorization: "Bearer token-abc:xyz",
+		},
+		{
+			name:          "no bearer token",
+			config:        OIDCConfig{ClientID: "steve"},
+			authorization: "Basic dXNlcjpwYXNz",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			test.config.IssuerURL = issuer.server.URL
+			test.config.CAFile = issuer.caFile
+			authenticator, err := NewOIDCAuthenticator(context.Background(), test.config)
+			require.NoError(t, err)
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/pods", nil)
+			if test.token != nil {
+				req.Header.Set("Authorization", "Bearer "+test.token())
+			} else {
+				req.Header.Set("Authorization", test.authorization)
+			}
+			info, ok, err := authenticator.Authenticate(req)
+			if test.wantErr {
+				assert.Error(t, err)
+				assert.False(t, ok)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.wantOK, ok)
+			assert.Equal(t, test.wantUser, info)
+		})
+	}
+}
+
+func TestNewOIDCAuthenticatorErrors(t *testing.T) {
+	issuer := newTestIssuer(t)
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	_, err := NewOIDCAuthenticator(ctx, OIDCConfig{ClientID: "steve"})
+	assert.Error(t, err)
+	_, err = NewOIDCAuthenticator(ctx, OIDCConfig{IssuerURL: issuer.server.URL})
+	assert.Error(t, err)
+	_, err = NewOIDCAuthenticator(ctx, OIDCConfig{IssuerURL: "http://dex.example.com", ClientID: "steve"})
+	assert.Error(t, err, "the issuer must be served over https")
+	// the discovered issuer must match exactly
+	_, err = NewOIDCAuthenticator(ctx, OIDCConfig{IssuerURL: issuer.server.URL + "/", ClientID: "steve", CAFile: issuer.caFile})
+	assert.Error(t, err)
+	_, err = NewOIDCAuthenticator(ctx, OIDCConfig{IssuerURL: issuer.server.URL + "/missing", ClientID: "steve", CAFile: issuer.caFile})
+	assert.Error(t, err)
+}
@@ -0,0 +1,339 @@
+// Package tokens issues scoped and expiring API tokens, stored as secrets, and authenticates the requests bearing them,
+// so that standalone deployments can grant systems such as CI limited access without Kubernetes service accounts.
+package tokens
+
+import (
+	"context"
+	cryptorand "crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"path"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	corev1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+	"k8s.io/apimachinery/pkg/util/rand"
+	"k8s.io/apiserver/pkg/authentication/user"
+	corev1client "k8s.io/client-go/kubernetes/typed/core/v1"
+)
+
+const (
+	// ScopeRead allows the requests which don't modify anything: GET, HEAD and OPTIONS requests, including watches, but
+	// not the upgrade requests of exec, attach and portforward streams, which run commands in or connect to pods
+	ScopeRead = "read"
+	// ScopeWrite allows all requests
+	ScopeWrite = "write"
+
+	// DefaultTTL is how long tokens created without a TTL are valid
+	DefaultTTL = 24 * time.Hour
+	// MaxTTL is the longest TTL of tokens
+	MaxTTL = 365 * 24 * time.Hour
+	// CacheTTL is how long verified tokens are cached, and so how long deleting a token can take to revoke it on other
+	// replicas
+	CacheTTL = 10 * time.Second
+
+	// maxCachedTokens is the number of cached tokens from which expired ones are swept
+	maxCachedTokens = 1024
+
+	// prefix identifies the bearer tokens issued by the manager, so that the tokens of other authenticators are left
+	// alone
+	prefix      = "steve-"
+	secretType  = corev1.SecretType("steve.cattle.io/token")
+	secretLabel = "steve.cattle.io/token"
+	hashKey     = "hash"
+	tokenKey    = "token"
+)
+
+var (
+	ErrInvalidToken = errors.New("invalid token")
+	ErrExpiredToken = errors.New("token expired")
+	ErrOutOfScope   = errors.New("request out of the scopes of the token")
+	// ErrInvalidOptions is wrapped by the errors of the tokens created with invalid scopes or TTLs
+	ErrInvalidOptions = errors.New("invalid token options")
+)
+
+// Token is an API token. It authenticates requests as its user and groups, limited to its scopes, until it expires
+type Token struct {
+	ID          string    `json:"id"`
+	Description string    `json:"description,omitempty"`
+	UserName    string    `json:"userName"`
+	Groups      []string  `json:"groups,omitempty"`
+	Scopes      []string  `json:"scopes"`
+	CreatedAt   time.Time `json:"createdAt"`
+	ExpiresAt   time.Time `json:"expiresAt"`
+	// Value is the bearer token. It is only returned when the token is created, only its hash is kept
+	Value string `json:"value,omitempty"`
+}
+
+// Expired returns whether the token is expired at now
+func (t *Token) Expired(now time.Time) bool {
+	return !now.Before(t.ExpiresAt)
+}
+
+// streamingSubresources are the subresources of pods whose GET requests open streams into pods, directly through the
+// Kubernetes API or as the links of steve
+var streamingSubresources = map[string]bool{
+	"exec":        true,
+	"attach":      true,
+	"portforward": true,
+}
+
+// Allows returns whether the scopes of the token allow a request
+func (t *Token) Allows(req *http.Request) bool {
+	for _, scope := range t.Scopes {
+		switch scope {
+		case ScopeWrite:
+			return true
+		case ScopeRead:
+			if isReadOnly(req) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isReadOnly returns whether a request can't modify anything, nor open a stream into a pod
+func isReadOnly(req *http.Request) bool {
+	if req.Method != http.MethodGet && req.Method != http.MethodHead && req.Method != http.MethodOptions {
+		return false
+	}
+	if httpstream.IsUpgradeRequest(req) {
+		return false
+	}
+	if streamingSubresources[req.URL.Query().Get("link")] {
+		return false
+	}
+	return !(strings.Contains(req.URL.Path, "/pods/") && streamingSubresources[path.Base(req.URL.Path)])
+}
+
+// cachedToken is a verified token, cached until expires
+type cachedToken struct {
+	token   *Token
+	expires time.Time
+}
+
+// Manager creates, lists and deletes tokens, kept in the secrets of a namespace, and authenticates requests with them
+type Manager struct {
+	secrets corev1client.SecretInterface
+	now     func() time.Time
+
+	// cache holds the verified tokens by the hash of their bearer token, so that requests don't get their secrets
+	cacheLock sync.Mutex
+	cache     map[string]cachedToken
+}
+
+// NewManager returns a Manager keeping tokens in secrets, which should be those of a namespace only administrators
+// can read
+func NewManager(secrets corev1client.SecretInterface) *Manager {
+	return &Manager{
+		secrets: secrets,
+		now:     time.Now,
+		cache:   map[string]cachedToken{},
+	}
+}
+
+// Create issues a token for the user, returning it with its value. Tokens are read only if no scopes are set, and
+// valid for DefaultTTL if ttl is zero.
+func (m *Manager) Create(ctx context.Context, user user.Info, description string, scopes []string, ttl time.Duration) (*Token, error) {
+	if len(scopes) == 0 {
+		scopes = []string{ScopeRead}
+	}
+	for _, scope := range scopes {
+		if scope != ScopeRead && scope != ScopeWrite {
+			return nil, fmt.Errorf("%w: invalid scope %q, expected %s or %s", ErrInvalidOptions, scope, ScopeRead, ScopeWrite)
+		}
+	}
+	if ttl == 0 {
+		ttl = DefaultTTL
+	}
+	if ttl < 0 || ttl > MaxTTL {
+		return nil, fmt.Errorf("%w: invalid TTL %s, expected at most %s", ErrInvalidOptions, ttl, MaxTTL)
+	}
+
+	secret := make([]byte, 32)
+	if _, err := cryptorand.Read(secret); err != nil {
+		return nil, err
+	}
+	now := m.now().UTC().Truncate(time.Second)
+	token := &Token{
+		ID:          rand.String(10),
+		Description: description,
+		UserName:    user.GetName(),
+		Groups:      user.GetGroups(),
+		Scopes:      scopes,
+		CreatedAt:   now,
+		ExpiresAt:   now.Add(ttl),
+	}
+	data, err := json.Marshal(token)
+	if err != nil {
+		return nil, err
+	}
+	value := base64.RawURLEncoding.EncodeToString(secret)
+	_, err = m.secrets.Create(ctx, &corev1.Secret{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   secretName(token.ID),
+			Labels: map[string]string{secretLabel: "true"},
+		},
+		Type: secretType,
+		Data: map[string][]byte{
+			hashKey:  []byte(hash(value)),
+			tokenKey: data,
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return nil, err
+	}
+	token.Value = prefix + token.ID + "." + value
+	return token, nil
+}
+
+// Get returns the token of the ID, without its value
+func (m *Manager) Get(ctx context.Context, id string) (*Token, error) {
+	secret, err := m.secrets.Get(ctx, secretName(id), metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	return fromSecret(secret)
+}
+
+// List returns the tokens, without their values, sorted by ID
+func (m *Manager) List(ctx context.Context) ([]*Token, error) {
+	secrets, err := m.secrets.List(ctx, metav1.ListOptions{LabelSelector: secretLabel})
+	if err != nil {
+		return nil, err
+	}
+	var result []*Token
+	for i := range secrets.Items {
+		token, err := fromSecret(&secrets.Items[i])
+		if err != nil {
+			continue
+		}
+		result = append(result, token)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result, nil
+}
+
+// Delete revokes the token of the ID. Other replicas keep authenticating it until it expires from their cache, for at
+// most CacheTTL.
+func (m *Manager) Delete(ctx context.Context, id string) error {
+	if err := m.secrets.Delete(ctx, secretName(id), metav1.DeleteOptions{}); err != nil {
+		return err
+	}
+	m.cacheLock.Lock()
+	defer m.cacheLock.Unlock()
+	for key, cached := range m.cache {
+		if cached.token.ID == id {
+			delete(m.cache, key)
+		}
+	}
+	return nil
+}
+
+// Authenticate authenticates the requests bearing tokens issued by the manager as the users of the tokens. Requests
+// with expired tokens, or out of the scopes of their tokens, fail to authenticate. Verified tokens are cached for
+// CacheTTL.
+func (m *Manager) Authenticate(req *http.Request) (user.Info, bool, error) {
+	bearer, ok := strings.CutPrefix(req.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		return nil, false, nil
+	}
+	bearer, ok = strings.CutPrefix(bearer, prefix)
+	if !ok {
+		return nil, false, nil
+	}
+	id, value, ok := strings.Cut(bearer, ".")
+	if !ok || id == "" || value == "" {
+		return nil, false, nil
+	}
+
+	token, err := m.verify(req.Context(), id, value)
+	if err != nil {
+		return nil, false, err
+	}
+	if token.Expired(m.now()) {
+		return nil, false, ErrExpiredToken
+	}
+	if !token.Allows(req) {
+		return nil, false, ErrOutOfScope
+	}
+	return &user.DefaultInfo{
+		Name:   token.UserName,
+		Groups: token.Groups,
+	}, true, nil
+}
+
+// verify returns the token of the ID if value is its secret, from the cache if it was verified less than CacheTTL ago
+func (m *Manager) verify(ctx context.Context, id, value string) (*Token, error) {
+	key := hash(id + "." + value)
+	now := m.now()
+	m.cacheLock.Lock()
+	cached, ok := m.cache[key]
+	m.cacheLock.Unlock()
+	if ok && now.Before(cached.expires) {
+		return cached.token, nil
+	}
+
+	secret, err := m.secrets.Get(ctx, secretName(id), metav1.GetOptions{})
+	if apierrors.IsNotFound(err) {
+		return nil, ErrInvalidToken
+	} else if err != nil {
+		return nil, err
+	}
+	if subtle.ConstantTimeCompare(secret.Data[hashKey], []byte(hash(value))) != 1 {
+		return nil, ErrInvalidToken
+	}
+	token, err := fromSecret(secret)
+	if err != nil {
+		return nil, err
+	}
+
+	m.cacheLock.Lock()
+	defer m.cacheLock.Unlock()
+	if len(m.cache) >= maxCachedTokens {
+		for k, cached := range m.cache {
+			if !now.Before(cached.expires) {
+				delete(m.cache, k)
+			}
+		}
+	}
+	// only verified tokens are cached, and none while the cache is full of unexpired ones
+	if len(m.cache) < maxCachedTokens {
+		m.cache[key] = cachedToken{token: token, expires: now.Add(CacheTTL)}
+	}
+	return token, nil
+}
+
+func fromSecret(secret *corev1.Secret) (*Token, error) {
+	if secret.Type != secretType {
+		return nil, ErrInvalidToken
+	}
+	token := &Token{}
+	if err := json.Unmarshal(secret.Data[tokenKey], token); err != nil {
+		return nil, fmt.Errorf("invalid token %s: %w", secret.Name, err)
+	}
+	return token, nil
+}
+
+func secretName(id string) string {
+	return "token-" + id
+}
+
+func hash(value string) string {
+	sum := sha256.Sum256([]byte(value))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,154 @@
+package tokens
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestManager(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	manager := NewManager(fake.NewSimpleClientset().CoreV1().Secrets("tokens"))
+	manager.now = func() time.Time { return now }
+	ci := &user.DefaultInfo{Name: "ci", Groups: []string{"readers"}}
+
+	_, err := manager.Create(ctx, ci, "", []string{"admin"}, 0)
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+	_, err = manager.Create(ctx, ci, "", nil, 2*MaxTTL)
+	assert.ErrorIs(t, err, ErrInvalidOptions)
+
+	read, err := manager.Create(ctx, ci, "nightly build", nil, 0)
+	require.NoError(t, err)
+	assert.Equal(t, []string{ScopeRead}, read.Scopes)
+	assert.Equal(t, now.Add(DefaultTTL), read.ExpiresAt)
+	assert.Regexp(t, "^steve-"+read.ID+`\..+`, read.Value)
+	write, err := manager.Create(ctx, ci, "", []string{ScopeWrite}, time.Hour)
+	require.NoError(t, err)
+
+	tokens, err := manager.List(ctx)
+	require.NoError(t, err)
+	require.Len(t, tokens, 2)
+	for _, token := range tokens {
+		assert.Empty(t, token.Value, "values are not kept")
+	}
+
+	authenticate := func(method, bearer string) (user.Info, bool, error) {
+		req := httptest.NewRequest(method, "/v1/pods", nil)
+		if bearer != "" {
+			req.Header.Set("Authorization", "Bearer "+bearer)
+		}
+		return manager.Authenticate(req)
+	}
+
+	info, ok, err := authenticate(http.MethodGet, read.Value)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	assert.Equal(t, "ci", info.GetName())
+	assert.Equal(t, []string{"readers"}, info.GetGroups())
+
+	_, _, err = authenticate(http.MethodPost, read.Value)
+	assert.ErrorIs(t, err, ErrOutOfScope)
+	_, ok, err = authenticate(http.MethodPost, write.Value)
+	require.NoError(t, err)
+	assert.True(t, ok)
+	_, _, err = authenticate(http.MethodGet, read.Value+"x")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	_, _, err = authenticate(http.MethodGet, "steve-unknown.value")
+	assert.ErrorIs(t, err, ErrInvalidToken)
+
+	// the tokens of other authenticators are left alone
+	_, ok, err = authenticate(http.MethodGet, "")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+	_, ok, err = authenticate(http.MethodGet, "header.payload.signature")
+	assert.NoError(t, err)
+	assert.False(t, ok)
+
+	now = now.Add(time.Hour)
+	_, _, err = authenticate(http.MethodPost, write.Value)
+	assert.ErrorIs(t, err, ErrExpiredToken)
+
+	require.NoError(t, manager.Delete(ctx, read.ID))
+	_, _, err = authenticate(http.MethodGet, read.Value)
+	assert.ErrorIs(t, err, ErrInvalidToken)
+}
+
+func TestReadScope(t *testing.T) {
+	read := &Token{Scopes: []string{ScopeRead}}
+	write := &Token{Scopes: []string{ScopeWrite}}
+	upgrade := func(req *http.Request) *http.Request {
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", "websocket")
+		return req
+	}
+
+	tests := []struct {
+		name    string
+		req     *http.Request
+		allowed bool
+	}{
+		{name: "list", req: httptest.NewRequest(http.MethodGet, "/v1/pods", nil), allowed: true},
+		{name: "watch", req: httptest.NewRequest(http.MethodGet, "/v1/pods?watch=true", nil), allowed: true},
+		{name: "logs", req: httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/pods/web/log", nil), allowed: true},
+		{name: "create", req: httptest.NewRequest(http.MethodPost, "/v1/pods", nil)},
+		{name: "exec", req: httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/pods/web/exec?command=sh", nil)},
+		{name: "attach", req: httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/pods/web/attach", nil)},
+		{name: "portforward", req: httptest.NewRequest(http.MethodGet, "/api/v1/namespaces/default/pods/web/portforward", nil)},
+		{name: "exec link", req: httptest.NewRequest(http.MethodGet, "/v1/pods/default/web?link=exec&command=sh", nil)},
+		{name: "upgrade", req: upgrade(httptest.NewRequest(http.MethodGet, "/k8s/clusters/local/api/v1/namespaces/default/pods/web/exec", nil))},
+		{name: "any upgrade", req: upgrade(httptest.NewRequest(http.MethodGet, "/v1/pods", nil))},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.allowed, read.Allows(test.req))
+			assert.True(t, write.Allows(test.req))
+		})
+	}
+}
+
+func TestAuthenticateCache(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	client := fake.NewSimpleClientset()
+	gets := 0
+	client.PrependReactor("get", "secrets", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		gets++
+		return false, nil, nil
+	})
+	manager := NewManager(client.CoreV1().Secrets("tokens"))
+	manager.now = func() time.Time { return now }
+	token, err := manager.Create(ctx, &user.DefaultInfo{Name: "ci"}, "", nil, time.Hour)
+	require.NoError(t, err)
+
+	authenticate := func(method, bearer string) error {
+		req := httptest.NewRequest(method, "/v1/pods", nil)
+		req.Header.Set("Authorization", "Bearer "+bearer)
+		_, _, err := manager.Authenticate(req)
+		return err
+	}
+
+	require.NoError(t, authenticate(http.MethodGet, token.Value))
+	require.NoError(t, authenticate(http.MethodGet, token.Value))
+	assert.Equal(t, 1, gets, "verified tokens are cached")
+	assert.ErrorIs(t, authenticate(http.MethodPost, token.Value), ErrOutOfScope, "cached tokens are limited to their scopes")
+	assert.ErrorIs(t, authenticate(http.MethodGet, token.Value+"x"), ErrInvalidToken, "only the bearer token is cached")
+	assert.ErrorIs(t, authenticate(http.MethodGet, token.Value+"x"), ErrInvalidToken)
+	assert.Equal(t, 3, gets, "invalid tokens are not cached")
+
+	now = now.Add(CacheTTL)
+	require.NoError(t, authenticate(http.MethodGet, token.Value))
+	assert.Equal(t, 4, gets, "tokens are verified again once their cache expires")
+
+	now = now.Add(time.Hour)
+	assert.ErrorIs(t, authenticate(http.MethodGet, token.Value), ErrExpiredToken)
+}
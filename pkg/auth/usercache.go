@@ -0,0 +1,246 @@
+package auth
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/dynamic/dynamicinformer"
+	"k8s.io/client-go/tools/cache"
+)
+
+// minSweepSize is the number of cached tokens from which expired ones are swept
+const minSweepSize = 1024
+
+// Invalidator drops the cached users of authenticators, so that their attributes and groups are looked up again on
+// their next request rather than once their cache TTL expires. Embedders call it when an external auth provider
+// changes the group membership of users. The authenticators returned by NewWebhookAuthenticator implement it.
+type Invalidator interface {
+	// InvalidateUser drops the cached tokens of a user
+	InvalidateUser(name string)
+	// InvalidateGroup drops the cached tokens of the members of a group
+	InvalidateGroup(group string)
+	// InvalidateAll drops all the cached tokens
+	InvalidateAll()
+}
+
+// cachedToken is the result of authenticating a token
+type cachedToken struct {
+	user    user.Info
+	ok      bool
+	expires time.Time
+}
+
+// userCache caches the users tokens are authenticated as for a TTL, indexed by the names and groups of the users so
+// that they can be invalidated
+type userCache struct {
+	lock sync.Mutex
+	ttl  time.Duration
+	now  func() time.Time
+	// generation is incremented by every invalidation, so that users authenticated before are not cached after it
+	generation uint64
+	nextSweep  int
+	byToken    map[string]cachedToken
+	byUser     map[string]map[string]bool
+	byGroup    map[string]map[string]bool
+}
+
+func newUserCache(ttl time.Duration) *userCache {
+	return &userCache{
+		ttl:       ttl,
+		now:       time.Now,
+		nextSweep: minSweepSize,
+		byToken:   map[string]cachedToken{},
+		byUser:    map[string]map[string]bool{},
+		byGroup:   map[string]map[string]bool{},
+	}
+}
+
+func tokenKey(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// get returns the cached user of a token, whether the token was authenticated, and whether it was cached, as well as
+// the generation to add the result of authenticating it at
+func (c *userCache) get(token string) (user.Info, bool, bool, uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	key := tokenKey(token)
+	cached, found := c.byToken[key]
+	if found && c.now().After(cached.expires) {
+		c.remove(key)
+		found = false
+	}
+	return cached.user, cached.ok, found, c.generation
+}
+
+// add caches the result of authenticating a token, unless users were invalidated since generation
+func (c *userCache) add(token string, info user.Info, ok bool, generation uint64) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	if generation != c.generation {
+		return
+	}
+	if len(c.byToken) >= c.nextSweep {
+		c.sweep()
+	}
+	key := tokenKey(token)
+	c.remove(key)
+	c.byToken[key] = cachedToken{
+		user:    info,
+		ok:      ok,
+		expires: c.now().Add(c.ttl),
+	}
+	if info == nil {
+		return
+	}
+	index(c.byUser, info.GetName(), key)
+	for _, group := range info.GetGroups() {
+		index(c.byGroup, group, key)
+	}
+}
+
+// sweep removes the expired tokens, sweeping again once the cache doubles
+func (c *userCache) sweep() {
+	now := c.now()
+	for key, cached := range c.byToken {
+		if now.After(cached.expires) {
+			c.remove(key)
+		}
+	}
+	c.nextSweep = max(2*len(c.byToken), minSweepSize)
+}
+
+func index(indexer map[string]map[string]bool, name, key string) {
+	keys, ok := indexer[name]
+	if !ok {
+		keys = map[string]bool{}
+		indexer[name] = keys
+	}
+	keys[key] = true
+}
+
+func unindex(indexer map[string]map[string]bool, name, key string) {
+	delete(indexer[name], key)
+	if len(indexer[name]) == 0 {
+		delete(indexer, name)
+	}
+}
+
+func (c *userCache) remove(key string) {
+	cached, ok := c.byToken[key]
+	if !ok {
+		return
+	}
+	delete(c.byToken, key)
+	if cached.user == nil {
+		return
+	}
+	unindex(c.byUser, cached.user.GetName(), key)
+	for _, group := range cached.user.GetGroups() {
+		unindex(c.byGroup, group, key)
+	}
+}
+
+func (c *userCache) InvalidateUser(name string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.generation++
+	for key := range c.byUser[name] {
+		c.remove(key)
+	}
+}
+
+func (c *userCache) InvalidateGroup(group string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.generation++
+	for key := range c.byGroup[group] {
+		c.remove(key)
+	}
+}
+
+func (c *userCache) InvalidateAll() {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+
+	c.generation++
+	c.byToken = map[string]cachedToken{}
+	c.byUser = map[string]map[string]bool{}
+	c.byGroup = map[string]map[string]bool{}
+}
+
+// InvalidationSource is a resource whose objects back the attributes or groups of users, such as the userattributes
+// of Rancher, which invalidates the users it names whenever one of its objects changes
+type InvalidationSource struct {
+	GVR schema.GroupVersionResource
+	// Users returns the names of the users whose cache an object invalidates, its name if it is nil
+	Users func(obj *unstructured.Unstructured) []string
+	// Groups returns the groups whose members' cache an object invalidates
+	Groups func(obj *unstructured.Unstructured) []string
+}
+
+func (s InvalidationSource) invalidate(invalidator Invalidator, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		// the object is unknown, so any user may be affected
+		invalidator.InvalidateAll()
+		return
+	}
+	users := []string{u.GetName()}
+	if s.Users != nil {
+		users = s.Users(u)
+	}
+	for _, name := range users {
+		invalidator.InvalidateUser(name)
+	}
+	if s.Groups != nil {
+		for _, group := range s.Groups(u) {
+			invalidator.InvalidateGroup(group)
+		}
+	}
+}
+
+// WatchInvalidations watches the objects of sources until ctx is done, invalidating the users of invalidator they
+// back whenever they are added, changed or removed
+func WatchInvalidations(ctx context.Context, client dynamic.Interface, invalidator Invalidator, sources ...InvalidationSource) error {
+	for _, source := range sources {
+		source := source
+		informer := dynamicinformer.NewFilteredDynamicInformer(client, source.GVR, "", 0, cache.Indexers{}, nil).Informer()
+		_, err := informer.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+			AddFunc: func(obj interface{}, isInInitialList bool) {
+				// the objects listed at start precede any cached user
+				if !isInInitialList {
+					source.invalidate(invalidator, obj)
+				}
+			},
+			UpdateFunc: func(_, obj interface{}) {
+				source.invalidate(invalidator, obj)
+			},
+			DeleteFunc: func(obj interface{}) {
+				source.invalidate(invalidator, obj)
+			},
+		})
+		if err != nil {
+			return err
+		}
+		logrus.Debugf("Invalidating the cached users on changes of %s", source.GVR)
+		go informer.Run(ctx.Done())
+	}
+	return nil
+}
@@ -0,0 +1,243 @@
+package auth
+
+import (
+	"context"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authentication/user"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+)
+
+// fakeTokenAuth authenticates the tokens of users, counting the tokens authenticated
+type fakeTokenAuth struct {
+	users map[string]*user.DefaultInfo
+	calls int
+}
+
+func (f *fakeTokenAuth) AuthenticateToken(_ context.Context, token string) (*authenticator.Response, bool, error) {
+	f.calls++
+	info, ok := f.users[token]
+	if !ok {
+		return nil, false, nil
+	}
+	return &authenticator.Response{User: info}, true, nil
+}
+
+func TestWebhookAuthCache(t *testing.T) {
+	tokens := &fakeTokenAuth{users: map[string]*user.DefaultInfo{
+		"alice-token": {Name: "alice", Groups: []string{"devs"}},
+		"bob-token":   {Name: "bob", Groups: []string{"devs", "ops"}},
+	}}
+	now := time.Now()
+	w := &webhookAuth{auth: tokens, cache: newUserCache(time.Minute)}
+	w.cache.now = func() time.Time { return now }
+
+	authenticate := func(token string) (user.Info, bool) {
+		req, _ := http.NewRequest(http.MethodGet, "/v1/pods", nil)
+		req.Header.Set("Authorization", "Bearer "+token)
+		info, ok, err := w.Authenticate(req)
+		require.NoError(t, err)
+		return info, ok
+	}
+
+	info, ok := authenticate("alice-token")
+	assert.True(t, ok)
+	assert.Equal(t, "alice", info.GetName())
+	authenticate("alice-token")
+	authenticate("bob-token")
+	_, ok = authenticate("unknown-token")
+	assert.False(t, ok)
+	authenticate("unknown-token")
+	assert.Equal(t, 3, tokens.calls, "authenticated and unauthenticated tokens are cached")
+
+	// group membership changed by the auth provider
+	tokens.users["alice-token"] = &user.DefaultInfo{Name: "alice", Groups: []string{"devs", "admins"}}
+	w.InvalidateUser("alice")
+	info, _ = authenticate("alice-token")
+	assert.Equal(t, []string{"devs", "admins"}, info.GetGroups())
+	authenticate("bob-token")
+	assert.Equal(t, 4, tokens.calls, "only the tokens of the user are invalidated")
+
+	w.InvalidateGroup("ops")
+	authenticate("bob-token")
+	authenticate("alice-token")
+	assert.Equal(t, 5, tokens.calls, "only the tokens of the members of the group are invalidated")
+
+	w.InvalidateAll()
+	authenticate("alice-token")
+	authenticate("unknown-token")
+	assert.Equal(t, 7, tokens.calls)
+
+	now = now.Add(2 * time.Minute)
+	authenticate("alice-token")
+	assert.Equal(t, 8, tokens.calls, "expired tokens are authenticated again")
+}
+
+// blockingTokenAuth authenticates tokens once released, counting the tokens authenticated
+type blockingTokenAuth struct {
+	release chan struct{}
+	calls   atomic.Int32
+}
+
+func (b *blockingTokenAuth) AuthenticateToken(ctx context.Context, _ string) (*authenticator.Response, bool, error) {
+	b.calls.Add(1)
+	select {
+	case <-b.release:
+	case <-ctx.Done():
+		return nil, false, ctx.Err()
+	}
+	return &authenticator.Response{User: &user.DefaultInfo{Name: "alice"}}, true, nil
+}
+
+func TestWebhookAuthConcurrentTokens(t *testing.T) {
+	tokens := &blockingTokenAuth{release: make(chan struct{})}
+	w := &webhookAuth{auth: tokens, cache: newUserCache(time.Minute)}
+
+	const requests = 10
+	var wg sync.WaitGroup
+	var authenticated atomic.Int32
+	for i := 0; i < requests; i++ {
+		ctx, cancel := context.WithCancel(context.Background())
+		req, _ := http.NewRequestWithContext(ctx, http.MethodGet, "/v1/pods", nil)
+		req.Header.Set("Authorization", "Bearer alice-token")
+		if i == 0 {
+			// the request whose flight authenticates the token ends before it is authenticated
+			cancel()
+		} else {
+			defer cancel()
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			info, ok, err := w.Authenticate(req)
+			if err == nil && ok && info.GetName() == "alice" {
+				authenticated.Add(1)
+			}
+		}()
+	}
+	// the token is authenticated once all requests wait for it
+	time.Sleep(100 * time.Millisecond)
+	close(tokens.release)
+	wg.Wait()
+
+	assert.Equal(t, int32(requests), authenticated.Load())
+	assert.Equal(t, int32(1), tokens.calls.Load(), "concurrent requests of an uncached token authenticate it once")
+}
+
+func TestUserCacheGeneration(t *testing.T) {
+	c := newUserCache(time.Minute)
+	_, _, found, generation := c.get("token")
+	assert.False(t, found)
+
+	// users authenticated before an invalidation are not cached, since they may be stale
+	c.InvalidateUser("alice")
+	c.add("token", &user.DefaultInfo{Name: "alice"}, true, generation)
+	_, _, found, generation = c.get("token")
+	assert.False(t, found)
+
+	c.add("token", &user.DefaultInfo{Name: "alice"}, true, generation)
+	info, ok, found, _ := c.get("token")
+	assert.True(t, found)
+	assert.True(t, ok)
+	assert.Equal(t, "alice", info.GetName())
+}
+
+func TestUserCacheSweep(t *testing.T) {
+	now := time.Now()
+	c := newUserCache(time.Minute)
+	c.now = func() time.Time { return now }
+	for i := 0; i < minSweepSize; i++ {
+		c.add(string(rune(i)), &user.DefaultInfo{Name: "alice"}, true, 0)
+	}
+	now = now.Add(2 * time.Minute)
+	c.add("token", &user.DefaultInfo{Name: "bob"}, true, 0)
+	assert.Len(t, c.byToken, 1)
+	assert.Len(t, c.byUser, 1)
+}
+
+// recordingInvalidator records the users and groups invalidated
+type recordingInvalidator struct {
+	lock   sync.Mutex
+	users  []string
+	groups []string
+}
+
+func (r *recordingInvalidator) InvalidateUser(name string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.users = append(r.users, name)
+}
+
+func (r *recordingInvalidator) InvalidateGroup(group string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.groups = append(r.groups, group)
+}
+
+func (r *recordingInvalidator) InvalidateAll() {}
+
+func (r *recordingInvalidator) invalidated() ([]string, []string) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string(nil), r.users...), append([]string(nil), r.groups...)
+}
+
+func TestWatchInvalidations(t *testing.T) {
+	gvr := schema.GroupVersionResource{Group: "management.cattle.io", Version: "v3", Resource: "userattributes"}
+	attributes := func(name string, groups ...interface{}) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "management.cattle.io/v3",
+			"kind":       "UserAttribute",
+			"groups":     groups,
+		}}
+		obj.SetName(name)
+		return obj
+	}
+	scheme := runtime.NewScheme()
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme, map[schema.GroupVersionResource]string{
+		gvr: "UserAttributeList",
+	}, attributes("u-existing"))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	invalidator := &recordingInvalidator{}
+	err := WatchInvalidations(ctx, client, invalidator, InvalidationSource{
+		GVR: gvr,
+		Groups: func(obj *unstructured.Unstructured) []string {
+			groups, _, _ := unstructured.NestedStringSlice(obj.Object, "groups")
+			return groups
+		},
+	})
+	require.NoError(t, err)
+
+	resources := client.Resource(gvr)
+	// the watch is established asynchronously, so objects are created until it is noticed
+	assert.Eventually(t, func() bool {
+		_ = resources.Delete(ctx, "u-new", metav1.DeleteOptions{})
+		_, _ = resources.Create(ctx, attributes("u-new", "okta_group://ops"), metav1.CreateOptions{})
+		users, _ := invalidator.invalidated()
+		return len(users) > 0
+	}, 5*time.Second, 50*time.Millisecond)
+
+	_, err = resources.Update(ctx, attributes("u-existing", "okta_group://devs"), metav1.UpdateOptions{})
+	require.NoError(t, err)
+	assert.Eventually(t, func() bool {
+		users, groups := invalidator.invalidated()
+		return users[len(users)-1] == "u-existing" && len(groups) > 0 && groups[len(groups)-1] == "okta_group://devs"
+	}, 5*time.Second, 50*time.Millisecond)
+	users, groups := invalidator.invalidated()
+	assert.NotContains(t, users[:len(users)-1], "u-existing", "objects listed at start don't invalidate their users")
+	assert.Contains(t, users, "u-new")
+	assert.Contains(t, groups, "okta_group://ops")
+}
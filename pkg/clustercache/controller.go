@@ -2,7 +2,9 @@ package clustercache
 
 import (
 	"context"
+	"sort"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/rancher/apiserver/pkg/types"
@@ -46,6 +48,25 @@ type watcher struct {
 	informer cache.SharedIndexInformer
 	gvk      schema2.GroupVersionKind
 	gvr      schema2.GroupVersionResource
+	// syncedAt and lastEvent are unix nanoseconds, zero until the initial list completes and an event is received
+	syncedAt  atomic.Int64
+	lastEvent atomic.Int64
+}
+
+// status returns the status of the cache of the watcher
+func (w *watcher) status() CacheStatus {
+	status := CacheStatus{
+		GVK: w.gvk,
+	}
+	if syncedAt := w.syncedAt.Load(); syncedAt != 0 {
+		status.Synced = true
+		status.SyncedAt = time.Unix(0, syncedAt)
+		status.Objects = len(w.informer.GetStore().ListKeys())
+	}
+	if lastEvent := w.lastEvent.Load(); lastEvent != 0 {
+		status.LastEvent = time.Unix(0, lastEvent)
+	}
+	return status
 }
 
 type clusterCache struct {
@@ -59,6 +80,7 @@ type clusterCache struct {
 	addHandlers    cancelCollection
 	removeHandlers cancelCollection
 	changeHandlers cancelCollection
+	syncedHandlers cancelCollection
 }
 
 func NewClusterCache(ctx context.Context, dynamicClient dynamic.Interface) ClusterCache {
@@ -91,9 +113,11 @@ func validSchema(schema *types.APISchema) bool {
 	return true
 }
 
-func (h *clusterCache) addResourceEventHandler(gvk schema2.GroupVersionKind, informer cache.SharedIndexInformer) {
-	informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
+func (h *clusterCache) addResourceEventHandler(w *watcher) {
+	gvk := w.gvk
+	w.informer.AddEventHandler(cache.ResourceEventHandlerFuncs{
 		AddFunc: func(obj interface{}) {
+			w.lastEvent.Store(time.Now().UnixNano())
 			if rObj, ok := obj.(runtime.Object); ok {
 				h.workqueue.Add(event{
 					add: true,
@@ -103,6 +127,7 @@ func (h *clusterCache) addResourceEventHandler(gvk schema2.GroupVersionKind, inf
 			}
 		},
 		UpdateFunc: func(oldObj, newObj interface{}) {
+			w.lastEvent.Store(time.Now().UnixNano())
 			if rObj, ok := newObj.(runtime.Object); ok {
 				if rOldObj, ok := oldObj.(runtime.Object); ok {
 					h.workqueue.Add(event{
@@ -114,6 +139,7 @@ func (h *clusterCache) addResourceEventHandler(gvk schema2.GroupVersionKind, inf
 			}
 		},
 		DeleteFunc: func(obj interface{}) {
+			w.lastEvent.Store(time.Now().UnixNano())
 			if rObj, ok := obj.(runtime.Object); ok {
 				h.workqueue.Add(event{
 					obj: rObj,
@@ -161,8 +187,9 @@ func (h *clusterCache) OnSchemas(schemas *schema.Collection) error {
 		toWait = append(toWait, w)
 
 		logrus.Infof("Watching metadata for %s", w.gvk)
-		h.addResourceEventHandler(w.gvk, w.informer)
+		h.addResourceEventHandler(w)
 		go w.informer.Run(w.ctx.Done())
+		go h.notifySynced(w)
 	}
 
 	for gvk, w := range h.watchers {
@@ -218,6 +245,47 @@ func (h *clusterCache) List(gvk schema2.GroupVersionKind) []interface{} {
 	return w.informer.GetStore().List()
 }
 
+// notifySynced marks the cache of the watcher synced once its initial list completes, and calls the synced handlers
+func (h *clusterCache) notifySynced(w *watcher) {
+	if !cache.WaitForCacheSync(w.ctx.Done(), w.informer.HasSynced) {
+		return
+	}
+	w.syncedAt.Store(time.Now().UnixNano())
+	status := w.status()
+	for _, handler := range h.syncedHandlers.List() {
+		handler.(SyncedHandler)(status)
+	}
+}
+
+func (h *clusterCache) Status(gvk schema2.GroupVersionKind) (CacheStatus, bool) {
+	h.RLock()
+	defer h.RUnlock()
+
+	w, ok := h.watchers[gvk]
+	if !ok {
+		return CacheStatus{}, false
+	}
+	return w.status(), true
+}
+
+func (h *clusterCache) Statuses() []CacheStatus {
+	h.RLock()
+	defer h.RUnlock()
+
+	result := make([]CacheStatus, 0, len(h.watchers))
+	for _, w := range h.watchers {
+		result = append(result, w.status())
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].GVK.String() < result[j].GVK.String()
+	})
+	return result
+}
+
+func (h *clusterCache) OnSynced(ctx context.Context, handler SyncedHandler) {
+	h.syncedHandlers.Add(ctx, handler)
+}
+
 func (h *clusterCache) start() {
 	defer h.workqueue.ShutDown()
 	for {
@@ -0,0 +1,69 @@
+package clustercache
+
+import (
+	"context"
+	"time"
+
+	schema2 "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// CacheStatus is the readiness of the cache of a type, for embedders to hold off serving clients until the caches they
+// depend on are warm
+type CacheStatus struct {
+	GVK schema2.GroupVersionKind
+	// Synced is true once the initial list of the type is complete, at SyncedAt
+	Synced   bool
+	SyncedAt time.Time
+	// LastEvent is when the last add, update or delete of an object of the type was received, including those of the
+	// initial list. It is zero if none was.
+	LastEvent time.Time
+	// Objects is the number of cached objects, zero until the cache is synced
+	Objects int
+}
+
+// SyncedHandler is called with the status of the cache of a type when its initial list completes
+type SyncedHandler func(status CacheStatus)
+
+// StatusReporter reports the readiness of the caches of a ClusterCache
+type StatusReporter interface {
+	// Status returns the status of the cache of a type, false if the type isn't cached (yet)
+	Status(gvk schema2.GroupVersionKind) (CacheStatus, bool)
+	// Statuses returns the status of the caches of all cached types, sorted by GVK
+	Statuses() []CacheStatus
+	// OnSynced registers a handler called when the cache of a type is synced, until ctx is done. Caches already
+	// synced aren't reported.
+	OnSynced(ctx context.Context, handler SyncedHandler)
+}
+
+var _ StatusReporter = (*clusterCache)(nil)
+
+// WaitForSynced waits for the caches of gvks to be synced, including those of types which aren't cached yet, such as
+// CRDs which aren't discovered yet, or for ctx to be done.
+func WaitForSynced(ctx context.Context, reporter StatusReporter, gvks ...schema2.GroupVersionKind) error {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	synced := make(chan schema2.GroupVersionKind, len(gvks))
+	reporter.OnSynced(ctx, func(status CacheStatus) {
+		select {
+		case synced <- status.GVK:
+		case <-ctx.Done():
+		}
+	})
+
+	waiting := map[schema2.GroupVersionKind]bool{}
+	for _, gvk := range gvks {
+		if status, ok := reporter.Status(gvk); !ok || !status.Synced {
+			waiting[gvk] = true
+		}
+	}
+	for len(waiting) > 0 {
+		select {
+		case gvk := <-synced:
+			delete(waiting, gvk)
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return nil
+}
@@ -0,0 +1,82 @@
+package clustercache
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	schema2 "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeReporter struct {
+	lock     sync.Mutex
+	statuses map[schema2.GroupVersionKind]CacheStatus
+	handlers []SyncedHandler
+}
+
+func (f *fakeReporter) Status(gvk schema2.GroupVersionKind) (CacheStatus, bool) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	status, ok := f.statuses[gvk]
+	return status, ok
+}
+
+func (f *fakeReporter) Statuses() []CacheStatus {
+	return nil
+}
+
+func (f *fakeReporter) OnSynced(_ context.Context, handler SyncedHandler) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.handlers = append(f.handlers, handler)
+}
+
+func (f *fakeReporter) sync(gvk schema2.GroupVersionKind) {
+	f.lock.Lock()
+	status := CacheStatus{GVK: gvk, Synced: true, SyncedAt: time.Now()}
+	f.statuses[gvk] = status
+	handlers := f.handlers
+	f.lock.Unlock()
+	for _, handler := range handlers {
+		handler(status)
+	}
+}
+
+func TestWaitForSynced(t *testing.T) {
+	pods := schema2.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	nodes := schema2.GroupVersionKind{Version: "v1", Kind: "Node"}
+	deployments := schema2.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	reporter := &fakeReporter{
+		statuses: map[schema2.GroupVersionKind]CacheStatus{
+			pods:  {GVK: pods, Synced: true},
+			nodes: {GVK: nodes},
+		},
+	}
+
+	assert.NoError(t, WaitForSynced(context.Background(), reporter, pods), "synced caches don't wait")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, WaitForSynced(ctx, reporter, pods, nodes), context.DeadlineExceeded)
+
+	done := make(chan error)
+	go func() {
+		done <- WaitForSynced(context.Background(), reporter, pods, nodes, deployments)
+	}()
+	// wait for the handler to be registered
+	assert.Eventually(t, func() bool {
+		reporter.lock.Lock()
+		defer reporter.lock.Unlock()
+		return len(reporter.handlers) == 3
+	}, time.Second, time.Millisecond)
+	reporter.sync(nodes)
+	select {
+	case <-done:
+		t.Fatal("returned before all caches were synced")
+	case <-time.After(10 * time.Millisecond):
+	}
+	reporter.sync(deployments)
+	assert.NoError(t, <-done)
+}
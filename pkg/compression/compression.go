@@ -0,0 +1,151 @@
+// Package compression compresses the large responses of the API, such as lists of many objects, for the clients
+// accepting it, to reduce the size of the payloads sent to the UI.
+package compression
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+var gzipWriters = sync.Pool{
+	New: func() interface{} {
+		writer, _ := gzip.NewWriterLevel(nil, gzip.BestSpeed)
+		return writer
+	},
+}
+
+// Middleware returns a middleware compressing with gzip the responses of at least minSize bytes to the requests
+// accepting it. Smaller responses, responses already encoded, responses flushed before reaching minSize, such as
+// watches, and upgraded connections, such as websockets, aren't compressed.
+func Middleware(minSize int) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			if req.Method == http.MethodHead || req.Header.Get("Upgrade") != "" || !acceptsGzip(req) {
+				next.ServeHTTP(rw, req)
+				return
+			}
+			rw.Header().Add("Vary", "Accept-Encoding")
+			writer := &writer{
+				ResponseWriter: rw,
+				minSize:        minSize,
+				status:         http.StatusOK,
+			}
+			defer writer.close()
+			next.ServeHTTP(writer, req)
+		})
+	}
+}
+
+// acceptsGzip returns whether the Accept-Encoding header of the request accepts gzip
+func acceptsGzip(req *http.Request) bool {
+	for _, value := range req.Header.Values("Accept-Encoding") {
+		for _, encoding := range strings.Split(value, ",") {
+			name, params, _ := strings.Cut(strings.TrimSpace(encoding), ";")
+			if !strings.EqualFold(strings.TrimSpace(name), "gzip") {
+				continue
+			}
+			q, ok := strings.CutPrefix(strings.TrimSpace(params), "q=")
+			if !ok {
+				return true
+			}
+			weight, err := strconv.ParseFloat(q, 64)
+			return err == nil && weight > 0
+		}
+	}
+	return false
+}
+
+// writer buffers the response until it reaches the minimum size, then compresses it, unless it is already encoded
+type writer struct {
+	http.ResponseWriter
+	minSize int
+	status  int
+	buffer  []byte
+	// started is true once the status is written, compressed is true if the body is compressed by gzip
+	started    bool
+	compressed bool
+	gzip       *gzip.Writer
+}
+
+func (w *writer) WriteHeader(status int) {
+	if w.started {
+		return
+	}
+	w.status = status
+}
+
+func (w *writer) Write(data []byte) (int, error) {
+	if w.started {
+		if w.compressed {
+			return w.gzip.Write(data)
+		}
+		return w.ResponseWriter.Write(data)
+	}
+	w.buffer = append(w.buffer, data...)
+	if len(w.buffer) >= w.minSize {
+		if err := w.start(w.ResponseWriter.Header().Get("Content-Encoding") == ""); err != nil {
+			return 0, err
+		}
+	}
+	return len(data), nil
+}
+
+// Flush sends the buffered response, uncompressed if it hasn't reached the minimum size yet, since streams must
+// be flushed as they go
+func (w *writer) Flush() {
+	if !w.started {
+		if err := w.start(false); err != nil {
+			return
+		}
+	}
+	if w.compressed {
+		_ = w.gzip.Flush()
+	}
+	if flusher, ok := w.ResponseWriter.(http.Flusher); ok {
+		flusher.Flush()
+	}
+}
+
+func (w *writer) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// start writes the status and the buffered response, compressed or not
+func (w *writer) start(compress bool) error {
+	w.started = true
+	w.compressed = compress
+	if compress {
+		header := w.ResponseWriter.Header()
+		header.Del("Content-Length")
+		header.Set("Content-Encoding", "gzip")
+		w.gzip = gzipWriters.Get().(*gzip.Writer)
+		w.gzip.Reset(w.ResponseWriter)
+	}
+	w.ResponseWriter.WriteHeader(w.status)
+	buffer := w.buffer
+	w.buffer = nil
+	if len(buffer) == 0 {
+		return nil
+	}
+	_, err := w.Write(buffer)
+	return err
+}
+
+// close sends what is left of the response
+func (w *writer) close() {
+	if !w.started {
+		if len(w.buffer) == 0 && w.status == http.StatusOK {
+			// nothing was written, let the server write its default response
+			return
+		}
+		_ = w.start(false)
+	}
+	if w.compressed {
+		_ = w.gzip.Close()
+		w.gzip.Reset(nil)
+		gzipWriters.Put(w.gzip)
+	}
+}
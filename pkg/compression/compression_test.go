@@ -0,0 +1,75 @@
+package compression
+
+import (
+	"compress/gzip"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	large := strings.Repeat(`{"type":"pod"},`, 1000)
+	tests := []struct {
+		name           string
+		method         string
+		acceptEncoding string
+		encoding       string
+		body           string
+		flush          bool
+		wantCompressed bool
+	}{
+		{name: "large response", method: http.MethodGet, acceptEncoding: "gzip, deflate", body: large, wantCompressed: true},
+		{name: "small response", method: http.MethodGet, acceptEncoding: "gzip", body: "{}"},
+		{name: "gzip not accepted", method: http.MethodGet, acceptEncoding: "deflate", body: large},
+		{name: "gzip refused", method: http.MethodGet, acceptEncoding: "gzip;q=0", body: large},
+		{name: "gzip weighted", method: http.MethodGet, acceptEncoding: "br;q=1.0, gzip;q=0.5", body: large, wantCompressed: true},
+		{name: "no accept encoding", method: http.MethodGet, body: large},
+		{name: "already encoded", method: http.MethodGet, acceptEncoding: "gzip", encoding: "identity", body: large},
+		{name: "flushed before the minimum size", method: http.MethodGet, acceptEncoding: "gzip", body: large, flush: true},
+		{name: "head request", method: http.MethodHead, acceptEncoding: "gzip"},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			handler := Middleware(1024)(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				if test.encoding != "" {
+					rw.Header().Set("Content-Encoding", test.encoding)
+				}
+				rw.WriteHeader(http.StatusAccepted)
+				if test.flush {
+					_, _ = rw.Write([]byte("["))
+					rw.(http.Flusher).Flush()
+				}
+				_, _ = rw.Write([]byte(test.body))
+			}))
+			req := httptest.NewRequest(test.method, "/v1/pods", nil)
+			if test.acceptEncoding != "" {
+				req.Header.Set("Accept-Encoding", test.acceptEncoding)
+			}
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, req)
+
+			assert.Equal(t, http.StatusAccepted, rw.Code)
+			want := test.body
+			if test.flush {
+				want = "[" + want
+			}
+			if !test.wantCompressed {
+				assert.NotEqual(t, "gzip", rw.Header().Get("Content-Encoding"))
+				assert.Equal(t, want, rw.Body.String())
+				return
+			}
+			assert.Equal(t, "gzip", rw.Header().Get("Content-Encoding"))
+			assert.Less(t, rw.Body.Len(), len(want)/4)
+			reader, err := gzip.NewReader(rw.Body)
+			require.NoError(t, err)
+			body, err := io.ReadAll(reader)
+			require.NoError(t, err)
+			assert.Equal(t, want, string(body))
+		})
+	}
+}
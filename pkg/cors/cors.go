@@ -0,0 +1,137 @@
+// Package cors lets browser apps served from other origins call the API, according to a cross-origin resource sharing
+// policy.
+package cors
+
+import (
+	"errors"
+	"net/http"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// csrfHeader is the header of the CSRF token, which is only exposed to the origins allowed by name
+const csrfHeader = "X-API-CSRF"
+
+var (
+	// ErrWildcardCredentials is returned by Validate for policies allowing all origins to send credentials, which would
+	// let any site make requests as the users of the API
+	ErrWildcardCredentials = errors.New("CORS policies allowing all origins can't allow credentials")
+
+	// DefaultAllowedHeaders are the request headers allowed if none are configured
+	DefaultAllowedHeaders = []string{"Accept", "Authorization", "Content-Type", "X-API-CSRF", "X-Steve-Features"}
+	// DefaultExposedHeaders are the response headers exposed to apps if none are configured
+	DefaultExposedHeaders = []string{"X-API-CSRF", "X-Steve-Features"}
+
+	allowedMethods = strings.Join([]string{
+		http.MethodGet, http.MethodHead, http.MethodPost, http.MethodPut, http.MethodPatch, http.MethodDelete,
+	}, ", ")
+)
+
+// Policy is the cross-origin resource sharing policy
+type Policy struct {
+	// AllowedOrigins are the origins of the apps allowed to call the API, such as https://app.example.com. An origin
+	// may start with a wildcard subdomain, such as https://*.example.com, and * allows all origins, which can't send
+	// credentials nor read the CSRF token
+	AllowedOrigins []string
+	// AllowedHeaders are the request headers apps may set, DefaultAllowedHeaders if empty
+	AllowedHeaders []string
+	// ExposedHeaders are the response headers apps may read, DefaultExposedHeaders if empty
+	ExposedHeaders []string
+	// AllowCredentials allows apps to send cookies and the Authorization header
+	AllowCredentials bool
+	// MaxAge is how long browsers may cache the responses of preflight requests, not set if zero
+	MaxAge time.Duration
+}
+
+// Validate returns an error if the policy allows all origins to send credentials
+func (p *Policy) Validate() error {
+	if p.AllowCredentials && slices.Contains(p.AllowedOrigins, "*") {
+		return ErrWildcardCredentials
+	}
+	return nil
+}
+
+// Middleware returns a middleware adding the CORS headers of the policy to the responses to the requests of allowed
+// origins, and answering their preflight requests. Requests of other origins are served without CORS headers, so
+// browsers don't let apps read the responses.
+func Middleware(policy Policy) func(http.Handler) http.Handler {
+	allowedHeaders := policy.AllowedHeaders
+	if len(allowedHeaders) == 0 {
+		allowedHeaders = DefaultAllowedHeaders
+	}
+	exposedHeaders := policy.ExposedHeaders
+	if len(exposedHeaders) == 0 {
+		exposedHeaders = DefaultExposedHeaders
+	}
+	allowHeaders := strings.Join(allowedHeaders, ", ")
+	exposeHeaders := strings.Join(exposedHeaders, ", ")
+	// origins only allowed by * can't read the CSRF token, which would let them forge the requests of sessions
+	wildcardExposeHeaders := strings.Join(slices.DeleteFunc(slices.Clone(exposedHeaders), func(header string) bool {
+		return strings.EqualFold(header, csrfHeader)
+	}), ", ")
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			origin := req.Header.Get("Origin")
+			if origin == "" {
+				next.ServeHTTP(rw, req)
+				return
+			}
+			rw.Header().Add("Vary", "Origin")
+			allowed, byName := policy.allows(origin)
+			if !allowed {
+				next.ServeHTTP(rw, req)
+				return
+			}
+
+			// the origin is echoed rather than *, which browsers don't accept with credentials
+			rw.Header().Set("Access-Control-Allow-Origin", origin)
+			if policy.AllowCredentials && byName {
+				rw.Header().Set("Access-Control-Allow-Credentials", "true")
+			}
+			if req.Method == http.MethodOptions && req.Header.Get("Access-Control-Request-Method") != "" {
+				rw.Header().Set("Access-Control-Allow-Methods", allowedMethods)
+				rw.Header().Set("Access-Control-Allow-Headers", allowHeaders)
+				if policy.MaxAge > 0 {
+					rw.Header().Set("Access-Control-Max-Age", strconv.Itoa(int(policy.MaxAge.Seconds())))
+				}
+				rw.WriteHeader(http.StatusNoContent)
+				return
+			}
+			if byName {
+				rw.Header().Set("Access-Control-Expose-Headers", exposeHeaders)
+			} else if wildcardExposeHeaders != "" {
+				rw.Header().Set("Access-Control-Expose-Headers", wildcardExposeHeaders)
+			}
+			next.ServeHTTP(rw, req)
+		})
+	}
+}
+
+// AllowsByName returns whether the policy allows the origin by name or subdomain, rather than by *. Such origins can
+// read the CSRF token.
+func (p *Policy) AllowsByName(origin string) bool {
+	_, byName := p.allows(origin)
+	return byName
+}
+
+// allows returns whether the policy allows the origin, and whether it allows it by name or subdomain rather than by *
+func (p *Policy) allows(origin string) (bool, bool) {
+	wildcard := false
+	for _, allowed := range p.AllowedOrigins {
+		if allowed == "*" {
+			wildcard = true
+			continue
+		}
+		if strings.EqualFold(allowed, origin) {
+			return true, true
+		}
+		scheme, domain, ok := strings.Cut(allowed, "://*.")
+		if ok && strings.HasPrefix(origin, scheme+"://") && strings.HasSuffix(strings.ToLower(origin), "."+strings.ToLower(domain)) {
+			return true, true
+		}
+	}
+	return wildcard, false
+}
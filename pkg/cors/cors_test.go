@@ -0,0 +1,132 @@
+package cors
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	policy := Policy{
+		AllowedOrigins:   []string{"https://app.example.com", "https://*.apps.example.com"},
+		AllowCredentials: true,
+		MaxAge:           10 * time.Minute,
+	}
+	tests := []struct {
+		name          string
+		method        string
+		origin        string
+		preflight     bool
+		wantOrigin    string
+		wantStatus    int
+		wantServed    bool
+		wantMaxAge    string
+		wantExposed   string
+		wantAllowedHd string
+	}{
+		{
+			name:       "same origin",
+			method:     http.MethodGet,
+			wantStatus: http.StatusOK,
+			wantServed: true,
+		},
+		{
+			name:        "allowed origin",
+			method:      http.MethodGet,
+			origin:      "https://app.example.com",
+			wantOrigin:  "https://app.example.com",
+			wantStatus:  http.StatusOK,
+			wantServed:  true,
+			wantExposed: "X-API-CSRF, X-Steve-Features",
+		},
+		{
+			name:        "allowed subdomain",
+			method:      http.MethodPost,
+			origin:      "https://ci.apps.example.com",
+			wantOrigin:  "https://ci.apps.example.com",
+			wantStatus:  http.StatusOK,
+			wantServed:  true,
+			wantExposed: "X-API-CSRF, X-Steve-Features",
+		},
+		{
+			name:       "other origin",
+			method:     http.MethodGet,
+			origin:     "https://evil.com",
+			wantStatus: http.StatusOK,
+			wantServed: true,
+		},
+		{
+			name:       "other scheme",
+			method:     http.MethodGet,
+			origin:     "http://ci.apps.example.com",
+			wantStatus: http.StatusOK,
+			wantServed: true,
+		},
+		{
+			name:          "preflight",
+			method:        http.MethodOptions,
+			origin:        "https://app.example.com",
+			preflight:     true,
+			wantOrigin:    "https://app.example.com",
+			wantStatus:    http.StatusNoContent,
+			wantMaxAge:    "600",
+			wantAllowedHd: "Accept, Authorization, Content-Type, X-API-CSRF, X-Steve-Features",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			served := false
+			handler := Middleware(policy)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {
+				served = true
+			}))
+			req := httptest.NewRequest(test.method, "/v1/pods", nil)
+			if test.origin != "" {
+				req.Header.Set("Origin", test.origin)
+			}
+			if test.preflight {
+				req.Header.Set("Access-Control-Request-Method", http.MethodDelete)
+			}
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, req)
+
+			assert.Equal(t, test.wantStatus, rw.Code)
+			assert.Equal(t, test.wantServed, served)
+			assert.Equal(t, test.wantOrigin, rw.Header().Get("Access-Control-Allow-Origin"))
+			assert.Equal(t, test.wantMaxAge, rw.Header().Get("Access-Control-Max-Age"))
+			assert.Equal(t, test.wantExposed, rw.Header().Get("Access-Control-Expose-Headers"))
+			assert.Equal(t, test.wantAllowedHd, rw.Header().Get("Access-Control-Allow-Headers"))
+			if test.wantOrigin != "" {
+				assert.Equal(t, "true", rw.Header().Get("Access-Control-Allow-Credentials"))
+			}
+		})
+	}
+}
+
+func TestWildcard(t *testing.T) {
+	assert.ErrorIs(t, (&Policy{AllowedOrigins: []string{"*"}, AllowCredentials: true}).Validate(), ErrWildcardCredentials)
+	assert.NoError(t, (&Policy{AllowedOrigins: []string{"*"}}).Validate())
+	assert.NoError(t, (&Policy{AllowedOrigins: []string{"https://app.example.com"}, AllowCredentials: true}).Validate())
+
+	// policies which weren't validated still don't let all origins send credentials
+	policy := Policy{AllowedOrigins: []string{"https://app.example.com", "*"}, AllowCredentials: true}
+	handler := Middleware(policy)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	serve := func(origin string) http.Header {
+		req := httptest.NewRequest(http.MethodGet, "/v1/pods", nil)
+		req.Header.Set("Origin", origin)
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, req)
+		return rw.Header()
+	}
+
+	header := serve("https://evil.com")
+	assert.Equal(t, "https://evil.com", header.Get("Access-Control-Allow-Origin"))
+	assert.Empty(t, header.Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "X-Steve-Features", header.Get("Access-Control-Expose-Headers"), "the CSRF token isn't exposed")
+
+	header = serve("https://app.example.com")
+	assert.Equal(t, "true", header.Get("Access-Control-Allow-Credentials"))
+	assert.Equal(t, "X-API-CSRF, X-Steve-Features", header.Get("Access-Control-Expose-Headers"))
+}
@@ -0,0 +1,115 @@
+// Package csrf protects browser sessions against cross-site request forgery, by requiring the requests changing objects
+// to echo a token only the pages of the session can read.
+package csrf
+
+import (
+	"crypto/rand"
+	"crypto/subtle"
+	"encoding/hex"
+	"net/http"
+	"net/url"
+
+	"github.com/rancher/steve/pkg/cors"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+)
+
+const (
+	// CookieName is the cookie holding the token of a session
+	CookieName = "CSRF"
+	// Header is the request header echoing the token, which is also set on every response for apps served from other
+	// origins, which can't read the cookie
+	Header = "X-API-CSRF"
+	// QueryParam is the query param echoing the token in WebSocket and SPDY upgrades, which browsers can't set headers on
+	QueryParam = "csrf"
+)
+
+// Middleware returns a middleware requiring the POST, PUT, PATCH and DELETE requests to send the token of the CSRF
+// cookie in the X-API-CSRF header, and setting the cookie if it isn't. Requests with an Authorization header are not
+// checked, since browsers don't send it on their own.
+//
+// WebSocket and SPDY upgrades, such as watches and exec sessions, are checked too, whatever their method: browsers send
+// cookies on the upgrades of any site, but always send their origin. Upgrades are allowed from the origin of the API,
+// and from the origins policy allows by name, if it isn't nil. Those of other origins must send the token in the csrf
+// query param.
+func Middleware(policy *cors.Policy) func(http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			token := ""
+			if cookie, err := req.Cookie(CookieName); err == nil {
+				token = cookie.Value
+			}
+
+			if req.Header.Get("Authorization") == "" {
+				valid := true
+				switch {
+				case httpstream.IsUpgradeRequest(req):
+					valid = allowedOrigin(req, policy) || matches(req.URL.Query().Get(QueryParam), token)
+				case !safe(req.Method):
+					valid = matches(req.Header.Get(Header), token)
+				}
+				if !valid {
+					http.Error(rw, "invalid CSRF token", http.StatusForbidden)
+					return
+				}
+			}
+
+			if token == "" {
+				var err error
+				token, err = newToken()
+				if err != nil {
+					http.Error(rw, err.Error(), http.StatusInternalServerError)
+					return
+				}
+				http.SetCookie(rw, cookie(req, token))
+			}
+			rw.Header().Set(Header, token)
+			next.ServeHTTP(rw, req)
+		})
+	}
+}
+
+// matches returns whether a token sent by a request is that of its cookie
+func matches(sent, token string) bool {
+	return token != "" && subtle.ConstantTimeCompare([]byte(sent), []byte(token)) == 1
+}
+
+// allowedOrigin returns whether the origin of an upgrade is allowed without a token: upgrades without an origin, which
+// browsers always send, those of the origin of the API, and those of the origins policy allows by name
+func allowedOrigin(req *http.Request, policy *cors.Policy) bool {
+	origin := req.Header.Get("Origin")
+	if origin == "" {
+		return true
+	}
+	if u, err := url.Parse(origin); err == nil && u.Host != "" && u.Host == req.Host {
+		return true
+	}
+	return policy != nil && policy.AllowsByName(origin)
+}
+
+func safe(method string) bool {
+	return method == http.MethodGet || method == http.MethodHead || method == http.MethodOptions
+}
+
+// cookie returns the cookie of the token, readable by the pages of the session. It is sent on cross-site requests
+// when served over TLS, for apps served from other origins.
+func cookie(req *http.Request, token string) *http.Cookie {
+	cookie := &http.Cookie{
+		Name:     CookieName,
+		Value:    token,
+		Path:     "/",
+		SameSite: http.SameSiteLaxMode,
+	}
+	if req.TLS != nil {
+		cookie.Secure = true
+		cookie.SameSite = http.SameSiteNoneMode
+	}
+	return cookie
+}
+
+func newToken() (string, error) {
+	token := make([]byte, 16)
+	if _, err := rand.Read(token); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(token), nil
+}
@@ -0,0 +1,102 @@
+package csrf
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/steve/pkg/cors"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMiddleware(t *testing.T) {
+	handler := Middleware(nil)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	serve := func(method, cookie, header, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/v1/pods", nil)
+		if cookie != "" {
+			req.AddCookie(&http.Cookie{Name: CookieName, Value: cookie})
+		}
+		if header != "" {
+			req.Header.Set(Header, header)
+		}
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, req)
+		return rw
+	}
+
+	// reads set the cookie, which then must be echoed by the requests changing objects
+	rw := serve(http.MethodGet, "", "", "")
+	assert.Equal(t, http.StatusOK, rw.Code)
+	cookies := rw.Result().Cookies()
+	require.Len(t, cookies, 1)
+	token := cookies[0].Value
+	assert.NotEmpty(t, token)
+	assert.Equal(t, token, rw.Header().Get(Header))
+
+	rw = serve(http.MethodGet, token, "", "")
+	assert.Empty(t, rw.Result().Cookies(), "the cookie is only set once")
+	assert.Equal(t, token, rw.Header().Get(Header))
+
+	tests := []struct {
+		name          string
+		method        string
+		cookie        string
+		header        string
+		authorization string
+		want          int
+	}{
+		{name: "echoed token", method: http.MethodPost, cookie: token, header: token, want: http.StatusOK},
+		{name: "missing header", method: http.MethodDelete, cookie: token, want: http.StatusForbidden},
+		{name: "wrong header", method: http.MethodPut, cookie: token, header: "other", want: http.StatusForbidden},
+		{name: "missing cookie", method: http.MethodPatch, header: token, want: http.StatusForbidden},
+		{name: "authorization header", method: http.MethodPost, authorization: "Bearer token", want: http.StatusOK},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, serve(test.method, test.cookie, test.header, test.authorization).Code)
+		})
+	}
+}
+
+func TestMiddlewareUpgrades(t *testing.T) {
+	policy := &cors.Policy{AllowedOrigins: []string{"https://app.example.com", "*"}}
+	handler := Middleware(policy)(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	token := "token"
+	serve := func(upgrade, origin, query string) int {
+		req := httptest.NewRequest(http.MethodGet, "https://steve.example.com/v1/subscribe"+query, nil)
+		req.AddCookie(&http.Cookie{Name: CookieName, Value: token})
+		req.Header.Set("Connection", "Upgrade")
+		req.Header.Set("Upgrade", upgrade)
+		if origin != "" {
+			req.Header.Set("Origin", origin)
+		}
+		rw := httptest.NewRecorder()
+		handler.ServeHTTP(rw, req)
+		return rw.Code
+	}
+
+	tests := []struct {
+		name    string
+		upgrade string
+		origin  string
+		query   string
+		want    int
+	}{
+		{name: "same origin", upgrade: "websocket", origin: "https://steve.example.com", want: http.StatusOK},
+		{name: "origin allowed by name", upgrade: "websocket", origin: "https://app.example.com", want: http.StatusOK},
+		{name: "no origin", upgrade: "SPDY/3.1", want: http.StatusOK},
+		{name: "other origin", upgrade: "websocket", origin: "https://evil.example.com", want: http.StatusForbidden},
+		{name: "other origin with spdy", upgrade: "SPDY/3.1", origin: "https://evil.example.com", want: http.StatusForbidden},
+		{name: "other origin with the token", upgrade: "websocket", origin: "https://evil.example.com", query: "?csrf=" + token, want: http.StatusOK},
+		{name: "other origin with a wrong token", upgrade: "websocket", origin: "https://evil.example.com", query: "?csrf=other", want: http.StatusForbidden},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, serve(test.upgrade, test.origin, test.query))
+		})
+	}
+}
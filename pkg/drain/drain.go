@@ -0,0 +1,91 @@
+// Package drain lets the server finish the requests in flight when it shuts down, so that rolling upgrades don't drop
+// clients abruptly: responses ask the clients to reconnect elsewhere, long-running requests such as websockets are told
+// to end, and the server waits for them before closing its listeners.
+package drain
+
+import (
+	"context"
+	"net/http"
+	"sync"
+)
+
+type contextKey struct{}
+
+// Drainer tracks the requests in flight and starts draining them on shutdown
+type Drainer struct {
+	lock     sync.Mutex
+	inFlight int
+	// idle is closed when no request is left in flight while draining
+	idle     chan struct{}
+	draining chan struct{}
+	once     sync.Once
+}
+
+func New() *Drainer {
+	return &Drainer{
+		draining: make(chan struct{}),
+	}
+}
+
+// Middleware tracks the requests served by next. While draining, responses close their connection, which makes
+// HTTP/2 connections send a GOAWAY frame, so that clients send their next requests to another server.
+func (d *Drainer) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		d.start()
+		defer d.finish()
+		select {
+		case <-d.draining:
+			rw.Header().Set("Connection", "close")
+		default:
+		}
+		next.ServeHTTP(rw, req.WithContext(context.WithValue(req.Context(), contextKey{}, d.draining)))
+	})
+}
+
+// Drain starts draining and waits for the requests in flight to finish, or for ctx to be done.
+func (d *Drainer) Drain(ctx context.Context) error {
+	d.once.Do(func() {
+		close(d.draining)
+	})
+
+	d.lock.Lock()
+	if d.inFlight == 0 {
+		d.lock.Unlock()
+		return nil
+	}
+	if d.idle == nil {
+		d.idle = make(chan struct{})
+	}
+	idle := d.idle
+	d.lock.Unlock()
+
+	select {
+	case <-idle:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (d *Drainer) start() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.inFlight++
+}
+
+func (d *Drainer) finish() {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	d.inFlight--
+	if d.inFlight == 0 && d.idle != nil {
+		close(d.idle)
+		d.idle = nil
+	}
+}
+
+// Draining returns a channel closed when the server serving the request of ctx starts draining, for long-running
+// requests to end. The channel is nil, thus never closed, if the request isn't tracked by a Drainer.
+func Draining(ctx context.Context) <-chan struct{} {
+	draining, _ := ctx.Value(contextKey{}).(chan struct{})
+	return draining
+}
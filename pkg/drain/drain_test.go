@@ -0,0 +1,54 @@
+package drain
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDrain(t *testing.T) {
+	d := New()
+	release := make(chan struct{})
+	started := make(chan struct{})
+	handler := d.Middleware(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/watch" {
+			close(started)
+			<-Draining(req.Context())
+			<-release
+		}
+	}))
+
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/pods", nil))
+	assert.Empty(t, rw.Header().Get("Connection"))
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/watch", nil))
+	}()
+	<-started
+
+	// the request in flight outlives the timeout
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	assert.ErrorIs(t, d.Drain(ctx), context.DeadlineExceeded)
+
+	// responses close their connection while draining
+	rw = httptest.NewRecorder()
+	handler.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/pods", nil))
+	assert.Equal(t, "close", rw.Header().Get("Connection"))
+
+	close(release)
+	require.NoError(t, d.Drain(context.Background()))
+	<-done
+}
+
+func TestDrainingUntracked(t *testing.T) {
+	assert.Nil(t, Draining(context.Background()))
+}
@@ -14,6 +14,7 @@ import (
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apiserver/pkg/admission"
 	"k8s.io/apiserver/pkg/authentication/authenticator"
 	"k8s.io/apiserver/pkg/authorization/authorizer"
 	"k8s.io/apiserver/pkg/endpoints/openapi"
@@ -64,6 +65,14 @@ type ExtensionAPIServerOptions struct {
 	// If nil, the default version is the version of the Kubernetes Go library
 	// compiled in the final binary.
 	EffectiveVersion utilversion.EffectiveVersion
+
+	// Admission hooks are run, in order, before objects are created, updated
+	// or deleted, all mutating hooks running before validating hooks. Use
+	// [NewMutatingHook] and [NewValidatingHook] to create them. Optional.
+	//
+	// Validating hooks are run by the validation functions passed to the
+	// stores' methods, so stores MUST call them (see [CreateOrUpdate]).
+	Admission []admission.Interface
 }
 
 // ExtensionAPIServer wraps a [genericapiserver.GenericAPIServer] to implement
@@ -159,6 +168,9 @@ func NewExtensionAPIServer(scheme *runtime.Scheme, codecs serializer.CodecFactor
 	}
 
 	config.Authentication.Authenticator = opts.Authenticator
+	if len(opts.Admission) > 0 {
+		config.AdmissionControl = admission.NewChainHandler(opts.Admission...)
+	}
 
 	completedConfig := config.Complete()
 	genericServer, err := completedConfig.New("imperative-api", genericapiserver.NewEmptyDelegate())
@@ -220,9 +232,13 @@ func (s *ExtensionAPIServer) GetAuthorizer() authorizer.Authorizer {
 //
 // Implementing the various verbs goes as follows:
 //   - get: [rest.Getter] must be implemented
-//   - list: [rest.Lister] must be implemented. To help implement table conversion, we provide [ConvertToTable] and [ConvertToTableDefault].
+//   - list: [rest.Lister] must be implemented. To help implement table conversion, we provide [ConvertToTable], [ConvertToTableDefault]
+//     and [NewTableConvertor], which shows the columns of a [ColumnProvider].
 //     Use [ConvertListOptions] to convert the [metainternalversion.ListOptions] to a [metav1.ListOptions].
+//     Field selectors on fields other than metadata.name and metadata.namespace must be registered with [AddFieldSelectors],
+//     and can be matched with [MatchesFieldSelector].
 //   - watch: [rest.Watcher] must be implemented. Use [ConvertListOptions] to convert the [metainternalversion.ListOptions] to a [metav1.ListOptions].
+//     To support resuming watches from a resource version, we provide [WatchHistory].
 //   - create: [rest.Creater] must be implemented
 //   - update: [rest.Updater] must be implemented. To help implement this correctly with create-on-update support, we provide [CreateOrUpdate].
 //   - patch: [rest.Patcher] must be implemented, which is essentially [rest.Getter] and [rest.Updater]
@@ -258,6 +274,44 @@ func (s *ExtensionAPIServer) Install(resourceName string, gvk schema.GroupVersio
 	return nil
 }
 
+// InstallSubresource adds a store for a subresource of a resource previously
+// installed with [ExtensionAPIServer.Install] for the same group and version.
+//
+// The subresource is served at /apis/{group}/{version}/{resourceName}/{name}/{subresource}
+// and is authorized separately from its resource, as {resourceName}/{subresource}, so
+// that RBAC rules can grant it on its own (eg: testtypes/status).
+//
+// A subresource store MUST implement [rest.Storage]. It SHOULD NOT implement [rest.Lister]
+// or [rest.Watcher], which are only meaningful for resources. Common subresources are
+// implemented as follows:
+//   - status: [rest.Getter] and [rest.Updater], getting and updating the object of the resource
+//     while only changing its status
+//   - scale: [rest.Getter] and [rest.Updater] for [k8s.io/api/autoscaling/v1.Scale] objects. The store
+//     must also implement [rest.GroupVersionKindProvider], returning autoscaling/v1 Scale, and the
+//     autoscaling/v1 types must be added to the scheme.
+//   - actions: [rest.NamedCreater] to handle POST requests (eg: tokens/refresh), or [rest.Connecter]
+//     to handle requests with arbitrary methods and bodies
+//
+// Other verbs are implemented by the same interfaces as in [ExtensionAPIServer.Install].
+//
+//nolint:misspell
+func (s *ExtensionAPIServer) InstallSubresource(resourceName string, subresource string, gvk schema.GroupVersionKind, storage rest.Storage) error {
+	if subresource == "" || strings.Contains(subresource, "/") {
+		return fmt.Errorf("invalid subresource name %q", subresource)
+	}
+
+	apiGroup, ok := s.apiGroups[gvk.Group]
+	if !ok {
+		return fmt.Errorf("resource %s of %s must be installed before its subresources", resourceName, gvk.GroupVersion())
+	}
+	if _, ok := apiGroup.VersionedResourcesStorageMap[gvk.Version][resourceName]; !ok {
+		return fmt.Errorf("resource %s of %s must be installed before its subresources", resourceName, gvk.GroupVersion())
+	}
+
+	apiGroup.VersionedResourcesStorageMap[gvk.Version][resourceName+"/"+subresource] = storage
+	return nil
+}
+
 func getDefinitionName(scheme *runtime.Scheme, replacements map[string]string) func(string) (string, spec.Extensions) {
 	return func(name string) (string, spec.Extensions) {
 		namer := openapi.NewDefinitionNamer(scheme)
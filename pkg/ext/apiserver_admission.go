@@ -0,0 +1,76 @@
+package ext
+
+import (
+	"context"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apiserver/pkg/admission"
+)
+
+var (
+	_ admission.MutationInterface   = (*mutatingHook)(nil)
+	_ admission.ValidationInterface = (*validatingHook)(nil)
+)
+
+// AdmissionHookFunc is called with the attributes of a request before the
+// object is created, updated or deleted by the store.
+//
+// Mutating hooks can change the object returned by [admission.Attributes.GetObject].
+// Returning an error rejects the request. Errors which are not of type
+// [k8s.io/apimachinery/pkg/api/errors.APIStatus] are returned as Forbidden.
+type AdmissionHookFunc func(ctx context.Context, attrs admission.Attributes) error
+
+// NewMutatingHook returns an admission hook calling fn to mutate objects for the
+// given operations, or for create, update and delete if none are given.
+func NewMutatingHook(fn AdmissionHookFunc, operations ...admission.Operation) admission.MutationInterface {
+	return &mutatingHook{
+		Handler: newAdmissionHandler(operations),
+		fn:      fn,
+	}
+}
+
+// NewValidatingHook returns an admission hook calling fn to validate objects for the
+// given operations, or for create, update and delete if none are given.
+func NewValidatingHook(fn AdmissionHookFunc, operations ...admission.Operation) admission.ValidationInterface {
+	return &validatingHook{
+		Handler: newAdmissionHandler(operations),
+		fn:      fn,
+	}
+}
+
+func newAdmissionHandler(operations []admission.Operation) *admission.Handler {
+	if len(operations) == 0 {
+		operations = []admission.Operation{admission.Create, admission.Update, admission.Delete}
+	}
+	return admission.NewHandler(operations...)
+}
+
+type mutatingHook struct {
+	*admission.Handler
+	fn AdmissionHookFunc
+}
+
+// Admit implements [admission.MutationInterface]
+func (m *mutatingHook) Admit(ctx context.Context, attrs admission.Attributes, _ admission.ObjectInterfaces) error {
+	return admissionError(attrs, m.fn(ctx, attrs))
+}
+
+type validatingHook struct {
+	*admission.Handler
+	fn AdmissionHookFunc
+}
+
+// Validate implements [admission.ValidationInterface]
+func (v *validatingHook) Validate(ctx context.Context, attrs admission.Attributes, _ admission.ObjectInterfaces) error {
+	return admissionError(attrs, v.fn(ctx, attrs))
+}
+
+func admissionError(attrs admission.Attributes, err error) error {
+	if err == nil {
+		return nil
+	}
+	if _, ok := err.(apierrors.APIStatus); ok {
+		return err
+	}
+	return admission.NewForbidden(attrs, err)
+}
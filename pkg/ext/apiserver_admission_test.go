@@ -0,0 +1,124 @@
+package ext
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apiserver/pkg/admission"
+	"k8s.io/apiserver/pkg/authentication/authenticator"
+	"k8s.io/apiserver/pkg/authorization/authorizer"
+)
+
+func TestAdmission(t *testing.T) {
+	scheme := runtime.NewScheme()
+	AddToScheme(scheme)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ln, err := (&net.ListenConfig{}).Listen(ctx, "tcp", ":0")
+	require.NoError(t, err)
+
+	// annotates created objects
+	mutating := NewMutatingHook(func(ctx context.Context, attrs admission.Attributes) error {
+		obj, ok := attrs.GetObject().(*TestType)
+		if !ok {
+			return fmt.Errorf("unexpected object %T", attrs.GetObject())
+		}
+		obj.Annotations = map[string]string{"admitted": "true"}
+		return nil
+	}, admission.Create)
+	// rejects protected objects
+	validating := NewValidatingHook(func(ctx context.Context, attrs admission.Attributes) error {
+		if attrs.GetName() == "protected" {
+			return fmt.Errorf("%s is protected", attrs.GetName())
+		}
+		return nil
+	})
+
+	store := newDefaultTestStore()
+	store.items["protected"] = &TestType{
+		TypeMeta:   testTypeFixture.TypeMeta,
+		ObjectMeta: metav1.ObjectMeta{Name: "protected"},
+	}
+	extensionAPIServer, cleanup, err := setupExtensionAPIServer(t, scheme, store, func(opts *ExtensionAPIServerOptions) {
+		opts.Listener = ln
+		opts.Authorizer = authorizer.AuthorizerFunc(authzAllowAll)
+		opts.Authenticator = authenticator.RequestFunc(authAsAdmin)
+		opts.Admission = []admission.Interface{mutating, validating}
+	}, nil)
+	require.NoError(t, err)
+	defer cleanup()
+
+	createRequest := func(method string, path string, obj *TestType) *http.Request {
+		var body io.Reader
+		if obj != nil {
+			raw, err := json.Marshal(obj)
+			require.NoError(t, err)
+			body = bytes.NewReader(raw)
+		}
+		return httptest.NewRequest(method, path, body)
+	}
+	newObj := func(name string) *TestType {
+		obj := testTypeFixture.DeepCopy()
+		obj.Name = name
+		return obj
+	}
+
+	tests := []struct {
+		name                string
+		request             *http.Request
+		expectedStatusCode  int
+		expectedAnnotations map[string]string
+	}{
+		{
+			name:                "create mutated",
+			request:             createRequest(http.MethodPost, "/apis/ext.cattle.io/v1/testtypes", newObj("bar")),
+			expectedStatusCode:  http.StatusCreated,
+			expectedAnnotations: map[string]string{"admitted": "true"},
+		},
+		{
+			name:               "update validated",
+			request:            createRequest(http.MethodPut, "/apis/ext.cattle.io/v1/testtypes/protected", newObj("protected")),
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "delete validated",
+			request:            createRequest(http.MethodDelete, "/apis/ext.cattle.io/v1/testtypes/protected", nil),
+			expectedStatusCode: http.StatusForbidden,
+		},
+		{
+			name:               "delete allowed",
+			request:            createRequest(http.MethodDelete, "/apis/ext.cattle.io/v1/testtypes/bar", nil),
+			expectedStatusCode: http.StatusOK,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			extensionAPIServer.ServeHTTP(w, test.request)
+
+			resp := w.Result()
+			body, _ := io.ReadAll(resp.Body)
+
+			require.Equal(t, test.expectedStatusCode, resp.StatusCode, string(body))
+			if test.expectedAnnotations != nil {
+				obj := &TestType{}
+				require.NoError(t, json.Unmarshal(body, obj))
+				require.Equal(t, test.expectedAnnotations, obj.Annotations)
+			}
+		})
+	}
+	require.Contains(t, store.items, "protected")
+}
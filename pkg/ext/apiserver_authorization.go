@@ -43,6 +43,11 @@ func (a *AccessSetAuthorizer) Authorize(ctx context.Context, attrs authorizer.At
 		Group:    attrs.GetAPIGroup(),
 		Resource: attrs.GetResource(),
 	}
+	// Subresources are granted by RBAC rules on {resource}/{subresource}, not
+	// by the rules on their resource
+	if subresource := attrs.GetSubresource(); subresource != "" {
+		gr.Resource += "/" + subresource
+	}
 
 	if accessSet.Grants(verb, gr, namespace, name) {
 		return authorizer.DecisionAllow, "", nil
@@ -26,6 +26,7 @@ import (
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/runtime/serializer"
 	yamlutil "k8s.io/apimachinery/pkg/util/yaml"
 	"k8s.io/apimachinery/pkg/watch"
@@ -617,3 +618,76 @@ func TestAuthorization_NonResourceURLs(t *testing.T) {
 		})
 	}
 }
+
+func TestAuthorization_Subresources(t *testing.T) {
+	statusUser := &user.DefaultInfo{
+		Name: "status-user",
+	}
+
+	statusAccessSet := &accesscontrol.AccessSet{}
+	statusAccessSet.Add("get", schema.GroupResource{Group: "ext.cattle.io", Resource: "testtypes"}, accesscontrol.Access{
+		Namespace:    accesscontrol.All,
+		ResourceName: accesscontrol.All,
+	})
+	statusAccessSet.Add("update", schema.GroupResource{Group: "ext.cattle.io", Resource: "testtypes/status"}, accesscontrol.Access{
+		Namespace:    accesscontrol.All,
+		ResourceName: accesscontrol.All,
+	})
+
+	tests := []struct {
+		name        string
+		verb        string
+		subresource string
+		expected    authorizer.Decision
+	}{
+		{
+			name:     "resource granted",
+			verb:     "get",
+			expected: authorizer.DecisionAllow,
+		},
+		{
+			name:        "subresource not granted by resource",
+			verb:        "get",
+			subresource: "status",
+			expected:    authorizer.DecisionDeny,
+		},
+		{
+			name:        "subresource granted",
+			verb:        "update",
+			subresource: "status",
+			expected:    authorizer.DecisionAllow,
+		},
+		{
+			name:     "resource not granted by subresource",
+			verb:     "update",
+			expected: authorizer.DecisionDeny,
+		},
+		{
+			name:        "other subresource not granted",
+			verb:        "update",
+			subresource: "scale",
+			expected:    authorizer.DecisionDeny,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			crtl := gomock.NewController(t)
+			asl := fake.NewMockAccessSetLookup(crtl)
+			asl.EXPECT().AccessFor(statusUser).Return(statusAccessSet)
+
+			auth := NewAccessSetAuthorizer(asl)
+			authorized, _, err := auth.Authorize(context.TODO(), authorizer.AttributesRecord{
+				User:            statusUser,
+				ResourceRequest: true,
+				Verb:            tt.verb,
+				APIGroup:        "ext.cattle.io",
+				Resource:        "testtypes",
+				Subresource:     tt.subresource,
+				Name:            "foo",
+			})
+			require.NoError(t, err)
+			require.Equal(t, tt.expected, authorized)
+		})
+	}
+}
@@ -224,6 +224,117 @@ func TestStore(t *testing.T) {
 	}
 }
 
+// statusStore serves the status subresource of the objects of a testStore
+type statusStore struct {
+	parent *testStore[*TestType, *TestTypeList]
+}
+
+// New implements [regrest.Storage]
+func (s *statusStore) New() runtime.Object {
+	return s.parent.New()
+}
+
+// Destroy implements [regrest.Storage]
+func (s *statusStore) Destroy() {
+}
+
+// Get implements [regrest.Getter]
+func (s *statusStore) Get(ctx context.Context, name string, options *metav1.GetOptions) (runtime.Object, error) {
+	return s.parent.Get(ctx, name, options)
+}
+
+// Update implements [regrest.Updater]
+func (s *statusStore) Update(ctx context.Context, name string, objInfo regrest.UpdatedObjectInfo, createValidation regrest.ValidateObjectFunc, updateValidation regrest.ValidateObjectUpdateFunc, _ bool, options *metav1.UpdateOptions) (runtime.Object, bool, error) {
+	s.parent.lock.Lock()
+	defer s.parent.lock.Unlock()
+	return CreateOrUpdate(ctx, name, objInfo, createValidation, updateValidation, false, options, s.parent.get, s.parent.create, s.parent.update)
+}
+
+func TestSubresources(t *testing.T) {
+	scheme := runtime.NewScheme()
+	AddToScheme(scheme)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	ln, err := (&net.ListenConfig{}).Listen(ctx, "tcp", ":0")
+	require.NoError(t, err)
+
+	store := newDefaultTestStore()
+	extensionAPIServer, cleanup, err := setupExtensionAPIServer(t, scheme, store, func(opts *ExtensionAPIServerOptions) {
+		opts.Listener = ln
+		opts.Authorizer = authorizer.AuthorizerFunc(authzAllowAll)
+		opts.Authenticator = authenticator.RequestFunc(authAsAdmin)
+	}, func(s *ExtensionAPIServer) error {
+		err := s.InstallSubresource("testtypes", "status", testTypeGV.WithKind("TestType"), &statusStore{parent: store})
+		require.NoError(t, err)
+
+		err = s.InstallSubresource("testtypeothers", "status", testTypeGV.WithKind("TestTypeOther"), &statusStore{parent: store})
+		require.Error(t, err)
+
+		err = s.InstallSubresource("testtypes", "", testTypeGV.WithKind("TestType"), &statusStore{parent: store})
+		require.Error(t, err)
+		return nil
+	})
+	require.NoError(t, err)
+	defer cleanup()
+
+	updatedObj := testTypeFixture.DeepCopy()
+	updatedObj.Annotations = map[string]string{
+		"foo": "bar",
+	}
+	raw, err := json.Marshal(updatedObj)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name               string
+		request            *http.Request
+		expectedStatusCode int
+		expectedBody       *TestType
+	}{
+		{
+			name:               "get status",
+			request:            httptest.NewRequest(http.MethodGet, "/apis/ext.cattle.io/v1/testtypes/foo/status", nil),
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       &testTypeFixture,
+		},
+		{
+			name:               "update status",
+			request:            httptest.NewRequest(http.MethodPut, "/apis/ext.cattle.io/v1/testtypes/foo/status", bytes.NewReader(raw)),
+			expectedStatusCode: http.StatusOK,
+			expectedBody:       updatedObj,
+		},
+		{
+			name:               "create not allowed",
+			request:            httptest.NewRequest(http.MethodPost, "/apis/ext.cattle.io/v1/testtypes/foo/status", bytes.NewReader(raw)),
+			expectedStatusCode: http.StatusMethodNotAllowed,
+		},
+		{
+			name:               "get unknown subresource",
+			request:            httptest.NewRequest(http.MethodGet, "/apis/ext.cattle.io/v1/testtypes/foo/scale", nil),
+			expectedStatusCode: http.StatusNotFound,
+		},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			w := httptest.NewRecorder()
+			extensionAPIServer.ServeHTTP(w, test.request)
+
+			resp := w.Result()
+			body, _ := io.ReadAll(resp.Body)
+
+			require.Equal(t, test.expectedStatusCode, resp.StatusCode, string(body))
+			if test.expectedBody != nil {
+				obj := &TestType{}
+				require.NoError(t, json.Unmarshal(body, obj))
+				require.Equal(t, test.expectedBody.Name, obj.Name)
+				require.Equal(t, test.expectedBody.Annotations, obj.Annotations)
+			}
+		})
+	}
+}
+
 // This store tests when there's only a subset of verbs supported
 type partialStorage struct {
 	gvk schema.GroupVersionKind
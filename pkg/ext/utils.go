@@ -9,8 +9,10 @@ import (
 	"k8s.io/apimachinery/pkg/api/meta"
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apiserver/pkg/registry/rest"
 )
 
@@ -84,6 +86,33 @@ func convertToTable[T runtime.Object](ctx context.Context, object runtime.Object
 	return table, nil
 }
 
+// ColumnProvider provides the columns shown by kubectl (and Rancher UI) for objects of type T.
+type ColumnProvider[T runtime.Object] interface {
+	// ColumnDefinitions returns the definitions of the columns
+	ColumnDefinitions() []metav1.TableColumnDefinition
+	// Cells returns the cells of the row of obj, one per column
+	Cells(obj T) []string
+}
+
+// NewTableConvertor returns a [rest.TableConvertor] showing the columns of
+// provider, to be embedded in stores implementing [rest.Lister].
+func NewTableConvertor[T runtime.Object](groupResource schema.GroupResource, provider ColumnProvider[T]) rest.TableConvertor {
+	return &tableConvertor[T]{
+		groupResource: groupResource,
+		provider:      provider,
+	}
+}
+
+type tableConvertor[T runtime.Object] struct {
+	groupResource schema.GroupResource
+	provider      ColumnProvider[T]
+}
+
+// ConvertToTable implements [rest.TableConvertor]
+func (t *tableConvertor[T]) ConvertToTable(ctx context.Context, object runtime.Object, tableOptions runtime.Object) (*metav1.Table, error) {
+	return ConvertToTable[T](ctx, object, tableOptions, t.groupResource, t.provider.ColumnDefinitions(), t.provider.Cells)
+}
+
 func cellStringToCellAny(cells []string) []any {
 	var res []any
 	for _, cell := range cells {
@@ -190,6 +219,50 @@ func ConvertListOptions(options *metainternalversion.ListOptions) (*metav1.ListO
 	return &out, nil
 }
 
+// FieldsFunc returns the fields of an object which can be used in field
+// selectors, by their label (eg: spec.userID).
+type FieldsFunc[T runtime.Object] func(obj T) fields.Set
+
+// AddFieldSelectors registers the fields which can be used in field selectors
+// for gvk, in addition to metadata.name and metadata.namespace. Requests with
+// field selectors using other fields are rejected before reaching the store.
+func AddFieldSelectors(scheme *runtime.Scheme, gvk schema.GroupVersionKind, labels ...string) error {
+	supported := sets.New(labels...)
+	return scheme.AddFieldLabelConversionFunc(gvk, func(label, value string) (string, string, error) {
+		if supported.Has(label) {
+			return label, value, nil
+		}
+		return runtime.DefaultMetaV1FieldSelectorConversion(label, value)
+	})
+}
+
+// MatchesFieldSelector helps implement [rest.Lister] and [rest.Watcher] by
+// checking if obj matches the field selector of options, if any.
+//
+// metadata.name and metadata.namespace are always available. Other fields are
+// returned by fieldsFn, which can be nil, and must have been registered with
+// [AddFieldSelectors].
+func MatchesFieldSelector[T runtime.Object](options *metainternalversion.ListOptions, obj T, fieldsFn FieldsFunc[T]) (bool, error) {
+	if options == nil || options.FieldSelector == nil || options.FieldSelector.Empty() {
+		return true, nil
+	}
+
+	objMeta, err := meta.Accessor(obj)
+	if err != nil {
+		return false, convertError(err)
+	}
+	set := fields.Set{
+		"metadata.name":      objMeta.GetName(),
+		"metadata.namespace": objMeta.GetNamespace(),
+	}
+	if fieldsFn != nil {
+		for label, value := range fieldsFn(obj) {
+			set[label] = value
+		}
+	}
+	return options.FieldSelector.Matches(set), nil
+}
+
 func convertError(err error) error {
 	if _, ok := err.(apierrors.APIStatus); ok {
 		return err
@@ -1,14 +1,18 @@
 package ext
 
 import (
+	"context"
 	"fmt"
 	"net/http"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metainternalversion "k8s.io/apimachinery/pkg/apis/meta/internalversion"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/runtime"
 )
 
 func TestConvertListOptions(t *testing.T) {
@@ -67,3 +71,97 @@ func TestConvertError(t *testing.T) {
 		})
 	}
 }
+
+type testTypeColumns struct{}
+
+func (testTypeColumns) ColumnDefinitions() []metav1.TableColumnDefinition {
+	return []metav1.TableColumnDefinition{
+		{Name: "Name", Type: "name"},
+		{Name: "Owner", Type: "string"},
+	}
+}
+
+func (testTypeColumns) Cells(obj *TestType) []string {
+	return []string{obj.Name, obj.Annotations["owner"]}
+}
+
+func TestNewTableConvertor(t *testing.T) {
+	obj := testTypeFixture.DeepCopy()
+	obj.Annotations = map[string]string{"owner": "admin"}
+
+	convertor := NewTableConvertor[*TestType](testTypeGV.WithResource("testtypes").GroupResource(), testTypeColumns{})
+	table, err := convertor.ConvertToTable(context.TODO(), &TestTypeList{Items: []TestType{*obj}}, nil)
+	require.NoError(t, err)
+
+	assert.Equal(t, testTypeColumns{}.ColumnDefinitions(), table.ColumnDefinitions)
+	require.Len(t, table.Rows, 1)
+	assert.Equal(t, []any{"foo", "admin"}, table.Rows[0].Cells)
+}
+
+func TestAddFieldSelectors(t *testing.T) {
+	scheme := runtime.NewScheme()
+	gvk := testTypeGV.WithKind("TestType")
+	require.NoError(t, AddFieldSelectors(scheme, gvk, "spec.owner"))
+
+	for _, label := range []string{"spec.owner", "metadata.name", "metadata.namespace"} {
+		_, _, err := scheme.ConvertFieldLabel(gvk, label, "foo")
+		assert.NoError(t, err, label)
+	}
+	_, _, err := scheme.ConvertFieldLabel(gvk, "spec.other", "foo")
+	assert.Error(t, err)
+}
+
+func TestMatchesFieldSelector(t *testing.T) {
+	obj := testTypeFixture.DeepCopy()
+	obj.Annotations = map[string]string{"owner": "admin"}
+	ownerFields := func(obj *TestType) fields.Set {
+		return fields.Set{"spec.owner": obj.Annotations["owner"]}
+	}
+
+	tests := []struct {
+		name     string
+		options  *metainternalversion.ListOptions
+		fieldsFn FieldsFunc[*TestType]
+		expected bool
+	}{
+		{
+			name:     "no options",
+			expected: true,
+		},
+		{
+			name:     "no field selector",
+			options:  &metainternalversion.ListOptions{},
+			expected: true,
+		},
+		{
+			name:     "matching name",
+			options:  &metainternalversion.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", "foo")},
+			expected: true,
+		},
+		{
+			name:     "other name",
+			options:  &metainternalversion.ListOptions{FieldSelector: fields.OneTermEqualSelector("metadata.name", "bar")},
+			expected: false,
+		},
+		{
+			name:     "matching field",
+			options:  &metainternalversion.ListOptions{FieldSelector: fields.OneTermEqualSelector("spec.owner", "admin")},
+			fieldsFn: ownerFields,
+			expected: true,
+		},
+		{
+			name:     "excluded field",
+			options:  &metainternalversion.ListOptions{FieldSelector: fields.OneTermNotEqualSelector("spec.owner", "admin")},
+			fieldsFn: ownerFields,
+			expected: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			matches, err := MatchesFieldSelector(tt.options, obj, tt.fieldsFn)
+			require.NoError(t, err)
+			assert.Equal(t, tt.expected, matches)
+		})
+	}
+}
@@ -0,0 +1,182 @@
+package ext
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// WatchHistory helps implement [rest.Watcher] with resourceVersion semantics.
+//
+// It keeps the most recent events of a store's objects, so that watches can
+// resume from the resource version of a previous list or event, like
+// kubectl does. Resource versions must be integers, increasing with each
+// event. Resuming from a resource version older than the events kept fails
+// with a 410 Gone error, telling clients to list again.
+type WatchHistory struct {
+	lock     sync.Mutex
+	capacity int
+	events   []watch.Event
+	// dropped is the resource version of the last event dropped from the history
+	dropped  uint64
+	watchers map[*historyWatcher]struct{}
+}
+
+// NewWatchHistory returns a WatchHistory keeping up to capacity events
+func NewWatchHistory(capacity int) *WatchHistory {
+	return &WatchHistory{
+		capacity: capacity,
+		watchers: map[*historyWatcher]struct{}{},
+	}
+}
+
+// Add records an event and sends it to the current watches. The resource
+// version of the event's object must be greater than those of the previous
+// events.
+func (h *WatchHistory) Add(event watch.Event) error {
+	rv, err := eventResourceVersion(event)
+	if err != nil {
+		return err
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if len(h.events) > 0 {
+		last, _ := eventResourceVersion(h.events[len(h.events)-1])
+		if rv <= last {
+			return fmt.Errorf("resource version %d is not greater than %d", rv, last)
+		}
+	}
+	h.events = append(h.events, event)
+	if len(h.events) > h.capacity {
+		h.dropped, _ = eventResourceVersion(h.events[0])
+		h.events = h.events[1:]
+	}
+	for w := range h.watchers {
+		w.send(event)
+	}
+	return nil
+}
+
+// Watch returns a watch of the events following resourceVersion. An empty or
+// "0" resource version only watches new events.
+func (h *WatchHistory) Watch(ctx context.Context, resourceVersion string) (watch.Interface, error) {
+	var rv uint64
+	if resourceVersion != "" {
+		var err error
+		rv, err = strconv.ParseUint(resourceVersion, 10, 64)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid resource version %q", resourceVersion))
+		}
+	}
+
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if rv > 0 && rv < h.dropped {
+		return nil, apierrors.NewResourceExpired(fmt.Sprintf("too old resource version: %d (%d)", rv, h.dropped))
+	}
+
+	w := &historyWatcher{
+		result: make(chan watch.Event),
+		notify: make(chan struct{}, 1),
+		done:   make(chan struct{}),
+	}
+	if rv > 0 {
+		for _, event := range h.events {
+			if eventRV, _ := eventResourceVersion(event); eventRV > rv {
+				w.send(event)
+			}
+		}
+	}
+	h.watchers[w] = struct{}{}
+	w.remove = func() {
+		h.lock.Lock()
+		defer h.lock.Unlock()
+		delete(h.watchers, w)
+	}
+
+	go w.run(ctx)
+	return w, nil
+}
+
+func eventResourceVersion(event watch.Event) (uint64, error) {
+	obj, err := meta.Accessor(event.Object)
+	if err != nil {
+		return 0, err
+	}
+	rv, err := strconv.ParseUint(obj.GetResourceVersion(), 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid resource version %q: %w", obj.GetResourceVersion(), err)
+	}
+	return rv, nil
+}
+
+// historyWatcher queues the events sent to it, so that slow watchers never block the history
+type historyWatcher struct {
+	result   chan watch.Event
+	notify   chan struct{}
+	done     chan struct{}
+	stopOnce sync.Once
+	remove   func()
+
+	lock    sync.Mutex
+	pending []watch.Event
+}
+
+func (w *historyWatcher) send(event watch.Event) {
+	w.lock.Lock()
+	w.pending = append(w.pending, event)
+	w.lock.Unlock()
+
+	select {
+	case w.notify <- struct{}{}:
+	default:
+	}
+}
+
+func (w *historyWatcher) run(ctx context.Context) {
+	defer close(w.result)
+	defer w.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-w.done:
+			return
+		case <-w.notify:
+		}
+
+		w.lock.Lock()
+		events := w.pending
+		w.pending = nil
+		w.lock.Unlock()
+
+		for _, event := range events {
+			select {
+			case w.result <- event:
+			case <-ctx.Done():
+				return
+			case <-w.done:
+				return
+			}
+		}
+	}
+}
+
+// Stop implements [watch.Interface]
+func (w *historyWatcher) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.done)
+		w.remove()
+	})
+}
+
+// ResultChan implements [watch.Interface]
+func (w *historyWatcher) ResultChan() <-chan watch.Event {
+	return w.result
+}
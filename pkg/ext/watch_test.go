@@ -0,0 +1,90 @@
+package ext
+
+import (
+	"context"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func testTypeEvent(eventType watch.EventType, rv int) watch.Event {
+	obj := testTypeFixture.DeepCopy()
+	obj.ResourceVersion = strconv.Itoa(rv)
+	return watch.Event{Type: eventType, Object: obj}
+}
+
+func receiveResourceVersions(t *testing.T, w watch.Interface, count int) []string {
+	var result []string
+	for i := 0; i < count; i++ {
+		select {
+		case event := <-w.ResultChan():
+			result = append(result, event.Object.(*TestType).ResourceVersion)
+		case <-time.After(5 * time.Second):
+			t.Fatalf("timed out waiting for event %d", i)
+		}
+	}
+	return result
+}
+
+func TestWatchHistory(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	history := NewWatchHistory(3)
+	for rv := 1; rv <= 4; rv++ {
+		require.NoError(t, history.Add(testTypeEvent(watch.Modified, rv)))
+	}
+	assert.Error(t, history.Add(testTypeEvent(watch.Modified, 4)), "resource versions must increase")
+
+	// resumes after the given resource version
+	w, err := history.Watch(ctx, "2")
+	require.NoError(t, err)
+	defer w.Stop()
+	assert.Equal(t, []string{"3", "4"}, receiveResourceVersions(t, w, 2))
+
+	// only watches new events
+	newWatch, err := history.Watch(ctx, "")
+	require.NoError(t, err)
+	defer newWatch.Stop()
+	require.NoError(t, history.Add(testTypeEvent(watch.Deleted, 5)))
+	assert.Equal(t, []string{"5"}, receiveResourceVersions(t, w, 1))
+	assert.Equal(t, []string{"5"}, receiveResourceVersions(t, newWatch, 1))
+
+	// events 1 and 2 were dropped
+	_, err = history.Watch(ctx, "1")
+	assert.True(t, apierrors.IsResourceExpired(err) || apierrors.IsGone(err), err)
+
+	_, err = history.Watch(ctx, "foo")
+	assert.True(t, apierrors.IsBadRequest(err), err)
+}
+
+func TestWatchHistoryStop(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	history := NewWatchHistory(10)
+
+	stopped, err := history.Watch(ctx, "")
+	require.NoError(t, err)
+	stopped.Stop()
+	canceled, err := history.Watch(ctx, "")
+	require.NoError(t, err)
+	cancel()
+
+	for _, w := range []watch.Interface{stopped, canceled} {
+		select {
+		case _, ok := <-w.ResultChan():
+			assert.False(t, ok)
+		case <-time.After(5 * time.Second):
+			t.Fatal("timed out waiting for the watch to end")
+		}
+	}
+	require.Eventually(t, func() bool {
+		history.lock.Lock()
+		defer history.lock.Unlock()
+		return len(history.watchers) == 0
+	}, 5*time.Second, 10*time.Millisecond)
+}
@@ -0,0 +1,57 @@
+// Package features enables experimental behaviors for single requests, with the X-Steve-Features header, so that they
+// can be tried out by some clients before being enabled for all of them.
+package features
+
+import (
+	"context"
+	"net/http"
+	"sort"
+	"strings"
+)
+
+// Header is the request header listing the features to enable, comma-separated. The response has the same header
+// listing the features which were enabled
+const Header = "X-Steve-Features"
+
+type contextKey struct{}
+
+// Middleware returns a middleware enabling the features of the X-Steve-Features header for the request, as long as
+// they are allowed. Other features are ignored.
+func Middleware(allowed []string) func(http.Handler) http.Handler {
+	allowlist := map[string]bool{}
+	for _, feature := range allowed {
+		allowlist[feature] = true
+	}
+
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			enabled := map[string]bool{}
+			for _, value := range req.Header.Values(Header) {
+				for _, feature := range strings.Split(value, ",") {
+					feature = strings.TrimSpace(feature)
+					if allowlist[feature] {
+						enabled[feature] = true
+					}
+				}
+			}
+			if len(enabled) == 0 {
+				next.ServeHTTP(rw, req)
+				return
+			}
+
+			names := make([]string, 0, len(enabled))
+			for feature := range enabled {
+				names = append(names, feature)
+			}
+			sort.Strings(names)
+			rw.Header().Set(Header, strings.Join(names, ","))
+			next.ServeHTTP(rw, req.WithContext(context.WithValue(req.Context(), contextKey{}, enabled)))
+		})
+	}
+}
+
+// Enabled returns true if the feature was enabled for the request of ctx
+func Enabled(ctx context.Context, feature string) bool {
+	enabled, _ := ctx.Value(contextKey{}).(map[string]bool)
+	return enabled[feature]
+}
@@ -0,0 +1,56 @@
+package features
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMiddleware(t *testing.T) {
+	tests := []struct {
+		name           string
+		headers        []string
+		expected       map[string]bool
+		expectedHeader string
+	}{
+		{
+			name:     "no header",
+			expected: map[string]bool{"new-sort": false, "streaming": false},
+		},
+		{
+			name:           "allowed features are enabled",
+			headers:        []string{"streaming, new-sort"},
+			expected:       map[string]bool{"new-sort": true, "streaming": true},
+			expectedHeader: "new-sort,streaming",
+		},
+		{
+			name:           "other features are ignored",
+			headers:        []string{"streaming,unknown", "admin"},
+			expected:       map[string]bool{"new-sort": false, "streaming": true, "unknown": false, "admin": false},
+			expectedHeader: "streaming",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var enabled map[string]bool
+			handler := Middleware([]string{"new-sort", "streaming"})(http.HandlerFunc(func(_ http.ResponseWriter, req *http.Request) {
+				enabled = map[string]bool{}
+				for feature := range test.expected {
+					enabled[feature] = Enabled(req.Context(), feature)
+				}
+			}))
+
+			req := httptest.NewRequest(http.MethodGet, "/v1/pods", nil)
+			for _, header := range test.headers {
+				req.Header.Add(Header, header)
+			}
+			rw := httptest.NewRecorder()
+			handler.ServeHTTP(rw, req)
+
+			assert.Equal(t, test.expected, enabled)
+			assert.Equal(t, test.expectedHeader, rw.Header().Get(Header))
+		})
+	}
+}
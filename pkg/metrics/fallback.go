@@ -0,0 +1,35 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	CacheFallback = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "k8s_proxy",
+			Name:      "sql_cache_fallback",
+			Help:      "Whether lists of a resource fall back to the Kubernetes API server because its SQL cache is failing",
+		},
+		[]string{resourceLabel})
+	CacheFallbackTransitions = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "k8s_proxy",
+			Name:      "sql_cache_fallback_transitions_total",
+			Help:      "Total count of the transitions of resources to falling back to the Kubernetes API server",
+		},
+		[]string{resourceLabel})
+)
+
+// RecordCacheFallback records whether lists of resource fall back to the Kubernetes API server
+func RecordCacheFallback(resource string, fallback bool) {
+	if !prometheusMetrics {
+		return
+	}
+	if fallback {
+		CacheFallback.With(prometheus.Labels{resourceLabel: resource}).Set(1)
+		CacheFallbackTransitions.With(prometheus.Labels{resourceLabel: resource}).Inc()
+		return
+	}
+	CacheFallback.With(prometheus.Labels{resourceLabel: resource}).Set(0)
+}
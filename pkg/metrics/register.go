@@ -14,5 +14,19 @@ func init() {
 		prometheus.MustRegister(ProxyTotalResponses)
 		prometheus.MustRegister(K8sClientResponseTime)
 		prometheus.MustRegister(ProxyStoreResponseTime)
+		prometheus.MustRegister(ActiveWatches)
+		prometheus.MustRegister(WatchEvents)
+		prometheus.MustRegister(WatchBacklog)
+		prometheus.MustRegister(SubscribeDropped)
+		prometheus.MustRegister(UserRequests)
+		prometheus.MustRegister(UserRequestTime)
+		prometheus.MustRegister(CacheFallback)
+		prometheus.MustRegister(CacheFallbackTransitions)
+		prometheus.MustRegister(SQLCacheConnections)
+		prometheus.MustRegister(SQLCacheTransactionWait)
+		prometheus.MustRegister(SQLCacheConnectionsInUse)
+		prometheus.MustRegister(SQLCacheWriteBatchSize)
+		prometheus.MustRegister(UnindexedFieldRequests)
+		prometheus.MustRegister(ListBudgetExceeded)
 	}
 }
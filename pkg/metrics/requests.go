@@ -0,0 +1,249 @@
+package metrics
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	userLabel = "user"
+	// maxQueryShapes bounds the number of query shapes kept, the fastest being dropped first
+	maxQueryShapes = 1000
+)
+
+var (
+	UserRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "k8s_proxy",
+			Name:      "user_requests_total",
+			Help:      "Total count of store requests per user",
+		},
+		[]string{userLabel, resourceLabel, methodLabel, codeLabel})
+	UserRequestTime = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "k8s_proxy",
+			Name:      "user_request_time",
+			Help:      "Store request times in ms per user",
+		},
+		[]string{userLabel, resourceLabel, methodLabel})
+)
+
+// Requests keeps the statistics of the requests to the stores of all resources
+var Requests = NewRequestStats()
+
+// Request is a request served by the store of a resource
+type Request struct {
+	User     string
+	Resource string
+	Method   string
+	// Query is the query of list requests, nil for other requests
+	Query    url.Values
+	Duration time.Duration
+	Err      error
+}
+
+// QueryStat are the statistics of the list requests of a resource sharing the same query shape, that is the same
+// filtered fields, sort and page size regardless of the filtered values
+type QueryStat struct {
+	Resource string `json:"resource"`
+	Shape    string `json:"shape"`
+	// Count is the number of requests
+	Count int64 `json:"count"`
+	// SlowCount is the number of requests slower than the slow request threshold
+	SlowCount int64 `json:"slowCount"`
+	// AverageMilliseconds is the average duration of the requests
+	AverageMilliseconds float64 `json:"averageMilliseconds"`
+	// MaxMilliseconds is the duration of the slowest request
+	MaxMilliseconds int64 `json:"maxMilliseconds"`
+	// LastUser is the user of the last request
+	LastUser string    `json:"lastUser"`
+	LastSeen time.Time `json:"lastSeen"`
+}
+
+type queryStat struct {
+	QueryStat
+	total time.Duration
+	max   time.Duration
+}
+
+// RequestStats records the per-user metrics of store requests, the statistics of the shapes of list queries, and logs
+// slow requests
+type RequestStats struct {
+	lock          sync.Mutex
+	stats         map[string]*queryStat
+	slowThreshold time.Duration
+	now           func() time.Time
+}
+
+// NewRequestStats returns empty request statistics
+func NewRequestStats() *RequestStats {
+	return &RequestStats{
+		stats: map[string]*queryStat{},
+		now:   time.Now,
+	}
+}
+
+// SetSlowThreshold sets the duration above which requests are logged, 0 disables logging
+func (r *RequestStats) SetSlowThreshold(threshold time.Duration) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.slowThreshold = threshold
+}
+
+// Record records a request served by a store
+func (r *RequestStats) Record(req Request) {
+	ms := float64(req.Duration.Milliseconds())
+	if prometheusMetrics {
+		UserRequests.With(prometheus.Labels{
+			userLabel:     req.User,
+			resourceLabel: req.Resource,
+			methodLabel:   req.Method,
+			codeLabel:     MetricLogger{Method: req.Method}.getAPIErrorCode(req.Err),
+		}).Inc()
+		UserRequestTime.With(prometheus.Labels{
+			userLabel:     req.User,
+			resourceLabel: req.Resource,
+			methodLabel:   req.Method,
+		}).Observe(ms)
+	}
+
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	slow := r.slowThreshold > 0 && req.Duration >= r.slowThreshold
+	if slow {
+		logrus.WithFields(logrus.Fields{
+			"user":     req.User,
+			"resource": req.Resource,
+			"method":   req.Method,
+			"duration": req.Duration,
+			"filter":   req.Query["filter"],
+			"sort":     req.Query.Get("sort"),
+			"pagesize": req.Query.Get("pagesize"),
+			"page":     req.Query.Get("page"),
+		}).Warn("slow request")
+	}
+	if req.Query == nil {
+		return
+	}
+
+	shape := QueryShape(req.Query)
+	key := req.Resource + "?" + shape
+	stat, ok := r.stats[key]
+	if !ok {
+		if len(r.stats) >= maxQueryShapes {
+			r.dropFastestLocked()
+		}
+		stat = &queryStat{QueryStat: QueryStat{Resource: req.Resource, Shape: shape}}
+		r.stats[key] = stat
+	}
+	stat.Count++
+	if slow {
+		stat.SlowCount++
+	}
+	stat.total += req.Duration
+	if req.Duration > stat.max {
+		stat.max = req.Duration
+	}
+	stat.LastUser = req.User
+	stat.LastSeen = r.now()
+}
+
+func (r *RequestStats) dropFastestLocked() {
+	var fastest string
+	for key, stat := range r.stats {
+		if fastest == "" || stat.max < r.stats[fastest].max {
+			fastest = key
+		}
+	}
+	delete(r.stats, fastest)
+}
+
+// Slowest returns the statistics of the query shapes, the slowest first, at most limit of them if limit is positive
+func (r *RequestStats) Slowest(limit int) []QueryStat {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+
+	result := make([]QueryStat, 0, len(r.stats))
+	for _, stat := range r.stats {
+		s := stat.QueryStat
+		s.MaxMilliseconds = stat.max.Milliseconds()
+		s.AverageMilliseconds = float64(stat.total.Milliseconds()) / float64(stat.Count)
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].MaxMilliseconds != result[j].MaxMilliseconds {
+			return result[i].MaxMilliseconds > result[j].MaxMilliseconds
+		}
+		if result[i].Resource != result[j].Resource {
+			return result[i].Resource < result[j].Resource
+		}
+		return result[i].Shape < result[j].Shape
+	})
+	if limit > 0 && len(result) > limit {
+		result = result[:limit]
+	}
+	return result
+}
+
+// QueryShape returns the shape of a list query: the fields it filters on without their values, its sort, its page size
+// and the other parameters it sets, so that queries loading the cache the same way are counted together.
+func QueryShape(query url.Values) string {
+	var parts []string
+	var filters []string
+	for _, filter := range query["filter"] {
+		for _, f := range splitOrFilters(filter) {
+			field, _, _ := strings.Cut(strings.TrimPrefix(f, "!"), " ")
+			if i := strings.IndexAny(field, "=!<>~"); i >= 0 {
+				field = field[:i]
+			}
+			filters = append(filters, strings.TrimSpace(field))
+		}
+	}
+	sort.Strings(filters)
+	if len(filters) > 0 {
+		parts = append(parts, "filter="+strings.Join(filters, ","))
+	}
+	for _, param := range []string{"sort", "pagesize"} {
+		if value := query.Get(param); value != "" {
+			parts = append(parts, param+"="+value)
+		}
+	}
+	var others []string
+	for param := range query {
+		switch param {
+		case "filter", "sort", "pagesize", "page", "continue", "revision":
+		default:
+			others = append(others, param)
+		}
+	}
+	sort.Strings(others)
+	return strings.Join(append(parts, others...), "&")
+}
+
+// splitOrFilters splits the value of a filter parameter on commas which aren't part of a set of values
+func splitOrFilters(filter string) []string {
+	var result []string
+	depth, start := 0, 0
+	for i, c := range filter {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				result = append(result, filter[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(result, filter[start:])
+}
@@ -0,0 +1,124 @@
+package metrics
+
+import (
+	"net/url"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRequestStats(t *testing.T) {
+	now := time.Unix(600, 0)
+	stats := NewRequestStats()
+	stats.now = func() time.Time { return now }
+	stats.SetSlowThreshold(time.Second)
+
+	stats.Record(Request{
+		User:     "jane",
+		Resource: "pod",
+		Method:   "GET",
+		Query:    url.Values{"filter": {"metadata.name=foo"}, "pagesize": {"50"}},
+		Duration: 2 * time.Second,
+	})
+	stats.Record(Request{
+		User:     "john",
+		Resource: "pod",
+		Method:   "GET",
+		Query:    url.Values{"filter": {"metadata.name=bar"}, "pagesize": {"50"}, "page": {"2"}},
+		Duration: 500 * time.Millisecond,
+	})
+	stats.Record(Request{
+		User:     "jane",
+		Resource: "secret",
+		Method:   "GET",
+		Query:    url.Values{},
+		Duration: 100 * time.Millisecond,
+	})
+	// requests other than lists have no query shape
+	stats.Record(Request{User: "jane", Resource: "pod", Method: "PUT", Duration: 3 * time.Second})
+
+	assert.Equal(t, []QueryStat{
+		{
+			Resource:            "pod",
+			Shape:               "filter=metadata.name&pagesize=50",
+			Count:               2,
+			SlowCount:           1,
+			AverageMilliseconds: 1250,
+			MaxMilliseconds:     2000,
+			LastUser:            "john",
+			LastSeen:            now,
+		},
+		{
+			Resource:            "secret",
+			Count:               1,
+			AverageMilliseconds: 100,
+			MaxMilliseconds:     100,
+			LastUser:            "jane",
+			LastSeen:            now,
+		},
+	}, stats.Slowest(0))
+	require.Len(t, stats.Slowest(1), 1)
+	assert.Equal(t, "pod", stats.Slowest(1)[0].Resource)
+}
+
+func TestRequestStatsDropFastest(t *testing.T) {
+	stats := NewRequestStats()
+	for i := 0; i < maxQueryShapes; i++ {
+		stats.Record(Request{
+			Resource: "pod",
+			Query:    url.Values{"pagesize": {time.Duration(i).String()}},
+			Duration: time.Duration(i+1) * time.Millisecond,
+		})
+	}
+	stats.Record(Request{Resource: "secret", Query: url.Values{}, Duration: time.Hour})
+
+	slowest := stats.Slowest(0)
+	assert.Len(t, slowest, maxQueryShapes)
+	assert.Equal(t, "secret", slowest[0].Resource)
+	assert.Equal(t, int64(2), slowest[len(slowest)-1].MaxMilliseconds)
+}
+
+func TestQueryShape(t *testing.T) {
+	tests := []struct {
+		name  string
+		query url.Values
+		want  string
+	}{
+		{
+			name: "empty",
+		},
+		{
+			name: "filters without values, sorted",
+			query: url.Values{
+				"filter": {"spec.nodeName=node1,metadata.name~foo", "!metadata.labels[app]"},
+			},
+			want: "filter=metadata.labels[app],metadata.name,spec.nodeName",
+		},
+		{
+			name: "set filters",
+			query: url.Values{
+				"filter": {"metadata.labels[app] in (a,b),metadata.namespace notin (c)"},
+			},
+			want: "filter=metadata.labels[app],metadata.namespace",
+		},
+		{
+			name: "sort, page size and other params",
+			query: url.Values{
+				"sort":                 {"-metadata.creationTimestamp"},
+				"pagesize":             {"100"},
+				"page":                 {"3"},
+				"continue":             {"abc"},
+				"projectsornamespaces": {"p-1"},
+				"groupBy":              {"metadata.namespace"},
+			},
+			want: "sort=-metadata.creationTimestamp&pagesize=100&groupBy&projectsornamespaces",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, QueryShape(test.query))
+		})
+	}
+}
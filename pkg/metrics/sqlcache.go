@@ -0,0 +1,107 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+const (
+	fieldLabel   = "field"
+	outcomeLabel = "outcome"
+	poolLabel    = "pool"
+
+	// ListBudgetRejected is the outcome of lists rejected because they exceed the per-request memory budget
+	ListBudgetRejected = "rejected"
+	// ListBudgetPaginated is the outcome of lists paginated to fit the per-request memory budget
+	ListBudgetPaginated = "paginated"
+	// ListBudgetThrottled is the outcome of lists rejected because concurrent lists exceed the global memory budget
+	ListBudgetThrottled = "throttled"
+)
+
+var (
+	SQLCacheConnections = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: "k8s_proxy",
+			Name:      "sql_cache_connections_opened_total",
+			Help:      "Total count of the connections opened to the SQL cache database, which grows with concurrent lists",
+		})
+	SQLCacheTransactionWait = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "k8s_proxy",
+			Name:      "sql_cache_transaction_wait_seconds",
+			Help:      "Time transactions of the SQL cache waited for a connection of their pool, writer or reader, to begin",
+			Buckets:   []float64{0.0001, 0.001, 0.01, 0.1, 1, 10},
+		},
+		[]string{poolLabel})
+	SQLCacheConnectionsInUse = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "k8s_proxy",
+			Name:      "sql_cache_connections_in_use",
+			Help:      "Connections of the pools of the SQL cache database, writer or reader, in use when a transaction last began",
+		},
+		[]string{poolLabel})
+	SQLCacheWriteBatchSize = prometheus.NewHistogram(
+		prometheus.HistogramOpts{
+			Subsystem: "k8s_proxy",
+			Name:      "sql_cache_write_batch_size",
+			Help:      "Transactions of events of the SQL cache committed in each batch of the writer connection",
+			Buckets:   []float64{1, 10, 100, 1000, 10000},
+		})
+	UnindexedFieldRequests = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "k8s_proxy",
+			Name:      "sql_cache_unindexed_field_requests_total",
+			Help:      "Total count of the lists filtering or sorting on a field which isn't indexed in the SQL cache",
+		},
+		[]string{resourceLabel, fieldLabel})
+	ListBudgetExceeded = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "k8s_proxy",
+			Name:      "sql_cache_list_budget_exceeded_total",
+			Help:      "Total count of the lists of the SQL cache estimated to exceed their memory budget, by outcome",
+		},
+		[]string{resourceLabel, outcomeLabel})
+)
+
+// RecordSQLCacheConnection records a connection opened to the SQL cache database
+func RecordSQLCacheConnection() {
+	if !prometheusMetrics {
+		return
+	}
+	SQLCacheConnections.Inc()
+}
+
+// RecordSQLCacheTransaction records a transaction of a pool of the SQL cache database, which waited for wait to begin
+// with inUse connections of the pool in use
+func RecordSQLCacheTransaction(pool string, wait time.Duration, inUse int) {
+	if !prometheusMetrics {
+		return
+	}
+	SQLCacheTransactionWait.With(prometheus.Labels{poolLabel: pool}).Observe(wait.Seconds())
+	SQLCacheConnectionsInUse.With(prometheus.Labels{poolLabel: pool}).Set(float64(inUse))
+}
+
+// RecordSQLCacheWriteBatch records a batch of the SQL cache database committing writes transactions of events
+func RecordSQLCacheWriteBatch(writes int) {
+	if !prometheusMetrics {
+		return
+	}
+	SQLCacheWriteBatchSize.Observe(float64(writes))
+}
+
+// RecordUnindexedField records a list of resource filtering or sorting on field, which isn't indexed in the SQL cache
+func RecordUnindexedField(resource, field string) {
+	if !prometheusMetrics {
+		return
+	}
+	UnindexedFieldRequests.With(prometheus.Labels{resourceLabel: resource, fieldLabel: field}).Inc()
+}
+
+// RecordListBudgetExceeded records a list of resource exceeding its memory budget, with the outcome of the list
+func RecordListBudgetExceeded(resource, outcome string) {
+	if !prometheusMetrics {
+		return
+	}
+	ListBudgetExceeded.With(prometheus.Labels{resourceLabel: resource, outcomeLabel: outcome}).Inc()
+}
@@ -0,0 +1,173 @@
+package metrics
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	ActiveWatches = prometheus.NewGaugeVec(
+		prometheus.GaugeOpts{
+			Subsystem: "k8s_proxy",
+			Name:      "active_watches",
+			Help:      "Number of active watches",
+		},
+		[]string{resourceLabel})
+	WatchEvents = prometheus.NewCounterVec(
+		prometheus.CounterOpts{
+			Subsystem: "k8s_proxy",
+			Name:      "watch_events_total",
+			Help:      "Total count of events delivered to watches",
+		},
+		[]string{resourceLabel})
+	WatchBacklog = prometheus.NewHistogramVec(
+		prometheus.HistogramOpts{
+			Subsystem: "k8s_proxy",
+			Name:      "watch_backlog",
+			Help:      "Number of events waiting to be delivered to a watch when an event is delivered",
+			Buckets:   []float64{0, 1, 5, 10, 50, 100},
+		},
+		[]string{resourceLabel})
+	SubscribeDropped = prometheus.NewCounter(
+		prometheus.CounterOpts{
+			Subsystem: "k8s_proxy",
+			Name:      "subscribe_dropped_connections_total",
+			Help:      "Total count of the subscribe websockets reset because their client didn't keep up with their events",
+		})
+)
+
+// RecordSubscribeDropped records a subscribe websocket reset because its client didn't keep up with its events
+func RecordSubscribeDropped() {
+	if !prometheusMetrics {
+		return
+	}
+	SubscribeDropped.Inc()
+}
+
+// Watches keeps the statistics of the watches of all resources
+var Watches = NewWatchStats()
+
+// WatchStat are the statistics of the watches of a resource
+type WatchStat struct {
+	Resource string                  `json:"resource"`
+	GVK      schema.GroupVersionKind `json:"gvk"`
+	// Active is the number of active watches
+	Active int `json:"active"`
+	// Events is the total number of events delivered to watches
+	Events int64 `json:"events"`
+	// EventsPerMinute is the number of events delivered to watches during the last full minute
+	EventsPerMinute int64 `json:"eventsPerMinute"`
+	// AverageBacklog is the average number of events waiting to be delivered to a watch when an event is delivered
+	AverageBacklog float64 `json:"averageBacklog"`
+}
+
+type watchStat struct {
+	WatchStat
+	backlog int64
+	// minute is the last minute during which events were delivered, counted by current, previous being the count of
+	// the minute before
+	minute   int64
+	current  int64
+	previous int64
+}
+
+// WatchStats keeps the statistics of watches per resource
+type WatchStats struct {
+	lock  sync.Mutex
+	stats map[string]*watchStat
+	now   func() time.Time
+}
+
+// NewWatchStats returns empty watch statistics
+func NewWatchStats() *WatchStats {
+	return &WatchStats{
+		stats: map[string]*watchStat{},
+		now:   time.Now,
+	}
+}
+
+func (w *WatchStats) statLocked(resource string) *watchStat {
+	stat, ok := w.stats[resource]
+	if !ok {
+		stat = &watchStat{WatchStat: WatchStat{Resource: resource}}
+		w.stats[resource] = stat
+	}
+	return stat
+}
+
+// Started records a new watch of resource
+func (w *WatchStats) Started(resource string, gvk schema.GroupVersionKind) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	stat := w.statLocked(resource)
+	stat.GVK = gvk
+	stat.Active++
+	if prometheusMetrics {
+		ActiveWatches.With(prometheus.Labels{resourceLabel: resource}).Inc()
+	}
+}
+
+// Stopped records the end of a watch of resource
+func (w *WatchStats) Stopped(resource string) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.statLocked(resource).Active--
+	if prometheusMetrics {
+		ActiveWatches.With(prometheus.Labels{resourceLabel: resource}).Dec()
+	}
+}
+
+// Delivered records an event delivered to a watch of resource, backlog events still waiting to be delivered
+func (w *WatchStats) Delivered(resource string, backlog int) {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	stat := w.statLocked(resource)
+	stat.Events++
+	stat.backlog += int64(backlog)
+	minute := w.now().Unix() / 60
+	switch {
+	case stat.minute == minute:
+	case stat.minute == minute-1:
+		stat.previous = stat.current
+		stat.current = 0
+	default:
+		stat.previous = 0
+		stat.current = 0
+	}
+	stat.minute = minute
+	stat.current++
+	if prometheusMetrics {
+		WatchEvents.With(prometheus.Labels{resourceLabel: resource}).Inc()
+		WatchBacklog.With(prometheus.Labels{resourceLabel: resource}).Observe(float64(backlog))
+	}
+}
+
+// List returns the statistics of the resources which have been watched, sorted by resource
+func (w *WatchStats) List() []WatchStat {
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	minute := w.now().Unix() / 60
+
+	result := make([]WatchStat, 0, len(w.stats))
+	for _, stat := range w.stats {
+		s := stat.WatchStat
+		switch stat.minute {
+		case minute:
+			s.EventsPerMinute = stat.previous
+		case minute - 1:
+			s.EventsPerMinute = stat.current
+		}
+		if stat.Events > 0 {
+			s.AverageBacklog = float64(stat.backlog) / float64(stat.Events)
+		}
+		result = append(result, s)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Resource < result[j].Resource
+	})
+	return result
+}
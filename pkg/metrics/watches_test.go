@@ -0,0 +1,38 @@
+package metrics
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestWatchStats(t *testing.T) {
+	now := time.Unix(600, 0)
+	stats := NewWatchStats()
+	stats.now = func() time.Time { return now }
+	podGVK := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+
+	stats.Started("pod", podGVK)
+	stats.Started("pod", podGVK)
+	stats.Started("secret", schema.GroupVersionKind{Version: "v1", Kind: "Secret"})
+	stats.Stopped("secret")
+	stats.Delivered("pod", 0)
+	stats.Delivered("pod", 3)
+	stats.Delivered("pod", 1)
+	assert.Equal(t, []WatchStat{
+		{Resource: "pod", GVK: podGVK, Active: 2, Events: 3, AverageBacklog: 4.0 / 3},
+		{Resource: "secret", GVK: schema.GroupVersionKind{Version: "v1", Kind: "Secret"}},
+	}, stats.List())
+
+	// events of the last full minute are counted
+	now = now.Add(time.Minute)
+	assert.Equal(t, int64(3), stats.List()[0].EventsPerMinute)
+	stats.Delivered("pod", 0)
+	assert.Equal(t, int64(3), stats.List()[0].EventsPerMinute)
+	now = now.Add(time.Minute)
+	assert.Equal(t, int64(1), stats.List()[0].EventsPerMinute)
+	now = now.Add(time.Minute)
+	assert.Equal(t, int64(0), stats.List()[0].EventsPerMinute)
+}
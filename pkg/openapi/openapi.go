@@ -0,0 +1,402 @@
+// Package openapi generates an OpenAPI v3 document describing steve's /v1 API, assembled from the schemas a user can
+// access: their collections, resources, actions and links.
+package openapi
+
+import (
+	"net/http"
+	"sort"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/slice"
+	"k8s.io/kube-openapi/pkg/spec3"
+	"k8s.io/kube-openapi/pkg/validation/spec"
+	"k8s.io/utils/ptr"
+)
+
+const (
+	// Path is where the document is served
+	Path = "/v1/openapi/v3"
+
+	openAPIVersion = "3.0.0"
+	jsonMediaType  = "application/json"
+
+	resourceComponent   = "Resource"
+	collectionComponent = "Collection"
+	errorComponent      = "Error"
+)
+
+const description = "The steve /v1 API. Resources are described by the schemas of the /v1/schemas endpoint, and " +
+	"the fields of Kubernetes types by the /v1/schemaDefinitions endpoint. APIs of the extension API server, " +
+	"when enabled, are described by its own /ext/openapi/v3 endpoint."
+
+// listParameters are the query parameters of list requests
+var listParameters = []struct {
+	name        string
+	description string
+}{
+	{"filter", "Filters resources by field values, e.g. metadata.name=foo"},
+	{"sort", "Sorts resources by fields, e.g. metadata.name,-metadata.creationTimestamp"},
+	{"limit", "Maximum number of resources to return per request"},
+	{"continue", "Continue token returned by a previous, limited, request"},
+	{"page", "Page of resources to return"},
+	{"pagesize", "Number of resources per page"},
+	{"revision", "Resource version the list must be at least as recent as"},
+	{"changesSince", "Lists the objects changed since a revision, with the type of their change, instead of the objects"},
+}
+
+// Document returns the OpenAPI v3 document of the /v1 API for the given schemas
+func Document(apiSchemas *types.APISchemas) *spec3.OpenAPI {
+	doc := &spec3.OpenAPI{
+		Version: openAPIVersion,
+		Info: &spec.Info{
+			InfoProps: spec.InfoProps{
+				Title:       "Steve",
+				Description: description,
+				Version:     "v1",
+			},
+		},
+		Paths: &spec3.Paths{Paths: map[string]*spec3.Path{}},
+		Components: &spec3.Components{
+			Schemas: map[string]*spec.Schema{
+				resourceComponent:   resourceSchema(),
+				collectionComponent: collectionSchema(),
+				errorComponent:      errorSchema(),
+			},
+		},
+	}
+
+	ids := make([]string, 0, len(apiSchemas.Schemas))
+	for id := range apiSchemas.Schemas {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+	for _, id := range ids {
+		addSchema(doc, apiSchemas, apiSchemas.Schemas[id])
+	}
+	return doc
+}
+
+func addSchema(doc *spec3.OpenAPI, apiSchemas *types.APISchemas, apiSchema *types.APISchema) {
+	if apiSchema == nil || apiSchema.Schema == nil {
+		return
+	}
+
+	resource := componentRef(resourceComponent)
+	if len(apiSchema.ResourceFields) > 0 {
+		doc.Components.Schemas[apiSchema.ID] = fieldsSchema(apiSchemas, apiSchema)
+		resource = componentRef(apiSchema.ID)
+	}
+
+	base := "/v1/" + pluralName(apiSchema)
+	namespaced := attributes.Namespaced(apiSchema)
+	tags := []string{apiSchema.ID}
+
+	collection := &spec3.Path{}
+	if slice.ContainsString(apiSchema.CollectionMethods, http.MethodGet) {
+		collection.Get = operation(tags, "list-"+apiSchema.ID, "List "+apiSchema.ID, listQueryParameters(), nil,
+			collectionRef(resource))
+	}
+	if slice.ContainsString(apiSchema.CollectionMethods, http.MethodPost) || len(apiSchema.CollectionActions) > 0 {
+		var parameters []*spec3.Parameter
+		summary := "Create a " + apiSchema.ID
+		if len(apiSchema.CollectionActions) > 0 {
+			parameters = append(parameters, actionParameter(apiSchema.CollectionActions,
+				!slice.ContainsString(apiSchema.CollectionMethods, http.MethodPost)))
+			summary = "Create a " + apiSchema.ID + ", or run a collection action if the action parameter is set"
+		}
+		collection.Post = operation(tags, "create-"+apiSchema.ID, summary, parameters, requestBody(resource), resource)
+	}
+	addPath(doc, base, collection)
+
+	if namespaced && slice.ContainsString(apiSchema.CollectionMethods, http.MethodGet) {
+		addPath(doc, base+"/{namespace}", &spec3.Path{
+			PathProps: spec3.PathProps{
+				Parameters: []*spec3.Parameter{pathParameter("namespace")},
+				Get: operation(tags, "list-namespaced-"+apiSchema.ID, "List "+apiSchema.ID+" in a namespace",
+					listQueryParameters(), nil, collectionRef(resource)),
+			},
+		})
+	}
+
+	resourcePath := base + "/{name}"
+	pathParameters := []*spec3.Parameter{pathParameter("name")}
+	if namespaced {
+		resourcePath = base + "/{namespace}/{name}"
+		pathParameters = []*spec3.Parameter{pathParameter("namespace"), pathParameter("name")}
+	}
+	item := &spec3.Path{PathProps: spec3.PathProps{Parameters: pathParameters}}
+	if slice.ContainsString(apiSchema.ResourceMethods, http.MethodGet) {
+		var parameters []*spec3.Parameter
+		summary := "Get a " + apiSchema.ID
+		if len(apiSchema.LinkHandlers) > 0 {
+			parameters = append(parameters, linkParameter(apiSchema.LinkHandlers))
+			summary = "Get a " + apiSchema.ID + ", or one of its links if the link parameter is set"
+		}
+		item.Get = operation(tags, "get-"+apiSchema.ID, summary, parameters, nil, resource)
+	}
+	if slice.ContainsString(apiSchema.ResourceMethods, http.MethodPut) {
+		item.Put = operation(tags, "update-"+apiSchema.ID, "Update a "+apiSchema.ID, nil, requestBody(resource), resource)
+	}
+	if slice.ContainsString(apiSchema.ResourceMethods, http.MethodPatch) {
+		item.Patch = operation(tags, "patch-"+apiSchema.ID, "Patch a "+apiSchema.ID, nil,
+			requestBody(&spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"object"}}}), resource)
+	}
+	if slice.ContainsString(apiSchema.ResourceMethods, http.MethodDelete) {
+		item.Delete = operation(tags, "delete-"+apiSchema.ID, "Delete a "+apiSchema.ID, nil, nil, resource)
+	}
+	if len(apiSchema.ResourceActions) > 0 {
+		item.Post = operation(tags, "action-"+apiSchema.ID, "Run an action on a "+apiSchema.ID,
+			[]*spec3.Parameter{actionParameter(apiSchema.ResourceActions, true)},
+			requestBody(&spec.Schema{SchemaProps: spec.SchemaProps{Type: []string{"object"}}}), nil)
+	}
+	addPath(doc, resourcePath, item)
+}
+
+// addPath adds the path to the document unless it has no operation
+func addPath(doc *spec3.OpenAPI, path string, item *spec3.Path) {
+	if item.Get == nil && item.Post == nil && item.Put == nil && item.Patch == nil && item.Delete == nil {
+		return
+	}
+	doc.Paths.Paths[path] = item
+}
+
+func pluralName(apiSchema *types.APISchema) string {
+	if apiSchema.PluralName != "" {
+		return strings.ToLower(apiSchema.PluralName)
+	}
+	return apiSchema.ID
+}
+
+func operation(tags []string, id, summary string, parameters []*spec3.Parameter, body *spec3.RequestBody, result *spec.Schema) *spec3.Operation {
+	okResponse := &spec3.Response{ResponseProps: spec3.ResponseProps{Description: "OK"}}
+	if result != nil {
+		okResponse.Content = map[string]*spec3.MediaType{
+			jsonMediaType: {MediaTypeProps: spec3.MediaTypeProps{Schema: result}},
+		}
+	}
+	return &spec3.Operation{
+		OperationProps: spec3.OperationProps{
+			Tags:        tags,
+			OperationId: id,
+			Summary:     summary,
+			Parameters:  parameters,
+			RequestBody: body,
+			Responses: &spec3.Responses{
+				ResponsesProps: spec3.ResponsesProps{
+					StatusCodeResponses: map[int]*spec3.Response{
+						http.StatusOK: okResponse,
+					},
+					Default: &spec3.Response{
+						ResponseProps: spec3.ResponseProps{
+							Description: "Error",
+							Content: map[string]*spec3.MediaType{
+								jsonMediaType: {MediaTypeProps: spec3.MediaTypeProps{Schema: componentRef(errorComponent)}},
+							},
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func requestBody(schema *spec.Schema) *spec3.RequestBody {
+	return &spec3.RequestBody{
+		RequestBodyProps: spec3.RequestBodyProps{
+			Required: true,
+			Content: map[string]*spec3.MediaType{
+				jsonMediaType: {MediaTypeProps: spec3.MediaTypeProps{Schema: schema}},
+			},
+		},
+	}
+}
+
+func pathParameter(name string) *spec3.Parameter {
+	return &spec3.Parameter{
+		ParameterProps: spec3.ParameterProps{
+			Name:     name,
+			In:       "path",
+			Required: true,
+			Schema:   spec.StringProperty(),
+		},
+	}
+}
+
+func queryParameter(name, description string, required bool, values []string) *spec3.Parameter {
+	schema := spec.StringProperty()
+	for _, value := range values {
+		schema.Enum = append(schema.Enum, value)
+	}
+	return &spec3.Parameter{
+		ParameterProps: spec3.ParameterProps{
+			Name:        name,
+			In:          "query",
+			Description: description,
+			Required:    required,
+			Schema:      schema,
+		},
+	}
+}
+
+func listQueryParameters() []*spec3.Parameter {
+	result := make([]*spec3.Parameter, 0, len(listParameters))
+	for _, parameter := range listParameters {
+		result = append(result, queryParameter(parameter.name, parameter.description, false, nil))
+	}
+	return result
+}
+
+func actionParameter(actions map[string]schemas.Action, required bool) *spec3.Parameter {
+	return queryParameter("action", "Action to run", required, sortedKeys(actions))
+}
+
+func linkParameter(links map[string]http.Handler) *spec3.Parameter {
+	return queryParameter("link", "Link to follow", false, sortedKeys(links))
+}
+
+func sortedKeys[T any](m map[string]T) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+func componentRef(name string) *spec.Schema {
+	return spec.RefSchema("#/components/schemas/" + name)
+}
+
+func collectionRef(resource *spec.Schema) *spec.Schema {
+	return &spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			AllOf: []spec.Schema{
+				*componentRef(collectionComponent),
+				{
+					SchemaProps: spec.SchemaProps{
+						Type: []string{"object"},
+						Properties: map[string]spec.Schema{
+							"data": *spec.ArrayProperty(resource),
+						},
+					},
+				},
+			},
+		},
+	}
+}
+
+func resourceSchema() *spec.Schema {
+	return &spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			Description: "A resource. Kubernetes resources have their usual fields, as well as the following ones",
+			Type:        []string{"object"},
+			Properties: map[string]spec.Schema{
+				"id":      *spec.StringProperty(),
+				"type":    *spec.StringProperty(),
+				"links":   *spec.MapProperty(spec.StringProperty()),
+				"actions": *spec.MapProperty(spec.StringProperty()),
+			},
+			AdditionalProperties: &spec.SchemaOrBool{Allows: true},
+		},
+	}
+}
+
+func collectionSchema() *spec.Schema {
+	return &spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			Description: "A list of resources",
+			Type:        []string{"object"},
+			Properties: map[string]spec.Schema{
+				"type":         *spec.StringProperty(),
+				"resourceType": *spec.StringProperty(),
+				"revision":     *spec.StringProperty(),
+				"count":        *spec.Int64Property(),
+				"pages":        *spec.Int64Property(),
+				"continue":     *spec.StringProperty(),
+				"links":        *spec.MapProperty(spec.StringProperty()),
+				"actions":      *spec.MapProperty(spec.StringProperty()),
+				"data":         *spec.ArrayProperty(componentRef(resourceComponent)),
+			},
+		},
+	}
+}
+
+func errorSchema() *spec.Schema {
+	return &spec.Schema{
+		SchemaProps: spec.SchemaProps{
+			Type: []string{"object"},
+			Properties: map[string]spec.Schema{
+				"type":      *spec.StringProperty(),
+				"status":    *spec.Int64Property(),
+				"code":      *spec.StringProperty(),
+				"message":   *spec.StringProperty(),
+				"fieldName": *spec.StringProperty(),
+				"details": {
+					SchemaProps: spec.SchemaProps{Type: []string{"object"}},
+				},
+			},
+		},
+	}
+}
+
+// fieldsSchema returns the schema of the resources of a schema which defines its fields
+func fieldsSchema(apiSchemas *types.APISchemas, apiSchema *types.APISchema) *spec.Schema {
+	result := resourceSchema()
+	result.Description = apiSchema.Description
+	result.AdditionalProperties = nil
+	for _, name := range sortedKeys(apiSchema.ResourceFields) {
+		field := apiSchema.ResourceFields[name]
+		fieldSchema := fieldTypeSchema(apiSchemas, field.Type)
+		fieldSchema.Description = field.Description
+		fieldSchema.Default = field.Default
+		fieldSchema.Nullable = field.Nullable
+		fieldSchema.MinLength = field.MinLength
+		fieldSchema.MaxLength = field.MaxLength
+		if field.Min != nil {
+			fieldSchema.Minimum = ptr.To(float64(*field.Min))
+		}
+		if field.Max != nil {
+			fieldSchema.Maximum = ptr.To(float64(*field.Max))
+		}
+		for _, option := range field.Options {
+			fieldSchema.Enum = append(fieldSchema.Enum, option)
+		}
+		result.Properties[name] = *fieldSchema
+		if field.Required {
+			result.Required = append(result.Required, name)
+		}
+	}
+	return result
+}
+
+// fieldTypeSchema returns the schema of a field type of wrangler schemas
+func fieldTypeSchema(apiSchemas *types.APISchemas, fieldType string) *spec.Schema {
+	switch {
+	case strings.HasPrefix(fieldType, "map[") && strings.HasSuffix(fieldType, "]"):
+		return spec.MapProperty(fieldTypeSchema(apiSchemas, fieldType[len("map["):len(fieldType)-1]))
+	case strings.HasPrefix(fieldType, "array[") && strings.HasSuffix(fieldType, "]"):
+		return spec.ArrayProperty(fieldTypeSchema(apiSchemas, fieldType[len("array["):len(fieldType)-1]))
+	case strings.HasPrefix(fieldType, "reference["):
+		return spec.StringProperty()
+	}
+	switch fieldType {
+	case "string", "password", "enum", "hostname", "dnsLabel", "dnsLabelRestricted", "base64":
+		return spec.StringProperty()
+	case "date":
+		return spec.DateTimeProperty()
+	case "int":
+		return spec.Int64Property()
+	case "float":
+		return spec.Float64Property()
+	case "boolean":
+		return spec.BoolProperty()
+	}
+	if schema := apiSchemas.LookupSchema(fieldType); schema != nil && len(schema.ResourceFields) > 0 {
+		return componentRef(schema.ID)
+	}
+	// json and unknown types can be any value
+	return &spec.Schema{}
+}
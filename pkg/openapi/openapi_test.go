@@ -0,0 +1,123 @@
+package openapi
+
+import (
+	"encoding/json"
+	"net/http"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/kube-openapi/pkg/spec3"
+	"k8s.io/utils/ptr"
+)
+
+func testSchemas() *types.APISchemas {
+	apiSchemas := types.EmptyAPISchemas()
+	pod := types.APISchema{
+		Schema: &schemas.Schema{
+			ID:                "pod",
+			PluralName:        "pods",
+			CollectionMethods: []string{http.MethodGet, http.MethodPost},
+			ResourceMethods:   []string{http.MethodGet, http.MethodPut, http.MethodPatch, http.MethodDelete},
+			ResourceActions: map[string]schemas.Action{
+				"diff": {},
+			},
+			Attributes: map[string]interface{}{},
+		},
+		LinkHandlers: map[string]http.Handler{
+			"nodeAdmission": http.NotFoundHandler(),
+			"log":           http.NotFoundHandler(),
+		},
+	}
+	attributes.SetNamespaced(&pod, true)
+	apiSchemas.MustAddSchema(pod)
+	apiSchemas.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID:                "count",
+			PluralName:        "counts",
+			CollectionMethods: []string{http.MethodGet},
+			ResourceFields: map[string]schemas.Field{
+				"id":     {Type: "string", Required: true},
+				"counts": {Type: "map[itemCount]", Description: "counts by type"},
+			},
+		},
+	})
+	apiSchemas.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID: "itemCount",
+			ResourceFields: map[string]schemas.Field{
+				"revision": {Type: "int", Min: ptr.To[int64](0)},
+				"state":    {Type: "enum", Options: []string{"active", "error"}},
+				"data":     {Type: "json"},
+			},
+		},
+	})
+	return apiSchemas
+}
+
+func TestDocument(t *testing.T) {
+	doc := Document(testSchemas())
+
+	assert.Equal(t, "3.0.0", doc.Version)
+	paths := map[string][]string{}
+	for path, item := range doc.Paths.Paths {
+		paths[path] = operations(item)
+	}
+	assert.Equal(t, map[string][]string{
+		"/v1/pods":                    {"get", "post"},
+		"/v1/pods/{namespace}":        {"get"},
+		"/v1/pods/{namespace}/{name}": {"get", "put", "patch", "delete", "post"},
+		"/v1/counts":                  {"get"},
+	}, paths)
+
+	podItem := doc.Paths.Paths["/v1/pods/{namespace}/{name}"]
+	require.Len(t, podItem.Get.Parameters, 1)
+	assert.Equal(t, "link", podItem.Get.Parameters[0].Name)
+	assert.Equal(t, []interface{}{"log", "nodeAdmission"}, podItem.Get.Parameters[0].Schema.Enum)
+	require.Len(t, podItem.Post.Parameters, 1)
+	assert.Equal(t, "action", podItem.Post.Parameters[0].Name)
+	assert.True(t, podItem.Post.Parameters[0].Required)
+	assert.Equal(t, "#/components/schemas/Resource",
+		podItem.Put.Responses.StatusCodeResponses[http.StatusOK].Content[jsonMediaType].Schema.Ref.String())
+
+	require.Contains(t, doc.Components.Schemas, "count")
+	count := doc.Components.Schemas["count"]
+	assert.Equal(t, []string{"id"}, count.Required)
+	assert.Equal(t, "counts by type", count.Properties["counts"].Description)
+	assert.Equal(t, "#/components/schemas/itemCount", count.Properties["counts"].AdditionalProperties.Schema.Ref.String())
+
+	require.Contains(t, doc.Components.Schemas, "itemCount")
+	itemCount := doc.Components.Schemas["itemCount"]
+	assert.Equal(t, []string{"integer"}, []string(itemCount.Properties["revision"].Type))
+	assert.Equal(t, float64(0), *itemCount.Properties["revision"].Minimum)
+	assert.Equal(t, []interface{}{"active", "error"}, itemCount.Properties["state"].Enum)
+	assert.Empty(t, itemCount.Properties["data"].Type)
+
+	data, err := json.Marshal(doc)
+	require.NoError(t, err)
+	var decoded spec3.OpenAPI
+	require.NoError(t, json.Unmarshal(data, &decoded))
+	assert.Len(t, decoded.Paths.Paths, 4)
+}
+
+func operations(item *spec3.Path) []string {
+	var result []string
+	for _, op := range []struct {
+		name      string
+		operation *spec3.Operation
+	}{
+		{"get", item.Get},
+		{"put", item.Put},
+		{"patch", item.Patch},
+		{"delete", item.Delete},
+		{"post", item.Post},
+	} {
+		if op.operation != nil {
+			result = append(result, op.name)
+		}
+	}
+	return result
+}
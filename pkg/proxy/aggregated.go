@@ -0,0 +1,124 @@
+package proxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	remoteUserHeader        = "X-Remote-User"
+	remoteGroupHeader       = "X-Remote-Group"
+	remoteExtraHeaderPrefix = "X-Remote-Extra-"
+)
+
+// AggregatedAPI configures requests for an API group, or a version of it, to be proxied directly to the aggregated
+// API server serving it, rather than through the Kubernetes API server. This allows trusting a different CA than the
+// one of the Kubernetes API server, e.g. when TLS is terminated by a service mesh.
+//
+// Like the Kubernetes API server does, steve authenticates with a client certificate and passes the user in the
+// X-Remote-User, X-Remote-Group and X-Remote-Extra-* headers, so the aggregated API server must be configured to trust
+// the client certificate for request header authentication.
+type AggregatedAPI struct {
+	Group string
+	// Version restricts proxying to a version of the group. All versions are proxied if it's empty
+	Version string
+	// Host is the URL of the aggregated API server
+	Host string
+	// TLSClientConfig holds the CA bundle trusted for the aggregated API server and the client certificate and key
+	// used to authenticate to it
+	TLSClientConfig rest.TLSClientConfig
+}
+
+func (a AggregatedAPI) pathPrefix() string {
+	if a.Version == "" {
+		return "/apis/" + a.Group + "/"
+	}
+	return "/apis/" + a.Group + "/" + a.Version + "/"
+}
+
+// AggregatedHandler returns a handler proxying requests for the given aggregated APIs directly to them, and any other
+// request to next
+func AggregatedHandler(apis []AggregatedAPI, next http.Handler) (http.Handler, error) {
+	type route struct {
+		prefix  string
+		handler http.Handler
+	}
+
+	var routes []route
+	for _, api := range apis {
+		if api.Group == "" {
+			return nil, fmt.Errorf("aggregated API for host %s has no group", api.Host)
+		}
+		if _, err := url.Parse(api.Host); err != nil {
+			return nil, fmt.Errorf("invalid host for aggregated API %s: %w", api.Group, err)
+		}
+		handler, err := Handler("/", &rest.Config{
+			Host:            api.Host,
+			TLSClientConfig: api.TLSClientConfig,
+		})
+		if err != nil {
+			return nil, fmt.Errorf("failed to create proxy for aggregated API %s: %w", api.Group, err)
+		}
+		routes = append(routes, route{
+			prefix:  api.pathPrefix(),
+			handler: requestHeaderAuth(handler),
+		})
+	}
+
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		path := req.URL.Path
+		if !strings.HasSuffix(path, "/") {
+			path += "/"
+		}
+		for _, route := range routes {
+			if strings.HasPrefix(path, route.prefix) {
+				route.handler.ServeHTTP(rw, req)
+				return
+			}
+		}
+		next.ServeHTTP(rw, req)
+	}), nil
+}
+
+// requestHeaderAuth passes the authenticated user in request headers, replacing any sent by the client
+func requestHeaderAuth(handler http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		user, ok := request.UserFrom(req.Context())
+		if !ok || isUnauthenticated(user) {
+			http.Error(rw, "not authorized", http.StatusUnauthorized)
+			return
+		}
+
+		req = req.Clone(req.Context())
+		for k := range req.Header {
+			if strings.HasPrefix(k, "Impersonate-") || strings.HasPrefix(k, "X-Remote-") {
+				delete(req.Header, k)
+			}
+		}
+		req.Header.Set(remoteUserHeader, user.GetName())
+		for _, group := range user.GetGroups() {
+			req.Header.Add(remoteGroupHeader, group)
+		}
+		for key, values := range user.GetExtra() {
+			for _, value := range values {
+				req.Header.Add(remoteExtraHeaderPrefix+url.PathEscape(key), value)
+			}
+		}
+		handler.ServeHTTP(rw, req)
+	})
+}
+
+func isUnauthenticated(user user.Info) bool {
+	for _, group := range user.GetGroups() {
+		if group == "system:unauthenticated" {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,122 @@
+package proxy
+
+import (
+	"encoding/pem"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/rest"
+)
+
+func TestAggregatedHandler(t *testing.T) {
+	var received *http.Request
+	aggregated := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		received = req
+		rw.Write([]byte("aggregated"))
+	}))
+	defer aggregated.Close()
+	caData := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: aggregated.Certificate().Raw})
+
+	next := http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("next"))
+	})
+	handler, err := AggregatedHandler([]AggregatedAPI{
+		{
+			Group:           "metrics.k8s.io",
+			Version:         "v1beta1",
+			Host:            aggregated.URL,
+			TLSClientConfig: rest.TLSClientConfig{CAData: caData},
+		},
+	}, next)
+	require.NoError(t, err)
+
+	tests := []struct {
+		name         string
+		path         string
+		user         user.Info
+		expectedCode int
+		expectedBody string
+	}{
+		{
+			name:         "aggregated API",
+			path:         "/apis/metrics.k8s.io/v1beta1/nodes",
+			user:         &user.DefaultInfo{Name: "alice", Groups: []string{"devs", "system:authenticated"}, Extra: map[string][]string{"scope": {"a"}}},
+			expectedCode: http.StatusOK,
+			expectedBody: "aggregated",
+		},
+		{
+			name:         "other version of the aggregated API",
+			path:         "/apis/metrics.k8s.io/v1",
+			user:         &user.DefaultInfo{Name: "alice"},
+			expectedCode: http.StatusOK,
+			expectedBody: "next",
+		},
+		{
+			name:         "group with the aggregated API's group as prefix",
+			path:         "/apis/metrics.k8s.io.example.com/v1beta1/nodes",
+			user:         &user.DefaultInfo{Name: "alice"},
+			expectedCode: http.StatusOK,
+			expectedBody: "next",
+		},
+		{
+			name:         "unauthenticated",
+			path:         "/apis/metrics.k8s.io/v1beta1/nodes",
+			user:         &user.DefaultInfo{Name: "system:anonymous", Groups: []string{"system:unauthenticated"}},
+			expectedCode: http.StatusUnauthorized,
+			expectedBody: "not authorized\n",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			received = nil
+			req := httptest.NewRequest(http.MethodGet, test.path, nil)
+			req.Header.Set("X-Remote-User", "admin")
+			req.Header.Set("Impersonate-User", "admin")
+			req = req.WithContext(request.WithUser(req.Context(), test.user))
+			rw := httptest.NewRecorder()
+
+			handler.ServeHTTP(rw, req)
+
+			assert.Equal(t, test.expectedCode, rw.Code)
+			body, _ := io.ReadAll(rw.Body)
+			assert.Equal(t, test.expectedBody, string(body))
+			if test.expectedBody != "aggregated" {
+				assert.Nil(t, received)
+				return
+			}
+			require.NotNil(t, received)
+			assert.Equal(t, test.path, received.URL.Path)
+			assert.Equal(t, "alice", received.Header.Get("X-Remote-User"))
+			assert.Equal(t, []string{"devs", "system:authenticated"}, received.Header.Values("X-Remote-Group"))
+			assert.Equal(t, "a", received.Header.Get("X-Remote-Extra-Scope"))
+			assert.Empty(t, received.Header.Get("Impersonate-User"))
+		})
+	}
+}
+
+func TestAggregatedHandlerUntrustedCA(t *testing.T) {
+	aggregated := httptest.NewTLSServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rw.Write([]byte("aggregated"))
+	}))
+	defer aggregated.Close()
+
+	handler, err := AggregatedHandler([]AggregatedAPI{{Group: "metrics.k8s.io", Host: aggregated.URL}}, http.NotFoundHandler())
+	require.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/apis/metrics.k8s.io/v1beta1/nodes", nil)
+	req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: "alice"}))
+	rw := httptest.NewRecorder()
+	handler.ServeHTTP(rw, req)
+	assert.Equal(t, http.StatusInternalServerError, rw.Code)
+}
+
+func TestAggregatedHandlerNoGroup(t *testing.T) {
+	_, err := AggregatedHandler([]AggregatedAPI{{Host: "https://localhost"}}, http.NotFoundHandler())
+	assert.Error(t, err)
+}
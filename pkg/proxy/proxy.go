@@ -19,6 +19,15 @@ func ImpersonatingHandler(prefix string, cfg *rest.Config) http.Handler {
 	})
 }
 
+// UserHandler returns a handler proxying requests to the Kubernetes API as the user of the request when impersonate is
+// set, or with the credentials of cfg otherwise
+func UserHandler(prefix string, cfg *rest.Config, impersonate bool) (http.Handler, error) {
+	if impersonate {
+		return ImpersonatingHandler(prefix, cfg), nil
+	}
+	return Handler(prefix, cfg)
+}
+
 func setupUserAuth(req *http.Request, user user.Info, cfg *rest.Config) (*rest.Config, bool) {
 	authed := true
 	for _, group := range user.GetGroups() {
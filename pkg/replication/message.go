@@ -0,0 +1,23 @@
+package replication
+
+const (
+	snapshotMessage = "snapshot"
+	addedMessage    = "added"
+	modifiedMessage = "modified"
+	deletedMessage  = "deleted"
+
+	// SourceLabel is the label of replicated objects naming the source they were replicated from
+	SourceLabel = "replication.cattle.io/source"
+)
+
+// Message is a message of the replication websocket: a snapshot of all the objects of a type, sent for each type
+// when the websocket connects, or the event of an object
+type Message struct {
+	Type       string `json:"type"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	// Objects are the objects of a snapshot
+	Objects []map[string]interface{} `json:"objects,omitempty"`
+	// Object is the object of an event
+	Object map[string]interface{} `json:"object,omitempty"`
+}
@@ -0,0 +1,240 @@
+package replication
+
+import (
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"net/http"
+	"net/url"
+	"strings"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/sirupsen/logrus"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+)
+
+const handshakeTimeout = 10 * time.Second
+
+// Source is a Steve whose objects are mirrored
+type Source struct {
+	// Name names the source in the names and the source label of the objects mirrored from it
+	Name string
+	// URL is the URL of the replication schema of the source, such as https://cluster.example.com/v1/replications
+	URL string
+	// Token is the bearer token the source is connected to with
+	Token string
+	// Dialer dials the connections to the source, such as those through the tunnel of the aggregation server, the
+	// connections being dialed directly if it is nil
+	Dialer func(ctx context.Context, network, addr string) (net.Conn, error)
+	// TLSConfig is the TLS configuration of the connections, the default one if it is nil
+	TLSConfig *tls.Config
+	// Types are the types mirrored from the source
+	Types []k8sschema.GroupVersionKind
+}
+
+// Mirror mirrors the objects of sources into replicas, which the SQL cache lists and watches in place of the API
+// server for the mirrored types
+type Mirror struct {
+	sources  []Source
+	replicas map[k8sschema.GroupVersionKind]*replica
+}
+
+// NewMirror returns a mirror of sources, whose names must be unique and can't contain colons, since they prefix the
+// names of the objects mirrored from them
+func NewMirror(sources []Source) (*Mirror, error) {
+	m := &Mirror{
+		sources:  sources,
+		replicas: map[k8sschema.GroupVersionKind]*replica{},
+	}
+	names := map[string]bool{}
+	for _, source := range sources {
+		if source.Name == "" || strings.ContainsAny(source.Name, ":/") {
+			return nil, fmt.Errorf("invalid replication source name [%s]", source.Name)
+		}
+		if names[source.Name] {
+			return nil, fmt.Errorf("duplicate replication source [%s]", source.Name)
+		}
+		names[source.Name] = true
+		if _, err := url.Parse(source.URL); err != nil || source.URL == "" {
+			return nil, fmt.Errorf("invalid URL of replication source [%s]: %v", source.Name, err)
+		}
+		if len(source.Types) == 0 {
+			return nil, fmt.Errorf("replication source [%s] has no types", source.Name)
+		}
+		for _, gvk := range source.Types {
+			if m.replicas[gvk] == nil {
+				m.replicas[gvk] = newReplica(gvk)
+			}
+		}
+	}
+	return m, nil
+}
+
+// Types returns the mirrored types
+func (m *Mirror) Types() []k8sschema.GroupVersionKind {
+	result := make([]k8sschema.GroupVersionKind, 0, len(m.replicas))
+	for gvk := range m.replicas {
+		result = append(result, gvk)
+	}
+	return result
+}
+
+// Client returns the client listing and watching the replica of a type, if it is mirrored
+func (m *Mirror) Client(gvk k8sschema.GroupVersionKind) (dynamic.ResourceInterface, bool) {
+	r, ok := m.replicas[gvk]
+	if !ok {
+		return nil, false
+	}
+	return &replicaClient{replica: r}, true
+}
+
+// Run mirrors the sources until ctx is done, reconnecting to them with backoff
+func (m *Mirror) Run(ctx context.Context) {
+	for _, source := range m.sources {
+		go m.run(ctx, source)
+	}
+}
+
+func newBackoff() wait.Backoff {
+	return wait.Backoff{
+		Duration: time.Second,
+		Factor:   2,
+		Jitter:   0.5,
+		Steps:    math.MaxInt32,
+		Cap:      2 * time.Minute,
+	}
+}
+
+func (m *Mirror) run(ctx context.Context, source Source) {
+	backoff := newBackoff()
+	for {
+		synced := false
+		err := m.mirror(ctx, source, func() {
+			synced = true
+		})
+		if ctx.Err() != nil {
+			return
+		}
+		if synced {
+			backoff = newBackoff()
+		}
+		delay := backoff.Step()
+		logrus.Errorf("Failed to mirror replication source [%s], retrying in %s: %v", source.Name, delay, err)
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(delay):
+		}
+	}
+}
+
+// mirror connects to a source and applies its messages until the connection closes, calling onSynced once the
+// snapshots of all its types are applied
+func (m *Mirror) mirror(ctx context.Context, source Source, onSynced func()) error {
+	u, err := url.Parse(source.URL)
+	if err != nil {
+		return err
+	}
+	switch u.Scheme {
+	case "http":
+		u.Scheme = "ws"
+	case "https":
+		u.Scheme = "wss"
+	}
+	query := u.Query()
+	for _, gvk := range source.Types {
+		query.Add(gvkParam, FormatGVK(gvk))
+	}
+	u.RawQuery = query.Encode()
+
+	dialer := websocket.Dialer{
+		Proxy:             http.ProxyFromEnvironment,
+		HandshakeTimeout:  handshakeTimeout,
+		NetDialContext:    source.Dialer,
+		TLSClientConfig:   source.TLSConfig,
+		EnableCompression: true,
+	}
+	headers := http.Header{}
+	if source.Token != "" {
+		headers.Set("Authorization", "Bearer "+source.Token)
+	}
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+	conn, resp, err := dialer.DialContext(ctx, u.String(), headers)
+	if err != nil {
+		if resp != nil {
+			return fmt.Errorf("%w: %s", err, resp.Status)
+		}
+		return err
+	}
+	defer conn.Close()
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+
+	pending := map[k8sschema.GroupVersionKind]bool{}
+	for _, gvk := range source.Types {
+		pending[gvk] = true
+	}
+	for {
+		var message Message
+		if err := conn.ReadJSON(&message); err != nil {
+			if websocket.IsCloseError(err, websocket.CloseGoingAway, websocket.CloseTryAgainLater) {
+				return fmt.Errorf("source closed the connection: %w", err)
+			}
+			return err
+		}
+		gvk := k8sschema.FromAPIVersionAndKind(message.APIVersion, message.Kind)
+		r, ok := m.replicas[gvk]
+		if !ok {
+			return fmt.Errorf("unexpected type [%s]", FormatGVK(gvk))
+		}
+		switch message.Type {
+		case snapshotMessage:
+			r.sync(source.Name, message.Objects)
+			delete(pending, gvk)
+			if len(pending) == 0 && onSynced != nil {
+				onSynced()
+				onSynced = nil
+			}
+		case addedMessage, modifiedMessage, deletedMessage:
+			if message.Object == nil {
+				return errors.New("event without an object")
+			}
+			r.apply(source.Name, message.Type, message.Object)
+		default:
+			return fmt.Errorf("unexpected message [%s]", message.Type)
+		}
+	}
+}
+
+// Templates makes the schemas of the mirrored types read-only, their objects only being listed from the replicas
+func (m *Mirror) Templates() []schema.Template {
+	var result []schema.Template
+	for _, gvk := range m.Types() {
+		gvk := gvk
+		result = append(result, schema.Template{
+			Group: gvk.Group,
+			Kind:  gvk.Kind,
+			Customize: func(apiSchema *types.APISchema) {
+				if attributes.GVK(apiSchema) != gvk {
+					return
+				}
+				apiSchema.CollectionMethods = []string{http.MethodGet}
+				apiSchema.ResourceMethods = nil
+			},
+		})
+	}
+	return result
+}
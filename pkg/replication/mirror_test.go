@@ -0,0 +1,81 @@
+package replication
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestMirror(t *testing.T) {
+	ccache := &fakeClusterCache{
+		objects: map[k8sschema.GroupVersionKind][]interface{}{
+			deploymentGVK: {&unstructured.Unstructured{Object: deployment("a", 1)}},
+		},
+		registered: make(chan struct{}),
+	}
+	var token string
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		token = req.Header.Get("Authorization")
+		assert.Equal(t, []string{"apps/v1/Deployment"}, req.URL.Query()[gvkParam])
+		err := handler(&types.APIRequest{Request: req, Response: rw}, ccache, []k8sschema.GroupVersionKind{deploymentGVK})
+		assert.NoError(t, err)
+	}))
+	defer server.Close()
+
+	m, err := NewMirror([]Source{{
+		Name:  "east",
+		URL:   server.URL + "/v1/replications",
+		Token: "secret",
+		Types: []k8sschema.GroupVersionKind{deploymentGVK},
+	}})
+	require.NoError(t, err)
+	client, ok := m.Client(deploymentGVK)
+	require.True(t, ok)
+	_, ok = m.Client(podGVK)
+	assert.False(t, ok)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	synced := make(chan struct{})
+	go func() {
+		_ = m.mirror(ctx, m.sources[0], func() { close(synced) })
+	}()
+	<-synced
+	<-ccache.registered
+	assert.Equal(t, "Bearer secret", token)
+
+	listNames := func() []string {
+		list, err := client.List(ctx, metav1.ListOptions{})
+		require.NoError(t, err)
+		return names(list)
+	}
+	assert.Equal(t, []string{"east:a"}, listNames())
+
+	ccache.call(ccache.added, deploymentGVK, &unstructured.Unstructured{Object: deployment("b", 1)})
+	ccache.call(ccache.removed, deploymentGVK, &unstructured.Unstructured{Object: deployment("a", 1)})
+	assert.Eventually(t, func() bool {
+		return assert.ObjectsAreEqual([]string{"east:b"}, listNames())
+	}, 5*time.Second, 10*time.Millisecond)
+}
+
+func TestNewMirror(t *testing.T) {
+	types := []k8sschema.GroupVersionKind{deploymentGVK}
+	_, err := NewMirror([]Source{{Name: "east:1", URL: "https://east", Types: types}})
+	assert.Error(t, err)
+	_, err = NewMirror([]Source{{Name: "east", URL: "https://east"}})
+	assert.Error(t, err)
+	_, err = NewMirror([]Source{{Name: "east", URL: "https://east", Types: types}, {Name: "east", URL: "https://west", Types: types}})
+	assert.Error(t, err)
+	m, err := NewMirror([]Source{{Name: "east", URL: "https://east", Types: types}, {Name: "west", URL: "https://west", Types: types}})
+	require.NoError(t, err)
+	assert.Equal(t, types, m.Types())
+}
@@ -0,0 +1,234 @@
+package replication
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"sync"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/dynamic"
+)
+
+// historySize is how many events of a replica are kept for watches to resume from, older resource versions being
+// too old for them
+const historySize = 1000
+
+// replica holds the objects of a kind replicated from all sources, keyed by source, namespace and name, under resource
+// versions of its own, so that informers can list and watch them like the objects of an API server
+type replica struct {
+	gvk schema.GroupVersionKind
+
+	lock            sync.RWMutex
+	objects         map[string]*unstructured.Unstructured
+	resourceVersion int64
+	// history holds the latest events, in the order of their resource versions
+	history  []replicaEvent
+	watchers map[*replicaWatch]bool
+}
+
+type replicaEvent struct {
+	resourceVersion int64
+	event           watch.Event
+}
+
+func newReplica(gvk schema.GroupVersionKind) *replica {
+	return &replica{
+		gvk:      gvk,
+		objects:  map[string]*unstructured.Unstructured{},
+		watchers: map[*replicaWatch]bool{},
+	}
+}
+
+func objectKey(source string, obj *unstructured.Unstructured) string {
+	return source + "/" + obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// replicate returns a copy of an object of a source as it is replicated: named {source}:{name}, so that the objects
+// of different sources don't collide, with the source label, and without managed fields
+func replicate(source string, obj map[string]interface{}) *unstructured.Unstructured {
+	result := (&unstructured.Unstructured{Object: obj}).DeepCopy()
+	result.SetName(source + ":" + result.GetName())
+	labels := result.GetLabels()
+	if labels == nil {
+		labels = map[string]string{}
+	}
+	labels[SourceLabel] = source
+	result.SetLabels(labels)
+	result.SetManagedFields(nil)
+	return result
+}
+
+// sync replaces the objects of a source with those of a snapshot
+func (r *replica) sync(source string, objects []map[string]interface{}) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	keys := map[string]bool{}
+	for _, obj := range objects {
+		replicated := replicate(source, obj)
+		key := objectKey(source, replicated)
+		keys[key] = true
+		r.put(key, replicated)
+	}
+	prefix := source + "/"
+	for key, obj := range r.objects {
+		if len(key) > len(prefix) && key[:len(prefix)] == prefix && !keys[key] {
+			r.remove(key, obj)
+		}
+	}
+}
+
+// apply applies an event of a source
+func (r *replica) apply(source string, eventType string, obj map[string]interface{}) {
+	replicated := replicate(source, obj)
+	key := objectKey(source, replicated)
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	if eventType == deletedMessage {
+		if existing, ok := r.objects[key]; ok {
+			r.remove(key, existing)
+		}
+		return
+	}
+	r.put(key, replicated)
+}
+
+func (r *replica) put(key string, obj *unstructured.Unstructured) {
+	eventType := watch.Added
+	if _, ok := r.objects[key]; ok {
+		eventType = watch.Modified
+	}
+	r.resourceVersion++
+	obj.SetResourceVersion(strconv.FormatInt(r.resourceVersion, 10))
+	r.objects[key] = obj
+	r.notify(watch.Event{Type: eventType, Object: obj.DeepCopy()})
+}
+
+func (r *replica) remove(key string, obj *unstructured.Unstructured) {
+	r.resourceVersion++
+	delete(r.objects, key)
+	deleted := obj.DeepCopy()
+	deleted.SetResourceVersion(strconv.FormatInt(r.resourceVersion, 10))
+	r.notify(watch.Event{Type: watch.Deleted, Object: deleted})
+}
+
+func (r *replica) notify(event watch.Event) {
+	r.history = append(r.history, replicaEvent{resourceVersion: r.resourceVersion, event: event})
+	if len(r.history) > historySize {
+		r.history = r.history[len(r.history)-historySize:]
+	}
+	for w := range r.watchers {
+		w.send(event)
+	}
+}
+
+// list returns the objects of all sources, sorted by key, at the current resource version
+func (r *replica) list() *unstructured.UnstructuredList {
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	keys := make([]string, 0, len(r.objects))
+	for key := range r.objects {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	list := &unstructured.UnstructuredList{}
+	list.SetAPIVersion(r.gvk.GroupVersion().String())
+	list.SetKind(r.gvk.Kind + "List")
+	list.SetResourceVersion(strconv.FormatInt(r.resourceVersion, 10))
+	for _, key := range keys {
+		list.Items = append(list.Items, *r.objects[key].DeepCopy())
+	}
+	return list
+}
+
+// watch returns a watch of the events after a resource version, the current one if it is empty. Watches from
+// resource versions older than the history get an expired error, so that informers list the objects again.
+func (r *replica) watch(resourceVersion string) (watch.Interface, error) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	from := r.resourceVersion
+	if resourceVersion != "" && resourceVersion != "0" {
+		rv, err := strconv.ParseInt(resourceVersion, 10, 64)
+		if err != nil {
+			return nil, apierrors.NewBadRequest(fmt.Sprintf("invalid resource version [%s]", resourceVersion))
+		}
+		from = rv
+	}
+
+	w := &replicaWatch{
+		replica: r,
+		result:  make(chan watch.Event, historySize),
+		done:    make(chan struct{}),
+	}
+	if from < r.resourceVersion {
+		if len(r.history) == 0 || r.history[0].resourceVersion > from+1 {
+			w.result <- watch.Event{
+				Type:   watch.Error,
+				Object: &apierrors.NewResourceExpired(fmt.Sprintf("too old resource version: %d", from)).ErrStatus,
+			}
+			close(w.result)
+			return w, nil
+		}
+		for _, event := range r.history {
+			if event.resourceVersion > from {
+				w.result <- event.event
+			}
+		}
+	}
+	r.watchers[w] = true
+	return w, nil
+}
+
+// replicaWatch is a watch of a replica, stopped if it falls behind its events by a whole history, so that its
+// informer lists the objects again
+type replicaWatch struct {
+	replica  *replica
+	result   chan watch.Event
+	done     chan struct{}
+	stopOnce sync.Once
+}
+
+// send is called with the lock of the replica held
+func (w *replicaWatch) send(event watch.Event) {
+	select {
+	case w.result <- event:
+	default:
+		delete(w.replica.watchers, w)
+		close(w.result)
+	}
+}
+
+func (w *replicaWatch) ResultChan() <-chan watch.Event {
+	return w.result
+}
+
+func (w *replicaWatch) Stop() {
+	w.stopOnce.Do(func() {
+		w.replica.lock.Lock()
+		defer w.replica.lock.Unlock()
+		if w.replica.watchers[w] {
+			delete(w.replica.watchers, w)
+			close(w.result)
+		}
+	})
+}
+
+// replicaClient lists and watches a replica, which is all informers do with their clients. Replicas are read-only,
+// other methods are those of the nil embedded interface.
+type replicaClient struct {
+	dynamic.ResourceInterface
+	replica *replica
+}
+
+func (c *replicaClient) List(_ context.Context, _ metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	return c.replica.list(), nil
+}
+
+func (c *replicaClient) Watch(_ context.Context, opts metav1.ListOptions) (watch.Interface, error) {
+	return c.replica.watch(opts.ResourceVersion)
+}
@@ -0,0 +1,104 @@
+package replication
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+var deploymentGVK = k8sschema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+
+func deployment(name string, generation int64) map[string]interface{} {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("apps/v1")
+	obj.SetKind("Deployment")
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	obj.SetGeneration(generation)
+	obj.SetResourceVersion("100")
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl"}})
+	return obj.Object
+}
+
+func names(list *unstructured.UnstructuredList) []string {
+	var result []string
+	for _, item := range list.Items {
+		result = append(result, item.GetName())
+	}
+	return result
+}
+
+func TestReplicaSync(t *testing.T) {
+	r := newReplica(deploymentGVK)
+	r.sync("east", []map[string]interface{}{deployment("a", 1), deployment("b", 1)})
+	r.sync("west", []map[string]interface{}{deployment("a", 1)})
+
+	list := r.list()
+	assert.Equal(t, []string{"east:a", "east:b", "west:a"}, names(list))
+	assert.Equal(t, "3", list.GetResourceVersion())
+	assert.Equal(t, "east", list.Items[0].GetLabels()[SourceLabel])
+	assert.Empty(t, list.Items[0].GetManagedFields())
+	assert.Equal(t, "1", list.Items[0].GetResourceVersion())
+
+	// the objects of a source missing from its snapshot are deleted
+	r.sync("east", []map[string]interface{}{deployment("b", 2)})
+	assert.Equal(t, []string{"east:b", "west:a"}, names(r.list()))
+
+	r.apply("west", addedMessage, deployment("c", 1))
+	r.apply("west", deletedMessage, deployment("a", 1))
+	r.apply("west", deletedMessage, deployment("missing", 1))
+	assert.Equal(t, []string{"east:b", "west:c"}, names(r.list()))
+}
+
+func TestReplicaWatch(t *testing.T) {
+	r := newReplica(deploymentGVK)
+	r.sync("east", []map[string]interface{}{deployment("a", 1)})
+	client := &replicaClient{replica: r}
+
+	list, err := client.List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	r.apply("east", modifiedMessage, deployment("a", 2))
+
+	// events after the resource version of the list are replayed, then the following ones are sent
+	w, err := client.Watch(context.Background(), metav1.ListOptions{ResourceVersion: list.GetResourceVersion()})
+	require.NoError(t, err)
+	defer w.Stop()
+	event := <-w.ResultChan()
+	assert.Equal(t, watch.Modified, event.Type)
+	assert.Equal(t, int64(2), event.Object.(*unstructured.Unstructured).GetGeneration())
+
+	r.apply("east", deletedMessage, deployment("a", 2))
+	event = <-w.ResultChan()
+	assert.Equal(t, watch.Deleted, event.Type)
+	assert.Equal(t, "east:a", event.Object.(*unstructured.Unstructured).GetName())
+	assert.Equal(t, "3", event.Object.(*unstructured.Unstructured).GetResourceVersion())
+
+	w.Stop()
+	_, ok := <-w.ResultChan()
+	assert.False(t, ok)
+}
+
+func TestReplicaWatchExpired(t *testing.T) {
+	r := newReplica(deploymentGVK)
+	for i := 0; i < historySize+2; i++ {
+		r.apply("east", modifiedMessage, deployment("a", int64(i)))
+	}
+
+	w, err := r.watch("1")
+	require.NoError(t, err)
+	event := <-w.ResultChan()
+	require.Equal(t, watch.Error, event.Type)
+	assert.Equal(t, int32(http.StatusGone), event.Object.(*metav1.Status).Code)
+	_, ok := <-w.ResultChan()
+	assert.False(t, ok)
+
+	_, err = r.watch("invalid")
+	assert.Error(t, err)
+}
@@ -0,0 +1,254 @@
+// Package replication streams the objects of selected types from the cluster cache of a Steve to other Steves, which
+// mirror them into their SQL cache, so that a central Steve can serve a read-only inventory of many clusters.
+package replication
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/clustercache"
+	"github.com/rancher/steve/pkg/drain"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// gvkParam selects the types to replicate, as group/version/kind, or version/kind for the core group
+	gvkParam = "gvk"
+	// queueSize is how many events are queued for a websocket while they are written to it. The websocket is closed
+	// once it is exceeded, its client then reconnecting and getting snapshots again.
+	queueSize = 1000
+	// closeTimeout is how long sending the close frame may take
+	closeTimeout = 5 * time.Second
+)
+
+// pingInterval is how often pings are sent on the websockets, which also keeps the connections of idle types open
+var pingInterval = 30 * time.Second
+
+var upgrader = websocket.Upgrader{
+	HandshakeTimeout:  60 * time.Second,
+	EnableCompression: true,
+}
+
+// ParseGVK parses a type as group/version/kind, or version/kind for the core group
+func ParseGVK(s string) (k8sschema.GroupVersionKind, error) {
+	parts := strings.Split(s, "/")
+	switch {
+	case len(parts) == 2 && parts[0] != "" && parts[1] != "":
+		return k8sschema.GroupVersionKind{Version: parts[0], Kind: parts[1]}, nil
+	case len(parts) == 3 && parts[0] != "" && parts[1] != "" && parts[2] != "":
+		return k8sschema.GroupVersionKind{Group: parts[0], Version: parts[1], Kind: parts[2]}, nil
+	}
+	return k8sschema.GroupVersionKind{}, fmt.Errorf("invalid type [%s], expected group/version/kind or version/kind", s)
+}
+
+// FormatGVK formats a type as ParseGVK parses it
+func FormatGVK(gvk k8sschema.GroupVersionKind) string {
+	if gvk.Group == "" {
+		return gvk.Version + "/" + gvk.Kind
+	}
+	return gvk.Group + "/" + gvk.Version + "/" + gvk.Kind
+}
+
+// Register registers the replication schema, whose websocket streams a snapshot of the objects of each of the
+// requested types in the cluster cache, then their events. Only the types allowed can be replicated, all of them if
+// none are requested, and only by the users who can list and watch them in all namespaces.
+func Register(baseSchema *types.APISchemas, ccache clustercache.ClusterCache, allowed []k8sschema.GroupVersionKind) {
+	baseSchema.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID:                "replication",
+			PluralName:        "replications",
+			CollectionMethods: []string{"GET"},
+		},
+		ListHandler: func(apiOp *types.APIRequest) (types.APIObjectList, error) {
+			gvks, err := requestedTypes(apiOp, allowed)
+			if err != nil {
+				return types.APIObjectList{}, err
+			}
+			if err := handler(apiOp, ccache, gvks); err != nil {
+				logrus.Errorf("Error during replication: %v", err)
+			}
+			return types.APIObjectList{}, validation.ErrComplete
+		},
+	})
+}
+
+// requestedTypes returns the types requested among the allowed ones, checking the user can replicate them
+func requestedTypes(apiOp *types.APIRequest, allowed []k8sschema.GroupVersionKind) ([]k8sschema.GroupVersionKind, error) {
+	isAllowed := map[k8sschema.GroupVersionKind]bool{}
+	for _, gvk := range allowed {
+		isAllowed[gvk] = true
+	}
+
+	gvks := allowed
+	if requested := apiOp.Request.URL.Query()[gvkParam]; len(requested) > 0 {
+		gvks = nil
+		for _, s := range requested {
+			gvk, err := ParseGVK(s)
+			if err != nil {
+				return nil, apierror.NewAPIError(validation.InvalidFormat, err.Error())
+			}
+			if !isAllowed[gvk] {
+				return nil, apierror.NewAPIError(validation.PermissionDenied, fmt.Sprintf("type [%s] is not replicated", s))
+			}
+			gvks = append(gvks, gvk)
+		}
+	}
+	if len(gvks) == 0 {
+		return nil, apierror.NewAPIError(validation.InvalidOption, "no types are replicated")
+	}
+
+	for _, gvk := range gvks {
+		apiSchema := lookupSchema(apiOp.Schemas, gvk)
+		if apiSchema == nil {
+			return nil, apierror.NewAPIError(validation.NotFound, fmt.Sprintf("type [%s] is not served", FormatGVK(gvk)))
+		}
+		access := accesscontrol.GetAccessListMap(apiSchema)
+		if !access.All("list") || !access.All("watch") {
+			return nil, apierror.NewAPIError(validation.PermissionDenied, fmt.Sprintf("can not list and watch [%s] in all namespaces", FormatGVK(gvk)))
+		}
+	}
+	return gvks, nil
+}
+
+func lookupSchema(apiSchemas *types.APISchemas, gvk k8sschema.GroupVersionKind) *types.APISchema {
+	if apiSchemas == nil {
+		return nil
+	}
+	for _, apiSchema := range apiSchemas.Schemas {
+		if attributes.GVK(apiSchema) == gvk {
+			return apiSchema
+		}
+	}
+	return nil
+}
+
+func handler(apiOp *types.APIRequest, ccache clustercache.ClusterCache, gvks []k8sschema.GroupVersionKind) error {
+	c, err := upgrader.Upgrade(apiOp.Response, apiOp.Request, nil)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	ctx, cancel := context.WithCancel(apiOp.Context())
+	defer cancel()
+
+	// the handlers are registered before the snapshots are taken, so that no event is missed between them. Events
+	// queued before a snapshot are applied again by the mirror, which is harmless.
+	queue := make(chan Message, queueSize)
+	overflow := make(chan struct{})
+	var overflowOnce sync.Once
+	replicated := map[k8sschema.GroupVersionKind]bool{}
+	for _, gvk := range gvks {
+		replicated[gvk] = true
+	}
+	enqueue := func(messageType string, gvk k8sschema.GroupVersionKind, obj runtime.Object) error {
+		if !replicated[gvk] {
+			return nil
+		}
+		message, ok := newMessage(messageType, gvk, obj)
+		if !ok {
+			return nil
+		}
+		// the handlers are called by the worker of the cluster cache, which must not block on slow websockets
+		select {
+		case queue <- message:
+		default:
+			overflowOnce.Do(func() { close(overflow) })
+		}
+		return nil
+	}
+	ccache.OnAdd(ctx, func(gvk k8sschema.GroupVersionKind, _ string, obj runtime.Object) error {
+		return enqueue(addedMessage, gvk, obj)
+	})
+	ccache.OnChange(ctx, func(gvk k8sschema.GroupVersionKind, _ string, obj, _ runtime.Object) error {
+		return enqueue(modifiedMessage, gvk, obj)
+	})
+	ccache.OnRemove(ctx, func(gvk k8sschema.GroupVersionKind, _ string, obj runtime.Object) error {
+		return enqueue(deletedMessage, gvk, obj)
+	})
+
+	for _, gvk := range gvks {
+		if err := c.WriteJSON(snapshot(ccache, gvk)); err != nil {
+			return err
+		}
+	}
+
+	// the client only sends the close frame, which is read to notice it
+	closed := make(chan struct{})
+	go func() {
+		defer close(closed)
+		for {
+			if _, _, err := c.NextReader(); err != nil {
+				return
+			}
+		}
+	}()
+
+	t := time.NewTicker(pingInterval)
+	defer t.Stop()
+	for {
+		select {
+		case message := <-queue:
+			if err := c.WriteJSON(message); err != nil {
+				return err
+			}
+		case <-overflow:
+			return c.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "replication fell behind"),
+				time.Now().Add(closeTimeout))
+		case <-t.C:
+			if err := c.WriteControl(websocket.PingMessage, nil, time.Now().Add(closeTimeout)); err != nil {
+				return err
+			}
+		case <-closed:
+			return nil
+		case <-drain.Draining(apiOp.Context()):
+			return c.WriteControl(websocket.CloseMessage,
+				websocket.FormatCloseMessage(websocket.CloseGoingAway, ""),
+				time.Now().Add(closeTimeout))
+		case <-ctx.Done():
+			return nil
+		}
+	}
+}
+
+func snapshot(ccache clustercache.ClusterCache, gvk k8sschema.GroupVersionKind) Message {
+	message := Message{
+		Type:       snapshotMessage,
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Objects:    []map[string]interface{}{},
+	}
+	for _, obj := range ccache.List(gvk) {
+		if u, ok := obj.(*unstructured.Unstructured); ok {
+			message.Objects = append(message.Objects, u.Object)
+		}
+	}
+	return message
+}
+
+func newMessage(messageType string, gvk k8sschema.GroupVersionKind, obj runtime.Object) (Message, bool) {
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return Message{}, false
+	}
+	return Message{
+		Type:       messageType,
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+		Object:     u.DeepCopy().Object,
+	}, true
+}
@@ -0,0 +1,119 @@
+package replication
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/clustercache"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var podGVK = k8sschema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+
+type fakeClusterCache struct {
+	clustercache.ClusterCache
+
+	lock    sync.Mutex
+	objects map[k8sschema.GroupVersionKind][]interface{}
+	added   []clustercache.Handler
+	removed []clustercache.Handler
+	// registered is closed once the handlers are registered
+	registered chan struct{}
+}
+
+func (f *fakeClusterCache) List(gvk k8sschema.GroupVersionKind) []interface{} {
+	return f.objects[gvk]
+}
+
+func (f *fakeClusterCache) OnAdd(_ context.Context, handler clustercache.Handler) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.added = append(f.added, handler)
+}
+
+func (f *fakeClusterCache) OnChange(context.Context, clustercache.ChangeHandler) {}
+
+func (f *fakeClusterCache) OnRemove(_ context.Context, handler clustercache.Handler) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	f.removed = append(f.removed, handler)
+	close(f.registered)
+}
+
+func (f *fakeClusterCache) call(handlers []clustercache.Handler, gvk k8sschema.GroupVersionKind, obj runtime.Object) {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	for _, handler := range handlers {
+		_ = handler(gvk, "", obj)
+	}
+}
+
+func TestParseGVK(t *testing.T) {
+	for _, s := range []string{"v1/Pod", "apps/v1/Deployment"} {
+		gvk, err := ParseGVK(s)
+		require.NoError(t, err)
+		assert.Equal(t, s, FormatGVK(gvk))
+	}
+	for _, s := range []string{"Pod", "apps//Deployment", "a/b/c/d"} {
+		_, err := ParseGVK(s)
+		assert.Error(t, err, s)
+	}
+}
+
+func TestRequestedTypes(t *testing.T) {
+	deployments := &types.APISchema{Schema: &schemas.Schema{ID: "apps.deployment"}}
+	attributes.SetGVK(deployments, deploymentGVK)
+	attributes.SetAccess(deployments, accesscontrol.AccessListByVerb{
+		"list":  accesscontrol.AccessList{{Namespace: accesscontrol.All, ResourceName: accesscontrol.All}},
+		"watch": accesscontrol.AccessList{{Namespace: accesscontrol.All, ResourceName: accesscontrol.All}},
+	})
+	pods := &types.APISchema{Schema: &schemas.Schema{ID: "pod"}}
+	attributes.SetGVK(pods, podGVK)
+	attributes.SetAccess(pods, accesscontrol.AccessListByVerb{
+		"list":  accesscontrol.AccessList{{Namespace: "default", ResourceName: accesscontrol.All}},
+		"watch": accesscontrol.AccessList{{Namespace: "default", ResourceName: accesscontrol.All}},
+	})
+	apiSchemas := types.EmptyAPISchemas()
+	apiSchemas.Schemas = map[string]*types.APISchema{"apps.deployment": deployments, "pod": pods}
+	allowed := []k8sschema.GroupVersionKind{deploymentGVK, podGVK}
+
+	tests := []struct {
+		name    string
+		query   string
+		allowed []k8sschema.GroupVersionKind
+		want    []k8sschema.GroupVersionKind
+		wantErr bool
+	}{
+		{name: "requested type", query: "gvk=apps/v1/Deployment", allowed: allowed, want: []k8sschema.GroupVersionKind{deploymentGVK}},
+		{name: "type not allowed", query: "gvk=apps/v1/Deployment", allowed: []k8sschema.GroupVersionKind{podGVK}, wantErr: true},
+		{name: "type listed in a namespace only", query: "gvk=v1/Pod", allowed: allowed, wantErr: true},
+		{name: "invalid type", query: "gvk=Deployment", allowed: allowed, wantErr: true},
+		{name: "all allowed types", allowed: []k8sschema.GroupVersionKind{deploymentGVK}, want: []k8sschema.GroupVersionKind{deploymentGVK}},
+		{name: "no types allowed", wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			apiOp := &types.APIRequest{
+				Request: httptest.NewRequest(http.MethodGet, "/v1/replications?"+test.query, nil),
+				Schemas: apiSchemas,
+			}
+			gvks, err := requestedTypes(apiOp, test.allowed)
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, gvks)
+		})
+	}
+}
@@ -0,0 +1,90 @@
+// Package accessexplanation provides the accessExplanation schema, which explains how the roles bound to a user grant,
+// or don't grant, a verb on a resource, to debug objects missing from the lists of users.
+package accessexplanation
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+const defaultVerb = "list"
+
+// Explainer explains how the roles bound to a user and their groups grant a verb on a resource
+type Explainer interface {
+	Explain(user user.Info, verb string, gr k8sschema.GroupResource, namespace, name string) *accesscontrol.Explanation
+}
+
+// Register registers the accessExplanation schema, which is served by ID, the ID being the resource to explain the
+// access to, followed by its group if any, such as deployments.apps. Explaining the access of other users requires
+// the permission to impersonate them and their groups.
+func Register(baseSchema *types.APISchemas, explainer Explainer, lookup accesscontrol.AccessSetLookup) {
+	baseSchema.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID:              "accessExplanation",
+			PluralName:      "accessExplanations",
+			ResourceMethods: []string{"GET"},
+		},
+		ByIDHandler: func(apiOp *types.APIRequest) (types.APIObject, error) {
+			return byID(apiOp, explainer, lookup)
+		},
+	})
+}
+
+func byID(apiOp *types.APIRequest, explainer Explainer, lookup accesscontrol.AccessSetLookup) (types.APIObject, error) {
+	requester, ok := request.UserFrom(apiOp.Context())
+	if !ok {
+		return types.APIObject{}, apierror.NewAPIError(validation.PermissionDenied, "no user on the request")
+	}
+	query := apiOp.Request.URL.Query()
+	target, err := targetUser(requester, query.Get("user"), query["group"], lookup)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+
+	resource, group, _ := strings.Cut(apiOp.Name, ".")
+	verb := query.Get("verb")
+	if verb == "" {
+		verb = defaultVerb
+	}
+	explanation := explainer.Explain(target, verb, k8sschema.GroupResource{Group: group, Resource: resource},
+		query.Get("namespace"), query.Get("name"))
+	return types.APIObject{
+		ID:     apiOp.Name,
+		Type:   "accessExplanation",
+		Object: explanation,
+	}, nil
+}
+
+// targetUser returns the user whose access is explained, the requester unless another user is set, in which case the
+// requester must be allowed to impersonate the user and the groups set
+func targetUser(requester user.Info, name string, groups []string, lookup accesscontrol.AccessSetLookup) (user.Info, error) {
+	if name == "" {
+		if len(groups) > 0 {
+			return nil, apierror.NewAPIError(validation.InvalidOption, "group requires user")
+		}
+		return requester, nil
+	}
+	if name == requester.GetName() && len(groups) == 0 {
+		return requester, nil
+	}
+
+	access := lookup.AccessFor(requester)
+	if !access.Grants("impersonate", k8sschema.GroupResource{Resource: "users"}, "", name) {
+		return nil, apierror.NewAPIError(validation.PermissionDenied, fmt.Sprintf("can not impersonate user %s", name))
+	}
+	for _, group := range groups {
+		if !access.Grants("impersonate", k8sschema.GroupResource{Resource: "groups"}, "", group) {
+			return nil, apierror.NewAPIError(validation.PermissionDenied, fmt.Sprintf("can not impersonate group %s", group))
+		}
+	}
+	return &user.DefaultInfo{Name: name, Groups: groups}, nil
+}
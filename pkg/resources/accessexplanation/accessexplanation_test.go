@@ -0,0 +1,80 @@
+package accessexplanation
+
+import (
+	"testing"
+
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+)
+
+type accessSetLookup struct {
+	accessSet *accesscontrol.AccessSet
+}
+
+func (a *accessSetLookup) AccessFor(user.Info) *accesscontrol.AccessSet {
+	return a.accessSet
+}
+
+func (a *accessSetLookup) PurgeUserData(string) {}
+
+func TestTargetUser(t *testing.T) {
+	requester := &user.DefaultInfo{Name: "admin", Groups: []string{"admins"}}
+	accessSet := &accesscontrol.AccessSet{}
+	accessSet.Add("impersonate", schema.GroupResource{Resource: "users"}, accesscontrol.Access{Namespace: accesscontrol.All, ResourceName: "alice"})
+	accessSet.Add("impersonate", schema.GroupResource{Resource: "groups"}, accesscontrol.Access{Namespace: accesscontrol.All, ResourceName: "devs"})
+	lookup := &accessSetLookup{accessSet: accessSet}
+
+	tests := []struct {
+		name      string
+		user      string
+		groups    []string
+		want      user.Info
+		wantError bool
+	}{
+		{
+			name: "the requester",
+			want: requester,
+		},
+		{
+			name: "the requester by name",
+			user: "admin",
+			want: requester,
+		},
+		{
+			name:   "an impersonated user and group",
+			user:   "alice",
+			groups: []string{"devs"},
+			want:   &user.DefaultInfo{Name: "alice", Groups: []string{"devs"}},
+		},
+		{
+			name:      "a user which can't be impersonated",
+			user:      "bob",
+			wantError: true,
+		},
+		{
+			name:      "a group which can't be impersonated",
+			user:      "alice",
+			groups:    []string{"ops"},
+			wantError: true,
+		},
+		{
+			name:      "groups without a user",
+			groups:    []string{"devs"},
+			wantError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			got, err := targetUser(requester, test.user, test.groups, lookup)
+			if test.wantError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, got)
+		})
+	}
+}
@@ -0,0 +1,233 @@
+// Package actions lets embedders register custom actions on the schemas of kinds, such as rotating the certificates of
+// a cluster or draining a node, authorized with a SelfSubjectAccessReview of the requester before they are invoked.
+package actions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+// Func invokes an action on the object identified by the name and namespace of apiOp, or on the collection for
+// collection actions, with the decoded request body. The result, if not nil, is written as JSON.
+type Func func(apiOp *types.APIRequest, input data.Object) (interface{}, error)
+
+// Action is a custom action of the objects, or of the collection, of a kind
+type Action struct {
+	Group   string
+	Version string
+	Kind    string
+	Name    string
+	// Collection actions are invoked on the collection of the kind rather than on its objects
+	Collection bool
+	// Verb is the verb the requester must be granted on the object, or on the collection, to invoke the action. It
+	// defaults to update for actions on objects, and to create for actions on the collection
+	Verb string
+	// Subresource, if set, is the subresource the verb must be granted on, such as eviction for pods
+	Subresource string
+	// Input and Output are the IDs of the schemas of the request body and of the result, if any. Fields required by
+	// the input schema must be set in the request body
+	Input  string
+	Output string
+	Invoke Func
+}
+
+// GVK returns the GroupVersionKind the action applies to
+func (a Action) GVK() k8sschema.GroupVersionKind {
+	return k8sschema.GroupVersionKind{Group: a.Group, Version: a.Version, Kind: a.Kind}
+}
+
+// Validate returns an error if the action cannot be registered
+func (a Action) Validate() error {
+	if a.Version == "" || a.Kind == "" {
+		return fmt.Errorf("action [%s] requires a version and a kind", a.Name)
+	}
+	if a.Name == "" {
+		return fmt.Errorf("action of %s requires a name", a.GVK())
+	}
+	if a.Invoke == nil {
+		return fmt.Errorf("action [%s] of %s has no func", a.Name, a.GVK())
+	}
+	return nil
+}
+
+func (a Action) verb() string {
+	switch {
+	case a.Verb != "":
+		return a.Verb
+	case a.Collection:
+		return "create"
+	default:
+		return "update"
+	}
+}
+
+// Actions holds validated actions by GVK
+type Actions struct {
+	byGVK map[k8sschema.GroupVersionKind][]Action
+}
+
+// New validates the given actions and returns them indexed by GVK
+func New(actions []Action) (*Actions, error) {
+	a := &Actions{
+		byGVK: map[k8sschema.GroupVersionKind][]Action{},
+	}
+	names := map[k8sschema.GroupVersionKind]map[string]bool{}
+	for _, action := range actions {
+		if err := action.Validate(); err != nil {
+			return nil, err
+		}
+		gvk := action.GVK()
+		if names[gvk] == nil {
+			names[gvk] = map[string]bool{}
+		}
+		// resource and collection actions share their handlers
+		if names[gvk][action.Name] {
+			return nil, fmt.Errorf("action [%s] is defined more than once for %s", action.Name, gvk)
+		}
+		names[gvk][action.Name] = true
+		a.byGVK[gvk] = append(a.byGVK[gvk], action)
+	}
+	return a, nil
+}
+
+// Template returns a schema template adding the actions of kinds to their schemas. Actions already registered on a
+// schema under the same name, such as diff, are kept.
+func Template(cg proxy.ClientGetter, actions *Actions) schema.Template {
+	return schema.Template{
+		Customize: func(apiSchema *types.APISchema) {
+			if actions == nil {
+				return
+			}
+			for _, action := range actions.byGVK[attributes.GVK(apiSchema)] {
+				add(apiSchema, action, &handler{cg: cg, action: action})
+			}
+		},
+	}
+}
+
+func add(apiSchema *types.APISchema, action Action, h http.Handler) {
+	if _, ok := apiSchema.ActionHandlers[action.Name]; ok {
+		return
+	}
+	if apiSchema.ActionHandlers == nil {
+		apiSchema.ActionHandlers = map[string]http.Handler{}
+	}
+	apiSchema.ActionHandlers[action.Name] = h
+
+	definition := schemas.Action{Input: action.Input, Output: action.Output}
+	if action.Collection {
+		if apiSchema.CollectionActions == nil {
+			apiSchema.CollectionActions = map[string]schemas.Action{}
+		}
+		apiSchema.CollectionActions[action.Name] = definition
+		return
+	}
+	if apiSchema.ResourceActions == nil {
+		apiSchema.ResourceActions = map[string]schemas.Action{}
+	}
+	apiSchema.ResourceActions[action.Name] = definition
+}
+
+// handler serves an action, once the requester is authorized to invoke it and its input is valid
+type handler struct {
+	cg     proxy.ClientGetter
+	action Action
+}
+
+func (h *handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	apiContext := types.GetAPIContext(req.Context())
+	result, err := h.invoke(apiContext, req)
+	if err != nil {
+		apiContext.WriteError(proxy.TranslateError(err))
+		return
+	}
+
+	if result == nil {
+		rw.WriteHeader(http.StatusNoContent)
+		return
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(result); err != nil {
+		logrus.Errorf("failed to write the result of action %s: %v", h.action.Name, err)
+	}
+}
+
+func (h *handler) invoke(apiContext *types.APIRequest, req *http.Request) (interface{}, error) {
+	if err := h.authorize(apiContext); err != nil {
+		return nil, err
+	}
+
+	// the body is optional, as YAML or JSON
+	input := data.Object{}
+	if err := yaml.NewYAMLOrJSONDecoder(req.Body, 4096).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("failed to parse input: %v", err))
+	}
+	if err := validateInput(apiContext.Schemas, h.action, input); err != nil {
+		return nil, err
+	}
+	return h.action.Invoke(apiContext, input)
+}
+
+// authorize checks that the requester is granted the verb of the action with a SelfSubjectAccessReview, made with the
+// requester's credentials
+func (h *handler) authorize(apiContext *types.APIRequest) error {
+	client, err := h.cg.K8sInterface(apiContext)
+	if err != nil {
+		return err
+	}
+	gvr := attributes.GVR(apiContext.Schema)
+	attrs := &authorizationv1.ResourceAttributes{
+		Verb:        h.action.verb(),
+		Group:       gvr.Group,
+		Version:     gvr.Version,
+		Resource:    gvr.Resource,
+		Subresource: h.action.Subresource,
+		Namespace:   apiContext.Namespace,
+		Name:        apiContext.Name,
+	}
+	review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(apiContext.Context(), &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{ResourceAttributes: attrs},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	if !review.Status.Allowed {
+		return apierror.NewAPIError(validation.PermissionDenied, fmt.Sprintf("action %s requires the %s verb", h.action.Name, attrs.Verb))
+	}
+	return nil
+}
+
+// validateInput checks that the fields required by the input schema of an action are set
+func validateInput(apiSchemas *types.APISchemas, action Action, input data.Object) error {
+	if action.Input == "" || apiSchemas == nil {
+		return nil
+	}
+	inputSchema := apiSchemas.LookupSchema(action.Input)
+	if inputSchema == nil {
+		return nil
+	}
+	for name, field := range inputSchema.ResourceFields {
+		if _, ok := input[name]; field.Required && !ok {
+			return apierror.NewFieldAPIError(validation.MissingRequired, name, fmt.Sprintf("%s is required by action %s", name, action.Name))
+		}
+	}
+	return nil
+}
@@ -0,0 +1,176 @@
+package actions
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var nodeGVK = k8sschema.GroupVersionKind{Version: "v1", Kind: "Node"}
+
+func noop(*types.APIRequest, data.Object) (interface{}, error) {
+	return nil, nil
+}
+
+type clientGetter struct {
+	proxy.ClientGetter
+	client kubernetes.Interface
+}
+
+func (c *clientGetter) K8sInterface(*types.APIRequest) (kubernetes.Interface, error) {
+	return c.client, nil
+}
+
+func TestNew(t *testing.T) {
+	tests := []struct {
+		name      string
+		actions   []Action
+		wantError bool
+	}{
+		{
+			name: "valid actions",
+			actions: []Action{
+				{Version: "v1", Kind: "Node", Name: "drain", Invoke: noop},
+				{Version: "v1", Kind: "Node", Name: "cordonAll", Collection: true, Invoke: noop},
+			},
+		},
+		{
+			name:      "missing kind",
+			actions:   []Action{{Version: "v1", Name: "drain", Invoke: noop}},
+			wantError: true,
+		},
+		{
+			name:      "missing func",
+			actions:   []Action{{Version: "v1", Kind: "Node", Name: "drain"}},
+			wantError: true,
+		},
+		{
+			name: "duplicated name",
+			actions: []Action{
+				{Version: "v1", Kind: "Node", Name: "drain", Invoke: noop},
+				{Version: "v1", Kind: "Node", Name: "drain", Collection: true, Invoke: noop},
+			},
+			wantError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(test.actions)
+			if test.wantError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTemplate(t *testing.T) {
+	actions, err := New([]Action{
+		{Version: "v1", Kind: "Node", Name: "drain", Input: "drainInput", Invoke: noop},
+		{Version: "v1", Kind: "Node", Name: "cordonAll", Collection: true, Invoke: noop},
+		{Version: "v1", Kind: "Node", Name: "diff", Invoke: noop},
+	})
+	require.NoError(t, err)
+
+	existing := &handler{}
+	node := &types.APISchema{Schema: &schemas.Schema{}}
+	attributes.SetGVK(node, nodeGVK)
+	node.ActionHandlers = map[string]http.Handler{"diff": existing}
+	Template(nil, actions).Customize(node)
+
+	assert.Len(t, node.ActionHandlers, 3)
+	assert.Same(t, existing, node.ActionHandlers["diff"], "existing actions are kept")
+	assert.Equal(t, map[string]schemas.Action{"drain": {Input: "drainInput"}}, node.ResourceActions)
+	assert.Equal(t, map[string]schemas.Action{"cordonAll": {}}, node.CollectionActions)
+
+	pod := &types.APISchema{Schema: &schemas.Schema{}}
+	attributes.SetGVK(pod, k8sschema.GroupVersionKind{Version: "v1", Kind: "Pod"})
+	Template(nil, actions).Customize(pod)
+	assert.Empty(t, pod.ActionHandlers)
+}
+
+func TestHandler(t *testing.T) {
+	var reviews []*authorizationv1.ResourceAttributes
+	allowed := true
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		reviews = append(reviews, review.Spec.ResourceAttributes)
+		review.Status.Allowed = allowed
+		return true, review, nil
+	})
+
+	var invoked data.Object
+	h := &handler{
+		cg: &clientGetter{client: client},
+		action: Action{Version: "v1", Kind: "Node", Name: "drain", Input: "drainInput", Subresource: "status",
+			Invoke: func(apiOp *types.APIRequest, input data.Object) (interface{}, error) {
+				invoked = input
+				return map[string]interface{}{"drained": apiOp.Name}, nil
+			}},
+	}
+	apiSchemas := types.EmptyAPISchemas()
+	apiSchemas.MustAddSchema(types.APISchema{Schema: &schemas.Schema{
+		ID:             "drainInput",
+		ResourceFields: map[string]schemas.Field{"gracePeriod": {Type: "int", Required: true}},
+	}})
+	node := &types.APISchema{Schema: &schemas.Schema{}}
+	attributes.SetGVR(node, k8sschema.GroupVersionResource{Version: "v1", Resource: "nodes"})
+
+	serve := func(body string) (*httptest.ResponseRecorder, error) {
+		var writtenErr error
+		rw := httptest.NewRecorder()
+		apiOp := types.StoreAPIContext(&types.APIRequest{
+			Name:         "node1",
+			Schema:       node,
+			Schemas:      apiSchemas,
+			Request:      httptest.NewRequest(http.MethodPost, "/v1/nodes/node1?action=drain", strings.NewReader(body)),
+			Response:     rw,
+			ErrorHandler: func(_ *types.APIRequest, err error) { writtenErr = err },
+		})
+		h.ServeHTTP(rw, apiOp.Request)
+		return rw, writtenErr
+	}
+
+	rw, err := serve(`{"gracePeriod": 30}`)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.JSONEq(t, `{"drained": "node1"}`, rw.Body.String())
+	assert.Equal(t, float64(30), invoked["gracePeriod"])
+	require.Len(t, reviews, 1)
+	assert.Equal(t, &authorizationv1.ResourceAttributes{Verb: "update", Version: "v1", Resource: "nodes", Subresource: "status", Name: "node1"}, reviews[0])
+
+	_, err = serve(`{}`)
+	assert.Equal(t, validation.MissingRequired, apiErrorCode(t, err), "required input fields must be set")
+
+	allowed = false
+	invoked = nil
+	_, err = serve(`{"gracePeriod": 30}`)
+	assert.Equal(t, validation.PermissionDenied, apiErrorCode(t, err))
+	assert.Nil(t, invoked)
+}
+
+func apiErrorCode(t *testing.T, err error) validation.ErrorCode {
+	var apiError *apierror.APIError
+	require.True(t, errors.As(err, &apiError))
+	return apiError.Code
+}
@@ -0,0 +1,168 @@
+// Package apitokens provides the apiToken schema, with which administrators issue, list and revoke the API tokens
+// authenticated by a tokens.Manager.
+package apitokens
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/parse"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/steve/pkg/auth/tokens"
+	"github.com/rancher/wrangler/v3/pkg/data/convert"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apiserver/pkg/authentication/user"
+	authorizationv1client "k8s.io/client-go/kubernetes/typed/authorization/v1"
+)
+
+// Register registers the apiToken schema. Creating one issues a token for the user and groups set, the requester by
+// default, with the description, scopes and ttl set, such as 720h, returning its value, which can't be read
+// afterwards. Deleting a token revokes it. All are restricted to administrators, that is users granted all verbs on all
+// resources. Issuing a token for another user, or for groups, also requires the requester to be granted impersonate on
+// that user and each of those groups, checked with a SubjectAccessReview made with sar.
+func Register(baseSchema *types.APISchemas, manager *tokens.Manager, asl accesscontrol.AccessSetLookup, sar authorizationv1client.SubjectAccessReviewInterface) {
+	baseSchema.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID:                "apiToken",
+			PluralName:        "apiTokens",
+			CollectionMethods: []string{"GET", "POST"},
+			ResourceMethods:   []string{"GET", "DELETE"},
+		},
+		ListHandler: func(request *types.APIRequest) (types.APIObjectList, error) {
+			if _, err := accesscontrol.CheckAdmin(request, asl, "managing API tokens"); err != nil {
+				return types.APIObjectList{}, err
+			}
+			list, err := manager.List(request.Context())
+			if err != nil {
+				return types.APIObjectList{}, err
+			}
+			result := types.APIObjectList{}
+			for _, token := range list {
+				result.Objects = append(result.Objects, toAPIObject(token))
+			}
+			return result, nil
+		},
+		ByIDHandler: func(request *types.APIRequest) (types.APIObject, error) {
+			if _, err := accesscontrol.CheckAdmin(request, asl, "managing API tokens"); err != nil {
+				return types.APIObject{}, err
+			}
+			token, err := manager.Get(request.Context(), request.Name)
+			if err != nil {
+				return types.APIObject{}, toAPIError(request.Name, err)
+			}
+			return toAPIObject(token), nil
+		},
+		CreateHandler: func(request *types.APIRequest) (types.APIObject, error) {
+			requester, err := accesscontrol.CheckAdmin(request, asl, "managing API tokens")
+			if err != nil {
+				return types.APIObject{}, err
+			}
+			return create(request, manager, sar, requester)
+		},
+		DeleteHandler: func(request *types.APIRequest) (types.APIObject, error) {
+			if _, err := accesscontrol.CheckAdmin(request, asl, "managing API tokens"); err != nil {
+				return types.APIObject{}, err
+			}
+			token, err := manager.Get(request.Context(), request.Name)
+			if err != nil {
+				return types.APIObject{}, toAPIError(request.Name, err)
+			}
+			if err := manager.Delete(request.Context(), request.Name); err != nil {
+				return types.APIObject{}, toAPIError(request.Name, err)
+			}
+			return toAPIObject(token), nil
+		},
+	})
+}
+
+func create(request *types.APIRequest, manager *tokens.Manager, sar authorizationv1client.SubjectAccessReviewInterface, requester user.Info) (types.APIObject, error) {
+	data, err := parse.Body(request.Request)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	body := data.Data()
+
+	var ttl time.Duration
+	if value := body.String("ttl"); value != "" {
+		ttl, err = time.ParseDuration(value)
+		if err != nil {
+			return types.APIObject{}, apierror.NewFieldAPIError(validation.InvalidFormat, "ttl", err.Error())
+		}
+	}
+	target := requester
+	name, groups := body.String("userName"), convert.ToStringSlice(body["groups"])
+	if name != "" || len(groups) > 0 {
+		if name == "" {
+			name = requester.GetName()
+		}
+		target = &user.DefaultInfo{Name: name, Groups: groups}
+		if err := authorizeImpersonation(request.Context(), sar, requester, target); err != nil {
+			return types.APIObject{}, err
+		}
+	}
+
+	token, err := manager.Create(request.Context(), target, body.String("description"), convert.ToStringSlice(body["scopes"]), ttl)
+	if errors.Is(err, tokens.ErrInvalidOptions) {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	} else if err != nil {
+		return types.APIObject{}, err
+	}
+	return toAPIObject(token), nil
+}
+
+// authorizeImpersonation checks that the requester is granted impersonate on the user of the target, unless it is the
+// requester, and on each of its groups, with a SubjectAccessReview of the requester
+func authorizeImpersonation(ctx context.Context, sar authorizationv1client.SubjectAccessReviewInterface, requester, target user.Info) error {
+	var attrs []*authorizationv1.ResourceAttributes
+	if target.GetName() != requester.GetName() {
+		attrs = append(attrs, &authorizationv1.ResourceAttributes{Verb: "impersonate", Resource: "users", Name: target.GetName()})
+	}
+	for _, group := range target.GetGroups() {
+		attrs = append(attrs, &authorizationv1.ResourceAttributes{Verb: "impersonate", Resource: "groups", Name: group})
+	}
+	extra := map[string]authorizationv1.ExtraValue{}
+	for key, values := range requester.GetExtra() {
+		extra[key] = values
+	}
+	for _, attr := range attrs {
+		review, err := sar.Create(ctx, &authorizationv1.SubjectAccessReview{
+			Spec: authorizationv1.SubjectAccessReviewSpec{
+				ResourceAttributes: attr,
+				User:               requester.GetName(),
+				Groups:             requester.GetGroups(),
+				UID:                requester.GetUID(),
+				Extra:              extra,
+			},
+		}, metav1.CreateOptions{})
+		if err != nil {
+			return err
+		}
+		if !review.Status.Allowed {
+			return apierror.NewAPIError(validation.PermissionDenied, fmt.Sprintf("issuing tokens for %s %s requires the impersonate verb", attr.Resource, attr.Name))
+		}
+	}
+	return nil
+}
+
+func toAPIError(id string, err error) error {
+	if apierrors.IsNotFound(err) {
+		return apierror.NewAPIError(validation.NotFound, "no token "+id)
+	}
+	return err
+}
+
+func toAPIObject(token *tokens.Token) types.APIObject {
+	return types.APIObject{
+		ID:     token.ID,
+		Type:   "apiToken",
+		Object: token,
+	}
+}
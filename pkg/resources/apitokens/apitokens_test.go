@@ -0,0 +1,119 @@
+package apitokens
+
+import (
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/steve/pkg/auth/tokens"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type fakeAccessSetLookup map[string]*accesscontrol.AccessSet
+
+func (f fakeAccessSetLookup) AccessFor(user user.Info) *accesscontrol.AccessSet {
+	if access, ok := f[user.GetName()]; ok {
+		return access
+	}
+	return &accesscontrol.AccessSet{}
+}
+
+func (f fakeAccessSetLookup) PurgeUserData(_ string) {}
+
+func TestRegister(t *testing.T) {
+	admin := &accesscontrol.AccessSet{}
+	all := k8sschema.GroupResource{Group: accesscontrol.All, Resource: accesscontrol.All}
+	admin.Add(accesscontrol.All, all, accesscontrol.Access{Namespace: accesscontrol.All, ResourceName: accesscontrol.All})
+	reader := &accesscontrol.AccessSet{}
+	reader.Add("list", all, accesscontrol.Access{Namespace: accesscontrol.All, ResourceName: accesscontrol.All})
+	asl := fakeAccessSetLookup{"admin": admin, "reader": reader}
+
+	// admin may impersonate the user ci and the group readers, and nothing else
+	client := fake.NewSimpleClientset()
+	var reviews []string
+	client.PrependReactor("create", "subjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SubjectAccessReview)
+		attrs := review.Spec.ResourceAttributes
+		reviews = append(reviews, review.Spec.User+" "+attrs.Verb+" "+attrs.Resource+"/"+attrs.Name)
+		allowed := review.Spec.User == "admin" && attrs.Verb == "impersonate" &&
+			(attrs.Resource == "users" && attrs.Name == "ci" || attrs.Resource == "groups" && attrs.Name == "readers")
+		review.Status.Allowed = allowed
+		return true, review, nil
+	})
+	manager := tokens.NewManager(client.CoreV1().Secrets("tokens"))
+	baseSchemas := types.EmptyAPISchemas()
+	Register(baseSchemas, manager, asl, client.AuthorizationV1().SubjectAccessReviews())
+	schema := baseSchemas.LookupSchema("apiToken")
+	require.NotNil(t, schema)
+
+	requestFor := func(name, id, body string) *types.APIRequest {
+		req := httptest.NewRequest("POST", "/v1/apiTokens", strings.NewReader(body))
+		req.Header.Set("Content-Type", "application/json")
+		req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: name}))
+		return &types.APIRequest{Request: req, Name: id}
+	}
+
+	// only administrators can manage tokens
+	_, err := schema.CreateHandler(requestFor("reader", "", `{}`))
+	assert.Error(t, err)
+	_, err = schema.ListHandler(requestFor("reader", "", ""))
+	assert.Error(t, err)
+
+	_, err = schema.CreateHandler(requestFor("admin", "", `{"ttl": "forever"}`))
+	assert.Error(t, err)
+	_, err = schema.CreateHandler(requestFor("admin", "", `{"scopes": ["admin"]}`))
+	assert.Error(t, err)
+
+	// tokens are issued for the requester by default, with no review
+	obj, err := schema.CreateHandler(requestFor("admin", "", `{}`))
+	require.NoError(t, err)
+	assert.Equal(t, "admin", obj.Object.(*tokens.Token).UserName)
+	assert.Empty(t, reviews)
+	_, err = schema.DeleteHandler(requestFor("admin", obj.ID, ""))
+	require.NoError(t, err)
+
+	// other users and groups require impersonate on each of them
+	_, err = schema.CreateHandler(requestFor("admin", "", `{"userName": "root"}`))
+	assert.Error(t, err)
+	_, err = schema.CreateHandler(requestFor("admin", "", `{"groups": ["system:masters"]}`))
+	assert.Error(t, err)
+	_, err = schema.CreateHandler(requestFor("admin", "", `{"userName": "ci", "groups": ["readers", "system:masters"]}`))
+	assert.Error(t, err)
+	reviews = nil
+
+	obj, err = schema.CreateHandler(requestFor("admin", "", `{"userName": "ci", "groups": ["readers"], "description": "nightly", "ttl": "720h"}`))
+	require.NoError(t, err)
+	assert.Equal(t, []string{"admin impersonate users/ci", "admin impersonate groups/readers"}, reviews)
+	token := obj.Object.(*tokens.Token)
+	assert.Equal(t, "apiToken", obj.Type)
+	assert.Equal(t, "ci", token.UserName)
+	assert.Equal(t, []string{"readers"}, token.Groups)
+	assert.Equal(t, []string{tokens.ScopeRead}, token.Scopes)
+	assert.NotEmpty(t, token.Value)
+
+	obj, err = schema.ByIDHandler(requestFor("admin", token.ID, ""))
+	require.NoError(t, err)
+	assert.Equal(t, "nightly", obj.Object.(*tokens.Token).Description)
+	assert.Empty(t, obj.Object.(*tokens.Token).Value)
+
+	list, err := schema.ListHandler(requestFor("admin", "", ""))
+	require.NoError(t, err)
+	assert.Len(t, list.Objects, 1)
+
+	_, err = schema.DeleteHandler(requestFor("reader", token.ID, ""))
+	assert.Error(t, err)
+	_, err = schema.DeleteHandler(requestFor("admin", token.ID, ""))
+	require.NoError(t, err)
+	_, err = schema.ByIDHandler(requestFor("admin", token.ID, ""))
+	assert.Error(t, err)
+}
@@ -0,0 +1,222 @@
+// Package applications provides the application schema, which lists the objects of every type carrying an application
+// identity, such as the instance label or the Helm release they were applied with, along with a rollup of their
+// health, so that clients can show the objects of an application without listing each type themselves.
+package applications
+
+import (
+	"fmt"
+	"net/url"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/resources/virtual/identity"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	sourceParam   = "source"
+	defaultSource = "instance"
+	// maxConcurrentLists bounds the types whose objects are listed at the same time
+	maxConcurrentLists = 10
+)
+
+// Application is the application object, returned by the identity of the application
+type Application struct {
+	// Source is the name of the identity source, such as instance or helmRelease, and Value the identity
+	Source string `json:"source"`
+	Value  string `json:"value"`
+	// Resources are the objects of the application, sorted by type, namespace and name
+	Resources []Resource `json:"resources"`
+	Summary   Summary    `json:"summary"`
+	// Errors are the errors listing the objects of types, by type
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Resource is an object of an application, with its state
+type Resource struct {
+	Type          string `json:"type"`
+	APIVersion    string `json:"apiVersion"`
+	Kind          string `json:"kind"`
+	Namespace     string `json:"namespace,omitempty"`
+	Name          string `json:"name"`
+	State         string `json:"state,omitempty"`
+	Error         bool   `json:"error,omitempty"`
+	Transitioning bool   `json:"transitioning,omitempty"`
+	Message       string `json:"message,omitempty"`
+}
+
+// Summary rolls up the health of the objects of an application
+type Summary struct {
+	Total         int `json:"total"`
+	Error         int `json:"error"`
+	Transitioning int `json:"transitioning"`
+	// States counts the objects by state
+	States map[string]int `json:"states,omitempty"`
+}
+
+// Register registers the application schema, which is served by ID, the ID being the identity of the application.
+// The source query param names the source of the identity, instance if empty, and the namespace of the request, if
+// any, restricts the objects to those of a namespace. Objects are listed with a filter on the indexed identity fields,
+// which only the SQL cache supports.
+func Register(baseSchema *types.APISchemas) {
+	baseSchema.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID:              "application",
+			PluralName:      "applications",
+			ResourceMethods: []string{"GET"},
+		},
+		ByIDHandler: byID,
+	})
+}
+
+func byID(request *types.APIRequest) (types.APIObject, error) {
+	sourceName := request.Query.Get(sourceParam)
+	if sourceName == "" {
+		sourceName = defaultSource
+	}
+	source, ok := identity.Lookup(sourceName)
+	if !ok {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidOption, fmt.Sprintf("unknown application identity source [%s]", sourceName))
+	}
+
+	// values with the separators of filters cannot be filtered on
+	if strings.ContainsAny(request.Name, ",'") {
+		return types.APIObject{}, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("application identity [%s] cannot contain commas or quotes", request.Name))
+	}
+
+	application := &Application{
+		Source: source.Name,
+		Value:  request.Name,
+	}
+	application.Resources, application.Errors = resources(request, source, request.Name)
+	application.Summary = summarize(application.Resources)
+	return types.APIObject{
+		ID:     request.Name,
+		Type:   "application",
+		Object: application,
+	}, nil
+}
+
+// resources lists the objects with the identity among the types the user can list, sorted by type, namespace and
+// name, and returns them with the errors listing types
+func resources(request *types.APIRequest, source identity.Source, value string) ([]Resource, map[string]string) {
+	var (
+		lock   sync.Mutex
+		result []Resource
+		errs   map[string]string
+		eg     errgroup.Group
+	)
+	eg.SetLimit(maxConcurrentLists)
+	for _, apiSchema := range listableSchemas(request) {
+		apiSchema := apiSchema
+		eg.Go(func() error {
+			r, err := resourcesOfType(request, apiSchema, source, value)
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				if errs == nil {
+					errs = map[string]string{}
+				}
+				errs[apiSchema.ID] = err.Error()
+			} else {
+				result = append(result, r...)
+			}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Type != result[j].Type {
+			return result[i].Type < result[j].Type
+		}
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Name < result[j].Name
+	})
+	return result, errs
+}
+
+// listableSchemas returns the schemas of the Kubernetes types the user can list, only namespaced ones when the request
+// has a namespace
+func listableSchemas(request *types.APIRequest) []*types.APISchema {
+	var result []*types.APISchema
+	for _, apiSchema := range request.Schemas.Schemas {
+		if apiSchema.Store == nil || attributes.Kind(apiSchema) == "" {
+			continue
+		}
+		if request.Namespace != "" && !attributes.Namespaced(apiSchema) {
+			continue
+		}
+		if request.AccessControl != nil && request.AccessControl.CanList(request, apiSchema) != nil {
+			continue
+		}
+		result = append(result, apiSchema)
+	}
+	return result
+}
+
+func resourcesOfType(request *types.APIRequest, apiSchema *types.APISchema, source identity.Source, value string) ([]Resource, error) {
+	listOp := request.Clone()
+	listOp.Schema = apiSchema
+	listOp.Type = apiSchema.ID
+	listOp.Name = ""
+	listOp.Link = ""
+	listOp.Request = request.Request.Clone(request.Context())
+	// the value is quoted to be matched exactly
+	listOp.Request.URL.RawQuery = url.Values{
+		"filter": []string{fmt.Sprintf("metadata.application.%s='%s'", source.Name, value)},
+	}.Encode()
+	list, err := apiSchema.Store.List(listOp, apiSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	gvk := attributes.GVK(apiSchema)
+	var result []Resource
+	for _, obj := range list.Objects {
+		data := obj.Data()
+		// only the objects of the SQL cache have the identity field, which other stores don't filter on
+		if data.String("metadata", "application", source.Name) != value {
+			continue
+		}
+		result = append(result, Resource{
+			Type:          apiSchema.ID,
+			APIVersion:    gvk.GroupVersion().String(),
+			Kind:          gvk.Kind,
+			Namespace:     data.String("metadata", "namespace"),
+			Name:          data.String("metadata", "name"),
+			State:         data.String("metadata", "state", "name"),
+			Error:         data.Bool("metadata", "state", "error"),
+			Transitioning: data.Bool("metadata", "state", "transitioning"),
+			Message:       data.String("metadata", "state", "message"),
+		})
+	}
+	return result, nil
+}
+
+func summarize(resources []Resource) Summary {
+	summary := Summary{Total: len(resources)}
+	for _, resource := range resources {
+		switch {
+		case resource.Error:
+			summary.Error++
+		case resource.Transitioning:
+			summary.Transitioning++
+		}
+		if resource.State != "" {
+			if summary.States == nil {
+				summary.States = map[string]int{}
+			}
+			summary.States[resource.State]++
+		}
+	}
+	return summary
+}
@@ -0,0 +1,130 @@
+package applications
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/store/empty"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore returns all objects of a type, like stores which don't filter on the identity fields, and records the
+// queries of lists
+type fakeStore struct {
+	empty.Store
+	objects map[string][]types.APIObject
+	errs    map[string]error
+	queries []string
+}
+
+func (f *fakeStore) List(apiOp *types.APIRequest, schema *types.APISchema) (types.APIObjectList, error) {
+	f.queries = append(f.queries, apiOp.Request.URL.RawQuery)
+	if err := f.errs[schema.ID]; err != nil {
+		return types.APIObjectList{}, err
+	}
+	return types.APIObjectList{Objects: f.objects[schema.ID]}, nil
+}
+
+func object(namespace, name, instance, state string, isError bool) types.APIObject {
+	metadata := map[string]interface{}{
+		"namespace": namespace,
+		"name":      name,
+		"state": map[string]interface{}{
+			"name":  state,
+			"error": isError,
+		},
+	}
+	if instance != "" {
+		metadata["application"] = map[string]interface{}{"instance": instance}
+	}
+	return types.APIObject{Object: map[string]interface{}{"metadata": metadata}}
+}
+
+func kubernetesSchema(id, group, kind string, namespaced bool, store types.Store) *types.APISchema {
+	return &types.APISchema{
+		Schema: &schemas.Schema{
+			ID: id,
+			Attributes: map[string]interface{}{
+				"group":      group,
+				"version":    "v1",
+				"kind":       kind,
+				"namespaced": namespaced,
+			},
+		},
+		Store: store,
+	}
+}
+
+func TestByID(t *testing.T) {
+	store := &fakeStore{
+		objects: map[string][]types.APIObject{
+			"apps.deployment": {
+				object("shop", "web", "shop", "active", false),
+				object("shop", "other", "other", "active", false),
+			},
+			"pod": {
+				object("shop", "web-2", "shop", "crashloopbackoff", true),
+				object("shop", "web-1", "shop", "running", false),
+			},
+		},
+		errs: map[string]error{
+			"secret": errors.New("cache failing"),
+		},
+	}
+	apiSchemas := types.EmptyAPISchemas()
+	apiSchemas.Schemas = map[string]*types.APISchema{
+		"apps.deployment": kubernetesSchema("apps.deployment", "apps", "Deployment", true, store),
+		"pod":             kubernetesSchema("pod", "", "Pod", true, store),
+		"secret":          kubernetesSchema("secret", "", "Secret", true, store),
+		"node":            kubernetesSchema("node", "", "Node", false, store),
+		"count":           {Schema: &schemas.Schema{ID: "count"}},
+	}
+	request := &types.APIRequest{
+		Name:      "shop",
+		Namespace: "shop",
+		Schemas:   apiSchemas,
+		Request:   httptest.NewRequest("GET", "/v1/applications/shop/shop", nil),
+	}
+
+	obj, err := byID(request)
+	require.NoError(t, err)
+	assert.Equal(t, "shop", obj.ID)
+	assert.Equal(t, &Application{
+		Source: "instance",
+		Value:  "shop",
+		Resources: []Resource{
+			{Type: "apps.deployment", APIVersion: "apps/v1", Kind: "Deployment", Namespace: "shop", Name: "web", State: "active"},
+			{Type: "pod", APIVersion: "v1", Kind: "Pod", Namespace: "shop", Name: "web-1", State: "running"},
+			{Type: "pod", APIVersion: "v1", Kind: "Pod", Namespace: "shop", Name: "web-2", State: "crashloopbackoff", Error: true},
+		},
+		Summary: Summary{
+			Total:  3,
+			Error:  1,
+			States: map[string]int{"active": 1, "running": 1, "crashloopbackoff": 1},
+		},
+		Errors: map[string]string{"secret": "cache failing"},
+	}, obj.Object)
+	// cluster-scoped types are skipped when listing a namespace
+	assert.Len(t, store.queries, 3)
+	assert.Contains(t, store.queries, "filter=metadata.application.instance%3D%27shop%27")
+}
+
+func TestByIDInvalid(t *testing.T) {
+	request := &types.APIRequest{
+		Name:    "shop",
+		Schemas: types.EmptyAPISchemas(),
+		Request: httptest.NewRequest("GET", "/v1/applications/shop?source=unknown", nil),
+	}
+	request.Query = request.Request.URL.Query()
+	_, err := byID(request)
+	assert.Error(t, err)
+
+	request.Name = "shop,web"
+	request.Query = nil
+	_, err = byID(request)
+	assert.Error(t, err)
+}
@@ -0,0 +1,93 @@
+// Package bulk provides the response envelope of operations touching multiple objects, reporting the outcome of each
+// object separately instead of aggregating errors into a single message.
+package bulk
+
+import (
+	"encoding/json"
+	"net/http"
+
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+)
+
+// Status is the outcome of an operation on a single object
+type Status string
+
+const (
+	StatusSucceeded Status = "succeeded"
+	StatusFailed    Status = "failed"
+)
+
+// Result is the response of an operation touching multiple objects
+type Result struct {
+	Type  string       `json:"type"`
+	Items []ItemResult `json:"items"`
+}
+
+// ItemResult is the outcome of an operation on one of the objects
+type ItemResult struct {
+	APIVersion string `json:"apiVersion,omitempty"`
+	Kind       string `json:"kind,omitempty"`
+	Namespace  string `json:"namespace,omitempty"`
+	Name       string `json:"name,omitempty"`
+	Status     Status `json:"status"`
+	// Reason is the Kubernetes reason of the failure, e.g. Forbidden or Conflict
+	Reason metav1.StatusReason `json:"reason,omitempty"`
+	// Message describes the failure
+	Message string `json:"message,omitempty"`
+	// Retryable is true if the failure is transient, and retrying the operation on the object may succeed
+	Retryable bool `json:"retryable,omitempty"`
+}
+
+// NewItemResult returns the result of an operation on obj which failed with err, or succeeded if err is nil.
+func NewItemResult(obj runtime.Object, err error) ItemResult {
+	result := ItemResult{Status: StatusSucceeded}
+	if obj != nil {
+		result.APIVersion, result.Kind = obj.GetObjectKind().GroupVersionKind().ToAPIVersionAndKind()
+		if m, mErr := meta.Accessor(obj); mErr == nil {
+			result.Namespace = m.GetNamespace()
+			result.Name = m.GetName()
+		}
+	}
+	if err != nil {
+		result.Status = StatusFailed
+		result.Reason = apierrors.ReasonForError(err)
+		result.Message = err.Error()
+		result.Retryable = IsRetryable(err)
+	}
+	return result
+}
+
+// IsRetryable returns true if err is a transient failure of the Kubernetes API.
+func IsRetryable(err error) bool {
+	return apierrors.IsConflict(err) ||
+		apierrors.IsServerTimeout(err) ||
+		apierrors.IsTimeout(err) ||
+		apierrors.IsTooManyRequests(err) ||
+		apierrors.IsServiceUnavailable(err) ||
+		apierrors.IsInternalError(err)
+}
+
+// Failed returns true if the operation failed for any of the objects.
+func (r *Result) Failed() bool {
+	for _, item := range r.Items {
+		if item.Status == StatusFailed {
+			return true
+		}
+	}
+	return false
+}
+
+// Write writes the result as JSON, with a 207 Multi-Status code if the operation failed for any of the objects.
+func (r *Result) Write(rw http.ResponseWriter) error {
+	r.Type = "bulkResult"
+	code := http.StatusOK
+	if r.Failed() {
+		code = http.StatusMultiStatus
+	}
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(code)
+	return json.NewEncoder(rw).Encode(r)
+}
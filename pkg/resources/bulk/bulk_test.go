@@ -0,0 +1,105 @@
+package bulk
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestNewItemResult(t *testing.T) {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("apps/v1")
+	obj.SetKind("Deployment")
+	obj.SetNamespace("default")
+	obj.SetName("web")
+	gr := schema.GroupResource{Group: "apps", Resource: "deployments"}
+
+	tests := []struct {
+		name     string
+		err      error
+		expected ItemResult
+	}{
+		{
+			name: "success",
+			expected: ItemResult{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Namespace:  "default",
+				Name:       "web",
+				Status:     StatusSucceeded,
+			},
+		},
+		{
+			name: "permanent failure",
+			err:  apierrors.NewForbidden(gr, "web", errors.New("denied")),
+			expected: ItemResult{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Namespace:  "default",
+				Name:       "web",
+				Status:     StatusFailed,
+				Reason:     metav1.StatusReasonForbidden,
+				Message:    `deployments.apps "web" is forbidden: denied`,
+			},
+		},
+		{
+			name: "transient failure",
+			err:  apierrors.NewConflict(gr, "web", errors.New("modified")),
+			expected: ItemResult{
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Namespace:  "default",
+				Name:       "web",
+				Status:     StatusFailed,
+				Reason:     metav1.StatusReasonConflict,
+				Message:    `Operation cannot be fulfilled on deployments.apps "web": modified`,
+				Retryable:  true,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, NewItemResult(obj, test.err))
+		})
+	}
+}
+
+func TestResultWrite(t *testing.T) {
+	tests := []struct {
+		name         string
+		result       Result
+		expectedCode int
+	}{
+		{
+			name:         "all succeeded",
+			result:       Result{Items: []ItemResult{{Status: StatusSucceeded}}},
+			expectedCode: http.StatusOK,
+		},
+		{
+			name:         "some failed",
+			result:       Result{Items: []ItemResult{{Status: StatusSucceeded}, {Status: StatusFailed}}},
+			expectedCode: http.StatusMultiStatus,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			rw := httptest.NewRecorder()
+			require.NoError(t, test.result.Write(rw))
+			assert.Equal(t, test.expectedCode, rw.Code)
+
+			var decoded Result
+			require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &decoded))
+			assert.Equal(t, "bulkResult", decoded.Type)
+			assert.Equal(t, test.result.Items, decoded.Items)
+		})
+	}
+}
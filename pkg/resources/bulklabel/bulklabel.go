@@ -0,0 +1,202 @@
+// Package bulklabel provides the label collection action, which adds and removes labels and annotations of all the
+// objects of a type matching the filters of the request, with the requester's permissions on each object.
+package bulklabel
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/resources/bulk"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	k8svalidation "k8s.io/apimachinery/pkg/util/validation"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	actionName = "label"
+	// maxObjects bounds the number of objects a single request can edit, so that a broad filter can't edit a whole
+	// cluster by mistake
+	maxObjects = 500
+)
+
+// Edit adds and removes keys of the labels, or of the annotations, of objects
+type Edit struct {
+	Add    map[string]string `json:"add,omitempty"`
+	Remove []string          `json:"remove,omitempty"`
+}
+
+// Input is the request body of the label action
+type Input struct {
+	Labels      Edit `json:"labels"`
+	Annotations Edit `json:"annotations"`
+}
+
+// Template returns a schema template adding the label action to the collection of every type which can be listed and
+// patched
+func Template(cg proxy.ClientGetter) schema.Template {
+	handler := &Handler{cg: cg}
+	return schema.Template{
+		Customize: func(apiSchema *types.APISchema) {
+			add(apiSchema, handler)
+		},
+	}
+}
+
+func add(apiSchema *types.APISchema, handler http.Handler) {
+	if attributes.GVR(apiSchema).Resource == "" {
+		return
+	}
+	verbs := attributes.Verbs(apiSchema)
+	if !slices.Contains(verbs, "list") || !slices.Contains(verbs, "patch") {
+		return
+	}
+	if _, ok := apiSchema.ActionHandlers[actionName]; ok {
+		return
+	}
+
+	if apiSchema.ActionHandlers == nil {
+		apiSchema.ActionHandlers = map[string]http.Handler{}
+	}
+	apiSchema.ActionHandlers[actionName] = handler
+	if apiSchema.CollectionActions == nil {
+		apiSchema.CollectionActions = map[string]schemas.Action{}
+	}
+	apiSchema.CollectionActions[actionName] = schemas.Action{}
+}
+
+// Handler serves the label action. The objects matching the filters of the request, which the requester can list, are
+// patched one by one with the requester's credentials, so that objects the requester can't patch fail on their own.
+type Handler struct {
+	cg proxy.ClientGetter
+}
+
+func (h *Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	apiContext := types.GetAPIContext(req.Context())
+	result, err := h.label(apiContext, req)
+	if err != nil {
+		apiContext.WriteError(proxy.TranslateError(err))
+		return
+	}
+	if err := result.Write(rw); err != nil {
+		logrus.Errorf("failed to write the result of action %s: %v", actionName, err)
+	}
+}
+
+func (h *Handler) label(apiContext *types.APIRequest, req *http.Request) (*bulk.Result, error) {
+	var input Input
+	if err := yaml.NewYAMLOrJSONDecoder(req.Body, 4096).Decode(&input); err != nil && !errors.Is(err, io.EOF) {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("failed to parse input: %v", err))
+	}
+	patch, err := mergePatch(input)
+	if err != nil {
+		return nil, err
+	}
+	objects, err := list(apiContext)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &bulk.Result{Items: []bulk.ItemResult{}}
+	for _, obj := range objects {
+		client, err := h.cg.Client(apiContext, apiContext.Schema, obj.GetNamespace(), rest.NoWarnings{})
+		if err == nil {
+			_, err = client.Patch(apiContext.Context(), obj.GetName(), k8stypes.MergePatchType, patch, metav1.PatchOptions{})
+		}
+		result.Items = append(result.Items, bulk.NewItemResult(obj, err))
+	}
+	return result, nil
+}
+
+// list returns the objects of the collection matching the filters of the request, across all its pages
+func list(apiContext *types.APIRequest) ([]*unstructured.Unstructured, error) {
+	listOp := apiContext.Clone()
+	listOp.Method = http.MethodGet
+	listOp.Action = ""
+	query := url.Values{}
+	for key, values := range apiContext.Request.URL.Query() {
+		if key != "action" {
+			query[key] = values
+		}
+	}
+
+	var objects []*unstructured.Unstructured
+	for {
+		listOp.Request = apiContext.Request.Clone(apiContext.Context())
+		listOp.Request.Method = http.MethodGet
+		listOp.Request.URL.RawQuery = query.Encode()
+		listOp.Query = query
+		list, err := apiContext.Schema.Store.List(listOp, apiContext.Schema)
+		if err != nil {
+			return nil, err
+		}
+		for _, obj := range list.Objects {
+			if u, ok := obj.Object.(*unstructured.Unstructured); ok {
+				objects = append(objects, u)
+			}
+		}
+		if len(objects) > maxObjects {
+			return nil, apierror.NewAPIError(validation.InvalidOption,
+				fmt.Sprintf("more than %d objects match, narrow the filters of the request", maxObjects))
+		}
+		if list.Continue == "" {
+			return objects, nil
+		}
+		query.Set("continue", list.Continue)
+	}
+}
+
+// mergePatch returns the JSON merge patch adding and removing the labels and annotations of the input
+func mergePatch(input Input) ([]byte, error) {
+	metadata := map[string]interface{}{}
+	for _, field := range []struct {
+		name string
+		edit Edit
+		// validateValue returns the errors of a value, labels having restricted values unlike annotations
+		validateValue func(string) []string
+	}{
+		{name: "labels", edit: input.Labels, validateValue: k8svalidation.IsValidLabelValue},
+		{name: "annotations", edit: input.Annotations, validateValue: func(string) []string { return nil }},
+	} {
+		values := map[string]interface{}{}
+		for key, value := range field.edit.Add {
+			if errs := k8svalidation.IsQualifiedName(key); len(errs) > 0 {
+				return nil, apierror.NewFieldAPIError(validation.InvalidFormat, field.name, fmt.Sprintf("invalid key %q: %s", key, strings.Join(errs, "; ")))
+			}
+			if errs := field.validateValue(value); len(errs) > 0 {
+				return nil, apierror.NewFieldAPIError(validation.InvalidFormat, field.name, fmt.Sprintf("invalid value of %q: %s", key, strings.Join(errs, "; ")))
+			}
+			values[key] = value
+		}
+		for _, key := range field.edit.Remove {
+			if _, ok := field.edit.Add[key]; ok {
+				return nil, apierror.NewFieldAPIError(validation.InvalidOption, field.name, fmt.Sprintf("%q is both added and removed", key))
+			}
+			// null removes a key from the object
+			values[key] = nil
+		}
+		if len(values) > 0 {
+			metadata[field.name] = values
+		}
+	}
+	if len(metadata) == 0 {
+		return nil, apierror.NewAPIError(validation.MissingRequired, "no labels or annotations to add or remove")
+	}
+	return json.Marshal(map[string]interface{}{"metadata": metadata})
+}
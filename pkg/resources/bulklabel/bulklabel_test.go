@@ -0,0 +1,187 @@
+package bulklabel
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/store/empty"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/resources/bulk"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+var namespaceGVR = k8sschema.GroupVersionResource{Version: "v1", Resource: "namespaces"}
+
+type clientGetter struct {
+	proxy.ClientGetter
+	client dynamic.Interface
+}
+
+func (c *clientGetter) Client(_ *types.APIRequest, _ *types.APISchema, namespace string, _ rest.WarningHandler) (dynamic.ResourceInterface, error) {
+	return c.client.Resource(namespaceGVR), nil
+}
+
+// pagedStore lists its objects one per page, recording the query of each list
+type pagedStore struct {
+	empty.Store
+	objects []runtime.Object
+	queries []string
+}
+
+func (s *pagedStore) List(apiOp *types.APIRequest, _ *types.APISchema) (types.APIObjectList, error) {
+	s.queries = append(s.queries, apiOp.Request.URL.RawQuery)
+	page := 0
+	if token := apiOp.Request.URL.Query().Get("continue"); token != "" {
+		page = len(token)
+	}
+	list := types.APIObjectList{Objects: []types.APIObject{{Object: s.objects[page]}}}
+	if page+1 < len(s.objects) {
+		list.Continue = strings.Repeat("x", page+1)
+	}
+	return list, nil
+}
+
+func newNamespace(name string, labels map[string]string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Namespace")
+	obj.SetName(name)
+	obj.SetLabels(labels)
+	return obj
+}
+
+func serve(cg proxy.ClientGetter, store types.Store, query, body string) (*httptest.ResponseRecorder, error) {
+	var writtenErr error
+	rw := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/namespaces?"+query, strings.NewReader(body))
+	apiOp := types.StoreAPIContext(&types.APIRequest{
+		Schema:       &types.APISchema{Schema: &schemas.Schema{ID: "namespace"}, Store: store},
+		Method:       http.MethodPost,
+		Action:       actionName,
+		Request:      req,
+		Response:     rw,
+		ErrorHandler: func(_ *types.APIRequest, err error) { writtenErr = err },
+	})
+	(&Handler{cg: cg}).ServeHTTP(rw, apiOp.Request)
+	return rw, writtenErr
+}
+
+func TestLabel(t *testing.T) {
+	scheme := runtime.NewScheme()
+	client := dynamicfake.NewSimpleDynamicClientWithCustomListKinds(scheme, map[k8sschema.GroupVersionResource]string{namespaceGVR: "NamespaceList"},
+		newNamespace("a", map[string]string{"team": "x", "old": "1"}),
+		newNamespace("b", nil),
+	)
+	client.PrependReactor("patch", "namespaces", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.(k8stesting.PatchAction).GetName() == "c" {
+			return true, nil, apierrors.NewForbidden(namespaceGVR.GroupResource(), "c", errors.New("no"))
+		}
+		return false, nil, nil
+	})
+	store := &pagedStore{objects: []runtime.Object{newNamespace("a", nil), newNamespace("b", nil), newNamespace("c", nil)}}
+
+	rw, err := serve(&clientGetter{client: client}, store, "action=label&filter=metadata.labels.team=x",
+		`{"labels": {"add": {"team": "y"}, "remove": ["old"]}, "annotations": {"add": {"note": "tagged in bulk"}}}`)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusMultiStatus, rw.Code)
+	assert.Equal(t, []string{
+		"filter=metadata.labels.team%3Dx",
+		"continue=x&filter=metadata.labels.team%3Dx",
+		"continue=xx&filter=metadata.labels.team%3Dx",
+	}, store.queries, "all the pages matching the filters are listed, without the action")
+
+	var result bulk.Result
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &result))
+	require.Len(t, result.Items, 3)
+	assert.Equal(t, bulk.StatusSucceeded, result.Items[0].Status)
+	assert.Equal(t, bulk.StatusSucceeded, result.Items[1].Status)
+	assert.Equal(t, bulk.StatusFailed, result.Items[2].Status)
+	assert.Equal(t, metav1.StatusReasonForbidden, result.Items[2].Reason)
+
+	a, err := client.Resource(namespaceGVR).Get(context.Background(), "a", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, map[string]string{"team": "y"}, a.GetLabels())
+	assert.Equal(t, map[string]string{"note": "tagged in bulk"}, a.GetAnnotations())
+}
+
+func TestMergePatch(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     Input
+		wantPatch string
+		wantCode  validation.ErrorCode
+	}{
+		{
+			name:      "labels and annotations",
+			input:     Input{Labels: Edit{Add: map[string]string{"team": "a"}, Remove: []string{"old"}}, Annotations: Edit{Remove: []string{"note"}}},
+			wantPatch: `{"metadata":{"annotations":{"note":null},"labels":{"old":null,"team":"a"}}}`,
+		},
+		{
+			name:     "nothing to edit",
+			wantCode: validation.MissingRequired,
+		},
+		{
+			name:     "invalid label key",
+			input:    Input{Labels: Edit{Add: map[string]string{"bad key": "a"}}},
+			wantCode: validation.InvalidFormat,
+		},
+		{
+			name:     "invalid label value",
+			input:    Input{Labels: Edit{Add: map[string]string{"team": "not a label value"}}},
+			wantCode: validation.InvalidFormat,
+		},
+		{
+			name:      "annotation values are free",
+			input:     Input{Annotations: Edit{Add: map[string]string{"note": "any value: at all"}}},
+			wantPatch: `{"metadata":{"annotations":{"note":"any value: at all"}}}`,
+		},
+		{
+			name:     "key added and removed",
+			input:    Input{Labels: Edit{Add: map[string]string{"team": "a"}, Remove: []string{"team"}}},
+			wantCode: validation.InvalidOption,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			patch, err := mergePatch(test.input)
+			if test.wantCode.Code != "" {
+				var apiErr *apierror.APIError
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, test.wantCode, apiErr.Code)
+				return
+			}
+			require.NoError(t, err)
+			assert.JSONEq(t, test.wantPatch, string(patch))
+		})
+	}
+}
+
+func TestTooManyObjects(t *testing.T) {
+	store := &pagedStore{}
+	for i := 0; i <= maxObjects; i++ {
+		store.objects = append(store.objects, newNamespace("ns", nil))
+	}
+	_, err := serve(&clientGetter{}, store, "action=label", `{"labels": {"add": {"team": "a"}}}`)
+	var apiErr *apierror.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, validation.InvalidOption, apiErr.Code)
+}
@@ -0,0 +1,202 @@
+// Package cacheadvisor provides the cacheAdvisor schema, which helps operators decide which fields of a type should be
+// indexed in the SQL cache.
+package cacheadvisor
+
+import (
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// sampleSize is the maximum number of cached objects inspected to discover fields
+	sampleSize = 100
+	// minCoverage is the minimum ratio of sampled objects in which a field must be present to be suggested
+	minCoverage = 0.5
+)
+
+var (
+	// fieldNameRegex and keyRegex match the (sub)field names the SQL cache is able to index
+	fieldNameRegex = regexp.MustCompile(`^[a-zA-Z]+$`)
+	keyRegex       = regexp.MustCompile(`^[a-zA-Z./]+$`)
+	// ignoredFields are top-level fields which are either indexed by default or managed by steve
+	ignoredFields = map[string]bool{
+		"apiVersion": true,
+		"kind":       true,
+		"id":         true,
+		"_id":        true,
+		"_type":      true,
+	}
+)
+
+// Cache is the SQL cache store the advisor inspects
+type Cache interface {
+	// IndexedFields returns the fields of a schema that are indexed in the cache
+	IndexedFields(schema *types.APISchema) [][]string
+	// UnindexedFields returns how many times lists of a schema filtered or sorted on each field which isn't indexed
+	UnindexedFields(schema *types.APISchema) map[string]int
+}
+
+// Sampler returns the cached objects of a type, only sampling the objects the requester is allowed to list
+type Sampler interface {
+	// Sample returns up to limit cached objects of a schema's type
+	Sample(apiOp *types.APIRequest, schema *types.APISchema, limit int) ([]unstructured.Unstructured, error)
+}
+
+// Advice is the cacheAdvisor object returned for a schema
+type Advice struct {
+	// IndexedFields are the fields currently indexed in the cache
+	IndexedFields []string `json:"indexedFields"`
+	// SampleSize is the number of cached objects which were inspected
+	SampleSize int `json:"sampleSize"`
+	// SuggestedFields are commonly present fields which are not indexed yet
+	SuggestedFields []FieldSuggestion `json:"suggestedFields"`
+	// RequestedFields are the unindexed fields lists filtered or sorted on, most requested first
+	RequestedFields []FieldRequest `json:"requestedFields"`
+}
+
+// FieldSuggestion is a field which could be indexed to enable filtering and sorting on it
+type FieldSuggestion struct {
+	Field string `json:"field"`
+	// Coverage is the ratio of sampled objects in which the field is present
+	Coverage float64 `json:"coverage"`
+}
+
+// FieldRequest is an unindexed field lists filtered or sorted on, which failed as the field can't be filtered or
+// sorted on until it is indexed
+type FieldRequest struct {
+	Field string `json:"field"`
+	// Count is the number of lists which filtered or sorted on the field since steve started
+	Count int `json:"count"`
+}
+
+// Register registers the cacheAdvisor schema, which is served by ID, the ID being the ID of the schema to advise on.
+// Fields are suggested from a sample of the objects the requester is allowed to list.
+func Register(baseSchema *types.APISchemas, cache Cache, sampler Sampler) {
+	baseSchema.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID:              "cacheAdvisor",
+			PluralName:      "cacheAdvisors",
+			ResourceMethods: []string{"GET"},
+		},
+		ByIDHandler: func(request *types.APIRequest) (types.APIObject, error) {
+			return byID(request, cache, sampler)
+		},
+	})
+}
+
+func byID(request *types.APIRequest, cache Cache, sampler Sampler) (types.APIObject, error) {
+	// users can only get advice about the schemas they have access to, the sample only contains the objects they can list
+	schema := request.Schemas.LookupSchema(request.Name)
+	if schema == nil || schema.Store == nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.NotFound, "no such schema")
+	}
+
+	sample, err := sampler.Sample(request, schema, sampleSize)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+
+	indexed := map[string]bool{}
+	var indexedFields []string
+	for _, field := range cache.IndexedFields(schema) {
+		name := strings.Join(field, ".")
+		indexed[name] = true
+		indexedFields = append(indexedFields, name)
+	}
+
+	return types.APIObject{
+		ID:   schema.ID,
+		Type: "cacheAdvisor",
+		Object: Advice{
+			IndexedFields:   indexedFields,
+			SampleSize:      len(sample),
+			SuggestedFields: suggestFields(sample, indexed),
+			RequestedFields: rankRequests(cache.UnindexedFields(schema)),
+		},
+	}, nil
+}
+
+// suggestFields returns the indexable fields present in at least minCoverage of the sample, most common first
+func suggestFields(sample []unstructured.Unstructured, indexed map[string]bool) []FieldSuggestion {
+	counts := map[string]int{}
+	for _, obj := range sample {
+		paths := map[string]bool{}
+		for key, value := range obj.Object {
+			if ignoredFields[key] {
+				continue
+			}
+			if key == "metadata" {
+				if m, ok := value.(map[string]interface{}); ok {
+					collectLabels(m, paths)
+				}
+				continue
+			}
+			collectPaths(key, value, paths)
+		}
+		for path := range paths {
+			counts[path]++
+		}
+	}
+
+	var result []FieldSuggestion
+	for path, count := range counts {
+		coverage := float64(count) / float64(len(sample))
+		if indexed[path] || coverage < minCoverage {
+			continue
+		}
+		result = append(result, FieldSuggestion{Field: path, Coverage: coverage})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Coverage != result[j].Coverage {
+			return result[i].Coverage > result[j].Coverage
+		}
+		return result[i].Field < result[j].Field
+	})
+	return result
+}
+
+// rankRequests returns the requested fields, most requested first
+func rankRequests(counts map[string]int) []FieldRequest {
+	result := make([]FieldRequest, 0, len(counts))
+	for field, count := range counts {
+		result = append(result, FieldRequest{Field: field, Count: count})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Count != result[j].Count {
+			return result[i].Count > result[j].Count
+		}
+		return result[i].Field < result[j].Field
+	})
+	return result
+}
+
+// collectPaths adds the paths of all scalar values under value to paths, skipping any subfield which can't be indexed
+func collectPaths(path string, value interface{}, paths map[string]bool) {
+	switch v := value.(type) {
+	case map[string]interface{}:
+		for key, child := range v {
+			if !fieldNameRegex.MatchString(key) {
+				continue
+			}
+			collectPaths(path+"."+key, child, paths)
+		}
+	case string, bool, int, int64, float64:
+		paths[path] = true
+	}
+}
+
+func collectLabels(metadata map[string]interface{}, paths map[string]bool) {
+	labels, _ := metadata["labels"].(map[string]interface{})
+	for key := range labels {
+		if keyRegex.MatchString(key) {
+			paths["metadata.labels["+key+"]"] = true
+		}
+	}
+}
@@ -0,0 +1,108 @@
+package cacheadvisor
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestSuggestFields(t *testing.T) {
+	sample := []unstructured.Unstructured{
+		{
+			Object: map[string]interface{}{
+				"apiVersion": "example.io/v1",
+				"kind":       "Widget",
+				"metadata": map[string]interface{}{
+					"name": "a",
+					"labels": map[string]interface{}{
+						"app":         "web",
+						"invalid-key": "x",
+					},
+				},
+				"spec": map[string]interface{}{
+					"size":      int64(1),
+					"color":     "blue",
+					"tags":      []interface{}{"x"},
+					"with-dash": "y",
+					"nested": map[string]interface{}{
+						"enabled": true,
+					},
+				},
+			},
+		},
+		{
+			Object: map[string]interface{}{
+				"apiVersion": "example.io/v1",
+				"kind":       "Widget",
+				"metadata": map[string]interface{}{
+					"name": "b",
+					"labels": map[string]interface{}{
+						"app": "db",
+					},
+				},
+				"spec": map[string]interface{}{
+					"size": int64(2),
+				},
+			},
+		},
+		{
+			Object: map[string]interface{}{
+				"apiVersion": "example.io/v1",
+				"kind":       "Widget",
+				"metadata": map[string]interface{}{
+					"name": "c",
+				},
+				"spec": map[string]interface{}{
+					"size": int64(3),
+				},
+			},
+		},
+	}
+	tests := []struct {
+		name     string
+		sample   []unstructured.Unstructured
+		indexed  map[string]bool
+		expected []FieldSuggestion
+	}{
+		{
+			name:    "common scalar fields and labels are suggested",
+			sample:  sample,
+			indexed: map[string]bool{},
+			expected: []FieldSuggestion{
+				{Field: "spec.size", Coverage: 1},
+				{Field: "metadata.labels[app]", Coverage: 2.0 / 3},
+			},
+		},
+		{
+			name:    "indexed fields are not suggested",
+			sample:  sample,
+			indexed: map[string]bool{"spec.size": true},
+			expected: []FieldSuggestion{
+				{Field: "metadata.labels[app]", Coverage: 2.0 / 3},
+			},
+		},
+		{
+			name:    "empty sample",
+			indexed: map[string]bool{},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, suggestFields(test.sample, test.indexed))
+		})
+	}
+}
+
+func TestRankRequests(t *testing.T) {
+	assert.Equal(t, []FieldRequest{}, rankRequests(nil))
+	assert.Equal(t, []FieldRequest{
+		{Field: "spec.hostname", Count: 5},
+		{Field: "metadata.labels[tier]", Count: 2},
+		{Field: "spec.priority", Count: 2},
+	}, rankRequests(map[string]int{
+		"spec.priority":         2,
+		"spec.hostname":         5,
+		"metadata.labels[tier]": 2,
+	}))
+}
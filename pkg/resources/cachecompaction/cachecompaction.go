@@ -0,0 +1,62 @@
+// Package cachecompaction provides the cacheCompaction schema, which lists the last compactions of the database of the
+// SQL cache and lets administrators compact it on demand.
+package cachecompaction
+
+import (
+	"context"
+	"strconv"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+)
+
+// Compactor compacts the database of the SQL cache
+type Compactor interface {
+	Compact(ctx context.Context) (db.CompactionResult, error)
+	Results() []db.CompactionResult
+}
+
+// Register registers the cacheCompaction schema. Listing it returns the results of the last compactions, and creating
+// one compacts the database, returning its result. Both are restricted to administrators, that is users granted all
+// verbs on all resources.
+func Register(baseSchema *types.APISchemas, compactor Compactor, asl accesscontrol.AccessSetLookup) {
+	baseSchema.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID:                "cacheCompaction",
+			PluralName:        "cacheCompactions",
+			CollectionMethods: []string{"GET", "POST"},
+		},
+		ListHandler: func(request *types.APIRequest) (types.APIObjectList, error) {
+			if _, err := accesscontrol.CheckAdmin(request, asl, "compacting the cache"); err != nil {
+				return types.APIObjectList{}, err
+			}
+			result := types.APIObjectList{}
+			for _, compaction := range compactor.Results() {
+				result.Objects = append(result.Objects, toAPIObject(compaction))
+			}
+			return result, nil
+		},
+		CreateHandler: func(request *types.APIRequest) (types.APIObject, error) {
+			if _, err := accesscontrol.CheckAdmin(request, asl, "compacting the cache"); err != nil {
+				return types.APIObject{}, err
+			}
+			result, err := compactor.Compact(request.Context())
+			if err != nil {
+				return types.APIObject{}, apierror.NewAPIError(validation.ServerError, "compaction failed: "+err.Error())
+			}
+			return toAPIObject(result), nil
+		},
+	})
+}
+
+func toAPIObject(result db.CompactionResult) types.APIObject {
+	return types.APIObject{
+		ID:     strconv.FormatInt(result.Start.UnixNano(), 10),
+		Type:   "cacheCompaction",
+		Object: result,
+	}
+}
@@ -0,0 +1,89 @@
+package cachecompaction
+
+import (
+	"context"
+	"errors"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+type fakeCompactor struct {
+	results []db.CompactionResult
+	err     error
+}
+
+func (f *fakeCompactor) Compact(ctx context.Context) (db.CompactionResult, error) {
+	result := db.CompactionResult{Start: time.Unix(int64(len(f.results)+1), 0), FreedBytes: 4096}
+	f.results = append([]db.CompactionResult{result}, f.results...)
+	return result, f.err
+}
+
+func (f *fakeCompactor) Results() []db.CompactionResult {
+	return f.results
+}
+
+type fakeAccessSetLookup map[string]*accesscontrol.AccessSet
+
+func (f fakeAccessSetLookup) AccessFor(user user.Info) *accesscontrol.AccessSet {
+	if access, ok := f[user.GetName()]; ok {
+		return access
+	}
+	return &accesscontrol.AccessSet{}
+}
+
+func (f fakeAccessSetLookup) PurgeUserData(_ string) {}
+
+func TestRegister(t *testing.T) {
+	admin := &accesscontrol.AccessSet{}
+	all := k8sschema.GroupResource{Group: accesscontrol.All, Resource: accesscontrol.All}
+	admin.Add(accesscontrol.All, all, accesscontrol.Access{Namespace: accesscontrol.All, ResourceName: accesscontrol.All})
+	reader := &accesscontrol.AccessSet{}
+	reader.Add("list", all, accesscontrol.Access{Namespace: accesscontrol.All, ResourceName: accesscontrol.All})
+	asl := fakeAccessSetLookup{"admin": admin, "reader": reader}
+
+	compactor := &fakeCompactor{}
+	baseSchemas := types.EmptyAPISchemas()
+	Register(baseSchemas, compactor, asl)
+	schema := baseSchemas.LookupSchema("cacheCompaction")
+	require.NotNil(t, schema)
+
+	requestFor := func(name string) *types.APIRequest {
+		req := httptest.NewRequest("POST", "/v1/cacheCompactions", nil)
+		req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: name}))
+		return &types.APIRequest{Request: req}
+	}
+
+	// only administrators can compact the cache or list compactions
+	_, err := schema.CreateHandler(requestFor("reader"))
+	assert.Error(t, err)
+	_, err = schema.ListHandler(requestFor("reader"))
+	assert.Error(t, err)
+	assert.Empty(t, compactor.results)
+
+	obj, err := schema.CreateHandler(requestFor("admin"))
+	require.NoError(t, err)
+	assert.Equal(t, "cacheCompaction", obj.Type)
+	assert.Equal(t, compactor.results[0], obj.Object)
+	_, err = schema.CreateHandler(requestFor("admin"))
+	require.NoError(t, err)
+
+	list, err := schema.ListHandler(requestFor("admin"))
+	require.NoError(t, err)
+	require.Len(t, list.Objects, 2)
+	assert.Equal(t, compactor.results[0], list.Objects[0].Object)
+	assert.NotEqual(t, list.Objects[0].ID, list.Objects[1].ID)
+
+	compactor.err = errors.New("database is locked")
+	_, err = schema.CreateHandler(requestFor("admin"))
+	assert.Error(t, err)
+}
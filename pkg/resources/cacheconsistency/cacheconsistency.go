@@ -0,0 +1,43 @@
+// Package cacheconsistency provides the cacheConsistency schema, which lists the discrepancies found on start between
+// the tables of the database of the SQL cache kept from an earlier run and the types discovered in the cluster.
+package cacheconsistency
+
+import (
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+)
+
+// Checker is a database of the SQL cache whose consistency is checked on start
+type Checker interface {
+	// ConsistencyReport returns the report of the last check, false if it wasn't checked yet
+	ConsistencyReport() (db.ConsistencyReport, bool)
+}
+
+// Register registers the cacheConsistency schema. Listing it returns the report of the last consistency check, if the
+// database was checked already, which is restricted to administrators, that is users granted all verbs on all
+// resources.
+func Register(baseSchema *types.APISchemas, checker Checker, asl accesscontrol.AccessSetLookup) {
+	baseSchema.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID:                "cacheConsistency",
+			PluralName:        "cacheConsistencies",
+			CollectionMethods: []string{"GET"},
+		},
+		ListHandler: func(request *types.APIRequest) (types.APIObjectList, error) {
+			if _, err := accesscontrol.CheckAdmin(request, asl, "checking the consistency of the cache"); err != nil {
+				return types.APIObjectList{}, err
+			}
+			result := types.APIObjectList{}
+			if report, ok := checker.ConsistencyReport(); ok {
+				result.Objects = append(result.Objects, types.APIObject{
+					ID:     report.Database,
+					Type:   "cacheConsistency",
+					Object: report,
+				})
+			}
+			return result, nil
+		},
+	})
+}
@@ -0,0 +1,74 @@
+package cacheconsistency
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+type fakeChecker struct {
+	report *db.ConsistencyReport
+}
+
+func (f *fakeChecker) ConsistencyReport() (db.ConsistencyReport, bool) {
+	if f.report == nil {
+		return db.ConsistencyReport{}, false
+	}
+	return *f.report, true
+}
+
+type fakeAccessSetLookup map[string]*accesscontrol.AccessSet
+
+func (f fakeAccessSetLookup) AccessFor(user user.Info) *accesscontrol.AccessSet {
+	if access, ok := f[user.GetName()]; ok {
+		return access
+	}
+	return &accesscontrol.AccessSet{}
+}
+
+func (f fakeAccessSetLookup) PurgeUserData(_ string) {}
+
+func TestRegister(t *testing.T) {
+	admin := &accesscontrol.AccessSet{}
+	all := k8sschema.GroupResource{Group: accesscontrol.All, Resource: accesscontrol.All}
+	admin.Add(accesscontrol.All, all, accesscontrol.Access{Namespace: accesscontrol.All, ResourceName: accesscontrol.All})
+	reader := &accesscontrol.AccessSet{}
+	reader.Add("list", all, accesscontrol.Access{Namespace: accesscontrol.All, ResourceName: accesscontrol.All})
+	asl := fakeAccessSetLookup{"admin": admin, "reader": reader}
+
+	checker := &fakeChecker{}
+	baseSchemas := types.EmptyAPISchemas()
+	Register(baseSchemas, checker, asl)
+	schema := baseSchemas.LookupSchema("cacheConsistency")
+	require.NotNil(t, schema)
+
+	requestFor := func(name string) *types.APIRequest {
+		req := httptest.NewRequest("GET", "/v1/cacheConsistencies", nil)
+		req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: name}))
+		return &types.APIRequest{Request: req}
+	}
+
+	// only administrators can list the reports
+	_, err := schema.ListHandler(requestFor("reader"))
+	assert.Error(t, err)
+
+	// nothing is listed until the database is checked
+	list, err := schema.ListHandler(requestFor("admin"))
+	require.NoError(t, err)
+	assert.Empty(t, list.Objects)
+
+	checker.report = &db.ConsistencyReport{Database: "informer_object_cache.db", ExtraTables: []string{"example.com_v1_Gone"}}
+	list, err = schema.ListHandler(requestFor("admin"))
+	require.NoError(t, err)
+	require.Len(t, list.Objects, 1)
+	assert.Equal(t, "informer_object_cache.db", list.Objects[0].ID)
+	assert.Equal(t, *checker.report, list.Objects[0].Object)
+}
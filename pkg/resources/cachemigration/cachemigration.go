@@ -0,0 +1,43 @@
+// Package cachemigration provides the cacheMigration schema, which lists the status of the layout of the databases of
+// the SQL cache, such as the migrations applied to them when they were opened.
+package cachemigration
+
+import (
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+)
+
+// Source is a database whose layout is migrated, such as the database of the SQL cache, or the snapshot it is
+// bootstrapped from
+type Source interface {
+	MigrationStatus() db.MigrationStatus
+}
+
+// Register registers the cacheMigration schema. Listing it returns the status of the layout of each source, which is
+// restricted to administrators, that is users granted all verbs on all resources.
+func Register(baseSchema *types.APISchemas, asl accesscontrol.AccessSetLookup, sources ...Source) {
+	baseSchema.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID:                "cacheMigration",
+			PluralName:        "cacheMigrations",
+			CollectionMethods: []string{"GET"},
+		},
+		ListHandler: func(request *types.APIRequest) (types.APIObjectList, error) {
+			if _, err := accesscontrol.CheckAdmin(request, asl, "listing cache migrations"); err != nil {
+				return types.APIObjectList{}, err
+			}
+			result := types.APIObjectList{}
+			for _, source := range sources {
+				status := source.MigrationStatus()
+				result.Objects = append(result.Objects, types.APIObject{
+					ID:     status.Database,
+					Type:   "cacheMigration",
+					Object: status,
+				})
+			}
+			return result, nil
+		},
+	})
+}
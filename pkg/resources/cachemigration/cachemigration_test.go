@@ -0,0 +1,66 @@
+package cachemigration
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+type fakeSource db.MigrationStatus
+
+func (f fakeSource) MigrationStatus() db.MigrationStatus {
+	return db.MigrationStatus(f)
+}
+
+type fakeAccessSetLookup map[string]*accesscontrol.AccessSet
+
+func (f fakeAccessSetLookup) AccessFor(user user.Info) *accesscontrol.AccessSet {
+	if access, ok := f[user.GetName()]; ok {
+		return access
+	}
+	return &accesscontrol.AccessSet{}
+}
+
+func (f fakeAccessSetLookup) PurgeUserData(_ string) {}
+
+func TestRegister(t *testing.T) {
+	admin := &accesscontrol.AccessSet{}
+	all := k8sschema.GroupResource{Group: accesscontrol.All, Resource: accesscontrol.All}
+	admin.Add(accesscontrol.All, all, accesscontrol.Access{Namespace: accesscontrol.All, ResourceName: accesscontrol.All})
+	reader := &accesscontrol.AccessSet{}
+	reader.Add("list", all, accesscontrol.Access{Namespace: accesscontrol.All, ResourceName: accesscontrol.All})
+	asl := fakeAccessSetLookup{"admin": admin, "reader": reader}
+
+	cache := fakeSource{Database: "informer_object_cache.db", Version: 0, SchemaVersion: db.SchemaVersion, Kept: true,
+		Applied: []db.AppliedMigration{{Version: 1, Description: "index"}}}
+	snapshot := fakeSource{Database: "snapshot.db", Version: db.SchemaVersion, SchemaVersion: db.SchemaVersion}
+	baseSchemas := types.EmptyAPISchemas()
+	Register(baseSchemas, asl, cache, snapshot)
+	schema := baseSchemas.LookupSchema("cacheMigration")
+	require.NotNil(t, schema)
+
+	requestFor := func(name string) *types.APIRequest {
+		req := httptest.NewRequest("GET", "/v1/cacheMigrations", nil)
+		req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: name}))
+		return &types.APIRequest{Request: req}
+	}
+
+	// only administrators can list migrations
+	_, err := schema.ListHandler(requestFor("reader"))
+	assert.Error(t, err)
+
+	list, err := schema.ListHandler(requestFor("admin"))
+	require.NoError(t, err)
+	require.Len(t, list.Objects, 2)
+	assert.Equal(t, "informer_object_cache.db", list.Objects[0].ID)
+	assert.Equal(t, db.MigrationStatus(cache), list.Objects[0].Object)
+	assert.Equal(t, db.MigrationStatus(snapshot), list.Objects[1].Object)
+}
@@ -0,0 +1,125 @@
+// Package cachesnapshot provides the cacheSnapshot schema, which lets administrators take consistent snapshots of the
+// database of the SQL cache and download them.
+package cachesnapshot
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"os"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+)
+
+// Snapshotter snapshots the database of the SQL cache
+type Snapshotter interface {
+	Create(ctx context.Context) (db.Snapshot, error)
+	Get(id string) (db.Snapshot, error)
+	List() ([]db.Snapshot, error)
+	Open(id string) (*os.File, error)
+	Delete(id string) error
+}
+
+// Register registers the cacheSnapshot schema. Creating one snapshots the database, getting one with the download
+// query parameter downloads its file. All methods are restricted to administrators, that is users granted all verbs
+// on all resources.
+func Register(baseSchema *types.APISchemas, snapshotter Snapshotter, asl accesscontrol.AccessSetLookup) {
+	baseSchema.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID:                "cacheSnapshot",
+			PluralName:        "cacheSnapshots",
+			CollectionMethods: []string{"GET", "POST"},
+			ResourceMethods:   []string{"GET", "DELETE"},
+		},
+		ListHandler: func(request *types.APIRequest) (types.APIObjectList, error) {
+			if _, err := accesscontrol.CheckAdmin(request, asl, "snapshotting the cache"); err != nil {
+				return types.APIObjectList{}, err
+			}
+			snapshots, err := snapshotter.List()
+			if err != nil {
+				return types.APIObjectList{}, apierror.NewAPIError(validation.ServerError, "listing snapshots failed: "+err.Error())
+			}
+			result := types.APIObjectList{}
+			for _, snapshot := range snapshots {
+				result.Objects = append(result.Objects, toAPIObject(snapshot))
+			}
+			return result, nil
+		},
+		ByIDHandler: func(request *types.APIRequest) (types.APIObject, error) {
+			if _, err := accesscontrol.CheckAdmin(request, asl, "snapshotting the cache"); err != nil {
+				return types.APIObject{}, err
+			}
+			if _, ok := request.Query["download"]; ok {
+				return types.APIObject{}, download(request, snapshotter)
+			}
+			snapshot, err := snapshotter.Get(request.Name)
+			if err != nil {
+				return types.APIObject{}, toAPIError(err)
+			}
+			return toAPIObject(snapshot), nil
+		},
+		CreateHandler: func(request *types.APIRequest) (types.APIObject, error) {
+			if _, err := accesscontrol.CheckAdmin(request, asl, "snapshotting the cache"); err != nil {
+				return types.APIObject{}, err
+			}
+			snapshot, err := snapshotter.Create(request.Context())
+			if err != nil {
+				return types.APIObject{}, apierror.NewAPIError(validation.ServerError, "snapshot failed: "+err.Error())
+			}
+			return toAPIObject(snapshot), nil
+		},
+		DeleteHandler: func(request *types.APIRequest) (types.APIObject, error) {
+			if _, err := accesscontrol.CheckAdmin(request, asl, "snapshotting the cache"); err != nil {
+				return types.APIObject{}, err
+			}
+			snapshot, err := snapshotter.Get(request.Name)
+			if err != nil {
+				return types.APIObject{}, toAPIError(err)
+			}
+			if err := snapshotter.Delete(request.Name); err != nil {
+				return types.APIObject{}, toAPIError(err)
+			}
+			return toAPIObject(snapshot), nil
+		},
+	})
+}
+
+// download writes the file of the snapshot as the response
+func download(request *types.APIRequest, snapshotter Snapshotter) error {
+	f, err := snapshotter.Open(request.Name)
+	if err != nil {
+		return toAPIError(err)
+	}
+	defer f.Close()
+	rw := request.Response
+	rw.Header().Set("Content-Type", "application/vnd.sqlite3")
+	rw.Header().Set("Content-Disposition", `attachment; filename="cache-snapshot-`+request.Name+`.db"`)
+	rw.WriteHeader(http.StatusOK)
+	if _, err := io.Copy(rw, f); err != nil {
+		// the response is already started, the error can only be logged
+		logrus.Errorf("Failed to download the cache snapshot %s: %v", request.Name, err)
+	}
+	return validation.ErrComplete
+}
+
+func toAPIError(err error) error {
+	if errors.Is(err, db.ErrSnapshotNotFound) {
+		return apierror.NewAPIError(validation.NotFound, err.Error())
+	}
+	return apierror.NewAPIError(validation.ServerError, err.Error())
+}
+
+func toAPIObject(snapshot db.Snapshot) types.APIObject {
+	return types.APIObject{
+		ID:     snapshot.ID,
+		Type:   "cacheSnapshot",
+		Object: snapshot,
+	}
+}
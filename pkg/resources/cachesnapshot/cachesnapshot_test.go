@@ -0,0 +1,139 @@
+package cachesnapshot
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+// fakeSnapshotter keeps snapshots as files holding their ID
+type fakeSnapshotter struct {
+	dir       string
+	snapshots []db.Snapshot
+}
+
+func (f *fakeSnapshotter) Create(context.Context) (db.Snapshot, error) {
+	snapshot := db.Snapshot{ID: time.Unix(int64(len(f.snapshots)+1), 0).Format("150405"), Size: 6}
+	f.snapshots = append([]db.Snapshot{snapshot}, f.snapshots...)
+	return snapshot, os.WriteFile(filepath.Join(f.dir, snapshot.ID), []byte(snapshot.ID), 0o600)
+}
+
+func (f *fakeSnapshotter) Get(id string) (db.Snapshot, error) {
+	for _, snapshot := range f.snapshots {
+		if snapshot.ID == id {
+			return snapshot, nil
+		}
+	}
+	return db.Snapshot{}, db.ErrSnapshotNotFound
+}
+
+func (f *fakeSnapshotter) List() ([]db.Snapshot, error) {
+	return f.snapshots, nil
+}
+
+func (f *fakeSnapshotter) Open(id string) (*os.File, error) {
+	if _, err := f.Get(id); err != nil {
+		return nil, err
+	}
+	return os.Open(filepath.Join(f.dir, id))
+}
+
+func (f *fakeSnapshotter) Delete(id string) error {
+	for i, snapshot := range f.snapshots {
+		if snapshot.ID == id {
+			f.snapshots = append(f.snapshots[:i], f.snapshots[i+1:]...)
+			return nil
+		}
+	}
+	return db.ErrSnapshotNotFound
+}
+
+type fakeAccessSetLookup map[string]*accesscontrol.AccessSet
+
+func (f fakeAccessSetLookup) AccessFor(user user.Info) *accesscontrol.AccessSet {
+	if access, ok := f[user.GetName()]; ok {
+		return access
+	}
+	return &accesscontrol.AccessSet{}
+}
+
+func (f fakeAccessSetLookup) PurgeUserData(_ string) {}
+
+func TestRegister(t *testing.T) {
+	admin := &accesscontrol.AccessSet{}
+	all := k8sschema.GroupResource{Group: accesscontrol.All, Resource: accesscontrol.All}
+	admin.Add(accesscontrol.All, all, accesscontrol.Access{Namespace: accesscontrol.All, ResourceName: accesscontrol.All})
+	reader := &accesscontrol.AccessSet{}
+	reader.Add("list", all, accesscontrol.Access{Namespace: accesscontrol.All, ResourceName: accesscontrol.All})
+	asl := fakeAccessSetLookup{"admin": admin, "reader": reader}
+
+	snapshotter := &fakeSnapshotter{dir: t.TempDir()}
+	baseSchemas := types.EmptyAPISchemas()
+	Register(baseSchemas, snapshotter, asl)
+	schema := baseSchemas.LookupSchema("cacheSnapshot")
+	require.NotNil(t, schema)
+
+	requestFor := func(name, id, query string) *types.APIRequest {
+		req := httptest.NewRequest(http.MethodGet, "/v1/cacheSnapshots/"+id+query, nil)
+		req = req.WithContext(request.WithUser(req.Context(), &user.DefaultInfo{Name: name}))
+		return &types.APIRequest{Request: req, Response: httptest.NewRecorder(), Name: id, Query: req.URL.Query()}
+	}
+	assertCode := func(t *testing.T, code validation.ErrorCode, err error) {
+		var apiErr *apierror.APIError
+		require.ErrorAs(t, err, &apiErr)
+		assert.Equal(t, code, apiErr.Code)
+	}
+
+	// only administrators can snapshot the cache or access the snapshots
+	_, err := schema.CreateHandler(requestFor("reader", "", ""))
+	assertCode(t, validation.PermissionDenied, err)
+	_, err = schema.ListHandler(requestFor("reader", "", ""))
+	assertCode(t, validation.PermissionDenied, err)
+	assert.Empty(t, snapshotter.snapshots)
+
+	obj, err := schema.CreateHandler(requestFor("admin", "", ""))
+	require.NoError(t, err)
+	assert.Equal(t, "cacheSnapshot", obj.Type)
+	assert.Equal(t, snapshotter.snapshots[0], obj.Object)
+	id := obj.ID
+	_, err = schema.ByIDHandler(requestFor("reader", id, "?download"))
+	assertCode(t, validation.PermissionDenied, err)
+
+	obj, err = schema.ByIDHandler(requestFor("admin", id, ""))
+	require.NoError(t, err)
+	assert.Equal(t, id, obj.ID)
+
+	req := requestFor("admin", id, "?download")
+	_, err = schema.ByIDHandler(req)
+	assert.Equal(t, validation.ErrComplete, err)
+	rw := req.Response.(*httptest.ResponseRecorder)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, id, rw.Body.String())
+	assert.Contains(t, rw.Header().Get("Content-Disposition"), "cache-snapshot-"+id+".db")
+
+	list, err := schema.ListHandler(requestFor("admin", "", ""))
+	require.NoError(t, err)
+	require.Len(t, list.Objects, 1)
+
+	_, err = schema.DeleteHandler(requestFor("admin", id, ""))
+	require.NoError(t, err)
+	_, err = schema.ByIDHandler(requestFor("admin", id, ""))
+	assertCode(t, validation.NotFound, err)
+	_, err = schema.ByIDHandler(requestFor("admin", id, "?download"))
+	assertCode(t, validation.NotFound, err)
+}
@@ -0,0 +1,256 @@
+// Package capi provides the built-in customizations of the types of Cluster API (CAPI): indexed display columns for
+// the phase and readiness of Machines, MachineDeployments and Clusters, and the capiMachineRollup schema, which
+// summarizes the machines of each cluster so that dashboards don't have to list every machine.
+package capi
+
+import (
+	"sort"
+	"strconv"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/resources/columns"
+	"github.com/rancher/steve/pkg/resources/virtual/computed"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	// Group and Version are those of the CAPI types customized by steve
+	Group   = "cluster.x-k8s.io"
+	Version = "v1beta1"
+
+	clusterNameLabel    = "cluster.x-k8s.io/cluster-name"
+	controlPlaneLabel   = "cluster.x-k8s.io/control-plane"
+	deploymentNameLabel = "cluster.x-k8s.io/deployment-name"
+
+	machineSchemaID = "cluster.x-k8s.io.machine"
+	rollupSchemaID  = "capiMachineRollup"
+)
+
+// Columns returns the display columns of the CAPI types, backed by computed fields so that lists can sort and filter
+// on them. Their names differ from those of the additionalPrinterColumns of the CAPI CRDs, which would hide them.
+func Columns() []columns.Column {
+	return []columns.Column{
+		{
+			Group:       Group,
+			Version:     Version,
+			Kind:        "Machine",
+			Name:        "Machine Phase",
+			Field:       "status.computed.machinePhase",
+			Compute:     phase,
+			Description: "The phase of the machine, such as Provisioning or Running",
+		},
+		{
+			Group:       Group,
+			Version:     Version,
+			Kind:        "Machine",
+			Name:        "Machine Ready",
+			Field:       "status.computed.machineReady",
+			Compute:     ready,
+			Description: "The status of the Ready condition of the machine",
+		},
+		{
+			Group:       Group,
+			Version:     Version,
+			Kind:        "MachineDeployment",
+			Name:        "Deployment Phase",
+			Field:       "status.computed.deploymentPhase",
+			Compute:     phase,
+			Description: "The phase of the machine deployment, such as ScalingUp or Running",
+		},
+		{
+			Group:       Group,
+			Version:     Version,
+			Kind:        "MachineDeployment",
+			Name:        "Ready Ratio",
+			Field:       "status.computed.readyRatio",
+			Compute:     computed.ReadyReplicasRatio,
+			Type:        "number",
+			Description: "The ratio of the desired machines of the machine deployment which are ready",
+		},
+		{
+			Group:       Group,
+			Version:     Version,
+			Kind:        "Cluster",
+			Name:        "Cluster Phase",
+			Field:       "status.computed.clusterPhase",
+			Compute:     phase,
+			Description: "The phase of the cluster, such as Provisioned or Deleting",
+		},
+		{
+			Group:       Group,
+			Version:     Version,
+			Kind:        "Cluster",
+			Name:        "Control Plane Ready",
+			Field:       "status.computed.controlPlaneReady",
+			Compute:     controlPlaneReady,
+			Type:        "boolean",
+			Description: "Whether the control plane of the cluster is ready",
+		},
+	}
+}
+
+// ready computes the status of the Ready condition of machines
+var ready = computed.ConditionStatus("Ready")
+
+func phase(obj *unstructured.Unstructured) (string, bool) {
+	value, ok, _ := unstructured.NestedString(obj.Object, "status", "phase")
+	return value, ok && value != ""
+}
+
+func controlPlaneReady(obj *unstructured.Unstructured) (string, bool) {
+	value, ok, _ := unstructured.NestedBool(obj.Object, "status", "controlPlaneReady")
+	if !ok {
+		return "", false
+	}
+	return strconv.FormatBool(value), true
+}
+
+// Rollup summarizes the machines of a cluster
+type Rollup struct {
+	Namespace string `json:"namespace"`
+	Cluster   string `json:"cluster"`
+	Machines  int    `json:"machines"`
+	// Ready counts the machines whose Ready condition is True
+	Ready int `json:"ready"`
+	// ControlPlane counts the machines of the control plane, and ControlPlaneReady those which are ready
+	ControlPlane      int `json:"controlPlane"`
+	ControlPlaneReady int `json:"controlPlaneReady"`
+	// Phases counts the machines by phase
+	Phases map[string]int `json:"phases,omitempty"`
+	// Deployments counts the machines by machine deployment
+	Deployments map[string]Count `json:"deployments,omitempty"`
+}
+
+// Count is the number of machines of a group, and how many of them are ready
+type Count struct {
+	Machines int `json:"machines"`
+	Ready    int `json:"ready"`
+}
+
+// Register registers the capiMachineRollup schema, which lists the rollup of the machines of each cluster among the
+// machines the user can list, and returns that of a single cluster by its namespace/name ID. Machines are listed from
+// the store of their schema, which is expected to be the SQL cache.
+func Register(baseSchema *types.APISchemas) {
+	baseSchema.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID:                rollupSchemaID,
+			PluralName:        "capiMachineRollups",
+			CollectionMethods: []string{"GET"},
+			ResourceMethods:   []string{"GET"},
+		},
+		ListHandler: list,
+		ByIDHandler: func(request *types.APIRequest) (types.APIObject, error) {
+			id := request.Name
+			if request.Namespace != "" {
+				id = request.Namespace + "/" + request.Name
+			}
+			rollups, err := list(request)
+			if err != nil {
+				return types.APIObject{}, err
+			}
+			for _, obj := range rollups.Objects {
+				if obj.ID == id {
+					return obj, nil
+				}
+			}
+			return types.APIObject{}, apierror.NewAPIError(validation.NotFound, "no machines for cluster "+id)
+		},
+	})
+}
+
+func list(request *types.APIRequest) (types.APIObjectList, error) {
+	result := types.APIObjectList{}
+	// pseudo-access check, the schema is only there if CAPI is installed and the user can list machines
+	machineSchema := request.Schemas.LookupSchema(machineSchemaID)
+	if machineSchema == nil || machineSchema.Store == nil {
+		return result, nil
+	}
+
+	listOp := request.Clone()
+	listOp.Schema = machineSchema
+	listOp.Type = machineSchema.ID
+	listOp.Name = ""
+	listOp.Link = ""
+	listOp.Request = request.Request.Clone(request.Context())
+	listOp.Request.URL.RawQuery = ""
+	machines, err := machineSchema.Store.List(listOp, machineSchema)
+	if err != nil {
+		return result, err
+	}
+
+	for _, rollup := range rollups(machines.Objects) {
+		result.Objects = append(result.Objects, types.APIObject{
+			ID:     rollup.Namespace + "/" + rollup.Cluster,
+			Type:   rollupSchemaID,
+			Object: rollup,
+		})
+	}
+	return result, nil
+}
+
+// rollups groups machines by cluster, skipping those without a cluster, sorted by namespace and cluster
+func rollups(machines []types.APIObject) []*Rollup {
+	byCluster := map[string]*Rollup{}
+	for _, machine := range machines {
+		obj := machine.Data()
+		cluster := obj.String("spec", "clusterName")
+		if cluster == "" {
+			cluster = obj.String("metadata", "labels", clusterNameLabel)
+		}
+		if cluster == "" {
+			continue
+		}
+		namespace := obj.String("metadata", "namespace")
+		key := namespace + "/" + cluster
+		rollup := byCluster[key]
+		if rollup == nil {
+			rollup = &Rollup{Namespace: namespace, Cluster: cluster}
+			byCluster[key] = rollup
+		}
+
+		status, _ := ready(&unstructured.Unstructured{Object: obj})
+		isReady := status == "True"
+		rollup.Machines++
+		if isReady {
+			rollup.Ready++
+		}
+		if _, ok := obj.Map("metadata", "labels")[controlPlaneLabel]; ok {
+			rollup.ControlPlane++
+			if isReady {
+				rollup.ControlPlaneReady++
+			}
+		}
+		if phase := obj.String("status", "phase"); phase != "" {
+			if rollup.Phases == nil {
+				rollup.Phases = map[string]int{}
+			}
+			rollup.Phases[phase]++
+		}
+		if deployment := obj.String("metadata", "labels", deploymentNameLabel); deployment != "" {
+			if rollup.Deployments == nil {
+				rollup.Deployments = map[string]Count{}
+			}
+			count := rollup.Deployments[deployment]
+			count.Machines++
+			if isReady {
+				count.Ready++
+			}
+			rollup.Deployments[deployment] = count
+		}
+	}
+
+	result := make([]*Rollup, 0, len(byCluster))
+	for _, rollup := range byCluster {
+		result = append(result, rollup)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].Namespace != result[j].Namespace {
+			return result[i].Namespace < result[j].Namespace
+		}
+		return result[i].Cluster < result[j].Cluster
+	})
+	return result
+}
@@ -0,0 +1,92 @@
+package capi
+
+import (
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/resources/columns"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestColumns(t *testing.T) {
+	c, err := columns.NewColumns(Columns())
+	require.NoError(t, err)
+	assert.Len(t, c.ComputedFields(), 6)
+
+	machine := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{
+			"phase": "Running",
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True"},
+			},
+		},
+	}}
+	value, ok := phase(machine)
+	assert.True(t, ok)
+	assert.Equal(t, "Running", value)
+	value, ok = ready(machine)
+	assert.True(t, ok)
+	assert.Equal(t, "True", value)
+
+	cluster := &unstructured.Unstructured{Object: map[string]interface{}{
+		"status": map[string]interface{}{"controlPlaneReady": false},
+	}}
+	value, ok = controlPlaneReady(cluster)
+	assert.True(t, ok)
+	assert.Equal(t, "false", value)
+	_, ok = phase(cluster)
+	assert.False(t, ok)
+}
+
+func machine(namespace, name, cluster, phase, ready string, labels map[string]interface{}) types.APIObject {
+	obj := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"namespace": namespace,
+			"name":      name,
+			"labels":    labels,
+		},
+		"spec": map[string]interface{}{
+			"clusterName": cluster,
+		},
+		"status": map[string]interface{}{
+			"phase": phase,
+			"conditions": []interface{}{
+				map[string]interface{}{"type": "Ready", "status": ready},
+			},
+		},
+	}
+	return types.APIObject{Object: &unstructured.Unstructured{Object: obj}}
+}
+
+func TestRollups(t *testing.T) {
+	machines := []types.APIObject{
+		machine("fleet", "cp-0", "a", "Running", "True", map[string]interface{}{controlPlaneLabel: ""}),
+		machine("fleet", "cp-1", "a", "Provisioning", "False", map[string]interface{}{controlPlaneLabel: ""}),
+		machine("fleet", "worker-0", "a", "Running", "True", map[string]interface{}{deploymentNameLabel: "workers"}),
+		machine("fleet", "worker-1", "a", "Running", "Unknown", map[string]interface{}{deploymentNameLabel: "workers"}),
+		machine("default", "b-0", "", "Running", "True", map[string]interface{}{clusterNameLabel: "b"}),
+		machine("default", "orphan", "", "Pending", "False", nil),
+	}
+
+	assert.Equal(t, []*Rollup{
+		{
+			Namespace: "default",
+			Cluster:   "b",
+			Machines:  1,
+			Ready:     1,
+			Phases:    map[string]int{"Running": 1},
+		},
+		{
+			Namespace:         "fleet",
+			Cluster:           "a",
+			Machines:          4,
+			Ready:             2,
+			ControlPlane:      2,
+			ControlPlaneReady: 1,
+			Phases:            map[string]int{"Running": 3, "Provisioning": 1},
+			Deployments:       map[string]Count{"workers": {Machines: 2, Ready: 1}},
+		},
+	}, rollups(machines))
+}
@@ -5,14 +5,17 @@ import (
 	"encoding/json"
 	"fmt"
 	"net/http"
+	"slices"
 
 	"github.com/pborman/uuid"
 	"github.com/rancher/apiserver/pkg/types"
 	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/resources/bulk"
 	steveschema "github.com/rancher/steve/pkg/schema"
 	"github.com/rancher/steve/pkg/stores/proxy"
 	"github.com/rancher/wrangler/v3/pkg/apply"
 	"github.com/rancher/wrangler/v3/pkg/yaml"
+	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
@@ -48,8 +51,19 @@ func (a *Apply) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 		return
 	}
 
-	if err := apply.WithDefaultNamespace(input.DefaultNamespace).ApplyObjects(objs...); err != nil {
-		apiContext.WriteError(err)
+	// objects are applied one by one to report their outcome separately, which requires not pruning the objects
+	// applied before
+	apply = apply.WithDefaultNamespace(input.DefaultNamespace).WithNoDelete()
+	objs = orderForApply(objs)
+
+	var bulkResult bulk.Result
+	for _, obj := range objs {
+		bulkResult.Items = append(bulkResult.Items, bulk.NewItemResult(obj, apply.ApplyObjects(obj)))
+	}
+	if bulkResult.Failed() {
+		if err := bulkResult.Write(rw); err != nil {
+			logrus.Errorf("failed to write apply result: %v", err)
+		}
 		return
 	}
 
@@ -62,6 +76,24 @@ func (a *Apply) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
 	apiContext.WriteResponseList(http.StatusOK, result)
 }
 
+// orderForApply returns objs with CRDs and namespaces first, as other objects may depend on them
+func orderForApply(objs []runtime.Object) []runtime.Object {
+	priority := func(obj runtime.Object) int {
+		switch obj.GetObjectKind().GroupVersionKind().GroupKind() {
+		case schema.GroupKind{Group: "apiextensions.k8s.io", Kind: "CustomResourceDefinition"}:
+			return 0
+		case schema.GroupKind{Kind: "Namespace"}:
+			return 1
+		}
+		return 2
+	}
+	result := slices.Clone(objs)
+	slices.SortStableFunc(result, func(a, b runtime.Object) int {
+		return priority(a) - priority(b)
+	})
+	return result
+}
+
 func (a *Apply) toAPIObject(apiContext *types.APIRequest, obj runtime.Object, defaultNamespace string) types.APIObject {
 	if defaultNamespace == "" {
 		defaultNamespace = "default"
@@ -0,0 +1,190 @@
+// Package columns adds display columns registered by embedders to the table columns of the schemas of their kinds, so
+// that they are rendered by clients along with those of Kubernetes without patching the columns of steve
+package columns
+
+import (
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/resources/common"
+	"github.com/rancher/steve/pkg/resources/virtual/computed"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/steve/pkg/schema/table"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// computedFieldRegex matches the fields of computed columns, whose names are restricted like those of computed fields
+var computedFieldRegex = regexp.MustCompile(`^status\.computed\.([a-zA-Z]+)$`)
+
+// columnTypes are the types of the additionalPrinterColumns of CRDs, which clients know how to render
+var columnTypes = []string{"string", "integer", "number", "boolean", "date"}
+
+// Column is a display column of the objects of a kind, backed by one of their fields. Fields need to be indexed by
+// the SQL cache, for example as label, annotation column or computed field, for lists to sort and filter on them.
+type Column struct {
+	Group   string
+	Version string
+	Kind    string
+	// Name is the header of the column
+	Name string
+	// Field is the path of the field displayed by the column, in the format of the filter and sort query params, such
+	// as metadata.labels[app.kubernetes.io/version]
+	Field string
+	// Compute derives the value of the column from objects, stored by the SQL cache as the computed field named by
+	// Field, which must then be status.computed.{name}
+	Compute computed.Func
+	// Type is one of string, integer, number, boolean or date, string if empty
+	Type        string
+	Format      string
+	Description string
+	// Priority is 0 for columns shown by default, higher for those only shown in wide views
+	Priority int
+}
+
+// GVK returns the GroupVersionKind the column applies to
+func (c Column) GVK() k8sschema.GroupVersionKind {
+	return k8sschema.GroupVersionKind{Group: c.Group, Version: c.Version, Kind: c.Kind}
+}
+
+// Validate returns an error if the column cannot be displayed
+func (c Column) Validate() error {
+	if c.Version == "" || c.Kind == "" {
+		return fmt.Errorf("column [%s] requires a version and a kind", c.Name)
+	}
+	if c.Name == "" || c.Field == "" {
+		return fmt.Errorf("column [%s] of %s requires a name and a field", c.Name, c.GVK())
+	}
+	if c.Compute != nil && !computedFieldRegex.MatchString(c.Field) {
+		return fmt.Errorf("computed column [%s] has field [%s], must be status.computed followed by letters only", c.Name, c.Field)
+	}
+	if c.Type != "" && !slices.Contains(columnTypes, c.Type) {
+		return fmt.Errorf("column [%s] has unsupported type [%s], must be one of %s", c.Name, c.Type, strings.Join(columnTypes, ", "))
+	}
+	return nil
+}
+
+// Columns holds validated columns by GVK
+type Columns struct {
+	byGVK map[k8sschema.GroupVersionKind][]Column
+}
+
+// NewColumns validates the given columns and returns them indexed by GVK
+func NewColumns(columns []Column) (*Columns, error) {
+	c := &Columns{
+		byGVK: map[k8sschema.GroupVersionKind][]Column{},
+	}
+	names := map[k8sschema.GroupVersionKind]map[string]bool{}
+	for _, column := range columns {
+		if err := column.Validate(); err != nil {
+			return nil, err
+		}
+		gvk := column.GVK()
+		if names[gvk] == nil {
+			names[gvk] = map[string]bool{}
+		}
+		if names[gvk][column.Name] {
+			return nil, fmt.Errorf("column [%s] is defined more than once for %s", column.Name, gvk)
+		}
+		names[gvk][column.Name] = true
+		c.byGVK[gvk] = append(c.byGVK[gvk], column)
+	}
+	return c, nil
+}
+
+// ComputedFields returns the computed fields backing computed columns, to be indexed by the SQL cache
+func (c *Columns) ComputedFields() []computed.Field {
+	if c == nil {
+		return nil
+	}
+	var fields []computed.Field
+	for _, columns := range c.byGVK {
+		for _, column := range columns {
+			if column.Compute == nil {
+				continue
+			}
+			fields = append(fields, computed.Field{
+				Group:   column.Group,
+				Version: column.Version,
+				Kind:    column.Kind,
+				Name:    computedFieldRegex.FindStringSubmatch(column.Field)[1],
+				Compute: column.Compute,
+			})
+		}
+	}
+	return fields
+}
+
+// Template returns a schema template appending the columns of kinds to the columns of their schemas, in the format of
+// the columns already set, which is that of the Table API for built-in types and that of additionalPrinterColumns for
+// CRDs. Columns whose name is already used by the schema are skipped.
+func Template(columns *Columns) schema.Template {
+	return schema.Template{
+		Customize: func(apiSchema *types.APISchema) {
+			if columns == nil {
+				return
+			}
+			extra := columns.byGVK[attributes.GVK(apiSchema)]
+			if len(extra) == 0 {
+				return
+			}
+			switch existing := attributes.Columns(apiSchema).(type) {
+			case nil:
+				attributes.SetColumns(apiSchema, appendDefinitions(nil, extra))
+			case []common.ColumnDefinition:
+				attributes.SetColumns(apiSchema, appendDefinitions(existing, extra))
+			case []table.Column:
+				attributes.SetColumns(apiSchema, appendTableColumns(existing, extra))
+			}
+		},
+	}
+}
+
+func appendDefinitions(existing []common.ColumnDefinition, extra []Column) []common.ColumnDefinition {
+	result := append([]common.ColumnDefinition{}, existing...)
+	for _, column := range extra {
+		if slices.ContainsFunc(existing, func(def common.ColumnDefinition) bool { return def.Name == column.Name }) {
+			continue
+		}
+		result = append(result, common.ColumnDefinition{
+			TableColumnDefinition: metav1.TableColumnDefinition{
+				Name:        column.Name,
+				Type:        typeOf(column),
+				Format:      column.Format,
+				Description: column.Description,
+				Priority:    int32(column.Priority),
+			},
+			Field: "$." + column.Field,
+		})
+	}
+	return result
+}
+
+func appendTableColumns(existing []table.Column, extra []Column) []table.Column {
+	result := append([]table.Column{}, existing...)
+	for _, column := range extra {
+		if slices.ContainsFunc(existing, func(col table.Column) bool { return col.Name == column.Name }) {
+			continue
+		}
+		result = append(result, table.Column{
+			Name:        column.Name,
+			Field:       "$." + column.Field,
+			Type:        typeOf(column),
+			Format:      column.Format,
+			Description: column.Description,
+			Priority:    column.Priority,
+		})
+	}
+	return result
+}
+
+func typeOf(column Column) string {
+	if column.Type == "" {
+		return "string"
+	}
+	return column.Type
+}
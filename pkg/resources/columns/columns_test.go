@@ -0,0 +1,126 @@
+package columns_test
+
+import (
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/resources/columns"
+	"github.com/rancher/steve/pkg/resources/common"
+	"github.com/rancher/steve/pkg/resources/virtual/computed"
+	"github.com/rancher/steve/pkg/schema/table"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestNewColumns(t *testing.T) {
+	tests := []struct {
+		name      string
+		columns   []columns.Column
+		wantError bool
+	}{
+		{
+			name: "valid columns",
+			columns: []columns.Column{
+				{Group: "apps", Version: "v1", Kind: "Deployment", Name: "Version", Field: "metadata.labels[app.kubernetes.io/version]"},
+				{Group: "apps", Version: "v1", Kind: "Deployment", Name: "Ready Ratio", Field: "status.computed.readyRatio", Compute: computed.ReadyReplicasRatio, Type: "number"},
+			},
+		},
+		{
+			name:      "missing kind",
+			columns:   []columns.Column{{Group: "apps", Version: "v1", Name: "Version", Field: "metadata.labels[version]"}},
+			wantError: true,
+		},
+		{
+			name:      "missing field",
+			columns:   []columns.Column{{Group: "apps", Version: "v1", Kind: "Deployment", Name: "Version"}},
+			wantError: true,
+		},
+		{
+			name:      "computed column outside of status.computed",
+			columns:   []columns.Column{{Group: "apps", Version: "v1", Kind: "Deployment", Name: "Ratio", Field: "status.ratio", Compute: computed.ReadyReplicasRatio}},
+			wantError: true,
+		},
+		{
+			name:      "unsupported type",
+			columns:   []columns.Column{{Group: "apps", Version: "v1", Kind: "Deployment", Name: "Version", Field: "metadata.labels[version]", Type: "int"}},
+			wantError: true,
+		},
+		{
+			name: "duplicated name",
+			columns: []columns.Column{
+				{Group: "apps", Version: "v1", Kind: "Deployment", Name: "Version", Field: "metadata.labels[version]"},
+				{Group: "apps", Version: "v1", Kind: "Deployment", Name: "Version", Field: "metadata.labels[release]"},
+			},
+			wantError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := columns.NewColumns(test.columns)
+			if test.wantError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestComputedFields(t *testing.T) {
+	c, err := columns.NewColumns([]columns.Column{
+		{Group: "apps", Version: "v1", Kind: "Deployment", Name: "Version", Field: "metadata.labels[version]"},
+		{Group: "apps", Version: "v1", Kind: "Deployment", Name: "Ready Ratio", Field: "status.computed.readyRatio", Compute: computed.ReadyReplicasRatio},
+	})
+	require.NoError(t, err)
+	fields := c.ComputedFields()
+	require.Len(t, fields, 1)
+	assert.Equal(t, "readyRatio", fields[0].Name)
+	assert.Equal(t, "Deployment", fields[0].Kind)
+	_, err = computed.NewFields(fields)
+	assert.NoError(t, err)
+}
+
+func TestTemplate(t *testing.T) {
+	c, err := columns.NewColumns([]columns.Column{
+		{Group: "apps", Version: "v1", Kind: "Deployment", Name: "Version", Field: "metadata.labels[version]", Priority: 1},
+		{Group: "example.io", Version: "v1", Kind: "Widget", Name: "Ratio", Field: "status.computed.ratio", Compute: computed.ReadyReplicasRatio, Type: "number"},
+	})
+	require.NoError(t, err)
+	customize := columns.Template(c).Customize
+	newSchema := func(group, kind string, cols interface{}) *types.APISchema {
+		s := &types.APISchema{Schema: &schemas.Schema{Attributes: map[string]interface{}{}}}
+		attributes.SetGVK(s, k8sschema.GroupVersionKind{Group: group, Version: "v1", Kind: kind})
+		if cols != nil {
+			attributes.SetColumns(s, cols)
+		}
+		return s
+	}
+
+	// columns of built-in types follow those of the Table API
+	deployment := newSchema("apps", "Deployment", []common.ColumnDefinition{
+		{TableColumnDefinition: metav1.TableColumnDefinition{Name: "Name", Type: "string"}, Field: "$.metadata.fields[0]"},
+	})
+	customize(deployment)
+	customize(deployment)
+	assert.Equal(t, []common.ColumnDefinition{
+		{TableColumnDefinition: metav1.TableColumnDefinition{Name: "Name", Type: "string"}, Field: "$.metadata.fields[0]"},
+		{TableColumnDefinition: metav1.TableColumnDefinition{Name: "Version", Type: "string", Priority: 1}, Field: "$.metadata.labels[version]"},
+	}, attributes.Columns(deployment), "columns are only added once")
+
+	// columns of CRDs follow their additionalPrinterColumns
+	widget := newSchema("example.io", "Widget", []table.Column{{Name: "Size", Field: ".spec.size", Type: "integer"}})
+	customize(widget)
+	assert.Equal(t, []table.Column{
+		{Name: "Size", Field: ".spec.size", Type: "integer"},
+		{Name: "Ratio", Field: "$.status.computed.ratio", Type: "number"},
+	}, attributes.Columns(widget))
+
+	// other kinds are left alone
+	pod := newSchema("", "Pod", nil)
+	customize(pod)
+	assert.Nil(t, attributes.Columns(pod))
+}
@@ -7,6 +7,7 @@ import (
 	"github.com/rancher/apiserver/pkg/types"
 	"github.com/rancher/steve/pkg/accesscontrol"
 	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/resources/virtual/problems"
 	"github.com/rancher/steve/pkg/schema"
 	metricsStore "github.com/rancher/steve/pkg/stores/metrics"
 	"github.com/rancher/steve/pkg/stores/proxy"
@@ -23,14 +24,15 @@ import (
 func DefaultTemplate(clientGetter proxy.ClientGetter,
 	summaryCache *summarycache.SummaryCache,
 	asl accesscontrol.AccessSetLookup,
-	namespaceCache corecontrollers.NamespaceCache) schema.Template {
+	namespaceCache corecontrollers.NamespaceCache,
+	fieldManager string) schema.Template {
 	return schema.Template{
-		Store:     metricsStore.NewMetricsStore(proxy.NewProxyStore(clientGetter, summaryCache, asl, namespaceCache)),
+		Store:     metricsStore.NewMetricsStore(proxy.NewProxyStore(clientGetter, summaryCache, asl, namespaceCache, fieldManager)),
 		Formatter: formatter(summaryCache, asl),
 	}
 }
 
-// DefaultTemplateForStore provides a default schema template which uses a provided, pre-initialized store. Primarily used when creating a Template that uses a Lasso SQL store internally.
+// DefaultTemplateForStore provides a default schema template which uses a provided, pre-initialized store. Primarily used when creating a Template that uses a SQL store internally.
 func DefaultTemplateForStore(store types.Store,
 	summaryCache *summarycache.SummaryCache,
 	asl accesscontrol.AccessSetLookup) schema.Template {
@@ -132,6 +134,7 @@ func formatter(summarycache *summarycache.SummaryCache, asl accesscontrol.Access
 			data.PutValue(unstr.Object, rel, "metadata", "relationships")
 
 			summary.NormalizeConditions(unstr)
+			_, _ = problems.TransformCommon(unstr)
 
 			includeFields(request, unstr)
 			excludeFields(request, unstr)
@@ -143,6 +146,10 @@ func formatter(summarycache *summarycache.SummaryCache, asl accesscontrol.Access
 
 func includeFields(request *types.APIRequest, unstr *unstructured.Unstructured) {
 	if fields, ok := request.Query["include"]; ok {
+		// include=events alone adds the events of objects to the SQL cache lists, without selecting other fields
+		if len(fields) == 1 && fields[0] == "events" {
+			return
+		}
 		newObj := map[string]interface{}{}
 		for _, f := range fields {
 			fieldParts := strings.Split(f, ".")
@@ -181,6 +181,47 @@ func Test_includeFields(t *testing.T) {
 				},
 			},
 		},
+		{
+			name: "include events only",
+			request: &types.APIRequest{
+				Query: url.Values{
+					"include": []string{"events"},
+				},
+			},
+			unstr: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind":   "Pod",
+					"events": []interface{}{map[string]interface{}{"reason": "BackOff"}},
+				},
+			},
+			want: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind":   "Pod",
+					"events": []interface{}{map[string]interface{}{"reason": "BackOff"}},
+				},
+			},
+		},
+		{
+			name: "include events and other fields",
+			request: &types.APIRequest{
+				Query: url.Values{
+					"include": []string{"events", "metadata.name"},
+				},
+			},
+			unstr: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"kind":     "Pod",
+					"metadata": map[string]interface{}{"name": "web", "namespace": "default"},
+					"events":   []interface{}{map[string]interface{}{"reason": "BackOff"}},
+				},
+			},
+			want: &unstructured.Unstructured{
+				Object: map[string]interface{}{
+					"metadata": map[string]interface{}{"name": "web"},
+					"events":   []interface{}{map[string]interface{}{"reason": "BackOff"}},
+				},
+			},
+		},
 	}
 
 	for _, tt := range tests {
@@ -1,19 +1,36 @@
 package counts
 
 import (
+	"context"
+	"fmt"
 	"net/http"
 	"strconv"
+	"strings"
 	"sync"
 
+	"github.com/rancher/apiserver/pkg/apierror"
 	"github.com/rancher/apiserver/pkg/store/empty"
 	"github.com/rancher/apiserver/pkg/types"
 	"github.com/rancher/steve/pkg/accesscontrol"
 	"github.com/rancher/steve/pkg/attributes"
 	"github.com/rancher/steve/pkg/clustercache"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/steve/pkg/summarycache"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
 	"github.com/rancher/wrangler/v3/pkg/summary"
+	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/runtime"
 	schema2 "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	breakdownParam      = "breakdown"
+	namespacesBreakdown = "namespaces"
+	projectsBreakdown   = "projects"
+	projectIDLabel      = partition.ProjectIDLabel
 )
 
 var (
@@ -22,10 +39,11 @@ var (
 		"schema":  true,
 		"apiRoot": true,
 	}
+	namespaceGVK = schema2.GroupVersionKind{Version: "v1", Kind: "Namespace"}
 )
 
 // Register registers a new count schema. This schema isn't a true resource but instead returns counts for other resources
-func Register(schemas *types.APISchemas, ccache clustercache.ClusterCache) {
+func Register(schemas *types.APISchemas, ccache clustercache.ClusterCache, summarizer summarycache.Summarizer) {
 	schemas.MustImportAndCustomize(Count{}, func(schema *types.APISchema) {
 		schema.CollectionMethods = []string{http.MethodGet}
 		schema.ResourceMethods = []string{http.MethodGet}
@@ -38,11 +56,28 @@ func Register(schemas *types.APISchemas, ccache clustercache.ClusterCache) {
 			},
 		}
 		schema.Store = &Store{
-			ccache: ccache,
+			ccache:     ccache,
+			summarizer: summarizer,
 		}
 	})
 }
 
+// Counter counts the objects of a type belonging to any of the partitions by namespace, project and state, and returns
+// the highest resource version among them, such as the SQL cache from the fields tables of types
+type Counter interface {
+	CountStates(ctx context.Context, gvk schema2.GroupVersionKind, partitions []partition.Partition) ([]informer.StateCount, int, error)
+}
+
+// SetCounter makes the count schema count objects with counter, rather than by listing them from the cluster cache.
+// Types counter fails to count, such as those it doesn't cache yet, are still listed from the cluster cache.
+func SetCounter(schemas *types.APISchemas, counter Counter) {
+	if schema := schemas.LookupSchema("count"); schema != nil {
+		if store, ok := schema.Store.(*Store); ok {
+			store.counter = counter
+		}
+	}
+}
+
 type Count struct {
 	ID     string               `json:"id,omitempty"`
 	Counts map[string]ItemCount `json:"counts"`
@@ -69,7 +104,9 @@ func (s *Summary) DeepCopy() *Summary {
 type ItemCount struct {
 	Summary    Summary            `json:"summary,omitempty"`
 	Namespaces map[string]Summary `json:"namespaces,omitempty"`
-	Revision   int                `json:"-"`
+	// Projects are the counts by project ID, the value of the field.cattle.io/projectId label of namespaces
+	Projects map[string]Summary `json:"projects,omitempty"`
+	Revision int                `json:"-"`
 }
 
 func (i *ItemCount) DeepCopy() *ItemCount {
@@ -81,12 +118,53 @@ func (i *ItemCount) DeepCopy() *ItemCount {
 			r.Namespaces[k] = *v.DeepCopy()
 		}
 	}
+	if r.Projects != nil {
+		r.Projects = map[string]Summary{}
+		for k, v := range i.Projects {
+			r.Projects[k] = *v.DeepCopy()
+		}
+	}
 	return &r
 }
 
+// breakdown is how counts are broken down, in addition to their summary
+type breakdown struct {
+	namespaces bool
+	projects   bool
+}
+
+// parseBreakdown returns the breakdowns requested with the breakdown query param, counts being broken down by
+// namespace by default
+func parseBreakdown(apiOp *types.APIRequest) (breakdown, error) {
+	if apiOp.Request == nil || apiOp.Request.URL == nil {
+		return breakdown{namespaces: true}, nil
+	}
+	values := apiOp.Request.URL.Query()[breakdownParam]
+	if len(values) == 0 {
+		return breakdown{namespaces: true}, nil
+	}
+	var result breakdown
+	for _, value := range values {
+		for _, b := range strings.Split(value, ",") {
+			switch b {
+			case namespacesBreakdown:
+				result.namespaces = true
+			case projectsBreakdown:
+				result.projects = true
+			default:
+				return breakdown{}, apierror.NewAPIError(validation.InvalidOption,
+					fmt.Sprintf("invalid %s %q, must be %s or %s", breakdownParam, b, namespacesBreakdown, projectsBreakdown))
+			}
+		}
+	}
+	return result, nil
+}
+
 type Store struct {
 	empty.Store
-	ccache clustercache.ClusterCache
+	ccache     clustercache.ClusterCache
+	summarizer summarycache.Summarizer
+	counter    Counter
 }
 
 func toAPIObject(c Count) types.APIObject {
@@ -98,12 +176,20 @@ func toAPIObject(c Count) types.APIObject {
 }
 
 func (s *Store) ByID(apiOp *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
-	c := s.getCount(apiOp)
+	b, err := parseBreakdown(apiOp)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	c := s.getCount(apiOp, b)
 	return toAPIObject(c), nil
 }
 
 func (s *Store) List(apiOp *types.APIRequest, schema *types.APISchema) (types.APIObjectList, error) {
-	c := s.getCount(apiOp)
+	b, err := parseBreakdown(apiOp)
+	if err != nil {
+		return types.APIObjectList{}, err
+	}
+	c := s.getCount(apiOp, b)
 	return types.APIObjectList{
 		Objects: []types.APIObject{
 			toAPIObject(c),
@@ -113,10 +199,15 @@ func (s *Store) List(apiOp *types.APIRequest, schema *types.APISchema) (types.AP
 
 // Watch creates a watch for the Counts schema. This returns only the counts which have changed since the watch was established
 func (s *Store) Watch(apiOp *types.APIRequest, schema *types.APISchema, w types.WatchRequest) (chan types.APIEvent, error) {
+	b, err := parseBreakdown(apiOp)
+	if err != nil {
+		return nil, err
+	}
 	var (
 		result      = make(chan Count, 100)
 		counts      map[string]ItemCount
 		gvkToSchema = map[schema2.GroupVersionKind]*types.APISchema{}
+		accessByID  = map[string]accesscontrol.AccessListByVerb{}
 		countLock   sync.Mutex
 	)
 
@@ -128,7 +219,8 @@ func (s *Store) Watch(apiOp *types.APIRequest, schema *types.APISchema, w types.
 		countLock.Unlock()
 	}()
 
-	counts = s.getCount(apiOp).Counts
+	counts = s.getCount(apiOp, b).Counts
+	projects := s.projects(b)
 	for id := range counts {
 		schema := apiOp.Schemas.LookupSchema(id)
 		if schema == nil {
@@ -136,6 +228,7 @@ func (s *Store) Watch(apiOp *types.APIRequest, schema *types.APISchema, w types.
 		}
 
 		gvkToSchema[attributes.GVK(schema)] = schema
+		accessByID[schema.ID], _ = attributes.Access(schema).(accesscontrol.AccessListByVerb)
 	}
 
 	onChange := func(add bool, gvk schema2.GroupVersionKind, _ string, obj, oldObj runtime.Object) error {
@@ -146,37 +239,56 @@ func (s *Store) Watch(apiOp *types.APIRequest, schema *types.APISchema, w types.
 			return nil
 		}
 
+		// the counts of the namespaces of a project moved to another are moved with them, by counting all objects again
+		if projects != nil && gvk == namespaceGVK && oldObj != nil && projectOf(obj) != projectOf(oldObj) {
+			counts = s.getCount(apiOp, b).Counts
+			changedCounts := make(map[string]ItemCount, len(counts))
+			for id, itemCount := range counts {
+				changedCounts[id] = *itemCount.DeepCopy()
+			}
+			result <- Count{
+				ID:     "count",
+				Counts: changedCounts,
+			}
+			return nil
+		}
+
 		schema := gvkToSchema[gvk]
 		if schema == nil {
 			return nil
 		}
 
-		_, namespace, revision, summary, ok := getInfo(obj)
+		name, namespace, revision, summary, ok := getInfo(s.summarizer, obj)
 		if !ok {
 			return nil
 		}
+		// changes are only counted for the objects counted by getCount
+		if access := accessByID[schema.ID]; !access.Grants("list", "*", "*") && !listable(access, namespace, name) {
+			return nil
+		}
 
 		itemCount := counts[schema.ID]
 		if revision <= itemCount.Revision {
 			return nil
 		}
+		project := projects.of(namespace)
 
 		if oldObj != nil {
-			if _, _, _, oldSummary, ok := getInfo(oldObj); ok {
+			if _, _, _, oldSummary, ok := getInfo(s.summarizer, oldObj); ok {
 				if oldSummary.Transitioning == summary.Transitioning &&
 					oldSummary.Error == summary.Error &&
 					simpleState(oldSummary) == simpleState(summary) {
 					return nil
 				}
-				itemCount = removeCounts(itemCount, namespace, oldSummary)
-				itemCount = addCounts(itemCount, namespace, summary)
+				itemCount = removeCounts(itemCount, namespace, project, oldSummary)
+				itemCount = addCounts(itemCount, namespace, project, summary)
 			} else {
 				return nil
 			}
 		} else if add {
-			itemCount = addCounts(itemCount, namespace, summary)
+			itemCount = addCounts(itemCount, namespace, project, summary)
 		} else {
-			itemCount = removeCounts(itemCount, namespace, summary)
+			itemCount = removeCounts(itemCount, namespace, project, summary)
 		}
 
 		counts[schema.ID] = itemCount
@@ -230,7 +342,51 @@ func (s *Store) schemasToWatch(apiOp *types.APIRequest) (result []*types.APISche
 	return
 }
 
-func getInfo(obj interface{}) (name string, namespace string, revision int, summaryResult summary.Summary, ok bool) {
+// listPartitions returns the partitions of the objects counted for a user, those listable returns true for
+func listPartitions(access accesscontrol.AccessListByVerb) []partition.Partition {
+	if access.Grants("list", accesscontrol.All, accesscontrol.All) {
+		return []partition.Partition{{Passthrough: true}}
+	}
+	byNamespace := map[string]*partition.Partition{}
+	namespacePartition := func(namespace string) *partition.Partition {
+		if byNamespace[namespace] == nil {
+			byNamespace[namespace] = &partition.Partition{Namespace: namespace, Names: sets.New[string]()}
+		}
+		return byNamespace[namespace]
+	}
+	for namespace, resources := range access.Granted("list") {
+		p := namespacePartition(namespace)
+		p.All = p.All || resources.All
+		p.Names.Insert(resources.Names.UnsortedList()...)
+	}
+	for _, a := range access["get"] {
+		if a.ResourceName != accesscontrol.All {
+			namespacePartition(a.Namespace).Names.Insert(a.ResourceName)
+		}
+	}
+	result := make([]partition.Partition, 0, len(byNamespace))
+	for _, p := range byNamespace {
+		result = append(result, *p)
+	}
+	return result
+}
+
+// listable returns whether an object is in the lists of its type returned to a user: the objects they can list, and
+// those they can get by name, as the partitions of the SQL cache list them
+func listable(access accesscontrol.AccessListByVerb, namespace, name string) bool {
+	if access.Grants("list", namespace, name) {
+		return true
+	}
+	for _, a := range access["get"] {
+		if a.ResourceName != accesscontrol.All && a.ResourceName == name &&
+			(a.Namespace == accesscontrol.All || a.Namespace == namespace) {
+			return true
+		}
+	}
+	return false
+}
+
+func getInfo(summarizer summarycache.Summarizer, obj interface{}) (name string, namespace string, revision int, summaryResult summary.Summary, ok bool) {
 	r, ok := obj.(runtime.Object)
 	if !ok {
 		return "", "", 0, summaryResult, false
@@ -246,56 +402,87 @@ func getInfo(obj interface{}) (name string, namespace string, revision int, summ
 		return "", "", 0, summaryResult, false
 	}
 
-	summaryResult = summary.Summarize(r)
+	summaryResult = summarizer.Summarize(r).Summary
 	return meta.GetName(), meta.GetNamespace(), revision, summaryResult, true
 }
 
-func removeCounts(itemCount ItemCount, ns string, summary summary.Summary) ItemCount {
-	itemCount.Summary = removeSummary(itemCount.Summary, summary)
-	if ns != "" {
-		itemCount.Namespaces[ns] = removeSummary(itemCount.Namespaces[ns], summary)
+// removeCounts removes an object from the counts of a type, and of its namespace and project if the counts are broken
+// down by namespace and project
+func removeCounts(itemCount ItemCount, ns, project string, summary summary.Summary) ItemCount {
+	return adjustCounts(itemCount, ns, project, summary, -1)
+}
+
+// addCounts adds an object to the counts of a type, and of its namespace and project if the counts are broken down by
+// namespace and project
+func addCounts(itemCount ItemCount, ns, project string, summary summary.Summary) ItemCount {
+	return adjustCounts(itemCount, ns, project, summary, 1)
+}
+
+// adjustCounts adds n objects of a namespace and project in the state of summary to the counts of a type, removing
+// them if n is negative
+func adjustCounts(itemCount ItemCount, ns, project string, summary summary.Summary, n int) ItemCount {
+	itemCount.Summary = adjustSummary(itemCount.Summary, summary, n)
+	if ns != "" && itemCount.Namespaces != nil {
+		itemCount.Namespaces[ns] = adjustSummary(itemCount.Namespaces[ns], summary, n)
+	}
+	if project != "" && itemCount.Projects != nil {
+		itemCount.Projects[project] = adjustSummary(itemCount.Projects[project], summary, n)
 	}
 	return itemCount
 }
 
-func addCounts(itemCount ItemCount, ns string, summary summary.Summary) ItemCount {
-	itemCount.Summary = addSummary(itemCount.Summary, summary)
-	if ns != "" {
-		itemCount.Namespaces[ns] = addSummary(itemCount.Namespaces[ns], summary)
+// projectsByNamespace looks up the projects of namespaces, nil if counts aren't broken down by project
+type projectsByNamespace struct {
+	ccache clustercache.ClusterCache
+}
+
+func (s *Store) projects(b breakdown) *projectsByNamespace {
+	if !b.projects {
+		return nil
+	}
+	return &projectsByNamespace{
+		ccache: s.ccache,
 	}
-	return itemCount
 }
 
-func removeSummary(counts Summary, summary summary.Summary) Summary {
-	counts.Count--
-	if summary.Transitioning {
-		counts.Transitioning--
+// of returns the ID of the current project of a namespace, empty if it isn't in a project
+func (p *projectsByNamespace) of(namespace string) string {
+	if p == nil || namespace == "" {
+		return ""
 	}
-	if summary.Error {
-		counts.Error--
+	if obj, ok, err := p.ccache.Get(namespaceGVK, "", namespace); err == nil && ok {
+		return projectOf(obj)
 	}
-	if simpleState(summary) != "" {
-		if counts.States == nil {
-			counts.States = map[string]int{}
-		}
-		counts.States[simpleState(summary)]--
+	return ""
+}
+
+// projectOf returns the ID of the project of a namespace object, empty if it isn't in a project
+func projectOf(obj any) string {
+	r, ok := obj.(runtime.Object)
+	if !ok {
+		return ""
 	}
-	return counts
+	m, err := meta.Accessor(r)
+	if err != nil {
+		return ""
+	}
+	return m.GetLabels()[projectIDLabel]
 }
 
-func addSummary(counts Summary, summary summary.Summary) Summary {
-	counts.Count++
+// adjustSummary adds n objects in the state of summary to counts, removing them if n is negative
+func adjustSummary(counts Summary, summary summary.Summary, n int) Summary {
+	counts.Count += n
 	if summary.Transitioning {
-		counts.Transitioning++
+		counts.Transitioning += n
 	}
 	if summary.Error {
-		counts.Error++
+		counts.Error += n
 	}
 	if simpleState(summary) != "" {
 		if counts.States == nil {
 			counts.States = map[string]int{}
 		}
-		counts.States[simpleState(summary)]++
+		counts.States[simpleState(summary)] += n
 	}
 	return counts
 }
@@ -309,27 +496,45 @@ func simpleState(summary summary.Summary) string {
 	return ""
 }
 
-func (s *Store) getCount(apiOp *types.APIRequest) Count {
+func (s *Store) getCount(apiOp *types.APIRequest, b breakdown) Count {
 	counts := map[string]ItemCount{}
+	projects := s.projects(b)
 
 	for _, schema := range s.schemasToWatch(apiOp) {
 		gvk := attributes.GVK(schema)
 		access, _ := attributes.Access(schema).(accesscontrol.AccessListByVerb)
 
 		rev := 0
-		itemCount := ItemCount{
-			Namespaces: map[string]Summary{},
+		itemCount := ItemCount{}
+		if b.namespaces {
+			itemCount.Namespaces = map[string]Summary{}
+		}
+		if b.projects {
+			itemCount.Projects = map[string]Summary{}
+		}
+
+		if s.counter != nil {
+			stateCounts, revision, err := s.counter.CountStates(apiOp.Context(), gvk, listPartitions(access))
+			if err == nil {
+				for _, c := range stateCounts {
+					itemCount = adjustCounts(itemCount, c.Namespace, c.Project, summary.Summary{Error: c.Error, Transitioning: c.Transitioning}, c.Count)
+				}
+				itemCount.Revision = revision
+				counts[schema.ID] = itemCount
+				continue
+			}
+			logrus.Debugf("counting %s from the cluster cache: %v", schema.ID, err)
 		}
 
 		all := access.Grants("list", "*", "*")
 
 		for _, obj := range s.ccache.List(gvk) {
-			name, ns, revision, summary, ok := getInfo(obj)
+			name, ns, revision, summary, ok := getInfo(s.summarizer, obj)
 			if !ok {
 				continue
 			}
 
-			if !all && !access.Grants("list", ns, name) && !access.Grants("get", ns, name) {
+			if !all && !listable(access, ns, name) {
 				continue
 			}
 
@@ -337,7 +542,7 @@ func (s *Store) getCount(apiOp *types.APIRequest) Count {
 				rev = revision
 			}
 
-			itemCount = addCounts(itemCount, ns, summary)
+			itemCount = addCounts(itemCount, ns, projects.of(ns), summary)
 		}
 
 		itemCount.Revision = rev
@@ -4,6 +4,7 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"net/http/httptest"
 	"testing"
 	"time"
 
@@ -15,11 +16,15 @@ import (
 	"github.com/rancher/steve/pkg/clustercache"
 	"github.com/rancher/steve/pkg/resources/counts"
 	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/steve/pkg/summarycache"
 	"github.com/rancher/wrangler/v3/pkg/schemas"
 	"github.com/rancher/wrangler/v3/pkg/summary"
 	"github.com/stretchr/testify/assert"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	schema2 "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
 )
 
 const (
@@ -103,7 +108,7 @@ func TestWatch(t *testing.T) {
 			gvk := attributes.GVK(testSchema)
 			newGVK := attributes.GVK(testNewSchema)
 			fakeCache.AddSummaryObj(makeSummarizedObject(gvk, "testName1", "testNs", "1"))
-			counts.Register(testSchemas, fakeCache)
+			counts.Register(testSchemas, fakeCache, summarycache.DefaultSummarizer)
 
 			// next, get the channel our results will be delivered on
 			countSchema := testSchemas.LookupSchema("count")
@@ -171,6 +176,217 @@ func TestWatch(t *testing.T) {
 	}
 }
 
+func TestProjectBreakdown(t *testing.T) {
+	testSchema := makeSchema(testResource)
+	addGenericPermissionsToSchema(testSchema, "list")
+	testSchemas := types.EmptyAPISchemas()
+	testSchemas.MustAddSchema(*testSchema)
+	testOp := func(query string) *types.APIRequest {
+		return &types.APIRequest{
+			Schemas:       testSchemas,
+			AccessControl: &server.SchemaBasedAccess{},
+			Request:       httptest.NewRequest(http.MethodGet, "/v1/counts?"+query, nil),
+		}
+	}
+	fakeCache := NewFakeClusterCache()
+	namespaceGVK := schema2.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+	for name, project := range map[string]string{"ns1": "p-1", "ns2": "p-1", "ns3": ""} {
+		namespace := makeSummarizedObject(namespaceGVK, name, "", "1")
+		if project != "" {
+			namespace.Labels = map[string]string{"field.cattle.io/projectId": project}
+		}
+		fakeCache.AddSummaryObj(namespace)
+	}
+	gvk := attributes.GVK(testSchema)
+	fakeCache.AddSummaryObj(makeSummarizedObject(gvk, "a", "ns1", "1"))
+	fakeCache.AddSummaryObj(makeSummarizedObject(gvk, "b", "ns2", "2"))
+	fakeCache.AddSummaryObj(makeSummarizedObject(gvk, "c", "ns3", "3"))
+	counts.Register(testSchemas, fakeCache, summarycache.DefaultSummarizer)
+	countSchema := testSchemas.LookupSchema("count")
+
+	list, err := countSchema.Store.List(testOp("breakdown=projects"), countSchema)
+	assert.NoError(t, err)
+	itemCount := list.Objects[0].Object.(counts.Count).Counts[testResource]
+	assert.Equal(t, 3, itemCount.Summary.Count)
+	// namespaces without a project are only in the summary
+	assert.Equal(t, map[string]counts.Summary{"p-1": {Count: 2}}, itemCount.Projects)
+	assert.Nil(t, itemCount.Namespaces)
+
+	list, err = countSchema.Store.List(testOp("breakdown=namespaces,projects"), countSchema)
+	assert.NoError(t, err)
+	itemCount = list.Objects[0].Object.(counts.Count).Counts[testResource]
+	assert.Len(t, itemCount.Namespaces, 3)
+	assert.Len(t, itemCount.Projects, 1)
+
+	list, err = countSchema.Store.List(testOp(""), countSchema)
+	assert.NoError(t, err)
+	itemCount = list.Objects[0].Object.(counts.Count).Counts[testResource]
+	assert.Len(t, itemCount.Namespaces, 3)
+	assert.Nil(t, itemCount.Projects)
+
+	_, err = countSchema.Store.List(testOp("breakdown=clusters"), countSchema)
+	assert.Error(t, err)
+
+	resChannel, err := countSchema.Store.Watch(testOp("breakdown=projects"), countSchema, types.WatchRequest{})
+	assert.NoError(t, err)
+	err = fakeCache.addHandler(gvk, "n/a", makeSummarizedObject(gvk, "d", "ns2", "4"))
+	assert.NoError(t, err)
+	outputCount, err := receiveWithTimeout(resChannel, 100*time.Millisecond)
+	assert.NoError(t, err)
+	itemCount = outputCount.Object.Object.(counts.Count).Counts[testResource]
+	assert.Equal(t, map[string]counts.Summary{"p-1": {Count: 3}}, itemCount.Projects)
+}
+
+func TestLimitedAccess(t *testing.T) {
+	testSchema := makeSchema(testResource)
+	testSchema.CollectionMethods = []string{http.MethodGet}
+	testSchema.Attributes["access"] = accesscontrol.AccessListByVerb{
+		"list": {{Namespace: "ns1", ResourceName: "*"}},
+		"get": {
+			// objects granted by name are listed, others which can only be got aren't
+			{Namespace: "ns2", ResourceName: "b"},
+			{Namespace: "ns3", ResourceName: "*"},
+		},
+		"watch": {{Namespace: "ns1", ResourceName: "*"}},
+	}
+	testSchemas := types.EmptyAPISchemas()
+	testSchemas.MustAddSchema(*testSchema)
+	testOp := &types.APIRequest{
+		Schemas:       testSchemas,
+		AccessControl: &server.SchemaBasedAccess{},
+		Request:       httptest.NewRequest(http.MethodGet, "/v1/counts", nil),
+	}
+	fakeCache := NewFakeClusterCache()
+	gvk := attributes.GVK(testSchema)
+	fakeCache.AddSummaryObj(makeSummarizedObject(gvk, "a", "ns1", "1"))
+	fakeCache.AddSummaryObj(makeSummarizedObject(gvk, "b", "ns2", "2"))
+	fakeCache.AddSummaryObj(makeSummarizedObject(gvk, "c", "ns2", "3"))
+	fakeCache.AddSummaryObj(makeSummarizedObject(gvk, "d", "ns3", "4"))
+	counts.Register(testSchemas, fakeCache, summarycache.DefaultSummarizer)
+	countSchema := testSchemas.LookupSchema("count")
+
+	list, err := countSchema.Store.List(testOp, countSchema)
+	assert.NoError(t, err)
+	itemCount := list.Objects[0].Object.(counts.Count).Counts[testResource]
+	assert.Equal(t, 2, itemCount.Summary.Count)
+	assert.Equal(t, map[string]counts.Summary{"ns1": {Count: 1}, "ns2": {Count: 1}}, itemCount.Namespaces)
+
+	resChannel, err := countSchema.Store.Watch(testOp, countSchema, types.WatchRequest{})
+	assert.NoError(t, err)
+	err = fakeCache.addHandler(gvk, "n/a", makeSummarizedObject(gvk, "e", "ns3", "5"))
+	assert.NoError(t, err)
+	_, err = receiveWithTimeout(resChannel, 100*time.Millisecond)
+	assert.Error(t, err, "objects the user can't list aren't counted")
+
+	err = fakeCache.addHandler(gvk, "n/a", makeSummarizedObject(gvk, "f", "ns1", "6"))
+	assert.NoError(t, err)
+	outputCount, err := receiveWithTimeout(resChannel, 100*time.Millisecond)
+	assert.NoError(t, err)
+	itemCount = outputCount.Object.Object.(counts.Count).Counts[testResource]
+	assert.Equal(t, 3, itemCount.Summary.Count)
+	assert.Equal(t, map[string]counts.Summary{"ns1": {Count: 2}, "ns2": {Count: 1}}, itemCount.Namespaces)
+}
+
+// fakeCounter counts objects as the SQL cache would, recording the partitions of the last count
+type fakeCounter struct {
+	counts     []informer.StateCount
+	revision   int
+	err        error
+	partitions []partition.Partition
+}
+
+func (f *fakeCounter) CountStates(_ context.Context, _ schema2.GroupVersionKind, partitions []partition.Partition) ([]informer.StateCount, int, error) {
+	f.partitions = partitions
+	return f.counts, f.revision, f.err
+}
+
+func TestCounter(t *testing.T) {
+	testSchema := makeSchema(testResource)
+	testSchema.CollectionMethods = []string{http.MethodGet}
+	testSchema.Attributes["access"] = accesscontrol.AccessListByVerb{
+		"list":  {{Namespace: "ns1", ResourceName: "*"}},
+		"get":   {{Namespace: "ns2", ResourceName: "b"}},
+		"watch": {{Namespace: "ns1", ResourceName: "*"}},
+	}
+	testSchemas := types.EmptyAPISchemas()
+	testSchemas.MustAddSchema(*testSchema)
+	testOp := &types.APIRequest{
+		Schemas:       testSchemas,
+		AccessControl: &server.SchemaBasedAccess{},
+		Request:       httptest.NewRequest(http.MethodGet, "/v1/counts?breakdown=namespaces,projects", nil),
+	}
+	fakeCache := NewFakeClusterCache()
+	gvk := attributes.GVK(testSchema)
+	fakeCache.AddSummaryObj(makeSummarizedObject(gvk, "a", "ns1", "1"))
+	counts.Register(testSchemas, fakeCache, summarycache.DefaultSummarizer)
+	counter := &fakeCounter{
+		counts: []informer.StateCount{
+			{Namespace: "ns1", Project: "p-1", Count: 3},
+			{Namespace: "ns1", Project: "p-1", Error: true, Count: 2},
+			{Namespace: "ns2", Transitioning: true, Count: 1},
+		},
+		revision: 7,
+	}
+	counts.SetCounter(testSchemas, counter)
+	countSchema := testSchemas.LookupSchema("count")
+
+	list, err := countSchema.Store.List(testOp, countSchema)
+	assert.NoError(t, err)
+	itemCount := list.Objects[0].Object.(counts.Count).Counts[testResource]
+	assert.Equal(t, counts.Summary{Count: 6, Error: 2, Transitioning: 1, States: map[string]int{"error": 2, "in-progress": 1}}, itemCount.Summary)
+	assert.Equal(t, map[string]counts.Summary{
+		"ns1": {Count: 5, Error: 2, States: map[string]int{"error": 2}},
+		"ns2": {Count: 1, Transitioning: 1, States: map[string]int{"in-progress": 1}},
+	}, itemCount.Namespaces)
+	assert.Equal(t, map[string]counts.Summary{"p-1": {Count: 5, Error: 2, States: map[string]int{"error": 2}}}, itemCount.Projects)
+	// objects are counted in the namespaces the user can list, and by the names they can get
+	assert.ElementsMatch(t, []partition.Partition{
+		{Namespace: "ns1", All: true, Names: sets.New[string]()},
+		{Namespace: "ns2", Names: sets.New("b")},
+	}, counter.partitions)
+
+	// types the counter can't count are listed from the cluster cache
+	counter.err = fmt.Errorf("no such table")
+	list, err = countSchema.Store.List(testOp, countSchema)
+	assert.NoError(t, err)
+	itemCount = list.Objects[0].Object.(counts.Count).Counts[testResource]
+	assert.Equal(t, 1, itemCount.Summary.Count)
+}
+
+func TestProjectMove(t *testing.T) {
+	testSchema := makeSchema(testResource)
+	addGenericPermissionsToSchema(testSchema, "list")
+	testSchemas := types.EmptyAPISchemas()
+	testSchemas.MustAddSchema(*testSchema)
+	testOp := &types.APIRequest{
+		Schemas:       testSchemas,
+		AccessControl: &server.SchemaBasedAccess{},
+		Request:       httptest.NewRequest(http.MethodGet, "/v1/counts?breakdown=projects", nil),
+	}
+	fakeCache := NewFakeClusterCache()
+	namespaceGVK := schema2.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+	namespace := makeSummarizedObject(namespaceGVK, "ns1", "", "1")
+	namespace.Labels = map[string]string{"field.cattle.io/projectId": "p-1"}
+	fakeCache.AddSummaryObj(namespace)
+	gvk := attributes.GVK(testSchema)
+	fakeCache.AddSummaryObj(makeSummarizedObject(gvk, "a", "ns1", "2"))
+	counts.Register(testSchemas, fakeCache, summarycache.DefaultSummarizer)
+	countSchema := testSchemas.LookupSchema("count")
+
+	resChannel, err := countSchema.Store.Watch(testOp, countSchema, types.WatchRequest{})
+	assert.NoError(t, err)
+
+	// the counts of the namespace move from its previous project to its new one
+	moved := makeSummarizedObject(namespaceGVK, "ns1", "", "3")
+	moved.Labels = map[string]string{"field.cattle.io/projectId": "p-2"}
+	fakeCache.summarizedObjects[0] = moved
+	assert.NoError(t, fakeCache.changeHandler(namespaceGVK, "ns1", moved, namespace))
+	outputCount, err := receiveWithTimeout(resChannel, 100*time.Millisecond)
+	assert.NoError(t, err)
+	itemCount := outputCount.Object.Object.(counts.Count).Counts[testResource]
+	assert.Equal(t, map[string]counts.Summary{"p-2": {Count: 1}}, itemCount.Projects)
+}
+
 // receiveWithTimeout tries to get a value from input within duration. Returns an error if no input was received during that period
 func receiveWithTimeout(input chan types.APIEvent, duration time.Duration) (*types.APIEvent, error) {
 	select {
@@ -246,6 +462,11 @@ func NewFakeClusterCache() *fakeClusterCache {
 }
 
 func (f *fakeClusterCache) Get(gvk schema2.GroupVersionKind, namespace, name string) (interface{}, bool, error) {
+	for _, summaryObj := range f.summarizedObjects {
+		if summaryObj.GroupVersionKind() == gvk && summaryObj.Namespace == namespace && summaryObj.Name == name {
+			return summaryObj, true, nil
+		}
+	}
 	return nil, false, nil
 }
 
@@ -0,0 +1,339 @@
+// Package crds provides the lifecycle actions of CustomResourceDefinitions: safeDelete, which refuses to delete a CRD
+// while custom resources of it exist unless forced, and migrateStorage, which starts the migration of its custom
+// resources to its storage version, along with the versions link listing the versions of a CRD and their usage.
+package crds
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/resources/actions"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+const versionsLink = "versions"
+
+var (
+	crdGVR = apiextensionsv1.SchemeGroupVersion.WithResource("customresourcedefinitions")
+
+	// migrationGVRs are the resources of the StorageVersionMigrations of Kubernetes and of the
+	// kube-storage-version-migrator, in order of preference
+	migrationGVRs = []k8sschema.GroupVersionResource{
+		{Group: "storagemigration.k8s.io", Version: "v1alpha1", Resource: "storageversionmigrations"},
+		{Group: "migration.k8s.io", Version: "v1alpha1", Resource: "storageversionmigrations"},
+	}
+)
+
+// CRDSafeDeleteInput is the input of the safeDelete action
+type CRDSafeDeleteInput struct {
+	// Force deletes the CRD, and therefore its custom resources, even if some exist
+	Force bool `json:"force,omitempty"`
+	// DryRun only counts the custom resources of the CRD
+	DryRun bool `json:"dryRun,omitempty"`
+}
+
+// CRDDeletion is the result of the safeDelete action
+type CRDDeletion struct {
+	Name string `json:"name"`
+	// CustomResources is the number of custom resources of the CRD, in all namespaces
+	CustomResources int64 `json:"customResources"`
+	Deleted         bool  `json:"deleted"`
+}
+
+// CRDStorageMigration is the result of the migrateStorage action
+type CRDStorageMigration struct {
+	Name string `json:"name"`
+	// StorageVersion is the version the custom resources are migrated to
+	StorageVersion string `json:"storageVersion"`
+	// Migration is the name of the StorageVersionMigration created, of the given API version
+	Migration  string `json:"migration"`
+	APIVersion string `json:"apiVersion"`
+}
+
+// Versions is the response of the versions link
+type Versions struct {
+	Name           string    `json:"name"`
+	StorageVersion string    `json:"storageVersion,omitempty"`
+	Versions       []Version `json:"versions"`
+	// Objects is the number of custom resources in the cache
+	Objects int `json:"objects"`
+	// NeedsMigration is true if objects may still be stored in versions other than the storage version
+	NeedsMigration bool `json:"needsMigration"`
+}
+
+// Version is a version of a CRD
+type Version struct {
+	Name       string `json:"name"`
+	Served     bool   `json:"served"`
+	Storage    bool   `json:"storage"`
+	Deprecated bool   `json:"deprecated,omitempty"`
+	// Stored is true if objects may be stored in the version, as listed in the storedVersions of the CRD
+	Stored bool `json:"stored"`
+	// Objects counts the cached custom resources written by clients with the version, according to their managed fields
+	Objects int `json:"objects"`
+}
+
+// Cache lists the cached objects of a kind
+type Cache interface {
+	List(gvk k8sschema.GroupVersionKind) []interface{}
+}
+
+// Register registers the schemas of the input and results of the actions of CRDs
+func Register(schemas *types.APISchemas) {
+	for _, obj := range []interface{}{CRDSafeDeleteInput{}, CRDDeletion{}, CRDStorageMigration{}} {
+		schemas.MustImportAndCustomize(obj, func(schema *types.APISchema) {
+			schema.CollectionMethods = []string{}
+			schema.ResourceMethods = []string{}
+		})
+	}
+}
+
+// Actions returns the safeDelete and migrateStorage actions of CRDs, invoked with the clients of the requesters. They
+// are registered after the actions of embedders, which take precedence.
+func Actions(cg proxy.ClientGetter) []actions.Action {
+	group, version := apiextensionsv1.SchemeGroupVersion.Group, apiextensionsv1.SchemeGroupVersion.Version
+	return []actions.Action{
+		{Group: group, Version: version, Kind: "CustomResourceDefinition", Name: "safeDelete", Verb: "delete",
+			Input: "crdSafeDeleteInput", Output: "crdDeletion", Invoke: safeDelete(cg)},
+		{Group: group, Version: version, Kind: "CustomResourceDefinition", Name: "migrateStorage", Verb: "update",
+			Output: "crdStorageMigration", Invoke: migrateStorage(cg)},
+	}
+}
+
+// Template returns a schema template adding the versions link to CRDs
+func Template(cg proxy.ClientGetter, cache Cache) schema.Template {
+	return schema.Template{
+		Group: apiextensionsv1.SchemeGroupVersion.Group,
+		Kind:  "CustomResourceDefinition",
+		Customize: func(apiSchema *types.APISchema) {
+			if apiSchema.LinkHandlers == nil {
+				apiSchema.LinkHandlers = map[string]http.Handler{}
+			}
+			apiSchema.LinkHandlers[versionsLink] = http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				serveVersions(rw, req, cg, cache)
+			})
+		},
+	}
+}
+
+func getCRD(apiOp *types.APIRequest, client dynamic.Interface) (*apiextensionsv1.CustomResourceDefinition, error) {
+	obj, err := client.Resource(crdGVR).Get(apiOp.Context(), apiOp.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	crd := &apiextensionsv1.CustomResourceDefinition{}
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(obj.Object, crd); err != nil {
+		return nil, err
+	}
+	return crd, nil
+}
+
+func storageVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for _, version := range crd.Spec.Versions {
+		if version.Storage {
+			return version.Name
+		}
+	}
+	return ""
+}
+
+func servedVersion(crd *apiextensionsv1.CustomResourceDefinition) string {
+	for _, version := range crd.Spec.Versions {
+		if version.Served {
+			return version.Name
+		}
+	}
+	return ""
+}
+
+func safeDelete(cg proxy.ClientGetter) actions.Func {
+	return func(apiOp *types.APIRequest, input data.Object) (interface{}, error) {
+		client, err := cg.DynamicClient(apiOp, nil)
+		if err != nil {
+			return nil, err
+		}
+		crd, err := getCRD(apiOp, client)
+		if err != nil {
+			return nil, err
+		}
+		count, err := countCustomResources(apiOp, client, crd)
+		if err != nil {
+			return nil, err
+		}
+		result := &CRDDeletion{Name: crd.Name, CustomResources: count}
+		if input.Bool("dryRun") {
+			return result, nil
+		}
+		if count > 0 && !input.Bool("force") {
+			return nil, apierror.NewAPIError(validation.InvalidState,
+				fmt.Sprintf("%d custom resources of %s exist and would be deleted with it, delete with force to delete them", count, crd.Name))
+		}
+		if err := client.Resource(crdGVR).Delete(apiOp.Context(), crd.Name, metav1.DeleteOptions{}); err != nil {
+			return nil, err
+		}
+		result.Deleted = true
+		return result, nil
+	}
+}
+
+// countCustomResources counts the custom resources of a CRD in all namespaces with the Kubernetes API, with the
+// credentials of the requester, so that requesters who can't list them can't delete the CRD with the action. The count
+// is read from the remaining items of a list of a single object.
+func countCustomResources(apiOp *types.APIRequest, client dynamic.Interface, crd *apiextensionsv1.CustomResourceDefinition) (int64, error) {
+	version := servedVersion(crd)
+	if version == "" {
+		// custom resources can't be listed, nor created, without served versions
+		return 0, nil
+	}
+	gvr := k8sschema.GroupVersionResource{Group: crd.Spec.Group, Version: version, Resource: crd.Spec.Names.Plural}
+	list, err := client.Resource(gvr).List(apiOp.Context(), metav1.ListOptions{Limit: 1})
+	if err != nil {
+		return 0, err
+	}
+	count := int64(len(list.Items))
+	if remaining := list.GetRemainingItemCount(); remaining != nil {
+		count += *remaining
+	} else if list.GetContinue() != "" {
+		// more objects exist, without the API server counting them
+		count++
+	}
+	return count, nil
+}
+
+func migrateStorage(cg proxy.ClientGetter) actions.Func {
+	return func(apiOp *types.APIRequest, _ data.Object) (interface{}, error) {
+		client, err := cg.DynamicClient(apiOp, nil)
+		if err != nil {
+			return nil, err
+		}
+		crd, err := getCRD(apiOp, client)
+		if err != nil {
+			return nil, err
+		}
+		version := storageVersion(crd)
+		if version == "" {
+			return nil, apierror.NewAPIError(validation.InvalidState, fmt.Sprintf("%s has no storage version", crd.Name))
+		}
+		k8sClient, err := cg.K8sInterface(apiOp)
+		if err != nil {
+			return nil, err
+		}
+
+		for _, gvr := range migrationGVRs {
+			resources, err := k8sClient.Discovery().ServerResourcesForGroupVersion(gvr.GroupVersion().String())
+			if err != nil || !slices.ContainsFunc(resources.APIResources, func(r metav1.APIResource) bool { return r.Name == gvr.Resource }) {
+				continue
+			}
+			migration := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": gvr.GroupVersion().String(),
+				"kind":       "StorageVersionMigration",
+				"metadata": map[string]interface{}{
+					"generateName": crd.Name + "-",
+				},
+				"spec": map[string]interface{}{
+					"resource": map[string]interface{}{
+						"group":    crd.Spec.Group,
+						"version":  version,
+						"resource": crd.Spec.Names.Plural,
+					},
+				},
+			}}
+			created, err := client.Resource(gvr).Create(apiOp.Context(), migration, metav1.CreateOptions{})
+			if err != nil {
+				return nil, err
+			}
+			return &CRDStorageMigration{
+				Name:           crd.Name,
+				StorageVersion: version,
+				Migration:      created.GetName(),
+				APIVersion:     gvr.GroupVersion().String(),
+			}, nil
+		}
+		return nil, apierror.NewAPIError(validation.ActionNotAvailable,
+			"storage version migrations require the storagemigration.k8s.io API or the kube-storage-version-migrator")
+	}
+}
+
+func serveVersions(rw http.ResponseWriter, req *http.Request, cg proxy.ClientGetter, cache Cache) {
+	apiOp := types.GetAPIContext(req.Context())
+	client, err := cg.DynamicClient(apiOp, nil)
+	if err != nil {
+		apiOp.WriteError(err)
+		return
+	}
+	crd, err := getCRD(apiOp, client)
+	if err != nil {
+		apiOp.WriteError(proxy.TranslateError(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(versions(crd, cache)); err != nil {
+		logrus.Errorf("failed to write the versions of CRD %s: %v", crd.Name, err)
+	}
+}
+
+// versions lists the versions of a CRD, counting the cached custom resources written with each of them. Custom
+// resources are cached in a single version, the one preferred by discovery.
+func versions(crd *apiextensionsv1.CustomResourceDefinition, cache Cache) *Versions {
+	result := &Versions{
+		Name:           crd.Name,
+		StorageVersion: storageVersion(crd),
+	}
+
+	written := map[string]int{}
+	for _, version := range crd.Spec.Versions {
+		if !version.Served {
+			continue
+		}
+		gvk := k8sschema.GroupVersionKind{Group: crd.Spec.Group, Version: version.Name, Kind: crd.Spec.Names.Kind}
+		objects := cache.List(gvk)
+		result.Objects += len(objects)
+		for _, obj := range objects {
+			meta, ok := obj.(metav1.Object)
+			if !ok {
+				continue
+			}
+			versions := map[string]bool{}
+			for _, entry := range meta.GetManagedFields() {
+				if gv, err := k8sschema.ParseGroupVersion(entry.APIVersion); err == nil && gv.Group == crd.Spec.Group {
+					versions[gv.Version] = true
+				}
+			}
+			for version := range versions {
+				written[version]++
+			}
+		}
+	}
+
+	for _, version := range crd.Spec.Versions {
+		stored := slices.Contains(crd.Status.StoredVersions, version.Name)
+		result.Versions = append(result.Versions, Version{
+			Name:       version.Name,
+			Served:     version.Served,
+			Storage:    version.Storage,
+			Deprecated: version.Deprecated,
+			Stored:     stored,
+			Objects:    written[version.Name],
+		})
+		if stored && !version.Storage {
+			result.NeedsMigration = true
+		}
+	}
+	return result
+}
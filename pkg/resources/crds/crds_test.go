@@ -0,0 +1,209 @@
+package crds
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apiextensionsv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	"k8s.io/client-go/rest"
+)
+
+var widgetGVR = k8sschema.GroupVersionResource{Group: "example.com", Version: "v1", Resource: "widgets"}
+
+type clientGetter struct {
+	proxy.ClientGetter
+	dynamic dynamic.Interface
+	client  kubernetes.Interface
+}
+
+func (c *clientGetter) DynamicClient(*types.APIRequest, rest.WarningHandler) (dynamic.Interface, error) {
+	return c.dynamic, nil
+}
+
+func (c *clientGetter) K8sInterface(*types.APIRequest) (kubernetes.Interface, error) {
+	return c.client, nil
+}
+
+type fakeCache map[k8sschema.GroupVersionKind][]interface{}
+
+func (f fakeCache) List(gvk k8sschema.GroupVersionKind) []interface{} {
+	return f[gvk]
+}
+
+func newCRD(storedVersions ...string) *apiextensionsv1.CustomResourceDefinition {
+	return &apiextensionsv1.CustomResourceDefinition{
+		ObjectMeta: metav1.ObjectMeta{Name: "widgets.example.com"},
+		Spec: apiextensionsv1.CustomResourceDefinitionSpec{
+			Group: "example.com",
+			Names: apiextensionsv1.CustomResourceDefinitionNames{Plural: "widgets", Kind: "Widget"},
+			Versions: []apiextensionsv1.CustomResourceDefinitionVersion{
+				{Name: "v1beta1", Served: true, Deprecated: true},
+				{Name: "v1", Served: true, Storage: true},
+			},
+		},
+		Status: apiextensionsv1.CustomResourceDefinitionStatus{StoredVersions: storedVersions},
+	}
+}
+
+func toUnstructured(t *testing.T, crd *apiextensionsv1.CustomResourceDefinition) *unstructured.Unstructured {
+	obj, err := runtime.DefaultUnstructuredConverter.ToUnstructured(crd)
+	require.NoError(t, err)
+	u := &unstructured.Unstructured{Object: obj}
+	u.SetAPIVersion("apiextensions.k8s.io/v1")
+	u.SetKind("CustomResourceDefinition")
+	return u
+}
+
+func widget(name string, managedVersions ...string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("example.com/v1beta1")
+	obj.SetKind("Widget")
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	var entries []metav1.ManagedFieldsEntry
+	for _, version := range managedVersions {
+		entries = append(entries, metav1.ManagedFieldsEntry{Manager: "client", APIVersion: version})
+	}
+	obj.SetManagedFields(entries)
+	return obj
+}
+
+func newDynamicClient(objects ...runtime.Object) *dynamicfake.FakeDynamicClient {
+	return dynamicfake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), map[k8sschema.GroupVersionResource]string{
+		crdGVR: "CustomResourceDefinitionList",
+		{Group: "example.com", Version: "v1beta1", Resource: "widgets"}: "WidgetList",
+		widgetGVR:        "WidgetList",
+		migrationGVRs[1]: "StorageVersionMigrationList",
+	}, objects...)
+}
+
+func newAPIOp() *types.APIRequest {
+	return &types.APIRequest{
+		Name:    "widgets.example.com",
+		Request: httptest.NewRequest(http.MethodPost, "/v1/apiextensions.k8s.io.customresourcedefinitions/widgets.example.com", nil),
+	}
+}
+
+func apiErrorCode(t *testing.T, err error) validation.ErrorCode {
+	var apiErr *apierror.APIError
+	require.True(t, errors.As(err, &apiErr), "expected an API error, got %v", err)
+	return apiErr.Code
+}
+
+func TestSafeDelete(t *testing.T) {
+	tests := []struct {
+		name        string
+		widgets     []runtime.Object
+		input       data.Object
+		want        *CRDDeletion
+		wantCode    validation.ErrorCode
+		wantDeleted bool
+	}{
+		{
+			name:        "no custom resources",
+			want:        &CRDDeletion{Name: "widgets.example.com", Deleted: true},
+			wantDeleted: true,
+		},
+		{
+			name:     "custom resources exist",
+			widgets:  []runtime.Object{widget("a"), widget("b")},
+			wantCode: validation.InvalidState,
+		},
+		{
+			name:    "dry run counts custom resources",
+			widgets: []runtime.Object{widget("a"), widget("b")},
+			input:   data.Object{"dryRun": true},
+			want:    &CRDDeletion{Name: "widgets.example.com", CustomResources: 2},
+		},
+		{
+			name:        "force deletes custom resources",
+			widgets:     []runtime.Object{widget("a")},
+			input:       data.Object{"force": true},
+			want:        &CRDDeletion{Name: "widgets.example.com", CustomResources: 1, Deleted: true},
+			wantDeleted: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := newDynamicClient(append(test.widgets, toUnstructured(t, newCRD("v1")))...)
+			result, err := safeDelete(&clientGetter{dynamic: client})(newAPIOp(), test.input)
+			if test.wantCode.Code != "" {
+				assert.Equal(t, test.wantCode, apiErrorCode(t, err))
+			} else {
+				require.NoError(t, err)
+				assert.Equal(t, test.want, result)
+			}
+
+			_, err = client.Resource(crdGVR).Get(context.Background(), "widgets.example.com", metav1.GetOptions{})
+			assert.Equal(t, test.wantDeleted, apierrors.IsNotFound(err))
+		})
+	}
+}
+
+func TestMigrateStorage(t *testing.T) {
+	dynamicClient := newDynamicClient(toUnstructured(t, newCRD("v1beta1", "v1")))
+	client := fake.NewSimpleClientset()
+	cg := &clientGetter{dynamic: dynamicClient, client: client}
+
+	_, err := migrateStorage(cg)(newAPIOp(), nil)
+	assert.Equal(t, validation.ActionNotAvailable, apiErrorCode(t, err))
+
+	client.Resources = []*metav1.APIResourceList{{
+		GroupVersion: "migration.k8s.io/v1alpha1",
+		APIResources: []metav1.APIResource{{Name: "storageversionmigrations", Kind: "StorageVersionMigration"}},
+	}}
+	result, err := migrateStorage(cg)(newAPIOp(), nil)
+	require.NoError(t, err)
+	migration := result.(*CRDStorageMigration)
+	assert.Equal(t, "v1", migration.StorageVersion)
+	assert.Equal(t, "migration.k8s.io/v1alpha1", migration.APIVersion)
+
+	migrations, err := dynamicClient.Resource(migrationGVRs[1]).List(context.Background(), metav1.ListOptions{})
+	require.NoError(t, err)
+	require.Len(t, migrations.Items, 1)
+	resource, _, _ := unstructured.NestedStringMap(migrations.Items[0].Object, "spec", "resource")
+	assert.Equal(t, map[string]string{"group": "example.com", "version": "v1", "resource": "widgets"}, resource)
+}
+
+func TestVersions(t *testing.T) {
+	cache := fakeCache{
+		{Group: "example.com", Version: "v1", Kind: "Widget"}: {
+			widget("a", "example.com/v1"),
+			widget("b", "example.com/v1beta1", "example.com/v1"),
+			widget("c", "example.com/v1beta1", "example.com/v1beta1", "v1"),
+		},
+	}
+	assert.Equal(t, &Versions{
+		Name:           "widgets.example.com",
+		StorageVersion: "v1",
+		Objects:        3,
+		NeedsMigration: true,
+		Versions: []Version{
+			{Name: "v1beta1", Served: true, Deprecated: true, Stored: true, Objects: 2},
+			{Name: "v1", Served: true, Storage: true, Stored: true, Objects: 2},
+		},
+	}, versions(newCRD("v1beta1", "v1"), cache))
+
+	result := versions(newCRD("v1"), fakeCache{})
+	assert.False(t, result.NeedsMigration)
+	assert.Equal(t, 0, result.Objects)
+}
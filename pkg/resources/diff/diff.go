@@ -0,0 +1,452 @@
+// Package diff provides the diff action, which previews the changes a manifest would make to an existing object.
+package diff
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"reflect"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/strategicpatch"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/kubernetes/scheme"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	actionName = "diff"
+	// maskedValue replaces the values of changed secret data
+	maskedValue = "***"
+)
+
+var (
+	// ignoredPaths change on every write and are left out of the diff
+	ignoredPaths = map[string]bool{
+		"metadata.managedFields":   true,
+		"metadata.resourceVersion": true,
+		"metadata.generation":      true,
+	}
+	simpleKeyRegex = regexp.MustCompile(`^[a-zA-Z_][a-zA-Z0-9_]*$`)
+)
+
+// Op is the kind of change made to a field
+type Op string
+
+const (
+	Add     Op = "add"
+	Remove  Op = "remove"
+	Replace Op = "replace"
+)
+
+// Change is a change made to a single field
+type Change struct {
+	// Path is the field which changed, e.g. spec.containers[0].image. Keys which aren't identifiers, like most label
+	// keys, are wrapped in brackets, e.g. metadata.labels[app.kubernetes.io/name]
+	Path string      `json:"path"`
+	Op   Op          `json:"op"`
+	Old  interface{} `json:"old,omitempty"`
+	New  interface{} `json:"new,omitempty"`
+}
+
+// Result is the response of the diff action
+type Result struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	// ServerSide is true if the manifest was dry-run applied with server-side apply, rather than as an update
+	ServerSide bool     `json:"serverSide"`
+	Changes    []Change `json:"changes"`
+	// DryRun is the object returned by the dry-run write, without its managed fields, if the includeDryRun query
+	// parameter is true
+	DryRun map[string]interface{} `json:"dryRun,omitempty"`
+}
+
+// Template returns a schema template adding the diff action to every type which can be updated
+func Template(cg proxy.ClientGetter) schema.Template {
+	handler := &Handler{cg: cg}
+	return schema.Template{
+		Customize: func(apiSchema *types.APISchema) {
+			add(apiSchema, handler)
+		},
+	}
+}
+
+func add(apiSchema *types.APISchema, handler http.Handler) {
+	if attributes.GVR(apiSchema).Resource == "" {
+		return
+	}
+	verbs := attributes.Verbs(apiSchema)
+	if !slices.Contains(verbs, "update") && !slices.Contains(verbs, "patch") {
+		return
+	}
+	if _, ok := apiSchema.ActionHandlers[actionName]; ok {
+		return
+	}
+
+	if apiSchema.ActionHandlers == nil {
+		apiSchema.ActionHandlers = map[string]http.Handler{}
+	}
+	apiSchema.ActionHandlers[actionName] = handler
+	if apiSchema.ResourceActions == nil {
+		apiSchema.ResourceActions = map[string]schemas.Action{}
+	}
+	apiSchema.ResourceActions[actionName] = schemas.Action{}
+}
+
+// Handler serves the diff action. The manifest in the request body, as YAML or JSON, is dry-run against the live
+// object, so that defaulting, admission and field ownership are taken into account like they would be by an actual
+// write. If the fieldManager query parameter is set, the manifest is server-side applied with that field manager,
+// otherwise it replaces the object. The object returned by the dry-run is included in the result if the includeDryRun
+// query parameter is true.
+type Handler struct {
+	cg proxy.ClientGetter
+}
+
+func (h *Handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	apiContext := types.GetAPIContext(req.Context())
+	result, err := h.diff(apiContext, req)
+	if err != nil {
+		apiContext.WriteError(proxy.TranslateError(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(result); err != nil {
+		logrus.Errorf("failed to write diff result: %v", err)
+	}
+}
+
+func (h *Handler) diff(apiContext *types.APIRequest, req *http.Request) (*Result, error) {
+	apiSchema := apiContext.Schema
+	manifest := &unstructured.Unstructured{}
+	if err := yaml.NewYAMLOrJSONDecoder(req.Body, 4096).Decode(&manifest.Object); err != nil {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("failed to parse manifest: %v", err))
+	}
+	if err := validateManifest(manifest, apiSchema, apiContext.Namespace, apiContext.Name); err != nil {
+		return nil, err
+	}
+
+	client, err := h.cg.DynamicClient(apiContext, rest.NoWarnings{})
+	if err != nil {
+		return nil, err
+	}
+	var resource dynamic.ResourceInterface = client.Resource(attributes.GVR(apiSchema))
+	if attributes.Namespaced(apiSchema) {
+		resource = client.Resource(attributes.GVR(apiSchema)).Namespace(apiContext.Namespace)
+	}
+
+	ctx := apiContext.Context()
+	live, err := resource.Get(ctx, apiContext.Name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Type: actionName,
+		ID:   apiContext.Name,
+	}
+	if apiContext.Namespace != "" {
+		result.ID = apiContext.Namespace + "/" + apiContext.Name
+	}
+
+	var merged *unstructured.Unstructured
+	if fieldManager := apiContext.Request.URL.Query().Get("fieldManager"); fieldManager != "" {
+		result.ServerSide = true
+		data, err := json.Marshal(manifest)
+		if err != nil {
+			return nil, err
+		}
+		force := true
+		merged, err = resource.Patch(ctx, apiContext.Name, k8stypes.ApplyPatchType, data, metav1.PatchOptions{
+			DryRun:       []string{metav1.DryRunAll},
+			FieldManager: fieldManager,
+			Force:        &force,
+		})
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		if manifest.GetResourceVersion() == "" {
+			manifest.SetResourceVersion(live.GetResourceVersion())
+		}
+		merged, err = resource.Update(ctx, manifest, metav1.UpdateOptions{
+			DryRun: []string{metav1.DryRunAll},
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	result.Changes = CompareWithPatchMeta(live.Object, merged.Object, patchMeta(live.GroupVersionKind()))
+	isSecret := live.GetKind() == "Secret" && live.GroupVersionKind().Group == ""
+	if isSecret {
+		maskSecretData(result.Changes)
+	}
+	if includeDryRun, _ := strconv.ParseBool(apiContext.Request.URL.Query().Get("includeDryRun")); includeDryRun {
+		merged.SetManagedFields(nil)
+		if isSecret {
+			maskSecretObject(merged.Object)
+		}
+		result.DryRun = merged.Object
+	}
+	return result, nil
+}
+
+// patchMeta returns the strategic merge patch metadata of built-in types, which tells how their lists are merged, or
+// nil for other types
+func patchMeta(gvk k8sschema.GroupVersionKind) strategicpatch.LookupPatchMeta {
+	obj, err := scheme.Scheme.New(gvk)
+	if err != nil {
+		return nil
+	}
+	meta, err := strategicpatch.NewPatchMetaFromStruct(obj)
+	if err != nil {
+		return nil
+	}
+	return meta
+}
+
+// validateManifest checks that the manifest describes the object the action was called on, filling in any identifying
+// field it leaves out
+func validateManifest(manifest *unstructured.Unstructured, apiSchema *types.APISchema, namespace, name string) error {
+	gvk := attributes.GVK(apiSchema)
+	if manifest.GetAPIVersion() == "" && manifest.GetKind() == "" {
+		manifest.SetGroupVersionKind(gvk)
+	} else if manifest.GroupVersionKind() != gvk {
+		return apierror.NewAPIError(validation.InvalidBodyContent,
+			fmt.Sprintf("manifest is a %s, expected a %s", manifest.GroupVersionKind(), gvk))
+	}
+
+	if manifest.GetName() == "" {
+		manifest.SetName(name)
+	} else if manifest.GetName() != name {
+		return apierror.NewAPIError(validation.InvalidBodyContent,
+			fmt.Sprintf("manifest name %q does not match %q", manifest.GetName(), name))
+	}
+
+	if manifest.GetNamespace() == "" {
+		manifest.SetNamespace(namespace)
+	} else if manifest.GetNamespace() != namespace {
+		return apierror.NewAPIError(validation.InvalidBodyContent,
+			fmt.Sprintf("manifest namespace %q does not match %q", manifest.GetNamespace(), namespace))
+	}
+	return nil
+}
+
+// Compare returns the changes between old and new, ordered by path. Lists are compared element by element.
+func Compare(old, new map[string]interface{}) []Change {
+	return CompareWithPatchMeta(old, new, nil)
+}
+
+// CompareWithPatchMeta returns the changes between old and new like Compare, except that the elements of lists merged
+// by key in strategic merge patches, such as containers or env vars, are matched by their merge key rather than by
+// their index, so that reordering them is not a change. Paths have the index of elements in new, or in old for removed
+// elements.
+func CompareWithPatchMeta(old, new map[string]interface{}, meta strategicpatch.LookupPatchMeta) []Change {
+	var changes []Change
+	compare("", old, new, meta, "", &changes)
+	return changes
+}
+
+// compare adds the changes between old and new to changes. meta is the patch metadata of maps and of the elements of
+// lists, and mergeKey the merge key of the elements of lists, if any.
+func compare(path string, old, new interface{}, meta strategicpatch.LookupPatchMeta, mergeKey string, changes *[]Change) {
+	if ignoredPaths[path] {
+		return
+	}
+
+	switch {
+	case old == nil && new == nil:
+		return
+	case old == nil:
+		*changes = append(*changes, Change{Path: path, Op: Add, New: new})
+		return
+	case new == nil:
+		*changes = append(*changes, Change{Path: path, Op: Remove, Old: old})
+		return
+	}
+
+	oldMap, oldIsMap := old.(map[string]interface{})
+	newMap, newIsMap := new.(map[string]interface{})
+	if oldIsMap && newIsMap {
+		keys := map[string]bool{}
+		for key := range oldMap {
+			keys[key] = true
+		}
+		for key := range newMap {
+			keys[key] = true
+		}
+		sortedKeys := make([]string, 0, len(keys))
+		for key := range keys {
+			sortedKeys = append(sortedKeys, key)
+		}
+		sort.Strings(sortedKeys)
+		for _, key := range sortedKeys {
+			childMeta, childMergeKey := lookupPatchMeta(meta, key, oldMap[key], newMap[key])
+			compare(childPath(path, key), oldMap[key], newMap[key], childMeta, childMergeKey, changes)
+		}
+		return
+	}
+
+	oldSlice, oldIsSlice := old.([]interface{})
+	newSlice, newIsSlice := new.([]interface{})
+	if oldIsSlice && newIsSlice {
+		if mergeKey != "" && hasUniqueKeys(oldSlice, mergeKey) && hasUniqueKeys(newSlice, mergeKey) {
+			compareByKey(path, oldSlice, newSlice, meta, mergeKey, changes)
+			return
+		}
+		for i := 0; i < max(len(oldSlice), len(newSlice)); i++ {
+			var oldItem, newItem interface{}
+			if i < len(oldSlice) {
+				oldItem = oldSlice[i]
+			}
+			if i < len(newSlice) {
+				newItem = newSlice[i]
+			}
+			compare(indexPath(path, i), oldItem, newItem, meta, "", changes)
+		}
+		return
+	}
+
+	if !reflect.DeepEqual(old, new) {
+		*changes = append(*changes, Change{Path: path, Op: Replace, Old: old, New: new})
+	}
+}
+
+// compareByKey compares the elements of lists with the same merge key
+func compareByKey(path string, oldSlice, newSlice []interface{}, meta strategicpatch.LookupPatchMeta, mergeKey string, changes *[]Change) {
+	oldIndexes := map[interface{}]int{}
+	for i, item := range oldSlice {
+		oldIndexes[item.(map[string]interface{})[mergeKey]] = i
+	}
+	matched := map[int]bool{}
+	for i, newItem := range newSlice {
+		var oldItem interface{}
+		if j, ok := oldIndexes[newItem.(map[string]interface{})[mergeKey]]; ok {
+			oldItem = oldSlice[j]
+			matched[j] = true
+		}
+		compare(indexPath(path, i), oldItem, newItem, meta, "", changes)
+	}
+	for j, oldItem := range oldSlice {
+		if !matched[j] {
+			compare(indexPath(path, j), oldItem, nil, meta, "", changes)
+		}
+	}
+}
+
+// hasUniqueKeys returns true if the elements of a list are maps with distinct scalar values for key
+func hasUniqueKeys(items []interface{}, key string) bool {
+	seen := map[interface{}]bool{}
+	for _, item := range items {
+		m, ok := item.(map[string]interface{})
+		if !ok {
+			return false
+		}
+		value := m[key]
+		switch value.(type) {
+		case string, int64, float64, bool:
+		default:
+			return false
+		}
+		if seen[value] {
+			return false
+		}
+		seen[value] = true
+	}
+	return true
+}
+
+// lookupPatchMeta returns the patch metadata of the field key of a map, and the merge key of its elements if it's a list
+// merged by key
+func lookupPatchMeta(meta strategicpatch.LookupPatchMeta, key string, old, new interface{}) (strategicpatch.LookupPatchMeta, string) {
+	if meta == nil {
+		return nil, ""
+	}
+	_, oldIsSlice := old.([]interface{})
+	_, newIsSlice := new.([]interface{})
+	if oldIsSlice || newIsSlice {
+		childMeta, patchMeta, err := meta.LookupPatchMetadataForSlice(key)
+		if err != nil {
+			return nil, ""
+		}
+		if slices.Contains(patchMeta.GetPatchStrategies(), "merge") {
+			return childMeta, patchMeta.GetPatchMergeKey()
+		}
+		return childMeta, ""
+	}
+	childMeta, _, err := meta.LookupPatchMetadataForStruct(key)
+	if err != nil {
+		return nil, ""
+	}
+	return childMeta, ""
+}
+
+func indexPath(path string, i int) string {
+	return path + "[" + strconv.Itoa(i) + "]"
+}
+
+func childPath(path, key string) string {
+	if !simpleKeyRegex.MatchString(key) {
+		return path + "[" + key + "]"
+	}
+	if path == "" {
+		return key
+	}
+	return path + "." + key
+}
+
+// maskSecretData hides the values of changed secret data, like kubectl diff does
+func maskSecretData(changes []Change) {
+	for i, change := range changes {
+		if !isSecretDataPath(change.Path) {
+			continue
+		}
+		if change.Old != nil {
+			changes[i].Old = maskedValue
+		}
+		if change.New != nil {
+			changes[i].New = maskedValue
+		}
+	}
+}
+
+// maskSecretObject hides the values of the data of a secret
+func maskSecretObject(obj map[string]interface{}) {
+	for _, field := range []string{"data", "stringData"} {
+		data, ok := obj[field].(map[string]interface{})
+		if !ok {
+			continue
+		}
+		for key := range data {
+			data[key] = maskedValue
+		}
+	}
+}
+
+func isSecretDataPath(path string) bool {
+	for _, prefix := range []string{"data", "stringData"} {
+		if path == prefix || strings.HasPrefix(path, prefix+".") || strings.HasPrefix(path, prefix+"[") {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,232 @@
+package diff
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestCompare(t *testing.T) {
+	tests := []struct {
+		name     string
+		old      map[string]interface{}
+		new      map[string]interface{}
+		expected []Change
+	}{
+		{
+			name: "no changes",
+			old:  map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}},
+			new:  map[string]interface{}{"spec": map[string]interface{}{"replicas": int64(1)}},
+		},
+		{
+			name: "added, removed and replaced fields",
+			old: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{"app.kubernetes.io/name": "web", "tier": "front"},
+				},
+				"spec": map[string]interface{}{"replicas": int64(1)},
+			},
+			new: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"labels": map[string]interface{}{"app.kubernetes.io/name": "web", "env": "prod"},
+				},
+				"spec": map[string]interface{}{"replicas": int64(3)},
+			},
+			expected: []Change{
+				{Path: "metadata.labels.env", Op: Add, New: "prod"},
+				{Path: "metadata.labels.tier", Op: Remove, Old: "front"},
+				{Path: "spec.replicas", Op: Replace, Old: int64(1), New: int64(3)},
+			},
+		},
+		{
+			name: "keys which aren't identifiers are bracketed",
+			old:  map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{"example.com/a": "1"}}},
+			new:  map[string]interface{}{"metadata": map[string]interface{}{"annotations": map[string]interface{}{"example.com/a": "2"}}},
+			expected: []Change{
+				{Path: "metadata.annotations[example.com/a]", Op: Replace, Old: "1", New: "2"},
+			},
+		},
+		{
+			name: "lists are compared by index",
+			old: map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+				map[string]interface{}{"name": "a", "image": "a:1"},
+			}}},
+			new: map[string]interface{}{"spec": map[string]interface{}{"containers": []interface{}{
+				map[string]interface{}{"name": "a", "image": "a:2"},
+				map[string]interface{}{"name": "b", "image": "b:1"},
+			}}},
+			expected: []Change{
+				{Path: "spec.containers[0].image", Op: Replace, Old: "a:1", New: "a:2"},
+				{Path: "spec.containers[1]", Op: Add, New: map[string]interface{}{"name": "b", "image": "b:1"}},
+			},
+		},
+		{
+			name: "fields changing on every write are ignored",
+			old: map[string]interface{}{"metadata": map[string]interface{}{
+				"resourceVersion": "1", "generation": int64(1), "managedFields": []interface{}{"a"},
+			}},
+			new: map[string]interface{}{"metadata": map[string]interface{}{
+				"resourceVersion": "2", "generation": int64(2), "managedFields": []interface{}{"a", "b"},
+			}},
+		},
+		{
+			name: "type changes are replacements",
+			old:  map[string]interface{}{"spec": map[string]interface{}{"value": "1"}},
+			new:  map[string]interface{}{"spec": map[string]interface{}{"value": map[string]interface{}{"a": "1"}}},
+			expected: []Change{
+				{Path: "spec.value", Op: Replace, Old: "1", New: map[string]interface{}{"a": "1"}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, Compare(test.old, test.new))
+		})
+	}
+}
+
+func TestCompareWithPatchMeta(t *testing.T) {
+	meta := patchMeta(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"})
+	require.NotNil(t, meta)
+	assert.Nil(t, patchMeta(schema.GroupVersionKind{Group: "example.io", Version: "v1", Kind: "Widget"}), "custom types have no patch metadata")
+
+	podSpec := func(containers ...interface{}) map[string]interface{} {
+		return map[string]interface{}{"spec": map[string]interface{}{"template": map[string]interface{}{
+			"spec": map[string]interface{}{"containers": containers},
+		}}}
+	}
+	old := podSpec(
+		map[string]interface{}{"name": "app", "image": "app:1", "env": []interface{}{
+			map[string]interface{}{"name": "A", "value": "1"},
+			map[string]interface{}{"name": "B", "value": "2"},
+		}},
+		map[string]interface{}{"name": "sidecar", "image": "proxy:1"},
+	)
+	new := podSpec(
+		map[string]interface{}{"name": "sidecar", "image": "proxy:1"},
+		map[string]interface{}{"name": "app", "image": "app:2", "env": []interface{}{
+			map[string]interface{}{"name": "B", "value": "2"},
+		}},
+		map[string]interface{}{"name": "debug", "image": "busybox"},
+	)
+	assert.Equal(t, []Change{
+		{Path: "spec.template.spec.containers[1].env[0]", Op: Remove, Old: map[string]interface{}{"name": "A", "value": "1"}},
+		{Path: "spec.template.spec.containers[1].image", Op: Replace, Old: "app:1", New: "app:2"},
+		{Path: "spec.template.spec.containers[2]", Op: Add, New: map[string]interface{}{"name": "debug", "image": "busybox"}},
+	}, CompareWithPatchMeta(old, new, meta), "containers and env vars are matched by name")
+	assert.Len(t, Compare(old, new), 7, "lists are compared by index without patch metadata")
+}
+
+func TestMaskSecretObject(t *testing.T) {
+	secret := map[string]interface{}{
+		"data":       map[string]interface{}{"password": "YQ=="},
+		"stringData": map[string]interface{}{"user": "admin"},
+		"type":       "Opaque",
+	}
+	maskSecretObject(secret)
+	assert.Equal(t, map[string]interface{}{
+		"data":       map[string]interface{}{"password": maskedValue},
+		"stringData": map[string]interface{}{"user": maskedValue},
+		"type":       "Opaque",
+	}, secret)
+}
+
+func TestMaskSecretData(t *testing.T) {
+	changes := []Change{
+		{Path: "data.password", Op: Replace, Old: "YQ==", New: "Yg=="},
+		{Path: "data[.dockerconfigjson]", Op: Add, New: "e30="},
+		{Path: "stringData", Op: Remove, Old: map[string]interface{}{"a": "b"}},
+		{Path: "metadata.labels.database", Op: Add, New: "x"},
+	}
+	maskSecretData(changes)
+	assert.Equal(t, []Change{
+		{Path: "data.password", Op: Replace, Old: maskedValue, New: maskedValue},
+		{Path: "data[.dockerconfigjson]", Op: Add, New: maskedValue},
+		{Path: "stringData", Op: Remove, Old: maskedValue},
+		{Path: "metadata.labels.database", Op: Add, New: "x"},
+	}, changes)
+}
+
+func TestValidateManifest(t *testing.T) {
+	apiSchema := &types.APISchema{Schema: &schemas.Schema{ID: "apps.deployment"}}
+	gvk := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	attributes.SetGVK(apiSchema, gvk)
+
+	tests := []struct {
+		name      string
+		manifest  map[string]interface{}
+		wantError bool
+	}{
+		{
+			name:     "identifying fields are filled in",
+			manifest: map[string]interface{}{"spec": map[string]interface{}{}},
+		},
+		{
+			name: "matching identifying fields",
+			manifest: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata":   map[string]interface{}{"namespace": "default", "name": "web"},
+			},
+		},
+		{
+			name:      "different kind",
+			manifest:  map[string]interface{}{"apiVersion": "apps/v1", "kind": "StatefulSet"},
+			wantError: true,
+		},
+		{
+			name:      "different name",
+			manifest:  map[string]interface{}{"metadata": map[string]interface{}{"name": "db"}},
+			wantError: true,
+		},
+		{
+			name:      "different namespace",
+			manifest:  map[string]interface{}{"metadata": map[string]interface{}{"namespace": "other"}},
+			wantError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			manifest := &unstructured.Unstructured{Object: test.manifest}
+			err := validateManifest(manifest, apiSchema, "default", "web")
+			if test.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, gvk, manifest.GroupVersionKind())
+			assert.Equal(t, "default", manifest.GetNamespace())
+			assert.Equal(t, "web", manifest.GetName())
+		})
+	}
+}
+
+func TestAdd(t *testing.T) {
+	handler := &Handler{}
+	newSchema := func(verbs []string) *types.APISchema {
+		apiSchema := &types.APISchema{Schema: &schemas.Schema{ID: "configmap"}}
+		attributes.SetGVR(apiSchema, schema.GroupVersionResource{Version: "v1", Resource: "configmaps"})
+		attributes.SetVerbs(apiSchema, verbs)
+		return apiSchema
+	}
+
+	updatable := newSchema([]string{"get", "list", "update"})
+	add(updatable, handler)
+	assert.Equal(t, map[string]http.Handler{actionName: handler}, updatable.ActionHandlers)
+	assert.Contains(t, updatable.ResourceActions, actionName)
+
+	readOnly := newSchema([]string{"get", "list"})
+	add(readOnly, handler)
+	assert.Empty(t, readOnly.ActionHandlers)
+
+	notKubernetes := &types.APISchema{Schema: &schemas.Schema{ID: "count"}}
+	add(notKubernetes, handler)
+	assert.Empty(t, notKubernetes.ActionHandlers)
+}
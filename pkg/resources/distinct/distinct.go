@@ -0,0 +1,55 @@
+// Package distinct provides the distinctValues schema, which returns the distinct values of fields among the objects
+// of a type, for example to populate filter dropdowns without listing every object.
+package distinct
+
+import (
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+)
+
+// Store counts the distinct values of the fields requested with the distinct query param
+type Store interface {
+	Distinct(apiOp *types.APIRequest, schema *types.APISchema) (map[string][]listprocessor.DistinctValue, error)
+}
+
+// Values is the distinctValues object returned for a schema
+type Values struct {
+	// Fields maps each requested field to its distinct values, most common first
+	Fields map[string][]listprocessor.DistinctValue `json:"fields"`
+}
+
+// Register registers the distinctValues schema, which is served by ID, the ID being the ID of the schema to list
+// values of.
+func Register(baseSchema *types.APISchemas, store Store) {
+	baseSchema.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID:              "distinctValues",
+			PluralName:      "distinctValues",
+			ResourceMethods: []string{"GET"},
+		},
+		ByIDHandler: func(request *types.APIRequest) (types.APIObject, error) {
+			return byID(request, store)
+		},
+	})
+}
+
+func byID(request *types.APIRequest, store Store) (types.APIObject, error) {
+	// pseudo-access check, values are then only counted among the objects the user can list
+	schema := request.Schemas.LookupSchema(request.Name)
+	if schema == nil || schema.Store == nil {
+		return types.APIObject{}, apierror.NewAPIError(validation.NotFound, "no such schema")
+	}
+
+	fields, err := store.Distinct(request, schema)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	return types.APIObject{
+		ID:     schema.ID,
+		Type:   "distinctValues",
+		Object: Values{Fields: fields},
+	}, nil
+}
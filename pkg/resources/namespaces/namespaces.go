@@ -0,0 +1,211 @@
+// Package namespaces provides the termination link of namespaces, which reports what is keeping a Terminating
+// namespace from being deleted: the finalizers of the namespace, the deletion conditions set by the namespace
+// controller, and the kinds of the objects remaining in the namespace, with how many of them are left and which
+// finalizers they are waiting on.
+package namespaces
+
+import (
+	"encoding/json"
+	"net/http"
+	"sort"
+	"sync"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/errgroup"
+)
+
+const (
+	terminationLink = "termination"
+	// maxConcurrentLists bounds the types whose remaining objects are listed at the same time
+	maxConcurrentLists = 10
+)
+
+// Termination is the response of the termination link
+type Termination struct {
+	ID string `json:"id"`
+	// Terminating is true once the namespace is being deleted, remaining objects only being listed then
+	Terminating       bool   `json:"terminating"`
+	DeletionTimestamp string `json:"deletionTimestamp,omitempty"`
+	// Finalizers are the finalizers of the spec of the namespace, removed by the namespace controller once the
+	// namespace is empty, followed by those of its metadata
+	Finalizers []string `json:"finalizers,omitempty"`
+	// Conditions are the conditions of the namespace which are true, such as NamespaceContentRemaining and
+	// NamespaceFinalizersRemaining
+	Conditions []Condition `json:"conditions,omitempty"`
+	// Remaining are the types of the objects remaining in the namespace, among those the user can list
+	Remaining []Remaining `json:"remaining,omitempty"`
+	// Errors are the errors listing the objects of types, by type
+	Errors map[string]string `json:"errors,omitempty"`
+}
+
+// Condition is a condition of a namespace
+type Condition struct {
+	Type    string `json:"type"`
+	Reason  string `json:"reason,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+// Remaining are the objects of a type remaining in a namespace
+type Remaining struct {
+	Type       string `json:"type"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Count      int    `json:"count"`
+	// Finalizers counts the remaining objects by finalizer
+	Finalizers map[string]int `json:"finalizers,omitempty"`
+}
+
+// Template returns a schema template adding the termination link to namespaces
+func Template() schema.Template {
+	return schema.Template{
+		ID: "namespace",
+		Customize: func(apiSchema *types.APISchema) {
+			if apiSchema.LinkHandlers == nil {
+				apiSchema.LinkHandlers = map[string]http.Handler{}
+			}
+			apiSchema.LinkHandlers[terminationLink] = http.HandlerFunc(serveTermination)
+		},
+	}
+}
+
+func serveTermination(rw http.ResponseWriter, req *http.Request) {
+	apiOp := types.GetAPIContext(req.Context())
+	result, err := termination(apiOp)
+	if err != nil {
+		apiOp.WriteError(err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(result); err != nil {
+		logrus.Errorf("failed to write namespace termination: %v", err)
+	}
+}
+
+func termination(apiOp *types.APIRequest) (*Termination, error) {
+	ns, err := apiOp.Schema.Store.ByID(apiOp, apiOp.Schema, apiOp.Name)
+	if err != nil {
+		return nil, err
+	}
+	obj := ns.Data()
+
+	result := &Termination{
+		ID:                apiOp.Name,
+		DeletionTimestamp: obj.String("metadata", "deletionTimestamp"),
+		Finalizers:        append(obj.StringSlice("spec", "finalizers"), obj.StringSlice("metadata", "finalizers")...),
+		Conditions:        trueConditions(obj),
+	}
+	result.Terminating = result.DeletionTimestamp != "" || obj.String("status", "phase") == "Terminating"
+	if !result.Terminating {
+		return result, nil
+	}
+	result.Remaining, result.Errors = remaining(apiOp, apiOp.Name)
+	return result, nil
+}
+
+func trueConditions(obj data.Object) []Condition {
+	var result []Condition
+	for _, condition := range obj.Slice("status", "conditions") {
+		if condition.String("status") != "True" {
+			continue
+		}
+		result = append(result, Condition{
+			Type:    condition.String("type"),
+			Reason:  condition.String("reason"),
+			Message: condition.String("message"),
+		})
+	}
+	return result
+}
+
+// remaining lists the objects of the namespaced types the user can list in a namespace, returning the types with
+// remaining objects, sorted by type, and the errors listing types
+func remaining(apiOp *types.APIRequest, namespace string) ([]Remaining, map[string]string) {
+	var (
+		lock   sync.Mutex
+		result []Remaining
+		errs   map[string]string
+		eg     errgroup.Group
+	)
+	eg.SetLimit(maxConcurrentLists)
+	for _, apiSchema := range namespacedSchemas(apiOp) {
+		apiSchema := apiSchema
+		eg.Go(func() error {
+			r, err := remainingOfType(apiOp, apiSchema, namespace)
+			lock.Lock()
+			defer lock.Unlock()
+			if err != nil {
+				if errs == nil {
+					errs = map[string]string{}
+				}
+				errs[apiSchema.ID] = err.Error()
+			} else if r.Count > 0 {
+				result = append(result, r)
+			}
+			return nil
+		})
+	}
+	_ = eg.Wait()
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Type < result[j].Type
+	})
+	return result, errs
+}
+
+// namespacedSchemas returns the schemas of the namespaced Kubernetes types the user can list
+func namespacedSchemas(apiOp *types.APIRequest) []*types.APISchema {
+	var result []*types.APISchema
+	for _, apiSchema := range apiOp.Schemas.Schemas {
+		if apiSchema.Store == nil || attributes.Kind(apiSchema) == "" || !attributes.Namespaced(apiSchema) {
+			continue
+		}
+		if apiOp.AccessControl != nil && apiOp.AccessControl.CanList(apiOp, apiSchema) != nil {
+			continue
+		}
+		result = append(result, apiSchema)
+	}
+	return result
+}
+
+func remainingOfType(apiOp *types.APIRequest, apiSchema *types.APISchema, namespace string) (Remaining, error) {
+	listOp := apiOp.Clone()
+	listOp.Schema = apiSchema
+	listOp.Type = apiSchema.ID
+	listOp.Namespace = namespace
+	listOp.Name = ""
+	listOp.Link = ""
+	listOp.Request = apiOp.Request.Clone(apiOp.Context())
+	listOp.Request.URL.RawQuery = ""
+	list, err := apiSchema.Store.List(listOp, apiSchema)
+	if err != nil {
+		return Remaining{}, err
+	}
+
+	gvk := attributes.GVK(apiSchema)
+	result := Remaining{
+		Type:       apiSchema.ID,
+		APIVersion: gvk.GroupVersion().String(),
+		Kind:       gvk.Kind,
+	}
+	for _, obj := range list.Objects {
+		data := obj.Data()
+		// stores which don't list by namespace return the objects of all namespaces
+		if data.String("metadata", "namespace") != namespace {
+			continue
+		}
+		result.Count++
+		for _, finalizer := range data.StringSlice("metadata", "finalizers") {
+			if result.Finalizers == nil {
+				result.Finalizers = map[string]int{}
+			}
+			result.Finalizers[finalizer]++
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,169 @@
+package namespaces
+
+import (
+	"errors"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/store/empty"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore returns the objects of all namespaces, like stores which don't list by namespace
+type fakeStore struct {
+	empty.Store
+	objects map[string][]types.APIObject
+	errs    map[string]error
+}
+
+func (f *fakeStore) ByID(apiOp *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
+	for _, obj := range f.objects[schema.ID] {
+		if obj.ID == id {
+			return obj, nil
+		}
+	}
+	return types.APIObject{}, errors.New("not found")
+}
+
+func (f *fakeStore) List(apiOp *types.APIRequest, schema *types.APISchema) (types.APIObjectList, error) {
+	if err := f.errs[schema.ID]; err != nil {
+		return types.APIObjectList{}, err
+	}
+	return types.APIObjectList{Objects: f.objects[schema.ID]}, nil
+}
+
+func object(namespace, name string, finalizers ...interface{}) types.APIObject {
+	return types.APIObject{
+		ID: namespace + "/" + name,
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"namespace":  namespace,
+				"name":       name,
+				"finalizers": finalizers,
+			},
+		},
+	}
+}
+
+func namespace(name string, terminating bool) types.APIObject {
+	obj := map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": name,
+		},
+		"spec": map[string]interface{}{
+			"finalizers": []interface{}{"kubernetes"},
+		},
+		"status": map[string]interface{}{
+			"phase": "Active",
+		},
+	}
+	if terminating {
+		obj["metadata"].(map[string]interface{})["deletionTimestamp"] = "2024-01-01T00:00:00Z"
+		obj["status"] = map[string]interface{}{
+			"phase": "Terminating",
+			"conditions": []interface{}{
+				map[string]interface{}{
+					"type":    "NamespaceDeletionDiscoveryFailure",
+					"status":  "False",
+					"reason":  "ResourcesDiscovered",
+					"message": "All resources successfully discovered",
+				},
+				map[string]interface{}{
+					"type":    "NamespaceFinalizersRemaining",
+					"status":  "True",
+					"reason":  "SomeFinalizersRemain",
+					"message": "Some content in the namespace has finalizers remaining: example.com/cleanup in 2 resource instances",
+				},
+			},
+		}
+	}
+	return types.APIObject{ID: name, Object: obj}
+}
+
+func TestTermination(t *testing.T) {
+	store := &fakeStore{
+		objects: map[string][]types.APIObject{
+			"namespace": {namespace("stuck", true), namespace("active", false)},
+			"pod": {
+				object("stuck", "web-1", "example.com/cleanup"),
+				object("stuck", "web-2", "example.com/cleanup", "example.com/backup"),
+				object("other", "web-3", "example.com/cleanup"),
+			},
+			"configmap": {object("other", "cm")},
+			"secret":    {object("stuck", "s")},
+		},
+		errs: map[string]error{
+			"example.widget": errors.New("widgets are unavailable"),
+		},
+	}
+	apiSchemas := types.EmptyAPISchemas()
+	for id, attrs := range map[string]map[string]interface{}{
+		"namespace":      {"kind": "Namespace", "version": "v1"},
+		"pod":            {"kind": "Pod", "version": "v1", "namespaced": true},
+		"configmap":      {"kind": "ConfigMap", "version": "v1", "namespaced": true},
+		"secret":         {"kind": "Secret", "version": "v1", "namespaced": true},
+		"example.widget": {"kind": "Widget", "group": "example.com", "version": "v1", "namespaced": true},
+		"count":          {"namespaced": true},
+	} {
+		apiSchemas.MustAddSchema(types.APISchema{
+			Schema: &schemas.Schema{ID: id, Attributes: attrs},
+			Store:  store,
+		})
+	}
+	request := func(name string) *types.APIRequest {
+		return &types.APIRequest{
+			Request: httptest.NewRequest("GET", "/v1/namespaces/"+name+"?link=termination", nil),
+			Schemas: apiSchemas,
+			Schema:  apiSchemas.LookupSchema("namespace"),
+			Name:    name,
+			Link:    terminationLink,
+		}
+	}
+
+	result, err := termination(request("stuck"))
+	require.NoError(t, err)
+	assert.Equal(t, &Termination{
+		ID:                "stuck",
+		Terminating:       true,
+		DeletionTimestamp: "2024-01-01T00:00:00Z",
+		Finalizers:        []string{"kubernetes"},
+		Conditions: []Condition{
+			{
+				Type:    "NamespaceFinalizersRemaining",
+				Reason:  "SomeFinalizersRemain",
+				Message: "Some content in the namespace has finalizers remaining: example.com/cleanup in 2 resource instances",
+			},
+		},
+		Remaining: []Remaining{
+			{
+				Type:       "pod",
+				APIVersion: "v1",
+				Kind:       "Pod",
+				Count:      2,
+				Finalizers: map[string]int{"example.com/cleanup": 2, "example.com/backup": 1},
+			},
+			{
+				Type:       "secret",
+				APIVersion: "v1",
+				Kind:       "Secret",
+				Count:      1,
+			},
+		},
+		Errors: map[string]string{
+			"example.widget": "widgets are unavailable",
+		},
+	}, result)
+
+	result, err = termination(request("active"))
+	require.NoError(t, err)
+	assert.Equal(t, &Termination{
+		ID:         "active",
+		Finalizers: []string{"kubernetes"},
+	}, result, "remaining objects are only listed for terminating namespaces")
+
+	_, err = termination(request("missing"))
+	assert.Error(t, err)
+}
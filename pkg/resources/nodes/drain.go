@@ -0,0 +1,249 @@
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/resources/actions"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const (
+	Draining = "draining"
+	Drained  = "drained"
+	Failed   = "failed"
+)
+
+// pollInterval is how often evictions rejected by disruption budgets are retried, and how often the evicted pods are
+// checked for deletion
+var pollInterval = 2 * time.Second
+
+// NodeDrain is the progress of the drain of a node, whose name is its ID
+type NodeDrain struct {
+	ID    string `json:"id"`
+	State string `json:"state"`
+	// Pods is the number of pods to evict, Evicted the number of those whose eviction was accepted
+	Pods    int `json:"pods"`
+	Evicted int `json:"evicted"`
+	// Remaining are the pods which are not deleted yet, as namespace/name
+	Remaining []string `json:"remaining,omitempty"`
+	Error     string   `json:"error,omitempty"`
+}
+
+func (n NodeDrain) toAPIObject() types.APIObject {
+	return types.APIObject{
+		Type:   "nodeDrain",
+		ID:     n.ID,
+		Object: n,
+	}
+}
+
+// Drains tracks the drains of nodes, which outlive the requests starting them
+type Drains struct {
+	ctx      context.Context
+	lock     sync.Mutex
+	drains   map[string]NodeDrain
+	watchers map[chan NodeDrain]struct{}
+}
+
+// NewDrains returns a tracker of drains, which are canceled when ctx is done
+func NewDrains(ctx context.Context) *Drains {
+	return &Drains{
+		ctx:      ctx,
+		drains:   map[string]NodeDrain{},
+		watchers: map[chan NodeDrain]struct{}{},
+	}
+}
+
+func (d *Drains) get(name string) (NodeDrain, bool) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	drain, ok := d.drains[name]
+	return drain, ok
+}
+
+func (d *Drains) list() []NodeDrain {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	result := make([]NodeDrain, 0, len(d.drains))
+	for _, drain := range d.drains {
+		result = append(result, drain)
+	}
+	return result
+}
+
+// start records the drain of a node, unless it is already being drained
+func (d *Drains) start(name string, pods []corev1.Pod) (NodeDrain, error) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	if d.drains[name].State == Draining {
+		return NodeDrain{}, apierror.NewAPIError(validation.Conflict, fmt.Sprintf("node %s is already being drained", name))
+	}
+	drain := NodeDrain{
+		ID:        name,
+		State:     Draining,
+		Pods:      len(pods),
+		Remaining: []string{},
+	}
+	for _, pod := range pods {
+		drain.Remaining = append(drain.Remaining, pod.Namespace+"/"+pod.Name)
+	}
+	d.set(drain)
+	return drain, nil
+}
+
+func (d *Drains) update(name string, f func(drain *NodeDrain)) {
+	d.lock.Lock()
+	defer d.lock.Unlock()
+	drain := d.drains[name]
+	drain.Remaining = append([]string{}, drain.Remaining...)
+	f(&drain)
+	d.set(drain)
+}
+
+// set stores the progress of a drain and sends it to watchers, dropping it for those which are not keeping up
+func (d *Drains) set(drain NodeDrain) {
+	d.drains[drain.ID] = drain
+	for watcher := range d.watchers {
+		select {
+		case watcher <- drain:
+		default:
+			logrus.Debugf("dropped the progress of the drain of node %s for a slow watcher", drain.ID)
+		}
+	}
+}
+
+// watch returns the progress of drains until ctx is done
+func (d *Drains) watch(ctx context.Context) chan NodeDrain {
+	result := make(chan NodeDrain, 100)
+	d.lock.Lock()
+	d.watchers[result] = struct{}{}
+	d.lock.Unlock()
+
+	go func() {
+		<-ctx.Done()
+		d.lock.Lock()
+		defer d.lock.Unlock()
+		delete(d.watchers, result)
+		close(result)
+	}()
+	return result
+}
+
+// invoke starts draining a node, once it's cordoned and its pods can be evicted, and returns the progress of the
+// drain. Pods are evicted with the client of the requester.
+func (d *Drains) invoke(cg proxy.ClientGetter) actions.Func {
+	return func(apiOp *types.APIRequest, input data.Object) (interface{}, error) {
+		opts, err := parseDrainOptions(input)
+		if err != nil {
+			return nil, err
+		}
+		client, err := cg.K8sInterface(apiOp)
+		if err != nil {
+			return nil, err
+		}
+		pods, err := podsToEvict(apiOp.Context(), client, apiOp.Name, opts.force)
+		if err != nil {
+			return nil, err
+		}
+		if err := setUnschedulable(apiOp.Context(), client, apiOp.Name, true); err != nil {
+			return nil, err
+		}
+		drain, err := d.start(apiOp.Name, pods)
+		if err != nil {
+			return nil, err
+		}
+		go d.run(client, apiOp.Name, pods, opts)
+		return drain, nil
+	}
+}
+
+// run evicts the pods of a node and waits for them to be deleted, until the timeout of the drain
+func (d *Drains) run(client kubernetes.Interface, name string, pods []corev1.Pod, opts drainOptions) {
+	ctx, cancel := context.WithTimeout(d.ctx, opts.timeout)
+	defer cancel()
+
+	err := d.evict(ctx, client, name, pods, opts)
+	if err == nil {
+		err = d.waitForDeletion(ctx, client, name, pods)
+	}
+	d.update(name, func(drain *NodeDrain) {
+		if err != nil {
+			drain.State = Failed
+			drain.Error = err.Error()
+			return
+		}
+		drain.State = Drained
+	})
+}
+
+// evict evicts pods, retrying those whose eviction is rejected by a disruption budget
+func (d *Drains) evict(ctx context.Context, client kubernetes.Interface, name string, pods []corev1.Pod, opts drainOptions) error {
+	for _, pod := range pods {
+		eviction := &policyv1.Eviction{
+			ObjectMeta:    metav1.ObjectMeta{Name: pod.Name, Namespace: pod.Namespace},
+			DeleteOptions: &metav1.DeleteOptions{GracePeriodSeconds: opts.gracePeriodSeconds},
+		}
+		for {
+			err := client.CoreV1().Pods(pod.Namespace).EvictV1(ctx, eviction)
+			if err == nil || apierrors.IsNotFound(err) {
+				break
+			}
+			if !apierrors.IsTooManyRequests(err) {
+				return fmt.Errorf("failed to evict pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			}
+			select {
+			case <-ctx.Done():
+				return fmt.Errorf("timed out evicting pod %s/%s: %w", pod.Namespace, pod.Name, err)
+			case <-time.After(pollInterval):
+			}
+		}
+		d.update(name, func(drain *NodeDrain) {
+			drain.Evicted++
+		})
+	}
+	return nil
+}
+
+// waitForDeletion waits for evicted pods to be deleted, pods recreated with the same name having another UID
+func (d *Drains) waitForDeletion(ctx context.Context, client kubernetes.Interface, name string, pods []corev1.Pod) error {
+	for {
+		var remaining []corev1.Pod
+		for _, pod := range pods {
+			current, err := client.CoreV1().Pods(pod.Namespace).Get(ctx, pod.Name, metav1.GetOptions{})
+			if apierrors.IsNotFound(err) || (err == nil && current.UID != pod.UID) {
+				continue
+			}
+			remaining = append(remaining, pod)
+		}
+		if len(remaining) != len(pods) {
+			d.update(name, func(drain *NodeDrain) {
+				drain.Remaining = []string{}
+				for _, pod := range remaining {
+					drain.Remaining = append(drain.Remaining, pod.Namespace+"/"+pod.Name)
+				}
+			})
+		}
+		if len(remaining) == 0 {
+			return nil
+		}
+		pods = remaining
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("timed out waiting for %d pods to be deleted", len(remaining))
+		case <-time.After(pollInterval):
+		}
+	}
+}
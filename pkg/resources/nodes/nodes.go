@@ -0,0 +1,144 @@
+// Package nodes provides the cordon, uncordon and drain actions of nodes. Drains evict the pods of nodes in the
+// background, their progress being returned by the nodeDrain schema, which can be subscribed to.
+package nodes
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/resources/actions"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"github.com/rancher/wrangler/v3/pkg/data/convert"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+)
+
+// defaultDrainTimeout is how long drains wait for the pods of a node to be evicted, unless a timeout is requested
+const defaultDrainTimeout = 5 * time.Minute
+
+// NodeDrainInput is the input of the drain action
+type NodeDrainInput struct {
+	// GracePeriodSeconds overrides the termination grace period of the evicted pods if set
+	GracePeriodSeconds int `json:"gracePeriodSeconds,omitempty"`
+	// Force evicts pods not managed by a controller and pods with emptyDir volumes, whose data is lost
+	Force bool `json:"force,omitempty"`
+	// TimeoutSeconds is how long to wait for the pods to be evicted, 300 if not set
+	TimeoutSeconds int `json:"timeoutSeconds,omitempty"`
+}
+
+type drainOptions struct {
+	gracePeriodSeconds *int64
+	force              bool
+	timeout            time.Duration
+}
+
+func parseDrainOptions(input data.Object) (drainOptions, error) {
+	opts := drainOptions{
+		force:   input.Bool("force"),
+		timeout: defaultDrainTimeout,
+	}
+	if value, ok := input["gracePeriodSeconds"]; ok && value != nil {
+		gracePeriod, err := convert.ToNumber(value)
+		if err != nil || gracePeriod < 0 {
+			return drainOptions{}, apierror.NewFieldAPIError(validation.InvalidFormat, "gracePeriodSeconds", "gracePeriodSeconds must be a positive number of seconds")
+		}
+		opts.gracePeriodSeconds = &gracePeriod
+	}
+	if value, ok := input["timeoutSeconds"]; ok && value != nil {
+		timeout, err := convert.ToNumber(value)
+		if err != nil || timeout <= 0 {
+			return drainOptions{}, apierror.NewFieldAPIError(validation.InvalidFormat, "timeoutSeconds", "timeoutSeconds must be a positive number of seconds")
+		}
+		opts.timeout = time.Duration(timeout) * time.Second
+	}
+	return opts, nil
+}
+
+// Register registers the nodeDrain schema, returning the progress of the drains of nodes, and the schema of the input
+// of the drain action
+func Register(schemas *types.APISchemas, drains *Drains) {
+	schemas.MustImportAndCustomize(NodeDrainInput{}, func(schema *types.APISchema) {
+		schema.CollectionMethods = []string{}
+		schema.ResourceMethods = []string{}
+	})
+	schemas.MustImportAndCustomize(NodeDrain{}, func(schema *types.APISchema) {
+		schema.CollectionMethods = []string{"GET"}
+		schema.ResourceMethods = []string{"GET"}
+		schema.Store = &Store{drains: drains}
+	})
+}
+
+// Actions returns the cordon, uncordon and drain actions of nodes, invoked with the clients of the requesters. They
+// are registered after the actions of embedders, which take precedence.
+func Actions(cg proxy.ClientGetter, drains *Drains) []actions.Action {
+	return []actions.Action{
+		{Version: "v1", Kind: "Node", Name: "cordon", Verb: "patch", Invoke: cordon(cg, true)},
+		{Version: "v1", Kind: "Node", Name: "uncordon", Verb: "patch", Invoke: cordon(cg, false)},
+		{Version: "v1", Kind: "Node", Name: "drain", Verb: "patch", Input: "nodeDrainInput", Output: "nodeDrain", Invoke: drains.invoke(cg)},
+	}
+}
+
+func cordon(cg proxy.ClientGetter, unschedulable bool) actions.Func {
+	return func(apiOp *types.APIRequest, _ data.Object) (interface{}, error) {
+		client, err := cg.K8sInterface(apiOp)
+		if err != nil {
+			return nil, err
+		}
+		return nil, setUnschedulable(apiOp.Context(), client, apiOp.Name, unschedulable)
+	}
+}
+
+func setUnschedulable(ctx context.Context, client kubernetes.Interface, name string, unschedulable bool) error {
+	patch := fmt.Sprintf(`{"spec":{"unschedulable":%t}}`, unschedulable)
+	_, err := client.CoreV1().Nodes().Patch(ctx, name, k8stypes.MergePatchType, []byte(patch), metav1.PatchOptions{})
+	return err
+}
+
+// podsToEvict returns the pods of a node to evict. DaemonSet and mirror pods are skipped, as they would be recreated
+// on the node. Pods not managed by a controller, which would not be recreated, and pods with emptyDir volumes, whose
+// data would be lost, are only evicted when forced.
+func podsToEvict(ctx context.Context, client kubernetes.Interface, node string, force bool) ([]corev1.Pod, error) {
+	pods, err := client.CoreV1().Pods("").List(ctx, metav1.ListOptions{FieldSelector: "spec.nodeName=" + node})
+	if err != nil {
+		return nil, err
+	}
+	var result []corev1.Pod
+	for _, pod := range pods.Items {
+		if pod.Spec.NodeName != node {
+			continue
+		}
+		if _, ok := pod.Annotations[corev1.MirrorPodAnnotationKey]; ok {
+			continue
+		}
+		controller := metav1.GetControllerOf(&pod)
+		if controller != nil && controller.Kind == "DaemonSet" {
+			continue
+		}
+		if !force && controller == nil {
+			return nil, apierror.NewAPIError(validation.InvalidState,
+				fmt.Sprintf("pod %s/%s is not managed by a controller, drain with force to evict it", pod.Namespace, pod.Name))
+		}
+		if !force && hasEmptyDir(pod) {
+			return nil, apierror.NewAPIError(validation.InvalidState,
+				fmt.Sprintf("pod %s/%s has emptyDir volumes, drain with force to evict it and lose their data", pod.Namespace, pod.Name))
+		}
+		result = append(result, pod)
+	}
+	return result, nil
+}
+
+func hasEmptyDir(pod corev1.Pod) bool {
+	for _, volume := range pod.Spec.Volumes {
+		if volume.EmptyDir != nil {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,248 @@
+package nodes
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	policyv1 "k8s.io/api/policy/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	k8stypes "k8s.io/apimachinery/pkg/types"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type clientGetter struct {
+	proxy.ClientGetter
+	client kubernetes.Interface
+}
+
+func (c *clientGetter) K8sInterface(*types.APIRequest) (kubernetes.Interface, error) {
+	return c.client, nil
+}
+
+func newPod(name, controllerKind string, mutate ...func(pod *corev1.Pod)) *corev1.Pod {
+	pod := &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: name, Namespace: "default", UID: k8stypes.UID("uid-" + name)},
+		Spec:       corev1.PodSpec{NodeName: "node1"},
+	}
+	if controllerKind != "" {
+		controller := true
+		pod.OwnerReferences = []metav1.OwnerReference{{Kind: controllerKind, Name: "owner", Controller: &controller}}
+	}
+	for _, f := range mutate {
+		f(pod)
+	}
+	return pod
+}
+
+func newAPIOp(ctx context.Context, name string) *types.APIRequest {
+	apiSchemas := types.EmptyAPISchemas()
+	apiSchemas.MustAddSchema(types.APISchema{Schema: &schemas.Schema{ID: "node"}})
+	return &types.APIRequest{
+		Name:    name,
+		Schemas: apiSchemas,
+		Request: httptest.NewRequest(http.MethodPost, "/v1/nodes/"+name, nil).WithContext(ctx),
+	}
+}
+
+func TestParseDrainOptions(t *testing.T) {
+	opts, err := parseDrainOptions(data.Object{})
+	require.NoError(t, err)
+	assert.Equal(t, drainOptions{timeout: defaultDrainTimeout}, opts)
+
+	opts, err = parseDrainOptions(data.Object{"gracePeriodSeconds": float64(0), "force": true, "timeoutSeconds": float64(60)})
+	require.NoError(t, err)
+	assert.Equal(t, int64(0), *opts.gracePeriodSeconds)
+	assert.True(t, opts.force)
+	assert.Equal(t, time.Minute, opts.timeout)
+
+	_, err = parseDrainOptions(data.Object{"timeoutSeconds": float64(-1)})
+	assert.Error(t, err)
+	_, err = parseDrainOptions(data.Object{"gracePeriodSeconds": "soon"})
+	assert.Error(t, err)
+}
+
+func TestPodsToEvict(t *testing.T) {
+	mirror := func(pod *corev1.Pod) {
+		pod.Annotations = map[string]string{corev1.MirrorPodAnnotationKey: "hash"}
+	}
+	emptyDir := func(pod *corev1.Pod) {
+		pod.Spec.Volumes = []corev1.Volume{{Name: "cache", VolumeSource: corev1.VolumeSource{EmptyDir: &corev1.EmptyDirVolumeSource{}}}}
+	}
+	otherNode := func(pod *corev1.Pod) {
+		pod.Spec.NodeName = "node2"
+	}
+	tests := []struct {
+		name      string
+		pods      []runtime.Object
+		force     bool
+		want      []string
+		wantError bool
+	}{
+		{
+			name: "daemonset and mirror pods are skipped",
+			pods: []runtime.Object{
+				newPod("web", "ReplicaSet"),
+				newPod("agent", "DaemonSet"),
+				newPod("static", "Node", mirror),
+				newPod("elsewhere", "ReplicaSet", otherNode),
+			},
+			want: []string{"web"},
+		},
+		{
+			name:      "unmanaged pods require force",
+			pods:      []runtime.Object{newPod("web", "ReplicaSet"), newPod("bare", "")},
+			wantError: true,
+		},
+		{
+			name:      "pods with emptyDir volumes require force",
+			pods:      []runtime.Object{newPod("web", "ReplicaSet", emptyDir)},
+			wantError: true,
+		},
+		{
+			name:  "force evicts unmanaged pods and pods with emptyDir volumes",
+			pods:  []runtime.Object{newPod("bare", ""), newPod("web", "ReplicaSet", emptyDir)},
+			force: true,
+			want:  []string{"bare", "web"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			client := fake.NewSimpleClientset(test.pods...)
+			pods, err := podsToEvict(context.Background(), client, "node1", test.force)
+			if test.wantError {
+				assert.Equal(t, validation.InvalidState, apiErrorCode(t, err))
+				return
+			}
+			require.NoError(t, err)
+			var names []string
+			for _, pod := range pods {
+				names = append(names, pod.Name)
+			}
+			assert.Equal(t, test.want, names)
+		})
+	}
+}
+
+func TestCordon(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}})
+	cg := &clientGetter{client: client}
+	apiOp := newAPIOp(context.Background(), "node1")
+
+	_, err := cordon(cg, true)(apiOp, data.Object{})
+	require.NoError(t, err)
+	node, err := client.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, node.Spec.Unschedulable)
+
+	_, err = cordon(cg, false)(apiOp, data.Object{})
+	require.NoError(t, err)
+	node, err = client.CoreV1().Nodes().Get(context.Background(), "node1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.False(t, node.Spec.Unschedulable)
+}
+
+func TestDrain(t *testing.T) {
+	pollInterval = 10 * time.Millisecond
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	client := fake.NewSimpleClientset(
+		&corev1.Node{ObjectMeta: metav1.ObjectMeta{Name: "node1"}},
+		newPod("web", "ReplicaSet"),
+		newPod("db", "StatefulSet"),
+		newPod("agent", "DaemonSet"),
+	)
+	// evictions of db are first rejected by its disruption budget
+	rejected := 0
+	var gracePeriods []*int64
+	client.PrependReactor("create", "pods", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		if action.GetSubresource() != "eviction" {
+			return false, nil, nil
+		}
+		eviction := action.(k8stesting.CreateAction).GetObject().(*policyv1.Eviction)
+		gracePeriods = append(gracePeriods, eviction.DeleteOptions.GracePeriodSeconds)
+		if eviction.Name == "db" && rejected < 2 {
+			rejected++
+			return true, nil, apierrors.NewTooManyRequests("disruption budget", 1)
+		}
+		err := client.Tracker().Delete(k8sschema.GroupVersionResource{Version: "v1", Resource: "pods"}, eviction.Namespace, eviction.Name)
+		return true, nil, err
+	})
+
+	drains := NewDrains(ctx)
+	store := &Store{drains: drains}
+	watchCtx, stopWatch := context.WithCancel(ctx)
+	defer stopWatch()
+	events, err := store.Watch(newAPIOp(ctx, "").WithContext(watchCtx), nil, types.WatchRequest{ID: "node1"})
+	require.NoError(t, err)
+
+	cg := &clientGetter{client: client}
+	apiOp := newAPIOp(ctx, "node1")
+	result, err := drains.invoke(cg)(apiOp, data.Object{"gracePeriodSeconds": float64(10)})
+	require.NoError(t, err)
+	started := result.(NodeDrain)
+	assert.Equal(t, Draining, started.State)
+	assert.Equal(t, 2, started.Pods)
+	assert.ElementsMatch(t, []string{"default/web", "default/db"}, started.Remaining)
+
+	node, err := client.CoreV1().Nodes().Get(ctx, "node1", metav1.GetOptions{})
+	require.NoError(t, err)
+	assert.True(t, node.Spec.Unschedulable, "drained nodes are cordoned")
+
+	_, err = drains.start("node2", nil)
+	require.NoError(t, err)
+	_, err = drains.start("node2", nil)
+	assert.Equal(t, validation.Conflict, apiErrorCode(t, err), "nodes are only drained once at a time")
+
+	var last NodeDrain
+	timeout := time.After(5 * time.Second)
+	for last.State != Drained {
+		select {
+		case event := <-events:
+			last = event.Object.Object.(NodeDrain)
+			require.NotEqual(t, Failed, last.State, last.Error)
+		case <-timeout:
+			t.Fatalf("drain did not complete, last progress: %+v", last)
+		}
+	}
+	assert.Equal(t, 2, last.Evicted)
+	assert.Empty(t, last.Remaining)
+	assert.Equal(t, 2, rejected)
+	for _, gracePeriod := range gracePeriods {
+		assert.Equal(t, int64(10), *gracePeriod)
+	}
+
+	obj, err := store.ByID(newAPIOp(ctx, ""), nil, "node1")
+	require.NoError(t, err)
+	assert.Equal(t, Drained, obj.Object.(NodeDrain).State)
+	list, err := store.List(newAPIOp(ctx, ""), nil)
+	require.NoError(t, err)
+	assert.Len(t, list.Objects, 2)
+	list, err = store.List(&types.APIRequest{Schemas: types.EmptyAPISchemas()}, nil)
+	require.NoError(t, err)
+	assert.Empty(t, list.Objects, "drains are hidden from users who can't see nodes")
+}
+
+func apiErrorCode(t *testing.T, err error) validation.ErrorCode {
+	var apiError *apierror.APIError
+	require.True(t, errors.As(err, &apiError), "expected an API error, got %v", err)
+	return apiError.Code
+}
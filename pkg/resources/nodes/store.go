@@ -0,0 +1,65 @@
+package nodes
+
+import (
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/store/empty"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+)
+
+// Store returns the progress of the drains of nodes, to users who can see nodes
+type Store struct {
+	empty.Store
+	drains *Drains
+}
+
+// canSeeNodes is a pseudo-access check, making sure that only users with access to nodes see their drains
+func canSeeNodes(apiOp *types.APIRequest) bool {
+	return apiOp.Schemas != nil && apiOp.Schemas.LookupSchema("node") != nil
+}
+
+func (s *Store) ByID(apiOp *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
+	if drain, ok := s.drains.get(id); ok && canSeeNodes(apiOp) {
+		return drain.toAPIObject(), nil
+	}
+	return types.APIObject{}, apierror.NewAPIError(validation.NotFound, "no drain of node "+id)
+}
+
+func (s *Store) List(apiOp *types.APIRequest, schema *types.APISchema) (types.APIObjectList, error) {
+	result := types.APIObjectList{}
+	if !canSeeNodes(apiOp) {
+		return result, nil
+	}
+	for _, drain := range s.drains.list() {
+		result.Objects = append(result.Objects, drain.toAPIObject())
+	}
+	return result, nil
+}
+
+// Watch returns the progress of drains as they change, only for the node of w.ID if set
+func (s *Store) Watch(apiOp *types.APIRequest, schema *types.APISchema, w types.WatchRequest) (chan types.APIEvent, error) {
+	if !canSeeNodes(apiOp) {
+		return nil, apierror.NewAPIError(validation.PermissionDenied, "can not watch the drains of nodes")
+	}
+	drains := s.drains.watch(apiOp.Context())
+	result := make(chan types.APIEvent)
+	go func() {
+		defer close(result)
+		for drain := range drains {
+			if w.ID != "" && drain.ID != w.ID {
+				continue
+			}
+			event := types.APIEvent{
+				Name:         types.ChangeAPIEvent,
+				ResourceType: "nodeDrain",
+				ID:           drain.ID,
+				Object:       drain.toAPIObject(),
+			}
+			select {
+			case result <- event:
+			case <-apiOp.Context().Done():
+			}
+		}
+	}()
+	return result, nil
+}
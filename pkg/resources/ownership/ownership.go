@@ -0,0 +1,185 @@
+// Package ownership provides the owners link of objects, which returns the objects owning an object, directly or
+// through other owners, for example the replica set and deployment owning a pod. The objects owned by an object are
+// listed with the ownedBy query parameter instead.
+package ownership
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	linkName = "owners"
+	// maxDepth bounds the number of owner references followed up from an object
+	maxDepth = 10
+)
+
+// Owner is an object owning the requested object
+type Owner struct {
+	// Type is the ID of the schema of the owner, empty if the user can't read objects of its kind
+	Type       string `json:"type,omitempty"`
+	ID         string `json:"id"`
+	APIVersion string `json:"apiVersion"`
+	Kind       string `json:"kind"`
+	Name       string `json:"name"`
+	UID        string `json:"uid"`
+	Controller bool   `json:"controller"`
+	// Depth is 1 for the direct owners of the object, 2 for their owners and so on
+	Depth int `json:"depth"`
+	// Error tells why the owner couldn't be read, in which case its own owners aren't returned
+	Error string `json:"error,omitempty"`
+}
+
+// Result is the response of the owners link
+type Result struct {
+	Type   string  `json:"type"`
+	ID     string  `json:"id"`
+	Owners []Owner `json:"owners"`
+}
+
+// Template returns a schema template adding the owners link to objects of all types which have owners
+func Template() schema.Template {
+	return schema.Template{
+		Customize: func(apiSchema *types.APISchema) {
+			if attributes.Kind(apiSchema) == "" {
+				return
+			}
+			if apiSchema.LinkHandlers == nil {
+				apiSchema.LinkHandlers = map[string]http.Handler{}
+			}
+			apiSchema.LinkHandlers[linkName] = http.HandlerFunc(serveOwners)
+		},
+		Formatter: func(request *types.APIRequest, resource *types.RawResource) {
+			if resource.APIObject.Object == nil || len(resource.APIObject.Data().Slice("metadata", "ownerReferences")) == 0 {
+				return
+			}
+			resource.Links[linkName] = request.URLBuilder.Link(resource.Schema, resource.ID, linkName)
+		},
+	}
+}
+
+func serveOwners(rw http.ResponseWriter, req *http.Request) {
+	apiOp := types.GetAPIContext(req.Context())
+	result, err := owners(apiOp)
+	if err != nil {
+		apiOp.WriteError(err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(result); err != nil {
+		logrus.Errorf("failed to write owners: %v", err)
+	}
+}
+
+// owners reads the requested object and its owners as the user, breadth first, so that owners the user can't read
+// are reported without revealing more than their owner references do
+func owners(apiOp *types.APIRequest) (*Result, error) {
+	obj, err := apiOp.Schema.Store.ByID(apiOp, apiOp.Schema, apiOp.Name)
+	if err != nil {
+		return nil, err
+	}
+	result := &Result{
+		Type:   linkName,
+		ID:     obj.ID,
+		Owners: []Owner{},
+	}
+
+	seen := map[string]bool{}
+	current := []*unstructured.Unstructured{toUnstructured(obj)}
+	for depth := 1; depth <= maxDepth && len(current) > 0; depth++ {
+		var next []*unstructured.Unstructured
+		for _, child := range current {
+			for _, ref := range child.GetOwnerReferences() {
+				if seen[string(ref.UID)] {
+					continue
+				}
+				seen[string(ref.UID)] = true
+				owner, ownerObj := readOwner(apiOp, child.GetNamespace(), ref)
+				owner.Depth = depth
+				result.Owners = append(result.Owners, owner)
+				if ownerObj != nil {
+					next = append(next, ownerObj)
+				}
+			}
+		}
+		current = next
+	}
+	return result, nil
+}
+
+// readOwner reads the owner referenced by ref, which is in namespace if it is namespaced
+func readOwner(apiOp *types.APIRequest, namespace string, ref metav1.OwnerReference) (Owner, *unstructured.Unstructured) {
+	owner := Owner{
+		ID:         ref.Name,
+		APIVersion: ref.APIVersion,
+		Kind:       ref.Kind,
+		Name:       ref.Name,
+		UID:        string(ref.UID),
+		Controller: ref.Controller != nil && *ref.Controller,
+	}
+	gv, err := k8sschema.ParseGroupVersion(ref.APIVersion)
+	if err != nil {
+		owner.Error = err.Error()
+		return owner, nil
+	}
+	ownerSchema := lookupSchema(apiOp, gv.WithKind(ref.Kind))
+	if ownerSchema == nil || ownerSchema.Store == nil {
+		owner.Error = "can not read " + ref.Kind
+		return owner, nil
+	}
+	owner.Type = ownerSchema.ID
+	if attributes.Namespaced(ownerSchema) {
+		owner.ID = namespace + "/" + ref.Name
+	}
+
+	ownerOp := apiOp.Clone()
+	ownerOp.Schema = ownerSchema
+	ownerOp.Type = ownerSchema.ID
+	ownerOp.Namespace = ""
+	ownerOp.Name = owner.ID
+	ownerOp.Link = ""
+	obj, err := ownerSchema.Store.ByID(ownerOp, ownerSchema, owner.ID)
+	if err != nil {
+		owner.Error = err.Error()
+		return owner, nil
+	}
+	ownerObj := toUnstructured(obj)
+	if string(ownerObj.GetUID()) != owner.UID {
+		// the owner was deleted and replaced by another object with the same name
+		owner.Error = "owner not found"
+		return owner, nil
+	}
+	return owner, ownerObj
+}
+
+// lookupSchema returns the schema of a kind among the schemas of the user, ignoring its version
+func lookupSchema(apiOp *types.APIRequest, gvk k8sschema.GroupVersionKind) *types.APISchema {
+	var result *types.APISchema
+	for _, apiSchema := range apiOp.Schemas.Schemas {
+		if attributes.Group(apiSchema) != gvk.Group || attributes.Kind(apiSchema) != gvk.Kind {
+			continue
+		}
+		if attributes.Version(apiSchema) == gvk.Version {
+			return apiSchema
+		}
+		result = apiSchema
+	}
+	return result
+}
+
+func toUnstructured(obj types.APIObject) *unstructured.Unstructured {
+	if u, ok := obj.Object.(*unstructured.Unstructured); ok {
+		return u
+	}
+	return &unstructured.Unstructured{Object: obj.Data()}
+}
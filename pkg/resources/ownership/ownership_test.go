@@ -0,0 +1,129 @@
+package ownership
+
+import (
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/store/empty"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type fakeStore struct {
+	empty.Store
+	objects map[string]*unstructured.Unstructured
+}
+
+func (f *fakeStore) ByID(apiOp *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
+	obj, ok := f.objects[schema.ID+":"+id]
+	if !ok {
+		return types.APIObject{}, apierror.NewAPIError(validation.NotFound, "not found")
+	}
+	return types.APIObject{Type: schema.ID, ID: id, Object: obj}, nil
+}
+
+func newObject(namespace, name, uid string, owners ...map[string]interface{}) *unstructured.Unstructured {
+	refs := make([]interface{}, 0, len(owners))
+	for _, owner := range owners {
+		refs = append(refs, owner)
+	}
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"namespace":       namespace,
+			"name":            name,
+			"uid":             uid,
+			"ownerReferences": refs,
+		},
+	}}
+}
+
+func TestOwners(t *testing.T) {
+	controller := true
+	store := &fakeStore{objects: map[string]*unstructured.Unstructured{
+		"pod:default/web-abc-123": newObject("default", "web-abc-123", "1",
+			map[string]interface{}{"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": "web-abc", "uid": "2", "controller": controller},
+			map[string]interface{}{"apiVersion": "example.io/v1", "kind": "Secret", "name": "hidden", "uid": "3"},
+		),
+		"apps.replicaset:default/web-abc": newObject("default", "web-abc", "2",
+			map[string]interface{}{"apiVersion": "apps/v1", "kind": "Deployment", "name": "web", "uid": "4", "controller": controller},
+		),
+		// the deployment was replaced by another one with the same name
+		"apps.deployment:default/web": newObject("default", "web", "5"),
+	}}
+	schemaOf := func(id, group, kind string) types.APISchema {
+		return types.APISchema{
+			Schema: &schemas.Schema{
+				ID: id,
+				Attributes: map[string]interface{}{
+					"group":      group,
+					"version":    "v1",
+					"kind":       kind,
+					"namespaced": true,
+				},
+			},
+			Store: store,
+		}
+	}
+	apiSchemas := types.EmptyAPISchemas()
+	for _, s := range []types.APISchema{
+		schemaOf("pod", "", "Pod"),
+		schemaOf("apps.replicaset", "apps", "ReplicaSet"),
+		schemaOf("apps.deployment", "apps", "Deployment"),
+	} {
+		apiSchemas.MustAddSchema(s)
+	}
+
+	result, err := owners(&types.APIRequest{
+		Schemas: apiSchemas,
+		Schema:  apiSchemas.LookupSchema("pod"),
+		Name:    "default/web-abc-123",
+	})
+	require.NoError(t, err)
+	assert.Equal(t, &Result{
+		Type: "owners",
+		ID:   "default/web-abc-123",
+		Owners: []Owner{
+			{
+				Type:       "apps.replicaset",
+				ID:         "default/web-abc",
+				APIVersion: "apps/v1",
+				Kind:       "ReplicaSet",
+				Name:       "web-abc",
+				UID:        "2",
+				Controller: true,
+				Depth:      1,
+			},
+			{
+				ID:         "hidden",
+				APIVersion: "example.io/v1",
+				Kind:       "Secret",
+				Name:       "hidden",
+				UID:        "3",
+				Depth:      1,
+				Error:      "can not read Secret",
+			},
+			{
+				Type:       "apps.deployment",
+				ID:         "default/web",
+				APIVersion: "apps/v1",
+				Kind:       "Deployment",
+				Name:       "web",
+				UID:        "4",
+				Controller: true,
+				Depth:      2,
+				Error:      "owner not found",
+			},
+		},
+	}, result)
+
+	_, err = owners(&types.APIRequest{
+		Schemas: apiSchemas,
+		Schema:  apiSchemas.LookupSchema("pod"),
+		Name:    "default/missing",
+	})
+	assert.Error(t, err)
+}
@@ -0,0 +1,240 @@
+package podproxy
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes"
+)
+
+const logsLink = "logs"
+
+// maxLogLineSize is the longest line of merged logs, the logs of a container stopping at longer lines
+const maxLogLineSize = 1024 * 1024
+
+// logOptions are the options of the logs link, parsed from its query params
+type logOptions struct {
+	podLogOptions corev1.PodLogOptions
+	// containers are the containers whose logs are streamed, those of the default container if empty
+	containers []string
+	// allContainers streams the logs of all the containers of the pod, including init containers
+	allContainers bool
+}
+
+func parseLogOptions(query url.Values) (logOptions, error) {
+	opts := logOptions{
+		containers: query["container"],
+	}
+	var err error
+	if opts.podLogOptions.Follow, err = boolParam(query, "follow"); err != nil {
+		return logOptions{}, err
+	}
+	if opts.podLogOptions.Previous, err = boolParam(query, "previous"); err != nil {
+		return logOptions{}, err
+	}
+	if opts.podLogOptions.Timestamps, err = boolParam(query, "timestamps"); err != nil {
+		return logOptions{}, err
+	}
+	if opts.allContainers, err = boolParam(query, "allContainers"); err != nil {
+		return logOptions{}, err
+	}
+	if opts.allContainers && len(opts.containers) > 0 {
+		return logOptions{}, apierror.NewAPIError(validation.InvalidOption, "container and allContainers are mutually exclusive")
+	}
+	if value := query.Get("tailLines"); value != "" {
+		tailLines, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || tailLines < 0 {
+			return logOptions{}, apierror.NewFieldAPIError(validation.InvalidFormat, "tailLines", "tailLines must be a positive number")
+		}
+		opts.podLogOptions.TailLines = &tailLines
+	}
+	if value := query.Get("sinceTime"); value != "" {
+		sinceTime, err := time.Parse(time.RFC3339, value)
+		if err != nil {
+			return logOptions{}, apierror.NewFieldAPIError(validation.InvalidDateFormat, "sinceTime", "sinceTime must be an RFC 3339 date")
+		}
+		opts.podLogOptions.SinceTime = &metav1.Time{Time: sinceTime}
+	}
+	if value := query.Get("sinceSeconds"); value != "" {
+		sinceSeconds, err := strconv.ParseInt(value, 10, 64)
+		if err != nil || sinceSeconds <= 0 {
+			return logOptions{}, apierror.NewFieldAPIError(validation.InvalidFormat, "sinceSeconds", "sinceSeconds must be a positive number")
+		}
+		if opts.podLogOptions.SinceTime != nil {
+			return logOptions{}, apierror.NewAPIError(validation.InvalidOption, "sinceTime and sinceSeconds are mutually exclusive")
+		}
+		opts.podLogOptions.SinceSeconds = &sinceSeconds
+	}
+	return opts, nil
+}
+
+func boolParam(query url.Values, name string) (bool, error) {
+	value := query.Get(name)
+	if value == "" {
+		return false, nil
+	}
+	result, err := strconv.ParseBool(value)
+	if err != nil {
+		return false, apierror.NewFieldAPIError(validation.InvalidFormat, name, name+" must be true or false")
+	}
+	return result, nil
+}
+
+// logsHandler streams the logs of pods, read with the client of the requester
+type logsHandler struct {
+	cg proxy.ClientGetter
+}
+
+func (h *logsHandler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	apiOp := types.GetAPIContext(req.Context())
+	if err := h.serve(apiOp, rw, req); err != nil {
+		apiOp.WriteError(proxy.TranslateError(err))
+	}
+}
+
+func (h *logsHandler) serve(apiOp *types.APIRequest, rw http.ResponseWriter, req *http.Request) error {
+	opts, err := parseLogOptions(req.URL.Query())
+	if err != nil {
+		return err
+	}
+	client, err := h.cg.K8sInterface(apiOp)
+	if err != nil {
+		return err
+	}
+	containers := opts.containers
+	if opts.allContainers {
+		if containers, err = podContainers(apiOp.Context(), client, apiOp.Namespace, apiOp.Name); err != nil {
+			return err
+		}
+	}
+
+	// streams are all opened before writing the response, so that failing to open one is reported as an error
+	streams, err := openLogStreams(apiOp.Context(), client, apiOp.Namespace, apiOp.Name, containers, opts.podLogOptions)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		for _, stream := range streams {
+			stream.Close()
+		}
+	}()
+
+	rw.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	rw.Header().Set("X-Content-Type-Options", "nosniff")
+	rw.WriteHeader(http.StatusOK)
+	w := &flushWriter{w: rw}
+	w.flusher, _ = rw.(http.Flusher)
+	w.Flush()
+
+	if len(containers) <= 1 {
+		_, err := io.Copy(w, streams[0])
+		logStreamError(apiOp, err)
+		return nil
+	}
+	mergeLogs(apiOp, w, containers, streams)
+	return nil
+}
+
+// podContainers returns the names of the init and regular containers of a pod
+func podContainers(ctx context.Context, client kubernetes.Interface, namespace, name string) ([]string, error) {
+	pod, err := client.CoreV1().Pods(namespace).Get(ctx, name, metav1.GetOptions{})
+	if err != nil {
+		return nil, err
+	}
+	var containers []string
+	for _, container := range pod.Spec.InitContainers {
+		containers = append(containers, container.Name)
+	}
+	for _, container := range pod.Spec.Containers {
+		containers = append(containers, container.Name)
+	}
+	return containers, nil
+}
+
+func openLogStreams(ctx context.Context, client kubernetes.Interface, namespace, name string, containers []string, podLogOptions corev1.PodLogOptions) ([]io.ReadCloser, error) {
+	if len(containers) == 0 {
+		// the default container of the pod
+		containers = []string{""}
+	}
+	var streams []io.ReadCloser
+	for _, container := range containers {
+		opts := podLogOptions
+		opts.Container = container
+		stream, err := client.CoreV1().Pods(namespace).GetLogs(name, &opts).Stream(ctx)
+		if err != nil {
+			for _, stream := range streams {
+				stream.Close()
+			}
+			return nil, err
+		}
+		streams = append(streams, stream)
+	}
+	return streams, nil
+}
+
+// mergeLogs writes the lines of the logs of several containers as they come, each prefixed with the name of its
+// container between brackets
+func mergeLogs(apiOp *types.APIRequest, w io.Writer, containers []string, streams []io.ReadCloser) {
+	var (
+		lock sync.Mutex
+		wg   sync.WaitGroup
+	)
+	for i, stream := range streams {
+		wg.Add(1)
+		go func(prefix string, stream io.Reader) {
+			defer wg.Done()
+			scanner := bufio.NewScanner(stream)
+			scanner.Buffer(make([]byte, 0, 64*1024), maxLogLineSize)
+			for scanner.Scan() {
+				lock.Lock()
+				_, err := fmt.Fprintf(w, "%s%s\n", prefix, scanner.Bytes())
+				lock.Unlock()
+				if err != nil {
+					return
+				}
+			}
+			logStreamError(apiOp, scanner.Err())
+		}("["+containers[i]+"] ", stream)
+	}
+	wg.Wait()
+}
+
+func logStreamError(apiOp *types.APIRequest, err error) {
+	if err == nil || apiOp.Context().Err() != nil || strings.Contains(err.Error(), "use of closed") {
+		return
+	}
+	logrus.Debugf("failed to stream the logs of pod %s/%s: %v", apiOp.Namespace, apiOp.Name, err)
+}
+
+// flushWriter flushes every write, so that followed logs are sent as they come
+type flushWriter struct {
+	w       io.Writer
+	flusher http.Flusher
+}
+
+func (f *flushWriter) Write(p []byte) (int, error) {
+	n, err := f.w.Write(p)
+	f.Flush()
+	return n, err
+}
+
+func (f *flushWriter) Flush() {
+	if f.flusher != nil {
+		f.flusher.Flush()
+	}
+}
@@ -0,0 +1,136 @@
+package podproxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/kubernetes/fake"
+)
+
+func TestParseLogOptions(t *testing.T) {
+	tailLines := int64(100)
+	sinceSeconds := int64(60)
+	sinceTime := metav1.NewTime(time.Date(2024, 5, 1, 10, 0, 0, 0, time.UTC))
+	tests := []struct {
+		name      string
+		query     string
+		want      logOptions
+		wantError validation.ErrorCode
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:  "follow with tail",
+			query: "follow=true&tailLines=100&container=app&container=sidecar",
+			want: logOptions{
+				podLogOptions: corev1.PodLogOptions{Follow: true, TailLines: &tailLines},
+				containers:    []string{"app", "sidecar"},
+			},
+		},
+		{
+			name:  "since time",
+			query: "sinceTime=2024-05-01T10:00:00Z&timestamps=true&previous=true",
+			want: logOptions{
+				podLogOptions: corev1.PodLogOptions{SinceTime: &sinceTime, Timestamps: true, Previous: true},
+			},
+		},
+		{
+			name:  "since seconds of all containers",
+			query: "sinceSeconds=60&allContainers=true",
+			want: logOptions{
+				podLogOptions: corev1.PodLogOptions{SinceSeconds: &sinceSeconds},
+				allContainers: true,
+			},
+		},
+		{
+			name:      "invalid tail",
+			query:     "tailLines=-1",
+			wantError: validation.InvalidFormat,
+		},
+		{
+			name:      "invalid since time",
+			query:     "sinceTime=yesterday",
+			wantError: validation.InvalidDateFormat,
+		},
+		{
+			name:      "invalid follow",
+			query:     "follow=maybe",
+			wantError: validation.InvalidFormat,
+		},
+		{
+			name:      "since time and seconds",
+			query:     "sinceTime=2024-05-01T10:00:00Z&sinceSeconds=60",
+			wantError: validation.InvalidOption,
+		},
+		{
+			name:      "containers and all containers",
+			query:     "container=app&allContainers=true",
+			wantError: validation.InvalidOption,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			query, err := url.ParseQuery(test.query)
+			require.NoError(t, err)
+			opts, err := parseLogOptions(query)
+			if test.wantError.Code != "" {
+				assert.Equal(t, test.wantError, apiErrorCode(t, err))
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, opts)
+		})
+	}
+}
+
+func TestLogsHandler(t *testing.T) {
+	client := fake.NewSimpleClientset(&corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{Name: "web", Namespace: "default"},
+		Spec: corev1.PodSpec{
+			InitContainers: []corev1.Container{{Name: "init"}},
+			Containers:     []corev1.Container{{Name: "app"}, {Name: "sidecar"}},
+		},
+	})
+	h := &logsHandler{cg: &clientGetter{client: client}}
+
+	serve := func(query string) (*httptest.ResponseRecorder, error) {
+		var writtenErr error
+		rw := httptest.NewRecorder()
+		apiOp := types.StoreAPIContext(&types.APIRequest{
+			Namespace:    "default",
+			Name:         "web",
+			Link:         logsLink,
+			Request:      httptest.NewRequest(http.MethodGet, "/v1/pods/default/web/logs?"+query, nil),
+			Response:     rw,
+			ErrorHandler: func(_ *types.APIRequest, err error) { writtenErr = err },
+		})
+		h.ServeHTTP(rw, apiOp.Request)
+		return rw, writtenErr
+	}
+
+	// the fake client returns "fake logs" as the logs of every container
+	rw, err := serve("container=app")
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, rw.Code)
+	assert.Equal(t, "text/plain; charset=utf-8", rw.Header().Get("Content-Type"))
+	assert.Equal(t, "fake logs", rw.Body.String())
+
+	rw, err = serve("allContainers=true")
+	require.NoError(t, err)
+	lines := strings.Split(strings.TrimSuffix(rw.Body.String(), "\n"), "\n")
+	assert.ElementsMatch(t, []string{"[init] fake logs", "[app] fake logs", "[sidecar] fake logs"}, lines)
+
+	_, err = serve("tailLines=many")
+	assert.Equal(t, validation.InvalidFormat, apiErrorCode(t, err))
+}
@@ -0,0 +1,109 @@
+// Package podproxy provides the exec, attach and portforward links of pods, which proxy the streams of the matching
+// subresources of the Kubernetes API through the proxy of steve, and their logs link, streaming the logs of their
+// containers. Terminals, port forwards and logs of UIs then use the same connection as the rest of the API.
+package podproxy
+
+import (
+	"fmt"
+	"net/http"
+	"net/url"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/httpstream"
+)
+
+// Subresources are the streaming subresources of pods served as links, under the same names
+var Subresources = []string{"exec", "attach", "portforward"}
+
+// Template returns a schema template adding the exec, attach, portforward and logs links to pods. Requests to the
+// streaming links are proxied by k8sProxy, which serves the Kubernetes API as the user of the request, once a
+// SelfSubjectAccessReview checks that the user can create the subresource. Logs are read with the client of the user.
+func Template(cg proxy.ClientGetter, k8sProxy http.Handler) schema.Template {
+	return schema.Template{
+		ID: "pod",
+		Customize: func(apiSchema *types.APISchema) {
+			if apiSchema.LinkHandlers == nil {
+				apiSchema.LinkHandlers = map[string]http.Handler{}
+			}
+			for _, subresource := range Subresources {
+				apiSchema.LinkHandlers[subresource] = &handler{
+					cg:          cg,
+					k8sProxy:    k8sProxy,
+					subresource: subresource,
+				}
+			}
+			apiSchema.LinkHandlers[logsLink] = &logsHandler{cg: cg}
+		},
+	}
+}
+
+type handler struct {
+	cg          proxy.ClientGetter
+	k8sProxy    http.Handler
+	subresource string
+}
+
+// ServeHTTP proxies the upgrade request of a link to the subresource of the pod. The query params of the link, such
+// as command, container, stdin, stdout, stderr and tty for exec, or ports for portforward, are those of the
+// subresource. The streams, including the resize stream of TTYs, are relayed as they are.
+func (h *handler) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	apiOp := types.GetAPIContext(req.Context())
+	if !httpstream.IsUpgradeRequest(req) {
+		apiOp.WriteError(apierror.NewAPIError(validation.InvalidAction,
+			fmt.Sprintf("%s requires a WebSocket or SPDY upgrade request", h.subresource)))
+		return
+	}
+	if err := h.authorize(apiOp); err != nil {
+		apiOp.WriteError(proxy.TranslateError(err))
+		return
+	}
+	h.k8sProxy.ServeHTTP(rw, h.proxyRequest(apiOp, req))
+}
+
+// proxyRequest returns the request of the subresource of the pod, with the query params of the link request
+func (h *handler) proxyRequest(apiOp *types.APIRequest, req *http.Request) *http.Request {
+	query := req.URL.Query()
+	query.Del("link")
+
+	proxyReq := req.Clone(req.Context())
+	proxyReq.URL = &url.URL{
+		Path:     fmt.Sprintf("/api/v1/namespaces/%s/pods/%s/%s", apiOp.Namespace, apiOp.Name, h.subresource),
+		RawQuery: query.Encode(),
+	}
+	proxyReq.RequestURI = proxyReq.URL.RequestURI()
+	return proxyReq
+}
+
+// authorize checks that the requester can create the subresource of the pod, with their credentials
+func (h *handler) authorize(apiOp *types.APIRequest) error {
+	client, err := h.cg.K8sInterface(apiOp)
+	if err != nil {
+		return err
+	}
+	review, err := client.AuthorizationV1().SelfSubjectAccessReviews().Create(apiOp.Context(), &authorizationv1.SelfSubjectAccessReview{
+		Spec: authorizationv1.SelfSubjectAccessReviewSpec{
+			ResourceAttributes: &authorizationv1.ResourceAttributes{
+				Verb:        "create",
+				Version:     "v1",
+				Resource:    "pods",
+				Subresource: h.subresource,
+				Namespace:   apiOp.Namespace,
+				Name:        apiOp.Name,
+			},
+		},
+	}, metav1.CreateOptions{})
+	if err != nil {
+		return err
+	}
+	if !review.Status.Allowed {
+		return apierror.NewAPIError(validation.PermissionDenied,
+			fmt.Sprintf("can not %s pod %s/%s", h.subresource, apiOp.Namespace, apiOp.Name))
+	}
+	return nil
+}
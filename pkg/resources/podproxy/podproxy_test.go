@@ -0,0 +1,108 @@
+package podproxy
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	authorizationv1 "k8s.io/api/authorization/v1"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/kubernetes"
+	"k8s.io/client-go/kubernetes/fake"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+type clientGetter struct {
+	proxy.ClientGetter
+	client kubernetes.Interface
+}
+
+func (c *clientGetter) K8sInterface(*types.APIRequest) (kubernetes.Interface, error) {
+	return c.client, nil
+}
+
+func TestTemplate(t *testing.T) {
+	pod := &types.APISchema{Schema: &schemas.Schema{ID: "pod"}}
+	Template(nil, nil).Customize(pod)
+	for _, subresource := range Subresources {
+		assert.Contains(t, pod.LinkHandlers, subresource)
+	}
+	assert.Contains(t, pod.LinkHandlers, logsLink)
+}
+
+func TestHandler(t *testing.T) {
+	var reviews []*authorizationv1.ResourceAttributes
+	allowed := true
+	client := fake.NewSimpleClientset()
+	client.PrependReactor("create", "selfsubjectaccessreviews", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		review := action.(k8stesting.CreateAction).GetObject().(*authorizationv1.SelfSubjectAccessReview)
+		reviews = append(reviews, review.Spec.ResourceAttributes)
+		review.Status.Allowed = allowed
+		return true, review, nil
+	})
+
+	var proxied *http.Request
+	h := &handler{
+		cg: &clientGetter{client: client},
+		k8sProxy: http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+			proxied = req
+			rw.WriteHeader(http.StatusSwitchingProtocols)
+		}),
+		subresource: "exec",
+	}
+
+	serve := func(upgrade bool) (*httptest.ResponseRecorder, error) {
+		var writtenErr error
+		rw := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/v1/pods/default/web?link=exec&command=sh&container=app&stdin=true&tty=true", nil)
+		if upgrade {
+			req.Header.Set("Connection", "Upgrade")
+			req.Header.Set("Upgrade", "websocket")
+			req.Header.Set("Sec-WebSocket-Protocol", "v4.channel.k8s.io")
+		}
+		apiOp := types.StoreAPIContext(&types.APIRequest{
+			Namespace:    "default",
+			Name:         "web",
+			Link:         "exec",
+			Request:      req,
+			Response:     rw,
+			ErrorHandler: func(_ *types.APIRequest, err error) { writtenErr = err },
+		})
+		h.ServeHTTP(rw, apiOp.Request)
+		return rw, writtenErr
+	}
+
+	rw, err := serve(true)
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusSwitchingProtocols, rw.Code)
+	require.NotNil(t, proxied)
+	assert.Equal(t, "/api/v1/namespaces/default/pods/web/exec", proxied.URL.Path)
+	assert.Equal(t, "command=sh&container=app&stdin=true&tty=true", proxied.URL.RawQuery)
+	assert.Equal(t, "v4.channel.k8s.io", proxied.Header.Get("Sec-WebSocket-Protocol"), "subprotocols, which carry TTY resizes, are kept")
+	require.Len(t, reviews, 1)
+	assert.Equal(t, &authorizationv1.ResourceAttributes{Verb: "create", Version: "v1", Resource: "pods", Subresource: "exec", Namespace: "default", Name: "web"}, reviews[0])
+
+	proxied = nil
+	_, err = serve(false)
+	assert.Equal(t, validation.InvalidAction, apiErrorCode(t, err), "links require upgrade requests")
+	assert.Nil(t, proxied)
+
+	allowed = false
+	_, err = serve(true)
+	assert.Equal(t, validation.PermissionDenied, apiErrorCode(t, err))
+	assert.Nil(t, proxied)
+}
+
+func apiErrorCode(t *testing.T, err error) validation.ErrorCode {
+	var apiError *apierror.APIError
+	require.True(t, errors.As(err, &apiError), "expected an API error, got %v", err)
+	return apiError.Code
+}
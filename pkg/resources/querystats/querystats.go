@@ -0,0 +1,71 @@
+// Package querystats provides the queryStat schema, which returns the statistics of the shapes of list queries, the
+// slowest first, so that operators can tell which views of the UI load the caches the most.
+package querystats
+
+import (
+	"fmt"
+	"hash/fnv"
+	"strconv"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/metrics"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+)
+
+const limitParam = "limit"
+
+// Stats returns the statistics of the shapes of list queries
+type Stats interface {
+	Slowest(limit int) []metrics.QueryStat
+}
+
+// Register registers the queryStat schema, which lists the statistics of the query shapes of the types the user can
+// access, the slowest first. The limit query param restricts the number of shapes returned.
+func Register(baseSchema *types.APISchemas, stats Stats) {
+	baseSchema.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID:                "queryStat",
+			PluralName:        "queryStats",
+			CollectionMethods: []string{"GET"},
+		},
+		ListHandler: func(request *types.APIRequest) (types.APIObjectList, error) {
+			limit := 0
+			if value := request.Query.Get(limitParam); value != "" {
+				var err error
+				limit, err = strconv.Atoi(value)
+				if err != nil || limit < 1 {
+					return types.APIObjectList{}, apierror.NewAPIError(validation.InvalidOption, fmt.Sprintf("invalid %s %q, must be a positive integer", limitParam, value))
+				}
+			}
+			return list(request, stats, limit), nil
+		},
+	})
+}
+
+func list(request *types.APIRequest, stats Stats, limit int) types.APIObjectList {
+	result := types.APIObjectList{}
+	for _, stat := range stats.Slowest(0) {
+		// pseudo-access check, to make sure that users only see the statistics of the schemas they have access to
+		if request.Schemas.LookupSchema(stat.Resource) == nil {
+			continue
+		}
+		if limit > 0 && len(result.Objects) == limit {
+			break
+		}
+		result.Objects = append(result.Objects, types.APIObject{
+			ID:     id(stat),
+			Type:   "queryStat",
+			Object: stat,
+		})
+	}
+	return result
+}
+
+// id returns a stable ID for a query shape, which can't be used as is in a URL
+func id(stat metrics.QueryStat) string {
+	h := fnv.New64a()
+	h.Write([]byte(stat.Resource + "?" + stat.Shape))
+	return fmt.Sprintf("%s-%x", stat.Resource, h.Sum64())
+}
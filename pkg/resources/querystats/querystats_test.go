@@ -0,0 +1,52 @@
+package querystats
+
+import (
+	"net/url"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/metrics"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStats []metrics.QueryStat
+
+func (f fakeStats) Slowest(limit int) []metrics.QueryStat {
+	if limit > 0 && len(f) > limit {
+		return f[:limit]
+	}
+	return f
+}
+
+func TestRegister(t *testing.T) {
+	stats := fakeStats{
+		{Resource: "secret", Shape: "filter=metadata.name", MaxMilliseconds: 3000},
+		{Resource: "pod", Shape: "sort=metadata.name", MaxMilliseconds: 2000},
+		{Resource: "pod", Shape: "", MaxMilliseconds: 1000},
+	}
+	baseSchemas := types.EmptyAPISchemas()
+	Register(baseSchemas, stats)
+	queryStat := baseSchemas.LookupSchema("queryStat")
+	require.NotNil(t, queryStat)
+
+	// the user can only access pods
+	userSchemas := types.EmptyAPISchemas()
+	userSchemas.MustAddSchema(types.APISchema{Schema: &schemas.Schema{ID: "pod"}})
+
+	list, err := queryStat.ListHandler(&types.APIRequest{Schemas: userSchemas, Query: url.Values{}})
+	require.NoError(t, err)
+	require.Len(t, list.Objects, 2)
+	assert.Equal(t, stats[1], list.Objects[0].Object)
+	assert.Equal(t, stats[2], list.Objects[1].Object)
+	assert.NotEqual(t, list.Objects[0].ID, list.Objects[1].ID)
+
+	list, err = queryStat.ListHandler(&types.APIRequest{Schemas: userSchemas, Query: url.Values{"limit": {"1"}}})
+	require.NoError(t, err)
+	require.Len(t, list.Objects, 1)
+	assert.Equal(t, stats[1], list.Objects[0].Object)
+
+	_, err = queryStat.ListHandler(&types.APIRequest{Schemas: userSchemas, Query: url.Values{"limit": {"0"}}})
+	assert.Error(t, err)
+}
@@ -0,0 +1,326 @@
+// Package redaction masks sensitive fields of objects, such as secret data or passwords in environment variables, for
+// users who aren't granted the view-sensitive verb on them.
+package redaction
+
+import (
+	"fmt"
+	"path"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/schema"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// ViewSensitiveVerb is the RBAC verb allowing users to see the redacted fields of a resource
+	ViewSensitiveVerb = "view-sensitive"
+	// Mask replaces redacted values
+	Mask = "<redacted>"
+)
+
+// Rule redacts fields of a kind of object. Paths are dot-separated field names, where * matches any field, and list
+// fields are followed by a selector, either [*] for all items or [field=pattern] for the items with a field matching
+// a glob pattern. For example, spec.containers[*].env[name=*PASSWORD*].value redacts the values of the environment
+// variables of a pod with PASSWORD in their name.
+type Rule struct {
+	Group string   `json:"group,omitempty"`
+	Kind  string   `json:"kind"`
+	Paths []string `json:"paths"`
+}
+
+// DefaultRules redact the data of secrets and the values of environment variables of pods and workloads which look
+// like passwords. They are not applied unless configured.
+var DefaultRules = []Rule{
+	{
+		Kind:  "Secret",
+		Paths: []string{"data.*", "stringData.*"},
+	},
+	{
+		Kind: "Pod",
+		Paths: []string{
+			"spec.containers[*].env[name=*PASSWORD*].value",
+			"spec.initContainers[*].env[name=*PASSWORD*].value",
+		},
+	},
+	workloadRule("apps", "Deployment"),
+	workloadRule("apps", "StatefulSet"),
+	workloadRule("apps", "DaemonSet"),
+	workloadRule("apps", "ReplicaSet"),
+	workloadRule("batch", "Job"),
+	{
+		Group: "batch",
+		Kind:  "CronJob",
+		Paths: []string{
+			"spec.jobTemplate.spec.template.spec.containers[*].env[name=*PASSWORD*].value",
+			"spec.jobTemplate.spec.template.spec.initContainers[*].env[name=*PASSWORD*].value",
+		},
+	},
+}
+
+func workloadRule(group, kind string) Rule {
+	return Rule{
+		Group: group,
+		Kind:  kind,
+		Paths: []string{
+			"spec.template.spec.containers[*].env[name=*PASSWORD*].value",
+			"spec.template.spec.initContainers[*].env[name=*PASSWORD*].value",
+		},
+	}
+}
+
+type segment struct {
+	// key is the name of the field, or * for any field
+	key string
+	// list is true if the field is a list whose items are selected
+	list bool
+	// selectField and selectPattern select the list items whose field matches the pattern. All items are selected if
+	// selectField is empty
+	selectField   string
+	selectPattern string
+}
+
+// Policy redacts the fields of objects according to rules
+type Policy struct {
+	paths map[k8sschema.GroupKind][][]segment
+}
+
+// New returns a policy applying rules, or an error if any of their paths is invalid
+func New(rules []Rule) (*Policy, error) {
+	policy := &Policy{paths: map[k8sschema.GroupKind][][]segment{}}
+	for _, rule := range rules {
+		if rule.Kind == "" {
+			return nil, fmt.Errorf("redaction rule for group %q has no kind", rule.Group)
+		}
+		gk := k8sschema.GroupKind{Group: rule.Group, Kind: rule.Kind}
+		for _, p := range rule.Paths {
+			segments, err := parsePath(p)
+			if err != nil {
+				return nil, fmt.Errorf("invalid redaction path %q for %s: %w", p, gk, err)
+			}
+			policy.paths[gk] = append(policy.paths[gk], segments)
+		}
+	}
+	return policy, nil
+}
+
+func parsePath(p string) ([]segment, error) {
+	var result []segment
+	for p != "" {
+		var seg segment
+		end := strings.IndexAny(p, ".[")
+		if end == -1 {
+			end = len(p)
+		}
+		seg.key = p[:end]
+		if seg.key == "" {
+			return nil, fmt.Errorf("empty field name")
+		}
+		p = p[end:]
+		if strings.HasPrefix(p, "[") {
+			closing := strings.Index(p, "]")
+			if closing == -1 {
+				return nil, fmt.Errorf("unterminated selector")
+			}
+			seg.list = true
+			selector := p[1:closing]
+			if selector != "*" {
+				field, pattern, ok := strings.Cut(selector, "=")
+				if !ok || field == "" {
+					return nil, fmt.Errorf("selector %q is neither * nor field=pattern", selector)
+				}
+				if _, err := path.Match(pattern, ""); err != nil {
+					return nil, fmt.Errorf("invalid pattern %q: %w", pattern, err)
+				}
+				seg.selectField, seg.selectPattern = field, pattern
+			}
+			p = p[closing+1:]
+		}
+		if p != "" {
+			if !strings.HasPrefix(p, ".") {
+				return nil, fmt.Errorf("expected . after %s", seg.key)
+			}
+			p = p[1:]
+			if p == "" {
+				return nil, fmt.Errorf("trailing .")
+			}
+		}
+		result = append(result, seg)
+	}
+	if len(result) == 0 {
+		return nil, fmt.Errorf("empty path")
+	}
+	return result, nil
+}
+
+// Redacts returns true if the policy has rules for the schema's kind
+func (p *Policy) Redacts(apiSchema *types.APISchema) bool {
+	return len(p.paths[attributes.GVK(apiSchema).GroupKind()]) > 0
+}
+
+// Redact replaces the values of the redacted fields of obj with Mask
+func (p *Policy) Redact(apiSchema *types.APISchema, obj map[string]interface{}) {
+	for _, segments := range p.paths[attributes.GVK(apiSchema).GroupKind()] {
+		walk(obj, nil, segments, false)
+	}
+}
+
+// Restore replaces the masked values of the redacted fields of obj with their values in current, so that writing back
+// a redacted object doesn't overwrite them. Masked fields missing from current are removed.
+func (p *Policy) Restore(apiSchema *types.APISchema, obj, current map[string]interface{}) {
+	for _, segments := range p.paths[attributes.GVK(apiSchema).GroupKind()] {
+		walk(obj, current, segments, true)
+	}
+}
+
+// walk masks the fields of value matched by segments or, when restoring, replaces the masked ones with the
+// corresponding fields of other
+func walk(value, other interface{}, segments []segment, restore bool) {
+	m, ok := value.(map[string]interface{})
+	if !ok {
+		return
+	}
+	otherMap, _ := other.(map[string]interface{})
+	seg, rest := segments[0], segments[1:]
+
+	for key, child := range m {
+		if seg.key != "*" && seg.key != key {
+			continue
+		}
+		if !seg.list {
+			if len(rest) > 0 {
+				walk(child, otherMap[key], rest, restore)
+			} else if !restore {
+				m[key] = Mask
+			} else if child == Mask {
+				if otherValue, ok := otherMap[key]; ok {
+					m[key] = otherValue
+				} else {
+					delete(m, key)
+				}
+			}
+			continue
+		}
+
+		items, ok := child.([]interface{})
+		if !ok {
+			continue
+		}
+		otherItems, _ := otherMap[key].([]interface{})
+		for i, item := range items {
+			if !seg.selects(item) {
+				continue
+			}
+			otherItem := seg.corresponding(i, item, otherItems)
+			if len(rest) > 0 {
+				walk(item, otherItem, rest, restore)
+			} else if !restore {
+				items[i] = Mask
+			} else if item == Mask {
+				items[i] = otherItem
+			}
+		}
+	}
+}
+
+func (s segment) selects(item interface{}) bool {
+	if s.selectField == "" {
+		return true
+	}
+	value, ok := fieldValue(item, s.selectField)
+	if !ok {
+		return false
+	}
+	matched, _ := path.Match(s.selectPattern, value)
+	return matched
+}
+
+// corresponding returns the item of otherItems matching the i-th item: the item with the same value of the selected
+// field if the items are selected by a field, otherwise the item at the same index
+func (s segment) corresponding(i int, item interface{}, otherItems []interface{}) interface{} {
+	if s.selectField == "" {
+		if i < len(otherItems) {
+			return otherItems[i]
+		}
+		return nil
+	}
+	value, _ := fieldValue(item, s.selectField)
+	for _, otherItem := range otherItems {
+		if otherValue, ok := fieldValue(otherItem, s.selectField); ok && otherValue == value {
+			return otherItem
+		}
+	}
+	return nil
+}
+
+func fieldValue(item interface{}, field string) (string, bool) {
+	m, ok := item.(map[string]interface{})
+	if !ok {
+		return "", false
+	}
+	value, ok := m[field].(string)
+	return value, ok
+}
+
+// allowed returns true if the user may see the redacted fields of the object
+func allowed(apiOp *types.APIRequest, asl accesscontrol.AccessSetLookup, apiSchema *types.APISchema, namespace, name string) bool {
+	user, ok := apiOp.GetUserInfo()
+	if !ok {
+		return false
+	}
+	accessSet := asl.AccessFor(user)
+	if accessSet == nil {
+		return false
+	}
+	return accessSet.Grants(ViewSensitiveVerb, attributes.GR(apiSchema), namespace, name)
+}
+
+// Template returns a schema template redacting the objects of the kinds policy has rules for, both when they are
+// formatted for users without the view-sensitive verb, and, by restoring masked values, when they are written back
+func Template(policy *Policy, asl accesscontrol.AccessSetLookup) schema.Template {
+	return schema.Template{
+		Formatter: func(request *types.APIRequest, resource *types.RawResource) {
+			if resource.Schema == nil || !policy.Redacts(resource.Schema) {
+				return
+			}
+			if allowed(request, asl, resource.Schema, resource.APIObject.Namespace(), resource.APIObject.Name()) {
+				return
+			}
+			policy.Redact(resource.Schema, resource.APIObject.Data())
+		},
+		Customize: func(apiSchema *types.APISchema) {
+			if apiSchema.Store == nil || !policy.Redacts(apiSchema) {
+				return
+			}
+			if _, ok := apiSchema.Store.(*Store); ok {
+				return
+			}
+			apiSchema.Store = &Store{
+				Store:  apiSchema.Store,
+				policy: policy,
+				asl:    asl,
+			}
+		},
+	}
+}
+
+// Store restores the redacted values of updated objects from their current state
+type Store struct {
+	types.Store
+	policy *Policy
+	asl    accesscontrol.AccessSetLookup
+}
+
+// Update updates a single object in the store, keeping the current values of the fields the user can't see.
+func (s *Store) Update(apiOp *types.APIRequest, apiSchema *types.APISchema, obj types.APIObject, id string) (types.APIObject, error) {
+	if !allowed(apiOp, s.asl, apiSchema, apiOp.Namespace, id) {
+		current, err := s.Store.ByID(apiOp, apiSchema, id)
+		if err != nil {
+			return types.APIObject{}, err
+		}
+		s.policy.Restore(apiSchema, obj.Data(), current.Data())
+	}
+	return s.Store.Update(apiOp, apiSchema, obj, id)
+}
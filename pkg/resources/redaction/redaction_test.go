@@ -0,0 +1,181 @@
+package redaction
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	acfake "github.com/rancher/steve/pkg/accesscontrol/fake"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+func newSchema(id, group, kind, resource string) *types.APISchema {
+	apiSchema := &types.APISchema{Schema: &schemas.Schema{ID: id}}
+	attributes.SetGVK(apiSchema, k8sschema.GroupVersionKind{Group: group, Version: "v1", Kind: kind})
+	attributes.SetGVR(apiSchema, k8sschema.GroupVersionResource{Group: group, Version: "v1", Resource: resource})
+	return apiSchema
+}
+
+func newPod() map[string]interface{} {
+	return map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name": "web",
+					"env": []interface{}{
+						map[string]interface{}{"name": "DB_PASSWORD", "value": "secret"},
+						map[string]interface{}{"name": "DB_HOST", "value": "db"},
+					},
+				},
+			},
+		},
+	}
+}
+
+func TestParsePath(t *testing.T) {
+	tests := []struct {
+		path      string
+		expected  []segment
+		wantError bool
+	}{
+		{
+			path:     "data.*",
+			expected: []segment{{key: "data"}, {key: "*"}},
+		},
+		{
+			path: "spec.containers[*].env[name=*PASSWORD*].value",
+			expected: []segment{
+				{key: "spec"},
+				{key: "containers", list: true},
+				{key: "env", list: true, selectField: "name", selectPattern: "*PASSWORD*"},
+				{key: "value"},
+			},
+		},
+		{path: "", wantError: true},
+		{path: "data.", wantError: true},
+		{path: "spec..value", wantError: true},
+		{path: "env[name]", wantError: true},
+		{path: "env[*", wantError: true},
+		{path: "env[*]value", wantError: true},
+		{path: "env[name=[]", wantError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.path, func(t *testing.T) {
+			segments, err := parsePath(test.path)
+			if test.wantError {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, segments)
+		})
+	}
+}
+
+func TestRedact(t *testing.T) {
+	policy, err := New(DefaultRules)
+	require.NoError(t, err)
+
+	secret := map[string]interface{}{
+		"type": "Opaque",
+		"data": map[string]interface{}{"a": "YQ==", "b": "Yg=="},
+	}
+	policy.Redact(newSchema("secret", "", "Secret", "secrets"), secret)
+	assert.Equal(t, map[string]interface{}{
+		"type": "Opaque",
+		"data": map[string]interface{}{"a": Mask, "b": Mask},
+	}, secret)
+
+	pod := newPod()
+	policy.Redact(newSchema("pod", "", "Pod", "pods"), pod)
+	expected := newPod()
+	expected["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})["env"].([]interface{})[0].(map[string]interface{})["value"] = Mask
+	assert.Equal(t, expected, pod)
+
+	configMap := map[string]interface{}{"data": map[string]interface{}{"a": "b"}}
+	assert.False(t, policy.Redacts(newSchema("configmap", "", "ConfigMap", "configmaps")))
+	policy.Redact(newSchema("configmap", "", "ConfigMap", "configmaps"), configMap)
+	assert.Equal(t, map[string]interface{}{"data": map[string]interface{}{"a": "b"}}, configMap)
+}
+
+func TestRestore(t *testing.T) {
+	policy, err := New(DefaultRules)
+	require.NoError(t, err)
+	podSchema := newSchema("pod", "", "Pod", "pods")
+
+	// the user reordered the environment variables, changed the host and added a new masked variable
+	updated := map[string]interface{}{
+		"spec": map[string]interface{}{
+			"containers": []interface{}{
+				map[string]interface{}{
+					"name": "web",
+					"env": []interface{}{
+						map[string]interface{}{"name": "DB_HOST", "value": "db2"},
+						map[string]interface{}{"name": "DB_PASSWORD", "value": Mask},
+						map[string]interface{}{"name": "NEW_PASSWORD", "value": Mask},
+					},
+				},
+			},
+		},
+	}
+	policy.Restore(podSchema, updated, newPod())
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"name": "DB_HOST", "value": "db2"},
+		map[string]interface{}{"name": "DB_PASSWORD", "value": "secret"},
+		map[string]interface{}{"name": "NEW_PASSWORD"},
+	}, updated["spec"].(map[string]interface{})["containers"].([]interface{})[0].(map[string]interface{})["env"])
+
+	secretSchema := newSchema("secret", "", "Secret", "secrets")
+	secret := map[string]interface{}{"data": map[string]interface{}{"a": Mask, "b": "bmV3", "c": Mask}}
+	policy.Restore(secretSchema, secret, map[string]interface{}{"data": map[string]interface{}{"a": "YQ==", "b": "Yg=="}})
+	assert.Equal(t, map[string]interface{}{"data": map[string]interface{}{"a": "YQ==", "b": "bmV3"}}, secret)
+}
+
+func TestTemplate(t *testing.T) {
+	policy, err := New(DefaultRules)
+	require.NoError(t, err)
+	secretSchema := newSchema("secret", "", "Secret", "secrets")
+	gr := k8sschema.GroupResource{Resource: "secrets"}
+
+	viewer := &user.DefaultInfo{Name: "viewer"}
+	viewerAccess := &accesscontrol.AccessSet{}
+	viewerAccess.Add("get", gr, accesscontrol.Access{Namespace: "default", ResourceName: "*"})
+	admin := &user.DefaultInfo{Name: "admin"}
+	adminAccess := &accesscontrol.AccessSet{}
+	adminAccess.Add("*", gr, accesscontrol.Access{Namespace: "*", ResourceName: "*"})
+
+	ctrl := gomock.NewController(t)
+	asl := acfake.NewMockAccessSetLookup(ctrl)
+	asl.EXPECT().AccessFor(viewer).Return(viewerAccess).AnyTimes()
+	asl.EXPECT().AccessFor(admin).Return(adminAccess).AnyTimes()
+
+	template := Template(policy, asl)
+	for _, test := range []struct {
+		user     user.Info
+		expected interface{}
+	}{
+		{user: viewer, expected: Mask},
+		{user: admin, expected: "YQ=="},
+	} {
+		t.Run(test.user.GetName(), func(t *testing.T) {
+			req := httptest.NewRequest("GET", "/v1/secrets/default/s", nil)
+			apiOp := &types.APIRequest{Request: req.WithContext(request.WithUser(req.Context(), test.user))}
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"namespace": "default", "name": "s"},
+				"data":     map[string]interface{}{"a": "YQ=="},
+			}}
+			resource := &types.RawResource{Schema: secretSchema, APIObject: types.APIObject{Object: obj}}
+			template.Formatter(apiOp, resource)
+			assert.Equal(t, test.expected, obj.Object["data"].(map[string]interface{})["a"])
+		})
+	}
+}
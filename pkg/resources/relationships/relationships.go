@@ -0,0 +1,274 @@
+// Package relationships provides the relationships link of objects, which returns the graph of the objects related to
+// an object, such as its owners, the node and volumes of a pod or the services of an ingress, in a single request.
+//
+// Relationships are those computed by the summary cache and indexed with objects in the SQL cache, which related
+// objects are listed from, one list per type and level of the graph. Relationships to the objects matching a label
+// selector, such as the pods of a service, are returned as edges but not followed.
+package relationships
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/steve/pkg/summarycache"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"github.com/rancher/wrangler/v3/pkg/data/convert"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	linkName   = "relationships"
+	depthParam = "depth"
+	// defaultDepth only returns the objects directly related to the requested object
+	defaultDepth = 1
+	maxDepth     = 3
+	// maxNodes bounds the size of the graph, relationships of further objects aren't followed
+	maxNodes = 500
+)
+
+// Node is an object of the graph
+type Node struct {
+	Type          string `json:"type"`
+	ID            string `json:"id"`
+	State         string `json:"state,omitempty"`
+	Message       string `json:"message,omitempty"`
+	Error         bool   `json:"error,omitempty"`
+	Transitioning bool   `json:"transitioning,omitempty"`
+	// Depth is the number of relationships between the requested object and this one
+	Depth int `json:"depth"`
+	// Missing is true if the object doesn't exist or the user can't list it, in which case its relationships aren't
+	// followed
+	Missing bool `json:"missing,omitempty"`
+}
+
+// Edge is a relationship between two objects of the graph, or between an object and the objects matching a selector
+type Edge struct {
+	FromType string `json:"fromType"`
+	FromID   string `json:"fromId"`
+	ToType   string `json:"toType"`
+	// ToID is empty for relationships to the objects matching Selector in ToNamespace
+	ToID        string `json:"toId,omitempty"`
+	ToNamespace string `json:"toNamespace,omitempty"`
+	Selector    string `json:"selector,omitempty"`
+	Rel         string `json:"rel"`
+}
+
+// Graph is the response of the relationships link
+type Graph struct {
+	Type  string `json:"type"`
+	ID    string `json:"id"`
+	Nodes []Node `json:"nodes"`
+	Edges []Edge `json:"edges"`
+	// Truncated is true if the graph reached maxNodes objects before the requested depth
+	Truncated bool `json:"truncated,omitempty"`
+}
+
+// Template returns a schema template adding the relationships link to objects of all types
+func Template() schema.Template {
+	return schema.Template{
+		Customize: func(apiSchema *types.APISchema) {
+			if attributes.Kind(apiSchema) == "" {
+				return
+			}
+			if apiSchema.LinkHandlers == nil {
+				apiSchema.LinkHandlers = map[string]http.Handler{}
+			}
+			apiSchema.LinkHandlers[linkName] = http.HandlerFunc(serveRelationships)
+		},
+		Formatter: func(request *types.APIRequest, resource *types.RawResource) {
+			resource.Links[linkName] = request.URLBuilder.Link(resource.Schema, resource.ID, linkName)
+		},
+	}
+}
+
+func serveRelationships(rw http.ResponseWriter, req *http.Request) {
+	apiOp := types.GetAPIContext(req.Context())
+	result, err := graph(apiOp)
+	if err != nil {
+		apiOp.WriteError(err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(result); err != nil {
+		logrus.Errorf("failed to write relationships: %v", err)
+	}
+}
+
+func graph(apiOp *types.APIRequest) (*Graph, error) {
+	depth := defaultDepth
+	if value := apiOp.Request.URL.Query().Get(depthParam); value != "" {
+		var err error
+		depth, err = strconv.Atoi(value)
+		if err != nil || depth < 1 || depth > maxDepth {
+			return nil, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("%s must be between 1 and %d", depthParam, maxDepth))
+		}
+	}
+
+	rootID := apiOp.Name
+	if apiOp.Namespace != "" {
+		rootID = apiOp.Namespace + "/" + apiOp.Name
+	}
+	result := &Graph{
+		Type:  linkName,
+		ID:    rootID,
+		Nodes: []Node{},
+		Edges: []Edge{},
+	}
+	seen := map[string]bool{key(apiOp.Schema.ID, rootID): true}
+	edges := map[Edge]bool{}
+	frontier := map[string][]string{apiOp.Schema.ID: {rootID}}
+	for level := 0; level <= depth && len(frontier) > 0; level++ {
+		next := map[string][]string{}
+		for _, schemaID := range sortedKeys(frontier) {
+			ids := frontier[schemaID]
+			objects, err := list(apiOp, schemaID, ids)
+			if err != nil {
+				return nil, err
+			}
+			if level == 0 && len(objects) == 0 {
+				return nil, apierror.NewAPIError(validation.NotFound, fmt.Sprintf("%s %s not found", schemaID, rootID))
+			}
+			for _, id := range ids {
+				obj, ok := objects[id]
+				if !ok {
+					result.Nodes = append(result.Nodes, Node{Type: schemaID, ID: id, Depth: level, Missing: true})
+					continue
+				}
+				result.Nodes = append(result.Nodes, toNode(schemaID, id, level, obj))
+				if level == depth {
+					continue
+				}
+				for _, rel := range relationshipsOf(obj) {
+					edge, related, relatedID := toEdge(schemaID, id, rel)
+					if !edges[edge] {
+						edges[edge] = true
+						result.Edges = append(result.Edges, edge)
+					}
+					if relatedID == "" || seen[key(related, relatedID)] {
+						continue
+					}
+					if len(seen) >= maxNodes {
+						result.Truncated = true
+						continue
+					}
+					seen[key(related, relatedID)] = true
+					next[related] = append(next[related], relatedID)
+				}
+			}
+		}
+		frontier = next
+	}
+	return result, nil
+}
+
+// list returns the objects of a type with the given IDs which the user can list, by ID
+func list(apiOp *types.APIRequest, schemaID string, ids []string) (map[string]data.Object, error) {
+	apiSchema := apiOp.Schemas.LookupSchema(schemaID)
+	if apiSchema == nil || apiSchema.Store == nil {
+		return nil, nil
+	}
+	listOp := apiOp.Clone()
+	listOp.Schema = apiSchema
+	listOp.Type = apiSchema.ID
+	listOp.Namespace = ""
+	listOp.Name = ""
+	listOp.Link = ""
+	listOp.Request = apiOp.Request.Clone(apiOp.Context())
+	listOp.Request.URL.RawQuery = url.Values{
+		"filter": []string{"id in (" + strings.Join(ids, ",") + ")"},
+	}.Encode()
+	list, err := apiSchema.Store.List(listOp, apiSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	wanted := map[string]bool{}
+	for _, id := range ids {
+		wanted[id] = true
+	}
+	result := map[string]data.Object{}
+	for _, obj := range list.Objects {
+		// stores which don't support the filter return more objects than requested
+		if wanted[obj.ID] {
+			result[obj.ID] = obj.Data()
+		}
+	}
+	return result, nil
+}
+
+func toNode(schemaID, id string, depth int, obj data.Object) Node {
+	state := obj.Map("metadata", "state")
+	return Node{
+		Type:          schemaID,
+		ID:            id,
+		State:         state.String("name"),
+		Message:       state.String("message"),
+		Error:         state.Bool("error"),
+		Transitioning: state.Bool("transitioning"),
+		Depth:         depth,
+	}
+}
+
+func relationshipsOf(obj data.Object) []summarycache.Relationship {
+	var result []summarycache.Relationship
+	for _, value := range convert.ToInterfaceSlice(data.GetValueN(obj, "metadata", "relationships")) {
+		var rel summarycache.Relationship
+		if err := convert.ToObj(value, &rel); err != nil {
+			continue
+		}
+		result = append(result, rel)
+	}
+	return result
+}
+
+// toEdge returns the edge of a relationship of an object, with the type and ID of the related object, the ID being
+// empty for relationships to the objects matching a selector
+func toEdge(schemaID, id string, rel summarycache.Relationship) (Edge, string, string) {
+	if rel.FromType != "" {
+		return Edge{
+			FromType: rel.FromType,
+			FromID:   rel.FromID,
+			ToType:   schemaID,
+			ToID:     id,
+			Rel:      rel.Rel,
+		}, rel.FromType, rel.FromID
+	}
+	edge := Edge{
+		FromType:    schemaID,
+		FromID:      id,
+		ToType:      rel.ToType,
+		ToID:        rel.ToID,
+		ToNamespace: rel.ToNamespace,
+		Selector:    rel.Selector,
+		Rel:         rel.Rel,
+	}
+	if rel.Selector != "" {
+		return edge, rel.ToType, ""
+	}
+	return edge, rel.ToType, rel.ToID
+}
+
+func key(schemaID, id string) string {
+	return schemaID + ":" + id
+}
+
+func sortedKeys(m map[string][]string) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
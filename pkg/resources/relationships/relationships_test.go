@@ -0,0 +1,123 @@
+package relationships
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/store/empty"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeStore ignores filters, like stores which don't list from the SQL cache
+type fakeStore struct {
+	empty.Store
+	objects map[string][]types.APIObject
+	lists   int
+}
+
+func (f *fakeStore) List(apiOp *types.APIRequest, schema *types.APISchema) (types.APIObjectList, error) {
+	f.lists++
+	return types.APIObjectList{Objects: f.objects[schema.ID]}, nil
+}
+
+func object(id, state string, rels ...map[string]interface{}) types.APIObject {
+	relationships := make([]interface{}, 0, len(rels))
+	for _, rel := range rels {
+		relationships = append(relationships, rel)
+	}
+	return types.APIObject{
+		ID: id,
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"state":         map[string]interface{}{"name": state},
+				"relationships": relationships,
+			},
+		},
+	}
+}
+
+func TestGraph(t *testing.T) {
+	store := &fakeStore{objects: map[string][]types.APIObject{
+		"pod": {
+			object("default/web-1", "running",
+				map[string]interface{}{"fromType": "apps.replicaset", "fromId": "default/web-abc", "rel": "owner"},
+				map[string]interface{}{"toType": "node", "toId": "node1", "rel": "uses"},
+				map[string]interface{}{"toType": "persistentvolumeclaim", "toId": "default/data", "rel": "uses"},
+			),
+			object("default/other", "running"),
+		},
+		"apps.replicaset": {
+			object("default/web-abc", "active",
+				map[string]interface{}{"fromType": "apps.deployment", "fromId": "default/web", "rel": "owner"},
+				map[string]interface{}{"toType": "pod", "toId": "default/web-1", "rel": "owner"},
+			),
+		},
+		"node": {
+			object("node1", "active"),
+		},
+		"service": {
+			object("default/web", "active",
+				map[string]interface{}{"toType": "pod", "toNamespace": "default", "selector": "app=web", "rel": "selects"},
+			),
+		},
+	}}
+	apiSchemas := types.EmptyAPISchemas()
+	for _, id := range []string{"pod", "apps.replicaset", "node", "service"} {
+		apiSchemas.MustAddSchema(types.APISchema{
+			Schema: &schemas.Schema{ID: id},
+			Store:  store,
+		})
+	}
+	request := func(query, schemaID, namespace, name string) *types.APIRequest {
+		return &types.APIRequest{
+			Request:   httptest.NewRequest("GET", "/v1/"+schemaID+"?"+query, nil),
+			Schemas:   apiSchemas,
+			Schema:    apiSchemas.LookupSchema(schemaID),
+			Namespace: namespace,
+			Name:      name,
+		}
+	}
+
+	result, err := graph(request("link=relationships", "pod", "default", "web-1"))
+	require.NoError(t, err)
+	assert.Equal(t, &Graph{
+		Type: "relationships",
+		ID:   "default/web-1",
+		Nodes: []Node{
+			{Type: "pod", ID: "default/web-1", State: "running"},
+			{Type: "apps.replicaset", ID: "default/web-abc", State: "active", Depth: 1},
+			{Type: "node", ID: "node1", State: "active", Depth: 1},
+			// the user can't list persistent volume claims
+			{Type: "persistentvolumeclaim", ID: "default/data", Depth: 1, Missing: true},
+		},
+		Edges: []Edge{
+			{FromType: "apps.replicaset", FromID: "default/web-abc", ToType: "pod", ToID: "default/web-1", Rel: "owner"},
+			{FromType: "pod", FromID: "default/web-1", ToType: "node", ToID: "node1", Rel: "uses"},
+			{FromType: "pod", FromID: "default/web-1", ToType: "persistentvolumeclaim", ToID: "default/data", Rel: "uses"},
+		},
+	}, result)
+
+	store.lists = 0
+	result, err = graph(request("depth=2", "pod", "default", "web-1"))
+	require.NoError(t, err)
+	// one list per type and level, types without a schema are not listed
+	assert.Equal(t, 3, store.lists)
+	assert.Contains(t, result.Nodes, Node{Type: "apps.deployment", ID: "default/web", Depth: 2, Missing: true})
+	assert.Contains(t, result.Edges, Edge{FromType: "apps.deployment", FromID: "default/web", ToType: "apps.replicaset", ToID: "default/web-abc", Rel: "owner"})
+	// the relationship of the replica set to the pod is the same edge as the one of the pod to its owner
+	assert.Len(t, result.Edges, 4)
+
+	// selectors are returned as edges, without following them
+	result, err = graph(request("", "service", "default", "web"))
+	require.NoError(t, err)
+	assert.Equal(t, []Node{{Type: "service", ID: "default/web", State: "active"}}, result.Nodes)
+	assert.Equal(t, []Edge{{FromType: "service", FromID: "default/web", ToType: "pod", ToNamespace: "default", Selector: "app=web", Rel: "selects"}}, result.Edges)
+
+	_, err = graph(request("", "pod", "default", "missing"))
+	assert.Error(t, err)
+	_, err = graph(request("depth=4", "pod", "default", "web-1"))
+	assert.Error(t, err)
+}
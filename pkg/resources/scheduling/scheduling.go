@@ -0,0 +1,210 @@
+// Package scheduling provides the nodeAdmission link of pods, which lists the nodes a pod's node selector, required
+// node affinity and tolerations admit it to.
+//
+// This is a bounded approximation of the scheduler's predicates, meant to troubleshoot scheduling issues: resource
+// requests, host ports, volumes, pod (anti-)affinity, topology spread constraints and preferred rules are not
+// considered, so a pod admitted to a node may still not fit on it.
+package scheduling
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sort"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	corev1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+const linkName = "nodeAdmission"
+
+// operators maps node selector operators to label selector operators
+var operators = map[corev1.NodeSelectorOperator]selection.Operator{
+	corev1.NodeSelectorOpIn:           selection.In,
+	corev1.NodeSelectorOpNotIn:        selection.NotIn,
+	corev1.NodeSelectorOpExists:       selection.Exists,
+	corev1.NodeSelectorOpDoesNotExist: selection.DoesNotExist,
+	corev1.NodeSelectorOpGt:           selection.GreaterThan,
+	corev1.NodeSelectorOpLt:           selection.LessThan,
+}
+
+// NodeAdmission tells whether a pod is admitted to a node, and why not otherwise
+type NodeAdmission struct {
+	Name     string   `json:"name"`
+	Admitted bool     `json:"admitted"`
+	Reasons  []string `json:"reasons,omitempty"`
+}
+
+// Result is the response of the nodeAdmission link
+type Result struct {
+	Type  string          `json:"type"`
+	ID    string          `json:"id"`
+	Nodes []NodeAdmission `json:"nodes"`
+}
+
+// Template returns a schema template adding the nodeAdmission link to pods
+func Template() schema.Template {
+	return schema.Template{
+		ID: "pod",
+		Customize: func(apiSchema *types.APISchema) {
+			if apiSchema.LinkHandlers == nil {
+				apiSchema.LinkHandlers = map[string]http.Handler{}
+			}
+			apiSchema.LinkHandlers[linkName] = http.HandlerFunc(serveNodeAdmission)
+		},
+		Formatter: func(request *types.APIRequest, resource *types.RawResource) {
+			resource.Links[linkName] = request.URLBuilder.Link(resource.Schema, resource.ID, linkName)
+		},
+	}
+}
+
+func serveNodeAdmission(rw http.ResponseWriter, req *http.Request) {
+	apiOp := types.GetAPIContext(req.Context())
+	result, err := nodeAdmission(apiOp)
+	if err != nil {
+		apiOp.WriteError(err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(result); err != nil {
+		logrus.Errorf("failed to write node admission result: %v", err)
+	}
+}
+
+func nodeAdmission(apiOp *types.APIRequest) (*Result, error) {
+	podObj, err := apiOp.Schema.Store.ByID(apiOp, apiOp.Schema, apiOp.Name)
+	if err != nil {
+		return nil, err
+	}
+	var pod corev1.Pod
+	if err := runtime.DefaultUnstructuredConverter.FromUnstructured(podObj.Data(), &pod); err != nil {
+		return nil, err
+	}
+
+	// nodes are listed as the user, so that only the nodes they can see are evaluated. List query parameters, such as
+	// filters, apply to the nodes
+	nodeSchema := apiOp.Schemas.LookupSchema("node")
+	if nodeSchema == nil || nodeSchema.Store == nil {
+		return nil, apierror.NewAPIError(validation.PermissionDenied, "can not list nodes")
+	}
+	nodeOp := apiOp.Clone()
+	nodeOp.Schema = nodeSchema
+	nodeOp.Type = nodeSchema.ID
+	nodeOp.Namespace = ""
+	nodeOp.Name = ""
+	nodeOp.Link = ""
+	nodeList, err := nodeSchema.Store.List(nodeOp, nodeSchema)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &Result{
+		Type: linkName,
+		ID:   pod.Namespace + "/" + pod.Name,
+	}
+	for _, nodeObj := range nodeList.Objects {
+		var node corev1.Node
+		if err := runtime.DefaultUnstructuredConverter.FromUnstructured(nodeObj.Data(), &node); err != nil {
+			return nil, err
+		}
+		reasons := Admit(&pod, &node)
+		result.Nodes = append(result.Nodes, NodeAdmission{
+			Name:     node.Name,
+			Admitted: len(reasons) == 0,
+			Reasons:  reasons,
+		})
+	}
+	sort.SliceStable(result.Nodes, func(i, j int) bool {
+		if result.Nodes[i].Admitted != result.Nodes[j].Admitted {
+			return result.Nodes[i].Admitted
+		}
+		return result.Nodes[i].Name < result.Nodes[j].Name
+	})
+	return result, nil
+}
+
+// Admit returns the reasons why pod can't be scheduled on node, considering only the node selector, required node
+// affinity, whether the node is cordoned, and NoSchedule and NoExecute taints. The pod is admitted if there are none.
+func Admit(pod *corev1.Pod, node *corev1.Node) []string {
+	var reasons []string
+
+	if !labels.SelectorFromSet(pod.Spec.NodeSelector).Matches(labels.Set(node.Labels)) {
+		reasons = append(reasons, "node selector does not match")
+	}
+	if affinity := pod.Spec.Affinity; affinity != nil && affinity.NodeAffinity != nil &&
+		affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution != nil &&
+		!matchesNodeSelector(affinity.NodeAffinity.RequiredDuringSchedulingIgnoredDuringExecution, node) {
+		reasons = append(reasons, "required node affinity does not match")
+	}
+
+	taints := node.Spec.Taints
+	if node.Spec.Unschedulable {
+		taints = append([]corev1.Taint{{
+			Key:    corev1.TaintNodeUnschedulable,
+			Effect: corev1.TaintEffectNoSchedule,
+		}}, taints...)
+	}
+	for i := range taints {
+		taint := &taints[i]
+		if taint.Effect == corev1.TaintEffectPreferNoSchedule || tolerates(pod.Spec.Tolerations, taint) {
+			continue
+		}
+		reasons = append(reasons, fmt.Sprintf("untolerated taint %s", taint.ToString()))
+	}
+	return reasons
+}
+
+func tolerates(tolerations []corev1.Toleration, taint *corev1.Taint) bool {
+	for i := range tolerations {
+		if tolerations[i].ToleratesTaint(taint) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesNodeSelector returns true if any of the selector's terms matches the node
+func matchesNodeSelector(selector *corev1.NodeSelector, node *corev1.Node) bool {
+	for _, term := range selector.NodeSelectorTerms {
+		if matchesNodeSelectorTerm(term, node) {
+			return true
+		}
+	}
+	return false
+}
+
+// matchesNodeSelectorTerm returns true if all the term's requirements match the node. Like for the scheduler, an
+// empty term matches no node
+func matchesNodeSelectorTerm(term corev1.NodeSelectorTerm, node *corev1.Node) bool {
+	if len(term.MatchExpressions) == 0 && len(term.MatchFields) == 0 {
+		return false
+	}
+	if !matchesRequirements(term.MatchExpressions, labels.Set(node.Labels)) {
+		return false
+	}
+	// metadata.name is the only field supported by the scheduler
+	return matchesRequirements(term.MatchFields, labels.Set{"metadata.name": node.Name})
+}
+
+func matchesRequirements(requirements []corev1.NodeSelectorRequirement, set labels.Set) bool {
+	for _, requirement := range requirements {
+		op, ok := operators[requirement.Operator]
+		if !ok {
+			return false
+		}
+		r, err := labels.NewRequirement(requirement.Key, op, requirement.Values)
+		if err != nil || !r.Matches(set) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,115 @@
+package scheduling
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestAdmit(t *testing.T) {
+	gpuNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "gpu1",
+			Labels: map[string]string{"zone": "a", "gpu": "true"},
+		},
+		Spec: corev1.NodeSpec{
+			Taints: []corev1.Taint{
+				{Key: "gpu", Value: "true", Effect: corev1.TaintEffectNoSchedule},
+				{Key: "spot", Effect: corev1.TaintEffectPreferNoSchedule},
+			},
+		},
+	}
+	cordonedNode := &corev1.Node{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:   "node2",
+			Labels: map[string]string{"zone": "b"},
+		},
+		Spec: corev1.NodeSpec{Unschedulable: true},
+	}
+	affinity := func(terms ...corev1.NodeSelectorTerm) *corev1.Affinity {
+		return &corev1.Affinity{NodeAffinity: &corev1.NodeAffinity{
+			RequiredDuringSchedulingIgnoredDuringExecution: &corev1.NodeSelector{NodeSelectorTerms: terms},
+		}}
+	}
+
+	tests := []struct {
+		name     string
+		spec     corev1.PodSpec
+		node     *corev1.Node
+		expected []string
+	}{
+		{
+			name:     "untolerated taint",
+			node:     gpuNode,
+			expected: []string{"untolerated taint gpu=true:NoSchedule"},
+		},
+		{
+			name: "tolerated taint",
+			spec: corev1.PodSpec{
+				Tolerations: []corev1.Toleration{{Key: "gpu", Operator: corev1.TolerationOpExists}},
+			},
+			node: gpuNode,
+		},
+		{
+			name: "node selector",
+			spec: corev1.PodSpec{
+				NodeSelector: map[string]string{"zone": "b"},
+				Tolerations:  []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			},
+			node:     gpuNode,
+			expected: []string{"node selector does not match"},
+		},
+		{
+			name: "cordoned node",
+			spec: corev1.PodSpec{
+				NodeSelector: map[string]string{"zone": "b"},
+			},
+			node:     cordonedNode,
+			expected: []string{"untolerated taint node.kubernetes.io/unschedulable:NoSchedule"},
+		},
+		{
+			name: "matching node affinity term",
+			spec: corev1.PodSpec{
+				Affinity: affinity(
+					corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+						{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"c"}},
+					}},
+					corev1.NodeSelectorTerm{MatchFields: []corev1.NodeSelectorRequirement{
+						{Key: "metadata.name", Operator: corev1.NodeSelectorOpIn, Values: []string{"gpu1"}},
+					}},
+				),
+				Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			},
+			node: gpuNode,
+		},
+		{
+			name: "node affinity requirements must all match",
+			spec: corev1.PodSpec{
+				Affinity: affinity(corev1.NodeSelectorTerm{MatchExpressions: []corev1.NodeSelectorRequirement{
+					{Key: "zone", Operator: corev1.NodeSelectorOpIn, Values: []string{"a"}},
+					{Key: "gpu", Operator: corev1.NodeSelectorOpDoesNotExist},
+				}}),
+				Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			},
+			node:     gpuNode,
+			expected: []string{"required node affinity does not match"},
+		},
+		{
+			name: "empty node affinity terms match no node",
+			spec: corev1.PodSpec{
+				Affinity:    affinity(corev1.NodeSelectorTerm{}),
+				Tolerations: []corev1.Toleration{{Operator: corev1.TolerationOpExists}},
+			},
+			node:     gpuNode,
+			expected: []string{"required node affinity does not match"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			pod := &corev1.Pod{Spec: test.spec}
+			assert.Equal(t, test.expected, Admit(pod, test.node))
+		})
+	}
+}
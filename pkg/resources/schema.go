@@ -4,17 +4,22 @@ import (
 	"context"
 
 	"github.com/rancher/apiserver/pkg/store/apiroot"
-	"github.com/rancher/apiserver/pkg/subscribe"
 	"github.com/rancher/apiserver/pkg/types"
 	"github.com/rancher/steve/pkg/accesscontrol"
 	"github.com/rancher/steve/pkg/client"
 	"github.com/rancher/steve/pkg/clustercache"
+	"github.com/rancher/steve/pkg/metrics"
 	"github.com/rancher/steve/pkg/resources/apigroups"
 	"github.com/rancher/steve/pkg/resources/cluster"
 	"github.com/rancher/steve/pkg/resources/common"
 	"github.com/rancher/steve/pkg/resources/counts"
 	"github.com/rancher/steve/pkg/resources/formatters"
+	"github.com/rancher/steve/pkg/resources/ownership"
+	"github.com/rancher/steve/pkg/resources/querystats"
+	"github.com/rancher/steve/pkg/resources/scheduling"
+	"github.com/rancher/steve/pkg/resources/subscribe"
 	"github.com/rancher/steve/pkg/resources/userpreferences"
+	"github.com/rancher/steve/pkg/resources/watchstats"
 	"github.com/rancher/steve/pkg/schema"
 	"github.com/rancher/steve/pkg/stores/proxy"
 	"github.com/rancher/steve/pkg/summarycache"
@@ -24,8 +29,11 @@ import (
 )
 
 func DefaultSchemas(ctx context.Context, baseSchema *types.APISchemas, ccache clustercache.ClusterCache,
-	cg proxy.ClientGetter, schemaFactory schema.Factory, serverVersion string) error {
-	counts.Register(baseSchema, ccache)
+	cg proxy.ClientGetter, schemaFactory schema.Factory, serverVersion string, summarizer summarycache.Summarizer,
+	subscribeOptions subscribe.Options, apiVersions []string) error {
+	counts.Register(baseSchema, ccache, summarizer)
+	watchstats.Register(baseSchema, metrics.Watches)
+	querystats.Register(baseSchema, metrics.Requests)
 	subscribe.Register(baseSchema, func(apiOp *types.APIRequest) *types.APISchemas {
 		user, ok := request.UserFrom(apiOp.Context())
 		if ok {
@@ -35,8 +43,8 @@ func DefaultSchemas(ctx context.Context, baseSchema *types.APISchemas, ccache cl
 			}
 		}
 		return apiOp.Schemas
-	}, serverVersion)
-	apiroot.Register(baseSchema, []string{"v1"}, "proxy:/apis")
+	}, serverVersion, subscribeOptions)
+	apiroot.Register(baseSchema, append([]string{"v1"}, apiVersions...), "proxy:/apis")
 	cluster.Register(ctx, baseSchema, cg, schemaFactory)
 	userpreferences.Register(baseSchema)
 	return nil
@@ -47,9 +55,10 @@ func DefaultSchemaTemplates(cf *client.Factory,
 	summaryCache *summarycache.SummaryCache,
 	lookup accesscontrol.AccessSetLookup,
 	discovery discovery.DiscoveryInterface,
-	namespaceCache corecontrollers.NamespaceCache) []schema.Template {
+	namespaceCache corecontrollers.NamespaceCache,
+	fieldManager string) []schema.Template {
 	return []schema.Template{
-		common.DefaultTemplate(cf, summaryCache, lookup, namespaceCache),
+		common.DefaultTemplate(cf, summaryCache, lookup, namespaceCache, fieldManager),
 		apigroups.Template(discovery),
 		{
 			ID:        "configmap",
@@ -63,6 +72,8 @@ func DefaultSchemaTemplates(cf *client.Factory,
 			ID:        "pod",
 			Formatter: formatters.Pod,
 		},
+		scheduling.Template(),
+		ownership.Template(),
 		{
 			ID: "management.cattle.io.cluster",
 			Customize: func(apiSchema *types.APISchema) {
@@ -94,6 +105,8 @@ func DefaultSchemaTemplatesForStore(store types.Store,
 			ID:        "pod",
 			Formatter: formatters.Pod,
 		},
+		scheduling.Template(),
+		ownership.Template(),
 		{
 			ID: "management.cattle.io.cluster",
 			Customize: func(apiSchema *types.APISchema) {
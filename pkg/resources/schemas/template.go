@@ -8,11 +8,13 @@ import (
 	"time"
 
 	"github.com/rancher/apiserver/pkg/builtin"
+	"github.com/rancher/apiserver/pkg/store/empty"
 	schemastore "github.com/rancher/apiserver/pkg/store/schema"
 	"github.com/rancher/apiserver/pkg/types"
 	"github.com/rancher/steve/pkg/accesscontrol"
 	"github.com/rancher/steve/pkg/schema"
 	"github.com/rancher/wrangler/v3/pkg/broadcast"
+	wschemas "github.com/rancher/wrangler/v3/pkg/schemas"
 	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/api/equality"
@@ -26,14 +28,76 @@ func SetupWatcher(ctx context.Context, schemas *types.APISchemas, asl accesscont
 	notifier := schemaChangeNotifier(ctx, factory)
 
 	schema := builtin.Schema
-	schema.Store = &Store{
+	store := &Store{
 		Store:              schema.Store,
 		asl:                asl,
 		sf:                 factory,
 		schemaChangeNotify: notifier,
 	}
+	schema.Store = store
 
 	schemas.AddSchema(schema)
+	schemas.MustAddSchema(types.APISchema{
+		Schema: &wschemas.Schema{
+			ID:                ChangeResourceType,
+			PluralName:        "schemaChanges",
+			CollectionMethods: []string{"GET"},
+		},
+		Store: &ChangeStore{store: store},
+	})
+}
+
+// ChangeResourceType is the pseudo-resource whose watch notifies the schema changes, for UIs to refresh their
+// navigation without watching the schemas themselves
+const ChangeResourceType = "schemaChange"
+
+// SchemaChange is the object of the events of the schemaChange pseudo-resource, the IDs of the schemas of the user
+// which were added, removed or changed, such as when CRDs are installed or uninstalled
+type SchemaChange struct {
+	Added   []string `json:"added,omitempty"`
+	Removed []string `json:"removed,omitempty"`
+	Changed []string `json:"changed,omitempty"`
+}
+
+// ChangeStore is the store of the schemaChange pseudo-resource, which can only be watched
+type ChangeStore struct {
+	empty.Store
+
+	store *Store
+}
+
+// List returns no object, schema changes are only notified to watches
+func (c *ChangeStore) List(_ *types.APIRequest, _ *types.APISchema) (types.APIObjectList, error) {
+	return types.APIObjectList{}, nil
+}
+
+// Watch returns a channel sending a change event summarizing each change of the schemas of the user of the request,
+// until Done is closed on the context of the request.
+func (c *ChangeStore) Watch(apiOp *types.APIRequest, _ *types.APISchema, _ types.WatchRequest) (chan types.APIEvent, error) {
+	return c.store.watch(apiOp, func(result chan types.APIEvent, events []types.APIEvent) {
+		if len(events) == 0 {
+			return
+		}
+		change := SchemaChange{}
+		for _, event := range events {
+			switch event.Name {
+			case types.CreateAPIEvent:
+				change.Added = append(change.Added, event.Object.ID)
+			case types.RemoveAPIEvent:
+				change.Removed = append(change.Removed, event.Object.ID)
+			default:
+				change.Changed = append(change.Changed, event.Object.ID)
+			}
+		}
+		result <- types.APIEvent{
+			Name:         types.ChangeAPIEvent,
+			ResourceType: ChangeResourceType,
+			Object: types.APIObject{
+				Type:   ChangeResourceType,
+				Object: change,
+			},
+		}
+	})
 }
 
 // Store hold information for watching updates to schemas
@@ -48,6 +112,16 @@ type Store struct {
 // Watch will return a APIevent channel that tracks changes to schemas for a user in a given APIRequest.
 // Changes will be returned until Done is closed on the context in the given APIRequest.
 func (s *Store) Watch(apiOp *types.APIRequest, _ *types.APISchema, _ types.WatchRequest) (chan types.APIEvent, error) {
+	return s.watch(apiOp, func(result chan types.APIEvent, events []types.APIEvent) {
+		for _, event := range events {
+			result <- event
+		}
+	})
+}
+
+// watch calls send with the events of the schemas of the user of apiOp which changed, on each change of the schemas or
+// of the access of the user, until Done is closed on the context of apiOp.
+func (s *Store) watch(apiOp *types.APIRequest, send func(result chan types.APIEvent, events []types.APIEvent)) (chan types.APIEvent, error) {
 	user, ok := request.UserFrom(apiOp.Request.Context())
 	if !ok {
 		return nil, validation.Unauthorized
@@ -94,22 +168,23 @@ func (s *Store) Watch(apiOp *types.APIRequest, _ *types.APISchema, _ types.Watch
 					return
 				}
 			}
-			schemas = s.sendSchemas(result, apiOp, user, schemas)
+			newSchemas, err := s.sf.Schemas(user)
+			if err != nil {
+				logrus.Errorf("failed to get schemas for %v: %v", user, err)
+				continue
+			}
+			send(result, schemaEvents(apiOp, schemas, newSchemas))
+			schemas = newSchemas
 		}
 	}()
 
 	return result, nil
 }
 
-// sendSchemas will send APIEvents onto the provided result channel based on detected changes in the schemas for the provided users.
-func (s *Store) sendSchemas(result chan types.APIEvent, apiOp *types.APIRequest, user user.Info, oldSchemas *types.APISchemas) *types.APISchemas {
-	// get the current schemas for a user
-	schemas, err := s.sf.Schemas(user)
-	if err != nil {
-		logrus.Errorf("failed to get schemas for %v: %v", user, err)
-		return oldSchemas
-	}
-
+// schemaEvents returns the APIEvents of the schemas which were created, changed or removed between oldSchemas and
+// schemas.
+func schemaEvents(apiOp *types.APIRequest, oldSchemas, schemas *types.APISchemas) []types.APIEvent {
+	var events []types.APIEvent
 	inNewSchemas := map[string]bool{}
 
 	// Convert the schemas for the given user to a flat list of APIObjects.
@@ -135,12 +210,12 @@ func (s *Store) sendSchemas(result chan types.APIEvent, apiOp *types.APIRequest,
 			}
 		}
 
-		// Send the new or modified schema as an APIObject on the APIEvent channel.
-		result <- types.APIEvent{
+		// The new or modified schema is sent as an APIObject.
+		events = append(events, types.APIEvent{
 			Name:         eventName,
 			ResourceType: "schema",
 			Object:       apiObject,
-		}
+		})
 	}
 
 	// Identify all of the oldSchema APIObjects that have been removed and send Remove APIEvents.
@@ -150,14 +225,14 @@ func (s *Store) sendSchemas(result chan types.APIEvent, apiOp *types.APIRequest,
 		if inNewSchemas[oldSchema.ID] {
 			continue
 		}
-		result <- types.APIEvent{
+		events = append(events, types.APIEvent{
 			Name:         types.RemoveAPIEvent,
 			ResourceType: "schema",
 			Object:       oldSchema,
-		}
+		})
 	}
 
-	return schemas
+	return events
 }
 
 // userChangeNotify gets the provided users AccessSet every 2 seconds.
@@ -489,3 +489,84 @@ func hasExpectedResults(t *testing.T, expectedEvents []types.APIEvent, resultCha
 		numEventsSent++
 	}
 }
+
+func Test_SchemaChangeWatch(t *testing.T) {
+	t.Parallel()
+	ctrl := gomock.NewController(t)
+	asl := acfake.NewMockAccessSetLookup(ctrl)
+	userInfo := &user.DefaultInfo{Name: "test", UID: "test"}
+	asl.EXPECT().AccessFor(userInfo).Return(&accesscontrol.AccessSet{}).AnyTimes()
+
+	schemaFor := func(id string, methods ...string) types.APISchema {
+		return types.APISchema{
+			Schema: &v1schema.Schema{
+				ID:                id,
+				PluralName:        id + "s",
+				CollectionMethods: []string{"GET"},
+				ResourceMethods:   append([]string{"GET"}, methods...),
+			},
+		}
+	}
+	factory := schemafake.NewMockFactory(ctrl)
+	initialSchemas := types.EmptyAPISchemas()
+	initialSchemas.MustAddSchema(schemaFor("pod"))
+	initialSchemas.MustAddSchema(schemaFor("node"))
+	initialSchemas.MustAddSchema(schemaFor("secret"))
+	updatedSchemas := types.EmptyAPISchemas()
+	updatedSchemas.MustAddSchema(schemaFor("pod"))
+	updatedSchemas.MustAddSchema(schemaFor("node", "PUT"))
+	updatedSchemas.MustAddSchema(schemaFor("cluster"))
+	gomock.InOrder(
+		factory.EXPECT().Schemas(userInfo).Return(initialSchemas, nil),
+		factory.EXPECT().Schemas(userInfo).Return(updatedSchemas, nil),
+		factory.EXPECT().Schemas(userInfo).Return(updatedSchemas, nil).AnyTimes(),
+	)
+
+	testCtx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var onChangeCB func()
+	factory.EXPECT().OnChange(gomock.AssignableToTypeOf(testCtx), gomock.AssignableToTypeOf(onChangeCB)).
+		Do(func(_ context.Context, cb func()) {
+			onChangeCB = cb
+		})
+
+	watcherSchemas := types.EmptyAPISchemas()
+	schemas.SetupWatcher(testCtx, watcherSchemas, asl, factory)
+	schema := watcherSchemas.LookupSchema(schemas.ChangeResourceType)
+	assert.NotNil(t, schema)
+
+	list, err := schema.Store.List(nil, schema)
+	assert.NoError(t, err)
+	assert.Empty(t, list.Objects)
+
+	apiOp := &types.APIRequest{
+		Request: httptest.NewRequest("GET", "/", nil).WithContext(request.WithUser(testCtx, userInfo)),
+	}
+	resultChan, err := schema.Store.Watch(apiOp, schema, types.WatchRequest{})
+	assert.NoError(t, err, "Unexpected error starting Watch")
+
+	// wait for the store's go routines to start watching for onChange events
+	time.Sleep(setupTimeout)
+	onChangeCB()
+
+	select {
+	case event := <-resultChan:
+		assert.Equal(t, types.ChangeAPIEvent, event.Name)
+		assert.Equal(t, schemas.ChangeResourceType, event.ResourceType)
+		assert.Equal(t, schemas.SchemaChange{
+			Added:   []string{"cluster"},
+			Removed: []string{"secret"},
+			Changed: []string{"node"},
+		}, event.Object.Object)
+	case <-time.After(time.Second):
+		assert.Fail(t, "timeout waiting for the schema change")
+	}
+
+	// no event is sent if the schemas didn't change
+	onChangeCB()
+	select {
+	case event := <-resultChan:
+		assert.Failf(t, "unexpected event", "%+v", event)
+	case <-time.After(setupTimeout):
+	}
+}
@@ -0,0 +1,119 @@
+package subscribe
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/metrics"
+)
+
+// defaultQueueSize is how many events are queued for a websocket if the size of its queue isn't set
+const defaultQueueSize = 1000
+
+// tooOldMessage is the error sent to the watches of a websocket which couldn't keep up with their events, which
+// clients handle as they handle watches expiring, by listing their objects again
+const tooOldMessage = "too old resource version: the client didn't keep up with the events of its watches, relist"
+
+// eventQueue queues the events of the watches of a websocket while they are written to it, up to a size. The events
+// are then dropped, and the websocket is reset.
+//
+// The events are read from the watches as soon as they are sent, so that a websocket slow to write to doesn't block
+// them, or make them stop silently, as the watches of the apiserver do once its buffer is full.
+type eventQueue struct {
+	size int
+	// notify is signaled whenever events are pushed, the queue overflows, or is closed
+	notify chan struct{}
+
+	lock       sync.Mutex
+	events     []types.APIEvent
+	overflowed bool
+	closed     bool
+}
+
+func newEventQueue(size int) *eventQueue {
+	if size <= 0 {
+		size = defaultQueueSize
+	}
+	return &eventQueue{
+		size:   size,
+		notify: make(chan struct{}, 1),
+	}
+}
+
+// consume queues the events until they are closed, then closes the queue
+func (q *eventQueue) consume(events <-chan types.APIEvent) {
+	for event := range events {
+		q.push(event)
+	}
+	q.close()
+}
+
+// push queues event, dropping it and all the queued events if the queue is full
+func (q *eventQueue) push(event types.APIEvent) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	if q.overflowed {
+		return
+	}
+	if len(q.events) >= q.size {
+		q.overflowed = true
+		q.events = nil
+	} else {
+		q.events = append(q.events, event)
+	}
+	q.signal()
+}
+
+func (q *eventQueue) close() {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	q.closed = true
+	q.signal()
+}
+
+func (q *eventQueue) signal() {
+	select {
+	case q.notify <- struct{}{}:
+	default:
+	}
+}
+
+// pop returns the queued events, and whether the queue overflowed or was closed
+func (q *eventQueue) pop() (events []types.APIEvent, overflowed, closed bool) {
+	q.lock.Lock()
+	defer q.lock.Unlock()
+	events = q.events
+	q.events = nil
+	return events, q.overflowed, q.closed
+}
+
+// closeTooOld resets the websocket of a client which didn't keep up with its events, sending each of its watches a
+// resource.error event saying its resource version is too old, so that the client lists its objects again, then
+// closing the websocket so that it subscribes again
+func closeTooOld(c *websocket.Conn, sent watches, minCompressSize int) error {
+	metrics.RecordSubscribeDropped()
+	keys := make([]string, 0, len(sent))
+	for key := range sent {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	for _, key := range keys {
+		watch := sent[key]
+		if err := writeEvent(c, types.APIEvent{
+			Name:         "resource.error",
+			ResourceType: watch.ResourceType,
+			Namespace:    watch.Namespace,
+			ID:           watch.ID,
+			Selector:     watch.Selector,
+			Revision:     watch.ResourceVersion,
+			Data:         map[string]interface{}{"error": tooOldMessage},
+		}, minCompressSize); err != nil {
+			return err
+		}
+	}
+	return c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseTryAgainLater, "too old"),
+		time.Now().Add(closeTimeout))
+}
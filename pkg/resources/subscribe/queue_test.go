@@ -0,0 +1,133 @@
+package subscribe
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventQueue(t *testing.T) {
+	tests := []struct {
+		name           string
+		size           int
+		pushed         int
+		close          bool
+		wantEvents     int
+		wantOverflowed bool
+	}{
+		{
+			name:       "events within the size are queued",
+			size:       3,
+			pushed:     3,
+			wantEvents: 3,
+		},
+		{
+			name:           "events beyond the size overflow the queue",
+			size:           3,
+			pushed:         4,
+			wantOverflowed: true,
+		},
+		{
+			name:       "the size defaults to 1000",
+			pushed:     1000,
+			wantEvents: 1000,
+		},
+		{
+			name:       "closed queues return their last events",
+			size:       3,
+			pushed:     2,
+			close:      true,
+			wantEvents: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := newEventQueue(test.size)
+			events := make(chan types.APIEvent, test.pushed)
+			for i := 0; i < test.pushed; i++ {
+				events <- types.APIEvent{Name: "resource.change"}
+			}
+			if test.close {
+				close(events)
+				q.consume(events)
+			} else {
+				for i := 0; i < test.pushed; i++ {
+					q.push(<-events)
+				}
+			}
+
+			<-q.notify
+			popped, overflowed, closed := q.pop()
+			assert.Len(t, popped, test.wantEvents)
+			assert.Equal(t, test.wantOverflowed, overflowed)
+			assert.Equal(t, test.close, closed)
+
+			popped, _, _ = q.pop()
+			assert.Empty(t, popped, "events are popped once")
+		})
+	}
+}
+
+func TestCloseTooOld(t *testing.T) {
+	sent := watches{}
+	for _, event := range []types.APIEvent{
+		{Name: "resource.start", ResourceType: "pod", Namespace: "default"},
+		{Name: "resource.change", ResourceType: "pod", Revision: "42"},
+		{Name: "resource.start", ResourceType: "node", ID: "worker"},
+	} {
+		sent.update(event)
+	}
+	server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		c, err := upgrader.Upgrade(rw, req, nil)
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		_ = closeTooOld(c, sent, 1024)
+	}))
+	defer server.Close()
+
+	conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+	require.NoError(t, err)
+	defer conn.Close()
+
+	type errorEvent struct {
+		Name         string `json:"name"`
+		ResourceType string `json:"resourceType"`
+		Namespace    string `json:"namespace"`
+		ID           string `json:"id"`
+		Revision     string `json:"revision"`
+		Data         struct {
+			Error string `json:"error"`
+		} `json:"data"`
+	}
+	var got []errorEvent
+	for i := 0; i < 2; i++ {
+		_, data, err := conn.ReadMessage()
+		require.NoError(t, err)
+		var event errorEvent
+		require.NoError(t, json.Unmarshal(data, &event))
+		got = append(got, event)
+	}
+	for _, event := range got {
+		assert.Equal(t, "resource.error", event.Name)
+		assert.True(t, strings.HasPrefix(event.Data.Error, "too old resource version"), event.Data.Error)
+	}
+	assert.Equal(t, "node", got[0].ResourceType)
+	assert.Equal(t, "worker", got[0].ID)
+	assert.Equal(t, "pod", got[1].ResourceType)
+	assert.Equal(t, "default", got[1].Namespace)
+	assert.Equal(t, "42", got[1].Revision)
+
+	_, _, err = conn.ReadMessage()
+	var closeErr *websocket.CloseError
+	require.ErrorAs(t, err, &closeErr)
+	assert.Equal(t, websocket.CloseTryAgainLater, closeErr.Code)
+}
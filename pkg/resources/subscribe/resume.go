@@ -0,0 +1,82 @@
+package subscribe
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"sort"
+
+	"github.com/rancher/apiserver/pkg/subscribe"
+	"github.com/rancher/apiserver/pkg/types"
+	"k8s.io/apimachinery/pkg/api/meta"
+)
+
+// maxCloseReason is the size limit of the reason of a websocket close frame, whose payload is limited to 125 bytes
+// including the close code
+const maxCloseReason = 123
+
+// watches tracks the watches of a websocket and the last revision each of them sent, to let clients resume them on
+// another server
+type watches map[string]subscribe.Subscribe
+
+// watchKey identifies the watch an event belongs to. The events of the watches don't carry the namespace they were
+// subscribed to, so it isn't part of the key.
+func watchKey(event types.APIEvent) string {
+	return event.ResourceType + "/" + event.ID + "/" + event.Selector
+}
+
+// update records the watches started and stopped by event, and the revision of the object it sent
+func (w watches) update(event types.APIEvent) {
+	switch event.Name {
+	case "resource.start":
+		w[watchKey(event)] = subscribe.Subscribe{
+			ResourceType: event.ResourceType,
+			Namespace:    event.Namespace,
+			ID:           event.ID,
+			Selector:     event.Selector,
+		}
+	case "resource.stop":
+		delete(w, watchKey(event))
+	default:
+		watch, ok := w[watchKey(event)]
+		if !ok {
+			return
+		}
+		revision := event.Revision
+		if obj, err := meta.Accessor(event.Object.Object); err == nil && obj.GetResourceVersion() != "" {
+			revision = obj.GetResourceVersion()
+		}
+		if revision != "" {
+			watch.ResourceVersion = revision
+			w[watchKey(event)] = watch
+		}
+	}
+}
+
+// resumeToken encodes the watches as the subscribe messages restarting them from the last revision they sent, in a
+// JSON list encoded with unpadded base64url
+func (w watches) resumeToken() (string, error) {
+	keys := make([]string, 0, len(w))
+	for key := range w {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	subs := make([]subscribe.Subscribe, 0, len(keys))
+	for _, key := range keys {
+		subs = append(subs, w[key])
+	}
+	data, err := json.Marshal(subs)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// ParseResumeToken decodes a resume token into the subscribe messages restarting the watches it carries
+func ParseResumeToken(token string) ([]subscribe.Subscribe, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	var subs []subscribe.Subscribe
+	return subs, json.Unmarshal(data, &subs)
+}
@@ -0,0 +1,174 @@
+// Package subscribe provides the subscribe schema, whose websocket streams the events of the watches clients subscribe
+// to, as the subscribe package of the apiserver does, but only compressing the messages large enough to benefit from
+// it.
+package subscribe
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/gorilla/websocket"
+	"github.com/rancher/apiserver/pkg/subscribe"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/drain"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+)
+
+var upgrader = websocket.Upgrader{
+	HandshakeTimeout: 60 * time.Second,
+	// permessage-deflate is negotiated with the clients supporting it, messages are then compressed by size
+	EnableCompression: true,
+}
+
+// pingInterval is how often pings are sent on idle and busy websockets alike
+var pingInterval = 30 * time.Second
+
+// closeTimeout is how long sending the close frame may take
+const closeTimeout = 5 * time.Second
+
+// Options are the options of the websockets of the subscribe schema
+type Options struct {
+	// MinCompressSize is the size in bytes from which messages are compressed if the client negotiated
+	// permessage-deflate, all of them being compressed if it is zero
+	MinCompressSize int
+	// CoalesceWindow is how long the changes of an object are held, only the latest of them being sent, changes not
+	// being coalesced if it is zero
+	CoalesceWindow time.Duration
+	// MaxEventsPerSecond is how many events of objects a websocket is sent per second at most, the watches whose
+	// events are dropped beyond it being sent a resource.resync event, events not being limited if it is zero
+	MaxEventsPerSecond int
+	// QueueSize is how many events are queued for a websocket while they are written to it, 1000 if it is zero. The
+	// websocket is reset once it is exceeded
+	QueueSize int
+}
+
+// Register registers the subscribe schema
+func Register(schemas *types.APISchemas, getter subscribe.SchemasGetter, serverVersion string, opts Options) {
+	if getter == nil {
+		getter = subscribe.DefaultGetter
+	}
+	subscribe.Register(schemas, getter, serverVersion)
+	schemas.LookupSchema("subscribe").ListHandler = func(apiOp *types.APIRequest) (types.APIObjectList, error) {
+		if err := handler(apiOp, getter, serverVersion, opts); err != nil {
+			logrus.Errorf("Error during subscribe %v", err)
+		}
+		return types.APIObjectList{}, validation.ErrComplete
+	}
+}
+
+func handler(apiOp *types.APIRequest, getter subscribe.SchemasGetter, serverVersion string, opts Options) error {
+	c, err := upgrader.Upgrade(apiOp.Response, apiOp.Request, nil)
+	if err != nil {
+		return err
+	}
+	defer c.Close()
+
+	session := subscribe.NewWatchSession(apiOp, getter)
+	defer session.Close()
+
+	queue := newEventQueue(opts.QueueSize)
+	// the queue consumes the events until the session closes them
+	go queue.consume(session.Watch(c))
+	resumable := watches{}
+	t := time.NewTicker(pingInterval)
+	defer t.Stop()
+	minCompressSize := opts.MinCompressSize
+	throttle := newThrottle(opts, resumable)
+	var throttleTicks <-chan time.Time
+	if throttle.enabled() {
+		throttleTicker := time.NewTicker(throttle.interval())
+		defer throttleTicker.Stop()
+		throttleTicks = throttleTicker.C
+	}
+	send := func(events []types.APIEvent) error {
+		for _, event := range events {
+			resumable.update(event)
+			if err := writeData(apiOp, getter, c, event, minCompressSize); err != nil {
+				return err
+			}
+		}
+		return nil
+	}
+
+	for {
+		select {
+		case <-queue.notify:
+			events, overflowed, closed := queue.pop()
+			if overflowed {
+				return closeTooOld(c, resumable, minCompressSize)
+			}
+			for _, event := range events {
+				if throttle.enabled() {
+					err = send(throttle.add(event))
+				} else {
+					err = send([]types.APIEvent{event})
+				}
+				if err != nil {
+					return err
+				}
+			}
+			if closed {
+				return nil
+			}
+		case <-throttleTicks:
+			if err := send(throttle.tick()); err != nil {
+				return err
+			}
+		case <-t.C:
+			if err := writeData(apiOp, getter, c, types.APIEvent{
+				Name: "ping",
+				Object: types.APIObject{
+					Object: map[string]interface{}{"version": serverVersion},
+				},
+			}, minCompressSize); err != nil {
+				return err
+			}
+		case <-drain.Draining(apiOp.Context()):
+			return closeResumable(c, resumable, minCompressSize)
+		}
+	}
+}
+
+// closeResumable closes the websocket of a server shutting down, sending the resume token of its watches in a
+// resource.resume event, then in the close frame if it fits, so that the client can restart them on another server
+// from where they stopped
+func closeResumable(c *websocket.Conn, resumable watches, minCompressSize int) error {
+	token, err := resumable.resumeToken()
+	if err != nil {
+		return err
+	}
+	if err := writeEvent(c, types.APIEvent{
+		Name: "resource.resume",
+		Data: map[string]interface{}{"token": token},
+	}, minCompressSize); err != nil {
+		return err
+	}
+	reason := token
+	if len(reason) > maxCloseReason {
+		reason = ""
+	}
+	return c.WriteControl(websocket.CloseMessage, websocket.FormatCloseMessage(websocket.CloseGoingAway, reason),
+		time.Now().Add(closeTimeout))
+}
+
+func writeData(apiOp *types.APIRequest, getter subscribe.SchemasGetter, c *websocket.Conn, event types.APIEvent, minCompressSize int) error {
+	event = subscribe.MarshallObject(apiOp, getter, event)
+	if event.Error != nil {
+		event.Name = "resource.error"
+		event.Data = map[string]interface{}{
+			"error": event.Error.Error(),
+		}
+	}
+	return writeEvent(c, event, minCompressSize)
+}
+
+// writeEvent sends event as it is, compressed if it is large enough
+func writeEvent(c *websocket.Conn, event types.APIEvent, minCompressSize int) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+	c.EnableWriteCompression(len(data) >= minCompressSize)
+	return c.WriteMessage(websocket.TextMessage, append(data, '\n'))
+}
@@ -0,0 +1,164 @@
+package subscribe
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/gorilla/websocket"
+	"github.com/rancher/apiserver/pkg/subscribe"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// countingConn counts the bytes read from a connection
+type countingConn struct {
+	net.Conn
+	read *atomic.Int64
+}
+
+func (c countingConn) Read(b []byte) (int, error) {
+	n, err := c.Conn.Read(b)
+	c.read.Add(int64(n))
+	return n, err
+}
+
+func TestWriteData(t *testing.T) {
+	// errors are sent as they are, large and compressible
+	message := strings.Repeat("the watch failed ", 1000)
+	tests := []struct {
+		name            string
+		minCompressSize int
+		compressed      bool
+	}{
+		{name: "messages above the minimum size are compressed", minCompressSize: 1024, compressed: true},
+		{name: "smaller messages aren't compressed", minCompressSize: 1 << 20},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				c, err := upgrader.Upgrade(rw, req, nil)
+				if err != nil {
+					return
+				}
+				defer c.Close()
+				apiOp := &types.APIRequest{Request: req, Response: rw, Schemas: types.EmptyAPISchemas()}
+				_ = writeData(apiOp, subscribe.DefaultGetter, c, types.APIEvent{Name: "resource.change", Error: errors.New(message)}, test.minCompressSize)
+			}))
+			defer server.Close()
+
+			read := &atomic.Int64{}
+			dialer := websocket.Dialer{
+				EnableCompression: true,
+				NetDialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+					conn, err := (&net.Dialer{}).DialContext(ctx, network, addr)
+					return countingConn{Conn: conn, read: read}, err
+				},
+			}
+			conn, _, err := dialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			_, data, err := conn.ReadMessage()
+			require.NoError(t, err)
+			event := map[string]interface{}{}
+			require.NoError(t, json.Unmarshal(data, &event))
+			assert.Equal(t, "resource.error", event["name"])
+			assert.True(t, strings.Contains(string(data), message), "the message is sent whole")
+			if test.compressed {
+				assert.Less(t, read.Load(), int64(len(data)/4))
+			} else {
+				assert.Greater(t, read.Load(), int64(len(data)))
+			}
+		})
+	}
+}
+
+func TestCloseResumable(t *testing.T) {
+	podEvents := []types.APIEvent{
+		{Name: "resource.start", ResourceType: "pod", Namespace: "default"},
+		{Name: "resource.change", ResourceType: "pod", Object: types.APIObject{Object: &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": "web", "resourceVersion": "42"},
+		}}}},
+		{Name: "resource.start", ResourceType: "secret"},
+		{Name: "resource.stop", ResourceType: "secret"},
+	}
+	nodeEvents := []types.APIEvent{
+		{Name: "resource.start", ResourceType: "node", ID: "worker"},
+		{Name: "resource.change", ResourceType: "node", ID: "worker", Revision: "7"},
+	}
+	tests := []struct {
+		name       string
+		events     []types.APIEvent
+		want       []subscribe.Subscribe
+		wantReason bool
+	}{
+		{
+			name:       "token in the close frame",
+			events:     podEvents,
+			want:       []subscribe.Subscribe{{ResourceType: "pod", Namespace: "default", ResourceVersion: "42"}},
+			wantReason: true,
+		},
+		{
+			name:   "token too long for the close frame",
+			events: append(podEvents, nodeEvents...),
+			want: []subscribe.Subscribe{
+				{ResourceType: "node", ID: "worker", ResourceVersion: "7"},
+				{ResourceType: "pod", Namespace: "default", ResourceVersion: "42"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			resumable := watches{}
+			for _, event := range test.events {
+				resumable.update(event)
+			}
+			server := httptest.NewServer(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+				c, err := upgrader.Upgrade(rw, req, nil)
+				if err != nil {
+					return
+				}
+				defer c.Close()
+				_ = closeResumable(c, resumable, 1024)
+			}))
+			defer server.Close()
+
+			conn, _, err := websocket.DefaultDialer.Dial("ws"+strings.TrimPrefix(server.URL, "http"), nil)
+			require.NoError(t, err)
+			defer conn.Close()
+
+			_, data, err := conn.ReadMessage()
+			require.NoError(t, err)
+			var event struct {
+				Name string
+				Data struct {
+					Token string
+				}
+			}
+			require.NoError(t, json.Unmarshal(data, &event))
+			assert.Equal(t, "resource.resume", event.Name)
+			subs, err := ParseResumeToken(event.Data.Token)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, subs)
+
+			_, _, err = conn.ReadMessage()
+			var closeErr *websocket.CloseError
+			require.ErrorAs(t, err, &closeErr)
+			assert.Equal(t, websocket.CloseGoingAway, closeErr.Code)
+			if test.wantReason {
+				assert.Equal(t, event.Data.Token, closeErr.Text)
+			} else {
+				assert.Empty(t, closeErr.Text)
+			}
+		})
+	}
+}
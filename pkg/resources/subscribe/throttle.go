@@ -0,0 +1,183 @@
+package subscribe
+
+import (
+	"sort"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/types"
+)
+
+// throttle coalesces the changes of objects and limits the rate of the events sent on a websocket, so that storms of
+// updates don't overwhelm the browsers watching them.
+//
+// The changes of an object are held for the coalescing window, only the latest of them being sent at its end. The
+// events of objects sent beyond the maximum per second are dropped, and the watches whose events were dropped are sent
+// a resource.resync event the next second, with the revision of the last event they were sent, so that their clients
+// list what they missed again.
+type throttle struct {
+	window             time.Duration
+	maxEventsPerSecond int
+	now                func() time.Time
+
+	// pending are the changes held for the coalescing window, by watch and object, in the order they arrived
+	pending  map[string]int
+	queued   []types.APIEvent
+	deadline time.Time
+
+	second  time.Time
+	sent    int
+	dropped map[string]types.APIEvent
+	// watches are the watches of the websocket, with the revisions of the last events they were sent
+	watches watches
+}
+
+func newThrottle(opts Options, sent watches) *throttle {
+	return &throttle{
+		window:             opts.CoalesceWindow,
+		maxEventsPerSecond: opts.MaxEventsPerSecond,
+		now:                time.Now,
+		pending:            map[string]int{},
+		dropped:            map[string]types.APIEvent{},
+		watches:            sent,
+	}
+}
+
+// enabled returns true if events are coalesced or limited
+func (t *throttle) enabled() bool {
+	return t.window > 0 || t.maxEventsPerSecond > 0
+}
+
+// interval is how often tick must be called
+func (t *throttle) interval() time.Duration {
+	if t.window > 0 && t.window < time.Second {
+		return t.window
+	}
+	return time.Second
+}
+
+// add returns the events to send when event is received
+func (t *throttle) add(event types.APIEvent) []types.APIEvent {
+	result := t.resyncs()
+	if t.window > 0 {
+		switch event.Name {
+		case "resource.change":
+			key := objectKey(event)
+			if i, ok := t.pending[key]; ok {
+				t.queued[i] = event
+				return result
+			}
+			if len(t.queued) == 0 {
+				t.deadline = t.now().Add(t.window)
+			}
+			t.pending[key] = len(t.queued)
+			t.queued = append(t.queued, event)
+			return result
+		case "resource.remove":
+			// the changes of removed objects don't matter anymore
+			if i, ok := t.pending[objectKey(event)]; ok {
+				t.queued[i].Name = ""
+				delete(t.pending, objectKey(event))
+			}
+		default:
+			// the other events of the watches, such as their stop, come after the changes they received before
+			result = append(result, t.flush()...)
+		}
+	}
+	return t.limit(result, event)
+}
+
+// tick returns the events to send once the coalescing window of the pending changes ended, and the resync events of
+// the watches whose events were dropped the previous second
+func (t *throttle) tick() []types.APIEvent {
+	result := t.resyncs()
+	if len(t.queued) > 0 && !t.now().Before(t.deadline) {
+		result = append(result, t.flush()...)
+	}
+	return result
+}
+
+// flush returns the pending changes which can be sent
+func (t *throttle) flush() []types.APIEvent {
+	var result []types.APIEvent
+	for _, event := range t.queued {
+		if event.Name != "" {
+			result = t.limit(result, event)
+		}
+	}
+	t.queued = nil
+	t.pending = map[string]int{}
+	return result
+}
+
+// limit appends event to events if it can be sent this second, or records its watch as needing a resync if it can't
+func (t *throttle) limit(events []types.APIEvent, event types.APIEvent) []types.APIEvent {
+	if !isObjectEvent(event) {
+		if event.Name == "resource.stop" {
+			delete(t.dropped, watchKey(event))
+		}
+		return append(events, event)
+	}
+	if t.maxEventsPerSecond > 0 {
+		t.rollover()
+		if t.sent >= t.maxEventsPerSecond {
+			if _, ok := t.dropped[watchKey(event)]; !ok {
+				resync := types.APIEvent{
+					Name:         "resource.resync",
+					ResourceType: event.ResourceType,
+					Namespace:    event.Namespace,
+					ID:           event.ID,
+					Selector:     event.Selector,
+				}
+				if watch, ok := t.watches[watchKey(event)]; ok {
+					resync.Namespace = watch.Namespace
+				}
+				t.dropped[watchKey(event)] = resync
+			}
+			return events
+		}
+		t.sent++
+	}
+	return append(events, event)
+}
+
+// resyncs returns the resync events of the watches whose events were dropped before this second
+func (t *throttle) resyncs() []types.APIEvent {
+	if len(t.dropped) == 0 || t.now().Truncate(time.Second).Equal(t.second) {
+		return nil
+	}
+	keys := make([]string, 0, len(t.dropped))
+	for key := range t.dropped {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	result := make([]types.APIEvent, 0, len(keys))
+	for _, key := range keys {
+		event := t.dropped[key]
+		event.Revision = t.watches[key].ResourceVersion
+		result = append(result, event)
+	}
+	t.dropped = map[string]types.APIEvent{}
+	return result
+}
+
+// rollover starts counting the events of a new second
+func (t *throttle) rollover() {
+	if second := t.now().Truncate(time.Second); !second.Equal(t.second) {
+		t.second = second
+		t.sent = 0
+	}
+}
+
+// isObjectEvent returns true for the events of the changes of objects, which are coalesced and limited
+func isObjectEvent(event types.APIEvent) bool {
+	switch event.Name {
+	case "resource.create", "resource.change", "resource.remove":
+		return true
+	}
+	return false
+}
+
+// objectKey identifies the object of an event of a watch
+func objectKey(event types.APIEvent) string {
+	return watchKey(event) + "/" + event.Object.ID
+}
@@ -0,0 +1,169 @@
+package subscribe
+
+import (
+	"testing"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func podEvent(name, id, revision string) types.APIEvent {
+	return types.APIEvent{
+		Name:         name,
+		ResourceType: "pod",
+		Object: types.APIObject{
+			ID: id,
+			Object: &unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": id, "resourceVersion": revision},
+			}},
+		},
+	}
+}
+
+func TestThrottle(t *testing.T) {
+	start := podEvent("resource.start", "", "")
+	start.Namespace = "default"
+	stop := podEvent("resource.stop", "", "")
+
+	// steps are events received, or ticks if they have no name, after some time
+	type step struct {
+		after time.Duration
+		event types.APIEvent
+		want  []string
+	}
+	tests := []struct {
+		name  string
+		opts  Options
+		steps []step
+	}{
+		{
+			name: "changes of an object are coalesced",
+			opts: Options{CoalesceWindow: 100 * time.Millisecond},
+			steps: []step{
+				{event: start, want: []string{"resource.start/"}},
+				{event: podEvent("resource.change", "a", "1")},
+				{event: podEvent("resource.change", "b", "2")},
+				{after: 50 * time.Millisecond, event: podEvent("resource.change", "a", "3")},
+				{after: 10 * time.Millisecond},
+				{after: 40 * time.Millisecond, want: []string{"resource.change/a/3", "resource.change/b/2"}},
+			},
+		},
+		{
+			name: "creations aren't coalesced",
+			opts: Options{CoalesceWindow: 100 * time.Millisecond},
+			steps: []step{
+				{event: podEvent("resource.create", "a", "1"), want: []string{"resource.create/a/1"}},
+			},
+		},
+		{
+			name: "changes of removed objects are dropped",
+			opts: Options{CoalesceWindow: 100 * time.Millisecond},
+			steps: []step{
+				{event: podEvent("resource.change", "a", "1")},
+				{event: podEvent("resource.change", "b", "2")},
+				{event: podEvent("resource.remove", "a", "3"), want: []string{"resource.remove/a/3"}},
+				{after: 100 * time.Millisecond, want: []string{"resource.change/b/2"}},
+			},
+		},
+		{
+			name: "changes are sent before the stop of their watch",
+			opts: Options{CoalesceWindow: 100 * time.Millisecond},
+			steps: []step{
+				{event: podEvent("resource.change", "a", "1")},
+				{event: stop, want: []string{"resource.change/a/1", "resource.stop/"}},
+				{after: 100 * time.Millisecond},
+			},
+		},
+		{
+			name: "events beyond the limit are dropped and their watch resynced the next second",
+			opts: Options{MaxEventsPerSecond: 2},
+			steps: []step{
+				{event: start, want: []string{"resource.start/"}},
+				{event: podEvent("resource.create", "a", "1"), want: []string{"resource.create/a/1"}},
+				{event: podEvent("resource.change", "a", "2"), want: []string{"resource.change/a/2"}},
+				{event: podEvent("resource.change", "a", "3")},
+				{event: podEvent("resource.remove", "a", "4")},
+				{after: 500 * time.Millisecond},
+				{after: 500 * time.Millisecond, want: []string{"resource.resync//2"}},
+				{event: podEvent("resource.create", "b", "5"), want: []string{"resource.create/b/5"}},
+			},
+		},
+		{
+			name: "the resync of a watch precedes its next events",
+			opts: Options{MaxEventsPerSecond: 1},
+			steps: []step{
+				{event: start, want: []string{"resource.start/"}},
+				{event: podEvent("resource.create", "a", "1"), want: []string{"resource.create/a/1"}},
+				{event: podEvent("resource.create", "b", "2")},
+				{after: time.Second, event: podEvent("resource.create", "c", "3"), want: []string{"resource.resync//1", "resource.create/c/3"}},
+			},
+		},
+		{
+			name: "stopped watches aren't resynced",
+			opts: Options{MaxEventsPerSecond: 1},
+			steps: []step{
+				{event: start, want: []string{"resource.start/"}},
+				{event: podEvent("resource.create", "a", "1"), want: []string{"resource.create/a/1"}},
+				{event: podEvent("resource.create", "b", "2")},
+				{event: stop, want: []string{"resource.stop/"}},
+				{after: time.Second},
+			},
+		},
+		{
+			name: "coalesced changes are limited",
+			opts: Options{CoalesceWindow: 100 * time.Millisecond, MaxEventsPerSecond: 1},
+			steps: []step{
+				{event: start, want: []string{"resource.start/"}},
+				{event: podEvent("resource.change", "a", "1")},
+				{event: podEvent("resource.change", "b", "2")},
+				{after: 100 * time.Millisecond, want: []string{"resource.change/a/1"}},
+				{after: 900 * time.Millisecond, want: []string{"resource.resync//1"}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+			sent := watches{}
+			throttle := newThrottle(test.opts, sent)
+			throttle.now = func() time.Time { return now }
+			for i, step := range test.steps {
+				now = now.Add(step.after)
+				var events []types.APIEvent
+				if step.event.Name == "" {
+					events = throttle.tick()
+				} else {
+					events = throttle.add(step.event)
+				}
+				got := []string{}
+				for _, event := range events {
+					sent.update(event)
+					got = append(got, describeEvent(t, event))
+				}
+				want := step.want
+				if want == nil {
+					want = []string{}
+				}
+				assert.Equal(t, want, got, "step %d", i)
+			}
+		})
+	}
+}
+
+// describeEvent describes an event by its name, its object and revision, or the revision of a resync
+func describeEvent(t *testing.T, event types.APIEvent) string {
+	if event.Name == "resource.resync" {
+		assert.Equal(t, "default", event.Namespace, "resyncs are in the namespace of their watch")
+		return event.Name + "//" + event.Revision
+	}
+	revision := ""
+	if u, ok := event.Object.Object.(*unstructured.Unstructured); ok {
+		revision = u.GetResourceVersion()
+	}
+	if event.Object.ID == "" {
+		return event.Name + "/"
+	}
+	return event.Name + "/" + event.Object.ID + "/" + revision
+}
@@ -0,0 +1,202 @@
+// Package usernamespaces provides the userNamespace schema, listing the namespaces the requesting user can access, and
+// watching them as the roles bound to the user and the namespaces change, so that namespace pickers don't list and
+// filter all the namespaces.
+package usernamespaces
+
+import (
+	"net/http"
+	"sort"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/store/empty"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	corecontrollers "github.com/rancher/wrangler/v3/pkg/generated/controllers/core/v1"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+const projectIDLabel = "field.cattle.io/projectId"
+
+// pollInterval is how often watches check for changes of the access of users and of namespaces
+var pollInterval = 2 * time.Second
+
+var namespacesResource = schema.GroupResource{Resource: "namespaces"}
+
+// UserNamespace is a namespace the user can access
+type UserNamespace struct {
+	ID string `json:"id"`
+	// ProjectID is the value of the field.cattle.io/projectId label of the namespace
+	ProjectID string `json:"projectId,omitempty"`
+	// Terminating is true if the namespace is being deleted
+	Terminating bool `json:"terminating,omitempty"`
+}
+
+func (u UserNamespace) toAPIObject() types.APIObject {
+	return types.APIObject{
+		Type:   "userNamespace",
+		ID:     u.ID,
+		Object: u,
+	}
+}
+
+// Register registers the userNamespace schema
+func Register(schemas *types.APISchemas, asl accesscontrol.AccessSetLookup, namespaces corecontrollers.NamespaceCache) {
+	schemas.MustImportAndCustomize(UserNamespace{}, func(schema *types.APISchema) {
+		schema.CollectionMethods = []string{http.MethodGet}
+		schema.ResourceMethods = []string{http.MethodGet}
+		schema.Store = &Store{
+			asl:        asl,
+			namespaces: namespaces,
+		}
+	})
+}
+
+// Store lists the namespaces the requesting user can access: those they can get, and those in which they can get or
+// list any type of object
+type Store struct {
+	empty.Store
+	asl        accesscontrol.AccessSetLookup
+	namespaces corecontrollers.NamespaceCache
+}
+
+func (s *Store) ByID(apiOp *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
+	namespaces, err := s.userNamespaces(apiOp)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	if ns, ok := namespaces[id]; ok {
+		return ns.toAPIObject(), nil
+	}
+	return types.APIObject{}, apierror.NewAPIError(validation.NotFound, "no namespace "+id)
+}
+
+func (s *Store) List(apiOp *types.APIRequest, schema *types.APISchema) (types.APIObjectList, error) {
+	namespaces, err := s.userNamespaces(apiOp)
+	if err != nil {
+		return types.APIObjectList{}, err
+	}
+	result := types.APIObjectList{}
+	for _, ns := range sorted(namespaces) {
+		result.Objects = append(result.Objects, ns.toAPIObject())
+	}
+	return result, nil
+}
+
+// Watch sends the namespaces the user gains access to as they are created, those they lose access to as they are
+// removed, and changes of the projects of namespaces, only for the namespace of w.ID if set
+func (s *Store) Watch(apiOp *types.APIRequest, schema *types.APISchema, w types.WatchRequest) (chan types.APIEvent, error) {
+	current, err := s.userNamespaces(apiOp)
+	if err != nil {
+		return nil, err
+	}
+	result := make(chan types.APIEvent)
+	go func() {
+		defer close(result)
+		for {
+			select {
+			case <-apiOp.Context().Done():
+				return
+			case <-time.After(pollInterval):
+			}
+			namespaces, err := s.userNamespaces(apiOp)
+			if err != nil {
+				logrus.Debugf("failed to get the namespaces of user: %v", err)
+				continue
+			}
+			for _, event := range changes(current, namespaces) {
+				if w.ID != "" && event.ID != w.ID {
+					continue
+				}
+				select {
+				case result <- event:
+				case <-apiOp.Context().Done():
+					return
+				}
+			}
+			current = namespaces
+		}
+	}()
+	return result, nil
+}
+
+func (s *Store) userNamespaces(apiOp *types.APIRequest) (map[string]UserNamespace, error) {
+	user, ok := request.UserFrom(apiOp.Context())
+	if !ok {
+		return nil, validation.Unauthorized
+	}
+	return s.namespacesFor(user)
+}
+
+// namespacesFor returns the existing namespaces a user can access, by name
+func (s *Store) namespacesFor(user user.Info) (map[string]UserNamespace, error) {
+	accessSet := s.asl.AccessFor(user)
+	withAccess := map[string]bool{}
+	for _, ns := range accessSet.Namespaces() {
+		withAccess[ns] = true
+	}
+
+	namespaces, err := s.namespaces.List(labels.Everything())
+	if err != nil {
+		return nil, err
+	}
+	result := map[string]UserNamespace{}
+	for _, ns := range namespaces {
+		if !withAccess[ns.Name] &&
+			!accessSet.Grants("get", namespacesResource, "", ns.Name) &&
+			!accessSet.Grants("list", namespacesResource, "", ns.Name) {
+			continue
+		}
+		result[ns.Name] = UserNamespace{
+			ID:          ns.Name,
+			ProjectID:   ns.Labels[projectIDLabel],
+			Terminating: ns.DeletionTimestamp != nil,
+		}
+	}
+	return result, nil
+}
+
+// changes returns the events turning the old namespaces into the new ones, sorted by namespace
+func changes(old, new map[string]UserNamespace) []types.APIEvent {
+	var result []types.APIEvent
+	for _, ns := range sorted(new) {
+		oldNS, ok := old[ns.ID]
+		switch {
+		case !ok:
+			result = append(result, event(types.CreateAPIEvent, ns))
+		case oldNS != ns:
+			result = append(result, event(types.ChangeAPIEvent, ns))
+		}
+	}
+	for _, ns := range sorted(old) {
+		if _, ok := new[ns.ID]; !ok {
+			result = append(result, event(types.RemoveAPIEvent, ns))
+		}
+	}
+	return result
+}
+
+func event(name string, ns UserNamespace) types.APIEvent {
+	return types.APIEvent{
+		Name:         name,
+		ResourceType: "userNamespace",
+		ID:           ns.ID,
+		Object:       ns.toAPIObject(),
+	}
+}
+
+func sorted(namespaces map[string]UserNamespace) []UserNamespace {
+	result := make([]UserNamespace, 0, len(namespaces))
+	for _, ns := range namespaces {
+		result = append(result, ns)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+	return result
+}
@@ -0,0 +1,117 @@
+package usernamespaces
+
+import (
+	"context"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/wrangler/v3/pkg/generic/fake"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apiserver/pkg/authentication/user"
+	"k8s.io/apiserver/pkg/endpoints/request"
+)
+
+type accessSetLookup struct {
+	lock      sync.Mutex
+	accessSet *accesscontrol.AccessSet
+}
+
+func (a *accessSetLookup) AccessFor(user.Info) *accesscontrol.AccessSet {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	return a.accessSet
+}
+
+func (a *accessSetLookup) PurgeUserData(string) {}
+
+func namespace(name, project string) *corev1.Namespace {
+	ns := &corev1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: name}}
+	if project != "" {
+		ns.Labels = map[string]string{projectIDLabel: project}
+	}
+	return ns
+}
+
+func TestStore(t *testing.T) {
+	pollInterval = 10 * time.Millisecond
+	defer func() { pollInterval = 2 * time.Second }()
+
+	ctrl := gomock.NewController(t)
+	var lock sync.Mutex
+	namespaces := []*corev1.Namespace{namespace("dev", "p-1"), namespace("prod", ""), namespace("shared", ""), namespace("other", "")}
+	cache := fake.NewMockNonNamespacedCacheInterface[*corev1.Namespace](ctrl)
+	cache.EXPECT().List(gomock.Any()).DoAndReturn(func(_ interface{}) ([]*corev1.Namespace, error) {
+		lock.Lock()
+		defer lock.Unlock()
+		return namespaces, nil
+	}).AnyTimes()
+
+	accessSet := &accesscontrol.AccessSet{}
+	accessSet.Add("list", schema.GroupResource{Resource: "pods"}, accesscontrol.Access{Namespace: "dev", ResourceName: accesscontrol.All})
+	// namespaces of bindings which don't exist aren't listed
+	accessSet.Add("get", schema.GroupResource{Resource: "secrets"}, accesscontrol.Access{Namespace: "deleted", ResourceName: "s"})
+	accessSet.Add("get", schema.GroupResource{Resource: "namespaces"}, accesscontrol.Access{Namespace: accesscontrol.All, ResourceName: "shared"})
+	lookup := &accessSetLookup{accessSet: accessSet}
+	store := &Store{asl: lookup, namespaces: cache}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	req := httptest.NewRequest("GET", "/v1/userNamespaces", nil)
+	req = req.WithContext(request.WithUser(ctx, &user.DefaultInfo{Name: "alice"}))
+	apiOp := &types.APIRequest{Request: req}
+
+	list, err := store.List(apiOp, nil)
+	require.NoError(t, err)
+	var ids []string
+	for _, obj := range list.Objects {
+		ids = append(ids, obj.ID)
+	}
+	assert.Equal(t, []string{"dev", "shared"}, ids)
+	assert.Equal(t, UserNamespace{ID: "dev", ProjectID: "p-1"}, list.Objects[0].Object)
+
+	_, err = store.ByID(apiOp, nil, "prod")
+	assert.Error(t, err)
+
+	events, err := store.Watch(apiOp, nil, types.WatchRequest{})
+	require.NoError(t, err)
+
+	// the user is granted access to prod, and dev is moved to another project
+	changed := &accesscontrol.AccessSet{}
+	changed.Merge(accessSet)
+	changed.Add("list", schema.GroupResource{Resource: "pods"}, accesscontrol.Access{Namespace: "prod", ResourceName: accesscontrol.All})
+	lock.Lock()
+	lookup.lock.Lock()
+	lookup.accessSet = changed
+	namespaces = []*corev1.Namespace{namespace("dev", "p-2"), namespace("prod", ""), namespace("other", "")}
+	lookup.lock.Unlock()
+	lock.Unlock()
+
+	var received []types.APIEvent
+	for len(received) < 3 {
+		select {
+		case event := <-events:
+			received = append(received, event)
+		case <-time.After(time.Second):
+			t.Fatalf("expected 3 events, got %d", len(received))
+		}
+	}
+	assert.Equal(t, types.ChangeAPIEvent, received[0].Name)
+	assert.Equal(t, UserNamespace{ID: "dev", ProjectID: "p-2"}, received[0].Object.Object)
+	assert.Equal(t, types.CreateAPIEvent, received[1].Name)
+	assert.Equal(t, "prod", received[1].ID)
+	assert.Equal(t, types.RemoveAPIEvent, received[2].Name)
+	assert.Equal(t, "shared", received[2].ID)
+
+	cancel()
+	for range events {
+	}
+}
@@ -0,0 +1,170 @@
+// Package annotations provides cache.TransformFunc's which promote configured annotations into indexed columns
+package annotations
+
+import (
+	"fmt"
+	"os"
+	"regexp"
+	"strconv"
+
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/yaml"
+)
+
+const (
+	// TypeString stores the annotation value as-is. It is the default if no type is given.
+	TypeString = "string"
+	// TypeInt only stores annotation values which are integers, in their canonical form.
+	TypeInt = "int"
+	// TypeBool only stores annotation values which are booleans, as "true" or "false".
+	TypeBool = "bool"
+)
+
+// columnNameRegex only allows letters, as those are the only characters accepted in field names by the SQL cache
+var columnNameRegex = regexp.MustCompile(`^[a-zA-Z]+$`)
+
+// Column maps an annotation of a kind to a virtual field in metadata.annotationColumns
+type Column struct {
+	Group      string `json:"group,omitempty"`
+	Version    string `json:"version"`
+	Kind       string `json:"kind"`
+	Annotation string `json:"annotation"`
+	Name       string `json:"name"`
+	Type       string `json:"type,omitempty"`
+}
+
+// GVK returns the GroupVersionKind the column applies to
+func (c Column) GVK() schema.GroupVersionKind {
+	return schema.GroupVersionKind{Group: c.Group, Version: c.Version, Kind: c.Kind}
+}
+
+// Field returns the path of the virtual field holding the annotation value
+func (c Column) Field() []string {
+	return []string{"metadata", "annotationColumns", c.Name}
+}
+
+// Validate returns an error if the column cannot be indexed
+func (c Column) Validate() error {
+	if c.Version == "" || c.Kind == "" {
+		return fmt.Errorf("annotation column [%s] requires a version and a kind", c.Name)
+	}
+	if !columnNameRegex.MatchString(c.Name) {
+		return fmt.Errorf("annotation column name [%s] must only contain letters", c.Name)
+	}
+	if errs := validation.IsQualifiedName(c.Annotation); len(errs) > 0 {
+		return fmt.Errorf("annotation column [%s] has an invalid annotation [%s]: %v", c.Name, c.Annotation, errs)
+	}
+	switch c.Type {
+	case "", TypeString, TypeInt, TypeBool:
+	default:
+		return fmt.Errorf("annotation column [%s] has unsupported type [%s], must be one of %s, %s or %s", c.Name, c.Type, TypeString, TypeInt, TypeBool)
+	}
+	return nil
+}
+
+// Columns holds validated annotation columns by GVK
+type Columns struct {
+	byGVK map[schema.GroupVersionKind][]Column
+}
+
+// NewColumns validates the given columns and returns them indexed by GVK
+func NewColumns(columns []Column) (*Columns, error) {
+	c := &Columns{
+		byGVK: map[schema.GroupVersionKind][]Column{},
+	}
+	names := map[schema.GroupVersionKind]map[string]bool{}
+	for _, column := range columns {
+		if err := column.Validate(); err != nil {
+			return nil, err
+		}
+		gvk := column.GVK()
+		if names[gvk] == nil {
+			names[gvk] = map[string]bool{}
+		}
+		if names[gvk][column.Name] {
+			return nil, fmt.Errorf("annotation column [%s] is defined more than once for %s", column.Name, gvk)
+		}
+		names[gvk][column.Name] = true
+		c.byGVK[gvk] = append(c.byGVK[gvk], column)
+	}
+	return c, nil
+}
+
+// LoadColumns reads annotation columns from a YAML or JSON file containing a list of columns
+func LoadColumns(path string) ([]Column, error) {
+	bytes, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var columns []Column
+	if err := yaml.Unmarshal(bytes, &columns); err != nil {
+		return nil, fmt.Errorf("failed to parse annotation columns from %s: %w", path, err)
+	}
+	return columns, nil
+}
+
+// Fields returns the fields which need to be indexed for the given GVK
+func (c *Columns) Fields(gvk schema.GroupVersionKind) [][]string {
+	if c == nil {
+		return nil
+	}
+	var fields [][]string
+	for _, column := range c.byGVK[gvk] {
+		fields = append(fields, column.Field())
+	}
+	return fields
+}
+
+// TransformFunc returns a func which copies configured annotation values into their virtual fields, or nil if the
+// GVK has no annotation columns
+func (c *Columns) TransformFunc(gvk schema.GroupVersionKind) func(*unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if c == nil || len(c.byGVK[gvk]) == 0 {
+		return nil
+	}
+	columns := c.byGVK[gvk]
+	return func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		values := map[string]interface{}{}
+		annotations := obj.GetAnnotations()
+		for _, column := range columns {
+			raw, ok := annotations[column.Annotation]
+			if !ok {
+				continue
+			}
+			value, err := convert(raw, column.Type)
+			if err != nil {
+				logrus.Debugf("skipping annotation column [%s] for %s %s/%s: %v", column.Name, gvk.Kind, obj.GetNamespace(), obj.GetName(), err)
+				continue
+			}
+			values[column.Name] = value
+		}
+		if len(values) > 0 {
+			data.PutValue(obj.Object, values, "metadata", "annotationColumns")
+		}
+		return obj, nil
+	}
+}
+
+// convert validates an annotation value against the column type. Values are kept as strings, since the SQL cache
+// stores all columns as text and unstructured objects must remain deep-copyable.
+func convert(value, columnType string) (string, error) {
+	switch columnType {
+	case TypeInt:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatInt(i, 10), nil
+	case TypeBool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return "", err
+		}
+		return strconv.FormatBool(b), nil
+	default:
+		return value, nil
+	}
+}
@@ -0,0 +1,131 @@
+package annotations_test
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/steve/pkg/resources/virtual/annotations"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var deploymentGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+
+func TestNewColumns(t *testing.T) {
+	tests := []struct {
+		name      string
+		columns   []annotations.Column
+		wantError bool
+	}{
+		{
+			name: "valid columns",
+			columns: []annotations.Column{
+				{Group: "apps", Version: "v1", Kind: "Deployment", Annotation: "scanner.example.com/critical", Name: "critical", Type: annotations.TypeInt},
+				{Group: "apps", Version: "v1", Kind: "Deployment", Annotation: "scanner.example.com/image", Name: "image"},
+			},
+		},
+		{
+			name: "name with non-letters",
+			columns: []annotations.Column{
+				{Group: "apps", Version: "v1", Kind: "Deployment", Annotation: "scanner.example.com/critical", Name: "critical-count"},
+			},
+			wantError: true,
+		},
+		{
+			name: "unsupported type",
+			columns: []annotations.Column{
+				{Group: "apps", Version: "v1", Kind: "Deployment", Annotation: "scanner.example.com/critical", Name: "critical", Type: "float"},
+			},
+			wantError: true,
+		},
+		{
+			name: "invalid annotation",
+			columns: []annotations.Column{
+				{Group: "apps", Version: "v1", Kind: "Deployment", Annotation: "not a key", Name: "critical"},
+			},
+			wantError: true,
+		},
+		{
+			name: "missing kind",
+			columns: []annotations.Column{
+				{Group: "apps", Version: "v1", Annotation: "scanner.example.com/critical", Name: "critical"},
+			},
+			wantError: true,
+		},
+		{
+			name: "duplicated name",
+			columns: []annotations.Column{
+				{Group: "apps", Version: "v1", Kind: "Deployment", Annotation: "scanner.example.com/critical", Name: "critical"},
+				{Group: "apps", Version: "v1", Kind: "Deployment", Annotation: "scanner.example.com/high", Name: "critical"},
+			},
+			wantError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := annotations.NewColumns(test.columns)
+			if test.wantError {
+				require.Error(t, err)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTransformFunc(t *testing.T) {
+	columns, err := annotations.NewColumns([]annotations.Column{
+		{Group: "apps", Version: "v1", Kind: "Deployment", Annotation: "scanner.example.com/critical", Name: "critical", Type: annotations.TypeInt},
+		{Group: "apps", Version: "v1", Kind: "Deployment", Annotation: "scanner.example.com/scanned", Name: "scanned", Type: annotations.TypeBool},
+		{Group: "apps", Version: "v1", Kind: "Deployment", Annotation: "scanner.example.com/report", Name: "report"},
+	})
+	require.NoError(t, err)
+
+	require.Nil(t, columns.TransformFunc(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}))
+	require.Equal(t, [][]string{
+		{"metadata", "annotationColumns", "critical"},
+		{"metadata", "annotationColumns", "scanned"},
+		{"metadata", "annotationColumns", "report"},
+	}, columns.Fields(deploymentGVK))
+
+	input := &unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"apiVersion": "apps/v1",
+			"kind":       "Deployment",
+			"metadata": map[string]interface{}{
+				"name": "web",
+				"annotations": map[string]interface{}{
+					"scanner.example.com/critical": "007",
+					"scanner.example.com/scanned":  "maybe",
+					"scanner.example.com/report":   "https://example.com/report",
+				},
+			},
+		},
+	}
+	output, err := columns.TransformFunc(deploymentGVK)(input)
+	require.NoError(t, err)
+	require.Equal(t, map[string]interface{}{
+		"critical": "7",
+		"report":   "https://example.com/report",
+	}, output.Object["metadata"].(map[string]interface{})["annotationColumns"])
+}
+
+func TestLoadColumns(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "columns.yaml")
+	require.NoError(t, os.WriteFile(path, []byte(`
+- group: apps
+  version: v1
+  kind: Deployment
+  annotation: scanner.example.com/critical
+  name: critical
+  type: int
+`), 0600))
+
+	columns, err := annotations.LoadColumns(path)
+	require.NoError(t, err)
+	require.Equal(t, []annotations.Column{
+		{Group: "apps", Version: "v1", Kind: "Deployment", Annotation: "scanner.example.com/critical", Name: "critical", Type: annotations.TypeInt},
+	}, columns)
+}
@@ -0,0 +1,163 @@
+// Package computed provides cache.TransformFunc's which store values derived from objects by Go functions registered by
+// embedders, such as the status of a condition, into indexed virtual fields
+package computed
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// fieldNameRegex only allows letters, as those are the only characters accepted in field names by the SQL cache
+var fieldNameRegex = regexp.MustCompile(`^[a-zA-Z]+$`)
+
+// Func derives the value of a computed field from an object, returning false if the object has no value. Values are
+// strings, since the SQL cache stores all columns as text and unstructured objects must remain deep-copyable, and are
+// sorted as text.
+type Func func(obj *unstructured.Unstructured) (string, bool)
+
+// Field maps a Func to a virtual field in status.computed of the objects of a kind
+type Field struct {
+	Group   string
+	Version string
+	Kind    string
+	Name    string
+	Compute Func
+}
+
+// GVK returns the GroupVersionKind the field applies to
+func (f Field) GVK() k8sschema.GroupVersionKind {
+	return k8sschema.GroupVersionKind{Group: f.Group, Version: f.Version, Kind: f.Kind}
+}
+
+// Path returns the path of the virtual field holding the computed value
+func (f Field) Path() []string {
+	return []string{"status", "computed", f.Name}
+}
+
+// Validate returns an error if the field cannot be indexed
+func (f Field) Validate() error {
+	if f.Version == "" || f.Kind == "" {
+		return fmt.Errorf("computed field [%s] requires a version and a kind", f.Name)
+	}
+	if !fieldNameRegex.MatchString(f.Name) {
+		return fmt.Errorf("computed field name [%s] must only contain letters", f.Name)
+	}
+	if f.Compute == nil {
+		return fmt.Errorf("computed field [%s] has no func", f.Name)
+	}
+	return nil
+}
+
+// Fields holds validated computed fields by GVK
+type Fields struct {
+	byGVK map[k8sschema.GroupVersionKind][]Field
+}
+
+// NewFields validates the given fields and returns them indexed by GVK
+func NewFields(fields []Field) (*Fields, error) {
+	f := &Fields{
+		byGVK: map[k8sschema.GroupVersionKind][]Field{},
+	}
+	names := map[k8sschema.GroupVersionKind]map[string]bool{}
+	for _, field := range fields {
+		if err := field.Validate(); err != nil {
+			return nil, err
+		}
+		gvk := field.GVK()
+		if names[gvk] == nil {
+			names[gvk] = map[string]bool{}
+		}
+		if names[gvk][field.Name] {
+			return nil, fmt.Errorf("computed field [%s] is defined more than once for %s", field.Name, gvk)
+		}
+		names[gvk][field.Name] = true
+		f.byGVK[gvk] = append(f.byGVK[gvk], field)
+	}
+	return f, nil
+}
+
+// Fields returns the fields which need to be indexed for the given GVK
+func (f *Fields) Fields(gvk k8sschema.GroupVersionKind) [][]string {
+	if f == nil {
+		return nil
+	}
+	var fields [][]string
+	for _, field := range f.byGVK[gvk] {
+		fields = append(fields, field.Path())
+	}
+	return fields
+}
+
+// TransformFunc returns a func which stores the computed values into their virtual fields, or nil if the GVK has no
+// computed fields
+func (f *Fields) TransformFunc(gvk k8sschema.GroupVersionKind) func(*unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if f == nil || len(f.byGVK[gvk]) == 0 {
+		return nil
+	}
+	fields := f.byGVK[gvk]
+	return func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		values := map[string]interface{}{}
+		for _, field := range fields {
+			if value, ok := field.Compute(obj); ok {
+				values[field.Name] = value
+			}
+		}
+		if len(values) > 0 {
+			data.PutValue(obj.Object, values, "status", "computed")
+		}
+		return obj, nil
+	}
+}
+
+// Template returns a schema template setting the computedFields attribute of the schemas of the kinds having computed
+// fields, so that clients can discover which fields they can filter and sort on
+func Template(fields *Fields) schema.Template {
+	return schema.Template{
+		Customize: func(apiSchema *types.APISchema) {
+			var paths []string
+			for _, field := range fields.Fields(attributes.GVK(apiSchema)) {
+				paths = append(paths, strings.Join(field, "."))
+			}
+			if len(paths) > 0 {
+				attributes.SetComputedFields(apiSchema, paths)
+			}
+		},
+	}
+}
+
+// ConditionStatus returns a Func computing the status of a condition of an object, such as "True" for a Ready
+// condition
+func ConditionStatus(conditionType string) Func {
+	return func(obj *unstructured.Unstructured) (string, bool) {
+		conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+		for _, condition := range conditions {
+			c, ok := condition.(map[string]interface{})
+			if !ok || c["type"] != conditionType {
+				continue
+			}
+			status, ok := c["status"].(string)
+			return status, ok
+		}
+		return "", false
+	}
+}
+
+// ReadyReplicasRatio computes the ratio of the replicas of a workload which are ready, from 0.00 to 1.00 so that it
+// sorts as text. Workloads scaled to zero have no ratio.
+func ReadyReplicasRatio(obj *unstructured.Unstructured) (string, bool) {
+	replicas, ok, _ := unstructured.NestedInt64(obj.Object, "spec", "replicas")
+	if !ok || replicas <= 0 {
+		return "", false
+	}
+	ready, _, _ := unstructured.NestedInt64(obj.Object, "status", "readyReplicas")
+	return strconv.FormatFloat(float64(ready)/float64(replicas), 'f', 2, 64), true
+}
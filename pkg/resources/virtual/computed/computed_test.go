@@ -0,0 +1,154 @@
+package computed_test
+
+import (
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/resources/virtual/computed"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var deploymentGVK = schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+
+func TestNewFields(t *testing.T) {
+	ready := computed.ConditionStatus("Available")
+	tests := []struct {
+		name      string
+		fields    []computed.Field
+		wantError bool
+	}{
+		{
+			name: "valid fields",
+			fields: []computed.Field{
+				{Group: "apps", Version: "v1", Kind: "Deployment", Name: "ready", Compute: ready},
+				{Group: "apps", Version: "v1", Kind: "Deployment", Name: "readyRatio", Compute: computed.ReadyReplicasRatio},
+				{Version: "v1", Kind: "Node", Name: "ready", Compute: computed.ConditionStatus("Ready")},
+			},
+		},
+		{
+			name:      "name with non-letters",
+			fields:    []computed.Field{{Group: "apps", Version: "v1", Kind: "Deployment", Name: "ready-ratio", Compute: ready}},
+			wantError: true,
+		},
+		{
+			name:      "missing kind",
+			fields:    []computed.Field{{Group: "apps", Version: "v1", Name: "ready", Compute: ready}},
+			wantError: true,
+		},
+		{
+			name:      "missing func",
+			fields:    []computed.Field{{Group: "apps", Version: "v1", Kind: "Deployment", Name: "ready"}},
+			wantError: true,
+		},
+		{
+			name: "duplicated name",
+			fields: []computed.Field{
+				{Group: "apps", Version: "v1", Kind: "Deployment", Name: "ready", Compute: ready},
+				{Group: "apps", Version: "v1", Kind: "Deployment", Name: "ready", Compute: computed.ReadyReplicasRatio},
+			},
+			wantError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := computed.NewFields(test.fields)
+			if test.wantError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestTransformFunc(t *testing.T) {
+	fields, err := computed.NewFields([]computed.Field{
+		{Group: "apps", Version: "v1", Kind: "Deployment", Name: "available", Compute: computed.ConditionStatus("Available")},
+		{Group: "apps", Version: "v1", Kind: "Deployment", Name: "readyRatio", Compute: computed.ReadyReplicasRatio},
+	})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{{"status", "computed", "available"}, {"status", "computed", "readyRatio"}}, fields.Fields(deploymentGVK))
+	assert.Nil(t, fields.TransformFunc(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}))
+
+	tests := []struct {
+		name     string
+		obj      map[string]interface{}
+		expected interface{}
+	}{
+		{
+			name: "all values computed",
+			obj: map[string]interface{}{
+				"spec": map[string]interface{}{"replicas": int64(3)},
+				"status": map[string]interface{}{
+					"readyReplicas": int64(2),
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "Progressing", "status": "True"},
+						map[string]interface{}{"type": "Available", "status": "False"},
+					},
+				},
+			},
+			expected: map[string]interface{}{"available": "False", "readyRatio": "0.67"},
+		},
+		{
+			name: "objects without values are left alone",
+			obj: map[string]interface{}{
+				"spec": map[string]interface{}{"replicas": int64(0)},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			obj, err := fields.TransformFunc(deploymentGVK)(&unstructured.Unstructured{Object: test.obj})
+			require.NoError(t, err)
+			value, _, _ := unstructured.NestedFieldNoCopy(obj.Object, "status", "computed")
+			assert.Equal(t, test.expected, value)
+		})
+	}
+
+	var nilFields *computed.Fields
+	assert.Nil(t, nilFields.Fields(deploymentGVK))
+	assert.Nil(t, nilFields.TransformFunc(deploymentGVK))
+}
+
+func TestReadyReplicasRatio(t *testing.T) {
+	ratio, ok := computed.ReadyReplicasRatio(&unstructured.Unstructured{Object: map[string]interface{}{
+		"spec": map[string]interface{}{"replicas": int64(2)},
+	}})
+	assert.True(t, ok)
+	assert.Equal(t, "0.00", ratio)
+
+	ratio, ok = computed.ReadyReplicasRatio(&unstructured.Unstructured{Object: map[string]interface{}{
+		"spec":   map[string]interface{}{"replicas": int64(2)},
+		"status": map[string]interface{}{"readyReplicas": int64(2)},
+	}})
+	assert.True(t, ok)
+	assert.Equal(t, "1.00", ratio)
+
+	_, ok = computed.ReadyReplicasRatio(&unstructured.Unstructured{Object: map[string]interface{}{}})
+	assert.False(t, ok)
+}
+
+func TestTemplate(t *testing.T) {
+	fields, err := computed.NewFields([]computed.Field{
+		{Group: "apps", Version: "v1", Kind: "Deployment", Name: "readyRatio", Compute: computed.ReadyReplicasRatio},
+	})
+	require.NoError(t, err)
+	template := computed.Template(fields)
+
+	deployments := &types.APISchema{Schema: &schemas.Schema{ID: "apps.deployment", Attributes: map[string]interface{}{
+		"group": "apps", "version": "v1", "kind": "Deployment",
+	}}}
+	template.Customize(deployments)
+	assert.Equal(t, []string{"status.computed.readyRatio"}, attributes.ComputedFields(deployments))
+
+	pods := &types.APISchema{Schema: &schemas.Schema{ID: "pod", Attributes: map[string]interface{}{
+		"version": "v1", "kind": "Pod",
+	}}}
+	template.Customize(pods)
+	assert.Nil(t, attributes.ComputedFields(pods))
+}
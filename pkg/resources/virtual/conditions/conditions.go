@@ -0,0 +1,104 @@
+// Package conditions provides cache.TransformFunc's which index the conditions of objects of all types by condition
+// type, so that lists can be filtered and sorted by the status, reason or last transition time of a condition
+package conditions
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// typeRegex only allows letters, as those are the only characters accepted in keys of fields by the SQL cache
+var typeRegex = regexp.MustCompile(`^[a-zA-Z]+$`)
+
+// DefaultTypes are the condition types indexed unless others are configured
+var DefaultTypes = []string{"Ready"}
+
+// indexedFields are the fields of conditions which are indexed
+var indexedFields = []string{"status", "reason", "lastTransitionTime"}
+
+// Conditions indexes the conditions of configured types of objects of all types, into the virtual
+// metadata.conditions[{type}] fields, which lists can also refer to as status.conditions[{type}]
+type Conditions struct {
+	types []string
+}
+
+// New validates the condition types to index and returns them
+func New(types []string) (*Conditions, error) {
+	seen := map[string]bool{}
+	for _, conditionType := range types {
+		if !typeRegex.MatchString(conditionType) {
+			return nil, fmt.Errorf("condition type [%s] must only contain letters", conditionType)
+		}
+		if seen[conditionType] {
+			return nil, fmt.Errorf("condition type [%s] is defined more than once", conditionType)
+		}
+		seen[conditionType] = true
+	}
+	return &Conditions{types: types}, nil
+}
+
+// Fields returns the fields which need to be indexed for all types
+func (c *Conditions) Fields() [][]string {
+	if c == nil {
+		return nil
+	}
+	var fields [][]string
+	for _, conditionType := range c.types {
+		for _, field := range indexedFields {
+			fields = append(fields, []string{"metadata", "conditions[" + conditionType + "]", field})
+		}
+	}
+	return fields
+}
+
+// TransformCommon copies the indexed fields of the conditions of the configured types into their virtual fields
+func (c *Conditions) TransformCommon(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if c == nil || len(c.types) == 0 {
+		return obj, nil
+	}
+	conditions, _, _ := unstructured.NestedSlice(obj.Object, "status", "conditions")
+	values := map[string]interface{}{}
+	for _, condition := range conditions {
+		m, ok := condition.(map[string]interface{})
+		if !ok {
+			continue
+		}
+		conditionType, _ := m["type"].(string)
+		if !c.indexes(conditionType) {
+			continue
+		}
+		fields := map[string]interface{}{}
+		for _, field := range indexedFields {
+			if value, ok := m[field].(string); ok {
+				fields[field] = value
+			}
+		}
+		values[conditionType] = fields
+	}
+	if len(values) > 0 {
+		data.PutValue(obj.Object, values, "metadata", "conditions")
+	}
+	return obj, nil
+}
+
+func (c *Conditions) indexes(conditionType string) bool {
+	for _, t := range c.types {
+		if t == conditionType {
+			return true
+		}
+	}
+	return false
+}
+
+// Field returns the virtual field indexing a field of a condition, if field refers to one as
+// status.conditions[{type}].{field}, or field itself otherwise
+func Field(field []string) []string {
+	if len(field) != 3 || field[0] != "status" || !strings.HasPrefix(field[1], "conditions[") {
+		return field
+	}
+	return []string{"metadata", field[1], field[2]}
+}
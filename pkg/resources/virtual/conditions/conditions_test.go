@@ -0,0 +1,86 @@
+package conditions_test
+
+import (
+	"testing"
+
+	"github.com/rancher/steve/pkg/resources/virtual/conditions"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestNew(t *testing.T) {
+	_, err := conditions.New(conditions.DefaultTypes)
+	assert.NoError(t, err)
+	_, err = conditions.New([]string{"Ready", "MemoryPressure"})
+	assert.NoError(t, err)
+	_, err = conditions.New([]string{"cattle.io/ready"})
+	assert.Error(t, err)
+	_, err = conditions.New([]string{"Ready", "Ready"})
+	assert.Error(t, err)
+}
+
+func TestFields(t *testing.T) {
+	c, err := conditions.New([]string{"Ready", "Available"})
+	require.NoError(t, err)
+	assert.Equal(t, [][]string{
+		{"metadata", "conditions[Ready]", "status"},
+		{"metadata", "conditions[Ready]", "reason"},
+		{"metadata", "conditions[Ready]", "lastTransitionTime"},
+		{"metadata", "conditions[Available]", "status"},
+		{"metadata", "conditions[Available]", "reason"},
+		{"metadata", "conditions[Available]", "lastTransitionTime"},
+	}, c.Fields())
+
+	var nilConditions *conditions.Conditions
+	assert.Nil(t, nilConditions.Fields())
+}
+
+func TestTransformCommon(t *testing.T) {
+	c, err := conditions.New([]string{"Ready", "Available"})
+	require.NoError(t, err)
+	tests := []struct {
+		name     string
+		obj      map[string]interface{}
+		expected interface{}
+	}{
+		{
+			name: "conditions of indexed types are copied",
+			obj: map[string]interface{}{
+				"status": map[string]interface{}{
+					"conditions": []interface{}{
+						map[string]interface{}{"type": "Ready", "status": "False", "reason": "KubeletNotReady", "lastTransitionTime": "2024-01-02T03:04:05Z", "message": "down"},
+						map[string]interface{}{"type": "MemoryPressure", "status": "False"},
+						map[string]interface{}{"type": "Available", "status": "True"},
+						"invalid",
+					},
+				},
+			},
+			expected: map[string]interface{}{
+				"Ready":     map[string]interface{}{"status": "False", "reason": "KubeletNotReady", "lastTransitionTime": "2024-01-02T03:04:05Z"},
+				"Available": map[string]interface{}{"status": "True"},
+			},
+		},
+		{
+			name: "objects without conditions are left alone",
+			obj: map[string]interface{}{
+				"spec": map[string]interface{}{},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			obj, err := c.TransformCommon(&unstructured.Unstructured{Object: test.obj})
+			require.NoError(t, err)
+			value, _, _ := unstructured.NestedFieldNoCopy(obj.Object, "metadata", "conditions")
+			assert.Equal(t, test.expected, value)
+		})
+	}
+}
+
+func TestField(t *testing.T) {
+	assert.Equal(t, []string{"metadata", "conditions[Ready]", "status"}, conditions.Field([]string{"status", "conditions[Ready]", "status"}))
+	assert.Equal(t, []string{"status", "conditions"}, conditions.Field([]string{"status", "conditions"}))
+	assert.Equal(t, []string{"spec", "conditions[Ready]", "status"}, conditions.Field([]string{"spec", "conditions[Ready]", "status"}))
+	assert.Equal(t, []string{"metadata", "name"}, conditions.Field([]string{"metadata", "name"}))
+}
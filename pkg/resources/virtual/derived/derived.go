@@ -0,0 +1,202 @@
+// Package derived provides a registry of rules deriving virtual fields, such as metadata.state, from the objects of
+// specific kinds, so that embedders can add their own fields or override the fields derived by steve, for instance to
+// apply the health rules of a CRD. Derived fields are indexed by the SQL cache and set on the objects served by steve.
+package derived
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"github.com/rancher/wrangler/v3/pkg/summary"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// fieldNameRegex only allows letters, as those are the only characters accepted in field names by the SQL cache
+var fieldNameRegex = regexp.MustCompile(`^[a-zA-Z]+$`)
+
+// Func sets the fields it derives from an object on the object
+type Func func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error)
+
+// Rule derives virtual fields of the objects of a kind. Rules run after the virtual fields of steve are set, so that
+// they can override them.
+type Rule struct {
+	Group   string
+	Version string
+	Kind    string
+	// Name identifies the rule among the rules of its kind: registering a rule with the same name replaces it
+	Name string
+	// Fields are the paths of the derived fields to index, such as status.health, so that lists can filter and sort on
+	// them. The fields of metadata.state are always indexed
+	Fields [][]string
+	Derive Func
+}
+
+// GVK returns the GroupVersionKind the rule applies to
+func (r Rule) GVK() k8sschema.GroupVersionKind {
+	return k8sschema.GroupVersionKind{Group: r.Group, Version: r.Version, Kind: r.Kind}
+}
+
+// Validate returns an error if the rule cannot be applied or its fields cannot be indexed
+func (r Rule) Validate() error {
+	if r.Version == "" || r.Kind == "" {
+		return fmt.Errorf("derived field rule [%s] requires a version and a kind", r.Name)
+	}
+	if r.Name == "" {
+		return fmt.Errorf("derived field rule of %s requires a name", r.GVK())
+	}
+	if r.Derive == nil {
+		return fmt.Errorf("derived field rule [%s] has no func", r.Name)
+	}
+	for _, field := range r.Fields {
+		if len(field) == 0 {
+			return fmt.Errorf("derived field rule [%s] has an empty field", r.Name)
+		}
+		for _, name := range field {
+			if !fieldNameRegex.MatchString(name) {
+				return fmt.Errorf("field [%s] of derived field rule [%s] must only contain letters", strings.Join(field, "."), r.Name)
+			}
+		}
+	}
+	return nil
+}
+
+// Registry holds the rules deriving virtual fields by GVK. Rules must be registered before the server starts, since
+// the fields of the caches created before aren't indexed.
+type Registry struct {
+	lock  sync.RWMutex
+	byGVK map[k8sschema.GroupVersionKind][]Rule
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{
+		byGVK: map[k8sschema.GroupVersionKind][]Rule{},
+	}
+}
+
+// Register validates and adds rules, replacing the rules of the same kind with the same names
+func (r *Registry) Register(rules ...Rule) error {
+	for _, rule := range rules {
+		if err := rule.Validate(); err != nil {
+			return err
+		}
+	}
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	for _, rule := range rules {
+		gvk := rule.GVK()
+		replaced := false
+		for i, existing := range r.byGVK[gvk] {
+			if existing.Name == rule.Name {
+				r.byGVK[gvk][i] = rule
+				replaced = true
+				break
+			}
+		}
+		if !replaced {
+			r.byGVK[gvk] = append(r.byGVK[gvk], rule)
+		}
+	}
+	return nil
+}
+
+func (r *Registry) rules(gvk k8sschema.GroupVersionKind) []Rule {
+	if r == nil {
+		return nil
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	return append([]Rule(nil), r.byGVK[gvk]...)
+}
+
+// Fields returns the derived fields which need to be indexed for the given GVK
+func (r *Registry) Fields(gvk k8sschema.GroupVersionKind) [][]string {
+	var fields [][]string
+	for _, rule := range r.rules(gvk) {
+		fields = append(fields, rule.Fields...)
+	}
+	return fields
+}
+
+// TransformFunc returns a func which applies the rules of the GVK, or nil if it has none
+func (r *Registry) TransformFunc(gvk k8sschema.GroupVersionKind) func(*unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	rules := r.rules(gvk)
+	if len(rules) == 0 {
+		return nil
+	}
+	return func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		var err error
+		for _, rule := range rules {
+			if obj, err = rule.Derive(obj); err != nil {
+				return nil, fmt.Errorf("derived field rule [%s]: %w", rule.Name, err)
+			}
+		}
+		return obj, nil
+	}
+}
+
+// Templates returns schema templates whose formatter applies the rules of their kind to the objects served by steve,
+// after the default formatter which sets the virtual fields of steve on objects read outside of the SQL cache
+func Templates(r *Registry) []schema.Template {
+	if r == nil {
+		return nil
+	}
+	r.lock.RLock()
+	defer r.lock.RUnlock()
+	kinds := map[k8sschema.GroupKind]bool{}
+	var templates []schema.Template
+	for gvk := range r.byGVK {
+		if kinds[gvk.GroupKind()] {
+			continue
+		}
+		kinds[gvk.GroupKind()] = true
+		templates = append(templates, schema.Template{
+			Group: gvk.Group,
+			Kind:  gvk.Kind,
+			Formatter: func(_ *types.APIRequest, resource *types.RawResource) {
+				unstr, ok := resource.APIObject.Object.(*unstructured.Unstructured)
+				if !ok {
+					return
+				}
+				transform := r.TransformFunc(unstr.GroupVersionKind())
+				if transform == nil {
+					return
+				}
+				derived, err := transform(unstr)
+				if err != nil {
+					logrus.Errorf("failed to derive the fields of %s %s: %v", unstr.GetKind(), unstr.GetName(), err)
+					return
+				}
+				resource.APIObject.Object = derived
+			},
+		})
+	}
+	return templates
+}
+
+// StateFunc computes the state of an object, returning false to leave the state derived by steve
+type StateFunc func(obj *unstructured.Unstructured) (summary.Summary, bool)
+
+// State returns a Func overriding the metadata.state fields of objects with the state computed by f
+func State(f StateFunc) Func {
+	return func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		s, ok := f(obj)
+		if !ok {
+			return obj, nil
+		}
+		data.PutValue(obj.Object, map[string]interface{}{
+			"name":          s.State,
+			"error":         s.Error,
+			"transitioning": s.Transitioning,
+			"message":       strings.Join(s.Message, ":"),
+		}, "metadata", "state")
+		return obj, nil
+	}
+}
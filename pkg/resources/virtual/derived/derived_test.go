@@ -0,0 +1,151 @@
+package derived_test
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/resources/virtual/derived"
+	"github.com/rancher/wrangler/v3/pkg/summary"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var widgetGVK = schema.GroupVersionKind{Group: "example.io", Version: "v1", Kind: "Widget"}
+
+func newWidget(healthy bool) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.io/v1",
+		"kind":       "Widget",
+		"metadata": map[string]interface{}{
+			"name": "widget",
+			"state": map[string]interface{}{
+				"name": "active",
+			},
+		},
+		"status": map[string]interface{}{
+			"healthy": healthy,
+		},
+	}}
+}
+
+// health derives the state of widgets from status.healthy
+var health = derived.State(func(obj *unstructured.Unstructured) (summary.Summary, bool) {
+	healthy, ok, _ := unstructured.NestedBool(obj.Object, "status", "healthy")
+	if !ok || healthy {
+		return summary.Summary{}, false
+	}
+	return summary.Summary{State: "unhealthy", Error: true, Message: []string{"widget", "is unhealthy"}}, true
+})
+
+func setField(name, value string) derived.Func {
+	return func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		return obj, unstructured.SetNestedField(obj.Object, value, "status", name)
+	}
+}
+
+func TestRegister(t *testing.T) {
+	tests := []struct {
+		name      string
+		rules     []derived.Rule
+		wantError bool
+	}{
+		{
+			name: "valid rules",
+			rules: []derived.Rule{
+				{Group: "example.io", Version: "v1", Kind: "Widget", Name: "health", Derive: health},
+				{Group: "example.io", Version: "v1", Kind: "Widget", Name: "tier", Fields: [][]string{{"status", "tier"}}, Derive: setField("tier", "gold")},
+			},
+		},
+		{
+			name:      "missing kind",
+			rules:     []derived.Rule{{Group: "example.io", Version: "v1", Name: "health", Derive: health}},
+			wantError: true,
+		},
+		{
+			name:      "missing name",
+			rules:     []derived.Rule{{Group: "example.io", Version: "v1", Kind: "Widget", Derive: health}},
+			wantError: true,
+		},
+		{
+			name:      "missing func",
+			rules:     []derived.Rule{{Group: "example.io", Version: "v1", Kind: "Widget", Name: "health"}},
+			wantError: true,
+		},
+		{
+			name:      "field with non-letters",
+			rules:     []derived.Rule{{Group: "example.io", Version: "v1", Kind: "Widget", Name: "tier", Fields: [][]string{{"status", "tier-name"}}, Derive: health}},
+			wantError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := derived.NewRegistry().Register(test.rules...)
+			if test.wantError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTransformFunc(t *testing.T) {
+	registry := derived.NewRegistry()
+	require.NoError(t, registry.Register(
+		derived.Rule{Group: "example.io", Version: "v1", Kind: "Widget", Name: "health", Derive: health},
+		derived.Rule{Group: "example.io", Version: "v1", Kind: "Widget", Name: "tier", Fields: [][]string{{"status", "tier"}}, Derive: setField("tier", "silver")},
+	))
+	// rules with the same name replace those registered before
+	require.NoError(t, registry.Register(derived.Rule{Group: "example.io", Version: "v1", Kind: "Widget", Name: "tier", Fields: [][]string{{"status", "tier"}}, Derive: setField("tier", "gold")}))
+
+	assert.Equal(t, [][]string{{"status", "tier"}}, registry.Fields(widgetGVK))
+	assert.Nil(t, registry.Fields(schema.GroupVersionKind{Version: "v1", Kind: "Pod"}))
+	assert.Nil(t, registry.TransformFunc(schema.GroupVersionKind{Group: "example.io", Version: "v2", Kind: "Widget"}), "rules apply to a version")
+	var nilRegistry *derived.Registry
+	assert.Nil(t, nilRegistry.TransformFunc(widgetGVK))
+
+	transform := registry.TransformFunc(widgetGVK)
+	require.NotNil(t, transform)
+	obj, err := transform(newWidget(false))
+	require.NoError(t, err)
+	state, _, _ := unstructured.NestedMap(obj.Object, "metadata", "state")
+	assert.Equal(t, map[string]interface{}{
+		"name":          "unhealthy",
+		"error":         true,
+		"transitioning": false,
+		"message":       "widget:is unhealthy",
+	}, state)
+	tier, _, _ := unstructured.NestedString(obj.Object, "status", "tier")
+	assert.Equal(t, "gold", tier)
+
+	obj, err = transform(newWidget(true))
+	require.NoError(t, err)
+	name, _, _ := unstructured.NestedString(obj.Object, "metadata", "state", "name")
+	assert.Equal(t, "active", name, "the state of steve is kept when the state func has none")
+
+	require.NoError(t, registry.Register(derived.Rule{Group: "example.io", Version: "v1", Kind: "Widget", Name: "broken", Derive: func(*unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		return nil, fmt.Errorf("broken")
+	}}))
+	_, err = registry.TransformFunc(widgetGVK)(newWidget(true))
+	assert.Error(t, err)
+}
+
+func TestTemplates(t *testing.T) {
+	registry := derived.NewRegistry()
+	require.NoError(t, registry.Register(
+		derived.Rule{Group: "example.io", Version: "v1", Kind: "Widget", Name: "health", Derive: health},
+		derived.Rule{Group: "example.io", Version: "v2", Kind: "Widget", Name: "health", Derive: health},
+	))
+	templates := derived.Templates(registry)
+	require.Len(t, templates, 1, "templates are by group and kind")
+	assert.Equal(t, "example.io", templates[0].Group)
+	assert.Equal(t, "Widget", templates[0].Kind)
+
+	resource := &types.RawResource{APIObject: types.APIObject{Object: newWidget(false)}}
+	templates[0].Formatter(&types.APIRequest{}, resource)
+	name, _, _ := unstructured.NestedString(resource.APIObject.Object.(*unstructured.Unstructured).Object, "metadata", "state", "name")
+	assert.Equal(t, "unhealthy", name)
+}
@@ -0,0 +1,76 @@
+// Package identity provides a cache.TransformFunc which indexes the application identities of objects of all types,
+// such as the Helm release or the inventory they were applied with, so that the objects of an application can be
+// listed across types
+package identity
+
+import (
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Source is a label or annotation identifying the application an object belongs to
+type Source struct {
+	// Name is the name of the virtual field holding the identity, under metadata.application
+	Name       string
+	Label      string
+	Annotation string
+}
+
+// Path returns the path of the virtual field holding the identity of the source
+func (s Source) Path() []string {
+	return []string{"metadata", "application", s.Name}
+}
+
+var (
+	// Sources are the sources of identities indexed for all types. Fleet deploys bundles as Helm releases, so that the
+	// objects of a bundle deployment share its Helm release.
+	Sources = []Source{
+		{Name: "instance", Label: "app.kubernetes.io/instance"},
+		{Name: "partOf", Label: "app.kubernetes.io/part-of"},
+		{Name: "helmRelease", Annotation: "meta.helm.sh/release-name"},
+		// the inventory object of kpt and cli-utils, which kustomizations are applied with by those tools
+		{Name: "inventory", Annotation: "config.k8s.io/owning-inventory"},
+	}
+
+	// Fields are the fields which need to be indexed for all types
+	Fields = fields()
+)
+
+func fields() [][]string {
+	result := make([][]string, 0, len(Sources))
+	for _, source := range Sources {
+		result = append(result, source.Path())
+	}
+	return result
+}
+
+// Lookup returns the source of the given name
+func Lookup(name string) (Source, bool) {
+	for _, source := range Sources {
+		if source.Name == name {
+			return source, true
+		}
+	}
+	return Source{}, false
+}
+
+// TransformCommon copies the identities of an object into their virtual fields
+func TransformCommon(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	identities := map[string]interface{}{}
+	for _, source := range Sources {
+		var value string
+		if source.Label != "" {
+			value = obj.GetLabels()[source.Label]
+		} else {
+			value = obj.GetAnnotations()[source.Annotation]
+		}
+		if value != "" {
+			identities[source.Name] = value
+		}
+	}
+	if len(identities) > 0 {
+		if err := unstructured.SetNestedMap(obj.Object, identities, "metadata", "application"); err != nil {
+			return nil, err
+		}
+	}
+	return obj, nil
+}
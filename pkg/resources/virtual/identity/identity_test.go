@@ -0,0 +1,46 @@
+package identity_test
+
+import (
+	"testing"
+
+	"github.com/rancher/steve/pkg/resources/virtual/identity"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTransformCommon(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "web",
+			"labels": map[string]interface{}{
+				"app.kubernetes.io/instance": "shop",
+			},
+			"annotations": map[string]interface{}{
+				"meta.helm.sh/release-name":      "shop-release",
+				"config.k8s.io/owning-inventory": "",
+			},
+		},
+	}}
+	obj, err := identity.TransformCommon(obj)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{
+		"instance":    "shop",
+		"helmRelease": "shop-release",
+	}, obj.Object["metadata"].(map[string]interface{})["application"])
+
+	bare := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "bare"},
+	}}
+	bare, err = identity.TransformCommon(bare)
+	require.NoError(t, err)
+	assert.NotContains(t, bare.Object["metadata"], "application")
+}
+
+func TestLookup(t *testing.T) {
+	source, ok := identity.Lookup("inventory")
+	assert.True(t, ok)
+	assert.Equal(t, []string{"metadata", "application", "inventory"}, source.Path())
+	_, ok = identity.Lookup("unknown")
+	assert.False(t, ok)
+}
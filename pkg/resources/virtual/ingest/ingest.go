@@ -0,0 +1,137 @@
+// Package ingest provides transformers applied to objects as they are stored in the SQL cache, such as stripping their
+// managed fields, so that embedders can reduce the size of the database and the cost of serializing listed objects.
+// Transformers run after the virtual fields are set, so that those can still be derived from the fields stripped.
+package ingest
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// LastAppliedAnnotation is the annotation kubectl apply keeps the last applied configuration of objects in
+const LastAppliedAnnotation = "kubectl.kubernetes.io/last-applied-configuration"
+
+// Func transforms an object before it is stored
+type Func func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error)
+
+// Transformer transforms the objects of a kind, or of all kinds if Kind is empty, before they are stored in the SQL
+// cache. Lists served from the cache return the transformed objects, while objects got by ID are read from Kubernetes.
+type Transformer struct {
+	Group   string
+	Version string
+	Kind    string
+	// Name identifies the transformer in errors
+	Name      string
+	Transform Func
+}
+
+// GVK returns the GroupVersionKind the transformer applies to
+func (t Transformer) GVK() k8sschema.GroupVersionKind {
+	return k8sschema.GroupVersionKind{Group: t.Group, Version: t.Version, Kind: t.Kind}
+}
+
+// Validate returns an error if the transformer cannot be applied
+func (t Transformer) Validate() error {
+	if t.Name == "" {
+		return fmt.Errorf("ingest transformer of %s requires a name", t.GVK())
+	}
+	if t.Transform == nil {
+		return fmt.Errorf("ingest transformer [%s] has no func", t.Name)
+	}
+	if t.Kind == "" && (t.Group != "" || t.Version != "") {
+		return fmt.Errorf("ingest transformer [%s] requires a kind with a group or version", t.Name)
+	}
+	if t.Kind != "" && t.Version == "" {
+		return fmt.Errorf("ingest transformer [%s] requires a version", t.Name)
+	}
+	return nil
+}
+
+// Transformers are the transformers applied to the objects stored in the SQL cache, by GVK
+type Transformers struct {
+	all   []Transformer
+	byGVK map[k8sschema.GroupVersionKind][]Transformer
+}
+
+// New validates transformers, which are applied in order, those of all kinds first
+func New(transformers ...Transformer) (*Transformers, error) {
+	result := &Transformers{
+		byGVK: map[k8sschema.GroupVersionKind][]Transformer{},
+	}
+	for _, transformer := range transformers {
+		if err := transformer.Validate(); err != nil {
+			return nil, err
+		}
+		if transformer.Kind == "" {
+			result.all = append(result.all, transformer)
+		} else {
+			result.byGVK[transformer.GVK()] = append(result.byGVK[transformer.GVK()], transformer)
+		}
+	}
+	return result, nil
+}
+
+// TransformFunc returns a func which applies the transformers of the GVK, or nil if it has none
+func (t *Transformers) TransformFunc(gvk k8sschema.GroupVersionKind) func(*unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	if t == nil {
+		return nil
+	}
+	transformers := append(append([]Transformer(nil), t.all...), t.byGVK[gvk]...)
+	if len(transformers) == 0 {
+		return nil
+	}
+	return func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		var err error
+		for _, transformer := range transformers {
+			if obj, err = transformer.Transform(obj); err != nil {
+				return nil, fmt.Errorf("ingest transformer [%s]: %w", transformer.Name, err)
+			}
+		}
+		return obj, nil
+	}
+}
+
+// StripManagedFields removes the managed fields of objects of all kinds, which are often most of their size
+var StripManagedFields = Transformer{
+	Name: "stripManagedFields",
+	Transform: func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "managedFields")
+		return obj, nil
+	},
+}
+
+// StripLastApplied removes the last applied configuration annotation of objects of all kinds, a copy of the object
+// as it was applied by kubectl
+var StripLastApplied = Transformer{
+	Name: "stripLastApplied",
+	Transform: func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		unstructured.RemoveNestedField(obj.Object, "metadata", "annotations", LastAppliedAnnotation)
+		if len(obj.GetAnnotations()) == 0 {
+			unstructured.RemoveNestedField(obj.Object, "metadata", "annotations")
+		}
+		return obj, nil
+	},
+}
+
+// DropLargeField returns a Func removing the field at path, such as status.resources, when its JSON encoding is larger
+// than maxSize bytes
+func DropLargeField(path []string, maxSize int) Func {
+	return func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		value, ok, err := unstructured.NestedFieldNoCopy(obj.Object, path...)
+		if err != nil || !ok {
+			return obj, nil
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return nil, fmt.Errorf("encoding field [%s]: %w", strings.Join(path, "."), err)
+		}
+		if len(encoded) > maxSize {
+			unstructured.RemoveNestedField(obj.Object, path...)
+		}
+		return obj, nil
+	}
+}
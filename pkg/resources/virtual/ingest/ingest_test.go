@@ -0,0 +1,99 @@
+package ingest
+
+import (
+	"errors"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+var (
+	podGVK        = k8sschema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	deploymentGVK = k8sschema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+)
+
+func newPod() *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Pod")
+	obj.SetName("pod")
+	obj.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl"}})
+	obj.SetAnnotations(map[string]string{LastAppliedAnnotation: `{"kind":"Pod"}`})
+	_ = unstructured.SetNestedField(obj.Object, strings.Repeat("x", 100), "status", "message")
+	return obj
+}
+
+func TestValidate(t *testing.T) {
+	transform := func(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) { return obj, nil }
+	tests := []struct {
+		name        string
+		transformer Transformer
+		wantErr     bool
+	}{
+		{name: "all kinds", transformer: Transformer{Name: "all", Transform: transform}},
+		{name: "kind", transformer: Transformer{Version: "v1", Kind: "Pod", Name: "pods", Transform: transform}},
+		{name: "no name", transformer: Transformer{Transform: transform}, wantErr: true},
+		{name: "no func", transformer: Transformer{Name: "none"}, wantErr: true},
+		{name: "group without kind", transformer: Transformer{Group: "apps", Name: "apps", Transform: transform}, wantErr: true},
+		{name: "kind without version", transformer: Transformer{Kind: "Pod", Name: "pods", Transform: transform}, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := New(test.transformer)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestTransformFunc(t *testing.T) {
+	transformers, err := New(
+		StripManagedFields,
+		StripLastApplied,
+		Transformer{Version: "v1", Kind: "Pod", Name: "dropStatusMessage", Transform: DropLargeField([]string{"status", "message"}, 50)},
+	)
+	require.NoError(t, err)
+
+	obj, err := transformers.TransformFunc(podGVK)(newPod())
+	require.NoError(t, err)
+	assert.Empty(t, obj.GetManagedFields())
+	assert.Nil(t, obj.GetAnnotations())
+	_, found, _ := unstructured.NestedString(obj.Object, "status", "message")
+	assert.False(t, found)
+
+	// the transformers of other kinds don't apply
+	obj, err = transformers.TransformFunc(deploymentGVK)(newPod())
+	require.NoError(t, err)
+	assert.Empty(t, obj.GetManagedFields())
+	_, found, _ = unstructured.NestedString(obj.Object, "status", "message")
+	assert.True(t, found)
+
+	var none *Transformers
+	assert.Nil(t, none.TransformFunc(podGVK))
+	transformers, err = New(Transformer{Version: "v1", Kind: "Pod", Name: "failing", Transform: func(*unstructured.Unstructured) (*unstructured.Unstructured, error) {
+		return nil, errors.New("failed")
+	}})
+	require.NoError(t, err)
+	assert.Nil(t, transformers.TransformFunc(deploymentGVK))
+	_, err = transformers.TransformFunc(podGVK)(newPod())
+	assert.ErrorContains(t, err, "ingest transformer [failing]: failed")
+}
+
+func TestDropLargeField(t *testing.T) {
+	obj, err := DropLargeField([]string{"status", "message"}, 200)(newPod())
+	require.NoError(t, err)
+	_, found, _ := unstructured.NestedString(obj.Object, "status", "message")
+	assert.True(t, found)
+
+	obj, err = DropLargeField([]string{"status", "missing"}, 0)(newPod())
+	require.NoError(t, err)
+	assert.Equal(t, newPod(), obj)
+}
@@ -0,0 +1,103 @@
+// Package owners provides a cache.TransformFunc which indexes the owner references of objects of all types, so that
+// lists can return the objects owned by another object
+package owners
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// separator delimits the values of the virtual fields, which also start and end with it so that each value can be
+// matched exactly with a partial match
+const separator = "|"
+
+var (
+	// UIDsField is the virtual field indexing the UIDs of the owners of an object
+	UIDsField = []string{"metadata", "owners", "uids"}
+	// NamesField is the virtual field indexing the kinds and names of the owners of an object, as {kind}/{name}
+	NamesField = []string{"metadata", "owners", "names"}
+	// Fields are the fields which need to be indexed for all types
+	Fields = [][]string{UIDsField, NamesField}
+
+	ownerReferenceUIDField = []string{"metadata", "ownerReferences", "uid"}
+	namespaceField         = []string{"metadata", "namespace"}
+)
+
+// TransformCommon copies the UIDs, kinds and names of the owners of an object into their virtual fields
+func TransformCommon(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	refs := obj.GetOwnerReferences()
+	if len(refs) == 0 {
+		return obj, nil
+	}
+	uids := make([]string, 0, len(refs))
+	names := make([]string, 0, len(refs))
+	for _, ref := range refs {
+		uids = append(uids, string(ref.UID))
+		names = append(names, ref.Kind+"/"+ref.Name)
+	}
+	data.PutValue(obj.Object, join(uids), UIDsField...)
+	data.PutValue(obj.Object, join(names), NamesField...)
+	return obj, nil
+}
+
+func join(values []string) string {
+	return separator + strings.Join(values, separator) + separator
+}
+
+// Filter rewrites a filter on metadata.ownerReferences.uid into a filter on the virtual field indexing the UIDs of all
+// owners, keeping exact matches exact. Other filters are returned as they are.
+func Filter(filter informer.Filter) informer.Filter {
+	if !slices.Equal(filter.Field, ownerReferenceUIDField) {
+		return filter
+	}
+	filter.Field = UIDsField
+	if !filter.Partial {
+		filter.Match = separator + filter.Match + separator
+		filter.Partial = true
+	}
+	return filter
+}
+
+// OwnedBy returns the filters matching the objects owned by the object identified by ownedBy, as
+// {kind}/{namespace}/{name}, or {kind}/{name} for owners which aren't namespaced
+func OwnedBy(ownedBy string) ([]informer.OrFilter, error) {
+	parts := strings.Split(ownedBy, "/")
+	for _, part := range parts {
+		if part == "" || strings.Contains(part, separator) {
+			parts = nil
+			break
+		}
+	}
+	var kind, namespace, name string
+	switch len(parts) {
+	case 2:
+		kind, name = parts[0], parts[1]
+	case 3:
+		kind, namespace, name = parts[0], parts[1], parts[2]
+	default:
+		return nil, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("owner [%s] must be {kind}/{namespace}/{name} or {kind}/{name}", ownedBy))
+	}
+
+	filters := []informer.OrFilter{{Filters: []informer.Filter{{
+		Field:   NamesField,
+		Match:   separator + kind + "/" + name + separator,
+		Op:      informer.Eq,
+		Partial: true,
+	}}}}
+	if namespace != "" {
+		// owner references can only refer to owners in the same namespace
+		filters = append(filters, informer.OrFilter{Filters: []informer.Filter{{
+			Field: namespaceField,
+			Match: namespace,
+			Op:    informer.Eq,
+		}}})
+	}
+	return filters, nil
+}
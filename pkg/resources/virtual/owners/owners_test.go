@@ -0,0 +1,86 @@
+package owners_test
+
+import (
+	"testing"
+
+	"github.com/rancher/steve/pkg/resources/virtual/owners"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTransformCommon(t *testing.T) {
+	obj := &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"name": "web-abc-123",
+			"ownerReferences": []interface{}{
+				map[string]interface{}{"apiVersion": "apps/v1", "kind": "ReplicaSet", "name": "web-abc", "uid": "1234"},
+				map[string]interface{}{"apiVersion": "v1", "kind": "Node", "name": "node1", "uid": "5678"},
+			},
+		},
+	}}
+	obj, err := owners.TransformCommon(obj)
+	require.NoError(t, err)
+	uids, _, _ := unstructured.NestedString(obj.Object, owners.UIDsField...)
+	assert.Equal(t, "|1234|5678|", uids)
+	names, _, _ := unstructured.NestedString(obj.Object, owners.NamesField...)
+	assert.Equal(t, "|ReplicaSet/web-abc|Node/node1|", names)
+
+	obj = &unstructured.Unstructured{Object: map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "orphan"},
+	}}
+	obj, err = owners.TransformCommon(obj)
+	require.NoError(t, err)
+	_, found, _ := unstructured.NestedFieldNoCopy(obj.Object, "metadata", "owners")
+	assert.False(t, found)
+}
+
+func TestFilter(t *testing.T) {
+	tests := []struct {
+		name     string
+		filter   informer.Filter
+		expected informer.Filter
+	}{
+		{
+			name:     "exact owner uid",
+			filter:   informer.Filter{Field: []string{"metadata", "ownerReferences", "uid"}, Match: "1234", Op: informer.Eq},
+			expected: informer.Filter{Field: owners.UIDsField, Match: "|1234|", Op: informer.Eq, Partial: true},
+		},
+		{
+			name:     "partial owner uid",
+			filter:   informer.Filter{Field: []string{"metadata", "ownerReferences", "uid"}, Match: "12", Op: informer.NotEq, Partial: true},
+			expected: informer.Filter{Field: owners.UIDsField, Match: "12", Op: informer.NotEq, Partial: true},
+		},
+		{
+			name:     "other field",
+			filter:   informer.Filter{Field: []string{"metadata", "name"}, Match: "web"},
+			expected: informer.Filter{Field: []string{"metadata", "name"}, Match: "web"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, owners.Filter(test.filter))
+		})
+	}
+}
+
+func TestOwnedBy(t *testing.T) {
+	filters, err := owners.OwnedBy("Deployment/default/web")
+	require.NoError(t, err)
+	assert.Equal(t, []informer.OrFilter{
+		{Filters: []informer.Filter{{Field: owners.NamesField, Match: "|Deployment/web|", Op: informer.Eq, Partial: true}}},
+		{Filters: []informer.Filter{{Field: []string{"metadata", "namespace"}, Match: "default", Op: informer.Eq}}},
+	}, filters)
+
+	filters, err = owners.OwnedBy("Node/node1")
+	require.NoError(t, err)
+	assert.Equal(t, []informer.OrFilter{
+		{Filters: []informer.Filter{{Field: owners.NamesField, Match: "|Node/node1|", Op: informer.Eq, Partial: true}}},
+	}, filters)
+
+	for _, invalid := range []string{"web", "Deployment/default/web/extra", "Deployment//web", "Deployment/a|b"} {
+		_, err = owners.OwnedBy(invalid)
+		assert.Error(t, err, invalid)
+	}
+}
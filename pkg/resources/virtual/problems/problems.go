@@ -0,0 +1,52 @@
+// Package problems provides cache.TransformFunc's which roll up the conditions of objects of all types which are in
+// error or warning into indexed counts, so that lists can be sorted by the most problematic objects first and filtered
+// to unhealthy objects
+package problems
+
+import (
+	"strconv"
+
+	"github.com/rancher/wrangler/v3/pkg/data"
+	wranglerSummary "github.com/rancher/wrangler/v3/pkg/summary"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	// ErrorsField counts the conditions of an object in error
+	ErrorsField = []string{"metadata", "problems", "errors"}
+	// WarningsField counts the conditions of an object which are transitioning, without being in error
+	WarningsField = []string{"metadata", "problems", "warnings"}
+	// EventsField counts the warning events involving an object. Events aren't part of the object, so that the field
+	// isn't indexed but set on listed objects
+	EventsField = []string{"metadata", "problems", "events"}
+
+	// Fields are the fields which need to be indexed for all types. Counts are strings, since the SQL cache stores
+	// all columns as text, and are sorted by value.
+	Fields = [][]string{ErrorsField, WarningsField}
+)
+
+// TransformCommon counts the conditions in error and in warning of objects, as judged by the condition summarizers of
+// wrangler which also set the error and transitioning fields of conditions served by steve
+func TransformCommon(obj *unstructured.Unstructured) (*unstructured.Unstructured, error) {
+	errors, warnings := Count(obj.Object)
+	data.PutValue(obj.Object, strconv.Itoa(errors), ErrorsField...)
+	data.PutValue(obj.Object, strconv.Itoa(warnings), WarningsField...)
+	return obj, nil
+}
+
+// Count returns the numbers of conditions of an object in error and in warning
+func Count(obj data.Object) (errors int, warnings int) {
+	for _, condition := range obj.Slice("status", "conditions") {
+		var summary wranglerSummary.Summary
+		for _, summarizer := range wranglerSummary.ConditionSummarizers {
+			summary = summarizer(obj, []wranglerSummary.Condition{{Object: condition}}, summary)
+		}
+		switch {
+		case summary.Error:
+			errors++
+		case summary.Transitioning:
+			warnings++
+		}
+	}
+	return errors, warnings
+}
@@ -0,0 +1,65 @@
+package problems_test
+
+import (
+	"testing"
+
+	"github.com/rancher/steve/pkg/resources/virtual/problems"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestTransformCommon(t *testing.T) {
+	tests := []struct {
+		name         string
+		conditions   []interface{}
+		wantErrors   string
+		wantWarnings string
+	}{
+		{
+			name:         "no conditions",
+			wantErrors:   "0",
+			wantWarnings: "0",
+		},
+		{
+			name: "healthy conditions",
+			conditions: []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "True"},
+				map[string]interface{}{"type": "Available", "status": "True"},
+			},
+			wantErrors:   "0",
+			wantWarnings: "0",
+		},
+		{
+			name: "conditions in error and in warning",
+			conditions: []interface{}{
+				map[string]interface{}{"type": "Ready", "status": "False", "reason": "Error", "message": "failed"},
+				map[string]interface{}{"type": "Stalled", "status": "True", "message": "stalled"},
+				map[string]interface{}{"type": "Available", "status": "False", "message": "updating"},
+				map[string]interface{}{"type": "Reconciling", "status": "True", "message": "reconciling"},
+			},
+			wantErrors:   "2",
+			wantWarnings: "2",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			obj := &unstructured.Unstructured{Object: map[string]interface{}{
+				"apiVersion": "apps/v1",
+				"kind":       "Deployment",
+				"metadata": map[string]interface{}{
+					"name": "web",
+				},
+			}}
+			if test.conditions != nil {
+				obj.Object["status"] = map[string]interface{}{"conditions": test.conditions}
+			}
+			obj, err := problems.TransformCommon(obj)
+			require.NoError(t, err)
+			errors, _, _ := unstructured.NestedString(obj.Object, problems.ErrorsField...)
+			warnings, _, _ := unstructured.NestedString(obj.Object, problems.WarningsField...)
+			assert.Equal(t, test.wantErrors, errors)
+			assert.Equal(t, test.wantWarnings, warnings)
+		})
+	}
+}
@@ -5,8 +5,15 @@ package virtual
 import (
 	"fmt"
 
+	"github.com/rancher/steve/pkg/resources/virtual/annotations"
 	"github.com/rancher/steve/pkg/resources/virtual/common"
+	"github.com/rancher/steve/pkg/resources/virtual/computed"
+	"github.com/rancher/steve/pkg/resources/virtual/conditions"
+	"github.com/rancher/steve/pkg/resources/virtual/derived"
 	"github.com/rancher/steve/pkg/resources/virtual/events"
+	"github.com/rancher/steve/pkg/resources/virtual/identity"
+	"github.com/rancher/steve/pkg/resources/virtual/owners"
+	"github.com/rancher/steve/pkg/resources/virtual/problems"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/tools/cache"
@@ -14,15 +21,24 @@ import (
 
 // TransformBuilder builds transform functions for specified GVKs through GetTransformFunc
 type TransformBuilder struct {
-	defaultFields *common.DefaultFields
+	defaultFields     *common.DefaultFields
+	annotationColumns *annotations.Columns
+	computedFields    *computed.Fields
+	conditions        *conditions.Conditions
+	derivedFields     *derived.Registry
 }
 
-// NewTransformBuilder returns a TransformBuilder using the given summary cache
-func NewTransformBuilder(cache common.SummaryCache) *TransformBuilder {
+// NewTransformBuilder returns a TransformBuilder using the given summary cache and, optionally, annotation columns,
+// computed fields, indexed conditions and rules deriving fields
+func NewTransformBuilder(cache common.SummaryCache, annotationColumns *annotations.Columns, computedFields *computed.Fields, conditions *conditions.Conditions, derivedFields *derived.Registry) *TransformBuilder {
 	return &TransformBuilder{
 		defaultFields: &common.DefaultFields{
 			Cache: cache,
 		},
+		annotationColumns: annotationColumns,
+		computedFields:    computedFields,
+		conditions:        conditions,
+		derivedFields:     derivedFields,
 	}
 }
 
@@ -32,7 +48,17 @@ func (t *TransformBuilder) GetTransformFunc(gvk schema.GroupVersionKind) cache.T
 	if gvk.Kind == "Event" && gvk.Group == "" && gvk.Version == "v1" {
 		converters = append(converters, events.TransformEventObject)
 	}
-	converters = append(converters, t.defaultFields.TransformCommon)
+	if annotationTransform := t.annotationColumns.TransformFunc(gvk); annotationTransform != nil {
+		converters = append(converters, annotationTransform)
+	}
+	if computedTransform := t.computedFields.TransformFunc(gvk); computedTransform != nil {
+		converters = append(converters, computedTransform)
+	}
+	converters = append(converters, t.defaultFields.TransformCommon, t.conditions.TransformCommon, owners.TransformCommon, problems.TransformCommon, identity.TransformCommon)
+	// derived fields come last, so that they can override the fields set by steve
+	if derivedTransform := t.derivedFields.TransformFunc(gvk); derivedTransform != nil {
+		converters = append(converters, derivedTransform)
+	}
 
 	return func(raw interface{}) (interface{}, error) {
 		obj, isSignal, err := common.GetUnstructured(raw)
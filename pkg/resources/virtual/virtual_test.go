@@ -87,6 +87,10 @@ func TestTransformChain(t *testing.T) {
 								"rel":         "uses",
 							},
 						},
+						"problems": map[string]interface{}{
+							"errors":   "0",
+							"warnings": "0",
+						},
 					},
 					"id":  "test-ns/testobj",
 					"_id": "old-id",
@@ -125,6 +129,10 @@ func TestTransformChain(t *testing.T) {
 						"name":          "oswaldsFarm",
 						"namespace":     "oswaldsNamespace",
 						"relationships": []any(nil),
+						"problems": map[string]interface{}{
+							"errors":   "1",
+							"warnings": "0",
+						},
 					},
 					"status": map[string]interface{}{
 						"conditions": []interface{}{
@@ -169,6 +177,10 @@ func TestTransformChain(t *testing.T) {
 						"name":          "gregsFarm",
 						"namespace":     "gregsNamespace",
 						"relationships": []any(nil),
+						"problems": map[string]interface{}{
+							"errors":   "0",
+							"warnings": "0",
+						},
 					},
 					"id":   "gregsNamespace/gregsFarm",
 					"_id":  "eventTest1id",
@@ -183,7 +195,7 @@ func TestTransformChain(t *testing.T) {
 				SummarizedObject: test.hasSummary,
 				Relationships:    test.hasRelationships,
 			}
-			tb := virtual.NewTransformBuilder(&fakeCache)
+			tb := virtual.NewTransformBuilder(&fakeCache, nil, nil, nil, nil)
 			raw, isSignal, err := common.GetUnstructured(test.input)
 			require.False(t, isSignal)
 			require.Nil(t, err)
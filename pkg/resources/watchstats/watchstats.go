@@ -0,0 +1,56 @@
+// Package watchstats provides the watchStat schema, which returns the statistics of the watches of each type, so that
+// operators can tell which types drive the load of WebSocket subscriptions.
+package watchstats
+
+import (
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/metrics"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+)
+
+// Stats returns the statistics of the watches of the types which have been watched
+type Stats interface {
+	List() []metrics.WatchStat
+}
+
+// Register registers the watchStat schema, which lists the statistics of the watches of the types the user can access,
+// and returns those of a single type by the ID of its schema.
+func Register(baseSchema *types.APISchemas, stats Stats) {
+	baseSchema.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID:                "watchStat",
+			PluralName:        "watchStats",
+			CollectionMethods: []string{"GET"},
+			ResourceMethods:   []string{"GET"},
+		},
+		ListHandler: func(request *types.APIRequest) (types.APIObjectList, error) {
+			return list(request, stats), nil
+		},
+		ByIDHandler: func(request *types.APIRequest) (types.APIObject, error) {
+			for _, obj := range list(request, stats).Objects {
+				if obj.ID == request.Name {
+					return obj, nil
+				}
+			}
+			return types.APIObject{}, apierror.NewAPIError(validation.NotFound, "no watch statistics for "+request.Name)
+		},
+	})
+}
+
+func list(request *types.APIRequest, stats Stats) types.APIObjectList {
+	result := types.APIObjectList{}
+	for _, stat := range stats.List() {
+		// pseudo-access check, to make sure that users only see the statistics of the schemas they have access to
+		if request.Schemas.LookupSchema(stat.Resource) == nil {
+			continue
+		}
+		result.Objects = append(result.Objects, types.APIObject{
+			ID:     stat.Resource,
+			Type:   "watchStat",
+			Object: stat,
+		})
+	}
+	return result
+}
@@ -0,0 +1,45 @@
+package watchstats
+
+import (
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/metrics"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStats []metrics.WatchStat
+
+func (f fakeStats) List() []metrics.WatchStat {
+	return f
+}
+
+func TestRegister(t *testing.T) {
+	stats := fakeStats{
+		{Resource: "pod", Active: 2, Events: 10},
+		{Resource: "secret", Active: 1},
+	}
+	baseSchemas := types.EmptyAPISchemas()
+	Register(baseSchemas, stats)
+	watchStat := baseSchemas.LookupSchema("watchStat")
+	require.NotNil(t, watchStat)
+
+	// the user can only access pods
+	userSchemas := types.EmptyAPISchemas()
+	userSchemas.MustAddSchema(types.APISchema{Schema: &schemas.Schema{ID: "pod"}})
+
+	list, err := watchStat.ListHandler(&types.APIRequest{Schemas: userSchemas})
+	require.NoError(t, err)
+	require.Len(t, list.Objects, 1)
+	assert.Equal(t, "pod", list.Objects[0].ID)
+	assert.Equal(t, stats[0], list.Objects[0].Object)
+
+	obj, err := watchStat.ByIDHandler(&types.APIRequest{Schemas: userSchemas, Name: "pod"})
+	require.NoError(t, err)
+	assert.Equal(t, stats[0], obj.Object)
+
+	_, err = watchStat.ByIDHandler(&types.APIRequest{Schemas: userSchemas, Name: "secret"})
+	assert.Error(t, err)
+}
@@ -0,0 +1,153 @@
+package revisions
+
+import (
+	"encoding/json"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	linkName = "revisions"
+	// maxObjectRevisions bounds the number of revisions listed for an object, the oldest ones being dropped first
+	maxObjectRevisions = 20
+)
+
+type objectKey struct {
+	schemaID  string
+	namespace string
+	name      string
+}
+
+// Revision is a revision of an object kept by steve
+type Revision struct {
+	ResourceVersion string `json:"resourceVersion"`
+	// Observed is when steve first returned the revision
+	Observed time.Time `json:"observed"`
+}
+
+// History is the response of the revisions link
+type History struct {
+	Type string `json:"type"`
+	ID   string `json:"id"`
+	// Revisions are the revisions of the object kept by steve, the most recent first
+	Revisions []Revision `json:"revisions"`
+}
+
+// history keeps the revisions recorded for each object, in the order they were observed
+type history struct {
+	lock    sync.Mutex
+	objects map[objectKey][]Revision
+}
+
+func (h *history) add(key objectKey, resourceVersion string, now time.Time, retention time.Duration) {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	if h.objects == nil {
+		h.objects = map[objectKey][]Revision{}
+	}
+	revisions := h.objects[key]
+	for _, revision := range revisions {
+		if revision.ResourceVersion == resourceVersion {
+			return
+		}
+	}
+	revisions = append(unexpired(revisions, now, retention), Revision{ResourceVersion: resourceVersion, Observed: now})
+	if len(revisions) > maxObjectRevisions {
+		revisions = revisions[len(revisions)-maxObjectRevisions:]
+	}
+	h.objects[key] = revisions
+	if len(h.objects) > maxRevisions {
+		// objects which aren't read anymore still have revisions, which expired
+		for key, revisions := range h.objects {
+			if revisions = unexpired(revisions, now, retention); len(revisions) == 0 {
+				delete(h.objects, key)
+			} else {
+				h.objects[key] = revisions
+			}
+		}
+	}
+}
+
+func (h *history) list(key objectKey) []Revision {
+	h.lock.Lock()
+	defer h.lock.Unlock()
+	return append([]Revision(nil), h.objects[key]...)
+}
+
+// unexpired returns the revisions observed within the retention duration
+func unexpired(revisions []Revision, now time.Time, retention time.Duration) []Revision {
+	for i, revision := range revisions {
+		if now.Sub(revision.Observed) < retention {
+			return revisions[i:]
+		}
+	}
+	return nil
+}
+
+// serveRevisions serves the revisions link of an object, which lists the revisions kept of the object, or returns the
+// revision given by the resourceVersion query parameter
+func (c *Cache) serveRevisions(rw http.ResponseWriter, req *http.Request) {
+	apiOp := types.GetAPIContext(req.Context())
+	var result interface{}
+	var err error
+	if resourceVersion := apiOp.Query.Get("resourceVersion"); resourceVersion != "" {
+		result, err = c.revision(apiOp, resourceVersion)
+	} else {
+		result, err = c.history(apiOp)
+	}
+	if err != nil {
+		apiOp.WriteError(err)
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(result); err != nil {
+		logrus.Errorf("failed to write revisions: %v", err)
+	}
+}
+
+// history lists the revisions kept of the requested object, which is read first, so that the user is allowed to read it
+// and its current revision is known
+func (c *Cache) history(apiOp *types.APIRequest) (*History, error) {
+	obj, err := apiOp.Schema.Store.ByID(apiOp, apiOp.Schema, apiOp.Name)
+	if err != nil {
+		return nil, err
+	}
+	key := objectKeyOf(apiOp.Schema, obj.Data())
+	result := &History{
+		Type:      linkName,
+		ID:        obj.ID,
+		Revisions: []Revision{},
+	}
+	now := c.now()
+	revisions := c.objects.list(key)
+	for i := len(revisions) - 1; i >= 0; i-- {
+		revision := revisions[i]
+		// revisions dropped from the cache, because they expired or too many revisions are kept, can't be returned
+		if _, ok := c.get(apiOp.Schema, key.namespace, key.name, revision.ResourceVersion); ok && now.Sub(revision.Observed) < c.retention {
+			result.Revisions = append(result.Revisions, revision)
+		}
+	}
+	return result, nil
+}
+
+// revision returns a revision kept of the requested object
+func (c *Cache) revision(apiOp *types.APIRequest, resourceVersion string) (map[string]interface{}, error) {
+	obj, err := apiOp.Schema.Store.ByID(apiOp, apiOp.Schema, apiOp.Name)
+	if err != nil {
+		return nil, err
+	}
+	key := objectKeyOf(apiOp.Schema, obj.Data())
+	revision, ok := c.get(apiOp.Schema, key.namespace, key.name, resourceVersion)
+	if !ok {
+		return nil, apierror.NewAPIError(validation.NotFound, "revision "+resourceVersion+" of "+obj.ID+" is not known")
+	}
+	return revision, nil
+}
@@ -0,0 +1,88 @@
+package revisions
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestServeRevisions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	revisions := New(time.Hour)
+	revisions.now = func() time.Time { return now }
+	backing := &testStore{current: newConfigMap("1", map[string]interface{}{"a": "1"})}
+	schema := &types.APISchema{Schema: &schemas.Schema{ID: "configmap"}}
+	schema.Store = &Store{Store: backing, revisions: revisions}
+
+	serve := func(query string) (*httptest.ResponseRecorder, error) {
+		var writtenErr error
+		rw := httptest.NewRecorder()
+		apiOp := types.StoreAPIContext(&types.APIRequest{
+			Name:         "cm",
+			Namespace:    "default",
+			Schema:       schema,
+			Query:        httptest.NewRequest(http.MethodGet, "/?"+query, nil).URL.Query(),
+			Request:      httptest.NewRequest(http.MethodGet, "/v1/configmaps/default/cm?"+query, nil),
+			Response:     rw,
+			ErrorHandler: func(_ *types.APIRequest, err error) { writtenErr = err },
+		})
+		revisions.serveRevisions(rw, apiOp.Request)
+		return rw, writtenErr
+	}
+	history := func() []Revision {
+		rw, err := serve("link=revisions")
+		require.NoError(t, err)
+		require.Equal(t, http.StatusOK, rw.Code)
+		var result History
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &result))
+		assert.Equal(t, "revisions", result.Type)
+		return result.Revisions
+	}
+
+	// the current revision is recorded when the history is read
+	assert.Equal(t, []Revision{{ResourceVersion: "1", Observed: now}}, history())
+	now = now.Add(30 * time.Minute)
+	backing.current = newConfigMap("2", map[string]interface{}{"a": "2"})
+	assert.Equal(t, []Revision{
+		{ResourceVersion: "2", Observed: now},
+		{ResourceVersion: "1", Observed: now.Add(-30 * time.Minute)},
+	}, history())
+
+	rw, err := serve("link=revisions&resourceVersion=1")
+	require.NoError(t, err)
+	var obj map[string]interface{}
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &obj))
+	assert.Equal(t, map[string]interface{}{"a": "1"}, obj["data"])
+
+	_, err = serve("link=revisions&resourceVersion=5")
+	var apiErr *apierror.APIError
+	require.True(t, errors.As(err, &apiErr))
+	assert.Equal(t, validation.NotFound, apiErr.Code)
+
+	// expired revisions aren't listed
+	now = now.Add(45 * time.Minute)
+	assert.Equal(t, []Revision{{ResourceVersion: "2", Observed: now.Add(-45 * time.Minute)}}, history())
+}
+
+func TestHistoryMaxObjectRevisions(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	h := history{}
+	key := objectKey{schemaID: "configmap", namespace: "default", name: "cm"}
+	for i := 0; i < maxObjectRevisions+5; i++ {
+		h.add(key, time.Duration(i).String(), now, time.Hour)
+	}
+	h.add(key, time.Duration(maxObjectRevisions).String(), now, time.Hour)
+	revisions := h.list(key)
+	require.Len(t, revisions, maxObjectRevisions)
+	assert.Equal(t, time.Duration(5).String(), revisions[0].ResourceVersion, "the oldest revisions are dropped")
+}
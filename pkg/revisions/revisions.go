@@ -0,0 +1,278 @@
+// Package revisions keeps the recent revisions of objects served by steve, so that update conflicts can be reported
+// with the changes made since the revision the client based its update on, and the recent revisions of an object can
+// be listed and read with its revisions link.
+package revisions
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/resources/diff"
+	"github.com/rancher/steve/pkg/schema"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"k8s.io/apimachinery/pkg/util/cache"
+)
+
+// maxRevisions bounds the number of revisions kept, the least recently used ones being dropped first
+const maxRevisions = 10000
+
+// ignoredPrefixes are fields added by steve or its clients rather than stored in Kubernetes
+var ignoredPrefixes = []string{
+	"id",
+	"type",
+	"links",
+	"actions",
+	"metadata.fields",
+	"metadata.state",
+	"metadata.relationships",
+}
+
+type key struct {
+	schemaID        string
+	namespace       string
+	name            string
+	resourceVersion string
+}
+
+// Cache keeps the revisions of objects read by ID or returned by updates within the retention window
+type Cache struct {
+	retention time.Duration
+	revisions *cache.LRUExpireCache
+	objects   history
+	now       func() time.Time
+}
+
+// New returns a cache keeping revisions for the retention duration
+func New(retention time.Duration) *Cache {
+	return &Cache{
+		retention: retention,
+		revisions: cache.NewLRUExpireCache(maxRevisions),
+		now:       time.Now,
+	}
+}
+
+func (c *Cache) add(apiSchema *types.APISchema, obj types.APIObject) {
+	data := obj.Data()
+	rv := data.String("metadata", "resourceVersion")
+	if rv == "" {
+		return
+	}
+	object := objectKeyOf(apiSchema, data)
+	c.revisions.Add(key{
+		schemaID:        object.schemaID,
+		namespace:       object.namespace,
+		name:            object.name,
+		resourceVersion: rv,
+	}, jsonCopy(data), c.retention)
+	c.objects.add(object, rv, c.now(), c.retention)
+}
+
+func objectKeyOf(apiSchema *types.APISchema, data map[string]interface{}) objectKey {
+	metadata, _ := data["metadata"].(map[string]interface{})
+	namespace, _ := metadata["namespace"].(string)
+	name, _ := metadata["name"].(string)
+	return objectKey{
+		schemaID:  apiSchema.ID,
+		namespace: namespace,
+		name:      name,
+	}
+}
+
+func (c *Cache) get(apiSchema *types.APISchema, namespace, name, resourceVersion string) (map[string]interface{}, bool) {
+	value, ok := c.revisions.Get(key{
+		schemaID:        apiSchema.ID,
+		namespace:       namespace,
+		name:            name,
+		resourceVersion: resourceVersion,
+	})
+	if !ok {
+		return nil, false
+	}
+	obj, ok := value.(map[string]interface{})
+	return obj, ok
+}
+
+// ConflictDetails describes the changes made to an object since the revision an update conflicting with them was based
+// on
+type ConflictDetails struct {
+	// BaseResourceVersion is the resource version the update was based on
+	BaseResourceVersion string `json:"baseResourceVersion"`
+	// CurrentResourceVersion is the resource version of the object when the update was rejected
+	CurrentResourceVersion string `json:"currentResourceVersion"`
+	// BaseAvailable is false if the base revision was not known, in which case changes can't be listed
+	BaseAvailable bool `json:"baseAvailable"`
+	// Changes are the changes made to the object since the base revision
+	Changes []diff.Change `json:"changes,omitempty"`
+	// ConflictingFields are the changed fields which the update changed as well
+	ConflictingFields []string `json:"conflictingFields,omitempty"`
+}
+
+func (c *ConflictDetails) Error() string {
+	return "object changed from resource version " + c.BaseResourceVersion + " to " + c.CurrentResourceVersion
+}
+
+// Details returns the details added to the error response
+func (c *ConflictDetails) Details() interface{} {
+	return c
+}
+
+// Template returns a schema template recording the revisions of objects of Kubernetes types, adding the changes made
+// since the base revision to update conflicts, and adding the revisions link to objects
+func Template(revisions *Cache) schema.Template {
+	return schema.Template{
+		Customize: func(apiSchema *types.APISchema) {
+			if apiSchema.Store == nil || attributes.GVR(apiSchema).Resource == "" {
+				return
+			}
+			if _, ok := apiSchema.Store.(*Store); ok {
+				return
+			}
+			apiSchema.Store = &Store{
+				Store:     apiSchema.Store,
+				revisions: revisions,
+			}
+			if apiSchema.LinkHandlers == nil {
+				apiSchema.LinkHandlers = map[string]http.Handler{}
+			}
+			apiSchema.LinkHandlers[linkName] = http.HandlerFunc(revisions.serveRevisions)
+		},
+		Formatter: func(request *types.APIRequest, resource *types.RawResource) {
+			if _, ok := resource.Schema.Store.(*Store); !ok || resource.APIObject.Object == nil {
+				return
+			}
+			resource.Links[linkName] = request.URLBuilder.Link(resource.Schema, resource.ID, linkName)
+		},
+	}
+}
+
+// Store records the revisions of objects and adds details to update conflicts
+type Store struct {
+	types.Store
+	revisions *Cache
+}
+
+// ByID looks up a single object by its ID, recording its revision.
+func (s *Store) ByID(apiOp *types.APIRequest, apiSchema *types.APISchema, id string) (types.APIObject, error) {
+	obj, err := s.Store.ByID(apiOp, apiSchema, id)
+	if err == nil {
+		s.revisions.add(apiSchema, obj)
+	}
+	return obj, err
+}
+
+// Update updates a single object in the store. If the update conflicts with a change made since the object's
+// resource version, the error's cause lists the changes.
+func (s *Store) Update(apiOp *types.APIRequest, apiSchema *types.APISchema, obj types.APIObject, id string) (types.APIObject, error) {
+	// the object may be modified by nested stores
+	submitted := jsonCopy(obj.Data())
+	result, err := s.Store.Update(apiOp, apiSchema, obj, id)
+	if err == nil {
+		// dry-run results keep the resource version of the object they weren't persisted to
+		if !proxy.IsDryRun(apiOp) {
+			s.revisions.add(apiSchema, result)
+		}
+		return result, nil
+	}
+
+	err = proxy.TranslateError(err)
+	var apiError *apierror.APIError
+	if !errors.As(err, &apiError) || !apierror.IsConflict(apiError) {
+		return result, err
+	}
+	current, getErr := s.ByID(apiOp, apiSchema, id)
+	if getErr != nil {
+		return result, err
+	}
+
+	details := conflictDetails(submitted, jsonCopy(current.Data()), func(rv string) (map[string]interface{}, bool) {
+		return s.revisions.get(apiSchema, apiOp.Namespace, id, rv)
+	})
+	return result, &apierror.APIError{
+		Code:      apiError.Code,
+		Message:   apiError.Message,
+		FieldName: apiError.FieldName,
+		Cause:     details,
+	}
+}
+
+func conflictDetails(submitted, current map[string]interface{}, base func(rv string) (map[string]interface{}, bool)) *ConflictDetails {
+	details := &ConflictDetails{
+		BaseResourceVersion:    resourceVersion(submitted),
+		CurrentResourceVersion: resourceVersion(current),
+	}
+	baseObj, ok := base(details.BaseResourceVersion)
+	if !ok {
+		return details
+	}
+	details.BaseAvailable = true
+	details.Changes = relevantChanges(diff.Compare(baseObj, current))
+
+	conflicting := map[string]bool{}
+	for _, clientChange := range relevantChanges(diff.Compare(baseObj, submitted)) {
+		for _, change := range details.Changes {
+			if overlaps(change.Path, clientChange.Path) {
+				conflicting[change.Path] = true
+			}
+		}
+	}
+	for path := range conflicting {
+		details.ConflictingFields = append(details.ConflictingFields, path)
+	}
+	sort.Strings(details.ConflictingFields)
+	return details
+}
+
+func resourceVersion(obj map[string]interface{}) string {
+	metadata, _ := obj["metadata"].(map[string]interface{})
+	rv, _ := metadata["resourceVersion"].(string)
+	return rv
+}
+
+func relevantChanges(changes []diff.Change) []diff.Change {
+	var result []diff.Change
+	for _, change := range changes {
+		ignored := false
+		for _, prefix := range ignoredPrefixes {
+			if overlaps(prefix, change.Path) && len(change.Path) >= len(prefix) {
+				ignored = true
+				break
+			}
+		}
+		if !ignored {
+			result = append(result, change)
+		}
+	}
+	return result
+}
+
+// overlaps returns true if a and b are the same field, or one of them is a subfield of the other
+func overlaps(a, b string) bool {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	if !strings.HasPrefix(b, a) {
+		return false
+	}
+	return len(a) == len(b) || b[len(a)] == '.' || b[len(a)] == '['
+}
+
+// jsonCopy returns a deep copy of obj, with values normalized to the types they have when decoded from JSON, so that
+// copies of objects from different sources can be compared
+func jsonCopy(obj map[string]interface{}) map[string]interface{} {
+	data, err := json.Marshal(obj)
+	if err != nil {
+		return nil
+	}
+	var result map[string]interface{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil
+	}
+	return result
+}
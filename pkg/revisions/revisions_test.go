@@ -0,0 +1,132 @@
+package revisions
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/resources/diff"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+type testStore struct {
+	types.Store
+	current *unstructured.Unstructured
+}
+
+func (t *testStore) ByID(_ *types.APIRequest, _ *types.APISchema, _ string) (types.APIObject, error) {
+	return types.APIObject{Object: t.current.DeepCopy()}, nil
+}
+
+func (t *testStore) Update(_ *types.APIRequest, _ *types.APISchema, data types.APIObject, _ string) (types.APIObject, error) {
+	if data.Data().String("metadata", "resourceVersion") != t.current.GetResourceVersion() {
+		return types.APIObject{}, apierror.NewAPIError(validation.Conflict, "the object has been modified")
+	}
+	t.current = &unstructured.Unstructured{Object: data.Data()}
+	t.current.SetResourceVersion(t.current.GetResourceVersion() + "0")
+	return types.APIObject{Object: t.current.DeepCopy()}, nil
+}
+
+func newConfigMap(rv string, data map[string]interface{}) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"namespace":       "default",
+			"name":            "cm",
+			"resourceVersion": rv,
+		},
+		"data": data,
+	}}
+}
+
+func TestStoreUpdateConflict(t *testing.T) {
+	schema := &types.APISchema{Schema: &schemas.Schema{ID: "configmap"}}
+	apiOp := &types.APIRequest{Namespace: "default"}
+	backing := &testStore{current: newConfigMap("1", map[string]interface{}{"a": "1", "b": "1", "c": int64(1)})}
+	store := &Store{Store: backing, revisions: New(time.Minute)}
+
+	// the client reads revision 1, which is then changed by someone else
+	_, err := store.ByID(apiOp, schema, "cm")
+	require.NoError(t, err)
+	backing.current = newConfigMap("2", map[string]interface{}{"a": "2", "b": "1", "c": int64(2)})
+
+	// the client changes a and b based on revision 1
+	submitted := newConfigMap("1", map[string]interface{}{"a": "3", "b": "3", "c": int64(1)})
+	submitted.Object["links"] = map[string]interface{}{"self": "..."}
+	_, err = store.Update(apiOp, schema, types.APIObject{Object: submitted.Object}, "cm")
+	require.Error(t, err)
+
+	apiError, ok := err.(*apierror.APIError)
+	require.True(t, ok)
+	assert.Equal(t, validation.Conflict, apiError.Code)
+	assert.Equal(t, &ConflictDetails{
+		BaseResourceVersion:    "1",
+		CurrentResourceVersion: "2",
+		BaseAvailable:          true,
+		Changes: []diff.Change{
+			{Path: "data.a", Op: diff.Replace, Old: "1", New: "2"},
+			{Path: "data.c", Op: diff.Replace, Old: float64(1), New: float64(2)},
+		},
+		ConflictingFields: []string{"data.a"},
+	}, apiError.Cause)
+
+	// the client retries based on the current revision
+	submitted.SetResourceVersion("2")
+	_, err = store.Update(apiOp, schema, types.APIObject{Object: submitted.Object}, "cm")
+	require.NoError(t, err)
+}
+
+func TestStoreUpdateConflictUnknownBase(t *testing.T) {
+	schema := &types.APISchema{Schema: &schemas.Schema{ID: "configmap"}}
+	apiOp := &types.APIRequest{Namespace: "default"}
+	backing := &testStore{current: newConfigMap("2", map[string]interface{}{"a": "2"})}
+	store := &Store{Store: backing, revisions: New(time.Minute)}
+
+	_, err := store.Update(apiOp, schema, types.APIObject{Object: newConfigMap("1", nil).Object}, "cm")
+	apiError, ok := err.(*apierror.APIError)
+	require.True(t, ok)
+	assert.Equal(t, &ConflictDetails{
+		BaseResourceVersion:    "1",
+		CurrentResourceVersion: "2",
+	}, apiError.Cause)
+}
+
+func TestStoreUpdateDryRun(t *testing.T) {
+	schema := &types.APISchema{Schema: &schemas.Schema{ID: "configmap"}}
+	backing := &testStore{current: newConfigMap("1", map[string]interface{}{"a": "1"})}
+	revisions := New(time.Minute)
+	store := &Store{Store: backing, revisions: revisions}
+
+	apiOp := &types.APIRequest{Namespace: "default"}
+	_, err := store.ByID(apiOp, schema, "cm")
+	require.NoError(t, err)
+
+	dryRunOp := &types.APIRequest{
+		Namespace: "default",
+		Request:   httptest.NewRequest(http.MethodPut, "/v1/configmaps/default/cm?dryRun=All", nil),
+	}
+	result, err := store.Update(dryRunOp, schema, types.APIObject{Object: newConfigMap("1", map[string]interface{}{"a": "2"}).Object}, "cm")
+	require.NoError(t, err)
+
+	base, ok := revisions.get(schema, "default", "cm", "1")
+	require.True(t, ok)
+	assert.Equal(t, "1", base["data"].(map[string]interface{})["a"], "the revision read isn't replaced by the dry run")
+	_, ok = revisions.get(schema, "default", "cm", result.Data().String("metadata", "resourceVersion"))
+	assert.False(t, ok, "dry-run results aren't recorded")
+}
+
+func TestOverlaps(t *testing.T) {
+	assert.True(t, overlaps("spec", "spec"))
+	assert.True(t, overlaps("spec", "spec.replicas"))
+	assert.True(t, overlaps("spec.containers[0].image", "spec.containers"))
+	assert.False(t, overlaps("spec.replicas", "spec.replicasMax"))
+	assert.False(t, overlaps("data.a", "data.b"))
+}
@@ -12,6 +12,7 @@ import (
 	"github.com/rancher/steve/pkg/attributes"
 	"github.com/rancher/wrangler/v3/pkg/name"
 	"github.com/sirupsen/logrus"
+	"golang.org/x/sync/singleflight"
 	"k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apimachinery/pkg/util/cache"
 	"k8s.io/apiserver/pkg/endpoints/request"
@@ -28,6 +29,11 @@ type Collection struct {
 	byGVK      map[schema.GroupVersionKind]string
 	cache      *cache.LRUExpireCache
 	userCache  *cache.LRUExpireCache
+	// byUser caches the schemas of users while the revision of their access sets is unchanged, if it is tracked
+	byUser *cache.LRUExpireCache
+	// generation is incremented whenever the schemas are reset, invalidating the schemas computed before
+	generation uint64
+	computing  singleflight.Group
 	lock       sync.RWMutex
 
 	ctx     context.Context
@@ -77,6 +83,7 @@ func NewCollection(ctx context.Context, baseSchema *types.APISchemas, access acc
 		byGVK:      map[schema.GroupVersionKind]string{},
 		cache:      cache.NewLRUExpireCache(1000),
 		userCache:  cache.NewLRUExpireCache(1000),
+		byUser:     cache.NewLRUExpireCache(1000),
 		notifiers:  map[int]func(){},
 		ctx:        ctx,
 		as:         access,
@@ -121,9 +128,13 @@ func (c *Collection) Reset(schemas map[string]*types.APISchema) {
 	c.schemas = schemas
 	c.byGVR = byGVR
 	c.byGVK = byGVK
+	c.generation++
 	for _, k := range c.cache.Keys() {
 		c.cache.Remove(k)
 	}
+	for _, k := range c.byUser.Keys() {
+		c.byUser.Remove(k)
+	}
 	c.lock.Unlock()
 	c.lock.RLock()
 	for _, f := range c.notifiers {
@@ -54,10 +54,12 @@ func forVersion(group, kind string, version v1.CustomResourceDefinitionVersion,
 	var versionColumns []table.Column
 	for _, col := range version.AdditionalPrinterColumns {
 		versionColumns = append(versionColumns, table.Column{
-			Name:   col.Name,
-			Field:  col.JSONPath,
-			Type:   col.Type,
-			Format: col.Format,
+			Name:        col.Name,
+			Field:       col.JSONPath,
+			Type:        col.Type,
+			Format:      col.Format,
+			Description: col.Description,
+			Priority:    int(col.Priority),
 		})
 	}
 
@@ -40,10 +40,12 @@ func TestAddCustomResources(t *testing.T) {
 								Name: "v1",
 								AdditionalPrinterColumns: []v1.CustomResourceColumnDefinition{
 									{
-										Name:     "TestColumn",
-										JSONPath: "TestPath",
-										Type:     "TestType",
-										Format:   "TestFormat",
+										Name:        "TestColumn",
+										JSONPath:    "TestPath",
+										Type:        "TestType",
+										Format:      "TestFormat",
+										Description: "TestDescription",
+										Priority:    1,
 									},
 								},
 								Schema: &v1.CustomResourceValidation{
@@ -169,10 +171,12 @@ func TestAddCustomResources(t *testing.T) {
 						Attributes: map[string]interface{}{
 							"columns": []table.Column{
 								{
-									Name:   "TestColumn",
-									Field:  "TestPath",
-									Type:   "TestType",
-									Format: "TestFormat",
+									Name:        "TestColumn",
+									Field:       "TestPath",
+									Type:        "TestType",
+									Format:      "TestFormat",
+									Description: "TestDescription",
+									Priority:    1,
 								},
 							},
 						},
@@ -0,0 +1,178 @@
+package definitions
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apimachinery/pkg/util/yaml"
+)
+
+const renderAction = "render"
+
+// skippedFields are the top level fields of objects left out of blueprints: the type and metadata are set from the
+// schema, and the status isn't set by clients.
+var skippedFields = map[string]bool{
+	"apiVersion": true,
+	"kind":       true,
+	"metadata":   true,
+	"status":     true,
+}
+
+// blueprint is a skeleton object of a type, which objects of the type can be created from
+type blueprint struct {
+	Object map[string]interface{} `json:"object"`
+}
+
+// blueprintByIDHandler is the Handler method for a request to get the blueprint of a specific schema.
+func (s *SchemaDefinitionHandler) blueprintByIDHandler(request *types.APIRequest) (types.APIObject, error) {
+	object, err := s.blueprintFor(request)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	return types.APIObject{
+		ID:     request.Name,
+		Type:   "schemaBlueprint",
+		Object: blueprint{Object: object},
+	}, nil
+}
+
+// renderBlueprint serves the render action, which merges the object of the request body into the blueprint of the
+// schema.
+func (s *SchemaDefinitionHandler) renderBlueprint(rw http.ResponseWriter, req *http.Request) {
+	apiContext := types.GetAPIContext(req.Context())
+	var partial map[string]interface{}
+	if err := yaml.NewYAMLOrJSONDecoder(req.Body, 4096).Decode(&partial); err != nil && !errors.Is(err, io.EOF) {
+		apiContext.WriteError(apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("failed to parse object: %v", err)))
+		return
+	}
+	object, err := s.blueprintFor(apiContext)
+	if err != nil {
+		apiContext.WriteError(err)
+		return
+	}
+	if err := validateType(object, partial); err != nil {
+		apiContext.WriteError(err)
+		return
+	}
+	apiContext.WriteResponse(http.StatusOK, types.APIObject{
+		ID:     apiContext.Name,
+		Type:   "schemaBlueprint",
+		Object: blueprint{Object: mergeObjects(object, partial)},
+	})
+}
+
+func (s *SchemaDefinitionHandler) blueprintFor(request *types.APIRequest) (map[string]interface{}, error) {
+	schemaDef, requestSchema, err := s.definitionFor(request)
+	if err != nil {
+		return nil, err
+	}
+	object := schemaDef.skeleton()
+	if gvk := attributes.GVK(requestSchema); gvk.Kind != "" {
+		apiVersion, kind := gvk.ToAPIVersionAndKind()
+		object["apiVersion"] = apiVersion
+		object["kind"] = kind
+		metadata := map[string]interface{}{"name": ""}
+		if attributes.Namespaced(requestSchema) {
+			metadata["namespace"] = ""
+		}
+		object["metadata"] = metadata
+	}
+	return object, nil
+}
+
+// skeleton returns an object of the definition type with the fields which are required or have a default. Required
+// fields without a default are set to the zero value of their type, or to the first of their allowed values.
+func (s *schemaDefinition) skeleton() map[string]interface{} {
+	object := s.skeletonOf(s.DefinitionType, map[string]bool{})
+	for field := range skippedFields {
+		delete(object, field)
+	}
+	return object
+}
+
+// skeletonOf returns the skeleton of a definition, visiting being the definitions it is nested in, which aren't
+// expanded again so that recursive definitions end.
+func (s *schemaDefinition) skeletonOf(typ string, visiting map[string]bool) map[string]interface{} {
+	object := map[string]interface{}{}
+	def, ok := s.Definitions[typ]
+	if !ok || visiting[typ] {
+		return object
+	}
+	visiting[typ] = true
+	defer delete(visiting, typ)
+
+	for name, field := range def.ResourceFields {
+		switch {
+		case field.Default != nil:
+			object[name] = field.Default
+		case field.Required:
+			if value, ok := s.zeroValue(field, visiting); ok {
+				object[name] = value
+			}
+		}
+	}
+	return object
+}
+
+// zeroValue returns the value of a required field without a default. Fields of types without a zero value aren't set.
+func (s *schemaDefinition) zeroValue(field definitionField, visiting map[string]bool) (interface{}, bool) {
+	if len(field.Enum) > 0 {
+		return field.Enum[0], true
+	}
+	switch field.Type {
+	case "string":
+		return "", true
+	case "int":
+		return 0, true
+	case "boolean":
+		return false, true
+	case "array":
+		return []interface{}{}, true
+	case "map":
+		return map[string]interface{}{}, true
+	}
+	if _, ok := s.Definitions[field.Type]; ok {
+		return s.skeletonOf(field.Type, visiting), true
+	}
+	return nil, false
+}
+
+// validateType returns an error if the partial object sets a type other than the one of the blueprint
+func validateType(object, partial map[string]interface{}) error {
+	for _, field := range []string{"apiVersion", "kind"} {
+		value, ok := partial[field]
+		if !ok || value == object[field] {
+			continue
+		}
+		if _, typed := object[field]; !typed {
+			continue
+		}
+		return apierror.NewFieldAPIError(validation.InvalidOption, field, fmt.Sprintf("must be %v", object[field]))
+	}
+	return nil
+}
+
+// mergeObjects returns the blueprint with the values of the partial object, merging objects field by field and
+// replacing every other value, including lists.
+func mergeObjects(object, partial map[string]interface{}) map[string]interface{} {
+	for key, value := range partial {
+		partialObject, ok := value.(map[string]interface{})
+		if !ok {
+			object[key] = value
+			continue
+		}
+		blueprintObject, ok := object[key].(map[string]interface{})
+		if !ok {
+			object[key] = partialObject
+			continue
+		}
+		object[key] = mergeObjects(blueprintObject, partialObject)
+	}
+	return object
+}
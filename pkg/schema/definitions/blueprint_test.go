@@ -0,0 +1,163 @@
+package definitions
+
+import (
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	wschemas "github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestSkeleton(t *testing.T) {
+	schemaDef := schemaDefinition{
+		DefinitionType: "io.cattle.management.v2.Widget",
+		Definitions: map[string]definition{
+			"io.cattle.management.v2.Widget": {
+				ResourceFields: map[string]definitionField{
+					"apiVersion": {Type: "string", Required: true},
+					"metadata":   {Type: "io.k8s.apimachinery.pkg.apis.meta.v1.ObjectMeta", Required: true},
+					"spec":       {Type: "io.cattle.management.v2.Widget.spec", Required: true},
+					"status":     {Type: "io.cattle.management.v2.Widget.status", Required: true},
+				},
+			},
+			"io.cattle.management.v2.Widget.spec": {
+				ResourceFields: map[string]definitionField{
+					"name":      {Type: "string", Required: true},
+					"replicas":  {Type: "int", Default: 1.0},
+					"paused":    {Type: "boolean", Required: true},
+					"mode":      {Type: "string", Required: true, Enum: []interface{}{"fast", "slow"}},
+					"ports":     {Type: "array", SubType: "int", Required: true},
+					"labels":    {Type: "map", SubType: "string", Required: true},
+					"optional":  {Type: "string"},
+					"child":     {Type: "io.cattle.management.v2.Widget.spec", Required: true},
+					"template":  {Type: "io.cattle.management.v2.Widget.template"},
+					"arbitrary": {Type: "json", Required: true},
+				},
+			},
+			"io.cattle.management.v2.Widget.template": {
+				ResourceFields: map[string]definitionField{
+					"image": {Type: "string", Required: true},
+				},
+			},
+		},
+	}
+	assert.Equal(t, map[string]interface{}{
+		"spec": map[string]interface{}{
+			"name":     "",
+			"replicas": 1.0,
+			"paused":   false,
+			"mode":     "fast",
+			"ports":    []interface{}{},
+			"labels":   map[string]interface{}{},
+			// recursive definitions aren't expanded again
+			"child": map[string]interface{}{},
+		},
+	}, schemaDef.skeleton())
+}
+
+func TestBlueprintByIDHandler(t *testing.T) {
+	widget := types.APISchema{
+		Schema: &wschemas.Schema{
+			ID:              "widget",
+			ResourceMethods: []string{"GET"},
+			ResourceFields: map[string]wschemas.Field{
+				"size":  {Type: "int", Required: true},
+				"color": {Type: "string", Default: "blue"},
+				"note":  {Type: "string"},
+			},
+		},
+	}
+	attributes.SetGVK(&widget, schema.GroupVersionKind{Group: "example.cattle.io", Version: "v1", Kind: "Widget"})
+	attributes.SetNamespaced(&widget, true)
+	baseSchemas := types.EmptyAPISchemas()
+	baseSchemas.MustAddSchema(widget)
+	handler := NewSchemaDefinitionHandler(baseSchemas, nil, nil)
+
+	obj, err := handler.blueprintByIDHandler(&types.APIRequest{Name: "widget", Schemas: baseSchemas})
+	require.NoError(t, err)
+	assert.Equal(t, types.APIObject{
+		ID:   "widget",
+		Type: "schemaBlueprint",
+		Object: blueprint{Object: map[string]interface{}{
+			"apiVersion": "example.cattle.io/v1",
+			"kind":       "Widget",
+			"metadata":   map[string]interface{}{"name": "", "namespace": ""},
+			"size":       0,
+		}},
+	}, obj)
+
+	_, err = handler.blueprintByIDHandler(&types.APIRequest{Name: "missing", Schemas: baseSchemas})
+	var apiErr *apierror.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, validation.NotFound, apiErr.Code)
+}
+
+func TestMergeObjects(t *testing.T) {
+	object := map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "", "namespace": ""},
+		"data":       map[string]interface{}{"a": "1"},
+		"items":      []interface{}{"a"},
+	}
+	partial := map[string]interface{}{
+		"metadata": map[string]interface{}{"name": "settings", "labels": map[string]interface{}{"app": "web"}},
+		"data":     map[string]interface{}{"b": "2"},
+		"items":    []interface{}{"b", "c"},
+	}
+	require.NoError(t, validateType(object, partial))
+	assert.Equal(t, map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata":   map[string]interface{}{"name": "settings", "namespace": "", "labels": map[string]interface{}{"app": "web"}},
+		"data":       map[string]interface{}{"a": "1", "b": "2"},
+		"items":      []interface{}{"b", "c"},
+	}, mergeObjects(object, partial))
+}
+
+func TestValidateType(t *testing.T) {
+	tests := []struct {
+		name      string
+		object    map[string]interface{}
+		partial   map[string]interface{}
+		wantError bool
+	}{
+		{
+			name:    "same type",
+			object:  map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"},
+			partial: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"},
+		},
+		{
+			name:   "no type",
+			object: map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"},
+		},
+		{
+			name:      "other kind",
+			object:    map[string]interface{}{"apiVersion": "v1", "kind": "ConfigMap"},
+			partial:   map[string]interface{}{"kind": "Secret"},
+			wantError: true,
+		},
+		{
+			name:    "untyped blueprint",
+			object:  map[string]interface{}{},
+			partial: map[string]interface{}{"kind": "Secret"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			err := validateType(test.object, test.partial)
+			if test.wantError {
+				var apiErr *apierror.APIError
+				require.ErrorAs(t, err, &apiErr)
+				assert.Equal(t, validation.InvalidOption, apiErr.Code)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+}
@@ -1,6 +1,7 @@
 package definitions
 
 import (
+	"encoding/json"
 	"fmt"
 
 	"github.com/rancher/apiserver/pkg/types"
@@ -82,6 +83,10 @@ func convertJSONSchemaPropsObject(props *apiextv1.JSONSchemaProps, path proto.Pa
 		if err != nil {
 			return definitionField{}, err
 		}
+		subField, err = addJSONSchemaPropsValidations(subField, &prop)
+		if err != nil {
+			return definitionField{}, fmt.Errorf("field %q of %q: %w", name, path.String(), err)
+		}
 
 		_, required := requiredSet[name]
 		subField.Required = required
@@ -93,6 +98,32 @@ func convertJSONSchemaPropsObject(props *apiextv1.JSONSchemaProps, path proto.Pa
 	return field, nil
 }
 
+// addJSONSchemaPropsValidations adds the default value and validation rules of props to field
+func addJSONSchemaPropsValidations(field definitionField, props *apiextv1.JSONSchemaProps) (definitionField, error) {
+	if props.Default != nil {
+		if err := json.Unmarshal(props.Default.Raw, &field.Default); err != nil {
+			return definitionField{}, fmt.Errorf("invalid default: %w", err)
+		}
+	}
+	for _, value := range props.Enum {
+		var enumValue interface{}
+		if err := json.Unmarshal(value.Raw, &enumValue); err != nil {
+			return definitionField{}, fmt.Errorf("invalid enum value: %w", err)
+		}
+		field.Enum = append(field.Enum, enumValue)
+	}
+	field.Pattern = props.Pattern
+	field.Minimum = props.Minimum
+	field.ExclusiveMinimum = props.ExclusiveMinimum
+	field.Maximum = props.Maximum
+	field.ExclusiveMaximum = props.ExclusiveMaximum
+	field.MinLength = props.MinLength
+	field.MaxLength = props.MaxLength
+	field.MinItems = props.MinItems
+	field.MaxItems = props.MaxItems
+	return field, nil
+}
+
 func convertJSONSchemaPropsPrimitive(props *apiextv1.JSONSchemaProps) definitionField {
 	return definitionField{
 		Description: props.Description,
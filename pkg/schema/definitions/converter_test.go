@@ -6,6 +6,7 @@ import (
 
 	"github.com/stretchr/testify/require"
 	apiextv1 "k8s.io/apiextensions-apiserver/pkg/apis/apiextensions/v1"
+	"k8s.io/utils/ptr"
 )
 
 func TestCRDToDefinition(t *testing.T) {
@@ -277,6 +278,90 @@ func TestCRDToDefinition(t *testing.T) {
 				},
 			},
 		},
+		{
+			name:      "validations and defaults",
+			modelName: "my.group.v1.Test",
+			rawSchema: []byte(`
+{
+  "type": "object",
+  "required": ["mode"],
+  "properties": {
+    "mode": {
+      "type": "string",
+      "enum": ["fast", "safe"],
+      "default": "safe"
+    },
+    "name": {
+      "type": "string",
+      "pattern": "^[a-z]+$",
+      "minLength": 1,
+      "maxLength": 63
+    },
+    "replicas": {
+      "type": "integer",
+      "minimum": 0,
+      "maximum": 10,
+      "exclusiveMaximum": true,
+      "default": 1
+    },
+    "hosts": {
+      "type": "array",
+      "minItems": 1,
+      "maxItems": 3,
+      "items": {
+        "type": "string"
+      }
+    },
+    "labels": {
+      "type": "object",
+      "default": {"app": "test"},
+      "additionalProperties": {
+        "type": "string"
+      }
+    }
+  }
+}`),
+			expectedSchemaDef: schemaDefinition{
+				DefinitionType: "my.group.v1.Test",
+				Definitions: map[string]definition{
+					"my.group.v1.Test": {
+						Type: "my.group.v1.Test",
+						ResourceFields: map[string]definitionField{
+							"mode": {
+								Type:     "string",
+								Required: true,
+								Default:  "safe",
+								Enum:     []interface{}{"fast", "safe"},
+							},
+							"name": {
+								Type:      "string",
+								Pattern:   "^[a-z]+$",
+								MinLength: ptr.To[int64](1),
+								MaxLength: ptr.To[int64](63),
+							},
+							"replicas": {
+								Type:             "int",
+								Default:          float64(1),
+								Minimum:          ptr.To[float64](0),
+								Maximum:          ptr.To[float64](10),
+								ExclusiveMaximum: true,
+							},
+							"hosts": {
+								Type:     "array",
+								SubType:  "string",
+								MinItems: ptr.To[int64](1),
+								MaxItems: ptr.To[int64](3),
+							},
+							"labels": {
+								Type:    "map",
+								SubType: "string",
+								Default: map[string]interface{}{"app": "test"},
+							},
+						},
+					},
+				},
+			},
+		},
 		{
 			name:      "maps in object",
 			modelName: "my.group.v1.Test",
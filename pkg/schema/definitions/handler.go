@@ -119,25 +119,34 @@ func (s *SchemaDefinitionHandler) Refresh() error {
 // byIDHandler is the Handler method for a request to get the schema definition for a specific schema. Will use the
 // cached models found during the last refresh as part of this process.
 func (s *SchemaDefinitionHandler) byIDHandler(request *types.APIRequest) (types.APIObject, error) {
+	schemaDef, _, err := s.definitionFor(request)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	return types.APIObject{
+		ID:     request.Name,
+		Type:   "schemaDefinition",
+		Object: schemaDef,
+	}, nil
+}
+
+// definitionFor returns the schema definition of the schema named by the request, along with the schema.
+func (s *SchemaDefinitionHandler) definitionFor(request *types.APIRequest) (schemaDefinition, *types.APISchema, error) {
 	// pseudo-access check, designed to make sure that users have access to the schema for the definition that they
 	// are accessing.
 	requestSchema := request.Schemas.LookupSchema(request.Name)
 	if requestSchema == nil {
-		return types.APIObject{}, apierror.NewAPIError(validation.NotFound, "no such schema")
+		return schemaDefinition{}, nil, apierror.NewAPIError(validation.NotFound, "no such schema")
 	}
 
 	if baseSchema := s.baseSchema.LookupSchema(requestSchema.ID); baseSchema != nil {
 		// if this schema is a base schema it won't be in the model cache. In this case, and only this case, we process
 		// the fields independently
 		definitions := baseSchemaToDefinition(*requestSchema)
-		return types.APIObject{
-			ID:   request.Name,
-			Type: "schemaDefinition",
-			Object: schemaDefinition{
-				DefinitionType: requestSchema.ID,
-				Definitions:    definitions,
-			},
-		}, nil
+		return schemaDefinition{
+			DefinitionType: requestSchema.ID,
+			Definitions:    definitions,
+		}, requestSchema, nil
 	}
 
 	s.lock.RLock()
@@ -146,25 +155,20 @@ func (s *SchemaDefinitionHandler) byIDHandler(request *types.APIRequest) (types.
 	s.lock.RUnlock()
 
 	if gvkModels == nil || protoModels == nil {
-		return types.APIObject{}, apierror.NewAPIError(notRefreshedErrorCode, "schema definitions not yet refreshed")
+		return schemaDefinition{}, nil, apierror.NewAPIError(notRefreshedErrorCode, "schema definitions not yet refreshed")
 	}
 
 	model, ok := gvkModels[requestSchema.ID]
 	if !ok {
-		return types.APIObject{}, apierror.NewAPIError(notRefreshedErrorCode, "no model found for schema, try again after refresh")
+		return schemaDefinition{}, nil, apierror.NewAPIError(notRefreshedErrorCode, "no model found for schema, try again after refresh")
 	}
 
 	schemaDef, err := buildSchemaDefinitionForModel(protoModels, model)
 	if err != nil {
 		logrus.Errorf("failed building schema definition for model %s: %s", model.ModelName, err)
-		return types.APIObject{}, apierror.NewAPIError(internalServerErrorCode, "failed building schema definition")
+		return schemaDefinition{}, nil, apierror.NewAPIError(internalServerErrorCode, "failed building schema definition")
 	}
-
-	return types.APIObject{
-		ID:     request.Name,
-		Type:   "schemaDefinition",
-		Object: schemaDef,
-	}, nil
+	return schemaDef, requestSchema, nil
 }
 
 func buildSchemaDefinitionForModel(models proto.Models, gvk gvkModel) (schemaDefinition, error) {
@@ -3,12 +3,14 @@ package definitions
 import (
 	"context"
 	"fmt"
+	"net/http"
 	"os"
 	"strconv"
 	"time"
 
 	"github.com/rancher/apiserver/pkg/types"
 	"github.com/rancher/steve/pkg/debounce"
+	"github.com/rancher/steve/pkg/stores/proxy"
 	apiextcontrollerv1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/apiextensions.k8s.io/v1"
 	v1 "github.com/rancher/wrangler/v3/pkg/generated/controllers/apiregistration.k8s.io/v1"
 	"github.com/rancher/wrangler/v3/pkg/schemas"
@@ -42,6 +44,20 @@ type definitionField struct {
 	SubType     string `json:"subtype,omitempty"`
 	Description string `json:"description,omitempty"`
 	Required    bool   `json:"required,omitempty"`
+	// Default is the value the field defaults to when it isn't set
+	Default interface{} `json:"default,omitempty"`
+	// The following are the validation rules of the field, as defined by OpenAPI. They are only known for fields of
+	// CRDs, since the OpenAPI V2 models of other types don't keep them.
+	Enum             []interface{} `json:"enum,omitempty"`
+	Pattern          string        `json:"pattern,omitempty"`
+	Minimum          *float64      `json:"minimum,omitempty"`
+	ExclusiveMinimum bool          `json:"exclusiveMinimum,omitempty"`
+	Maximum          *float64      `json:"maximum,omitempty"`
+	ExclusiveMaximum bool          `json:"exclusiveMaximum,omitempty"`
+	MinLength        *int64        `json:"minLength,omitempty"`
+	MaxLength        *int64        `json:"maxLength,omitempty"`
+	MinItems         *int64        `json:"minItems,omitempty"`
+	MaxItems         *int64        `json:"maxItems,omitempty"`
 }
 
 // Merge merges the provided schema into s. All conflicting values (i.e. that are in both schema and s)
@@ -67,20 +83,42 @@ func (s *schemaDefinition) Merge(schema schemaDefinition) error {
 	return nil
 }
 
-// Register registers the schemaDefinition schema.
+// Register registers the schemaDefinition and schemaBlueprint schemas. The client getter is used by the validate action
+// of schema definitions to dry-run objects with the permissions of the requester.
 func Register(ctx context.Context,
 	baseSchema *types.APISchemas,
 	client discovery.DiscoveryInterface,
 	crd apiextcontrollerv1.CustomResourceDefinitionController,
-	apiService v1.APIServiceController) {
+	apiService v1.APIServiceController,
+	cg proxy.ClientGetter) {
 	handler := NewSchemaDefinitionHandler(baseSchema, crd.Cache(), client)
 	baseSchema.MustAddSchema(types.APISchema{
 		Schema: &schemas.Schema{
 			ID:              "schemaDefinition",
 			PluralName:      "schemaDefinitions",
 			ResourceMethods: []string{"GET"},
+			ResourceActions: map[string]schemas.Action{
+				validateAction: {},
+			},
 		},
 		ByIDHandler: handler.byIDHandler,
+		ActionHandlers: map[string]http.Handler{
+			validateAction: &validator{handler: handler, cg: cg},
+		},
+	})
+	baseSchema.MustAddSchema(types.APISchema{
+		Schema: &schemas.Schema{
+			ID:              "schemaBlueprint",
+			PluralName:      "schemaBlueprints",
+			ResourceMethods: []string{"GET"},
+			ResourceActions: map[string]schemas.Action{
+				renderAction: {},
+			},
+		},
+		ByIDHandler: handler.blueprintByIDHandler,
+		ActionHandlers: map[string]http.Handler{
+			renderAction: http.HandlerFunc(handler.renderBlueprint),
+		},
 	})
 
 	debounce := debounce.DebounceableRefresher{
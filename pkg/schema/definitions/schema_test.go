@@ -24,7 +24,7 @@ func TestRegister(t *testing.T) {
 	crdController.EXPECT().OnChange(ctx, handlerKey, gomock.Any())
 	crdController.EXPECT().Cache().AnyTimes()
 	apisvcController.EXPECT().OnChange(ctx, handlerKey, gomock.Any())
-	Register(ctx, schemas, &client, crdController, apisvcController)
+	Register(ctx, schemas, &client, crdController, apisvcController, nil)
 	registeredSchema := schemas.LookupSchema("schemaDefinition")
 	require.NotNil(t, registeredSchema)
 	require.Len(t, registeredSchema.ResourceMethods, 1)
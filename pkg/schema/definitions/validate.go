@@ -0,0 +1,318 @@
+package definitions
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"unicode/utf8"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/yaml"
+	"k8s.io/client-go/rest"
+)
+
+const validateAction = "validate"
+
+// FieldError is an error of a field of a validated object. Field is the path of the field, such as
+// spec.containers[0].image, and is empty for errors of the whole object.
+type FieldError struct {
+	Field   string `json:"field,omitempty"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// ValidationResult is the result of the validate action. Object is the object as it would be created or updated,
+// returned by the dry-run when the object is valid.
+type ValidationResult struct {
+	Valid  bool                   `json:"valid"`
+	Errors []FieldError           `json:"errors"`
+	Object map[string]interface{} `json:"object,omitempty"`
+}
+
+// validator serves the validate action of schema definitions, which runs an object through the validation rules of
+// the definition of its schema and then, if it passes them, through a dry-run create or update, so that Kubernetes
+// validation and admission are run too.
+type validator struct {
+	handler *SchemaDefinitionHandler
+	cg      proxy.ClientGetter
+}
+
+func (v *validator) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	apiContext := types.GetAPIContext(req.Context())
+	result, err := v.validate(apiContext, req)
+	if err != nil {
+		apiContext.WriteError(proxy.TranslateError(err))
+		return
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	rw.WriteHeader(http.StatusOK)
+	if err := json.NewEncoder(rw).Encode(result); err != nil {
+		logrus.Errorf("failed to write validation result: %v", err)
+	}
+}
+
+func (v *validator) validate(apiContext *types.APIRequest, req *http.Request) (*ValidationResult, error) {
+	object := map[string]interface{}{}
+	if err := yaml.NewYAMLOrJSONDecoder(req.Body, 4096).Decode(&object); err != nil && !errors.Is(err, io.EOF) {
+		return nil, apierror.NewAPIError(validation.InvalidBodyContent, fmt.Sprintf("failed to parse object: %v", err))
+	}
+	schemaDef, requestSchema, err := v.handler.definitionFor(apiContext)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &ValidationResult{Errors: schemaDef.validate(object)}
+	if len(result.Errors) == 0 && v.cg != nil && attributes.GVR(requestSchema).Resource != "" {
+		result.Object, result.Errors, err = v.dryRun(apiContext, requestSchema, object)
+		if err != nil {
+			return nil, err
+		}
+	}
+	if result.Errors == nil {
+		result.Errors = []FieldError{}
+	}
+	result.Valid = len(result.Errors) == 0
+	return result, nil
+}
+
+// dryRun creates the object, or updates it if it has a resource version, without persisting it. Errors of Kubernetes
+// validation and admission are returned as field errors.
+func (v *validator) dryRun(apiContext *types.APIRequest, apiSchema *types.APISchema, object map[string]interface{}) (map[string]interface{}, []FieldError, error) {
+	obj := &unstructured.Unstructured{Object: object}
+	obj.SetGroupVersionKind(attributes.GVK(apiSchema))
+	if attributes.Namespaced(apiSchema) && obj.GetNamespace() == "" {
+		return nil, []FieldError{{Field: "metadata.namespace", Code: validation.MissingRequired.Code, Message: "the namespace is required"}}, nil
+	}
+
+	client, err := v.cg.Client(apiContext, apiSchema, obj.GetNamespace(), rest.NoWarnings{})
+	if err != nil {
+		return nil, nil, err
+	}
+	dryRun := []string{metav1.DryRunAll}
+	var resp *unstructured.Unstructured
+	if obj.GetResourceVersion() != "" {
+		resp, err = client.Update(apiContext.Context(), obj, metav1.UpdateOptions{DryRun: dryRun})
+	} else {
+		resp, err = client.Create(apiContext.Context(), obj, metav1.CreateOptions{DryRun: dryRun})
+	}
+	if err != nil {
+		fieldErrors, ok := statusFieldErrors(err)
+		if !ok {
+			return nil, nil, err
+		}
+		return nil, fieldErrors, nil
+	}
+	return resp.Object, nil, nil
+}
+
+// statusFieldErrors returns the field errors of an error returned by Kubernetes for an invalid or rejected object,
+// and false for any other error.
+func statusFieldErrors(err error) ([]FieldError, bool) {
+	var status apierrors.APIStatus
+	if !errors.As(err, &status) {
+		return nil, false
+	}
+	switch apierrors.ReasonForError(err) {
+	case metav1.StatusReasonInvalid, metav1.StatusReasonBadRequest, metav1.StatusReasonForbidden,
+		metav1.StatusReasonAlreadyExists, metav1.StatusReasonConflict:
+	default:
+		return nil, false
+	}
+
+	details := status.Status().Details
+	if details == nil || len(details.Causes) == 0 {
+		return []FieldError{{Code: string(status.Status().Reason), Message: status.Status().Message}}, true
+	}
+	var fieldErrors []FieldError
+	for _, cause := range details.Causes {
+		fieldErrors = append(fieldErrors, FieldError{
+			Field:   cause.Field,
+			Code:    string(cause.Type),
+			Message: cause.Message,
+		})
+	}
+	return fieldErrors, true
+}
+
+// validate returns the errors of an object against the rules of the definition. The status of the object isn't
+// validated, and fields which aren't in the definition are ignored.
+func (s *schemaDefinition) validate(object map[string]interface{}) []FieldError {
+	def := definition{ResourceFields: map[string]definitionField{}}
+	for name, field := range s.Definitions[s.DefinitionType].ResourceFields {
+		if name != "status" {
+			def.ResourceFields[name] = field
+		}
+	}
+	return s.validateObject("", def, object, map[string]bool{s.DefinitionType: true})
+}
+
+func (s *schemaDefinition) validateObject(path string, def definition, object map[string]interface{}, visiting map[string]bool) []FieldError {
+	var fieldErrors []FieldError
+	for _, name := range sortedKeys(def.ResourceFields) {
+		field := def.ResourceFields[name]
+		fieldPath := name
+		if path != "" {
+			fieldPath = path + "." + name
+		}
+		value, ok := object[name]
+		if !ok || value == nil {
+			if field.Required {
+				fieldErrors = append(fieldErrors, FieldError{Field: fieldPath, Code: validation.MissingRequired.Code, Message: "the field is required"})
+			}
+			continue
+		}
+		fieldErrors = append(fieldErrors, s.validateValue(fieldPath, field, value, visiting)...)
+	}
+	return fieldErrors
+}
+
+func (s *schemaDefinition) validateValue(path string, field definitionField, value interface{}, visiting map[string]bool) []FieldError {
+	fieldError := func(code validation.ErrorCode, format string, args ...interface{}) []FieldError {
+		return []FieldError{{Field: path, Code: code.Code, Message: fmt.Sprintf(format, args...)}}
+	}
+
+	switch field.Type {
+	case "string":
+		// int-or-string fields and quantities are strings in definitions, so numbers are accepted too
+		str, ok := value.(string)
+		if !ok {
+			if _, isNumber := toFloat(value); !isNumber {
+				return fieldError(validation.InvalidType, "must be a string")
+			}
+			break
+		}
+		length := int64(utf8.RuneCountInString(str))
+		if field.MinLength != nil && length < *field.MinLength {
+			return fieldError(validation.MinLengthExceeded, "must be at least %d characters long", *field.MinLength)
+		}
+		if field.MaxLength != nil && length > *field.MaxLength {
+			return fieldError(validation.MaxLengthExceeded, "must be at most %d characters long", *field.MaxLength)
+		}
+		if field.Pattern != "" {
+			if re, err := regexp.Compile(field.Pattern); err == nil && !re.MatchString(str) {
+				return fieldError(validation.InvalidFormat, "must match %s", field.Pattern)
+			}
+		}
+	case "int":
+		number, ok := toFloat(value)
+		if !ok {
+			return fieldError(validation.InvalidType, "must be a number")
+		}
+		if field.Minimum != nil && (number < *field.Minimum || field.ExclusiveMinimum && number == *field.Minimum) {
+			return fieldError(validation.MinLimitExceeded, "must be %s %v", comparison(field.ExclusiveMinimum, "greater than"), *field.Minimum)
+		}
+		if field.Maximum != nil && (number > *field.Maximum || field.ExclusiveMaximum && number == *field.Maximum) {
+			return fieldError(validation.MaxLimitExceeded, "must be %s %v", comparison(field.ExclusiveMaximum, "less than"), *field.Maximum)
+		}
+	case "boolean":
+		if _, ok := value.(bool); !ok {
+			return fieldError(validation.InvalidType, "must be a boolean")
+		}
+	case "array":
+		items, ok := value.([]interface{})
+		if !ok {
+			return fieldError(validation.InvalidType, "must be a list")
+		}
+		if field.MinItems != nil && int64(len(items)) < *field.MinItems {
+			return fieldError(validation.MinLimitExceeded, "must have at least %d items", *field.MinItems)
+		}
+		if field.MaxItems != nil && int64(len(items)) > *field.MaxItems {
+			return fieldError(validation.MaxLimitExceeded, "must have at most %d items", *field.MaxItems)
+		}
+		var fieldErrors []FieldError
+		for i, item := range items {
+			if item != nil {
+				fieldErrors = append(fieldErrors, s.validateValue(path+"["+strconv.Itoa(i)+"]", definitionField{Type: field.SubType}, item, visiting)...)
+			}
+		}
+		return fieldErrors
+	case "map":
+		values, ok := value.(map[string]interface{})
+		if !ok {
+			return fieldError(validation.InvalidType, "must be an object")
+		}
+		var fieldErrors []FieldError
+		for _, key := range sortedKeys(values) {
+			if values[key] != nil {
+				fieldErrors = append(fieldErrors, s.validateValue(path+"["+key+"]", definitionField{Type: field.SubType}, values[key], visiting)...)
+			}
+		}
+		return fieldErrors
+	default:
+		def, ok := s.Definitions[field.Type]
+		if !ok {
+			// types without a definition, such as arbitrary JSON, aren't validated
+			return nil
+		}
+		object, ok := value.(map[string]interface{})
+		if !ok {
+			return fieldError(validation.InvalidType, "must be an object")
+		}
+		if visiting[field.Type] {
+			return nil
+		}
+		visiting[field.Type] = true
+		defer delete(visiting, field.Type)
+		return s.validateObject(path, def, object, visiting)
+	}
+
+	if len(field.Enum) > 0 && !isEnumValue(field.Enum, value) {
+		return fieldError(validation.InvalidOption, "must be one of %v", field.Enum)
+	}
+	return nil
+}
+
+func comparison(exclusive bool, strict string) string {
+	if exclusive {
+		return strict
+	}
+	return strict + " or equal to"
+}
+
+func isEnumValue(enum []interface{}, value interface{}) bool {
+	for _, allowed := range enum {
+		if fmt.Sprint(allowed) == fmt.Sprint(value) {
+			return true
+		}
+	}
+	return false
+}
+
+// toFloat returns the value of a number, decoded from JSON or YAML
+func toFloat(value interface{}) (float64, bool) {
+	switch number := value.(type) {
+	case float64:
+		return number, true
+	case int64:
+		return float64(number), true
+	case int:
+		return float64(number), true
+	case json.Number:
+		f, err := number.Float64()
+		return f, err == nil
+	}
+	return 0, false
+}
+
+func sortedKeys[V any](m map[string]V) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
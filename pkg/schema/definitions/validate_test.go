@@ -0,0 +1,192 @@
+package definitions
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	wschemas "github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"k8s.io/client-go/dynamic"
+	dynamicfake "k8s.io/client-go/dynamic/fake"
+	"k8s.io/client-go/rest"
+	k8stesting "k8s.io/client-go/testing"
+)
+
+func TestValidate(t *testing.T) {
+	float64Ptr := func(f float64) *float64 { return &f }
+	int64Ptr := func(i int64) *int64 { return &i }
+	schemaDef := schemaDefinition{
+		DefinitionType: "io.cattle.management.v2.Widget",
+		Definitions: map[string]definition{
+			"io.cattle.management.v2.Widget": {
+				ResourceFields: map[string]definitionField{
+					"spec":   {Type: "io.cattle.management.v2.Widget.spec", Required: true},
+					"status": {Type: "io.cattle.management.v2.Widget.status", Required: true},
+				},
+			},
+			"io.cattle.management.v2.Widget.spec": {
+				ResourceFields: map[string]definitionField{
+					"name":     {Type: "string", Required: true, Pattern: "^[a-z]+$", MaxLength: int64Ptr(5)},
+					"replicas": {Type: "int", Minimum: float64Ptr(1), Maximum: float64Ptr(3), ExclusiveMaximum: true},
+					"paused":   {Type: "boolean"},
+					"mode":     {Type: "string", Enum: []interface{}{"fast", "slow"}},
+					"port":     {Type: "string"},
+					"ports":    {Type: "array", SubType: "int", MaxItems: int64Ptr(2)},
+					"labels":   {Type: "map", SubType: "string"},
+					"template": {Type: "io.cattle.management.v2.Widget.template"},
+				},
+			},
+			"io.cattle.management.v2.Widget.template": {
+				ResourceFields: map[string]definitionField{
+					"image": {Type: "string", Required: true},
+				},
+			},
+		},
+	}
+
+	tests := []struct {
+		name   string
+		object string
+		want   []FieldError
+	}{
+		{
+			name:   "valid",
+			object: `{"spec": {"name": "web", "replicas": 2, "paused": true, "mode": "fast", "port": 80, "ports": [80], "labels": {"app": "web"}, "template": {"image": "nginx"}}, "other": 1}`,
+		},
+		{
+			name:   "missing required",
+			object: `{"spec": {"template": {}}}`,
+			want: []FieldError{
+				{Field: "spec.name", Code: "MissingRequired", Message: "the field is required"},
+				{Field: "spec.template.image", Code: "MissingRequired", Message: "the field is required"},
+			},
+		},
+		{
+			name:   "invalid types",
+			object: `{"spec": {"name": 1, "replicas": "2", "paused": "yes", "ports": {}, "labels": {"app": true}, "template": []}}`,
+			want: []FieldError{
+				{Field: "spec.labels[app]", Code: "InvalidType", Message: "must be a string"},
+				{Field: "spec.paused", Code: "InvalidType", Message: "must be a boolean"},
+				{Field: "spec.ports", Code: "InvalidType", Message: "must be a list"},
+				{Field: "spec.replicas", Code: "InvalidType", Message: "must be a number"},
+				{Field: "spec.template", Code: "InvalidType", Message: "must be an object"},
+			},
+		},
+		{
+			name:   "invalid values",
+			object: `{"spec": {"name": "Web", "replicas": 3, "mode": "medium", "ports": [1, 2, "three"]}}`,
+			want: []FieldError{
+				{Field: "spec.mode", Code: "InvalidOption", Message: "must be one of [fast slow]"},
+				{Field: "spec.name", Code: "InvalidFormat", Message: "must match ^[a-z]+$"},
+				{Field: "spec.ports", Code: "MaxLimitExceeded", Message: "must have at most 2 items"},
+				{Field: "spec.replicas", Code: "MaxLimitExceeded", Message: "must be less than 3"},
+			},
+		},
+		{
+			name:   "limits",
+			object: `{"spec": {"name": "website", "replicas": 0, "ports": ["80"]}}`,
+			want: []FieldError{
+				{Field: "spec.name", Code: "MaxLengthExceeded", Message: "must be at most 5 characters long"},
+				{Field: "spec.ports[0]", Code: "InvalidType", Message: "must be a number"},
+				{Field: "spec.replicas", Code: "MinLimitExceeded", Message: "must be greater than or equal to 1"},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var object map[string]interface{}
+			require.NoError(t, json.Unmarshal([]byte(test.object), &object))
+			assert.Equal(t, test.want, schemaDef.validate(object))
+		})
+	}
+}
+
+var configMapGVR = schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+
+type clientGetter struct {
+	proxy.ClientGetter
+	client dynamic.Interface
+}
+
+func (c *clientGetter) Client(_ *types.APIRequest, _ *types.APISchema, namespace string, _ rest.WarningHandler) (dynamic.ResourceInterface, error) {
+	return c.client.Resource(configMapGVR).Namespace(namespace), nil
+}
+
+func TestValidateAction(t *testing.T) {
+	configMap := types.APISchema{
+		Schema: &wschemas.Schema{
+			ID:              "configmap",
+			ResourceMethods: []string{"GET"},
+			ResourceFields: map[string]wschemas.Field{
+				"data":      {Type: "map[string]"},
+				"immutable": {Type: "boolean"},
+			},
+		},
+	}
+	attributes.SetGVK(&configMap, schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"})
+	attributes.SetGVR(&configMap, configMapGVR)
+	attributes.SetNamespaced(&configMap, true)
+	baseSchemas := types.EmptyAPISchemas()
+	baseSchemas.MustAddSchema(configMap)
+
+	client := dynamicfake.NewSimpleDynamicClient(runtime.NewScheme())
+	client.PrependReactor("create", "configmaps", func(action k8stesting.Action) (bool, runtime.Object, error) {
+		obj := action.(k8stesting.CreateAction).GetObject()
+		if name := obj.(interface{ GetName() string }).GetName(); name == "rejected" {
+			return true, nil, apierrors.NewInvalid(schema.GroupKind{Kind: "ConfigMap"}, name, field.ErrorList{
+				field.Invalid(field.NewPath("data").Key("bad key"), "bad key", "a valid config key must consist of alphanumeric characters"),
+			})
+		}
+		if name := obj.(interface{ GetName() string }).GetName(); name == "broken" {
+			return true, nil, errors.New("connection refused")
+		}
+		return false, nil, nil
+	})
+	v := &validator{handler: NewSchemaDefinitionHandler(baseSchemas, nil, nil), cg: &clientGetter{client: client}}
+
+	serve := func(body string) (*ValidationResult, error) {
+		req := httptest.NewRequest(http.MethodPost, "/v1/schemaDefinitions/configmap?action=validate", strings.NewReader(body))
+		apiContext := &types.APIRequest{Name: "configmap", Schemas: baseSchemas, Request: req}
+		return v.validate(apiContext, req)
+	}
+
+	result, err := serve(`{"metadata": {"name": "settings", "namespace": "default"}, "data": {"mode": "fast"}}`)
+	require.NoError(t, err)
+	assert.True(t, result.Valid)
+	assert.Empty(t, result.Errors)
+	assert.Equal(t, "ConfigMap", result.Object["kind"], "the object is returned as dry-run")
+
+	result, err = serve(`{"metadata": {"name": "settings", "namespace": "default"}, "immutable": "yes"}`)
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, []FieldError{{Field: "immutable", Code: "InvalidType", Message: "must be a boolean"}}, result.Errors)
+	assert.Nil(t, result.Object, "objects failing validation aren't dry-run")
+
+	result, err = serve(`{"metadata": {"name": "settings"}}`)
+	require.NoError(t, err)
+	assert.Equal(t, []FieldError{{Field: "metadata.namespace", Code: "MissingRequired", Message: "the namespace is required"}}, result.Errors)
+
+	result, err = serve(`{"metadata": {"name": "rejected", "namespace": "default"}}`)
+	require.NoError(t, err)
+	assert.False(t, result.Valid)
+	assert.Equal(t, []FieldError{{
+		Field:   "data[bad key]",
+		Code:    "FieldValueInvalid",
+		Message: `Invalid value: "bad key": a valid config key must consist of alphanumeric characters`,
+	}}, result.Errors)
+
+	_, err = serve(`{"metadata": {"name": "broken", "namespace": "default"}}`)
+	assert.Error(t, err, "errors other than rejections of the object fail the action")
+}
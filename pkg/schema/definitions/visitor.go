@@ -1,6 +1,8 @@
 package definitions
 
 import (
+	"fmt"
+
 	"k8s.io/kube-openapi/pkg/util/proto"
 )
 
@@ -15,6 +17,7 @@ type schemaFieldVisitor struct {
 func (s *schemaFieldVisitor) VisitArray(array *proto.Array) {
 	field := definitionField{
 		Description: array.GetDescription(),
+		Default:     defaultValue(array),
 	}
 	// this currently is not recursive and provides little information for nested types- while this isn't optimal,
 	// it was kept this way to provide backwards compat with previous endpoints.
@@ -30,6 +33,7 @@ func (s *schemaFieldVisitor) VisitArray(array *proto.Array) {
 func (s *schemaFieldVisitor) VisitMap(protoMap *proto.Map) {
 	field := definitionField{
 		Description: protoMap.GetDescription(),
+		Default:     defaultValue(protoMap),
 	}
 	// this currently is not recursive and provides little information for nested types- while this isn't optimal,
 	// it was kept this way to provide backwards compat with previous endpoints.
@@ -44,6 +48,7 @@ func (s *schemaFieldVisitor) VisitMap(protoMap *proto.Map) {
 func (s *schemaFieldVisitor) VisitPrimitive(primitive *proto.Primitive) {
 	field := definitionField{
 		Description: primitive.GetDescription(),
+		Default:     defaultValue(primitive),
 	}
 	field.Type = getPrimitiveType(primitive.Type)
 	s.field = field
@@ -55,6 +60,7 @@ func (s *schemaFieldVisitor) VisitKind(kind *proto.Kind) {
 	field := definitionField{
 		Description: kind.GetDescription(),
 		Type:        path,
+		Default:     defaultValue(kind),
 	}
 	if _, ok := s.definitions[path]; ok {
 		// if we have already seen this kind, we don't want to re-evaluate the definition. Some kinds can be
@@ -96,6 +102,7 @@ func (s *schemaFieldVisitor) VisitReference(ref proto.Reference) {
 		field := definitionField{
 			Description: ref.GetDescription(),
 			Type:        ref.Reference(),
+			Default:     defaultValue(ref),
 		}
 		s.field = field
 		return
@@ -103,6 +110,7 @@ func (s *schemaFieldVisitor) VisitReference(ref proto.Reference) {
 	sub.Accept(s)
 	field := s.field
 	field.Description = ref.GetDescription()
+	field.Default = defaultValue(ref)
 	s.field = field
 }
 
@@ -113,5 +121,31 @@ func (s *schemaFieldVisitor) VisitArbitrary(arb *proto.Arbitrary) {
 	s.field = definitionField{
 		Description: arb.GetDescription(),
 		Type:        "string",
+		Default:     defaultValue(arb),
+	}
+}
+
+// defaultValue returns the default of the schema. Defaults of OpenAPI V2 models are decoded from YAML, so objects are
+// converted to maps with string keys, which can be encoded to JSON.
+func defaultValue(schema proto.Schema) interface{} {
+	return convertYAMLValue(schema.GetDefault())
+}
+
+func convertYAMLValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case map[interface{}]interface{}:
+		result := make(map[string]interface{}, len(v))
+		for key, item := range v {
+			result[fmt.Sprint(key)] = convertYAMLValue(item)
+		}
+		return result
+	case []interface{}:
+		result := make([]interface{}, len(v))
+		for i, item := range v {
+			result[i] = convertYAMLValue(item)
+		}
+		return result
+	default:
+		return value
 	}
 }
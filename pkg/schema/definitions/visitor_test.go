@@ -26,6 +26,20 @@ var (
 		},
 		Type: "number",
 	}
+	protoPrimitiveDefault = proto.Primitive{
+		BaseSchema: proto.BaseSchema{
+			Description: "primitive value - default",
+			Default:     "value",
+		},
+		Type: "string",
+	}
+	protoMapDefault = proto.Map{
+		BaseSchema: proto.BaseSchema{
+			Description: "testMap - default",
+			Default:     map[interface{}]interface{}{"key": []interface{}{map[interface{}]interface{}{"nested": 1}}},
+		},
+		SubType: &protoPrimitive,
+	}
 	protoArray = proto.Array{
 		BaseSchema: proto.BaseSchema{
 			Description: "testArray",
@@ -129,6 +143,27 @@ func TestSchemaFieldVisitor(t *testing.T) {
 				Description: protoPrimitiveNumber.Description,
 			},
 		},
+		{
+			name:            "primitive with default",
+			inputSchema:     &protoPrimitiveDefault,
+			wantDefinitions: map[string]definition{},
+			wantField: definitionField{
+				Type:        protoPrimitiveDefault.Type,
+				Description: protoPrimitiveDefault.Description,
+				Default:     "value",
+			},
+		},
+		{
+			name:            "map with default",
+			inputSchema:     &protoMapDefault,
+			wantDefinitions: map[string]definition{},
+			wantField: definitionField{
+				Type:        "map",
+				Description: protoMapDefault.Description,
+				SubType:     protoPrimitive.Type,
+				Default:     map[string]interface{}{"key": []interface{}{map[string]interface{}{"nested": 1}}},
+			},
+		},
 		{
 			name:        "kind",
 			inputSchema: &protoKind,
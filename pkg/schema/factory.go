@@ -5,6 +5,9 @@ import (
 	"context"
 	"fmt"
 	"net/http"
+	"slices"
+	"sort"
+	"strings"
 	"time"
 
 	"github.com/rancher/apiserver/pkg/builtin"
@@ -32,23 +35,72 @@ func newSchemas() (*types.APISchemas, error) {
 	return apiSchemas, nil
 }
 
+// userSchemas are the schemas computed for a user at a revision of the access set lookup and a generation of the
+// collection
+type userSchemas struct {
+	revision   uint64
+	generation uint64
+	schemas    *types.APISchemas
+}
+
 func (c *Collection) Schemas(user user.Info) (*types.APISchemas, error) {
+	// the revision and generation are read first, so that the schemas computed after are invalidated by any change
+	revisions, tracked := c.as.(accesscontrol.RevisionLookup)
+	var revision uint64
+	if tracked {
+		revision = revisions.Revision()
+	}
+	generation := c.currentGeneration()
+	key := userKey(user)
+	if tracked {
+		if val, ok := c.byUser.Get(key); ok {
+			cached := val.(userSchemas)
+			if cached.revision == revision && cached.generation == generation {
+				return cached.schemas, nil
+			}
+		}
+	}
+
 	access := c.as.AccessFor(user)
 	c.removeOldRecords(access, user)
 	val, ok := c.cache.Get(access.ID)
-	if ok {
-		schemas, _ := val.(*types.APISchemas)
+	if !ok {
+		// concurrent requests of users with the same access share the computation of their schemas
+		var err error
+		val, err, _ = c.computing.Do(access.ID, func() (interface{}, error) {
+			return c.schemasForSubject(access)
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	schemas, _ := val.(*types.APISchemas)
+	// schemas computed before a reset are not cached, since they may lack the schemas added by it
+	if generation != c.currentGeneration() {
 		return schemas, nil
 	}
-
-	schemas, err := c.schemasForSubject(access)
-	if err != nil {
-		return nil, err
+	if !ok {
+		c.addToCache(access, user, schemas)
+	}
+	if tracked {
+		c.byUser.Add(key, userSchemas{revision: revision, generation: generation, schemas: schemas}, 24*time.Hour)
 	}
-	c.addToCache(access, user, schemas)
 	return schemas, nil
 }
 
+func (c *Collection) currentGeneration() uint64 {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.generation
+}
+
+// userKey identifies a user by what its access set is looked up from, its name and groups
+func userKey(user user.Info) string {
+	groups := slices.Clone(user.GetGroups())
+	sort.Strings(groups)
+	return user.GetName() + "\x00" + strings.Join(groups, "\x00")
+}
+
 func (c *Collection) removeOldRecords(access *accesscontrol.AccessSet, user user.Info) {
 	current, ok := c.userCache.Get(user.GetName())
 	if ok {
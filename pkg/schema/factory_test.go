@@ -7,6 +7,7 @@ import (
 	"github.com/stretchr/testify/assert"
 
 	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/accesscontrol"
 	"github.com/rancher/wrangler/v3/pkg/schemas"
 	k8sSchema "k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/apiserver/pkg/authentication/user"
@@ -167,3 +168,56 @@ func makeSchema(resourceType string) *types.APISchema {
 		},
 	}
 }
+
+// revisionLookup is a mockAccessSetLookup tracking its revision and counting the access sets looked up
+type revisionLookup struct {
+	*mockAccessSetLookup
+	revision uint64
+	lookups  int
+}
+
+func (r *revisionLookup) AccessFor(user user.Info) *accesscontrol.AccessSet {
+	r.lookups++
+	return r.mockAccessSetLookup.AccessFor(user)
+}
+
+func (r *revisionLookup) Revision() uint64 {
+	return r.revision
+}
+
+func TestSchemasCachedByRevision(t *testing.T) {
+	lookup := &revisionLookup{mockAccessSetLookup: newMockAccessSetLookup()}
+	testUser := &user.DefaultInfo{Name: "testUser", Groups: []string{"b", "a"}}
+	lookup.AddAccessForUser(testUser, "get", k8sSchema.GroupResource{Group: testGroup, Resource: "testCRD"}, "*", "*")
+	collection := NewCollection(context.TODO(), types.EmptyAPISchemas(), lookup)
+	collection.schemas = map[string]*types.APISchema{"testCRD": makeSchema("testCRD")}
+
+	first, err := collection.Schemas(testUser)
+	assert.NoError(t, err)
+	assert.NotNil(t, first.LookupSchema("testCRD"))
+	second, err := collection.Schemas(&user.DefaultInfo{Name: "testUser", Groups: []string{"a", "b"}})
+	assert.NoError(t, err)
+	assert.Same(t, first, second)
+	assert.Equal(t, 1, lookup.lookups, "access is not looked up again at the same revision")
+
+	// a change of the roles looks the access up again, the schemas being reused if it is the same
+	lookup.revision++
+	third, err := collection.Schemas(testUser)
+	assert.NoError(t, err)
+	assert.Same(t, first, third)
+	assert.Equal(t, 2, lookup.lookups)
+
+	lookup.AddAccessForUser(testUser, "delete", k8sSchema.GroupResource{Group: testGroup, Resource: "testCRD"}, "*", "*")
+	lookup.revision++
+	fourth, err := collection.Schemas(testUser)
+	assert.NoError(t, err)
+	assert.NotSame(t, first, fourth)
+	assert.Contains(t, fourth.LookupSchema("testCRD").ResourceMethods, "DELETE")
+
+	// resetting the schemas invalidates those computed before
+	collection.Reset(map[string]*types.APISchema{"testCRD": makeSchema("testCRD"), "other": makeSchema("other")})
+	fifth, err := collection.Schemas(testUser)
+	assert.NoError(t, err)
+	assert.NotSame(t, fourth, fifth)
+	assert.Equal(t, 4, lookup.lookups)
+}
@@ -2,14 +2,30 @@ package cli
 
 import (
 	"context"
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
 
 	steveauth "github.com/rancher/steve/pkg/auth"
 	authcli "github.com/rancher/steve/pkg/auth/cli"
+	"github.com/rancher/steve/pkg/auth/tokens"
+	"github.com/rancher/steve/pkg/cors"
+	"github.com/rancher/steve/pkg/replication"
+	"github.com/rancher/steve/pkg/resources/virtual/annotations"
 	"github.com/rancher/steve/pkg/server"
+	sqlcachedb "github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/rancher/steve/pkg/stores/proxy"
+	"github.com/rancher/steve/pkg/stores/sqlproxy"
+	"github.com/rancher/steve/pkg/tracing"
 	"github.com/rancher/steve/pkg/ui"
+	"github.com/rancher/steve/pkg/usage"
 	"github.com/rancher/wrangler/v3/pkg/kubeconfig"
 	"github.com/rancher/wrangler/v3/pkg/ratelimit"
+	"github.com/sirupsen/logrus"
 	"github.com/urfave/cli"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/kubernetes"
 )
 
 type Config struct {
@@ -18,8 +34,101 @@ type Config struct {
 	HTTPSListenPort int
 	HTTPListenPort  int
 	UIPath          string
+	// SQLCacheAnnotationColumnsFile is the path to a YAML or JSON list of annotation columns for the SQL cache
+	SQLCacheAnnotationColumnsFile string
+	// SQLCacheHardeningMode controls whether SQL cache list results are verified against the requester's permissions
+	SQLCacheHardeningMode string
+	// SQLCacheDefaultSort is the sort applied by the SQL cache to lists without a sort
+	SQLCacheDefaultSort string
+	// SQLCacheTombstoneRetention is how long deleted objects can still be listed from the SQL cache
+	SQLCacheTombstoneRetention time.Duration
+	// SQLCacheChangeFeedSize is how many changes are kept for each type of the SQL cache
+	SQLCacheChangeFeedSize int
+	// SQLCacheUsageInterval is how often the usage of pods and nodes is scraped from the resource metrics API
+	SQLCacheUsageInterval time.Duration
+	// SQLCacheListBudgetMiB is the estimated memory a list of the SQL cache can take, in MiB
+	SQLCacheListBudgetMiB int64
+	// SQLCacheGlobalListBudgetMiB is the estimated memory concurrent lists of the SQL cache can take, in MiB
+	SQLCacheGlobalListBudgetMiB int64
+	// SQLCacheResultTTL is how long the results of identical lists of the SQL cache are cached
+	SQLCacheResultTTL time.Duration
+	// SQLCacheResultCacheMiB is the estimated memory the cached results of lists of the SQL cache can take, in MiB
+	SQLCacheResultCacheMiB int64
+	// SQLCacheMaintenanceSchedule is the cron-like schedule of the compactions of the SQL cache database
+	SQLCacheMaintenanceSchedule string
+	// SQLCacheSnapshotDir is the directory of the snapshots of the SQL cache database, which are disabled if empty
+	SQLCacheSnapshotDir string
+	// SQLCacheBootstrapSnapshot is the file of a snapshot of the SQL cache database the caches start from, if any
+	SQLCacheBootstrapSnapshot string
+	// SQLCacheTuning are the SQLite settings of the SQL cache database
+	SQLCacheTuning sqlcachedb.Tuning
+	// SQLCacheReadConnections is the number of read-only connections lists of the SQL cache run on
+	SQLCacheReadConnections int
+	// SQLCacheKeepDatabase keeps the SQL cache database of an earlier run, migrated, rather than deleting it on start
+	SQLCacheKeepDatabase bool
+	// SQLCacheReconcile reconciles the tables of a kept SQL cache database with the types discovered on start
+	SQLCacheReconcile bool
+	// SQLCacheWriteBatchWindow is how long events of the SQL cache are batched in a transaction, 0 to disable
+	SQLCacheWriteBatchWindow time.Duration
+	// SQLCacheWriteBatchSize is how many events of the SQL cache are batched in a transaction at most
+	SQLCacheWriteBatchSize int
+	// SQLCacheSyncWorkers is how many objects of the initial sync of a type of the SQL cache are encoded at once
+	SQLCacheSyncWorkers int
+	// SQLCacheExplain logs the query plans of the lists of the SQL cache in debug mode
+	SQLCacheExplain bool
+	// SQLCacheStripManagedFields strips the managed fields of the objects stored in the SQL cache
+	SQLCacheStripManagedFields bool
+	// SQLCacheMaxObjectSizeKiB is the size in KiB above which only the metadata of objects is stored in the SQL cache
+	SQLCacheMaxObjectSizeKiB int64
+	// SQLCacheQueryTimeout is how long each query of the SQL cache can run
+	SQLCacheQueryTimeout time.Duration
+	// SQLCacheConditionTypes are the types of the conditions indexed by the SQL cache, the defaults if empty
+	SQLCacheConditionTypes cli.StringSlice
+	// SQLCacheIndexedExtras are the extra virtual fields indexed by the SQL cache, as {extra} for all types or
+	// {extra}={type} for a single type
+	SQLCacheIndexedExtras cli.StringSlice
+	// RequestFeatures are the experimental features clients may enable for their requests
+	RequestFeatures cli.StringSlice
+	// ConflictRevisionRetention is how long revisions of objects are kept to report changes in update conflicts
+	ConflictRevisionRetention time.Duration
+	// DefaultFieldManager is the field manager of server-side applies which don't set one
+	DefaultFieldManager string
+	// Clusters are additional clusters to serve, as name=kubeconfig
+	Clusters cli.StringSlice
+	// SlowRequestThreshold is the duration above which requests are logged
+	SlowRequestThreshold time.Duration
+	// TokenNamespace is the namespace of the secrets keeping the API tokens, which are disabled if empty
+	TokenNamespace string
+	// Tracing configures the export of OpenTelemetry spans
+	Tracing tracing.Config
+	// CORSAllowedOrigins are the origins of the browser apps allowed to call the API, CORS is disabled if empty
+	CORSAllowedOrigins cli.StringSlice
+	// CORSAllowedHeaders are the request headers apps may set, the defaults if empty
+	CORSAllowedHeaders cli.StringSlice
+	// CORSAllowCredentials allows apps to send cookies and the Authorization header
+	CORSAllowCredentials bool
+	// CORSMaxAge is how long browsers may cache the responses of preflight requests
+	CORSMaxAge time.Duration
+	// CSRF requires the requests changing objects authenticated by cookies to echo the CSRF cookie
+	CSRF bool
+	// CompressionMinSize is the size in bytes above which responses and websocket messages are compressed
+	CompressionMinSize int
+	// SubscribeCoalesceWindow is how long subscribe websockets hold the changes of an object to send only the latest
+	SubscribeCoalesceWindow time.Duration
+	// SubscribeMaxEventsPerSecond is how many events of objects a subscribe websocket is sent per second at most
+	SubscribeMaxEventsPerSecond int
+	// SubscribeQueueSize is how many events are queued for a subscribe websocket before it is reset
+	SubscribeQueueSize int
+	// ShutdownTimeout is how long the requests in flight may take to finish on shutdown
+	ShutdownTimeout time.Duration
+	// URLPrefix is the path the API is served under, the root if empty
+	URLPrefix string
+	// ReplicationTypes are the types which can be replicated to other Steves, as group/version/kind
+	ReplicationTypes cli.StringSlice
 
-	WebhookConfig authcli.WebhookConfig
+	WebhookConfig  authcli.WebhookConfig
+	OIDCConfig     authcli.OIDCConfig
+	KerberosConfig authcli.KerberosConfig
 }
 
 func (c *Config) MustServer(ctx context.Context) *server.Server {
@@ -41,21 +150,218 @@ func (c *Config) ToServer(ctx context.Context, sqlCache bool) (*server.Server, e
 	}
 	restConfig.RateLimiter = ratelimit.None
 
-	if c.WebhookConfig.WebhookAuthentication {
-		auth, err = c.WebhookConfig.WebhookMiddleware()
+	var (
+		authenticators []steveauth.Authenticator
+		apiTokens      *tokens.Manager
+	)
+	if c.TokenNamespace != "" {
+		clientset, err := kubernetes.NewForConfig(restConfig)
+		if err != nil {
+			return nil, err
+		}
+		apiTokens = tokens.NewManager(clientset.CoreV1().Secrets(c.TokenNamespace))
+		authenticators = append(authenticators, apiTokens)
+	}
+	webhook, err := c.WebhookConfig.WebhookAuthenticator()
+	if err != nil {
+		return nil, err
+	}
+	if webhook != nil {
+		authenticators = append(authenticators, webhook)
+		if err := c.WebhookConfig.WatchInvalidations(ctx, restConfig, webhook); err != nil {
+			return nil, err
+		}
+	}
+	oidc, err := c.OIDCConfig.OIDCAuthenticator(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if oidc != nil {
+		authenticators = append(authenticators, oidc)
+	}
+	kerberos, err := c.KerberosConfig.KerberosAuthenticator()
+	if err != nil {
+		return nil, err
+	}
+	if kerberos != nil {
+		authenticators = append(authenticators, kerberos)
+	}
+	switch len(authenticators) {
+	case 0:
+	case 1:
+		auth = steveauth.ToMiddleware(authenticators[0])
+	default:
+		auth = steveauth.ToMiddleware(steveauth.Union(authenticators...))
+	}
+	if kerberos != nil {
+		// clients are asked to negotiate, and sent the token of the server once authenticated
+		authenticate := auth
+		auth = func(next http.Handler) http.Handler {
+			return steveauth.NegotiateChallenge(authenticate(next))
+		}
+	}
+
+	if c.Tracing.Enabled() {
+		shutdown, err := c.Tracing.Setup(ctx)
+		if err != nil {
+			return nil, err
+		}
+		go func() {
+			<-ctx.Done()
+			if err := shutdown(context.Background()); err != nil {
+				logrus.Errorf("failed to flush the spans: %v", err)
+			}
+		}()
+	}
+
+	var corsPolicy *cors.Policy
+	if len(c.CORSAllowedOrigins) > 0 {
+		corsPolicy = &cors.Policy{
+			AllowedOrigins:   c.CORSAllowedOrigins,
+			AllowedHeaders:   c.CORSAllowedHeaders,
+			AllowCredentials: c.CORSAllowCredentials,
+			MaxAge:           c.CORSMaxAge,
+		}
+	}
+
+	var annotationColumns []annotations.Column
+	if sqlCache && c.SQLCacheAnnotationColumnsFile != "" {
+		annotationColumns, err = annotations.LoadColumns(c.SQLCacheAnnotationColumnsFile)
 		if err != nil {
 			return nil, err
 		}
 	}
 
+	hardeningMode, err := sqlproxy.ParseHardeningMode(c.SQLCacheHardeningMode)
+	if err != nil {
+		return nil, err
+	}
+
+	var maintenanceSchedule *sqlcachedb.Schedule
+	if sqlCache && c.SQLCacheMaintenanceSchedule != "" {
+		maintenanceSchedule, err = sqlcachedb.ParseSchedule(c.SQLCacheMaintenanceSchedule)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var tuning *sqlcachedb.Tuning
+	if sqlCache && c.SQLCacheTuning != (sqlcachedb.Tuning{}) {
+		if err := c.SQLCacheTuning.Validate(); err != nil {
+			return nil, err
+		}
+		tuning = &c.SQLCacheTuning
+	}
+
+	if sqlCache && c.SQLCacheWriteBatchWindow > 0 && c.SQLCacheReadConnections <= 0 {
+		return nil, fmt.Errorf("batching events of the SQL cache requires read connections")
+	}
+	if sqlCache && c.SQLCacheReconcile && !c.SQLCacheKeepDatabase {
+		return nil, fmt.Errorf("reconciling the SQL cache database requires keeping it")
+	}
+
+	var replicationTypes []k8sschema.GroupVersionKind
+	for _, s := range c.ReplicationTypes {
+		gvk, err := replication.ParseGVK(s)
+		if err != nil {
+			return nil, err
+		}
+		replicationTypes = append(replicationTypes, gvk)
+	}
+
+	var indexedExtras sqlproxy.IndexedExtras
+	for _, s := range c.SQLCacheIndexedExtras {
+		name, typ, hasType := strings.Cut(s, "=")
+		extra, err := sqlproxy.ParseExtra(name)
+		if err != nil {
+			return nil, err
+		}
+		var gvk k8sschema.GroupVersionKind
+		if hasType {
+			if gvk, err = replication.ParseGVK(typ); err != nil {
+				return nil, err
+			}
+		}
+		if indexedExtras == nil {
+			indexedExtras = sqlproxy.IndexedExtras{}
+		}
+		indexedExtras[gvk] = append(indexedExtras[gvk], extra)
+	}
+
+	var clusters []server.Cluster
+	for _, cluster := range c.Clusters {
+		name, kubeConfig, ok := strings.Cut(cluster, "=")
+		if !ok {
+			return nil, fmt.Errorf("invalid cluster %q, expected name=kubeconfig", cluster)
+		}
+		clusterConfig, err := kubeconfig.GetNonInteractiveClientConfig(kubeConfig).ClientConfig()
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", name, err)
+		}
+		clusterConfig.RateLimiter = ratelimit.None
+		clusters = append(clusters, server.Cluster{
+			Name:       name,
+			RESTConfig: clusterConfig,
+			// each cluster caches its objects in a SQL database of its own, informer_object_cache-{name}.db
+			Options: &server.Options{
+				AuthMiddleware:          auth,
+				SQLCache:                sqlCache,
+				SQLCacheReadConnections: c.SQLCacheReadConnections,
+			},
+		})
+	}
+
 	return server.New(ctx, restConfig, &server.Options{
-		AuthMiddleware: auth,
-		Next:           ui.New(c.UIPath),
-		SQLCache:       sqlCache,
+		AuthMiddleware:              auth,
+		Next:                        ui.New(c.UIPath),
+		SQLCache:                    sqlCache,
+		SQLCacheAnnotationColumns:   annotationColumns,
+		SQLCacheHardeningMode:       hardeningMode,
+		SQLCacheDefaultSort:         c.SQLCacheDefaultSort,
+		SQLCacheTombstoneRetention:  c.SQLCacheTombstoneRetention,
+		SQLCacheChangeFeedSize:      c.SQLCacheChangeFeedSize,
+		SQLCacheUsageInterval:       c.SQLCacheUsageInterval,
+		SQLCacheListBudget:          c.SQLCacheListBudgetMiB << 20,
+		SQLCacheGlobalListBudget:    c.SQLCacheGlobalListBudgetMiB << 20,
+		SQLCacheResultTTL:           c.SQLCacheResultTTL,
+		SQLCacheResultCacheSize:     c.SQLCacheResultCacheMiB << 20,
+		SQLCacheMaintenanceSchedule: maintenanceSchedule,
+		SQLCacheSnapshotDir:         c.SQLCacheSnapshotDir,
+		SQLCacheBootstrapSnapshot:   c.SQLCacheBootstrapSnapshot,
+		SQLCacheTuning:              tuning,
+		SQLCacheReadConnections:     c.SQLCacheReadConnections,
+		SQLCacheKeepDatabase:        c.SQLCacheKeepDatabase,
+		SQLCacheReconcile:           c.SQLCacheReconcile,
+		SQLCacheWriteBatchWindow:    c.SQLCacheWriteBatchWindow,
+		SQLCacheWriteBatchSize:      c.SQLCacheWriteBatchSize,
+		SQLCacheSyncWorkers:         c.SQLCacheSyncWorkers,
+		SQLCacheExplain:             c.SQLCacheExplain,
+		SQLCacheStripManagedFields:  c.SQLCacheStripManagedFields,
+		SQLCacheMaxObjectSize:       c.SQLCacheMaxObjectSizeKiB << 10,
+		SQLCacheQueryTimeout:        c.SQLCacheQueryTimeout,
+		SQLCacheConditionTypes:      c.SQLCacheConditionTypes,
+		SQLCacheIndexedExtras:       indexedExtras,
+		RequestFeatures:             c.RequestFeatures,
+		ConflictRevisionRetention:   c.ConflictRevisionRetention,
+		DefaultFieldManager:         c.DefaultFieldManager,
+		Clusters:                    clusters,
+		SlowRequestThreshold:        c.SlowRequestThreshold,
+		Tokens:                      apiTokens,
+		Tracing:                     c.Tracing.Enabled(),
+		CORS:                        corsPolicy,
+		CSRF:                        c.CSRF,
+		CompressionMinSize:          c.CompressionMinSize,
+		SubscribeCoalesceWindow:     c.SubscribeCoalesceWindow,
+		SubscribeMaxEventsPerSecond: c.SubscribeMaxEventsPerSecond,
+		SubscribeQueueSize:          c.SubscribeQueueSize,
+		ShutdownTimeout:             c.ShutdownTimeout,
+		URLPrefix:                   c.URLPrefix,
+		ReplicationTypes:            replicationTypes,
 	})
 }
 
 func Flags(config *Config) []cli.Flag {
+	defaultTuning := sqlcachedb.DefaultTuning()
 	flags := []cli.Flag{
 		cli.StringFlag{
 			Name:        "kubeconfig",
@@ -81,7 +387,314 @@ func Flags(config *Config) []cli.Flag {
 			Value:       9080,
 			Destination: &config.HTTPListenPort,
 		},
+		cli.StringFlag{
+			Name:        "sql-cache-annotation-columns",
+			Usage:       "Path to a YAML or JSON file of annotations to index as columns when the SQL cache is enabled",
+			Destination: &config.SQLCacheAnnotationColumnsFile,
+		},
+		cli.StringFlag{
+			Name:        "sql-cache-hardening-mode",
+			Usage:       "Verify SQL cache list results against the requester's permissions and either log (log) or also block (block) mismatches",
+			Destination: &config.SQLCacheHardeningMode,
+		},
+		cli.StringFlag{
+			Name:        "sql-cache-default-sort",
+			Usage:       "Sort applied by the SQL cache to lists without a sort param, in the format of the sort param",
+			Destination: &config.SQLCacheDefaultSort,
+		},
+		cli.DurationFlag{
+			Name:        "sql-cache-tombstone-retention",
+			Usage:       "How long deleted objects can still be listed from the SQL cache with the includeDeleted param, 0 to disable",
+			Destination: &config.SQLCacheTombstoneRetention,
+		},
+		cli.IntFlag{
+			Name:        "sql-cache-change-feed-size",
+			Usage:       "How many changes are kept for each type of the SQL cache, listed with the changesSince param, 0 to disable",
+			Destination: &config.SQLCacheChangeFeedSize,
+		},
+		cli.DurationFlag{
+			Name:        "sql-cache-usage-interval",
+			Usage:       "How often the CPU and memory usage of pods and nodes is scraped from the resource metrics API, 0 to disable",
+			Value:       usage.DefaultInterval,
+			Destination: &config.SQLCacheUsageInterval,
+		},
+		cli.Int64Flag{
+			Name:        "sql-cache-list-budget-mib",
+			Usage:       "Estimated memory in MiB a list of the SQL cache can take, larger lists being paginated or rejected, 0 to disable",
+			Destination: &config.SQLCacheListBudgetMiB,
+		},
+		cli.Int64Flag{
+			Name:        "sql-cache-global-list-budget-mib",
+			Usage:       "Estimated memory in MiB concurrent lists of the SQL cache can take, further lists being rejected, 0 to disable",
+			Destination: &config.SQLCacheGlobalListBudgetMiB,
+		},
+		cli.DurationFlag{
+			Name:        "sql-cache-result-ttl",
+			Usage:       "How long the results of identical lists of the SQL cache are cached, unless objects of their type change, 0 to disable",
+			Value:       sqlproxy.DefaultResultTTL,
+			Destination: &config.SQLCacheResultTTL,
+		},
+		cli.Int64Flag{
+			Name:        "sql-cache-result-cache-mib",
+			Usage:       "Estimated memory in MiB the cached results of lists of the SQL cache can take, 0 for no bound",
+			Value:       sqlproxy.DefaultResultCacheSize >> 20,
+			Destination: &config.SQLCacheResultCacheMiB,
+		},
+		cli.StringFlag{
+			Name:        "sql-cache-maintenance-schedule",
+			Usage:       "Cron-like schedule of the compactions of the SQL cache database, such as \"0 3 * * *\", preferably at times of low traffic",
+			Destination: &config.SQLCacheMaintenanceSchedule,
+		},
+		cli.StringFlag{
+			Name:        "sql-cache-snapshot-dir",
+			Usage:       "Directory of the snapshots of the SQL cache database taken by administrators with the cacheSnapshot schema, which is disabled if empty",
+			Destination: &config.SQLCacheSnapshotDir,
+		},
+		cli.StringFlag{
+			Name:        "sql-cache-bootstrap-snapshot",
+			Usage:       "File of a snapshot of the SQL cache database, such as one downloaded from another replica, the caches start from rather than listing every object",
+			Destination: &config.SQLCacheBootstrapSnapshot,
+		},
+		cli.IntFlag{
+			Name:        "sql-cache-read-connections",
+			Usage:       "Number of read-only connections to the SQL cache database lists run on, while events are written on another one, 0 sharing the connections events are written with",
+			Value:       sqlcachedb.DefaultReadConnections,
+			Destination: &config.SQLCacheReadConnections,
+		},
+		cli.BoolFlag{
+			Name:        "sql-cache-keep-database",
+			Usage:       "Keep the SQL cache database of an earlier run on start, migrating its tables in place, rather than deleting it",
+			Destination: &config.SQLCacheKeepDatabase,
+		},
+		cli.BoolFlag{
+			Name:        "sql-cache-reconcile",
+			Usage:       "Drop the tables of the types which weren't discovered from a kept SQL cache database on start, and alter the columns of the others in place, rather than only reporting them",
+			Destination: &config.SQLCacheReconcile,
+		},
+		cli.DurationFlag{
+			Name:        "sql-cache-write-batch-window",
+			Usage:       "How long events are batched in a single transaction of the SQL cache database, delaying lists by as much, 0 to disable. Requires read connections",
+			Destination: &config.SQLCacheWriteBatchWindow,
+		},
+		cli.IntFlag{
+			Name:        "sql-cache-write-batch-size",
+			Usage:       "How many events are batched in a single transaction of the SQL cache database at most",
+			Value:       sqlcachedb.DefaultWriteBatchSize,
+			Destination: &config.SQLCacheWriteBatchSize,
+		},
+		cli.IntFlag{
+			Name:        "sql-cache-sync-workers",
+			Usage:       "Number of workers encoding and encrypting the objects of the initial sync of each type of the SQL cache.",
+			Value:       sqlcachedb.DefaultSyncWorkers,
+			Destination: &config.SQLCacheSyncWorkers,
+		},
+		cli.StringFlag{
+			Name:        "sql-cache-journal-mode",
+			Usage:       "Journal mode of the SQL cache database: WAL, DELETE, TRUNCATE, PERSIST or MEMORY",
+			Value:       defaultTuning.JournalMode,
+			Destination: &config.SQLCacheTuning.JournalMode,
+		},
+		cli.StringFlag{
+			Name:        "sql-cache-synchronous",
+			Usage:       "Synchronous level of the SQL cache database: OFF, NORMAL, FULL or EXTRA",
+			Value:       defaultTuning.Synchronous,
+			Destination: &config.SQLCacheTuning.Synchronous,
+		},
+		cli.IntFlag{
+			Name:        "sql-cache-page-size",
+			Usage:       "Page size of the SQL cache database in bytes, a power of two between 512 and 65536",
+			Value:       defaultTuning.PageSize,
+			Destination: &config.SQLCacheTuning.PageSize,
+		},
+		cli.IntFlag{
+			Name:        "sql-cache-cache-size",
+			Usage:       "Size of the page cache of each connection to the SQL cache database in KiB",
+			Value:       defaultTuning.CacheSizeKiB,
+			Destination: &config.SQLCacheTuning.CacheSizeKiB,
+		},
+		cli.DurationFlag{
+			Name:        "sql-cache-busy-timeout",
+			Usage:       "How long connections to the SQL cache database wait for the transactions of others",
+			Value:       defaultTuning.BusyTimeout,
+			Destination: &config.SQLCacheTuning.BusyTimeout,
+		},
+		cli.Int64Flag{
+			Name:        "sql-cache-mmap-size",
+			Usage:       "Size of the SQL cache database mapped in memory in bytes, 0 to disable memory mapping",
+			Value:       defaultTuning.MmapSize,
+			Destination: &config.SQLCacheTuning.MmapSize,
+		},
+		cli.IntFlag{
+			Name:        "sql-cache-wal-autocheckpoint",
+			Usage:       "Number of pages the write-ahead log of the SQL cache database grows to before it is checkpointed, 0 to disable automatic checkpoints",
+			Value:       defaultTuning.WALAutocheckpoint,
+			Destination: &config.SQLCacheTuning.WALAutocheckpoint,
+		},
+		cli.BoolFlag{
+			Name:        "sql-cache-explain",
+			Usage:       "Log the query plans of the lists of the SQL cache with --debug, warning about those which don't use indexes",
+			Destination: &config.SQLCacheExplain,
+		},
+		cli.BoolFlag{
+			Name:        "sql-cache-strip-managed-fields",
+			Usage:       "Strip the managed fields of the objects stored in the SQL cache, which lists then return without them",
+			Destination: &config.SQLCacheStripManagedFields,
+		},
+		cli.Int64Flag{
+			Name:        "sql-cache-max-object-size-kib",
+			Usage:       "Size in KiB of JSON above which only the metadata and indexed fields of objects are stored in the SQL cache, gets reading them whole from Kubernetes, 0 to disable",
+			Destination: &config.SQLCacheMaxObjectSizeKiB,
+		},
+		cli.DurationFlag{
+			Name:        "sql-cache-query-timeout",
+			Usage:       "How long each query of the SQL cache can run before the request fails with a 504 status, 0 to only bound queries by their request",
+			Value:       sqlproxy.DefaultQueryTimeout,
+			Destination: &config.SQLCacheQueryTimeout,
+		},
+		cli.StringSliceFlag{
+			Name:  "sql-cache-condition-type",
+			Usage: "Type of the conditions of objects of all types set by the SQL cache, can be repeated, defaults to Ready",
+			Value: &config.SQLCacheConditionTypes,
+		},
+		cli.StringSliceFlag{
+			Name:  "sql-cache-indexed-extra",
+			Usage: "Extra virtual fields indexed by the SQL cache, one of owners, problems, application or conditions, for all types or, as {extra}={group/version/kind}, for one type, can be repeated",
+			Value: &config.SQLCacheIndexedExtras,
+		},
+		cli.StringSliceFlag{
+			Name:  "request-feature",
+			Usage: "Experimental feature clients may enable for their requests with the X-Steve-Features header, can be repeated",
+			Value: &config.RequestFeatures,
+		},
+		cli.DurationFlag{
+			Name:        "conflict-revision-retention",
+			Usage:       "How long revisions of objects are kept to report the changes causing update conflicts, 0 to disable",
+			Destination: &config.ConflictRevisionRetention,
+		},
+		cli.StringFlag{
+			Name:        "default-field-manager",
+			Usage:       "Field manager of server-side applies which don't set the fieldManager query parameter",
+			Value:       proxy.DefaultFieldManager,
+			Destination: &config.DefaultFieldManager,
+		},
+		cli.StringSliceFlag{
+			Name:  "cluster",
+			Usage: "Additional cluster to serve under /v1/clusters/{name}/, as name=kubeconfig, can be repeated",
+			Value: &config.Clusters,
+		},
+		cli.DurationFlag{
+			Name:        "slow-request-threshold",
+			Usage:       "Duration above which requests are logged with their filters, sort and page size, 0 to disable",
+			Destination: &config.SlowRequestThreshold,
+		},
+		cli.StringFlag{
+			Name:        "token-namespace",
+			EnvVar:      "TOKEN_NAMESPACE",
+			Usage:       "Namespace of the secrets keeping the API tokens issued by administrators, enables authentication with them",
+			Destination: &config.TokenNamespace,
+		},
+		cli.StringFlag{
+			Name:        "tracing-exporter",
+			EnvVar:      "TRACING_EXPORTER",
+			Usage:       "Exporter of the OpenTelemetry spans of requests, otlp to export them to an OTLP gRPC collector, empty to disable tracing",
+			Destination: &config.Tracing.Exporter,
+		},
+		cli.StringFlag{
+			Name:        "tracing-endpoint",
+			EnvVar:      "TRACING_ENDPOINT",
+			Usage:       "Host and port of the OTLP collector, localhost:4317 by default",
+			Destination: &config.Tracing.Endpoint,
+		},
+		cli.BoolFlag{
+			Name:        "tracing-insecure",
+			EnvVar:      "TRACING_INSECURE",
+			Usage:       "Disable TLS with the OTLP collector",
+			Destination: &config.Tracing.Insecure,
+		},
+		cli.Float64Flag{
+			Name:        "tracing-sample-ratio",
+			EnvVar:      "TRACING_SAMPLE_RATIO",
+			Usage:       "Ratio of the traces started by steve which are sampled, traces continued from clients are sampled if the client sampled them",
+			Value:       1,
+			Destination: &config.Tracing.SampleRatio,
+		},
+		cli.StringSliceFlag{
+			Name:   "cors-allowed-origin",
+			EnvVar: "CORS_ALLOWED_ORIGINS",
+			Usage:  "Origin of the browser apps allowed to call the API, such as https://app.example.com or https://*.example.com, * for all origins, can be repeated",
+			Value:  &config.CORSAllowedOrigins,
+		},
+		cli.StringSliceFlag{
+			Name:   "cors-allowed-header",
+			EnvVar: "CORS_ALLOWED_HEADERS",
+			Usage:  "Request header the browser apps of other origins may set, can be repeated, defaults to the headers of the API",
+			Value:  &config.CORSAllowedHeaders,
+		},
+		cli.BoolFlag{
+			Name:        "cors-allow-credentials",
+			EnvVar:      "CORS_ALLOW_CREDENTIALS",
+			Usage:       "Allow the browser apps of other origins to send cookies and the Authorization header",
+			Destination: &config.CORSAllowCredentials,
+		},
+		cli.DurationFlag{
+			Name:        "cors-max-age",
+			EnvVar:      "CORS_MAX_AGE",
+			Usage:       "Duration browsers may cache the responses of preflight requests",
+			Destination: &config.CORSMaxAge,
+		},
+		cli.BoolFlag{
+			Name:        "csrf",
+			EnvVar:      "CSRF",
+			Usage:       "Require the requests changing objects without an Authorization header to echo the CSRF cookie in the X-API-CSRF header",
+			Destination: &config.CSRF,
+		},
+		cli.IntFlag{
+			Name:        "compression-min-size",
+			EnvVar:      "COMPRESSION_MIN_SIZE",
+			Usage:       "Size in bytes above which responses are compressed with gzip and websocket messages with permessage-deflate, for the clients supporting it, 0 to disable the compression of responses",
+			Value:       8192,
+			Destination: &config.CompressionMinSize,
+		},
+		cli.DurationFlag{
+			Name:        "subscribe-coalesce-window",
+			EnvVar:      "SUBSCRIBE_COALESCE_WINDOW",
+			Usage:       "How long subscribe websockets hold the changes of an object, sending only the latest of them, 0 not to coalesce changes",
+			Destination: &config.SubscribeCoalesceWindow,
+		},
+		cli.IntFlag{
+			Name:        "subscribe-max-events-per-second",
+			EnvVar:      "SUBSCRIBE_MAX_EVENTS_PER_SECOND",
+			Usage:       "How many events of objects a subscribe websocket is sent per second at most, the watches whose events are dropped being sent a resource.resync event, 0 for no limit",
+			Destination: &config.SubscribeMaxEventsPerSecond,
+		},
+		cli.IntFlag{
+			Name:        "subscribe-queue-size",
+			EnvVar:      "SUBSCRIBE_QUEUE_SIZE",
+			Usage:       "How many events are queued for a subscribe websocket whose client is slow, before its watches are sent a too old resource version error and it is closed",
+			Value:       1000,
+			Destination: &config.SubscribeQueueSize,
+		},
+		cli.DurationFlag{
+			Name:        "shutdown-timeout",
+			EnvVar:      "SHUTDOWN_TIMEOUT",
+			Usage:       "Duration the requests in flight may take to finish on shutdown, after subscribe websockets are closed with a resume token",
+			Value:       30 * time.Second,
+			Destination: &config.ShutdownTimeout,
+		},
+		cli.StringFlag{
+			Name:        "url-prefix",
+			EnvVar:      "URL_PREFIX",
+			Usage:       "Path the API is served under, such as /steve, the root if empty",
+			Destination: &config.URLPrefix,
+		},
+		cli.StringSliceFlag{
+			Name:  "replication-type",
+			Usage: "Type which can be replicated to other Steves through the replication schema, as group/version/kind or version/kind for the core group, can be repeated",
+			Value: &config.ReplicationTypes,
+		},
 	}
 
-	return append(flags, authcli.Flags(&config.WebhookConfig)...)
+	flags = append(flags, authcli.Flags(&config.WebhookConfig)...)
+	flags = append(flags, authcli.OIDCFlags(&config.OIDCConfig)...)
+	return append(flags, authcli.KerberosFlags(&config.KerberosConfig)...)
 }
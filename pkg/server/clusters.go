@@ -0,0 +1,484 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/apiserver/pkg/urlbuilder"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/auth"
+	"github.com/rancher/steve/pkg/schema/table"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"github.com/rancher/steve/pkg/stores/sqlproxy"
+	"github.com/rancher/steve/pkg/usage"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/rest"
+)
+
+const (
+	// ClustersPath prefixes the paths of the clusters served by steve: the API of a cluster is served at
+	// /v1/clusters/{name}/, for example /v1/clusters/downstream/v1/pods
+	ClustersPath = "/v1/clusters/"
+	// LocalCluster is the name of the cluster of the server's own RESTConfig
+	LocalCluster = "local"
+	// AllClusters is the name under which lists merging the results of all clusters are served, for example
+	// /v1/clusters/all/v1/pods
+	AllClusters = "all"
+	// clusterField is the field added to the objects of merged lists with the name of their cluster
+	clusterField = "cluster"
+	// maxMergedWindow is the most objects a page of a merged list without a continue token reads from each cluster:
+	// since the objects before the page are needed to sort it, clusters are asked for their objects up to its end
+	maxMergedWindow = 10000
+)
+
+// Cluster is an additional cluster served by steve
+type Cluster struct {
+	// Name of the cluster in paths, which can't be local or all
+	Name       string
+	RESTConfig *rest.Config
+	// Options of the server of the cluster. Their Clusters are ignored, and their AuthMiddleware defaults to that of
+	// the local cluster, so that the clusters are served to the same users. With the SQL cache, their SQLCacheDBPath
+	// defaults to informer_object_cache-{name}.db, since every cluster needs a database of its own
+	Options *Options
+}
+
+// clusters serves the API of each cluster under its path, and merged lists of all clusters
+type clusters struct {
+	names    []string
+	handlers map[string]http.Handler
+}
+
+// newClusters serves the additional clusters next to the local one, whose SQL cache database is localDB, if any
+func newClusters(ctx context.Context, local http.Handler, localAuth auth.Middleware, localDB string, additional []Cluster) (*clusters, error) {
+	result := &clusters{
+		names:    []string{LocalCluster},
+		handlers: map[string]http.Handler{LocalCluster: local},
+	}
+	// clusters are all validated before any of their servers is created. A database of the SQL cache can't be shared
+	// by servers, which would each reset and write it
+	options := make([]Options, len(additional))
+	databases := map[string]string{}
+	if localDB != "" {
+		databases[filepath.Clean(localDB)] = LocalCluster
+	}
+	for i, cluster := range additional {
+		if cluster.Name == "" || cluster.Name == AllClusters || strings.Contains(cluster.Name, "/") {
+			return nil, fmt.Errorf("invalid cluster name %q", cluster.Name)
+		}
+		if _, ok := result.handlers[cluster.Name]; ok {
+			return nil, fmt.Errorf("duplicate cluster name %q", cluster.Name)
+		}
+		result.handlers[cluster.Name] = nil
+		opts := Options{}
+		if cluster.Options != nil {
+			opts = *cluster.Options
+		}
+		opts.Clusters = nil
+		if opts.AuthMiddleware == nil {
+			// without a middleware every request is served as admin, which must not be the default of a cluster
+			// served next to the local one
+			opts.AuthMiddleware = localAuth
+		}
+		if opts.SQLCache {
+			if opts.SQLCacheDBPath == "" {
+				opts.SQLCacheDBPath = fmt.Sprintf("informer_object_cache-%s.db", cluster.Name)
+			}
+			db := filepath.Clean(opts.SQLCacheDBPath)
+			if other, ok := databases[db]; ok {
+				return nil, fmt.Errorf("cluster %s can't use the SQL cache database %s, which is used by cluster %s", cluster.Name, db, other)
+			}
+			databases[db] = cluster.Name
+		}
+		options[i] = opts
+	}
+	for i, cluster := range additional {
+		server, err := New(ctx, cluster.RESTConfig, &options[i])
+		if err != nil {
+			return nil, fmt.Errorf("cluster %s: %w", cluster.Name, err)
+		}
+		result.names = append(result.names, cluster.Name)
+		result.handlers[cluster.Name] = server
+	}
+	return result, nil
+}
+
+// clusterRequest returns a request for the API of a cluster, which builds URLs under the cluster's path
+func clusterRequest(req *http.Request, name, path string) *http.Request {
+	clusterReq := req.Clone(req.Context())
+	clusterReq.URL.Path = path
+	clusterReq.URL.RawPath = ""
+	clusterReq.RequestURI = clusterReq.URL.RequestURI()
+	clusterReq.Header.Set(urlbuilder.PrefixHeader, strings.TrimSuffix(req.Header.Get(urlbuilder.PrefixHeader), "/")+ClustersPath+name)
+	return clusterReq
+}
+
+func (c *clusters) ServeHTTP(rw http.ResponseWriter, req *http.Request) {
+	name, rest, ok := strings.Cut(strings.TrimPrefix(req.URL.Path, ClustersPath), "/")
+	if !strings.HasPrefix(req.URL.Path, ClustersPath) || !ok || rest == "" {
+		c.handlers[LocalCluster].ServeHTTP(rw, req)
+		return
+	}
+
+	if name == AllClusters && req.Method == http.MethodGet && strings.HasPrefix(rest, "v1/") && !strings.Contains(rest[len("v1/"):], "/") {
+		c.serveMergedList(rw, req, "/"+rest)
+		return
+	}
+	handler, ok := c.handlers[name]
+	if !ok {
+		c.handlers[LocalCluster].ServeHTTP(rw, req)
+		return
+	}
+	handler.ServeHTTP(rw, clusterRequest(req, name, "/"+rest))
+}
+
+// mergedList is a list of the objects of all clusters
+type mergedList struct {
+	Type          string            `json:"type"`
+	ResourceType  string            `json:"resourceType,omitempty"`
+	Data          []interface{}     `json:"data"`
+	Count         int               `json:"count"`
+	Pages         int               `json:"pages,omitempty"`
+	Continue      string            `json:"continue,omitempty"`
+	ClusterErrors map[string]string `json:"clusterErrors,omitempty"`
+}
+
+// sortKey is a field merged lists are sorted by
+type sortKey struct {
+	field      []string
+	descending bool
+}
+
+// parseSort parses the sort parameter of a list, a comma separated list of fields in . notation, with labels and
+// annotations as metadata.labels[name], each prefixed by - to sort it in descending order
+func parseSort(param string) []sortKey {
+	var keys []sortKey
+	for _, field := range strings.Split(param, ",") {
+		key := sortKey{}
+		if strings.HasPrefix(field, "-") {
+			key.descending = true
+			field = field[1:]
+		}
+		if field == "" {
+			continue
+		}
+		key.field = listprocessor.SplitField(field)
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+// objectKey returns the key of an object in the SQL cache, its namespace and name, by which objects with equal sort
+// keys are ordered
+func objectKey(obj unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// mergedObject is an object of a merged list, and the index of its cluster
+type mergedObject struct {
+	object  unstructured.Unstructured
+	cluster int
+}
+
+// sortObjects sorts the objects of a merged list by keys, as the SQL cache of each cluster sorts them: values are
+// compared as the types of their columns, values which aren't of the type of a number or date column sorting first,
+// and objects with equal keys by their key, then by cluster. Objects are left in the order of their clusters, and then
+// in the one their cluster returned them in, without keys.
+func sortObjects(objects []mergedObject, keys []sortKey, types listprocessor.ColumnTypes) {
+	if len(keys) == 0 {
+		return
+	}
+	sort.SliceStable(objects, func(i, j int) bool {
+		for _, key := range keys {
+			result := listprocessor.CompareForSort(types.Of(key.field), listprocessor.StringValue(objects[i].object, key.field), listprocessor.StringValue(objects[j].object, key.field))
+			if key.descending {
+				result = -result
+			}
+			if result != 0 {
+				return result < 0
+			}
+		}
+		return objectKey(objects[i].object) < objectKey(objects[j].object)
+	})
+}
+
+// mergedContinue is the position reached in each cluster by the pages of a merged list, the number of its objects
+// listed by a page and the pages before it, encoded in the continue token of the page. Clusters missing from it, added
+// since the first page, aren't listed by the next pages.
+type mergedContinue map[string]int
+
+func (m mergedContinue) encode() string {
+	data, _ := json.Marshal(m)
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+func decodeContinue(token string) (mergedContinue, error) {
+	data, err := base64.RawURLEncoding.DecodeString(token)
+	if err != nil {
+		return nil, err
+	}
+	result := mergedContinue{}
+	if err := json.Unmarshal(data, &result); err != nil {
+		return nil, err
+	}
+	for name, offset := range result {
+		if offset < 0 {
+			return nil, fmt.Errorf("negative position %d of cluster %s", offset, name)
+		}
+	}
+	return result, nil
+}
+
+// listResponse is the response of the server of a cluster to a list
+type listResponse struct {
+	header http.Header
+	code   int
+	body   bytes.Buffer
+}
+
+func (r *listResponse) Header() http.Header {
+	return r.header
+}
+
+func (r *listResponse) WriteHeader(code int) {
+	if r.code == 0 {
+		r.code = code
+	}
+}
+
+func (r *listResponse) Write(p []byte) (int, error) {
+	r.WriteHeader(http.StatusOK)
+	return r.body.Write(p)
+}
+
+// clusterList is a list of the objects of a cluster
+type clusterList struct {
+	ResourceType string                   `json:"resourceType"`
+	Data         []map[string]interface{} `json:"data"`
+	Count        *int                     `json:"count"`
+}
+
+// serve serves a GET request of path with query to the server of a cluster, decoding its JSON response into target.
+// It returns the message of the error returned by the server, if any.
+func (c *clusters) serve(req *http.Request, name, path string, query url.Values, target any) error {
+	clusterReq := clusterRequest(req, name, path)
+	clusterReq.URL.RawQuery = query.Encode()
+	clusterReq.RequestURI = clusterReq.URL.RequestURI()
+	clusterReq.Header.Set("Accept", "application/json")
+	resp := &listResponse{header: http.Header{}}
+	c.handlers[name].ServeHTTP(resp, clusterReq)
+	if resp.code == 0 {
+		resp.code = http.StatusOK
+	}
+	// numbers are kept as they were written, as the SQL cache compares them when they aren't in number columns
+	decoder := json.NewDecoder(bytes.NewReader(resp.body.Bytes()))
+	decoder.UseNumber()
+	err := decoder.Decode(target)
+	if resp.code != http.StatusOK || err != nil {
+		var failure struct {
+			Message string `json:"message"`
+		}
+		if json.Unmarshal(resp.body.Bytes(), &failure) == nil && failure.Message != "" {
+			return errors.New(failure.Message)
+		}
+		if resp.code != http.StatusOK {
+			return errors.New(http.StatusText(resp.code))
+		}
+		return err
+	}
+	return nil
+}
+
+// listWindow returns up to window objects of a cluster from offset in its order, and the count of its objects. They
+// are listed with the page and pagesize params, with a page size of window, which is one page, or two if offset isn't
+// at the start of a page. The whole list is returned if window is zero.
+func (c *clusters) listWindow(req *http.Request, name, path string, query url.Values, offset, window int) ([]map[string]interface{}, int, string, error) {
+	pages := []int{0}
+	if window > 0 {
+		pages = []int{offset/window + 1}
+		if offset%window != 0 {
+			pages = append(pages, offset/window+2)
+		}
+	}
+	var objects []map[string]interface{}
+	count, resourceType := -1, ""
+	for _, page := range pages {
+		pageQuery := url.Values{}
+		for key, values := range query {
+			pageQuery[key] = values
+		}
+		if page > 0 {
+			pageQuery.Set("page", strconv.Itoa(page))
+			pageQuery.Set("pagesize", strconv.Itoa(window))
+		}
+		var list clusterList
+		if err := c.serve(req, name, path, pageQuery, &list); err != nil {
+			return nil, 0, "", err
+		}
+		if count < 0 {
+			count, resourceType = offset+len(list.Data), list.ResourceType
+			if list.Count != nil {
+				count = *list.Count
+			}
+		}
+		objects = append(objects, list.Data...)
+	}
+	if window > 0 {
+		objects = objects[min(offset%window, len(objects)):]
+		objects = objects[:min(window, len(objects))]
+	}
+	return objects, count, resourceType, nil
+}
+
+// columnTypes returns the types the SQL cache of a cluster compares the columns of a type as when sorting them, read
+// from the schema of the type served by the cluster
+func (c *clusters) columnTypes(req *http.Request, name, resourceType string) listprocessor.ColumnTypes {
+	var schema struct {
+		Attributes struct {
+			Columns []table.Column `json:"columns"`
+		} `json:"attributes"`
+	}
+	apiSchema := &types.APISchema{Schema: &schemas.Schema{Attributes: map[string]interface{}{}}}
+	if resourceType != "" {
+		if err := c.serve(req, name, "/v1/schemas/"+resourceType, url.Values{}, &schema); err == nil && len(schema.Attributes.Columns) > 0 {
+			attributes.SetColumns(apiSchema, schema.Attributes.Columns)
+		}
+	}
+	return sqlproxy.ColumnTypes(apiSchema, [][]string{usage.CPUField, usage.MemoryField})
+}
+
+// serveMergedList lists the objects of a type in every cluster, adding the name of their cluster to the objects.
+// Clusters failing to list them are reported in the clusterErrors field.
+//
+// The objects are sorted and paginated across clusters, and the count is the sum of the counts of the clusters. Each
+// cluster is asked for its objects from the position the continue token of the previous page reached in it, a page
+// of them, which are sorted together before the page is taken. Without a continue token, each cluster is asked for the
+// objects up to the end of the requested page, which can't exceed maxMergedWindow objects.
+func (c *clusters) serveMergedList(rw http.ResponseWriter, req *http.Request, path string) {
+	query := req.URL.Query()
+	keys := parseSort(query.Get("sort"))
+	pageSize, _ := strconv.Atoi(query.Get("pagesize"))
+	pageSize = max(pageSize, 0)
+	page, err := strconv.Atoi(query.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+	offsets := mergedContinue{}
+	for _, name := range c.names {
+		offsets[name] = 0
+	}
+	window, skip := 0, 0
+	if token := query.Get("continue"); token != "" && pageSize > 0 {
+		offsets, err = decodeContinue(token)
+		if err != nil {
+			http.Error(rw, fmt.Sprintf("invalid continue token: %v", err), http.StatusBadRequest)
+			return
+		}
+		window = pageSize
+	} else if pageSize > 0 {
+		if page > maxMergedWindow/pageSize {
+			http.Error(rw, fmt.Sprintf("merged lists read at most %d objects of each cluster, up to the end of the page: list later pages with the continue token of the page before them", maxMergedWindow), http.StatusBadRequest)
+			return
+		}
+		window, skip = page*pageSize, (page-1)*pageSize
+	}
+	query.Del("page")
+	query.Del("pagesize")
+	query.Del("continue")
+
+	type clusterResult struct {
+		listed       bool
+		objects      []map[string]interface{}
+		count        int
+		resourceType string
+		err          error
+	}
+	results := make([]clusterResult, len(c.names))
+	var wg sync.WaitGroup
+	for i, name := range c.names {
+		offset, ok := offsets[name]
+		if !ok {
+			continue
+		}
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			objects, count, resourceType, err := c.listWindow(req, name, path, query, offset, window)
+			results[i] = clusterResult{listed: true, objects: objects, count: count, resourceType: resourceType, err: err}
+		}()
+	}
+	wg.Wait()
+
+	result := mergedList{
+		Type: "collection",
+		Data: []interface{}{},
+	}
+	var objects []mergedObject
+	typesFrom := ""
+	for i, name := range c.names {
+		if !results[i].listed {
+			continue
+		}
+		if results[i].err != nil {
+			if result.ClusterErrors == nil {
+				result.ClusterErrors = map[string]string{}
+			}
+			result.ClusterErrors[name] = results[i].err.Error()
+			continue
+		}
+		if typesFrom == "" {
+			typesFrom = name
+			result.ResourceType = results[i].resourceType
+		}
+		result.Count += results[i].count
+		for _, obj := range results[i].objects {
+			obj[clusterField] = name
+			objects = append(objects, mergedObject{object: unstructured.Unstructured{Object: obj}, cluster: i})
+		}
+	}
+
+	if len(keys) > 0 && typesFrom != "" {
+		sortObjects(objects, keys, c.columnTypes(req, typesFrom, result.ResourceType))
+	}
+	if pageSize > 0 {
+		end := min(skip+pageSize, len(objects))
+		next := mergedContinue{}
+		for name, offset := range offsets {
+			next[name] = offset
+		}
+		for _, obj := range objects[:end] {
+			next[c.names[obj.cluster]]++
+		}
+		for i, name := range c.names {
+			if results[i].listed && results[i].err == nil && next[name] < results[i].count {
+				result.Continue = next.encode()
+				break
+			}
+		}
+		objects = objects[min(skip, end):end]
+		result.Pages = (result.Count + pageSize - 1) / pageSize
+	}
+	for _, obj := range objects {
+		result.Data = append(result.Data, obj.object.Object)
+	}
+
+	rw.Header().Set("Content-Type", "application/json")
+	if err := json.NewEncoder(rw).Encode(result); err != nil {
+		http.Error(rw, err.Error(), http.StatusInternalServerError)
+	}
+}
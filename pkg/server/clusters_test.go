@@ -0,0 +1,238 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strconv"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/urlbuilder"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// clusterHandler serves a list of pods named after the cluster, recording the requests it receives
+func clusterHandler(name string, requests *[]string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		*requests = append(*requests, req.Header.Get(urlbuilder.PrefixHeader)+" "+req.URL.Path)
+		if name == "broken" {
+			rw.WriteHeader(http.StatusForbidden)
+			_, _ = rw.Write([]byte(`{"type":"error","message":"forbidden"}`))
+			return
+		}
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+			"type":         "collection",
+			"resourceType": "pod",
+			"data":         []interface{}{map[string]interface{}{"id": name + "-pod"}},
+		})
+	})
+}
+
+// sortingHandler serves a list of pods with the given restart counts, sorted by them and paginated as steve does,
+// recording the pages it is asked for, and the schema of pods, whose restarts column is an integer
+func sortingHandler(name string, requests *[]string, restarts ...int) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == "/v1/schemas/pod" {
+			_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+				"id":         "pod",
+				"attributes": map[string]interface{}{"columns": []interface{}{map[string]interface{}{"name": "Restarts", "field": "$.restarts", "type": "integer"}}},
+			})
+			return
+		}
+		query := req.URL.Query()
+		*requests = append(*requests, query.Get("page")+"x"+query.Get("pagesize"))
+		sort.Ints(restarts)
+		if query.Get("sort") == "-restarts" {
+			sort.Sort(sort.Reverse(sort.IntSlice(restarts)))
+		}
+		var data []interface{}
+		for _, count := range restarts {
+			id := name + "-" + strconv.Itoa(count)
+			data = append(data, map[string]interface{}{"id": id, "metadata": map[string]interface{}{"name": id}, "restarts": count})
+		}
+		count := len(data)
+		if pageSize, _ := strconv.Atoi(query.Get("pagesize")); pageSize > 0 {
+			page, _ := strconv.Atoi(query.Get("page"))
+			start := min(max(page-1, 0)*pageSize, len(data))
+			data = data[start:min(start+pageSize, len(data))]
+		}
+		_ = json.NewEncoder(rw).Encode(map[string]interface{}{
+			"type":         "collection",
+			"resourceType": "pod",
+			"data":         data,
+			"count":        count,
+		})
+	})
+}
+
+func TestMergedListPagination(t *testing.T) {
+	var localRequests, downstreamRequests []string
+	c := &clusters{
+		names: []string{LocalCluster, "downstream"},
+		handlers: map[string]http.Handler{
+			LocalCluster: sortingHandler(LocalCluster, &localRequests, 1, 4, 5, 8),
+			"downstream": sortingHandler("downstream", &downstreamRequests, 2, 3, 4, 9),
+		},
+	}
+	serve := func(query string) *httptest.ResponseRecorder {
+		rw := httptest.NewRecorder()
+		c.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/clusters/all/v1/pods?"+query, nil))
+		return rw
+	}
+	list := func(query string) ([]string, mergedList) {
+		rw := serve(query)
+		require.Equal(t, http.StatusOK, rw.Code)
+		var list mergedList
+		require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &list))
+		var ids []string
+		for _, obj := range list.Data {
+			ids = append(ids, obj.(map[string]interface{})["id"].(string))
+		}
+		return ids, list
+	}
+
+	ids, merged := list("sort=restarts&pagesize=3")
+	assert.Equal(t, []string{"local-1", "downstream-2", "downstream-3"}, ids)
+	assert.Equal(t, 8, merged.Count)
+	assert.Equal(t, 3, merged.Pages)
+	assert.NotEmpty(t, merged.Continue)
+
+	ids, _ = list("sort=restarts&pagesize=3&page=2")
+	assert.Equal(t, []string{"downstream-4", "local-4", "local-5"}, ids, "equal keys are ordered by the key of the objects")
+
+	ids, _ = list("sort=-restarts&pagesize=3&page=3")
+	assert.Equal(t, []string{"downstream-2", "local-1"}, ids)
+
+	ids, merged = list("sort=restarts&pagesize=3&page=4")
+	assert.Empty(t, ids)
+	assert.Empty(t, merged.Continue)
+
+	ids, merged = list("sort=-restarts")
+	assert.Len(t, ids, 8)
+	assert.Equal(t, "downstream-9", ids[0])
+	assert.Equal(t, 8, merged.Count)
+	assert.Zero(t, merged.Pages)
+	assert.Empty(t, merged.Continue)
+
+	// continue tokens hold the position reached in each cluster, which is asked for one page from there
+	localRequests, downstreamRequests = nil, nil
+	var all []string
+	token := ""
+	for {
+		ids, merged := list("sort=restarts&pagesize=3&continue=" + token)
+		all = append(all, ids...)
+		if merged.Continue == "" {
+			break
+		}
+		token = merged.Continue
+	}
+	assert.Equal(t, []string{"local-1", "downstream-2", "downstream-3", "downstream-4", "local-4", "local-5", "local-8", "downstream-9"}, all)
+	for _, request := range append(localRequests, downstreamRequests...) {
+		assert.Equal(t, "x3", request[len(request)-2:], "clusters are only asked for pages of the page size")
+	}
+
+	// pages without a continue token can't read more than maxMergedWindow objects of each cluster
+	assert.Equal(t, http.StatusBadRequest, serve("pagesize=100&page=101").Code)
+	assert.Equal(t, http.StatusBadRequest, serve(fmt.Sprintf("pagesize=%d", maxMergedWindow+1)).Code)
+	assert.Equal(t, http.StatusOK, serve(fmt.Sprintf("pagesize=%d&continue=%s", maxMergedWindow+1, mergedContinue{LocalCluster: 0}.encode())).Code)
+	assert.Equal(t, http.StatusBadRequest, serve("pagesize=3&continue=invalid").Code)
+}
+
+func TestSortObjects(t *testing.T) {
+	objects := func(values ...interface{}) []mergedObject {
+		var result []mergedObject
+		for i, value := range values {
+			result = append(result, mergedObject{object: unstructured.Unstructured{Object: map[string]interface{}{
+				"metadata": map[string]interface{}{"name": strconv.Itoa(i)},
+				"spec":     map[string]interface{}{"value": value},
+			}}})
+		}
+		return result
+	}
+	values := func(objects []mergedObject) []interface{} {
+		var result []interface{}
+		for _, obj := range objects {
+			result = append(result, obj.object.Object["spec"].(map[string]interface{})["value"])
+		}
+		return result
+	}
+	keys := parseSort("spec.value")
+
+	// values are compared as the text the SQL cache stores, unless their column has a type
+	sorted := objects(json.Number("10"), json.Number("9"), "", "a")
+	sortObjects(sorted, keys, nil)
+	assert.Equal(t, []interface{}{"", json.Number("10"), json.Number("9"), "a"}, values(sorted))
+
+	sorted = objects(json.Number("10"), json.Number("9"), "", "a")
+	sortObjects(sorted, keys, listprocessor.ColumnTypes{"spec.value": listprocessor.NumberColumn})
+	assert.Equal(t, []interface{}{"", "a", json.Number("9"), json.Number("10")}, values(sorted), "values which aren't numbers sort first")
+}
+
+func TestParseSort(t *testing.T) {
+	assert.Equal(t, []sortKey{
+		{field: []string{"metadata", "labels[app.kubernetes.io/name]"}, descending: true},
+		{field: []string{"metadata", "name"}},
+	}, parseSort("-metadata.labels[app.kubernetes.io/name],metadata.name"))
+	assert.Empty(t, parseSort(""))
+}
+
+func TestClusters(t *testing.T) {
+	var localRequests, downstreamRequests, brokenRequests []string
+	c := &clusters{
+		names: []string{LocalCluster, "downstream", "broken"},
+		handlers: map[string]http.Handler{
+			LocalCluster: clusterHandler(LocalCluster, &localRequests),
+			"downstream": clusterHandler("downstream", &downstreamRequests),
+			"broken":     clusterHandler("broken", &brokenRequests),
+		},
+	}
+
+	for _, path := range []string{"/v1/pods", "/v1/clusters/local", "/v1/clusters/unknown/v1/pods", "/v1/clusters/local/v1/pods", "/v1/clusters/downstream/v1/pods/default/foo"} {
+		c.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.Equal(t, []string{
+		" /v1/pods",
+		" /v1/clusters/local",
+		" /v1/clusters/unknown/v1/pods",
+		"/v1/clusters/local /v1/pods",
+	}, localRequests)
+	assert.Equal(t, []string{"/v1/clusters/downstream /v1/pods/default/foo"}, downstreamRequests)
+
+	rw := httptest.NewRecorder()
+	c.ServeHTTP(rw, httptest.NewRequest(http.MethodGet, "/v1/clusters/all/v1/pods?limit=10", nil))
+	require.Equal(t, http.StatusOK, rw.Code)
+	var list mergedList
+	require.NoError(t, json.Unmarshal(rw.Body.Bytes(), &list))
+	assert.Equal(t, mergedList{
+		Type:         "collection",
+		ResourceType: "pod",
+		Data: []interface{}{
+			map[string]interface{}{"id": "local-pod", "cluster": "local"},
+			map[string]interface{}{"id": "downstream-pod", "cluster": "downstream"},
+		},
+		Count:         2,
+		ClusterErrors: map[string]string{"broken": "forbidden"},
+	}, list)
+	assert.Equal(t, "/v1/clusters/downstream /v1/pods", downstreamRequests[1])
+}
+
+func TestNewClustersValidation(t *testing.T) {
+	for _, cluster := range []Cluster{{Name: ""}, {Name: AllClusters}, {Name: LocalCluster}, {Name: "a/b"}} {
+		_, err := newClusters(context.TODO(), http.NotFoundHandler(), nil, "", []Cluster{cluster})
+		assert.Error(t, err, cluster.Name)
+	}
+	// clusters can't share the database of their SQL cache
+	_, err := newClusters(context.TODO(), http.NotFoundHandler(), nil, "informer_object_cache.db", []Cluster{{Name: "downstream", Options: &Options{SQLCache: true, SQLCacheDBPath: "./informer_object_cache.db"}}})
+	assert.ErrorContains(t, err, "used by cluster local")
+	_, err = newClusters(context.TODO(), http.NotFoundHandler(), nil, "", []Cluster{
+		{Name: "downstream", Options: &Options{SQLCache: true, SQLCacheDBPath: "cache.db"}},
+		{Name: "other", Options: &Options{SQLCache: true, SQLCacheDBPath: "cache.db"}},
+	})
+	assert.ErrorContains(t, err, "used by cluster downstream")
+}
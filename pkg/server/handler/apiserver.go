@@ -11,13 +11,25 @@ import (
 	k8sproxy "github.com/rancher/steve/pkg/proxy"
 	"github.com/rancher/steve/pkg/schema"
 	"github.com/rancher/steve/pkg/server/router"
+	"github.com/rancher/steve/pkg/tracing"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/attribute"
 	"k8s.io/apiserver/pkg/endpoints/request"
 	"k8s.io/client-go/rest"
 )
 
+// APIVersion is an additional version of the /v1 API, served under /{Name} from the same schemas and stores
+type APIVersion struct {
+	// Name is the path segment of the version, such as v2
+	Name string
+	// Customize is called with the requests of the version before they are handled. It can set their ResponseWriter
+	// to change the serialization of the version, or change their schemas.
+	Customize func(apiOp *types.APIRequest)
+}
+
 func New(cfg *rest.Config, sf schema.Factory, authMiddleware auth.Middleware, next http.Handler,
-	routerFunc router.RouterFunc, extensionAPIServer http.Handler) (*apiserver.Server, http.Handler, error) {
+	routerFunc router.RouterFunc, extensionAPIServer http.Handler, aggregatedAPIs []k8sproxy.AggregatedAPI,
+	versions []APIVersion) (*apiserver.Server, http.Handler, error) {
 	var (
 		proxy http.Handler
 		err   error
@@ -29,14 +41,18 @@ func New(cfg *rest.Config, sf schema.Factory, authMiddleware auth.Middleware, ne
 	}
 	a.server.AccessControl = accesscontrol.NewAccessControl()
 
+	proxy, err = k8sproxy.UserHandler("/", cfg, authMiddleware != nil)
+	if err != nil {
+		return a.server, nil, err
+	}
 	if authMiddleware == nil {
-		proxy, err = k8sproxy.Handler("/", cfg)
+		authMiddleware = auth.ToMiddleware(auth.AuthenticatorFunc(auth.AlwaysAdmin))
+	}
+	if len(aggregatedAPIs) > 0 {
+		proxy, err = k8sproxy.AggregatedHandler(aggregatedAPIs, proxy)
 		if err != nil {
 			return a.server, nil, err
 		}
-		authMiddleware = auth.ToMiddleware(auth.AuthenticatorFunc(auth.AlwaysAdmin))
-	} else {
-		proxy = k8sproxy.ImpersonatingHandler("/", cfg)
 	}
 
 	w := authMiddleware
@@ -45,6 +61,16 @@ func New(cfg *rest.Config, sf schema.Factory, authMiddleware auth.Middleware, ne
 		K8sResource: w(a.apiHandler(k8sAPI)),
 		K8sProxy:    w(proxy),
 		APIRoot:     w(a.apiHandler(apiRoot)),
+		OpenAPI:     w(a.openAPIHandler()),
+	}
+	for _, version := range versions {
+		if handlers.Versions == nil {
+			handlers.Versions = map[string]router.Version{}
+		}
+		handlers.Versions[version.Name] = router.Version{
+			K8sResource: w(a.versionHandler(version, k8sAPI)),
+			APIRoot:     w(a.versionHandler(version, apiRoot)),
+		}
 	}
 	if extensionAPIServer != nil {
 		handlers.ExtensionAPIServer = w(extensionAPIServer)
@@ -60,20 +86,22 @@ type apiServer struct {
 	server *apiserver.Server
 }
 
-func (a *apiServer) common(rw http.ResponseWriter, req *http.Request) (*types.APIRequest, bool) {
+func (a *apiServer) common(rw http.ResponseWriter, req *http.Request, version string) (*types.APIRequest, bool) {
 	user, ok := request.UserFrom(req.Context())
 	if !ok {
 		return nil, false
 	}
 
+	_, span := tracing.Start(req.Context(), "schema.lookup", attribute.String("steve.user", user.GetName()))
 	schemas, err := a.sf.Schemas(user)
+	tracing.End(span, err)
 	if err != nil {
 		logrus.Errorf("HTTP request failed: %v", err)
 		rw.Write([]byte(err.Error()))
 		rw.WriteHeader(http.StatusInternalServerError)
 	}
 
-	urlBuilder, err := urlbuilder.NewPrefixed(req, schemas, "v1")
+	urlBuilder, err := urlbuilder.NewPrefixed(req, schemas, version)
 	if err != nil {
 		rw.Write([]byte(err.Error()))
 		rw.WriteHeader(http.StatusInternalServerError)
@@ -81,22 +109,30 @@ func (a *apiServer) common(rw http.ResponseWriter, req *http.Request) (*types.AP
 	}
 
 	return &types.APIRequest{
-		Schemas:    schemas,
-		Request:    req,
-		Response:   rw,
-		URLBuilder: urlBuilder,
+		Schemas:      schemas,
+		Request:      req,
+		Response:     rw,
+		URLBuilder:   urlBuilder,
+		ErrorHandler: errorHandler,
 	}, true
 }
 
 type APIFunc func(schema.Factory, *types.APIRequest)
 
 func (a *apiServer) apiHandler(apiFunc APIFunc) http.Handler {
+	return a.versionHandler(APIVersion{Name: "v1"}, apiFunc)
+}
+
+func (a *apiServer) versionHandler(version APIVersion, apiFunc APIFunc) http.Handler {
 	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
-		apiOp, ok := a.common(rw, req)
+		apiOp, ok := a.common(rw, req, version.Name)
 		if ok {
 			if apiFunc != nil {
 				apiFunc(a.sf, apiOp)
 			}
+			if version.Customize != nil {
+				version.Customize(apiOp)
+			}
 			a.server.Handle(apiOp)
 		}
 	})
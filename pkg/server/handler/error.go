@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"errors"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/handlers"
+	"github.com/rancher/apiserver/pkg/types"
+)
+
+// detailedError is implemented by the causes of APIErrors which add details to the error response
+type detailedError interface {
+	error
+	Details() interface{}
+}
+
+// errorHandler writes errors like the default apiserver error handler, adding the details of the error's cause if it
+// has any
+func errorHandler(request *types.APIRequest, err error) {
+	var apiError *apierror.APIError
+	if !errors.As(err, &apiError) {
+		handlers.ErrorHandler(request, err)
+		return
+	}
+	detailed, ok := apiError.Cause.(detailedError)
+	if !ok {
+		handlers.ErrorHandler(request, err)
+		return
+	}
+
+	e := map[string]interface{}{
+		"type":    "error",
+		"status":  apiError.Code.Status,
+		"code":    apiError.Code.Code,
+		"message": apiError.Message,
+		"details": detailed.Details(),
+	}
+	if apiError.FieldName != "" {
+		e["fieldName"] = apiError.FieldName
+	}
+	request.WriteResponse(apiError.Code.Status, types.APIObject{
+		Type:   "error",
+		Object: e,
+	})
+}
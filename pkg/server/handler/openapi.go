@@ -0,0 +1,23 @@
+package handler
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"github.com/rancher/steve/pkg/openapi"
+	"github.com/sirupsen/logrus"
+)
+
+// openAPIHandler serves the OpenAPI v3 document of the schemas the user can access
+func (a *apiServer) openAPIHandler() http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		apiOp, ok := a.common(rw, req, "v1")
+		if !ok {
+			return
+		}
+		rw.Header().Set("Content-Type", "application/json")
+		if err := json.NewEncoder(rw).Encode(openapi.Document(apiOp.Schemas)); err != nil {
+			logrus.Errorf("failed to write OpenAPI document: %v", err)
+		}
+	})
+}
@@ -2,6 +2,8 @@ package router
 
 import (
 	"net/http"
+	"regexp"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/rancher/apiserver/pkg/urlbuilder"
@@ -14,9 +16,20 @@ type Handlers struct {
 	APIRoot     http.Handler
 	K8sProxy    http.Handler
 	Next        http.Handler
+	// OpenAPI serves the OpenAPI v3 document of the /v1 API under /v1/openapi/v3. If nil, the path is handled like
+	// any other /v1 path.
+	OpenAPI http.Handler
 	// ExtensionAPIServer serves under /ext. If nil, the default unknown path
 	// handler is served.
 	ExtensionAPIServer http.Handler
+	// Versions are additional versions of the /v1 API, by name, each served under /{name} like /v1 is.
+	Versions map[string]Version
+}
+
+// Version serves an additional version of the /v1 API, such as /v2
+type Version struct {
+	K8sResource http.Handler
+	APIRoot     http.Handler
 }
 
 func Routes(h Handlers) http.Handler {
@@ -33,14 +46,16 @@ func Routes(h Handlers) http.Handler {
 		m.PathPrefix("/ext/").Handler(http.StripPrefix("/ext", h.ExtensionAPIServer))
 	}
 
-	m.Path("/v1/{type}").Handler(h.K8sResource)
-	m.Path("/v1/{type}/{nameorns}").Queries("link", "{link}").Handler(h.K8sResource)
-	m.Path("/v1/{type}/{nameorns}").Queries("action", "{action}").Handler(h.K8sResource)
-	m.Path("/v1/{type}/{nameorns}").Handler(h.K8sResource)
-	m.Path("/v1/{type}/{namespace}/{name}").Queries("action", "{action}").Handler(h.K8sResource)
-	m.Path("/v1/{type}/{namespace}/{name}").Queries("link", "{link}").Handler(h.K8sResource)
-	m.Path("/v1/{type}/{namespace}/{name}").Handler(h.K8sResource)
-	m.Path("/v1/{type}/{namespace}/{name}/{link}").Handler(h.K8sResource)
+	if h.OpenAPI != nil {
+		m.Path("/v1/openapi/v3").Handler(h.OpenAPI)
+	}
+
+	resourceRoutes(m, "/v1", h.K8sResource)
+	for name, version := range h.Versions {
+		m.Path("/{name:" + regexp.QuoteMeta(name) + "}").Handler(version.APIRoot)
+		resourceRoutes(m, "/"+name, version.K8sResource)
+	}
+
 	m.Path("/api").Handler(h.K8sProxy) // Can't just prefix this as UI needs /apikeys path
 	m.PathPrefix("/api/").Handler(h.K8sProxy)
 	m.PathPrefix("/apis").Handler(h.K8sProxy)
@@ -50,3 +65,44 @@ func Routes(h Handlers) http.Handler {
 
 	return m
 }
+
+// resourceRoutes routes the paths of the resources of a version of the API, under prefix, to handler
+func resourceRoutes(m *mux.Router, prefix string, handler http.Handler) {
+	m.Path(prefix + "/{type}").Handler(handler)
+	m.Path(prefix+"/{type}/{nameorns}").Queries("link", "{link}").Handler(handler)
+	m.Path(prefix+"/{type}/{nameorns}").Queries("action", "{action}").Handler(handler)
+	m.Path(prefix + "/{type}/{nameorns}").Handler(handler)
+	m.Path(prefix+"/{type}/{namespace}/{name}").Queries("action", "{action}").Handler(handler)
+	m.Path(prefix+"/{type}/{namespace}/{name}").Queries("link", "{link}").Handler(handler)
+	m.Path(prefix + "/{type}/{namespace}/{name}").Handler(handler)
+	m.Path(prefix + "/{type}/{namespace}/{name}/{link}").Handler(handler)
+}
+
+// Prefix serves handler under prefix, such as /steve, and next for other paths. The prefix is removed from the path
+// of the requests, and added to the X-API-URL-Prefix header, after any prefix set by proxies in front, so that the
+// links of responses include it. handler is returned as is if prefix is empty.
+func Prefix(prefix string, handler, next http.Handler) http.Handler {
+	prefix = strings.TrimSuffix(prefix, "/")
+	if prefix == "" {
+		return handler
+	}
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		rest, ok := strings.CutPrefix(req.URL.Path, prefix)
+		if !ok || (rest != "" && rest[0] != '/') {
+			next.ServeHTTP(rw, req)
+			return
+		}
+		if rest == "" {
+			rest = "/"
+		}
+		req = req.Clone(req.Context())
+		req.URL.Path = rest
+		if rawRest, ok := strings.CutPrefix(req.URL.RawPath, prefix); ok && rawRest != "" {
+			req.URL.RawPath = rawRest
+		} else {
+			req.URL.RawPath = ""
+		}
+		req.Header.Set(urlbuilder.PrefixHeader, req.Header.Get(urlbuilder.PrefixHeader)+prefix)
+		handler.ServeHTTP(rw, req)
+	})
+}
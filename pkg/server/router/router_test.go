@@ -0,0 +1,62 @@
+package router
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/rancher/apiserver/pkg/urlbuilder"
+	"github.com/stretchr/testify/assert"
+)
+
+// recorder returns a handler recording the path and mux vars of the requests it serves as named
+func recorder(name string, served *[]string) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		vars := mux.Vars(req)
+		*served = append(*served, name+" "+req.Header.Get(urlbuilder.PrefixHeader)+req.URL.Path+" "+vars["type"]+" "+vars["name"])
+	})
+}
+
+func TestRoutes(t *testing.T) {
+	var served []string
+	routes := Routes(Handlers{
+		K8sResource: recorder("v1", &served),
+		APIRoot:     recorder("root", &served),
+		K8sProxy:    recorder("proxy", &served),
+		Next:        recorder("next", &served),
+		Versions: map[string]Version{
+			"v2": {K8sResource: recorder("v2", &served), APIRoot: recorder("v2root", &served)},
+		},
+	})
+	for _, path := range []string{"/v1/pods", "/v2/pods/default/web", "/v2", "/v1", "/v3/pods", "/api/v1/pods"} {
+		routes.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	assert.Equal(t, []string{
+		"v1 /v1/pods pods ",
+		"v2 /v2/pods/default/web pods web",
+		"v2root /v2  v2",
+		"root /v1  v1",
+		"next /v3/pods  ",
+		"proxy /api/v1/pods  ",
+	}, served)
+}
+
+func TestPrefix(t *testing.T) {
+	var served []string
+	handler := Prefix("/steve/", recorder("api", &served), recorder("next", &served))
+	for _, path := range []string{"/steve/v1/pods", "/steve", "/stevedore/v1/pods", "/v1/pods"} {
+		handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+	req := httptest.NewRequest(http.MethodGet, "/steve/v1/pods", nil)
+	req.Header.Set(urlbuilder.PrefixHeader, "/proxy")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	assert.Equal(t, []string{
+		"api /steve/v1/pods  ",
+		"api /steve/  ",
+		"next /stevedore/v1/pods  ",
+		"next /v1/pods  ",
+		"api /proxy/steve/v1/pods  ",
+	}, served)
+}
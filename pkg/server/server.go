@@ -3,35 +3,92 @@ package server
 import (
 	"context"
 	"errors"
+	"fmt"
 	"net/http"
+	"regexp"
+	"slices"
+	"strings"
+	"sync"
+	"time"
 
 	apiserver "github.com/rancher/apiserver/pkg/server"
 	"github.com/rancher/apiserver/pkg/types"
 	"github.com/rancher/dynamiclistener/server"
 	"github.com/rancher/steve/pkg/accesscontrol"
 	"github.com/rancher/steve/pkg/aggregation"
+	"github.com/rancher/steve/pkg/attributes"
 	"github.com/rancher/steve/pkg/auth"
+	"github.com/rancher/steve/pkg/auth/tokens"
 	"github.com/rancher/steve/pkg/client"
 	"github.com/rancher/steve/pkg/clustercache"
+	"github.com/rancher/steve/pkg/compression"
 	schemacontroller "github.com/rancher/steve/pkg/controllers/schema"
+	"github.com/rancher/steve/pkg/cors"
+	"github.com/rancher/steve/pkg/csrf"
+	"github.com/rancher/steve/pkg/drain"
 	"github.com/rancher/steve/pkg/ext"
+	"github.com/rancher/steve/pkg/features"
+	"github.com/rancher/steve/pkg/metrics"
+	k8sproxy "github.com/rancher/steve/pkg/proxy"
+	"github.com/rancher/steve/pkg/replication"
 	"github.com/rancher/steve/pkg/resources"
+	"github.com/rancher/steve/pkg/resources/accessexplanation"
+	"github.com/rancher/steve/pkg/resources/actions"
+	"github.com/rancher/steve/pkg/resources/apitokens"
+	"github.com/rancher/steve/pkg/resources/applications"
+	"github.com/rancher/steve/pkg/resources/bulklabel"
+	"github.com/rancher/steve/pkg/resources/cacheadvisor"
+	"github.com/rancher/steve/pkg/resources/cachecompaction"
+	"github.com/rancher/steve/pkg/resources/cacheconsistency"
+	"github.com/rancher/steve/pkg/resources/cachemigration"
+	"github.com/rancher/steve/pkg/resources/cachesnapshot"
+	"github.com/rancher/steve/pkg/resources/capi"
+	"github.com/rancher/steve/pkg/resources/columns"
 	"github.com/rancher/steve/pkg/resources/common"
+	"github.com/rancher/steve/pkg/resources/counts"
+	"github.com/rancher/steve/pkg/resources/crds"
+	"github.com/rancher/steve/pkg/resources/diff"
+	"github.com/rancher/steve/pkg/resources/distinct"
+	"github.com/rancher/steve/pkg/resources/namespaces"
+	"github.com/rancher/steve/pkg/resources/nodes"
+	"github.com/rancher/steve/pkg/resources/podproxy"
+	"github.com/rancher/steve/pkg/resources/redaction"
+	"github.com/rancher/steve/pkg/resources/relationships"
 	"github.com/rancher/steve/pkg/resources/schemas"
+	"github.com/rancher/steve/pkg/resources/subscribe"
+	"github.com/rancher/steve/pkg/resources/usernamespaces"
+	"github.com/rancher/steve/pkg/resources/virtual/annotations"
+	"github.com/rancher/steve/pkg/resources/virtual/computed"
+	"github.com/rancher/steve/pkg/resources/virtual/conditions"
+	"github.com/rancher/steve/pkg/resources/virtual/derived"
+	"github.com/rancher/steve/pkg/resources/virtual/ingest"
+	"github.com/rancher/steve/pkg/revisions"
 	"github.com/rancher/steve/pkg/schema"
 	"github.com/rancher/steve/pkg/schema/definitions"
 	"github.com/rancher/steve/pkg/server/handler"
 	"github.com/rancher/steve/pkg/server/router"
+	sqlcachedb "github.com/rancher/steve/pkg/sqlcache/db"
+	sqlcachefactory "github.com/rancher/steve/pkg/sqlcache/factory"
+	sqlcacheinformer "github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/stores/fallback"
 	metricsStore "github.com/rancher/steve/pkg/stores/metrics"
 	"github.com/rancher/steve/pkg/stores/proxy"
 	"github.com/rancher/steve/pkg/stores/sqlpartition"
 	"github.com/rancher/steve/pkg/stores/sqlproxy"
+	"github.com/rancher/steve/pkg/stores/transform"
 	"github.com/rancher/steve/pkg/summarycache"
+	"github.com/rancher/steve/pkg/tombstone"
+	"github.com/rancher/steve/pkg/tracing"
+	"github.com/rancher/steve/pkg/usage"
+	"github.com/sirupsen/logrus"
+	k8sschema "k8s.io/apimachinery/pkg/runtime/schema"
 	"k8s.io/client-go/rest"
 )
 
 var ErrConfigRequired = errors.New("rest config is required")
 
+var apiVersionName = regexp.MustCompile(`^v[a-z0-9]+$`)
+
 var _ ExtensionAPIServer = (*ext.ExtensionAPIServer)(nil)
 
 // ExtensionAPIServer will run an extension API server. The extension API server
@@ -64,26 +121,200 @@ type Server struct {
 	needControllerStart bool
 	next                http.Handler
 	router              router.RouterFunc
+	urlPrefix           string
+	apiVersions         []handler.APIVersion
 
-	aggregationSecretNamespace string
-	aggregationSecretName      string
-	SQLCache                   bool
+	aggregationSecretNamespace  string
+	aggregationSecretName       string
+	aggregationHealth           *aggregation.Health
+	SQLCache                    bool
+	sqlCacheAnnotationColumns   []annotations.Column
+	sqlCacheComputedFields      []computed.Field
+	sqlCacheConditionTypes      []string
+	sqlCacheIndexedExtras       sqlproxy.IndexedExtras
+	sqlCacheHardeningMode       sqlproxy.HardeningMode
+	sqlCacheDefaultSort         string
+	sqlCacheTombstoneRetention  time.Duration
+	sqlCacheChangeFeedSize      int
+	sqlCacheUsageInterval       time.Duration
+	sqlCacheListBudget          int64
+	sqlCacheGlobalListBudget    int64
+	sqlCacheResultTTL           time.Duration
+	sqlCacheResultCacheSize     int64
+	sqlCacheMaintenanceSchedule *sqlcachedb.Schedule
+	sqlCacheSnapshotDir         string
+	sqlCacheBootstrapSnapshot   string
+	sqlCacheTuning              *sqlcachedb.Tuning
+	sqlCacheDBPath              string
+	sqlCacheKeepDatabase        bool
+	sqlCacheReconcile           bool
+	sqlCacheReadConnections     int
+	sqlCacheWriteBatchWindow    time.Duration
+	sqlCacheWriteBatchSize      int
+	sqlCacheSyncWorkers         int
+	sqlCacheExplain             bool
+	sqlCacheTransformers        []ingest.Transformer
+	sqlCacheMaxObjectSize       int64
+	sqlCacheQueryTimeout        time.Duration
+	columns                     []columns.Column
+	actions                     []actions.Action
+	accessSetStore              accesscontrol.AccessSetStore
+	accessSetStoreKey           []byte
+	aggregatedAPIs              []k8sproxy.AggregatedAPI
+	interceptors                *transform.Interceptors
+	redactionRules              []redaction.Rule
+	requestFeatures             []string
+	conflictRevisionRetention   time.Duration
+	defaultFieldManager         string
+	summarizer                  summarycache.Summarizer
+	derivedFields               *derived.Registry
+	clusters                    []Cluster
+	tokens                      *tokens.Manager
+	tracing                     bool
+	cors                        *cors.Policy
+	csrf                        bool
+	compressionMinSize          int
+	subscribeCoalesceWindow     time.Duration
+	subscribeMaxEventsPerSecond int
+	subscribeQueueSize          int
+	shutdownTimeout             time.Duration
+	replicationTypes            []k8sschema.GroupVersionKind
+	replicationSources          []replication.Source
 }
 
 type Options struct {
 	// Controllers If the controllers are passed in the caller must also start the controllers
-	Controllers                *Controllers
-	ClientFactory              *client.Factory
-	AccessSetLookup            accesscontrol.AccessSetLookup
+	Controllers     *Controllers
+	ClientFactory   *client.Factory
+	AccessSetLookup accesscontrol.AccessSetLookup
+	// AccessSetStore shares computed access sets between steve replicas. It is ignored if AccessSetLookup is set.
+	AccessSetStore accesscontrol.AccessSetStore
+	// AccessSetStoreKey signs the access sets shared through AccessSetStore, which are rejected if they were written
+	// without it. It is required with AccessSetStore, and must be the same for all the replicas sharing it
+	AccessSetStoreKey          []byte
 	AuthMiddleware             auth.Middleware
 	Next                       http.Handler
 	Router                     router.RouterFunc
 	AggregationSecretNamespace string
 	AggregationSecretName      string
-	ClusterRegistry            string
-	ServerVersion              string
-	// SQLCache enables the SQLite-based lasso caching mechanism
+	// AggregationStateListener is notified when the state of the aggregation connection changes, for example to report
+	// degraded connectivity. The state is also served at aggregation.HealthPath
+	AggregationStateListener aggregation.StateListener
+	ClusterRegistry          string
+	ServerVersion            string
+	// SQLCache enables the SQLite-based caching mechanism
 	SQLCache bool
+	// SQLCacheAnnotationColumns promotes annotations into indexed, filterable fields of the SQLite-based cache
+	SQLCacheAnnotationColumns []annotations.Column
+	// SQLCacheComputedFields indexes values derived from objects by Go funcs, such as the status of their Ready
+	// condition, as filterable and sortable fields of the SQLite-based cache in status.computed
+	SQLCacheComputedFields []computed.Field
+	// SQLCacheConditionTypes are the types of the conditions of objects set by the SQLite-based cache, so that lists can
+	// filter and sort on fields like status.conditions[Ready].status. conditions.DefaultTypes are set if it is nil
+	SQLCacheConditionTypes []string
+	// SQLCacheIndexedExtras are the types the extra virtual fields of objects, such as their owners, problems,
+	// application identities and conditions, are indexed for by the SQLite-based cache. Lists of other types filter and
+	// sort on them in memory.
+	SQLCacheIndexedExtras sqlproxy.IndexedExtras
+	// SQLCacheHardeningMode verifies list results of the SQLite-based cache against the requester's partitions
+	SQLCacheHardeningMode sqlproxy.HardeningMode
+	// SQLCacheDefaultSort is the sort applied by the SQLite-based cache to lists without a sort, unless the schema has
+	// a defaultSort attribute. It has the format of the sort query param
+	SQLCacheDefaultSort string
+	// SQLCacheTombstoneRetention is how long deleted objects can still be listed with the includeDeleted query param.
+	// Deleted objects are not recorded if it is zero
+	SQLCacheTombstoneRetention time.Duration
+	// SQLCacheChangeFeedSize is how many changes are kept for each type of the SQL cache, from its first list, which
+	// are listed with the changesSince query param. Changes aren't kept if it is zero
+	SQLCacheChangeFeedSize int
+	// SQLCacheUsageInterval is how often the CPU and memory usage of pods and nodes is scraped from the resource
+	// metrics API, to be listed, sorted and filtered on. Usage is not scraped if it is zero
+	SQLCacheUsageInterval time.Duration
+	// SQLCacheListBudget is the estimated memory, in bytes of JSON, a list can take. Lists over it are paginated if
+	// they aren't already, or rejected otherwise. Lists are not bounded if it is zero
+	SQLCacheListBudget int64
+	// SQLCacheGlobalListBudget is the estimated memory, in bytes of JSON, concurrent lists can take. Lists are rejected
+	// while it is exceeded. Concurrent lists are not bounded if it is zero
+	SQLCacheGlobalListBudget int64
+	// SQLCacheResultTTL is how long the results of identical lists are cached, unless objects of their type change,
+	// so that the same views refreshed by many clients don't run the same queries. Results are not cached if it is zero
+	SQLCacheResultTTL time.Duration
+	// SQLCacheResultCacheSize is the estimated memory, in bytes of JSON, the cached results of lists can take. Results
+	// are not cached while it is exceeded. Cached results are not bounded if it is zero
+	SQLCacheResultCacheSize int64
+	// SQLCacheMaintenanceSchedule is when the database of the SQLite-based cache is compacted, preferably at times of
+	// low traffic. It can also be compacted on demand with the cacheCompaction schema
+	SQLCacheMaintenanceSchedule *sqlcachedb.Schedule
+	// SQLCacheSnapshotDir is the directory of the snapshots of the database of the SQLite-based cache, taken by
+	// administrators with the cacheSnapshot schema, which is disabled if it is empty
+	SQLCacheSnapshotDir string
+	// SQLCacheBootstrapSnapshot is the file of a snapshot of the database of the SQLite-based cache, such as one
+	// downloaded from another replica with the cacheSnapshot schema, which caches start from, only watching the changes
+	// made since the snapshot rather than listing every object from the API server. Caches are listed if it is empty
+	SQLCacheBootstrapSnapshot string
+	// SQLCacheTuning are the SQLite settings of the database of the SQLite-based cache, such as its journal mode and
+	// synchronous level, to suit the storage it is on. The default settings are kept if it is nil
+	SQLCacheTuning *sqlcachedb.Tuning
+	// SQLCacheDBPath is the file of the database of the SQLite-based cache, which can't be shared with other servers,
+	// such as those of other clusters. It is sqlcachedb.InformerObjectCacheDBPath if empty
+	SQLCacheDBPath string
+	// SQLCacheKeepDatabase keeps the database of the SQLite-based cache of an earlier run on start, migrating its
+	// tables to the layout of this version of steve in place, rather than deleting it. Databases written by a later
+	// version of steve, before a downgrade, are deleted
+	SQLCacheKeepDatabase bool
+	// SQLCacheReconcile reconciles the tables of a kept database of the SQLite-based cache with the types discovered on
+	// start: the tables of the types which weren't discovered are dropped, and the columns of the fields of the others
+	// altered in place to match the fields indexed for them. The discrepancies are only reported if it is false
+	SQLCacheReconcile bool
+	// SQLCacheReadConnections is the size of the pool of read-only connections the lists of the SQLite-based cache run
+	// on, events being written on a connection of their own so that long lists don't delay them. Lists and events share
+	// a pool of writer connections if it is 0
+	SQLCacheReadConnections int
+	// SQLCacheWriteBatchWindow is how long the events of the SQLite-based cache, such as additions, updates and
+	// deletions of objects, are batched in a single transaction, in their order, so that event storms don't commit
+	// thousands of transactions per second. Lists only see events once their batch is committed. Events are written in
+	// their own transactions if it is 0, and can only be batched with SQLCacheReadConnections
+	SQLCacheWriteBatchWindow time.Duration
+	// SQLCacheWriteBatchSize is how many events of the SQLite-based cache are batched in a single transaction at most,
+	// the batch being committed before the end of its window once full. It is sqlcachedb.DefaultWriteBatchSize if 0
+	SQLCacheWriteBatchSize int
+	// SQLCacheSyncWorkers is how many objects of the initial sync of a type of the SQLite-based cache are encoded, and
+	// encrypted, at once, while they are written in statements of many rows. It is sqlcachedb.DefaultSyncWorkers if 0
+	SQLCacheSyncWorkers int
+	// SQLCacheExplain logs the query plans of the lists of the SQLite-based cache, warning about those which don't use
+	// indexes. Statements are only explained when debug logging is enabled
+	SQLCacheExplain bool
+	// SQLCacheTransformers transform objects as they are stored in the SQLite-based cache, after their virtual fields
+	// are set, for example to strip fields clients don't need from large objects, reducing the size of the database
+	// and the cost of serializing lists. Lists return the transformed objects
+	SQLCacheTransformers []ingest.Transformer
+	// SQLCacheStripManagedFields strips the managed fields of the objects of all types stored in the SQLite-based
+	// cache, with ingest.StripManagedFields, before the SQLCacheTransformers
+	SQLCacheStripManagedFields bool
+	// SQLCacheMaxObjectSize is the size, in bytes of JSON, above which objects are stored in the SQLite-based cache as
+	// their metadata and indexed fields, marked with their size in metadata.truncated.size, bounding the size of the
+	// database. Lists return them so, while gets return them whole from Kubernetes. Objects are stored whole if it is 0
+	SQLCacheMaxObjectSize int64
+	// SQLCacheQueryTimeout is how long each query of the SQLite-based cache can run before it is interrupted, the
+	// request failing with a 504 QueryTimeout error. Queries are also interrupted when their request is canceled, and
+	// only bounded by their request if it is zero
+	SQLCacheQueryTimeout time.Duration
+
+	// Columns are display columns added to the table columns of the schemas of their kinds, backed by indexed fields
+	// or by computed funcs, whose values are then indexed by the SQLite-based cache as computed fields
+	Columns []columns.Column
+
+	// Actions are custom actions added to the schemas of their kinds. The requester must be granted the verb of an
+	// action, checked with a SelfSubjectAccessReview, for it to be invoked
+	Actions []actions.Action
+
+	// URLPrefix is the path the API is served under, such as /steve, with /v1, the Kubernetes proxy and the other
+	// paths of the router under it. Requests outside of it are served by Next. The API is served at the root if it
+	// is empty
+	URLPrefix string
+	// APIVersions are additional versions of the /v1 API, such as v2, each served under /{name} with the schemas and
+	// stores of /v1 and customizing its requests, for example to change their serialization
+	APIVersions []handler.APIVersion
 
 	// ExtensionAPIServer enables an extension API server that will be served
 	// under /ext
@@ -92,12 +323,111 @@ type Options struct {
 	// In most cases, you'll want to use [github.com/rancher/steve/pkg/ext.NewExtensionAPIServer]
 	// to create an ExtensionAPIServer.
 	ExtensionAPIServer ExtensionAPIServer
+
+	// AggregatedAPIs are proxied to directly, with their own TLS configuration, rather than through the Kubernetes API
+	// server
+	AggregatedAPIs []k8sproxy.AggregatedAPI
+
+	// Interceptors mutate objects before they are written and post-process list results, for every schema. More can
+	// be added with Server.AddInterceptors
+	Interceptors []transform.Interceptor
+
+	// RedactionRules mask fields of objects, such as secret data, for users who aren't granted the view-sensitive verb
+	// on them. See redaction.DefaultRules
+	RedactionRules []redaction.Rule
+
+	// RequestFeatures are the experimental features clients may enable for their requests with the X-Steve-Features
+	// header. Features not listed are ignored
+	RequestFeatures []string
+
+	// ConflictRevisionRetention is how long the revisions of objects read by ID or updated are kept, so that update
+	// conflicts can report the changes made since the revision the update was based on. Revisions are not kept if it
+	// is zero
+	ConflictRevisionRetention time.Duration
+
+	// DefaultFieldManager is the field manager of server-side applies, PATCH requests with the
+	// application/apply-patch+yaml content type, which don't set the fieldManager query parameter. Defaults to steve
+	DefaultFieldManager string
+
+	// Summarizer computes the state, transitioning and error fields of objects and the counts of their states. Use a
+	// summarycache.TypeSummarizer to customize the rules of some types. Defaults to summarycache.DefaultSummarizer
+	Summarizer summarycache.Summarizer
+	// DerivedFields are the rules of embedders deriving virtual fields of the objects of some kinds, such as their
+	// metadata.state, after those of steve. The fields they declare are indexed by the SQLite-based cache
+	DerivedFields *derived.Registry
+
+	// Clusters are additional clusters served under /v1/clusters/{name}/, each by its own server, alongside the
+	// cluster of the RESTConfig which is served as the local cluster. Lists of all clusters are served under
+	// /v1/clusters/all/
+	Clusters []Cluster
+
+	// SlowRequestThreshold is the duration above which requests to the stores are logged with their list options.
+	// Slow requests are not logged if it is zero. The statistics of the shapes of list queries are served by the
+	// queryStat schema
+	SlowRequestThreshold time.Duration
+
+	// Tokens enables the apiToken schema, with which administrators issue API tokens. The tokens are only accepted if
+	// Tokens is also one of the authenticators of the AuthMiddleware
+	Tokens *tokens.Manager
+
+	// Tracing traces the requests served, the operations of the stores, the queries of the SQL cache and the requests
+	// sent to Kubernetes with OpenTelemetry spans, propagating the trace context of clients. Spans are created with
+	// the global tracer provider, see tracing.Config.Setup
+	Tracing bool
+
+	// CORS is the cross-origin resource sharing policy letting browser apps served from other origins call the API.
+	// Only same-origin apps can if it is nil
+	CORS *cors.Policy
+
+	// CSRF requires the requests changing objects without an Authorization header, that is those authenticated by
+	// cookies, to echo the CSRF cookie in the X-API-CSRF header
+	CSRF bool
+
+	// CompressionMinSize is the size in bytes above which responses are compressed with gzip for the clients accepting
+	// it, such as large lists, and above which the messages of subscribe websockets are compressed for the clients
+	// which negotiated permessage-deflate. Responses aren't compressed if it is zero, while all websocket messages are
+	CompressionMinSize int
+
+	// SubscribeCoalesceWindow is how long subscribe websockets hold the changes of an object, sending only the latest
+	// of the changes made within it. Changes aren't coalesced if it is zero
+	SubscribeCoalesceWindow time.Duration
+	// SubscribeMaxEventsPerSecond is how many events of objects a subscribe websocket is sent per second at most. The
+	// events beyond it are dropped, and their watches are sent a resource.resync event the next second. Events
+	// aren't limited if it is zero
+	SubscribeMaxEventsPerSecond int
+	// SubscribeQueueSize is how many events are queued for a subscribe websocket while they are written to it, 1000
+	// if it is zero. Clients which don't keep up and exceed it are sent a "too old resource version" error for each of
+	// their watches, then their websocket is closed
+	SubscribeQueueSize int
+
+	// ShutdownTimeout is how long ListenAndServe waits for the requests in flight to finish once ctx is done, before
+	// closing the listeners. Subscribe websockets are closed right away with a token to resume their watches. The
+	// requests in flight are cut off immediately if it is zero
+	ShutdownTimeout time.Duration
+
+	// ReplicationTypes are the types whose objects in the cluster cache can be replicated to other Steves through the
+	// replication schema, by the users who can list and watch them in all namespaces
+	ReplicationTypes []k8sschema.GroupVersionKind
+	// ReplicationSources are the Steves whose objects of some types are mirrored into the SQL cache, which SQLCache
+	// must enable, so that this Steve serves them as a read-only inventory of many clusters. The mirrored types must
+	// also be served by this cluster, and their objects are named {source}:{name}
+	ReplicationSources []replication.Source
 }
 
 func New(ctx context.Context, restConfig *rest.Config, opts *Options) (*Server, error) {
 	if opts == nil {
 		opts = &Options{}
 	}
+	if opts.CORS != nil {
+		if err := opts.CORS.Validate(); err != nil {
+			return nil, err
+		}
+	}
+
+	if opts.Tracing && restConfig != nil {
+		restConfig = rest.CopyConfig(restConfig)
+		restConfig.Wrap(tracing.WrapTransport)
+	}
 
 	server := &Server{
 		RESTConfig:                 restConfig,
@@ -107,14 +437,83 @@ func New(ctx context.Context, restConfig *rest.Config, opts *Options) (*Server,
 		controllers:                opts.Controllers,
 		next:                       opts.Next,
 		router:                     opts.Router,
+		urlPrefix:                  opts.URLPrefix,
+		apiVersions:                opts.APIVersions,
 		aggregationSecretNamespace: opts.AggregationSecretNamespace,
 		aggregationSecretName:      opts.AggregationSecretName,
 		ClusterRegistry:            opts.ClusterRegistry,
 		Version:                    opts.ServerVersion,
-		// SQLCache enables the SQLite-based lasso caching mechanism
-		SQLCache:           opts.SQLCache,
-		extensionAPIServer: opts.ExtensionAPIServer,
+		// SQLCache enables the SQLite-based caching mechanism
+		SQLCache:                    opts.SQLCache,
+		sqlCacheAnnotationColumns:   opts.SQLCacheAnnotationColumns,
+		sqlCacheComputedFields:      opts.SQLCacheComputedFields,
+		sqlCacheConditionTypes:      opts.SQLCacheConditionTypes,
+		sqlCacheIndexedExtras:       opts.SQLCacheIndexedExtras,
+		sqlCacheHardeningMode:       opts.SQLCacheHardeningMode,
+		sqlCacheDefaultSort:         opts.SQLCacheDefaultSort,
+		sqlCacheTombstoneRetention:  opts.SQLCacheTombstoneRetention,
+		sqlCacheChangeFeedSize:      opts.SQLCacheChangeFeedSize,
+		sqlCacheUsageInterval:       opts.SQLCacheUsageInterval,
+		sqlCacheListBudget:          opts.SQLCacheListBudget,
+		sqlCacheGlobalListBudget:    opts.SQLCacheGlobalListBudget,
+		sqlCacheResultTTL:           opts.SQLCacheResultTTL,
+		sqlCacheResultCacheSize:     opts.SQLCacheResultCacheSize,
+		sqlCacheMaintenanceSchedule: opts.SQLCacheMaintenanceSchedule,
+		sqlCacheSnapshotDir:         opts.SQLCacheSnapshotDir,
+		sqlCacheBootstrapSnapshot:   opts.SQLCacheBootstrapSnapshot,
+		sqlCacheTuning:              opts.SQLCacheTuning,
+		sqlCacheDBPath:              opts.SQLCacheDBPath,
+		sqlCacheKeepDatabase:        opts.SQLCacheKeepDatabase,
+		sqlCacheReconcile:           opts.SQLCacheReconcile,
+		sqlCacheReadConnections:     opts.SQLCacheReadConnections,
+		sqlCacheWriteBatchWindow:    opts.SQLCacheWriteBatchWindow,
+		sqlCacheWriteBatchSize:      opts.SQLCacheWriteBatchSize,
+		sqlCacheSyncWorkers:         opts.SQLCacheSyncWorkers,
+		sqlCacheExplain:             opts.SQLCacheExplain,
+		sqlCacheTransformers:        opts.SQLCacheTransformers,
+		sqlCacheMaxObjectSize:       opts.SQLCacheMaxObjectSize,
+		sqlCacheQueryTimeout:        opts.SQLCacheQueryTimeout,
+		columns:                     opts.Columns,
+		actions:                     opts.Actions,
+		extensionAPIServer:          opts.ExtensionAPIServer,
+		accessSetStore:              opts.AccessSetStore,
+		accessSetStoreKey:           opts.AccessSetStoreKey,
+		aggregatedAPIs:              opts.AggregatedAPIs,
+		interceptors:                &transform.Interceptors{},
+		redactionRules:              opts.RedactionRules,
+		requestFeatures:             opts.RequestFeatures,
+		conflictRevisionRetention:   opts.ConflictRevisionRetention,
+		defaultFieldManager:         opts.DefaultFieldManager,
+		summarizer:                  opts.Summarizer,
+		derivedFields:               opts.DerivedFields,
+		clusters:                    opts.Clusters,
+		tokens:                      opts.Tokens,
+		tracing:                     opts.Tracing,
+		cors:                        opts.CORS,
+		csrf:                        opts.CSRF,
+		compressionMinSize:          opts.CompressionMinSize,
+		subscribeCoalesceWindow:     opts.SubscribeCoalesceWindow,
+		subscribeMaxEventsPerSecond: opts.SubscribeMaxEventsPerSecond,
+		subscribeQueueSize:          opts.SubscribeQueueSize,
+		shutdownTimeout:             opts.ShutdownTimeout,
+		replicationTypes:            opts.ReplicationTypes,
+		replicationSources:          opts.ReplicationSources,
+	}
+	if opts.SQLCacheStripManagedFields {
+		server.sqlCacheTransformers = append([]ingest.Transformer{ingest.StripManagedFields}, server.sqlCacheTransformers...)
+	}
+	if opts.SlowRequestThreshold > 0 {
+		metrics.Requests.SetSlowThreshold(opts.SlowRequestThreshold)
 	}
+	if server.summarizer == nil {
+		server.summarizer = summarycache.DefaultSummarizer
+	}
+	if opts.AggregationStateListener != nil {
+		server.aggregationHealth = aggregation.NewHealth(opts.AggregationStateListener)
+	} else {
+		server.aggregationHealth = aggregation.NewHealth()
+	}
+	server.interceptors.Add(opts.Interceptors...)
 
 	if err := setup(ctx, server); err != nil {
 		return nil, err
@@ -164,67 +563,289 @@ func setup(ctx context.Context, server *Server) error {
 	}
 
 	asl := server.AccessSetLookup
-	if asl == nil {
+	if asl == nil && server.accessSetStore != nil {
+		asl, err = accesscontrol.NewAccessStoreWithBackend(ctx, server.controllers.RBAC, server.accessSetStore, server.accessSetStoreKey)
+		if err != nil {
+			return err
+		}
+	} else if asl == nil {
 		asl = accesscontrol.NewAccessStore(ctx, true, server.controllers.RBAC)
 	}
+	// access can only be explained by access set lookups which keep the roles bound to users
+	if explainer, ok := asl.(accessexplanation.Explainer); ok {
+		accessexplanation.Register(server.BaseSchemas, explainer, asl)
+	}
+	usernamespaces.Register(server.BaseSchemas, asl, server.controllers.Core.Namespace().Cache())
+	if server.tokens != nil {
+		apitokens.Register(server.BaseSchemas, server.tokens, asl, server.controllers.K8s.AuthorizationV1().SubjectAccessReviews())
+	}
 
 	ccache := clustercache.NewClusterCache(ctx, cf.AdminDynamicClient())
 	server.ClusterCache = ccache
+	if len(server.replicationTypes) > 0 {
+		replication.Register(server.BaseSchemas, ccache, server.replicationTypes)
+	}
 	sf := schema.NewCollection(ctx, server.BaseSchemas, asl)
 
-	if err = resources.DefaultSchemas(ctx, server.BaseSchemas, ccache, server.ClientFactory, sf, server.Version); err != nil {
+	if err = resources.DefaultSchemas(ctx, server.BaseSchemas, ccache, server.ClientFactory, sf, server.Version, server.summarizer,
+		subscribe.Options{
+			MinCompressSize:    server.compressionMinSize,
+			CoalesceWindow:     server.subscribeCoalesceWindow,
+			MaxEventsPerSecond: server.subscribeMaxEventsPerSecond,
+			QueueSize:          server.subscribeQueueSize,
+		}, apiVersionNames(server.apiVersions)); err != nil {
 		return err
 	}
 	definitions.Register(ctx, server.BaseSchemas, server.controllers.K8s.Discovery(),
-		server.controllers.CRD.CustomResourceDefinition(), server.controllers.API.APIService())
+		server.controllers.CRD.CustomResourceDefinition(), server.controllers.API.APIService(), cf)
 
-	summaryCache := summarycache.New(sf, ccache)
+	summaryCache := summarycache.New(sf, ccache, server.summarizer)
 	summaryCache.Start(ctx)
 	cols, err := common.NewDynamicColumns(server.RESTConfig)
 	if err != nil {
 		return err
 	}
 
+	extraColumns, err := columns.NewColumns(slices.Concat(capi.Columns(), server.columns))
+	if err != nil {
+		return err
+	}
+	sf.AddTemplate(columns.Template(extraColumns))
+	customActions, err := actions.New(server.actions)
+	if err != nil {
+		return err
+	}
+	sf.AddTemplate(actions.Template(cf, customActions))
+	drains := nodes.NewDrains(ctx)
+	nodes.Register(server.BaseSchemas, drains)
+	nodeActions, err := actions.New(nodes.Actions(cf, drains))
+	if err != nil {
+		return err
+	}
+	sf.AddTemplate(actions.Template(cf, nodeActions))
+	crds.Register(server.BaseSchemas)
+	crdActions, err := actions.New(crds.Actions(cf))
+	if err != nil {
+		return err
+	}
+	sf.AddTemplate(actions.Template(cf, crdActions))
+	sf.AddTemplate(crds.Template(cf, ccache))
+	podProxy, err := k8sproxy.UserHandler("/", server.RESTConfig, server.authMiddleware != nil)
+	if err != nil {
+		return err
+	}
+	sf.AddTemplate(podproxy.Template(cf, podProxy))
+	sf.AddTemplate(derived.Templates(server.derivedFields)...)
+
 	var onSchemasHandler schemacontroller.SchemasHandlerFunc
+	if len(server.replicationSources) > 0 && !server.SQLCache {
+		return errors.New("replication sources are mirrored into the SQL cache, which must be enabled")
+	}
 	if server.SQLCache {
-		s, err := sqlproxy.NewProxyStore(cols, cf, summaryCache, summaryCache, nil)
+		annotationColumns, err := annotations.NewColumns(server.sqlCacheAnnotationColumns)
+		if err != nil {
+			return err
+		}
+		computedFields, err := computed.NewFields(slices.Concat(server.sqlCacheComputedFields, extraColumns.ComputedFields()))
+		if err != nil {
+			return err
+		}
+		sf.AddTemplate(computed.Template(computedFields))
+		conditionTypes := server.sqlCacheConditionTypes
+		if conditionTypes == nil {
+			conditionTypes = conditions.DefaultTypes
+		}
+		indexedConditions, err := conditions.New(conditionTypes)
+		if err != nil {
+			return err
+		}
+		dbPath := server.sqlCacheDatabase()
+		if server.sqlCacheReconcile && !server.sqlCacheKeepDatabase {
+			return errors.New("reconciling the SQL cache database requires keeping it, it is recreated otherwise")
+		}
+		// the database must be vacuumable incrementally and tuned before it is created by the cache
+		sqlcachedb.EnableIncrementalVacuum()
+		if server.sqlCacheTuning != nil {
+			if err := sqlcachedb.SetTuning(dbPath, *server.sqlCacheTuning); err != nil {
+				return err
+			}
+		}
+		if server.sqlCacheExplain {
+			sqlcachedb.EnableExplain(dbPath)
+		}
+		open := sqlcachedb.NewPooledClient
+		if server.sqlCacheKeepDatabase {
+			open = sqlcachedb.OpenPooledClient
+		}
+		dbClient, err := open(dbPath, server.sqlCacheReadConnections)
+		if err != nil {
+			return err
+		}
+		// migrations are the databases whose layout is migrated, listed by the cacheMigration schema
+		migrations := []cachemigration.Source{dbClient}
+		batchSize := server.sqlCacheWriteBatchSize
+		if batchSize == 0 {
+			batchSize = sqlcachedb.DefaultWriteBatchSize
+		}
+		if err := dbClient.SetWriteBatching(server.sqlCacheWriteBatchWindow, batchSize); err != nil {
+			return err
+		}
+		if server.sqlCacheSyncWorkers > 0 {
+			dbClient.SetSyncWorkers(server.sqlCacheSyncWorkers)
+		}
+		cacheFactory := sqlcachefactory.NewCacheFactory(dbClient)
+		if server.sqlCacheKeepDatabase {
+			cacheconsistency.Register(server.BaseSchemas, dbClient, asl)
+		}
+		metadataLister := sqlcacheinformer.NewMetadataLister(dbPath)
+		counts.SetCounter(server.BaseSchemas, metadataLister)
+		storeOpts := sqlproxy.Options{
+			AnnotationColumns: annotationColumns,
+			ComputedFields:    computedFields,
+			Conditions:        indexedConditions,
+			DerivedFields:     server.derivedFields,
+			IndexedExtras:     server.sqlCacheIndexedExtras,
+			HardeningMode:     server.sqlCacheHardeningMode,
+			DefaultSort:       server.sqlCacheDefaultSort,
+			ListBudget:        server.sqlCacheListBudget,
+			GlobalListBudget:  server.sqlCacheGlobalListBudget,
+			ResultCacheTTL:    server.sqlCacheResultTTL,
+			ResultCacheSize:   server.sqlCacheResultCacheSize,
+			ClusterCache:      ccache,
+			QueryTimeout:      server.sqlCacheQueryTimeout,
+			MetadataLister:    metadataLister,
+			ChangeFeedSize:    server.sqlCacheChangeFeedSize,
+			MaxObjectSize:     server.sqlCacheMaxObjectSize,
+			FieldManager:      server.defaultFieldManager,
+		}
+		if len(server.sqlCacheTransformers) > 0 {
+			storeOpts.IngestTransformers, err = ingest.New(server.sqlCacheTransformers...)
+			if err != nil {
+				return err
+			}
+		}
+		if len(server.replicationSources) > 0 {
+			mirror, err := replication.NewMirror(server.replicationSources)
+			if err != nil {
+				return err
+			}
+			mirror.Run(ctx)
+			storeOpts.Replicas = mirror
+			sf.AddTemplate(mirror.Templates()...)
+		}
+		if server.sqlCacheTombstoneRetention > 0 {
+			tombstones := tombstone.New(server.sqlCacheTombstoneRetention)
+			tombstones.Start(ctx, ccache)
+			storeOpts.Tombstones = tombstones
+		}
+		if server.sqlCacheUsageInterval > 0 {
+			scraper := usage.NewScraper(cf.AdminDynamicClient(), dbClient)
+			go scraper.Run(ctx, server.sqlCacheUsageInterval)
+			storeOpts.Usage = scraper
+		}
+		if server.sqlCacheBootstrapSnapshot != "" {
+			bootstrap, err := sqlcachedb.OpenBootstrap(server.sqlCacheBootstrapSnapshot)
+			if errors.Is(err, sqlcachedb.ErrNewerSchema) {
+				// caches are listed from the API server instead, as they are without a snapshot
+				logrus.Warnf("Not bootstrapping the SQL cache from snapshot %s: %v", server.sqlCacheBootstrapSnapshot, err)
+			} else if err != nil {
+				return fmt.Errorf("opening the snapshot to bootstrap the SQL cache from: %w", err)
+			} else {
+				go func() {
+					<-ctx.Done()
+					bootstrap.Close()
+				}()
+				storeOpts.Bootstrap = bootstrap
+				migrations = append(migrations, bootstrap)
+			}
+		}
+		s, err := sqlproxy.NewProxyStore(ctx, cols, cf, summaryCache, summaryCache, cacheFactory, storeOpts)
 		if err != nil {
 			panic(err)
 		}
+		cachemigration.Register(server.BaseSchemas, asl, migrations...)
+		// warning events are counted in the problems of objects from the start, not only from the first list using them
+		if err := s.CountWarningEvents(); err != nil {
+			logrus.Infof("failed to warm up event informer for proxy store in steve, will try again on next list of warning events: %v", err)
+		}
+		// related objects are listed by ID, which only the SQL cache supports
+		sf.AddTemplate(relationships.Template())
+		// the objects remaining in terminating namespaces are listed for every namespaced type
+		sf.AddTemplate(namespaces.Template())
+		maintainer := sqlcachedb.NewMaintainer(dbPath)
+		cachecompaction.Register(server.BaseSchemas, maintainer, asl)
+		if server.sqlCacheMaintenanceSchedule != nil {
+			go maintainer.Run(ctx, server.sqlCacheMaintenanceSchedule)
+		}
+		if server.sqlCacheSnapshotDir != "" {
+			cachesnapshot.Register(server.BaseSchemas, sqlcachedb.NewSnapshotter(dbPath, server.sqlCacheSnapshotDir), asl)
+		}
+
+		partitionStore := sqlpartition.NewStore(s, asl)
+		distinct.Register(server.BaseSchemas, partitionStore)
+		cacheadvisor.Register(server.BaseSchemas, s, partitionStore)
+		capi.Register(server.BaseSchemas)
+		applications.Register(server.BaseSchemas)
 
 		errStore := proxy.NewErrorStore(
 			proxy.NewUnformatterStore(
 				proxy.NewWatchRefresh(
-					sqlpartition.NewStore(
-						s,
-						asl,
-					),
+					partitionStore,
 					asl,
 				),
 			),
 		)
-		store := metricsStore.NewMetricsStore(errStore)
+		// lists fall back to the Kubernetes API server while the cache of their type is failing
+		fallbackStore := fallback.NewStore(errStore,
+			proxy.NewProxyStore(cf, summaryCache, asl, server.controllers.Core.Namespace().Cache(), server.defaultFieldManager),
+			s.Reset)
+		store := metricsStore.NewMetricsStore(fallbackStore)
 		// end store setup code
 
 		for _, template := range resources.DefaultSchemaTemplatesForStore(store, server.BaseSchemas, summaryCache, asl, server.controllers.K8s.Discovery()) {
 			sf.AddTemplate(template)
 		}
 
+		var checkOnce sync.Once
 		onSchemasHandler = func(schemas *schema.Collection) error {
 			if err := ccache.OnSchemas(schemas); err != nil {
 				return err
 			}
+			// resetting the caches recreates their tables, unless the database is kept, in which case the columns of
+			// the tables of types are altered to the fields indexed for the current schemas as their caches are created
 			if err := s.Reset(); err != nil {
 				return err
 			}
+			// the tables of a kept database are checked against the types discovered first, to catch drift after
+			// upgrades, such as the tables of CRDs deleted since
+			if server.sqlCacheKeepDatabase {
+				checkOnce.Do(func() {
+					if _, err := cacheFactory.CheckConsistency(cachedTypes(schemas, s), server.sqlCacheReconcile); err != nil {
+						logrus.Errorf("failed to check the consistency of the SQL cache database: %v", err)
+					}
+				})
+			}
 			return nil
 		}
 	} else {
-		for _, template := range resources.DefaultSchemaTemplates(cf, server.BaseSchemas, summaryCache, asl, server.controllers.K8s.Discovery(), server.controllers.Core.Namespace().Cache()) {
+		for _, template := range resources.DefaultSchemaTemplates(cf, server.BaseSchemas, summaryCache, asl, server.controllers.K8s.Discovery(), server.controllers.Core.Namespace().Cache(), server.defaultFieldManager) {
 			sf.AddTemplate(template)
 		}
 		onSchemasHandler = ccache.OnSchemas
 	}
+	sf.AddTemplate(diff.Template(cf))
+	sf.AddTemplate(bulklabel.Template(cf))
+	sf.AddTemplate(transform.Template(server.interceptors))
+	if server.conflictRevisionRetention > 0 {
+		sf.AddTemplate(revisions.Template(revisions.New(server.conflictRevisionRetention)))
+	}
+	if len(server.redactionRules) > 0 {
+		policy, err := redaction.New(server.redactionRules)
+		if err != nil {
+			return err
+		}
+		sf.AddTemplate(redaction.Template(policy, asl))
+	}
 
 	schemas.SetupWatcher(ctx, server.BaseSchemas, asl, sf)
 
@@ -237,18 +858,106 @@ func setup(ctx context.Context, server *Server) error {
 		onSchemasHandler,
 		sf)
 
-	apiServer, handler, err := handler.New(server.RESTConfig, sf, server.authMiddleware, server.next, server.router, server.extensionAPIServer)
+	if err := validateRouting(server.urlPrefix, server.apiVersions); err != nil {
+		return err
+	}
+	apiServer, handler, err := handler.New(server.RESTConfig, sf, server.authMiddleware, server.next, server.router,
+		server.extensionAPIServer, server.aggregatedAPIs, server.apiVersions)
 	if err != nil {
 		return err
 	}
 
 	server.APIServer = apiServer
 	server.Handler = handler
+	if len(server.requestFeatures) > 0 {
+		server.Handler = features.Middleware(server.requestFeatures)(handler)
+	}
+	if server.aggregationSecretNamespace != "" && server.aggregationSecretName != "" {
+		server.Handler = aggregationHealthMiddleware(server.aggregationHealth, server.Handler)
+	}
+	if len(server.clusters) > 0 {
+		server.Handler, err = newClusters(ctx, server.Handler, server.authMiddleware, server.sqlCacheDatabase(), server.clusters)
+		if err != nil {
+			return err
+		}
+	}
+	server.Handler = router.Prefix(server.urlPrefix, server.Handler, server.next)
+	if server.compressionMinSize > 0 {
+		server.Handler = compression.Middleware(server.compressionMinSize)(server.Handler)
+	}
+	if server.csrf {
+		server.Handler = csrf.Middleware(server.cors)(server.Handler)
+	}
+	if server.cors != nil {
+		server.Handler = cors.Middleware(*server.cors)(server.Handler)
+	}
+	if server.tracing {
+		server.Handler = tracing.Handler(server.Handler)
+	}
 	server.SchemaFactory = sf
 
 	return nil
 }
 
+// validateRouting returns an error if the URL prefix isn't a path or if the additional API versions don't have unique
+// names, other than v1, which can be used as a path segment
+func validateRouting(urlPrefix string, apiVersions []handler.APIVersion) error {
+	if urlPrefix != "" && !strings.HasPrefix(urlPrefix, "/") {
+		return fmt.Errorf("URL prefix %q must start with /", urlPrefix)
+	}
+	seen := map[string]bool{"v1": true}
+	for _, version := range apiVersions {
+		if !apiVersionName.MatchString(version.Name) {
+			return fmt.Errorf("invalid API version name %q, must be lowercase letters and digits starting with v", version.Name)
+		}
+		if seen[version.Name] {
+			return fmt.Errorf("API version %s is already served", version.Name)
+		}
+		seen[version.Name] = true
+	}
+	return nil
+}
+
+func apiVersionNames(apiVersions []handler.APIVersion) []string {
+	var names []string
+	for _, version := range apiVersions {
+		names = append(names, version.Name)
+	}
+	return names
+}
+
+// cachedTypes returns the types of the schemas the SQL cache caches, with the fields it indexes for them
+func cachedTypes(schemas *schema.Collection, store *sqlproxy.Store) []sqlcachedb.CachedType {
+	var result []sqlcachedb.CachedType
+	for _, id := range schemas.IDs() {
+		apiSchema := schemas.Schema(id)
+		if apiSchema == nil || !slices.Contains(attributes.Verbs(apiSchema), "list") {
+			continue
+		}
+		gvk := attributes.GVK(apiSchema)
+		if gvk.Kind == "" {
+			continue
+		}
+		result = append(result, sqlcachedb.CachedType{
+			GVK:        gvk,
+			Fields:     store.IndexedFields(apiSchema),
+			Namespaced: attributes.Namespaced(apiSchema),
+		})
+	}
+	return result
+}
+
+// sqlCacheDatabase returns the file of the database of the SQL cache, or "" if it isn't enabled
+func (c *Server) sqlCacheDatabase() string {
+	if !c.SQLCache {
+		return ""
+	}
+	if c.sqlCacheDBPath == "" {
+		return sqlcachedb.InformerObjectCacheDBPath
+	}
+	return c.sqlCacheDBPath
+}
+
 func (c *Server) start(ctx context.Context) error {
 	if c.needControllerStart {
 		if err := c.controllers.Start(ctx); err != nil {
@@ -263,11 +972,69 @@ func (c *Server) start(ctx context.Context) error {
 	return nil
 }
 
+// AddInterceptors registers interceptors mutating objects before they are written and post-processing list results,
+// for every schema. They apply to requests made after they are added.
+func (c *Server) AddInterceptors(interceptors ...transform.Interceptor) {
+	c.interceptors.Add(interceptors...)
+}
+
+// CacheStatus returns the readiness of the cluster cache of a type: whether its initial list is complete, when it last
+// received an event and how many objects it holds. It returns false if the type isn't cached (yet).
+func (c *Server) CacheStatus(gvk k8sschema.GroupVersionKind) (clustercache.CacheStatus, bool) {
+	reporter, ok := c.ClusterCache.(clustercache.StatusReporter)
+	if !ok {
+		return clustercache.CacheStatus{}, false
+	}
+	return reporter.Status(gvk)
+}
+
+// CacheStatuses returns the readiness of the cluster caches of all cached types
+func (c *Server) CacheStatuses() []clustercache.CacheStatus {
+	reporter, ok := c.ClusterCache.(clustercache.StatusReporter)
+	if !ok {
+		return nil
+	}
+	return reporter.Statuses()
+}
+
+// OnCacheSynced registers a handler called when the cluster cache of a type completes its initial list, until ctx is
+// done
+func (c *Server) OnCacheSynced(ctx context.Context, handler clustercache.SyncedHandler) {
+	if reporter, ok := c.ClusterCache.(clustercache.StatusReporter); ok {
+		reporter.OnSynced(ctx, handler)
+	}
+}
+
+// WaitForCaches waits for the cluster caches of gvks to complete their initial list, for example to hold off routing
+// clients to the server until the caches they depend on are warm, or for ctx to be done
+func (c *Server) WaitForCaches(ctx context.Context, gvks ...k8sschema.GroupVersionKind) error {
+	reporter, ok := c.ClusterCache.(clustercache.StatusReporter)
+	if !ok {
+		return errors.New("the cluster cache doesn't report its status")
+	}
+	return clustercache.WaitForSynced(ctx, reporter, gvks...)
+}
+
 func (c *Server) StartAggregation(ctx context.Context) {
 	aggregation.Watch(ctx, c.controllers.Core.Secret(), c.aggregationSecretNamespace,
-		c.aggregationSecretName, c)
+		c.aggregationSecretName, c, c.aggregationHealth)
 }
 
+// aggregationHealthMiddleware serves the health of the aggregation connection, without authentication so that it can
+// be used for probes
+func aggregationHealthMiddleware(health *aggregation.Health, next http.Handler) http.Handler {
+	return http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		if req.URL.Path == aggregation.HealthPath && req.Method == http.MethodGet {
+			health.ServeHTTP(rw, req)
+			return
+		}
+		next.ServeHTTP(rw, req)
+	})
+}
+
+// ListenAndServe serves the API until ctx is done, then drains the servers: responses close their connection, sending
+// a GOAWAY frame on HTTP/2 connections, subscribe websockets are closed with a token to resume their watches, and the
+// requests in flight are given up to the shutdown timeout to finish before the listeners are closed.
 func (c *Server) ListenAndServe(ctx context.Context, httpsPort, httpPort int, opts *server.ListenOpts) error {
 	if opts == nil {
 		opts = &server.ListenOpts{}
@@ -281,10 +1048,19 @@ func (c *Server) ListenAndServe(ctx context.Context, httpsPort, httpPort int, op
 	if len(opts.TLSListenerConfig.SANs) == 0 {
 		opts.TLSListenerConfig.SANs = []string{"127.0.0.1"}
 	}
-	if err := server.ListenAndServe(ctx, httpsPort, httpPort, c, opts); err != nil {
+	// the listeners and the contexts of the requests outlive ctx until the requests are drained
+	serveCtx, stop := context.WithCancel(context.WithoutCancel(ctx))
+	defer stop()
+	drainer := drain.New()
+	if err := server.ListenAndServe(serveCtx, httpsPort, httpPort, drainer.Middleware(c), opts); err != nil {
 		return err
 	}
 
 	<-ctx.Done()
+	drainCtx, cancel := context.WithTimeout(context.Background(), c.shutdownTimeout)
+	defer cancel()
+	if err := drainer.Drain(drainCtx); err != nil {
+		logrus.Warnf("Shutting down with requests in flight after %v", c.shutdownTimeout)
+	}
 	return ctx.Err()
 }
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/rancher/steve/pkg/server/handler"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateRouting(t *testing.T) {
+	tests := []struct {
+		name        string
+		urlPrefix   string
+		apiVersions []string
+		wantError   bool
+	}{
+		{name: "defaults"},
+		{name: "prefix and versions", urlPrefix: "/steve", apiVersions: []string{"v2", "v3beta1"}},
+		{name: "relative prefix", urlPrefix: "steve", wantError: true},
+		{name: "v1", apiVersions: []string{"v1"}, wantError: true},
+		{name: "duplicate version", apiVersions: []string{"v2", "v2"}, wantError: true},
+		{name: "invalid version", apiVersions: []string{"v2/pods"}, wantError: true},
+		{name: "not a version", apiVersions: []string{"api"}, wantError: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var apiVersions []handler.APIVersion
+			for _, name := range test.apiVersions {
+				apiVersions = append(apiVersions, handler.APIVersion{Name: name})
+			}
+			err := validateRouting(test.urlPrefix, apiVersions)
+			if test.wantError {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,202 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"time"
+
+	"github.com/rancher/steve/pkg/metrics"
+	"github.com/sirupsen/logrus"
+)
+
+// DefaultWriteBatchSize is how many transactions of events are written in a batch at most by default
+const DefaultWriteBatchSize = 1000
+
+// savepoint is the savepoint of the batch each transaction of events is written in, so that it can be rolled back
+// without the others
+const savepoint = "event"
+
+// batch is the transaction of the writer connection transactions of events are written in during a window
+type batch struct {
+	*writeTx
+	// writes are the transactions of events committed in the batch
+	writes int
+	timer  *time.Timer
+}
+
+// SetWriteBatching batches the transactions of events, such as Add, Update and Delete of informers, written during
+// window, up to size of them, in a single transaction of the writer connection, in the order they are committed in,
+// so that event storms such as node drains don't commit, and sync the WAL, thousands of times per second.
+//
+// The events of a batch are read by the informers writing them, such as to tell additions from updates, but lists
+// only see them once it is committed, up to window later. Transactions of events are written one at a time, and must
+// not read with statements of the client while they are open. Batching is disabled if window is 0, which is the default.
+func (c *PooledClient) SetWriteBatching(window time.Duration, size int) error {
+	if window < 0 || (window > 0 && size <= 0) {
+		return fmt.Errorf("invalid write batching of %d transactions in %s", size, window)
+	}
+	c.batchLock.Lock()
+	defer c.batchLock.Unlock()
+	if err := c.flush(); err != nil {
+		return err
+	}
+	c.batchWindow, c.batchSize = window, size
+	return nil
+}
+
+// Flush commits the current batch of events, if any
+func (c *PooledClient) Flush() error {
+	c.batchLock.Lock()
+	defer c.batchLock.Unlock()
+	return c.flush()
+}
+
+// beginBatched begins a transaction of events, begun at start, in the current batch, or a new one. The batch lock must
+// be held, and is until the transaction is committed or rolled back.
+func (c *PooledClient) beginBatched(start time.Time) (TXClient, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.batch == nil {
+		// the batch outlives the transaction it is begun for, it isn't canceled with its context
+		tx, err := c.writer.BeginTx(context.Background(), nil)
+		if err != nil {
+			c.batchLock.Unlock()
+			return nil, err
+		}
+		b := &batch{writeTx: &writeTx{Tx: tx, client: c}}
+		b.timer = time.AfterFunc(c.batchWindow, func() {
+			c.batchLock.Lock()
+			defer c.batchLock.Unlock()
+			// the batch may have been committed when full already
+			if c.batch != b {
+				return
+			}
+			if err := c.flush(); err != nil {
+				logrus.Errorf("failed to commit a batch of events of the SQL cache: %v", err)
+			}
+		})
+		c.batch = b
+	}
+	metrics.RecordSQLCacheTransaction(writerPool, time.Since(start), c.writer.Stats().InUse)
+	if _, err := c.batch.Exec("SAVEPOINT " + savepoint); err != nil {
+		c.batchLock.Unlock()
+		return nil, err
+	}
+	return c.newTxClient(&batchedTx{batch: c.batch, client: c}), nil
+}
+
+// flush commits the current batch, if any. The batch lock must be held.
+func (c *PooledClient) flush() error {
+	b := c.batch
+	if b == nil {
+		return nil
+	}
+	c.batch = nil
+	b.timer.Stop()
+	metrics.RecordSQLCacheWriteBatch(b.writes)
+	if err := b.Commit(); err != nil {
+		return fmt.Errorf("committing %d transactions: %w", b.writes, err)
+	}
+	return nil
+}
+
+// discard rolls back the current batch, if any, such as when the database is deleted. The batch lock must be held.
+func (c *PooledClient) discard() {
+	b := c.batch
+	if b == nil {
+		return
+	}
+	c.batch = nil
+	b.timer.Stop()
+	_ = b.Rollback()
+}
+
+// queryBatch runs a statement in the current batch, if any, so that informers read the events they wrote. The batch is
+// held until the rows are read.
+func (c *PooledClient) queryBatch(ctx context.Context, stmt *sql.Stmt, params ...any) (rows *sql.Rows, ok bool, err error) {
+	c.batchLock.Lock()
+	if c.batch == nil {
+		c.batchLock.Unlock()
+		return nil, false, nil
+	}
+	rows, err = c.batch.Stmt(stmt).QueryContext(ctx, params...)
+	if err != nil {
+		c.batchLock.Unlock()
+		return nil, true, err
+	}
+	c.batchRows.Store(rows, struct{}{})
+	return rows, true, nil
+}
+
+// release releases the batch rows were read in, if they were
+func (c *PooledClient) release(rows Rows) {
+	if _, ok := c.batchRows.LoadAndDelete(rows); ok {
+		c.batchLock.Unlock()
+	}
+}
+
+// ReadObjects reads the objects of rows, which it closes
+func (c *PooledClient) ReadObjects(rows Rows, typ reflect.Type, shouldDecrypt bool) ([]any, error) {
+	defer c.release(rows)
+	return c.readObjects(rows, typ, shouldDecrypt)
+}
+
+// ReadStrings reads the strings of rows, which it closes
+func (c *PooledClient) ReadStrings(rows Rows) ([]string, error) {
+	defer c.release(rows)
+	return readStrings(rows)
+}
+
+// ReadInt reads the integer of the first of rows, which it closes
+func (c *PooledClient) ReadInt(rows Rows) (int, error) {
+	defer c.release(rows)
+	return readInt(rows)
+}
+
+// batchedTx is a transaction of events written in a batch, within a savepoint of it
+type batchedTx struct {
+	batch  *batch
+	client *PooledClient
+	done   bool
+}
+
+func (b *batchedTx) Exec(query string, args ...any) (sql.Result, error) {
+	return b.batch.Exec(query, args...)
+}
+
+func (b *batchedTx) Stmt(stmt *sql.Stmt) *sql.Stmt {
+	return b.batch.Stmt(stmt)
+}
+
+// Commit releases the savepoint of the transaction, and commits the batch if it is full
+func (b *batchedTx) Commit() error {
+	if b.done {
+		return sql.ErrTxDone
+	}
+	b.done = true
+	defer b.client.batchLock.Unlock()
+	if _, err := b.batch.Exec("RELEASE " + savepoint); err != nil {
+		return err
+	}
+	b.batch.writes++
+	if b.batch.writes >= b.client.batchSize {
+		return b.client.flush()
+	}
+	return nil
+}
+
+// Rollback rolls the batch back to the savepoint of the transaction, keeping the transactions committed before it
+func (b *batchedTx) Rollback() error {
+	if b.done {
+		return sql.ErrTxDone
+	}
+	b.done = true
+	defer b.client.batchLock.Unlock()
+	if _, err := b.batch.Exec("ROLLBACK TO " + savepoint); err != nil {
+		return err
+	}
+	_, err := b.batch.Exec("RELEASE " + savepoint)
+	return err
+}
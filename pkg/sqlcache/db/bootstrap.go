@@ -0,0 +1,131 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"fmt"
+	"os"
+	"strconv"
+	"sync"
+
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func init() {
+	// objects are encoded with gob, as unstructured objects holding these types
+	gob.Register(map[string]interface{}{})
+	gob.Register([]interface{}{})
+}
+
+// Bootstrap reads the objects of a snapshot of the database of the SQL cache, so that the caches of a new replica start
+// from them rather than from lists of every object from the API server. Each type is only read once, by the first
+// list of its cache, the following ones listing from the API server, such as when the changes made since the snapshot
+// can't be watched anymore.
+type Bootstrap struct {
+	conn      *sql.DB
+	migration MigrationStatus
+
+	lock sync.Mutex
+	// read are the types read from the snapshot already
+	read map[schema.GroupVersionKind]bool
+}
+
+// OpenBootstrap opens the file of a snapshot, such as one downloaded from another replica, to bootstrap caches from. The
+// snapshot is migrated to SchemaVersion in place first, and ErrNewerSchema is returned for snapshots taken by a later
+// version of steve, such as before a downgrade.
+func OpenBootstrap(path string) (*Bootstrap, error) {
+	// sqlite creates missing files even in read-only mode
+	if _, err := os.Stat(path); err != nil {
+		return nil, err
+	}
+	migration, err := migrateFile(context.Background(), path)
+	if err != nil {
+		return nil, err
+	}
+	conn, err := sql.Open("sqlite", "file:"+path+"?mode=ro")
+	if err != nil {
+		return nil, err
+	}
+	if err := conn.Ping(); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return &Bootstrap{
+		conn:      conn,
+		migration: migration,
+		read:      map[schema.GroupVersionKind]bool{},
+	}, nil
+}
+
+// List returns the objects of a type in the snapshot, with the latest of their resourceVersions, which the changes made
+// since the snapshot are watched from. ok is false if the type was read already, if it isn't in the snapshot, or if its
+// objects are encrypted, since their keys are those of the replica which took the snapshot.
+func (b *Bootstrap) List(ctx context.Context, gvk schema.GroupVersionKind) (list *unstructured.UnstructuredList, ok bool, err error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if b.read[gvk] {
+		return nil, false, nil
+	}
+	b.read[gvk] = true
+
+	table := TypeTable(gvk)
+	var exists int
+	err = b.conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table).Scan(&exists)
+	if err != nil || exists == 0 {
+		return nil, false, err
+	}
+	rows, err := b.conn.QueryContext(ctx, fmt.Sprintf(`SELECT object, objectnonce, dekid FROM "%s"`, table))
+	if err != nil {
+		return nil, false, err
+	}
+	defer rows.Close()
+
+	list = &unstructured.UnstructuredList{}
+	var latest uint64
+	for rows.Next() {
+		var data, nonce []byte
+		var dekID uint32
+		if err := rows.Scan(&data, &nonce, &dekID); err != nil {
+			return nil, false, err
+		}
+		if len(nonce) > 0 || dekID != 0 {
+			return nil, false, nil
+		}
+		var obj *unstructured.Unstructured
+		if err := gob.NewDecoder(bytes.NewReader(data)).Decode(&obj); err != nil {
+			return nil, false, fmt.Errorf("decoding object of %s: %w", gvk, err)
+		}
+		// resourceVersions are opaque, but those of Kubernetes are ordered integers, which changes are watched from
+		revision, err := strconv.ParseUint(obj.GetResourceVersion(), 10, 64)
+		if err != nil {
+			return nil, false, nil
+		}
+		latest = max(latest, revision)
+		list.Items = append(list.Items, *obj)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, false, err
+	}
+	if latest == 0 {
+		// the changes of types without objects can't be watched from any revision
+		return nil, false, nil
+	}
+	list.SetResourceVersion(strconv.FormatUint(latest, 10))
+	return list, true, nil
+}
+
+// MigrationStatus returns the status of the layout of the snapshot, when it was opened
+func (b *Bootstrap) MigrationStatus() MigrationStatus {
+	return b.migration
+}
+
+// Close closes the snapshot
+func (b *Bootstrap) Close() error {
+	if b == nil {
+		return nil
+	}
+	return b.conn.Close()
+}
@@ -0,0 +1,74 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/gob"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestBootstrap(t *testing.T) {
+	// the snapshot holds the tables of objects the store writes
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	conn, err := sql.Open("sqlite", "file:"+path+"?mode=rwc")
+	require.NoError(t, err)
+	defer conn.Close()
+	insert := func(table, name, revision string, nonce []byte) {
+		_, err := conn.Exec(`CREATE TABLE IF NOT EXISTS "` + table + `" (key TEXT UNIQUE NOT NULL PRIMARY KEY, object BLOB, objectnonce BLOB, dekid INTEGER)`)
+		require.NoError(t, err)
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": name, "resourceVersion": revision},
+			"spec":     map[string]interface{}{"ports": []interface{}{int64(80)}},
+		}}
+		var buf bytes.Buffer
+		require.NoError(t, gob.NewEncoder(&buf).Encode(obj))
+		_, err = conn.Exec(`INSERT INTO "`+table+`" VALUES (?, ?, ?, ?)`, name, buf.Bytes(), nonce, 0)
+		require.NoError(t, err)
+	}
+	insert("_v1_Pod", "pod1", "12", nil)
+	insert("_v1_Pod", "pod2", "34", nil)
+	insert("_v1_Secret", "secret1", "56", []byte("nonce"))
+	insert("_v1_ConfigMap", "config1", "opaque", nil)
+
+	_, err = OpenBootstrap(filepath.Join(t.TempDir(), "missing.db"))
+	assert.Error(t, err)
+	b, err := OpenBootstrap(path)
+	require.NoError(t, err)
+	defer b.Close()
+	assert.Equal(t, 0, b.MigrationStatus().Version, "snapshots are migrated to the current layout")
+
+	ctx := context.Background()
+	pods := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	list, ok, err := b.List(ctx, pods)
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "34", list.GetResourceVersion(), "changes are watched from the latest object")
+	require.Len(t, list.Items, 2)
+	assert.Equal(t, "pod1", list.Items[0].GetName())
+	ports, _, _ := unstructured.NestedSlice(list.Items[0].Object, "spec", "ports")
+	assert.Equal(t, []interface{}{int64(80)}, ports)
+
+	// types are only bootstrapped once
+	_, ok, err = b.List(ctx, pods)
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	for _, kind := range []string{"Secret", "ConfigMap", "Node"} {
+		_, ok, err = b.List(ctx, schema.GroupVersionKind{Version: "v1", Kind: kind})
+		require.NoError(t, err)
+		assert.False(t, ok, "%s objects which are encrypted, have no ordered revisions or aren't in the snapshot are listed", kind)
+	}
+
+	// snapshots taken by later versions of steve aren't used
+	_, err = conn.Exec(`PRAGMA user_version = 1000`)
+	require.NoError(t, err)
+	_, err = OpenBootstrap(path)
+	assert.ErrorIs(t, err, ErrNewerSchema)
+}
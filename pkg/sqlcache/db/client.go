@@ -0,0 +1,154 @@
+package db
+
+import (
+	"bytes"
+	"database/sql"
+	"encoding/gob"
+	"fmt"
+	"reflect"
+
+	"github.com/rancher/steve/pkg/sqlcache/db/transaction"
+	_ "modernc.org/sqlite"
+)
+
+// InformerObjectCacheDBPath is the file of the database of the SQL cache by default, relative to the working directory
+const InformerObjectCacheDBPath = "informer_object_cache.db"
+
+// Closable Closes an underlying connection and returns an error on failure.
+type Closable interface {
+	Close() error
+}
+
+// Rows represents sql rows. It exposes method to navigate the rows, read their outputs, and close them.
+type Rows interface {
+	Next() bool
+	Err() error
+	Close() error
+	Scan(dest ...any) error
+}
+
+// QueryError encapsulates an error while executing a query
+type QueryError struct {
+	QueryString string
+	Err         error
+}
+
+// Error returns a string representation of this QueryError
+func (e *QueryError) Error() string {
+	return "while executing query: " + e.QueryString + " got error: " + e.Err.Error()
+}
+
+// Unwrap returns the underlying error
+func (e *QueryError) Unwrap() error {
+	return e.Err
+}
+
+// TXClient represents a sql transaction. The TXClient must manage rollbacks as rollback functionality is not exposed.
+type TXClient interface {
+	StmtExec(stmt transaction.Stmt, args ...any) error
+	Exec(stmt string, args ...any) error
+	Commit() error
+	Stmt(stmt *sql.Stmt) transaction.Stmt
+	Cancel() error
+}
+
+// Encryptor encrypts data with a key which is rotated to avoid wear-out.
+type Encryptor interface {
+	// Encrypt encrypts the specified data, returning: the encrypted data, the nonce used to encrypt the data, and an ID identifying the key that was used (as it rotates). On failure error is returned instead.
+	Encrypt([]byte) ([]byte, []byte, uint32, error)
+}
+
+// Decryptor decrypts data previously encrypted by Encryptor.
+type Decryptor interface {
+	// Decrypt accepts a chunk of encrypted data, the nonce used to encrypt it and the ID of the used key (as it rotates). It returns the decrypted data or an error.
+	Decrypt([]byte, []byte, uint32) ([]byte, error)
+}
+
+// readObjects scans the given rows, decrypting them if shouldDecrypt is true, and decodes them into objects of typ
+func (c *PooledClient) readObjects(rows Rows, typ reflect.Type, shouldDecrypt bool) ([]any, error) {
+	var result []any
+	for rows.Next() {
+		data, err := c.decryptScan(rows, shouldDecrypt)
+		if err != nil {
+			return nil, closeRowsOnError(rows, err)
+		}
+		singleResult, err := fromBytes(data, typ)
+		if err != nil {
+			return nil, closeRowsOnError(rows, err)
+		}
+		result = append(result, singleResult.Elem().Interface())
+	}
+	if err := rows.Err(); err != nil {
+		return nil, closeRowsOnError(rows, err)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// readStrings scans the given rows into strings
+func readStrings(rows Rows) ([]string, error) {
+	var result []string
+	for rows.Next() {
+		var key string
+		if err := rows.Scan(&key); err != nil {
+			return nil, closeRowsOnError(rows, err)
+		}
+		result = append(result, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, closeRowsOnError(rows, err)
+	}
+	if err := rows.Close(); err != nil {
+		return nil, err
+	}
+	return result, nil
+}
+
+// readInt scans the first of the given rows into a single int, such as that of a COUNT query
+func readInt(rows Rows) (int, error) {
+	if !rows.Next() {
+		return 0, closeRowsOnError(rows, sql.ErrNoRows)
+	}
+	var result int
+	if err := rows.Scan(&result); err != nil {
+		return 0, closeRowsOnError(rows, err)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, closeRowsOnError(rows, err)
+	}
+	if err := rows.Close(); err != nil {
+		return 0, err
+	}
+	return result, nil
+}
+
+func (c *PooledClient) decryptScan(rows Rows, shouldDecrypt bool) ([]byte, error) {
+	var data, dataNonce sql.RawBytes
+	var kid uint32
+	if err := rows.Scan(&data, &dataNonce, &kid); err != nil {
+		return nil, err
+	}
+	if shouldDecrypt {
+		return c.decryptor.Decrypt(data, dataNonce, kid)
+	}
+	return data, nil
+}
+
+// fromBytes decodes an object from a byte slice
+func fromBytes(buf sql.RawBytes, typ reflect.Type) (reflect.Value, error) {
+	dec := gob.NewDecoder(bytes.NewReader(buf))
+	singleResult := reflect.New(typ)
+	err := dec.DecodeValue(singleResult)
+	return singleResult, err
+}
+
+// closeRowsOnError closes the sql.Rows object and wraps errors if needed
+func closeRowsOnError(rows Rows, err error) error {
+	ce := rows.Close()
+	if ce != nil {
+		return fmt.Errorf("error in closing rows while handling %s: %w", err.Error(), ce)
+	}
+	return err
+}
@@ -0,0 +1,139 @@
+package db
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// CachedType is a type discovered in the cluster, whose objects are cached with the fields indexed for it
+type CachedType struct {
+	GVK        schema.GroupVersionKind
+	Fields     [][]string
+	Namespaced bool
+}
+
+// ConsistencyReport are the discrepancies between the tables of a database, such as one kept from an earlier run, and
+// the types discovered in the cluster
+type ConsistencyReport struct {
+	Database string    `json:"database"`
+	Checked  time.Time `json:"checked"`
+	// ExtraTables are the objects tables of types which weren't discovered, such as those of CRDs deleted since
+	ExtraTables []string `json:"extraTables,omitempty"`
+	// MissingTables are the objects tables of discovered types which don't exist, which are created by the first list
+	// of their type
+	MissingTables []string `json:"missingTables,omitempty"`
+	// MismatchedColumns are the fields tables whose columns aren't those of the fields indexed for their type
+	MismatchedColumns []ColumnMismatch `json:"mismatchedColumns,omitempty"`
+	// Reconciled is true if the extra tables were dropped, and the mismatched columns altered in place
+	Reconciled bool   `json:"reconciled"`
+	Error      string `json:"error,omitempty"`
+}
+
+// CheckConsistency compares the tables of the database with the types discovered, logging the discrepancies, and, if
+// reconcile is true, drops the tables of the types which weren't discovered and alters the mismatched columns of the
+// fields tables in place. It must be called while no informer is created, such as before the first lists.
+func (c *PooledClient) CheckConsistency(types []CachedType, reconcile bool) (ConsistencyReport, error) {
+	report, err := c.checkConsistency(types, reconcile)
+	if err != nil {
+		report.Error = err.Error()
+	}
+	c.lock.Lock()
+	c.consistency = &report
+	c.lock.Unlock()
+	return report, err
+}
+
+func (c *PooledClient) checkConsistency(types []CachedType, reconcile bool) (ConsistencyReport, error) {
+	ctx := context.Background()
+	report := ConsistencyReport{Database: c.path, Checked: time.Now()}
+	discovered := map[string]CachedType{}
+	for _, typ := range types {
+		discovered[TypeTable(typ.GVK)] = typ
+	}
+
+	c.lock.RLock()
+	tables, err := typeTables(ctx, c.reader)
+	if err != nil {
+		c.lock.RUnlock()
+		return report, err
+	}
+	for _, table := range tables {
+		if _, ok := discovered[table]; !ok {
+			report.ExtraTables = append(report.ExtraTables, table)
+		}
+	}
+	for table, typ := range discovered {
+		if !slices.Contains(tables, table) {
+			report.MissingTables = append(report.MissingTables, table)
+			continue
+		}
+		mismatch, err := fieldsMismatch(ctx, c.reader, table, typ.Fields, typ.Namespaced)
+		if err != nil {
+			c.lock.RUnlock()
+			return report, err
+		}
+		if mismatch != nil {
+			report.MismatchedColumns = append(report.MismatchedColumns, *mismatch)
+		}
+	}
+	c.lock.RUnlock()
+	sort.Strings(report.ExtraTables)
+	sort.Strings(report.MissingTables)
+	sort.Slice(report.MismatchedColumns, func(i, j int) bool {
+		return report.MismatchedColumns[i].Table < report.MismatchedColumns[j].Table
+	})
+
+	if len(report.ExtraTables) > 0 {
+		logrus.Warnf("SQL cache database %s has the tables of types which weren't discovered: %v", c.path, report.ExtraTables)
+	}
+	for _, mismatch := range report.MismatchedColumns {
+		logrus.Warnf("SQL cache database %s has fields of %s which aren't indexed: %v, and lacks indexed fields: %v", c.path, mismatch.Table, mismatch.Extra, mismatch.Missing)
+	}
+	if len(report.MissingTables) > 0 {
+		logrus.Infof("SQL cache database %s has no tables for %d discovered types, created by their first lists", c.path, len(report.MissingTables))
+	}
+	if !reconcile || (len(report.ExtraTables) == 0 && len(report.MismatchedColumns) == 0) {
+		return report, nil
+	}
+
+	tx, err := c.BeginTx(ctx, true)
+	if err != nil {
+		return report, err
+	}
+	for _, table := range report.ExtraTables {
+		for _, suffix := range []string{"_fields", "_indices", "_labels", ""} {
+			if err := tx.Exec(fmt.Sprintf(`DROP TABLE IF EXISTS "%s%s"`, table, suffix)); err != nil {
+				return report, err
+			}
+		}
+	}
+	for _, mismatch := range report.MismatchedColumns {
+		for _, stmt := range mismatch.statements() {
+			if err := tx.Exec(stmt); err != nil {
+				return report, err
+			}
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return report, err
+	}
+	report.Reconciled = true
+	logrus.Infof("Reconciled SQL cache database %s: dropped the tables of %d types, altered the fields of %d types", c.path, len(report.ExtraTables), len(report.MismatchedColumns))
+	return report, nil
+}
+
+// ConsistencyReport returns the report of the last consistency check of the database, false if it wasn't checked
+func (c *PooledClient) ConsistencyReport() (ConsistencyReport, bool) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	if c.consistency == nil {
+		return ConsistencyReport{}, false
+	}
+	return *c.consistency, true
+}
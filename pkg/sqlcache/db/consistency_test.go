@@ -0,0 +1,61 @@
+package db_test
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestCheckConsistency(t *testing.T) {
+	c, err := db.OpenPooledClient(filepath.Join(t.TempDir(), "cache.db"), 2)
+	require.NoError(t, err)
+	defer c.Close()
+	for _, name := range []string{"_v1_Pod", "example.com_v1_Gone"} {
+		s, err := store.NewStore(&unstructured.Unstructured{}, cache.DeletionHandlingMetaNamespaceKeyFunc, c, false, name)
+		require.NoError(t, err)
+		_, err = informer.NewListOptionIndexer([][]string{{"spec", "nodeName"}}, s, true)
+		require.NoError(t, err)
+	}
+	_, ok := c.ConsistencyReport()
+	assert.False(t, ok)
+
+	types := []db.CachedType{
+		{GVK: schema.GroupVersionKind{Version: "v1", Kind: "Pod"}, Fields: [][]string{{"spec", "hostname"}}, Namespaced: true},
+		{GVK: schema.GroupVersionKind{Version: "v1", Kind: "Node"}},
+	}
+	mismatch := db.ColumnMismatch{Table: "_v1_Pod", Missing: []string{"spec.hostname"}, Extra: []string{"spec.nodeName"}}
+
+	// discrepancies are only reported unless they are reconciled
+	report, err := c.CheckConsistency(types, false)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"example.com_v1_Gone"}, report.ExtraTables)
+	assert.Equal(t, []string{"_v1_Node"}, report.MissingTables)
+	assert.Equal(t, []db.ColumnMismatch{mismatch}, report.MismatchedColumns)
+	assert.False(t, report.Reconciled)
+	last, ok := c.ConsistencyReport()
+	require.True(t, ok)
+	assert.Equal(t, report, last)
+	report, err = c.CheckConsistency(types, false)
+	require.NoError(t, err)
+	assert.Equal(t, []db.ColumnMismatch{mismatch}, report.MismatchedColumns)
+
+	report, err = c.CheckConsistency(types, true)
+	require.NoError(t, err)
+	assert.True(t, report.Reconciled)
+	assert.Equal(t, []db.ColumnMismatch{mismatch}, report.MismatchedColumns, "the discrepancies reconciled are reported")
+
+	report, err = c.CheckConsistency(types, true)
+	require.NoError(t, err)
+	assert.Empty(t, report.ExtraTables)
+	assert.Empty(t, report.MismatchedColumns)
+	assert.Equal(t, []string{"_v1_Node"}, report.MissingTables, "missing tables are created by the first lists of their types")
+	assert.False(t, report.Reconciled)
+}
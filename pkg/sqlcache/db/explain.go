@@ -0,0 +1,154 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+
+	"github.com/sirupsen/logrus"
+)
+
+// listQueryLogPrefix prefixes the debug message in which the ListOptionIndexer logs the statements of lists before
+// running them
+const listQueryLogPrefix = "ListOptionIndexer prepared statement: "
+
+// QueryPlan is the plan SQLite chose for a statement
+type QueryPlan struct {
+	// Steps are the details of the steps of the plan, such as "SEARCH f USING INDEX ..."
+	Steps []string
+	// FullScans are the steps scanning whole tables rather than searching indexes
+	FullScans []string
+	// TempSorts are the steps sorting rows in a temporary b-tree, because no index matches the sort
+	TempSorts []string
+}
+
+// Indexed returns whether the statement neither scans whole tables nor sorts without an index
+func (p QueryPlan) Indexed() bool {
+	return len(p.FullScans) == 0 && len(p.TempSorts) == 0
+}
+
+// Explainer logs the plans of the statements of lists of the SQL cache. It is a logrus hook, explaining the statements
+// the ListOptionIndexer logs at the debug level, so it only explains statements when debug logging is enabled.
+type Explainer struct {
+	path string
+
+	lock sync.Mutex
+	conn *sql.DB
+}
+
+// NewExplainer returns an Explainer of the statements run on the database of the SQL cache at path
+func NewExplainer(path string) *Explainer {
+	return &Explainer{
+		path: path,
+	}
+}
+
+// EnableExplain logs the plans of the statements of the lists of the SQL cache with an Explainer of its database at
+// path. Statements are explained against the database of each SQL cache they are explained for, those of other caches
+// failing to be
+func EnableExplain(path string) {
+	if !logrus.IsLevelEnabled(logrus.DebugLevel) {
+		logrus.Warn("SQL cache query plans are only explained with debug logging")
+	}
+	logrus.AddHook(NewExplainer(path))
+}
+
+// Levels returns the levels of the messages in which statements are logged
+func (e *Explainer) Levels() []logrus.Level {
+	return []logrus.Level{logrus.DebugLevel}
+}
+
+// Fire explains the statement of a message, if it logs one
+func (e *Explainer) Fire(entry *logrus.Entry) error {
+	query, ok := strings.CutPrefix(entry.Message, listQueryLogPrefix)
+	if !ok {
+		return nil
+	}
+	plan, err := e.Explain(context.Background(), query)
+	if err != nil {
+		logrus.Debugf("Failed to explain the query plan of a SQL cache list: %v", err)
+		return nil
+	}
+	fields := logrus.Fields{
+		"query":   query,
+		"plan":    strings.Join(plan.Steps, "; "),
+		"indexed": plan.Indexed(),
+	}
+	if !plan.Indexed() {
+		fields["fullScans"] = plan.FullScans
+		fields["tempSorts"] = plan.TempSorts
+		logrus.WithFields(fields).Info("SQL cache list doesn't use indexes")
+		return nil
+	}
+	logrus.WithFields(fields).Debug("SQL cache list query plan")
+	return nil
+}
+
+// Explain returns the plan of a statement. Its parameters are bound to NULL, as the plan doesn't depend on their values.
+func (e *Explainer) Explain(ctx context.Context, query string) (QueryPlan, error) {
+	conn, err := e.connection()
+	if err != nil {
+		return QueryPlan{}, err
+	}
+	params := make([]any, countParams(query))
+	rows, err := conn.QueryContext(ctx, "EXPLAIN QUERY PLAN "+query, params...)
+	if err != nil {
+		return QueryPlan{}, err
+	}
+	defer rows.Close()
+
+	var plan QueryPlan
+	for rows.Next() {
+		var id, parent, unused int64
+		var detail string
+		if err := rows.Scan(&id, &parent, &unused, &detail); err != nil {
+			return QueryPlan{}, err
+		}
+		plan.Steps = append(plan.Steps, detail)
+		switch {
+		case strings.HasPrefix(detail, "SCAN ") && !strings.Contains(detail, " USING "):
+			plan.FullScans = append(plan.FullScans, detail)
+		case strings.HasPrefix(detail, "USE TEMP B-TREE"):
+			plan.TempSorts = append(plan.TempSorts, detail)
+		}
+	}
+	return plan, rows.Err()
+}
+
+// connection returns the handle of the database, which can't write to it. Its connections aren't kept idle, so that
+// statements are explained against the database recreated by the resets of the cache.
+func (e *Explainer) connection() (*sql.DB, error) {
+	e.lock.Lock()
+	defer e.lock.Unlock()
+	if e.conn != nil {
+		return e.conn, nil
+	}
+	conn, err := sql.Open("sqlite", "file:"+e.path+"?mode=rw&_pragma=query_only=1")
+	if err != nil {
+		return nil, err
+	}
+	conn.SetMaxOpenConns(1)
+	conn.SetMaxIdleConns(0)
+	e.conn = conn
+	return conn, nil
+}
+
+// countParams returns the number of parameters of a statement, the question marks outside of quotes
+func countParams(query string) int {
+	count := 0
+	var quote rune
+	for _, c := range query {
+		switch {
+		case quote != 0:
+			if c == quote {
+				quote = 0
+			}
+		case c == '\'' || c == '"':
+			quote = c
+		case c == '?':
+			count++
+		}
+	}
+	return count
+}
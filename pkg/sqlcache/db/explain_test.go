@@ -0,0 +1,94 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/sirupsen/logrus"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func newExplainerDB(t *testing.T) *Explainer {
+	path := filepath.Join(t.TempDir(), "explain.db")
+	conn, err := sql.Open("sqlite", "file:"+path+"?mode=rwc&_pragma=journal_mode=wal")
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, stmt := range []string{
+		`CREATE TABLE "_v1_Pod" (key TEXT UNIQUE NOT NULL PRIMARY KEY, object BLOB)`,
+		`CREATE TABLE "_v1_Pod_fields" (key TEXT NOT NULL PRIMARY KEY, "metadata.name" TEXT, "spec.nodeName" TEXT)`,
+		`CREATE INDEX "_v1_Pod_fields_metadata.name_index" ON "_v1_Pod_fields"("metadata.name")`,
+	} {
+		_, err := conn.Exec(stmt)
+		require.NoError(t, err)
+	}
+	e := NewExplainer(path)
+	return e
+}
+
+func TestExplainerExplain(t *testing.T) {
+	e := newExplainerDB(t)
+	tests := []struct {
+		name        string
+		query       string
+		wantIndexed bool
+	}{
+		{
+			name: "filter and sort on an indexed field",
+			query: `SELECT o.object FROM "_v1_Pod" o JOIN "_v1_Pod_fields" f ON o.key = f.key
+  WHERE (f."metadata.name" = ?)
+  ORDER BY f."metadata.name" ASC
+  LIMIT ?`,
+			wantIndexed: true,
+		},
+		{
+			name: "filter on an unindexed field",
+			query: `SELECT o.object FROM "_v1_Pod" o JOIN "_v1_Pod_fields" f ON o.key = f.key
+  WHERE (f."spec.nodeName" LIKE ? ESCAPE '\')`,
+		},
+		{
+			name: "sort on an unindexed field",
+			query: `SELECT o.object FROM "_v1_Pod" o JOIN "_v1_Pod_fields" f ON o.key = f.key
+  WHERE (f."metadata.name" = ?)
+  ORDER BY f."spec.nodeName" DESC`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			plan, err := e.Explain(context.Background(), test.query)
+			require.NoError(t, err)
+			assert.NotEmpty(t, plan.Steps)
+			assert.Equal(t, test.wantIndexed, plan.Indexed(), plan.Steps)
+		})
+	}
+
+	_, err := e.Explain(context.Background(), `SELECT * FROM "_v1_Missing"`)
+	assert.Error(t, err)
+}
+
+func TestExplainerFire(t *testing.T) {
+	e := newExplainerDB(t)
+	var out bytes.Buffer
+	logrus.SetOutput(&out)
+	t.Cleanup(func() { logrus.SetOutput(os.Stderr) })
+
+	entry := logrus.NewEntry(logrus.StandardLogger())
+	entry.Message = "Params: [a]"
+	require.NoError(t, e.Fire(entry))
+	assert.Empty(t, out.String())
+
+	entry.Message = listQueryLogPrefix + `SELECT o.object FROM "_v1_Pod" o JOIN "_v1_Pod_fields" f ON o.key = f.key WHERE (f."spec.nodeName" = ?)`
+	require.NoError(t, e.Fire(entry))
+	assert.Contains(t, out.String(), "SQL cache list doesn't use indexes")
+	assert.Contains(t, out.String(), "fullScans")
+}
+
+func TestCountParams(t *testing.T) {
+	assert.Equal(t, 0, countParams(`SELECT 1`))
+	assert.Equal(t, 2, countParams(`SELECT * FROM t WHERE a = ? AND b LIKE ? ESCAPE '\'`))
+	assert.Equal(t, 1, countParams(`SELECT "a?" FROM t WHERE b = '?' AND c = ?`))
+}
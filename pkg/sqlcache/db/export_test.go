@@ -0,0 +1,16 @@
+package db
+
+import "database/sql"
+
+// internals exposed to the tests of package db_test, which write to the client with informers importing this package
+
+var (
+	MaxRows     = maxRows
+	MigrateFile = migrateFile
+	Migrations  = migrations
+)
+
+// Reader returns the read-only pool of the client
+func (c *PooledClient) Reader() *sql.DB {
+	return c.reader
+}
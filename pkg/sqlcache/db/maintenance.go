@@ -0,0 +1,195 @@
+// Package db is the SQLite database in which the SQL cache stores objects: the client informers write and lists read
+// objects with, and the migrations, snapshots, tuning and maintenance of the database.
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// autoVacuumIncremental is the value of the auto_vacuum pragma for databases which can be vacuumed incrementally
+	autoVacuumIncremental = 2
+	// maxResults is the number of compaction results kept
+	maxResults = 10
+)
+
+// EnableIncrementalVacuum makes the databases of the SQL cache created from now on vacuumable incrementally, so that
+// compactions release the pages of deleted objects to the file system without blocking writers for long. It must be
+// called before the cache is created, as the mode of a database can't be changed once it has tables.
+func EnableIncrementalVacuum() {
+	connections.lock.Lock()
+	connections.incrementalVacuum = true
+	connections.lock.Unlock()
+	registerConnectionHook()
+}
+
+// CompactionResult is the result of a compaction of the database
+type CompactionResult struct {
+	Start    time.Time     `json:"start"`
+	Duration time.Duration `json:"duration"`
+	// Scheduled is false for compactions triggered on demand
+	Scheduled bool `json:"scheduled"`
+	// FreedBytes is the size of the pages released to the file system by the incremental vacuum
+	FreedBytes int64 `json:"freedBytes"`
+	// TruncatedWALBytes is the size of the write-ahead log, truncated after its pages were written back to the database
+	TruncatedWALBytes int64 `json:"truncatedWALBytes"`
+	// Busy is true if the checkpoint couldn't complete because of concurrent transactions
+	Busy  bool   `json:"busy"`
+	Error string `json:"error,omitempty"`
+}
+
+// Maintainer compacts the database of the SQL cache: it releases the pages of deleted objects with an incremental
+// vacuum, if EnableIncrementalVacuum was called before the database was created, and checkpoints and truncates the
+// write-ahead log.
+type Maintainer struct {
+	path string
+	now  func() time.Time
+
+	// compactLock serializes compactions
+	compactLock sync.Mutex
+	lock        sync.Mutex
+	results     []CompactionResult
+}
+
+// NewMaintainer returns a Maintainer of the database of the SQL cache at path
+func NewMaintainer(path string) *Maintainer {
+	return &Maintainer{
+		path: path,
+		now:  time.Now,
+	}
+}
+
+// Run compacts the database according to schedule until ctx is done
+func (m *Maintainer) Run(ctx context.Context, schedule *Schedule) {
+	for {
+		next := schedule.Next(m.now())
+		if next.IsZero() {
+			logrus.Warnf("SQL cache maintenance schedule %q never runs", schedule)
+			return
+		}
+		logrus.Debugf("Next SQL cache compaction at %s", next)
+		timer := time.NewTimer(next.Sub(m.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+		result, err := m.compact(ctx, true)
+		if err != nil {
+			logrus.Errorf("Failed to compact the SQL cache database: %v", err)
+			continue
+		}
+		logrus.Infof("Compacted the SQL cache database in %s, %d bytes freed, %d WAL bytes truncated", result.Duration, result.FreedBytes, result.TruncatedWALBytes)
+	}
+}
+
+// Compact compacts the database now
+func (m *Maintainer) Compact(ctx context.Context) (CompactionResult, error) {
+	return m.compact(ctx, false)
+}
+
+// Results returns the results of the last compactions, the latest first
+func (m *Maintainer) Results() []CompactionResult {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	result := make([]CompactionResult, len(m.results))
+	for i := range m.results {
+		result[i] = m.results[len(m.results)-1-i]
+	}
+	return result
+}
+
+func (m *Maintainer) compact(ctx context.Context, scheduled bool) (CompactionResult, error) {
+	m.compactLock.Lock()
+	defer m.compactLock.Unlock()
+
+	result := CompactionResult{
+		Start:     m.now(),
+		Scheduled: scheduled,
+	}
+	err := m.compactDB(ctx, &result)
+	result.Duration = m.now().Sub(result.Start)
+	if err != nil {
+		result.Error = err.Error()
+	}
+
+	m.lock.Lock()
+	m.results = append(m.results, result)
+	if len(m.results) > maxResults {
+		m.results = m.results[len(m.results)-maxResults:]
+	}
+	m.lock.Unlock()
+	return result, err
+}
+
+func (m *Maintainer) compactDB(ctx context.Context, result *CompactionResult) error {
+	// the database is opened read-write without creating it, waiting for the transactions of the cache to complete
+	conn, err := sql.Open("sqlite", "file:"+m.path+"?mode=rw&_pragma=busy_timeout=120000")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+
+	var autoVacuum, pageSize, freeBefore, freeAfter int64
+	if err := conn.QueryRowContext(ctx, "PRAGMA auto_vacuum").Scan(&autoVacuum); err != nil {
+		return fmt.Errorf("reading auto_vacuum: %w", err)
+	}
+	if autoVacuum == autoVacuumIncremental {
+		if err := conn.QueryRowContext(ctx, "PRAGMA page_size").Scan(&pageSize); err != nil {
+			return fmt.Errorf("reading page_size: %w", err)
+		}
+		if err := conn.QueryRowContext(ctx, "PRAGMA freelist_count").Scan(&freeBefore); err != nil {
+			return fmt.Errorf("reading freelist_count: %w", err)
+		}
+		if err := incrementalVacuum(ctx, conn); err != nil {
+			return fmt.Errorf("incremental vacuum: %w", err)
+		}
+		if err := conn.QueryRowContext(ctx, "PRAGMA freelist_count").Scan(&freeAfter); err != nil {
+			return fmt.Errorf("reading freelist_count: %w", err)
+		}
+		result.FreedBytes = (freeBefore - freeAfter) * pageSize
+	}
+
+	walSize := fileSize(m.path + "-wal")
+	var busy, logFrames, checkpointed int64
+	if err := conn.QueryRowContext(ctx, "PRAGMA wal_checkpoint(TRUNCATE)").Scan(&busy, &logFrames, &checkpointed); err != nil {
+		return fmt.Errorf("checkpoint: %w", err)
+	}
+	result.Busy = busy != 0
+	result.TruncatedWALBytes = walSize - fileSize(m.path+"-wal")
+	if result.Busy {
+		return errors.New("checkpoint incomplete, the database is busy")
+	}
+	return nil
+}
+
+// incrementalVacuum releases all free pages, one per row of the pragma
+func incrementalVacuum(ctx context.Context, conn *sql.DB) error {
+	rows, err := conn.QueryContext(ctx, "PRAGMA incremental_vacuum")
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+	}
+	return rows.Err()
+}
+
+// fileSize returns the size of a file, 0 if it doesn't exist
+func fileSize(path string) int64 {
+	info, err := os.Stat(path)
+	if err != nil {
+		return 0
+	}
+	return info.Size()
+}
@@ -0,0 +1,56 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMaintainerCompact(t *testing.T) {
+	EnableIncrementalVacuum()
+	// the database is opened as the cache does
+	path := filepath.Join(t.TempDir(), InformerObjectCacheDBPath)
+	conn, err := sql.Open("sqlite", "file:"+path+"?mode=rwc&_pragma=journal_mode=wal&_pragma=synchronous=off")
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	var autoVacuum int
+	require.NoError(t, conn.QueryRow("PRAGMA auto_vacuum").Scan(&autoVacuum))
+	assert.Equal(t, autoVacuumIncremental, autoVacuum)
+	_, err = conn.Exec("CREATE TABLE objects (key TEXT PRIMARY KEY, data BLOB)")
+	require.NoError(t, err)
+	data := strings.Repeat("x", 4096)
+	for i := 0; i < 100; i++ {
+		_, err = conn.Exec("INSERT INTO objects VALUES (?, ?)", i, data)
+		require.NoError(t, err)
+	}
+	_, err = conn.Exec("DELETE FROM objects")
+	require.NoError(t, err)
+
+	m := NewMaintainer(path)
+	result, err := m.Compact(context.Background())
+	require.NoError(t, err)
+	assert.False(t, result.Scheduled)
+	assert.Greater(t, result.FreedBytes, int64(0))
+	assert.Greater(t, result.TruncatedWALBytes, int64(0))
+	assert.Empty(t, result.Error)
+
+	// nothing is left to compact
+	second, err := m.Compact(context.Background())
+	require.NoError(t, err)
+	assert.Zero(t, second.FreedBytes)
+	assert.Equal(t, []CompactionResult{second, result}, m.Results())
+}
+
+func TestMaintainerCompactMissingDatabase(t *testing.T) {
+	m := NewMaintainer(filepath.Join(t.TempDir(), "missing.db"))
+	result, err := m.Compact(context.Background())
+	assert.Error(t, err)
+	assert.NotEmpty(t, result.Error)
+	assert.Len(t, m.Results(), 1)
+}
@@ -0,0 +1,275 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// SchemaVersion is the version of the layout of the tables of the SQL cache written by this version of steve, which is
+// kept in the user_version of databases. Databases and snapshots of earlier layouts are migrated to it in place, while
+// those of later layouts, written by a later version of steve before a downgrade, aren't used. Databases of version 0
+// are in the layout of lasso's cache, which the SQL cache of steve was forked from.
+const SchemaVersion = 1
+
+// ErrNewerSchema is returned for databases in a layout written by a later version of steve
+var ErrNewerSchema = errors.New("database written by a later version of steve")
+
+// Migration alters the tables of each type in place from the layout of the previous version
+type Migration struct {
+	Version     int
+	Description string
+	// statements returns the statements altering the tables of a type, whose objects table is table
+	statements func(table string) []string
+}
+
+// migrations are the migrations of the layout of the tables of types, in order of version, the last one being
+// SchemaVersion. The tables of types created in databases of the current layout are created in it.
+var migrations = []Migration{
+	{
+		Version:     1,
+		Description: "index the keys of the indices tables, which the indices of objects are deleted by",
+		statements: func(table string) []string {
+			return []string{fmt.Sprintf(`CREATE INDEX IF NOT EXISTS "%[1]s_indices_key_index" ON "%[1]s_indices"(key)`, table)}
+		},
+	},
+}
+
+// MigrationStatus is the status of the layout of a database, such as that of the SQL cache or of the snapshot it was
+// bootstrapped from
+type MigrationStatus struct {
+	Database string `json:"database"`
+	// Version is the version of the layout of the database when it was opened, before its migrations
+	Version       int                `json:"version"`
+	SchemaVersion int                `json:"schemaVersion"`
+	Applied       []AppliedMigration `json:"applied,omitempty"`
+	// Kept is true if the database of an earlier run was kept, rather than created
+	Kept bool `json:"kept"`
+	// Error is why the database couldn't be migrated, and was recreated, or not used
+	Error string `json:"error,omitempty"`
+}
+
+// AppliedMigration is a migration applied to a database
+type AppliedMigration struct {
+	Version     int           `json:"version"`
+	Description string        `json:"description"`
+	Duration    time.Duration `json:"duration"`
+}
+
+// migrate migrates the tables of the types of a database to SchemaVersion, each migration in a transaction of its own,
+// returning ErrNewerSchema for databases of a later version
+func migrate(ctx context.Context, conn *sql.DB, database string) (MigrationStatus, error) {
+	status := MigrationStatus{Database: database, SchemaVersion: SchemaVersion}
+	if err := conn.QueryRowContext(ctx, "PRAGMA user_version").Scan(&status.Version); err != nil {
+		return status, err
+	}
+	if status.Version > SchemaVersion {
+		return status, fmt.Errorf("%w: layout version %d, while steve knows up to %d", ErrNewerSchema, status.Version, SchemaVersion)
+	}
+	tables, err := typeTables(ctx, conn)
+	if err != nil {
+		return status, err
+	}
+	for _, m := range migrations {
+		if m.Version <= status.Version {
+			continue
+		}
+		start := time.Now()
+		if err := applyMigration(ctx, conn, m, tables); err != nil {
+			return status, fmt.Errorf("migrating to layout version %d: %w", m.Version, err)
+		}
+		status.Applied = append(status.Applied, AppliedMigration{
+			Version:     m.Version,
+			Description: m.Description,
+			Duration:    time.Since(start),
+		})
+		logrus.Infof("Migrated SQL cache database %s to layout version %d: %s", database, m.Version, m.Description)
+	}
+	return status, nil
+}
+
+func applyMigration(ctx context.Context, conn *sql.DB, m Migration, tables []string) error {
+	tx, err := conn.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+	for _, table := range tables {
+		for _, stmt := range m.statements(table) {
+			if _, err := tx.ExecContext(ctx, stmt); err != nil {
+				return err
+			}
+		}
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf("PRAGMA user_version = %d", m.Version)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// typeTables returns the objects tables of the types of a database, those having an indices table
+func typeTables(ctx context.Context, conn *sql.DB) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, `SELECT name FROM sqlite_master WHERE type = 'table' AND name || '_indices' IN (SELECT name FROM sqlite_master WHERE type = 'table')`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var tables []string
+	for rows.Next() {
+		var table string
+		if err := rows.Scan(&table); err != nil {
+			return nil, err
+		}
+		tables = append(tables, table)
+	}
+	return tables, rows.Err()
+}
+
+// migrateFile migrates the database of a file, such as a snapshot
+func migrateFile(ctx context.Context, path string) (MigrationStatus, error) {
+	conn, err := sql.Open("sqlite", "file:"+path+"?mode=rw&_pragma=busy_timeout=120000")
+	if err != nil {
+		return MigrationStatus{Database: path, SchemaVersion: SchemaVersion}, err
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+	return migrate(ctx, conn, path)
+}
+
+// MigrationStatus returns the status of the layout of the database, when it was last opened
+func (c *PooledClient) MigrationStatus() MigrationStatus {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return c.migration
+}
+
+// AlterFields alters the columns of the fields table of a type kept from an earlier run in place, before its informer
+// uses it, so that they are those of fields, and of the metadata always indexed. Missing columns are filled as objects
+// are synced again. Tables which don't exist are created by the informer.
+func (c *PooledClient) AlterFields(gvk schema.GroupVersionKind, fields [][]string, namespaced bool) error {
+	ctx := context.Background()
+	table := TypeTable(gvk)
+	c.lock.RLock()
+	mismatch, err := fieldsMismatch(ctx, c.reader, table, fields, namespaced)
+	c.lock.RUnlock()
+	if err != nil || mismatch == nil {
+		return err
+	}
+	tx, err := c.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	for _, stmt := range mismatch.statements() {
+		if err := tx.Exec(stmt); err != nil {
+			return err
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return err
+	}
+	logrus.Infof("Altered the fields of %s in the SQL cache: added %v, dropped %v", gvk, mismatch.Missing, mismatch.Extra)
+	return nil
+}
+
+// ColumnMismatch are the columns of the fields table of a type which don't match the fields indexed for it
+type ColumnMismatch struct {
+	// Table is the objects table of the type
+	Table string `json:"table"`
+	// Missing are the columns of indexed fields the fields table doesn't have, Extra those of fields which aren't
+	Missing []string `json:"missing,omitempty"`
+	Extra   []string `json:"extra,omitempty"`
+}
+
+// fieldsMismatch returns how the columns of the fields table of a type don't match fields, nil if they do or if the
+// table doesn't exist
+func fieldsMismatch(ctx context.Context, conn *sql.DB, table string, fields [][]string, namespaced bool) (*ColumnMismatch, error) {
+	var exists int
+	err := conn.QueryRowContext(ctx, `SELECT COUNT(*) FROM sqlite_master WHERE type = 'table' AND name = ?`, table+"_fields").Scan(&exists)
+	if err != nil || exists == 0 {
+		return nil, err
+	}
+	existing, err := TableColumns(ctx, conn, table+"_fields")
+	if err != nil {
+		return nil, err
+	}
+	wanted := fieldsColumns(fields, namespaced)
+	mismatch := &ColumnMismatch{Table: table}
+	for _, column := range wanted {
+		if !slices.Contains(existing, column) {
+			mismatch.Missing = append(mismatch.Missing, column)
+		}
+	}
+	for _, column := range existing {
+		if column != "key" && !slices.Contains(wanted, column) {
+			mismatch.Extra = append(mismatch.Extra, column)
+		}
+	}
+	if len(mismatch.Missing) == 0 && len(mismatch.Extra) == 0 {
+		return nil, nil
+	}
+	return mismatch, nil
+}
+
+// statements returns the statements altering the fields table so that its columns match, adding the missing columns
+// and dropping the extra ones, along with their indexes
+func (m *ColumnMismatch) statements() []string {
+	var stmts []string
+	for _, column := range m.Missing {
+		stmts = append(stmts, fmt.Sprintf(`ALTER TABLE "%s_fields" ADD COLUMN "%s" TEXT`, m.Table, column))
+	}
+	for _, column := range m.Extra {
+		stmts = append(stmts,
+			fmt.Sprintf(`DROP INDEX IF EXISTS "%s_%s_index"`, m.Table, column),
+			fmt.Sprintf(`ALTER TABLE "%s_fields" DROP COLUMN "%s"`, m.Table, column))
+	}
+	return stmts
+}
+
+// TypeTable returns the objects table of a type, named as informers name it
+func TypeTable(gvk schema.GroupVersionKind) string {
+	return Sanitize(gvk.Group + "_" + gvk.Version + "_" + gvk.Kind)
+}
+
+// fieldsColumns returns the columns of the fields table informers create for fields, after those of the metadata they
+// always index
+func fieldsColumns(fields [][]string, namespaced bool) []string {
+	columns := []string{"metadata.name", "metadata.creationTimestamp"}
+	if namespaced {
+		columns = append(columns, "metadata.namespace")
+	}
+	for _, field := range fields {
+		columns = append(columns, Sanitize(strings.Join(field, ".")))
+	}
+	return columns
+}
+
+// TableColumns returns the columns of a table, or an error if it doesn't exist
+func TableColumns(ctx context.Context, conn *sql.DB, table string) ([]string, error) {
+	rows, err := conn.QueryContext(ctx, "SELECT name FROM pragma_table_info(?)", table)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var columns []string
+	for rows.Next() {
+		var column string
+		if err := rows.Scan(&column); err != nil {
+			return nil, err
+		}
+		columns = append(columns, column)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(columns) == 0 {
+		return nil, fmt.Errorf("table %s doesn't exist", table)
+	}
+	return columns, nil
+}
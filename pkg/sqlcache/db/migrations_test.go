@@ -0,0 +1,135 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+func TestOpenPooledClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	pods := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	newIndexer := func(c *db.PooledClient, fields [][]string) *informer.ListOptionIndexer {
+		s, err := store.NewStore(&unstructured.Unstructured{}, cache.DeletionHandlingMetaNamespaceKeyFunc, c, false, "_v1_Pod")
+		require.NoError(t, err)
+		indexer, err := informer.NewListOptionIndexer(fields, s, true)
+		require.NoError(t, err)
+		return indexer
+	}
+	exec := func(query string) {
+		conn, err := sql.Open("sqlite", "file:"+path+"?mode=rw")
+		require.NoError(t, err)
+		defer conn.Close()
+		_, err = conn.Exec(query)
+		require.NoError(t, err)
+	}
+	query := func(c *db.PooledClient, query string) []string {
+		rows, err := c.Reader().Query(query)
+		require.NoError(t, err)
+		values, err := c.ReadStrings(rows)
+		require.NoError(t, err)
+		return values
+	}
+
+	// new databases are in the current layout
+	c, err := db.NewPooledClient(path, 2)
+	require.NoError(t, err)
+	assert.Equal(t, db.MigrationStatus{Database: path, Version: db.SchemaVersion, SchemaVersion: db.SchemaVersion}, c.MigrationStatus())
+	indexer := newIndexer(c, [][]string{{"spec", "nodeName"}})
+	require.NoError(t, indexer.Add(newPod("pod1")))
+	require.NoError(t, c.Close())
+
+	// databases of earlier layouts are migrated in place
+	exec(`DROP INDEX "_v1_Pod_indices_key_index"`)
+	exec(`PRAGMA user_version = 0`)
+	c, err = db.OpenPooledClient(path, 2)
+	require.NoError(t, err)
+	status := c.MigrationStatus()
+	assert.True(t, status.Kept)
+	assert.Equal(t, 0, status.Version)
+	require.Len(t, status.Applied, len(db.Migrations))
+	assert.Equal(t, 1, status.Applied[0].Version)
+	assert.Equal(t, []string{"_v1_Pod_indices_key_index"}, query(c, `SELECT name FROM sqlite_master WHERE name = '_v1_Pod_indices_key_index'`))
+	assert.Equal(t, []string{fmt.Sprint(db.SchemaVersion)}, query(c, `PRAGMA user_version`))
+
+	// the columns of the fields of kept types are altered in place, keeping their objects
+	require.NoError(t, c.AlterFields(pods, [][]string{{"spec", "hostname"}}, true))
+	assert.Equal(t, []string{"key", "metadata.name", "metadata.creationTimestamp", "metadata.namespace", "spec.hostname"},
+		query(c, `SELECT name FROM pragma_table_info('_v1_Pod_fields')`))
+	assert.Empty(t, query(c, `SELECT name FROM sqlite_master WHERE name = '_v1_Pod_spec.nodeName_index'`))
+	indexer = newIndexer(c, [][]string{{"spec", "hostname"}})
+	_, ok, err := indexer.GetByKey("default/pod1")
+	require.NoError(t, err)
+	assert.True(t, ok)
+	require.NoError(t, indexer.Add(newPod("pod2")))
+	require.NoError(t, c.AlterFields(schema.GroupVersionKind{Version: "v1", Kind: "Node"}, nil, false), "missing tables are created by informers")
+
+	// kept databases are kept on resets too
+	require.NoError(t, c.NewConnection())
+	assert.Equal(t, []string{"default/pod1", "default/pod2"}, query(c, `SELECT key FROM "_v1_Pod" ORDER BY key`))
+	require.NoError(t, c.Close())
+
+	// databases of later layouts, written before a downgrade, are recreated
+	exec(fmt.Sprintf(`PRAGMA user_version = %d`, db.SchemaVersion+1))
+	c, err = db.OpenPooledClient(path, 2)
+	require.NoError(t, err)
+	defer c.Close()
+	status = c.MigrationStatus()
+	assert.False(t, status.Kept)
+	assert.Equal(t, db.SchemaVersion, status.Version)
+	assert.Contains(t, status.Error, db.ErrNewerSchema.Error())
+	assert.Empty(t, query(c, `SELECT name FROM sqlite_master`))
+	assert.Equal(t, []string{fmt.Sprint(db.SchemaVersion)}, query(c, `PRAGMA user_version`))
+
+	// databases which don't exist are created
+	c2, err := db.OpenPooledClient(filepath.Join(t.TempDir(), "new.db"), 1)
+	require.NoError(t, err)
+	defer c2.Close()
+	assert.False(t, c2.MigrationStatus().Kept)
+	assert.Empty(t, c2.MigrationStatus().Error)
+}
+
+func TestMigrateFile(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "snapshot.db")
+	conn, err := sql.Open("sqlite", "file:"+path+"?mode=rwc")
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, query := range []string{
+		`CREATE TABLE "_v1_Pod" (key TEXT PRIMARY KEY, object BLOB, objectnonce BLOB, dekid INTEGER)`,
+		`CREATE TABLE "_v1_Pod_indices" (name TEXT, value TEXT, key TEXT)`,
+		`CREATE TABLE "unrelated" (key TEXT)`,
+	} {
+		_, err := conn.Exec(query)
+		require.NoError(t, err)
+	}
+
+	status, err := db.MigrateFile(context.Background(), path)
+	require.NoError(t, err)
+	assert.Equal(t, 0, status.Version)
+	assert.Len(t, status.Applied, len(db.Migrations))
+	var indexes int
+	require.NoError(t, conn.QueryRow(`SELECT COUNT(*) FROM sqlite_master WHERE type = 'index' AND tbl_name = '_v1_Pod_indices'`).Scan(&indexes))
+	assert.Equal(t, 1, indexes)
+
+	// migrated databases aren't migrated again
+	status, err = db.MigrateFile(context.Background(), path)
+	require.NoError(t, err)
+	assert.Equal(t, db.SchemaVersion, status.Version)
+	assert.Empty(t, status.Applied)
+
+	_, err = conn.Exec(fmt.Sprintf(`PRAGMA user_version = %d`, db.SchemaVersion+1))
+	require.NoError(t, err)
+	_, err = db.MigrateFile(context.Background(), path)
+	assert.ErrorIs(t, err, db.ErrNewerSchema)
+}
@@ -0,0 +1,316 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/rancher/steve/pkg/metrics"
+	"github.com/rancher/steve/pkg/sqlcache/db/transaction"
+	"github.com/rancher/steve/pkg/sqlcache/encryption"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultReadConnections is the size of the pool of read-only connections of the SQL cache database by default
+	DefaultReadConnections = 4
+
+	writerPool = "writer"
+	readerPool = "reader"
+)
+
+// PooledClient is a client of the database of the SQL cache with a single connection writing the events of informers,
+// and a pool of read-only connections running the queries of lists, so that lists, however long, never hold the
+// connection events are written with. In the WAL journal mode, reads see the last write committed when they start
+// while writes go on.
+//
+// Statements are prepared on the read-only pool, on which lists run them. Transactions writing events prepare the
+// statements they run on the writer connection, for their duration.
+type PooledClient struct {
+	path    string
+	readers int
+	// keep is true if the database is kept on start and resets
+	keep bool
+
+	lock   sync.RWMutex
+	writer *sql.DB
+	reader *sql.DB
+	// queries are the queries of the statements prepared on the read-only pool
+	queries sync.Map
+	// statements are the statements of rows of queries, nil for those which don't write rows by key
+	statements  sync.Map
+	encryptor   Encryptor
+	decryptor   Decryptor
+	syncWorkers int
+	// migration is the status of the layout of the database when it was opened
+	migration MigrationStatus
+	// consistency is the report of the last consistency check of the database, if any
+	consistency *ConsistencyReport
+
+	// batchLock is held by the transactions of events written in the current batch, and by reads of it
+	batchLock   sync.Mutex
+	batchWindow time.Duration
+	batchSize   int
+	batch       *batch
+	// batchRows are the rows read in the current batch, which is held until they are read
+	batchRows sync.Map
+}
+
+// NewPooledClient deletes the database of the SQL cache at path, and opens it with a writer connection and up to readers
+// read-only connections. Lists and events share a pool of writer connections if readers is 0.
+func NewPooledClient(path string, readers int) (*PooledClient, error) {
+	return newPooledClient(path, readers, false)
+}
+
+// OpenPooledClient opens the database of the SQL cache at path as NewPooledClient does, but keeps the tables of an
+// earlier run, migrated to SchemaVersion in place, rather than deleting them, on start and on resets. The database is
+// deleted if it can't be migrated, such as when it was written by a later version of steve before a downgrade.
+func OpenPooledClient(path string, readers int) (*PooledClient, error) {
+	return newPooledClient(path, readers, true)
+}
+
+func newPooledClient(path string, readers int, keep bool) (*PooledClient, error) {
+	if readers < 0 {
+		return nil, fmt.Errorf("invalid number of read connections %d, must not be negative", readers)
+	}
+	m, err := encryption.NewManager()
+	if err != nil {
+		return nil, err
+	}
+	registerDatabase(path)
+	c := &PooledClient{
+		path:        path,
+		readers:     readers,
+		keep:        keep,
+		encryptor:   m,
+		decryptor:   m,
+		syncWorkers: DefaultSyncWorkers,
+	}
+	if err := c.open(); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// NewConnection deletes the database, unless it is kept, and opens it again, when the cache is reset
+func (c *PooledClient) NewConnection() error {
+	return c.open()
+}
+
+// open opens the database, keeping the database of an earlier run, migrated, if it is kept and can be, or deleting it
+// otherwise
+func (c *PooledClient) open() error {
+	c.batchLock.Lock()
+	defer c.batchLock.Unlock()
+	keep := c.keep
+	if keep {
+		if err := c.flush(); err != nil {
+			return err
+		}
+	} else {
+		c.discard()
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	for _, conn := range []*sql.DB{c.reader, c.writer} {
+		if conn == nil {
+			continue
+		}
+		if err := conn.Close(); err != nil {
+			return err
+		}
+	}
+	c.writer, c.reader = nil, nil
+	c.queries.Range(func(stmt, _ any) bool {
+		c.queries.Delete(stmt)
+		return true
+	})
+
+	if keep {
+		if _, err := os.Stat(c.path); errors.Is(err, os.ErrNotExist) {
+			keep = false
+		} else if err != nil {
+			return err
+		}
+	}
+	if keep {
+		err := c.connect()
+		if err == nil {
+			c.migration, err = migrate(context.Background(), c.writer, c.path)
+			c.migration.Kept = err == nil
+		}
+		if err == nil {
+			return nil
+		}
+		logrus.Warnf("Recreating the SQL cache database %s, which can't be kept: %v", c.path, err)
+		c.migration.Error = err.Error()
+		if c.writer != nil {
+			c.writer.Close()
+			c.reader.Close()
+			c.writer, c.reader = nil, nil
+		}
+	} else {
+		c.migration = MigrationStatus{Database: c.path, SchemaVersion: SchemaVersion}
+	}
+
+	if err := os.RemoveAll(c.path); err != nil {
+		return err
+	}
+	// the permissions are set beforehand, since they can't be when sqlite creates the file
+	f, err := os.OpenFile(c.path, os.O_RDWR|os.O_CREATE, 0o600)
+	if err != nil {
+		return err
+	}
+	if err := f.Close(); err != nil {
+		return err
+	}
+	if err := c.connect(); err != nil {
+		return err
+	}
+	// the database is created in the current layout, which the tables of types are created in
+	if _, err := c.writer.Exec(fmt.Sprintf("PRAGMA user_version = %d", SchemaVersion)); err != nil {
+		return err
+	}
+	c.migration.Version = SchemaVersion
+	return nil
+}
+
+// connect opens the writer connection and the read-only pool of the database. The lock must be held.
+func (c *PooledClient) connect() error {
+	// writing transactions are IMMEDIATE, so that they wait for each other rather than fail to upgrade their locks
+	writer, err := sql.Open("sqlite", "file:"+c.path+"?mode=rwc&_pragma=journal_mode=wal&_pragma=synchronous=off&"+
+		"_pragma=foreign_keys=on&_pragma=busy_timeout=120000&_txlock=immediate")
+	if err != nil {
+		return err
+	}
+	// the database is in the WAL journal mode before readers connect
+	if err := writer.Ping(); err != nil {
+		writer.Close()
+		return err
+	}
+	if c.readers == 0 {
+		// lists share the connections events are written with, opening as many as they need
+		c.writer, c.reader = writer, writer
+		return nil
+	}
+	writer.SetMaxOpenConns(1)
+	reader, err := sql.Open("sqlite", "file:"+c.path+"?mode=rw&_pragma=query_only=1&_pragma=foreign_keys=on&_pragma=busy_timeout=120000")
+	if err != nil {
+		writer.Close()
+		return err
+	}
+	reader.SetMaxOpenConns(c.readers)
+	reader.SetMaxIdleConns(c.readers)
+	c.writer, c.reader = writer, reader
+	return nil
+}
+
+// Prepare prepares a statement on the read-only pool
+func (c *PooledClient) Prepare(query string) (*sql.Stmt, error) {
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	stmt, err := c.reader.Prepare(query)
+	if err != nil {
+		return nil, &QueryError{QueryString: query, Err: err}
+	}
+	c.queries.Store(stmt, query)
+	return stmt, nil
+}
+
+// CloseStmt closes a statement prepared by Prepare
+func (c *PooledClient) CloseStmt(closable Closable) error {
+	if stmt, ok := closable.(*sql.Stmt); ok {
+		c.queries.Delete(stmt)
+	}
+	return closable.Close()
+}
+
+// QueryForRows runs a statement, in the current batch of events if any, on the read-only pool otherwise, unless it
+// belongs to a transaction
+func (c *PooledClient) QueryForRows(ctx context.Context, stmt transaction.Stmt, params ...any) (*sql.Rows, error) {
+	if prepared, ok := stmt.(*sql.Stmt); ok {
+		if _, ok := c.queries.Load(prepared); ok {
+			if rows, ok, err := c.queryBatch(ctx, prepared, params...); ok {
+				return rows, err
+			}
+		}
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	return stmt.QueryContext(ctx, params...)
+}
+
+// BeginTx begins a transaction on the writer connection, in the current batch if writes are batched, if it is for
+// writing, and on the read-only pool otherwise
+func (c *PooledClient) BeginTx(ctx context.Context, forWriting bool) (TXClient, error) {
+	start := time.Now()
+	if forWriting {
+		c.batchLock.Lock()
+		if c.batchWindow > 0 {
+			return c.beginBatched(start)
+		}
+		c.batchLock.Unlock()
+	}
+	c.lock.RLock()
+	defer c.lock.RUnlock()
+	pool, conn := readerPool, c.reader
+	if forWriting {
+		pool, conn = writerPool, c.writer
+	}
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: !forWriting})
+	metrics.RecordSQLCacheTransaction(pool, time.Since(start), conn.Stats().InUse)
+	if err != nil {
+		return nil, err
+	}
+	if !forWriting {
+		return transaction.NewClient(tx), nil
+	}
+	return c.newTxClient(&writeTx{Tx: tx, client: c}), nil
+}
+
+// writeTx is a transaction of the writer connection, which prepares the statements of the read-only pool it runs
+type writeTx struct {
+	*sql.Tx
+	client *PooledClient
+	// stmts are the statements prepared by the transaction, by query
+	stmts map[string]*sql.Stmt
+}
+
+func (w *writeTx) Stmt(stmt *sql.Stmt) *sql.Stmt {
+	query, ok := w.client.queries.Load(stmt)
+	if !ok {
+		return w.Tx.Stmt(stmt)
+	}
+	if prepared, ok := w.stmts[query.(string)]; ok {
+		return prepared
+	}
+	prepared, err := w.Tx.Prepare(query.(string))
+	if err != nil {
+		// the statement then fails with the error of database/sql when it runs
+		return w.Tx.Stmt(stmt)
+	}
+	if w.stmts == nil {
+		w.stmts = map[string]*sql.Stmt{}
+	}
+	w.stmts[query.(string)] = prepared
+	return prepared
+}
+
+// Close commits the current batch of events, if any, and closes the connections of the database
+func (c *PooledClient) Close() error {
+	c.batchLock.Lock()
+	defer c.batchLock.Unlock()
+	if err := c.flush(); err != nil {
+		return err
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if err := c.reader.Close(); err != nil {
+		return err
+	}
+	return c.writer.Close()
+}
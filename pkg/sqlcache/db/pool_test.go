@@ -0,0 +1,192 @@
+package db_test
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/store"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newPod(name string) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{}
+	obj.SetAPIVersion("v1")
+	obj.SetKind("Pod")
+	obj.SetNamespace("default")
+	obj.SetName(name)
+	return obj
+}
+
+// prepare prepares a statement of query on the read-only pool of c
+func prepare(t *testing.T, c *db.PooledClient, query string) *sql.Stmt {
+	stmt, err := c.Prepare(query)
+	require.NoError(t, err)
+	return stmt
+}
+
+func TestPooledClient(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	_, err := db.NewPooledClient(path, -1)
+	assert.Error(t, err)
+	c, err := db.NewPooledClient(path, 2)
+	require.NoError(t, err)
+	defer c.Close()
+
+	s, err := store.NewStore(&unstructured.Unstructured{}, cache.DeletionHandlingMetaNamespaceKeyFunc, c, false, "_v1_Pod")
+	require.NoError(t, err)
+	require.NoError(t, s.Add(newPod("pod1")))
+
+	// events are written while lists read
+	ctx := context.Background()
+	tx, err := c.BeginTx(ctx, false)
+	require.NoError(t, err)
+	stmt := prepare(t, c, `SELECT COUNT(*) FROM "_v1_Pod"`)
+	defer c.CloseStmt(stmt)
+	count := func() int {
+		rows, err := tx.Stmt(stmt).QueryContext(ctx)
+		require.NoError(t, err)
+		count, err := c.ReadInt(rows)
+		require.NoError(t, err)
+		return count
+	}
+	assert.Equal(t, 1, count())
+	require.NoError(t, s.Add(newPod("pod2")))
+	assert.Equal(t, 1, count(), "reads see the writes committed when they started")
+	require.NoError(t, tx.Commit())
+	assert.ElementsMatch(t, []string{"default/pod1", "default/pod2"}, s.ListKeys())
+
+	// reads can't write
+	tx, err = c.BeginTx(ctx, false)
+	require.NoError(t, err)
+	assert.Error(t, tx.Exec(`DELETE FROM "_v1_Pod"`))
+
+	// resets delete the database
+	require.NoError(t, c.NewConnection())
+	s, err = store.NewStore(&unstructured.Unstructured{}, cache.DeletionHandlingMetaNamespaceKeyFunc, c, false, "_v1_Pod")
+	require.NoError(t, err)
+	assert.Empty(t, s.ListKeys())
+	require.NoError(t, s.Add(newPod("pod3")))
+	obj, ok, err := s.GetByKey("default/pod3")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "pod3", obj.(*unstructured.Unstructured).GetName())
+}
+
+func TestPooledClientWriteBatching(t *testing.T) {
+	c, err := db.NewPooledClient(filepath.Join(t.TempDir(), "cache.db"), 2)
+	require.NoError(t, err)
+	defer c.Close()
+	s, err := store.NewStore(&unstructured.Unstructured{}, cache.DeletionHandlingMetaNamespaceKeyFunc, c, false, "_v1_Pod")
+	require.NoError(t, err)
+	assert.Error(t, c.SetWriteBatching(time.Second, 0))
+	require.NoError(t, c.SetWriteBatching(time.Hour, 3))
+
+	ctx := context.Background()
+	stmt := prepare(t, c, `SELECT COUNT(*) FROM "_v1_Pod"`)
+	defer c.CloseStmt(stmt)
+	committed := func() int {
+		tx, err := c.BeginTx(ctx, false)
+		require.NoError(t, err)
+		defer tx.Commit()
+		rows, err := tx.Stmt(stmt).QueryContext(ctx)
+		require.NoError(t, err)
+		count, err := c.ReadInt(rows)
+		require.NoError(t, err)
+		return count
+	}
+
+	require.NoError(t, s.Add(newPod("pod1")))
+	require.NoError(t, s.Add(newPod("pod2")))
+	assert.Equal(t, 0, committed(), "lists don't see the events of the batch")
+	_, ok, err := s.GetByKey("default/pod2")
+	require.NoError(t, err)
+	assert.True(t, ok, "informers read the events they wrote")
+
+	// transactions rolled back don't roll back the others of the batch
+	tx, err := c.BeginTx(ctx, true)
+	require.NoError(t, err)
+	require.NoError(t, tx.Exec(`DELETE FROM "_v1_Pod"`))
+	require.NoError(t, tx.Cancel())
+	assert.ElementsMatch(t, []string{"default/pod1", "default/pod2"}, s.ListKeys())
+
+	// full batches are committed
+	require.NoError(t, s.Add(newPod("pod3")))
+	assert.Equal(t, 3, committed())
+
+	// batches are committed at the end of their window
+	require.NoError(t, c.SetWriteBatching(10*time.Millisecond, 100))
+	require.NoError(t, s.Delete(newPod("pod1")))
+	assert.Eventually(t, func() bool { return committed() == 2 }, time.Second, 10*time.Millisecond)
+}
+
+func TestTxClientRows(t *testing.T) {
+	c, err := db.NewPooledClient(filepath.Join(t.TempDir(), "cache.db"), 2)
+	require.NoError(t, err)
+	defer c.Close()
+	ctx := context.Background()
+
+	// rows of the same key are written in order, whatever the rows of other keys written since
+	tx, err := c.BeginTx(ctx, true)
+	require.NoError(t, err)
+	require.NoError(t, tx.Exec(`CREATE TABLE "t" (key TEXT PRIMARY KEY, value TEXT)`))
+	require.NoError(t, tx.Commit())
+	insert := prepare(t, c, `INSERT INTO "t"(key, value) VALUES (?, ?) ON CONFLICT DO UPDATE SET value = excluded.value`)
+	del := prepare(t, c, `DELETE FROM "t" WHERE key = ?`)
+	tx, err = c.BeginTx(ctx, true)
+	require.NoError(t, err)
+	require.NoError(t, tx.StmtExec(tx.Stmt(insert), "a", "1"))
+	require.NoError(t, tx.StmtExec(tx.Stmt(insert), "b", "1"))
+	require.NoError(t, tx.StmtExec(tx.Stmt(del), "a"))
+	require.NoError(t, tx.StmtExec(tx.Stmt(insert), "a", "2"))
+	require.NoError(t, tx.StmtExec(tx.Stmt(insert), "c", "1"))
+	require.NoError(t, tx.StmtExec(tx.Stmt(del), "b"))
+	require.NoError(t, tx.Commit())
+	tx, err = c.BeginTx(ctx, false)
+	require.NoError(t, err)
+	rows, err := tx.Stmt(prepare(t, c, `SELECT key || '=' || value FROM "t" ORDER BY key`)).QueryContext(ctx)
+	require.NoError(t, err)
+	values, err := c.ReadStrings(rows)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, []string{"a=2", "c=1"}, values)
+
+	// objects, and their fields, are written in statements of many rows, encoded and encrypted by workers
+	s, err := store.NewStore(&unstructured.Unstructured{}, cache.DeletionHandlingMetaNamespaceKeyFunc, c, true, "_v1_Pod")
+	require.NoError(t, err)
+	indexer, err := informer.NewListOptionIndexer([][]string{{"spec", "nodeName"}}, s, true)
+	require.NoError(t, err)
+	require.NoError(t, indexer.Add(newPod("stale")))
+	var pods []any
+	for i := range 2*db.MaxRows + 1 {
+		pod := newPod(fmt.Sprintf("pod%d", i))
+		require.NoError(t, unstructured.SetNestedField(pod.Object, "node1", "spec", "nodeName"))
+		pods = append(pods, pod)
+	}
+	require.NoError(t, indexer.Replace(pods, ""))
+	assert.Len(t, indexer.ListKeys(), 2*db.MaxRows+1)
+	obj, ok, err := indexer.GetByKey("default/pod42")
+	require.NoError(t, err)
+	require.True(t, ok)
+	assert.Equal(t, "pod42", obj.(*unstructured.Unstructured).GetName())
+	_, ok, err = indexer.GetByKey("default/stale")
+	require.NoError(t, err)
+	assert.False(t, ok)
+
+	tx, err = c.BeginTx(ctx, false)
+	require.NoError(t, err)
+	rows, err = tx.Stmt(prepare(t, c, `SELECT COUNT(*) FROM "_v1_Pod_fields" WHERE "spec.nodeName" = 'node1'`)).QueryContext(ctx)
+	require.NoError(t, err)
+	count, err := c.ReadInt(rows)
+	require.NoError(t, err)
+	require.NoError(t, tx.Commit())
+	assert.Equal(t, 2*db.MaxRows+1, count)
+}
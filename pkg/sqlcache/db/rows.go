@@ -0,0 +1,359 @@
+package db
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"encoding/gob"
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/rancher/steve/pkg/sqlcache/db/transaction"
+)
+
+const (
+	// DefaultSyncWorkers is how many objects written in a transaction are encoded, and encrypted, at once by default
+	DefaultSyncWorkers = 4
+
+	// maxRows is how many rows a statement writes at most, well below the limit of parameters of sqlite
+	maxRows = 500
+)
+
+var deleteByKey = regexp.MustCompile(`^DELETE FROM ("[^"]+") WHERE key = \?$`)
+
+// rowsStatement is a statement inserting, or deleting, the row of a key, which is written with those of other keys in
+// a single statement
+type rowsStatement struct {
+	// the statement of many rows is prefix, followed by values for each row, separated by commas, and suffix
+	prefix string
+	values string
+	suffix string
+	// params are the parameters of each row, the key being that of index key
+	params int
+	key    int
+}
+
+// parseRows returns the statement of rows of a query, if it inserts, or deletes, the row of a key. These are the
+// statements the store and indexers write objects, and their fields, with.
+func parseRows(query string) (*rowsStatement, bool) {
+	if m := deleteByKey.FindStringSubmatch(query); m != nil {
+		return &rowsStatement{prefix: "DELETE FROM " + m[1] + " WHERE key IN (", values: "?", suffix: ")", params: 1}, true
+	}
+	if !strings.HasPrefix(query, "INSERT INTO ") && !strings.HasPrefix(query, "REPLACE INTO ") {
+		return nil, false
+	}
+	start := strings.Index(query, " VALUES (")
+	if start < 0 {
+		return nil, false
+	}
+	end := strings.Index(query[start:], ")") + start
+	values := query[start+len(" VALUES ") : end+1]
+	suffix := query[end+1:]
+	if strings.Trim(values, "(?, )") != "" || strings.Contains(suffix, "?") {
+		return nil, false
+	}
+	open, close := strings.Index(query, "("), strings.LastIndex(query[:start], ")")
+	if open < 0 || open > close {
+		return nil, false
+	}
+	columns := strings.Split(query[open+1:close], ",")
+	if len(columns) != strings.Count(values, "?") {
+		return nil, false
+	}
+	s := &rowsStatement{prefix: query[:start+len(" VALUES ")], values: values, suffix: suffix, params: len(columns), key: -1}
+	for i, column := range columns {
+		if strings.Trim(column, ` "`) == "key" {
+			s.key = i
+		}
+	}
+	return s, s.key >= 0
+}
+
+// query returns the statement writing n rows
+func (s *rowsStatement) query(n int) string {
+	return s.prefix + strings.Repeat(s.values+", ", n-1) + s.values + s.suffix
+}
+
+// row is a row written by a statement, whose object is encoded by a worker if encoded isn't nil
+type row struct {
+	args    []any
+	encoded *encoding
+}
+
+// encoding is the encoding, and encryption, of an object by a worker
+type encoding struct {
+	obj     any
+	encrypt bool
+	done    chan struct{}
+	data    []byte
+	nonce   []byte
+	keyID   uint32
+	err     error
+}
+
+// rowGroup are rows written by the same statement
+type rowGroup struct {
+	statement *rowsStatement
+	stmt      *sql.Stmt
+	rows      []row
+	keys      map[string]bool
+}
+
+// txClient is a transaction writing to the database, which writes the rows inserted, or deleted, by key in statements
+// of many rows, and encodes the objects it writes with workers. A row is written with the rows written before by the
+// same statement unless a statement written since writes a row of the same key. The objects, indices and fields
+// only depend on the other rows of their key, so rows are written in the order they would be for each key.
+//
+// Rows are written before any other statement, query, and commit, whose errors are those of the rows then.
+type txClient struct {
+	tx     transaction.SQLTx
+	client *PooledClient
+	groups []*rowGroup
+	// upserts are the objects written, the first being encoded in the transaction, the following by workers
+	upserts int
+	jobs    chan *encoding
+}
+
+func (c *PooledClient) newTxClient(tx transaction.SQLTx) *txClient {
+	return &txClient{tx: tx, client: c}
+}
+
+// Exec runs a statement, after the rows written
+func (t *txClient) Exec(query string, args ...any) error {
+	if err := t.flush(); err != nil {
+		return err
+	}
+	if _, err := t.tx.Exec(query, args...); err != nil {
+		return t.rollback(err)
+	}
+	return nil
+}
+
+// Stmt returns a statement of the transaction, which writes its rows with others if it writes rows by key
+func (t *txClient) Stmt(stmt *sql.Stmt) transaction.Stmt {
+	return &txStmt{tx: t, stmt: stmt}
+}
+
+// StmtExec runs a statement of the transaction
+func (t *txClient) StmtExec(stmt transaction.Stmt, args ...any) error {
+	if _, err := stmt.Exec(args...); err != nil {
+		return t.rollback(err)
+	}
+	return nil
+}
+
+// Commit writes the rows, and commits the transaction
+func (t *txClient) Commit() error {
+	if err := t.flush(); err != nil {
+		return err
+	}
+	t.stop()
+	return t.tx.Commit()
+}
+
+// Cancel rolls back the transaction, if it wasn't already
+func (t *txClient) Cancel() error {
+	t.groups = nil
+	t.stop()
+	if err := t.tx.Rollback(); err != sql.ErrTxDone {
+		return err
+	}
+	return nil
+}
+
+// rollback rolls back the transaction after an error, as transactions of the transaction package do
+func (t *txClient) rollback(err error) error {
+	t.groups = nil
+	t.stop()
+	if rerr := t.tx.Rollback(); rerr != nil && rerr != sql.ErrTxDone {
+		return fmt.Errorf("%w, then failed to roll back: %v", err, rerr)
+	}
+	return err
+}
+
+// add adds a row of a statement, returning false if it doesn't write rows by key
+func (t *txClient) add(stmt *sql.Stmt, r row) (bool, error) {
+	query, ok := t.client.queries.Load(stmt)
+	if !ok {
+		return false, nil
+	}
+	statement, ok := t.client.rowsStatement(query.(string))
+	if !ok || len(r.args) != statement.params {
+		return false, nil
+	}
+	key, ok := r.args[statement.key].(string)
+	if !ok {
+		return false, nil
+	}
+
+	var group *rowGroup
+	for i := len(t.groups) - 1; i >= 0; i-- {
+		if t.groups[i].stmt == stmt {
+			group = t.groups[i]
+			break
+		}
+		if t.groups[i].keys[key] {
+			// the row is written after the rows of its key
+			break
+		}
+	}
+	if group == nil {
+		group = &rowGroup{statement: statement, stmt: stmt, keys: map[string]bool{}}
+		t.groups = append(t.groups, group)
+	}
+	group.rows = append(group.rows, r)
+	group.keys[key] = true
+	if len(group.rows) >= maxRows {
+		return true, t.flush()
+	}
+	return true, nil
+}
+
+// flush writes the rows added, by group, rolling back the transaction on errors
+func (t *txClient) flush() error {
+	groups := t.groups
+	t.groups = nil
+	for _, group := range groups {
+		args := make([]any, 0, len(group.rows)*group.statement.params)
+		for _, r := range group.rows {
+			if r.encoded != nil {
+				<-r.encoded.done
+				if r.encoded.err != nil {
+					return t.rollback(r.encoded.err)
+				}
+				r.args[1], r.args[2], r.args[3] = r.encoded.data, r.encoded.nonce, r.encoded.keyID
+			}
+			args = append(args, r.args...)
+		}
+		var err error
+		if len(group.rows) == 1 {
+			_, err = t.tx.Stmt(group.stmt).Exec(args...)
+		} else {
+			_, err = t.tx.Exec(group.statement.query(len(group.rows)), args...)
+		}
+		if err != nil {
+			return t.rollback(err)
+		}
+	}
+	return nil
+}
+
+// upsert writes an object, encoding it with a worker unless it is the first of the transaction
+func (t *txClient) upsert(stmt *sql.Stmt, key string, obj any, encrypt bool) error {
+	e := &encoding{obj: obj, encrypt: encrypt, done: make(chan struct{})}
+	t.upserts++
+	workers := t.client.syncWorkers
+	if t.upserts == 1 || workers <= 1 {
+		t.client.encode(e)
+	} else {
+		if t.jobs == nil {
+			t.jobs = make(chan *encoding, workers)
+			for range workers {
+				go func(jobs chan *encoding) {
+					for e := range jobs {
+						t.client.encode(e)
+					}
+				}(t.jobs)
+			}
+		}
+		t.jobs <- e
+	}
+
+	r := row{args: []any{key, nil, nil, nil}, encoded: e}
+	ok, err := t.add(stmt, r)
+	if ok || err != nil {
+		return err
+	}
+	// the statement doesn't write rows by key
+	<-e.done
+	if e.err != nil {
+		return e.err
+	}
+	return t.StmtExec(t.Stmt(stmt), key, e.data, e.nonce, e.keyID)
+}
+
+// stop stops the workers encoding objects
+func (t *txClient) stop() {
+	if t.jobs != nil {
+		close(t.jobs)
+		t.jobs = nil
+	}
+}
+
+// txStmt is a statement of a transaction
+type txStmt struct {
+	tx   *txClient
+	stmt *sql.Stmt
+}
+
+func (s *txStmt) Exec(args ...any) (sql.Result, error) {
+	if ok, err := s.tx.add(s.stmt, row{args: append([]any(nil), args...)}); ok || err != nil {
+		return driver.RowsAffected(0), err
+	}
+	if err := s.tx.flush(); err != nil {
+		return nil, err
+	}
+	return s.tx.tx.Stmt(s.stmt).Exec(args...)
+}
+
+func (s *txStmt) Query(args ...any) (*sql.Rows, error) {
+	if err := s.tx.flush(); err != nil {
+		return nil, err
+	}
+	return s.tx.tx.Stmt(s.stmt).Query(args...)
+}
+
+func (s *txStmt) QueryContext(ctx context.Context, args ...any) (*sql.Rows, error) {
+	if err := s.tx.flush(); err != nil {
+		return nil, err
+	}
+	return s.tx.tx.Stmt(s.stmt).QueryContext(ctx, args...)
+}
+
+// rowsStatement returns the statement of rows of a query, parsed once
+func (c *PooledClient) rowsStatement(query string) (*rowsStatement, bool) {
+	if s, ok := c.statements.Load(query); ok {
+		return s.(*rowsStatement), s.(*rowsStatement) != nil
+	}
+	s, ok := parseRows(query)
+	if !ok {
+		s = nil
+	}
+	c.statements.Store(query, s)
+	return s, ok
+}
+
+// SetSyncWorkers sets how many objects written in a transaction, such as those of the initial sync of a type, are
+// encoded, and encrypted, at once
+func (c *PooledClient) SetSyncWorkers(workers int) {
+	c.syncWorkers = workers
+}
+
+// Upsert writes an object in a transaction, encoded with gob, and encrypted if encrypt is true
+func (c *PooledClient) Upsert(tx TXClient, stmt *sql.Stmt, key string, obj any, encrypt bool) error {
+	t, ok := tx.(*txClient)
+	if !ok {
+		e := &encoding{obj: obj, encrypt: encrypt, done: make(chan struct{})}
+		c.encode(e)
+		if e.err != nil {
+			return e.err
+		}
+		return tx.StmtExec(tx.Stmt(stmt), key, e.data, e.nonce, e.keyID)
+	}
+	return t.upsert(stmt, key, obj, encrypt)
+}
+
+// encode encodes an object with gob, and encrypts it if it is encrypted
+func (c *PooledClient) encode(e *encoding) {
+	defer close(e.done)
+	var buf bytes.Buffer
+	if e.err = gob.NewEncoder(&buf).Encode(e.obj); e.err != nil {
+		return
+	}
+	e.data = buf.Bytes()
+	if e.encrypt {
+		e.data, e.nonce, e.keyID, e.err = c.encryptor.Encrypt(e.data)
+	}
+}
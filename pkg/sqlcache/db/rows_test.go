@@ -0,0 +1,68 @@
+package db
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseRows(t *testing.T) {
+	tests := []struct {
+		query  string
+		ok     bool
+		params int
+		key    int
+		rows   string
+	}{
+		{
+			query:  `REPLACE INTO "_v1_Pod"(key, object, objectnonce, dekid) VALUES (?, ?, ?, ?)`,
+			ok:     true,
+			params: 4,
+			rows:   `REPLACE INTO "_v1_Pod"(key, object, objectnonce, dekid) VALUES (?, ?, ?, ?), (?, ?, ?, ?)`,
+		},
+		{
+			query:  `INSERT INTO "_v1_Pod_indices" (name, value, key) VALUES (?, ?, ?) ON CONFLICT DO NOTHING`,
+			ok:     true,
+			params: 3,
+			key:    2,
+			rows:   `INSERT INTO "_v1_Pod_indices" (name, value, key) VALUES (?, ?, ?), (?, ?, ?) ON CONFLICT DO NOTHING`,
+		},
+		{
+			query:  `INSERT INTO "_v1_Pod_fields"(key, "metadata.name") VALUES (?, ?) ON CONFLICT DO UPDATE SET "metadata.name" = excluded."metadata.name"`,
+			ok:     true,
+			params: 2,
+			rows:   `INSERT INTO "_v1_Pod_fields"(key, "metadata.name") VALUES (?, ?), (?, ?) ON CONFLICT DO UPDATE SET "metadata.name" = excluded."metadata.name"`,
+		},
+		{
+			query:  `DELETE FROM "_v1_Pod_fields" WHERE key = ?`,
+			ok:     true,
+			params: 1,
+			rows:   `DELETE FROM "_v1_Pod_fields" WHERE key IN (?, ?)`,
+		},
+		{
+			query: `SELECT object, objectnonce, dekid FROM "_v1_Pod" WHERE key = ?`,
+		},
+		{
+			query: `DELETE FROM "_v1_Pod_indices" WHERE name = ?`,
+		},
+		{
+			query: `INSERT INTO "_v1_Pod_indices" (name, value) VALUES (?, ?)`,
+		},
+		{
+			query: `INSERT INTO "_v1_Pod_fields"(key, "a") VALUES (?, ?) ON CONFLICT DO UPDATE SET "a" = ?`,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.query, func(t *testing.T) {
+			s, ok := parseRows(test.query)
+			require.Equal(t, test.ok, ok)
+			if !ok {
+				return
+			}
+			assert.Equal(t, test.params, s.params)
+			assert.Equal(t, test.key, s.key)
+			assert.Equal(t, test.rows, s.query(2))
+		})
+	}
+}
@@ -0,0 +1,150 @@
+package db
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Schedule is a cron-like schedule, made of five space-separated fields: minute (0-59), hour (0-23), day of the month
+// (1-31), month (1-12) and day of the week (0-6, 0 or 7 being Sunday). Each field is either *, a value, a range a-b, or
+// a comma-separated list of them, optionally followed by a step /n. As with cron, when both the day of the month and
+// the day of the week are restricted, days matching either of them are scheduled.
+type Schedule struct {
+	spec    string
+	minutes uint64
+	hours   uint64
+	days    uint64
+	months  uint64
+	weekday uint64
+	// anyDay is true if the day of the month or the day of the week is *
+	anyDay bool
+}
+
+type scheduleField struct {
+	name     string
+	min, max int
+}
+
+var scheduleFields = []scheduleField{
+	{"minute", 0, 59},
+	{"hour", 0, 23},
+	{"day of month", 1, 31},
+	{"month", 1, 12},
+	{"day of week", 0, 7},
+}
+
+// ParseSchedule parses a cron-like schedule, such as "0 3 * * *" for every day at 3am or "*/30 0-6 * * 1-5" for every
+// half hour from midnight to 6am on week days.
+func ParseSchedule(spec string) (*Schedule, error) {
+	fields := strings.Fields(spec)
+	if len(fields) != len(scheduleFields) {
+		return nil, fmt.Errorf("invalid schedule %q: expected %d fields, got %d", spec, len(scheduleFields), len(fields))
+	}
+	var bits [5]uint64
+	for i, field := range fields {
+		var err error
+		bits[i], err = parseScheduleField(field, scheduleFields[i])
+		if err != nil {
+			return nil, fmt.Errorf("invalid schedule %q: %w", spec, err)
+		}
+	}
+	// Sunday can be written 0 or 7
+	if bits[4]&(1<<7) != 0 {
+		bits[4] |= 1
+	}
+	return &Schedule{
+		spec:    spec,
+		minutes: bits[0],
+		hours:   bits[1],
+		days:    bits[2],
+		months:  bits[3],
+		weekday: bits[4],
+		anyDay:  fields[2] == "*" || fields[4] == "*",
+	}, nil
+}
+
+func parseScheduleField(value string, field scheduleField) (uint64, error) {
+	var result uint64
+	for _, part := range strings.Split(value, ",") {
+		rangePart, stepPart, hasStep := strings.Cut(part, "/")
+		step := 1
+		if hasStep {
+			var err error
+			step, err = strconv.Atoi(stepPart)
+			if err != nil || step < 1 {
+				return 0, fmt.Errorf("invalid step %q in %s", stepPart, field.name)
+			}
+		}
+		start, end := field.min, field.max
+		if rangePart != "*" {
+			from, to, isRange := strings.Cut(rangePart, "-")
+			var err error
+			start, err = parseScheduleValue(from, field)
+			if err != nil {
+				return 0, err
+			}
+			end = start
+			if isRange {
+				end, err = parseScheduleValue(to, field)
+				if err != nil {
+					return 0, err
+				}
+			} else if hasStep {
+				end = field.max
+			}
+			if end < start {
+				return 0, fmt.Errorf("invalid range %q in %s", rangePart, field.name)
+			}
+		}
+		for i := start; i <= end; i += step {
+			result |= 1 << i
+		}
+	}
+	return result, nil
+}
+
+func parseScheduleValue(value string, field scheduleField) (int, error) {
+	i, err := strconv.Atoi(value)
+	if err != nil || i < field.min || i > field.max {
+		return 0, fmt.Errorf("invalid %s %q, must be between %d and %d", field.name, value, field.min, field.max)
+	}
+	return i, nil
+}
+
+// String returns the spec the schedule was parsed from
+func (s *Schedule) String() string {
+	return s.spec
+}
+
+// Next returns the first scheduled time after t, or the zero time if there is none within 5 years, for example for
+// the 31st of February.
+func (s *Schedule) Next(t time.Time) time.Time {
+	t = t.Truncate(time.Minute).Add(time.Minute)
+	limit := t.AddDate(5, 0, 0)
+	for t.Before(limit) {
+		switch {
+		case s.months&(1<<uint(t.Month())) == 0:
+			t = time.Date(t.Year(), t.Month()+1, 1, 0, 0, 0, 0, t.Location())
+		case !s.matchesDay(t):
+			t = time.Date(t.Year(), t.Month(), t.Day()+1, 0, 0, 0, 0, t.Location())
+		case s.hours&(1<<uint(t.Hour())) == 0:
+			t = time.Date(t.Year(), t.Month(), t.Day(), t.Hour()+1, 0, 0, 0, t.Location())
+		case s.minutes&(1<<uint(t.Minute())) == 0:
+			t = t.Add(time.Minute)
+		default:
+			return t
+		}
+	}
+	return time.Time{}
+}
+
+func (s *Schedule) matchesDay(t time.Time) bool {
+	day := s.days&(1<<uint(t.Day())) != 0
+	weekday := s.weekday&(1<<uint(t.Weekday())) != 0
+	if s.anyDay {
+		return day && weekday
+	}
+	return day || weekday
+}
@@ -0,0 +1,83 @@
+package db
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSchedule(t *testing.T) {
+	// Friday, 15 March 2024, 10:17
+	now := time.Date(2024, 3, 15, 10, 17, 30, 0, time.UTC)
+	tests := []struct {
+		spec string
+		want time.Time
+	}{
+		{
+			spec: "* * * * *",
+			want: time.Date(2024, 3, 15, 10, 18, 0, 0, time.UTC),
+		},
+		{
+			spec: "0 3 * * *",
+			want: time.Date(2024, 3, 16, 3, 0, 0, 0, time.UTC),
+		},
+		{
+			spec: "*/30 0-6 * * 1-5",
+			want: time.Date(2024, 3, 18, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			spec: "15,45 10,22 * * *",
+			want: time.Date(2024, 3, 15, 10, 45, 0, 0, time.UTC),
+		},
+		{
+			spec: "0 0 1 * *",
+			want: time.Date(2024, 4, 1, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			// Sunday can be 7
+			spec: "0 4 * * 7",
+			want: time.Date(2024, 3, 17, 4, 0, 0, 0, time.UTC),
+		},
+		{
+			// either the day of the month or the day of the week
+			spec: "0 0 20 * 6",
+			want: time.Date(2024, 3, 16, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			spec: "0 0 29 2 *",
+			want: time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC),
+		},
+		{
+			spec: "0 0 31 2 *",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.spec, func(t *testing.T) {
+			schedule, err := ParseSchedule(test.spec)
+			require.NoError(t, err)
+			assert.Equal(t, test.want, schedule.Next(now))
+			assert.Equal(t, test.spec, schedule.String())
+		})
+	}
+}
+
+func TestParseScheduleErrors(t *testing.T) {
+	for _, spec := range []string{
+		"",
+		"* * * *",
+		"* * * * * *",
+		"60 * * * *",
+		"* 24 * * *",
+		"* * 0 * *",
+		"* * * 13 *",
+		"* * * * 8",
+		"5-1 * * * *",
+		"*/0 * * * *",
+		"a * * * *",
+	} {
+		_, err := ParseSchedule(spec)
+		assert.Error(t, err, spec)
+	}
+}
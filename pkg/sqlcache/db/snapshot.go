@@ -0,0 +1,169 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// snapshotSuffix is the extension of the snapshot files
+	snapshotSuffix = ".db"
+	// snapshotPerms are the permissions of the snapshot files, which hold the cached objects
+	snapshotPerms = 0o600
+)
+
+// ErrSnapshotNotFound is returned for snapshots which don't exist
+var ErrSnapshotNotFound = errors.New("snapshot not found")
+
+// Snapshot is a consistent copy of the database of the SQL cache
+type Snapshot struct {
+	// ID is the time the snapshot was started at, in nanoseconds since the epoch
+	ID      string    `json:"id"`
+	Created time.Time `json:"created"`
+	// Size is the size of the snapshot file in bytes
+	Size int64 `json:"size"`
+}
+
+// Snapshotter creates consistent snapshots of the database of the SQL cache in a directory, while the cache keeps
+// writing to it. Snapshots are taken with VACUUM INTO, which copies the database in a read transaction, leaving out its
+// free pages.
+type Snapshotter struct {
+	path string
+	dir  string
+	now  func() time.Time
+
+	// lock serializes snapshots
+	lock sync.Mutex
+}
+
+// NewSnapshotter returns a Snapshotter writing the snapshots of the database of the SQL cache at path to dir
+func NewSnapshotter(path, dir string) *Snapshotter {
+	return &Snapshotter{
+		path: path,
+		dir:  dir,
+		now:  time.Now,
+	}
+}
+
+// Create snapshots the database now
+func (s *Snapshotter) Create(ctx context.Context) (Snapshot, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+
+	if err := os.MkdirAll(s.dir, 0o700); err != nil {
+		return Snapshot{}, err
+	}
+	id := strconv.FormatInt(s.now().UnixNano(), 10)
+	path := s.file(id)
+	// VACUUM INTO writes to an empty file, created beforehand to control its permissions
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, snapshotPerms)
+	if err != nil {
+		return Snapshot{}, err
+	}
+	if err := f.Close(); err != nil {
+		return Snapshot{}, err
+	}
+	if err := s.vacuumInto(ctx, path); err != nil {
+		os.Remove(path)
+		return Snapshot{}, err
+	}
+	// snapshots are in the current layout, even those of databases which aren't migrated, such as those which aren't kept
+	if _, err := migrateFile(ctx, path); err != nil {
+		os.Remove(path)
+		return Snapshot{}, err
+	}
+	return s.Get(id)
+}
+
+func (s *Snapshotter) vacuumInto(ctx context.Context, path string) error {
+	// the database is opened read-write without creating it, waiting for the transactions of the cache to complete
+	conn, err := sql.Open("sqlite", "file:"+s.path+"?mode=rw&_pragma=busy_timeout=120000")
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+
+	if _, err := conn.ExecContext(ctx, "VACUUM INTO ?", path); err != nil {
+		return fmt.Errorf("vacuum into %s: %w", path, err)
+	}
+	return nil
+}
+
+// Get returns a snapshot by ID
+func (s *Snapshotter) Get(id string) (Snapshot, error) {
+	created, err := strconv.ParseInt(id, 10, 64)
+	if err != nil {
+		return Snapshot{}, ErrSnapshotNotFound
+	}
+	info, err := os.Stat(s.file(id))
+	if errors.Is(err, fs.ErrNotExist) {
+		return Snapshot{}, ErrSnapshotNotFound
+	} else if err != nil {
+		return Snapshot{}, err
+	}
+	return Snapshot{
+		ID:      id,
+		Created: time.Unix(0, created),
+		Size:    info.Size(),
+	}, nil
+}
+
+// List returns the snapshots, the latest first
+func (s *Snapshotter) List() ([]Snapshot, error) {
+	entries, err := os.ReadDir(s.dir)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	var result []Snapshot
+	for _, entry := range entries {
+		id, ok := strings.CutSuffix(entry.Name(), snapshotSuffix)
+		if !ok || entry.IsDir() {
+			continue
+		}
+		snapshot, err := s.Get(id)
+		if errors.Is(err, ErrSnapshotNotFound) {
+			continue
+		} else if err != nil {
+			return nil, err
+		}
+		result = append(result, snapshot)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Created.After(result[j].Created)
+	})
+	return result, nil
+}
+
+// Open opens the file of a snapshot, for example to download it
+func (s *Snapshotter) Open(id string) (*os.File, error) {
+	if _, err := s.Get(id); err != nil {
+		return nil, err
+	}
+	return os.Open(s.file(id))
+}
+
+// Delete deletes a snapshot
+func (s *Snapshotter) Delete(id string) error {
+	if _, err := s.Get(id); err != nil {
+		return err
+	}
+	return os.Remove(s.file(id))
+}
+
+// file returns the path of the file of a snapshot, whose ID must be validated first
+func (s *Snapshotter) file(id string) string {
+	return filepath.Join(s.dir, id+snapshotSuffix)
+}
@@ -0,0 +1,78 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestSnapshotter(t *testing.T) {
+	// the database is opened as the cache does, the snapshot being taken while it is open
+	path := filepath.Join(t.TempDir(), InformerObjectCacheDBPath)
+	conn, err := sql.Open("sqlite", "file:"+path+"?mode=rwc&_pragma=journal_mode=wal&_pragma=synchronous=off")
+	require.NoError(t, err)
+	defer conn.Close()
+	_, err = conn.Exec("CREATE TABLE objects (key TEXT PRIMARY KEY, data BLOB)")
+	require.NoError(t, err)
+	for i := 0; i < 10; i++ {
+		_, err = conn.Exec("INSERT INTO objects VALUES (?, ?)", i, "pod")
+		require.NoError(t, err)
+	}
+
+	s := NewSnapshotter(path, filepath.Join(t.TempDir(), "snapshots"))
+	list, err := s.List()
+	require.NoError(t, err)
+	assert.Empty(t, list, "the directory doesn't exist yet")
+
+	now := time.Unix(1700000000, 0)
+	s.now = func() time.Time { return now }
+	first, err := s.Create(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, "1700000000000000000", first.ID)
+	assert.True(t, first.Created.Equal(now))
+	assert.Greater(t, first.Size, int64(0))
+	info, err := os.Stat(s.file(first.ID))
+	require.NoError(t, err)
+	assert.Equal(t, os.FileMode(snapshotPerms), info.Mode().Perm())
+
+	now = now.Add(time.Hour)
+	second, err := s.Create(context.Background())
+	require.NoError(t, err)
+	list, err = s.List()
+	require.NoError(t, err)
+	assert.Equal(t, []Snapshot{second, first}, list)
+
+	// the snapshot is a database holding the objects
+	f, err := s.Open(first.ID)
+	require.NoError(t, err)
+	copied := filepath.Join(t.TempDir(), "copy.db")
+	out, err := os.Create(copied)
+	require.NoError(t, err)
+	_, err = io.Copy(out, f)
+	require.NoError(t, err)
+	require.NoError(t, f.Close())
+	require.NoError(t, out.Close())
+	snapshot, err := sql.Open("sqlite", "file:"+copied+"?mode=ro")
+	require.NoError(t, err)
+	defer snapshot.Close()
+	var count int
+	require.NoError(t, snapshot.QueryRow("SELECT COUNT(*) FROM objects").Scan(&count))
+	assert.Equal(t, 10, count)
+	var version int
+	require.NoError(t, snapshot.QueryRow("PRAGMA user_version").Scan(&version))
+	assert.Equal(t, SchemaVersion, version, "snapshots are in the current layout")
+
+	require.NoError(t, s.Delete(first.ID))
+	_, err = s.Get(first.ID)
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+	assert.ErrorIs(t, s.Delete("../"+InformerObjectCacheDBPath), ErrSnapshotNotFound)
+	_, err = s.Open("unknown")
+	assert.ErrorIs(t, err, ErrSnapshotNotFound)
+}
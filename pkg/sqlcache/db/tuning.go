@@ -0,0 +1,218 @@
+package db
+
+import (
+	"context"
+	"database/sql/driver"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rancher/steve/pkg/metrics"
+	"modernc.org/sqlite"
+)
+
+// Tuning are the SQLite settings of the database of the SQL cache, applied to every connection the cache opens.
+type Tuning struct {
+	// JournalMode is one of WAL, DELETE, TRUNCATE, PERSIST or MEMORY. WAL lets lists read while events are written
+	JournalMode string
+	// Synchronous is one of OFF, NORMAL, FULL or EXTRA. The database is recreated on start, so it doesn't need to
+	// survive crashes
+	Synchronous string
+	// PageSize is the size of the pages of the database in bytes, a power of two between 512 and 65536. It only
+	// applies to new databases
+	PageSize int
+	// CacheSizeKiB is the size of the page cache of each connection in KiB
+	CacheSizeKiB int
+	// BusyTimeout is how long a connection waits for the transactions of others to complete before failing
+	BusyTimeout time.Duration
+	// MmapSize is the size of the database mapped in memory in bytes, 0 disables memory mapping
+	MmapSize int64
+	// WALAutocheckpoint is the number of pages the WAL grows to before it is checkpointed into the database, 0
+	// disables automatic checkpoints. Larger values checkpoint less often during bursts of events
+	WALAutocheckpoint int
+}
+
+// DefaultTuning returns the settings the cache opens the database with, SQLite's defaults otherwise
+func DefaultTuning() Tuning {
+	return Tuning{
+		JournalMode:       "WAL",
+		Synchronous:       "OFF",
+		PageSize:          4096,
+		CacheSizeKiB:      2000,
+		BusyTimeout:       2 * time.Minute,
+		WALAutocheckpoint: 1000,
+	}
+}
+
+var (
+	journalModes      = []string{"WAL", "DELETE", "TRUNCATE", "PERSIST", "MEMORY"}
+	synchronousLevels = []string{"OFF", "NORMAL", "FULL", "EXTRA"}
+)
+
+// Validate returns an error if a setting is invalid
+func (t Tuning) Validate() error {
+	if !contains(journalModes, strings.ToUpper(t.JournalMode)) {
+		return fmt.Errorf("invalid journal mode %q, must be one of %s", t.JournalMode, strings.Join(journalModes, ", "))
+	}
+	if !contains(synchronousLevels, strings.ToUpper(t.Synchronous)) {
+		return fmt.Errorf("invalid synchronous level %q, must be one of %s", t.Synchronous, strings.Join(synchronousLevels, ", "))
+	}
+	if t.PageSize < 512 || t.PageSize > 65536 || t.PageSize&(t.PageSize-1) != 0 {
+		return fmt.Errorf("invalid page size %d, must be a power of two between 512 and 65536", t.PageSize)
+	}
+	if t.CacheSizeKiB < 0 {
+		return fmt.Errorf("invalid cache size %d, must not be negative", t.CacheSizeKiB)
+	}
+	if t.BusyTimeout < 0 {
+		return fmt.Errorf("invalid busy timeout %s, must not be negative", t.BusyTimeout)
+	}
+	if t.MmapSize < 0 {
+		return fmt.Errorf("invalid mmap size %d, must not be negative", t.MmapSize)
+	}
+	if t.WALAutocheckpoint < 0 {
+		return fmt.Errorf("invalid WAL autocheckpoint %d, must not be negative", t.WALAutocheckpoint)
+	}
+	return nil
+}
+
+// pragmas returns the statements applying the settings to a connection
+func (t Tuning) pragmas() []string {
+	return []string{
+		"PRAGMA journal_mode = " + strings.ToUpper(t.JournalMode),
+		"PRAGMA synchronous = " + strings.ToUpper(t.Synchronous),
+		// a negative cache size is in KiB rather than pages
+		fmt.Sprintf("PRAGMA cache_size = -%d", t.CacheSizeKiB),
+		fmt.Sprintf("PRAGMA busy_timeout = %d", t.BusyTimeout.Milliseconds()),
+		fmt.Sprintf("PRAGMA mmap_size = %d", t.MmapSize),
+		fmt.Sprintf("PRAGMA wal_autocheckpoint = %d", t.WALAutocheckpoint),
+	}
+}
+
+var connections = struct {
+	register sync.Once
+	lock     sync.Mutex
+	// databases are the tunings of the databases of the SQL caches by path, nil for those which aren't tuned
+	databases         map[string]*Tuning
+	incrementalVacuum bool
+}{
+	databases: map[string]*Tuning{InformerObjectCacheDBPath: nil},
+}
+
+// SetTuning applies tuning to the connections the SQL cache with the database at path opens from now on. It must be
+// called before the cache is created for the page size to apply.
+func SetTuning(path string, tuning Tuning) error {
+	if err := tuning.Validate(); err != nil {
+		return err
+	}
+	connections.lock.Lock()
+	connections.databases[path] = &tuning
+	connections.lock.Unlock()
+	registerConnectionHook()
+	return nil
+}
+
+// registerDatabase configures the connections to the database of a SQL cache at path, such as one of another cluster,
+// as those to the default one
+func registerDatabase(path string) {
+	connections.lock.Lock()
+	defer connections.lock.Unlock()
+	if _, ok := connections.databases[path]; !ok {
+		connections.databases[path] = nil
+	}
+}
+
+// database returns the tuning of the database of a connection, and false if it isn't that of a SQL cache
+func database(dsn string) (*Tuning, bool) {
+	file, _, _ := strings.Cut(strings.TrimPrefix(dsn, "file:"), "?")
+	for path, tuning := range connections.databases {
+		if file == path || strings.HasSuffix(file, "/"+path) {
+			return tuning, true
+		}
+	}
+	return nil, false
+}
+
+func registerConnectionHook() {
+	connections.register.Do(func() {
+		sqlite.RegisterConnectionHook(func(conn sqlite.ExecQuerierContext, dsn string) error {
+			connections.lock.Lock()
+			tuning, ok := database(dsn)
+			incrementalVacuum := connections.incrementalVacuum
+			connections.lock.Unlock()
+			if !ok {
+				return nil
+			}
+			metrics.RecordSQLCacheConnection()
+			// read-only connections, such as those of the pool of lists, only apply the settings of their connection
+			readOnly := strings.Contains(dsn, "query_only=1")
+			return configureConnection(conn, tuning, incrementalVacuum, readOnly)
+		})
+	})
+}
+
+// configureConnection applies the tuning to a connection opened by the cache, and configures the database itself if
+// it is new, unless the connection is read-only. The pragmas run after those of the connection string, overriding them.
+func configureConnection(conn sqlite.ExecQuerierContext, tuning *Tuning, incrementalVacuum, readOnly bool) error {
+	ctx := context.Background()
+	if tuning != nil {
+		for _, pragma := range tuning.pragmas() {
+			if readOnly && strings.HasPrefix(pragma, "PRAGMA journal_mode") {
+				// the journal mode is that of the database, set by the connections writing to it
+				continue
+			}
+			if _, err := conn.ExecContext(ctx, pragma, nil); err != nil {
+				return fmt.Errorf("%s: %w", pragma, err)
+			}
+		}
+	}
+	if readOnly || (tuning == nil && !incrementalVacuum) {
+		return nil
+	}
+
+	empty, err := isEmpty(conn)
+	if err != nil || !empty {
+		return err
+	}
+	// the page size and auto_vacuum mode of a database only change when it is vacuumed, which is immediate as it is
+	// empty, since the journal mode of the connection string has already been set
+	if tuning != nil {
+		if _, err := conn.ExecContext(ctx, fmt.Sprintf("PRAGMA page_size = %d", tuning.PageSize), nil); err != nil {
+			return err
+		}
+	}
+	if incrementalVacuum {
+		if _, err := conn.ExecContext(ctx, "PRAGMA auto_vacuum = INCREMENTAL", nil); err != nil {
+			return err
+		}
+	}
+	_, err = conn.ExecContext(ctx, "VACUUM", nil)
+	return err
+}
+
+// isEmpty returns whether the database of a connection has no tables
+func isEmpty(conn sqlite.ExecQuerierContext) (bool, error) {
+	rows, err := conn.QueryContext(context.Background(), "SELECT count(*) FROM sqlite_master", nil)
+	if err != nil {
+		return false, err
+	}
+	values := make([]driver.Value, 1)
+	err = rows.Next(values)
+	if closeErr := rows.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return false, err
+	}
+	count, ok := values[0].(int64)
+	return ok && count == 0, nil
+}
+
+func contains(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
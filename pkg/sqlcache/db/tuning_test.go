@@ -0,0 +1,93 @@
+package db
+
+import (
+	"database/sql"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTuningValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		tune    func(*Tuning)
+		wantErr bool
+	}{
+		{name: "defaults", tune: func(*Tuning) {}},
+		{name: "lowercase values", tune: func(t *Tuning) { t.JournalMode, t.Synchronous = "delete", "normal" }},
+		{name: "invalid journal mode", tune: func(t *Tuning) { t.JournalMode = "OFF" }, wantErr: true},
+		{name: "invalid synchronous level", tune: func(t *Tuning) { t.Synchronous = "2" }, wantErr: true},
+		{name: "page size not a power of two", tune: func(t *Tuning) { t.PageSize = 5000 }, wantErr: true},
+		{name: "page size too large", tune: func(t *Tuning) { t.PageSize = 131072 }, wantErr: true},
+		{name: "negative cache size", tune: func(t *Tuning) { t.CacheSizeKiB = -1 }, wantErr: true},
+		{name: "negative busy timeout", tune: func(t *Tuning) { t.BusyTimeout = -time.Second }, wantErr: true},
+		{name: "negative mmap size", tune: func(t *Tuning) { t.MmapSize = -1 }, wantErr: true},
+		{name: "negative WAL autocheckpoint", tune: func(t *Tuning) { t.WALAutocheckpoint = -1 }, wantErr: true},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			tuning := DefaultTuning()
+			test.tune(&tuning)
+			err := tuning.Validate()
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+		})
+	}
+}
+
+func TestSetTuning(t *testing.T) {
+	assert.Error(t, SetTuning(InformerObjectCacheDBPath, Tuning{}))
+
+	tuning := Tuning{
+		JournalMode:       "truncate",
+		Synchronous:       "FULL",
+		PageSize:          8192,
+		CacheSizeKiB:      4096,
+		BusyTimeout:       5 * time.Second,
+		MmapSize:          1 << 20,
+		WALAutocheckpoint: 10000,
+	}
+	require.NoError(t, SetTuning(InformerObjectCacheDBPath, tuning))
+	t.Cleanup(func() {
+		connections.lock.Lock()
+		connections.databases[InformerObjectCacheDBPath] = nil
+		connections.lock.Unlock()
+	})
+
+	// the settings override those the cache opens the database with
+	path := filepath.Join(t.TempDir(), InformerObjectCacheDBPath)
+	conn, err := sql.Open("sqlite", "file:"+path+"?mode=rwc&_pragma=journal_mode=wal&_pragma=synchronous=off&_pragma=busy_timeout=120000")
+	require.NoError(t, err)
+	defer conn.Close()
+	conn.SetMaxOpenConns(1)
+
+	var journalMode string
+	var synchronous, pageSize, cacheSize, busyTimeout, mmapSize, walAutocheckpoint int64
+	require.NoError(t, conn.QueryRow("PRAGMA journal_mode").Scan(&journalMode))
+	require.NoError(t, conn.QueryRow("PRAGMA synchronous").Scan(&synchronous))
+	require.NoError(t, conn.QueryRow("PRAGMA page_size").Scan(&pageSize))
+	require.NoError(t, conn.QueryRow("PRAGMA cache_size").Scan(&cacheSize))
+	require.NoError(t, conn.QueryRow("PRAGMA busy_timeout").Scan(&busyTimeout))
+	require.NoError(t, conn.QueryRow("PRAGMA mmap_size").Scan(&mmapSize))
+	require.NoError(t, conn.QueryRow("PRAGMA wal_autocheckpoint").Scan(&walAutocheckpoint))
+	assert.Equal(t, "truncate", journalMode)
+	assert.Equal(t, int64(2), synchronous)
+	assert.Equal(t, int64(8192), pageSize)
+	assert.Equal(t, int64(-4096), cacheSize)
+	assert.Equal(t, int64(5000), busyTimeout)
+	assert.Equal(t, int64(1<<20), mmapSize)
+	assert.Equal(t, int64(10000), walAutocheckpoint)
+
+	// other databases are left alone
+	other, err := sql.Open("sqlite", "file:"+filepath.Join(t.TempDir(), "other.db")+"?mode=rwc")
+	require.NoError(t, err)
+	defer other.Close()
+	require.NoError(t, other.QueryRow("PRAGMA page_size").Scan(&pageSize))
+	assert.Equal(t, int64(4096), pageSize)
+}
@@ -0,0 +1,168 @@
+// Package factory creates the informers of the SQL cache on a database client, such as one reading from a pool of
+// connections, writing the objects of the initial sync of each informer in a single transaction once it is synced.
+package factory
+
+import (
+	"fmt"
+	"os"
+	"sync"
+
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/store"
+	"k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/wait"
+	"k8s.io/client-go/dynamic"
+	"k8s.io/client-go/tools/cache"
+)
+
+// EncryptAllEnvVar is set to "true" if users want all types' data blobs to be encrypted in SQLite
+const EncryptAllEnvVar = "CATTLE_ENCRYPT_CACHE_ALL"
+
+// DBClient is the client of the database informers write to and list from, which is deleted and opened again when the
+// cache is reset
+type DBClient interface {
+	informer.DBClient
+	store.DBClient
+	NewConnection() error
+}
+
+// Cache lists the objects of a type from the database
+type Cache struct {
+	informer.ByOptionsLister
+}
+
+// flusher is a DBClient batching writes, which commits them when flushed
+type flusher interface {
+	Flush() error
+}
+
+// migrator is a DBClient keeping the tables of types in the layout of this version of steve, such as those of a
+// database kept from an earlier run
+type migrator interface {
+	AlterFields(gvk schema.GroupVersionKind, fields [][]string, namespaced bool) error
+}
+
+// checker is a DBClient checking the consistency of its tables with the types discovered
+type checker interface {
+	CheckConsistency(types []db.CachedType, reconcile bool) (db.ConsistencyReport, error)
+}
+
+// encryptedTypes are the types whose objects are always encrypted
+var encryptedTypes = map[schema.GroupVersionKind]bool{
+	{Version: "v1", Kind: "Secret"}: true,
+}
+
+// CacheFactory creates and keeps the informers of types, writing to and listing from a database client
+type CacheFactory struct {
+	dbClient   DBClient
+	encryptAll bool
+
+	// lock blocks resets while informers are created
+	lock   sync.RWMutex
+	wg     wait.Group
+	stopCh chan struct{}
+
+	informersLock sync.Mutex
+	informers     map[schema.GroupVersionKind]*guardedInformer
+}
+
+type guardedInformer struct {
+	lock     sync.Mutex
+	informer *informer.Informer
+}
+
+// NewCacheFactory returns a CacheFactory on dbClient. All objects are encrypted if EncryptAllEnvVar is set.
+func NewCacheFactory(dbClient DBClient) *CacheFactory {
+	return &CacheFactory{
+		dbClient:   dbClient,
+		encryptAll: os.Getenv(EncryptAllEnvVar) == "true",
+		stopCh:     make(chan struct{}),
+		informers:  map[schema.GroupVersionKind]*guardedInformer{},
+	}
+}
+
+// CacheFor returns the informer of a type, created and synced the first time, indexing fields of the objects listed
+// and watched with client
+func (f *CacheFactory) CacheFor(fields [][]string, transform cache.TransformFunc, client dynamic.ResourceInterface, gvk schema.GroupVersionKind, namespaced bool, watchable bool) (Cache, error) {
+	f.lock.RLock()
+	defer f.lock.RUnlock()
+
+	f.informersLock.Lock()
+	gi, ok := f.informers[gvk]
+	if !ok {
+		gi = &guardedInformer{}
+		f.informers[gvk] = gi
+	}
+	// informers of different types are created concurrently
+	f.informersLock.Unlock()
+
+	gi.lock.Lock()
+	defer gi.lock.Unlock()
+	if gi.informer == nil {
+		m, migrates := f.dbClient.(migrator)
+		if migrates {
+			if err := m.AlterFields(gvk, fields, namespaced); err != nil {
+				return Cache{}, fmt.Errorf("altering the fields of GVK %v: %w", gvk, err)
+			}
+		}
+		i, err := informer.NewInformer(client, fields, transform, gvk, f.dbClient, f.encryptAll || encryptedTypes[gvk], namespaced)
+		if err != nil {
+			return Cache{}, err
+		}
+		err = i.SetWatchErrorHandler(func(r *cache.Reflector, err error) {
+			if !watchable && errors.IsMethodNotSupported(err) {
+				return
+			}
+			cache.DefaultWatchErrorHandler(r, err)
+		})
+		if err != nil {
+			return Cache{}, err
+		}
+		f.wg.StartWithChannel(f.stopCh, i.Run)
+		gi.informer = i
+	}
+
+	if !cache.WaitForCacheSync(f.stopCh, gi.informer.HasSynced) {
+		return Cache{}, fmt.Errorf("failed to sync SQLite Informer cache for GVK %v", gvk)
+	}
+	written, err := gi.informer.WriteInitialSync()
+	if err != nil {
+		return Cache{}, fmt.Errorf("writing the initial sync of GVK %v: %w", gvk, err)
+	}
+	// the objects of the initial sync are listed right away, rather than once their batch is committed
+	if f, ok := f.dbClient.(flusher); ok && written {
+		if err := f.Flush(); err != nil {
+			return Cache{}, err
+		}
+	}
+	return Cache{ByOptionsLister: gi.informer}, nil
+}
+
+// CheckConsistency checks the tables of the database against the types discovered, reconciling them if reconcile is
+// true, while no informer is created. It fails if the database client can't check them.
+func (f *CacheFactory) CheckConsistency(types []db.CachedType, reconcile bool) (db.ConsistencyReport, error) {
+	c, ok := f.dbClient.(checker)
+	if !ok {
+		return db.ConsistencyReport{}, fmt.Errorf("the database client can't check the consistency of its tables")
+	}
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	return c.CheckConsistency(types, reconcile)
+}
+
+// Reset stops the informers, once those being created are, and deletes the database
+func (f *CacheFactory) Reset() error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+
+	close(f.stopCh)
+	f.stopCh = make(chan struct{})
+	f.wg.Wait()
+
+	f.informersLock.Lock()
+	f.informers = map[schema.GroupVersionKind]*guardedInformer{}
+	f.informersLock.Unlock()
+	return f.dbClient.NewConnection()
+}
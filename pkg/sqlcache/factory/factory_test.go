@@ -0,0 +1,71 @@
+package factory
+
+import (
+	"context"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+)
+
+func TestCacheFactory(t *testing.T) {
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "ConfigMap"}
+	gvr := schema.GroupVersionResource{Version: "v1", Resource: "configmaps"}
+	newConfigMap := func(name string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetGroupVersionKind(gvk)
+		obj.SetNamespace("default")
+		obj.SetName(name)
+		return obj
+	}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(),
+		map[schema.GroupVersionResource]string{gvr: "ConfigMapList"}, newConfigMap("config1"), newConfigMap("config2"))
+
+	dbClient, err := db.NewPooledClient(filepath.Join(t.TempDir(), "cache.db"), 2)
+	require.NoError(t, err)
+	defer dbClient.Close()
+	// objects are listed once synced, whatever the window of batches of events
+	require.NoError(t, dbClient.SetWriteBatching(time.Hour, db.DefaultWriteBatchSize))
+	f := NewCacheFactory(dbClient)
+	fields := [][]string{{"data", "key"}}
+	c, err := f.CacheFor(fields, nil, client.Resource(gvr), gvk, true, true)
+	require.NoError(t, err)
+
+	ctx := context.Background()
+	opts := informer.ListOptions{Sort: informer.Sort{PrimaryField: []string{"metadata", "name"}, PrimaryOrder: informer.DESC}}
+	list, total, _, err := c.ListByOptions(ctx, opts, []partition.Partition{{Passthrough: true}}, "")
+	require.NoError(t, err)
+	assert.Equal(t, 2, total)
+	assert.Equal(t, "config2", list.Items[0].GetName())
+
+	// events following the initial sync are written as they are watched
+	_, err = client.Resource(gvr).Namespace("default").Create(ctx, newConfigMap("config3"), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, dbClient.SetWriteBatching(0, 0))
+	assert.Eventually(t, func() bool {
+		_, total, _, err := c.ListByOptions(ctx, opts, []partition.Partition{{Passthrough: true}}, "")
+		return err == nil && total == 3
+	}, 5*time.Second, 10*time.Millisecond)
+
+	// the same informer is returned until the factory is reset
+	again, err := f.CacheFor(fields, nil, client.Resource(gvr), gvk, true, true)
+	require.NoError(t, err)
+	assert.Same(t, c.ByOptionsLister, again.ByOptionsLister)
+	require.NoError(t, f.Reset())
+	again, err = f.CacheFor(fields, nil, client.Resource(gvr), gvk, true, true)
+	require.NoError(t, err)
+	assert.NotSame(t, c.ByOptionsLister, again.ByOptionsLister)
+	_, total, _, err = again.ListByOptions(ctx, opts, []partition.Partition{{Passthrough: true}}, "")
+	require.NoError(t, err)
+	assert.Equal(t, 3, total)
+}
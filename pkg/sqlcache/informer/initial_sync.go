@@ -0,0 +1,105 @@
+package informer
+
+import (
+	"sync"
+
+	"k8s.io/client-go/tools/cache"
+)
+
+// initialSync is the indexer of an informer, which keeps the objects of its initial sync in memory, along with the
+// events following them until the informer is synced, to write them all in a single transaction once it is, rather
+// than each object of the initial list in a transaction of its own.
+type initialSync struct {
+	cache.Indexer
+
+	lock sync.RWMutex
+	// objects are the objects synced, which are written in the indexer once the informer is synced
+	objects cache.Store
+}
+
+// newInitialSync keeps the objects of the initial sync of indexer in memory. Those the indexer has already, such as those of
+// a kept database, are replaced by them once written.
+func newInitialSync(indexer cache.Indexer) *initialSync {
+	return &initialSync{
+		Indexer: indexer,
+		objects: cache.NewStore(cache.DeletionHandlingMetaNamespaceKeyFunc),
+	}
+}
+
+// write writes the objects synced in the indexer, in a single transaction, returning false if they were already
+func (s *initialSync) write() (bool, error) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.objects == nil {
+		return false, nil
+	}
+	if err := s.Indexer.Replace(s.objects.List(), ""); err != nil {
+		return false, err
+	}
+	s.objects = nil
+	return true, nil
+}
+
+// store returns where objects are read and written, in memory until the informer is synced. The returned func must be
+// called once done.
+func (s *initialSync) store() (cache.Store, func()) {
+	s.lock.RLock()
+	if s.objects != nil {
+		return s.objects, s.lock.RUnlock
+	}
+	return s.Indexer, s.lock.RUnlock
+}
+
+func (s *initialSync) Add(obj any) error {
+	store, done := s.store()
+	defer done()
+	return store.Add(obj)
+}
+
+func (s *initialSync) Update(obj any) error {
+	store, done := s.store()
+	defer done()
+	return store.Update(obj)
+}
+
+func (s *initialSync) Delete(obj any) error {
+	store, done := s.store()
+	defer done()
+	return store.Delete(obj)
+}
+
+func (s *initialSync) List() []any {
+	store, done := s.store()
+	defer done()
+	return store.List()
+}
+
+func (s *initialSync) ListKeys() []string {
+	store, done := s.store()
+	defer done()
+	return store.ListKeys()
+}
+
+func (s *initialSync) Get(obj any) (any, bool, error) {
+	store, done := s.store()
+	defer done()
+	return store.Get(obj)
+}
+
+func (s *initialSync) GetByKey(key string) (any, bool, error) {
+	store, done := s.store()
+	defer done()
+	return store.GetByKey(key)
+}
+
+func (s *initialSync) Replace(objects []any, resourceVersion string) error {
+	store, done := s.store()
+	defer done()
+	return store.Replace(objects, resourceVersion)
+}
+
+func (s *initialSync) Resync() error {
+	store, done := s.store()
+	defer done()
+	return store.Resync()
+}
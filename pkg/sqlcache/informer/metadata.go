@@ -0,0 +1,330 @@
+package informer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// ErrMetadataFilters is returned for lists of metadata with filters, which the fields table can't apply on its own
+var ErrMetadataFilters = errors.New("metadata lists don't support filters")
+
+// metadataColumnRegexp matches the columns of the fields table holding a value of the metadata of objects, either of a
+// field such as metadata.name, or of a key of a map such as metadata.labels[app]
+var metadataColumnRegexp = regexp.MustCompile(`^metadata\.([a-zA-Z]+)(?:\[(.+)])?$`)
+
+// stateCountColumns are the columns of the fields table CountStates counts objects by
+var stateCountColumns = []string{"metadata.state.error", "metadata.state.transitioning", "metadata.resourceVersion"}
+
+// MetadataLister lists the metadata of the objects of the SQL cache from the fields table kept for each type,
+// without reading, decrypting and decoding the objects themselves. The fields table only holds the name, namespace
+// and creation timestamp of objects, and the fields indexed for their type, such as labels, which are therefore all
+// the metadata it lists.
+type MetadataLister struct {
+	path string
+
+	lock sync.Mutex
+	conn *sql.DB
+}
+
+// NewMetadataLister returns a MetadataLister of the database of the SQL cache at path
+func NewMetadataLister(path string) *MetadataLister {
+	return &MetadataLister{
+		path: path,
+	}
+}
+
+// ListMetadata returns the metadata of the objects of a type belonging to any of the partitions, sorted and paginated
+// as lists of the ListOptionIndexer are, as PartialObjectMetadata objects. Filters aren't supported.
+func (m *MetadataLister) ListMetadata(ctx context.Context, gvk schema.GroupVersionKind, lo ListOptions, partitions []partition.Partition, namespace string) (*unstructured.UnstructuredList, int, string, error) {
+	if len(lo.Filters) > 0 {
+		return nil, 0, "", ErrMetadataFilters
+	}
+	conn, err := m.connection()
+	if err != nil {
+		return nil, 0, "", err
+	}
+	table := db.Sanitize(gvk.Group+"_"+gvk.Version+"_"+gvk.Kind) + "_fields"
+	columns, err := db.TableColumns(ctx, conn, table)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	var metadataColumns []string
+	for _, column := range columns {
+		if metadataColumnRegexp.MatchString(column) {
+			metadataColumns = append(metadataColumns, column)
+		}
+	}
+
+	query := fmt.Sprintf(`SELECT %s FROM "%s" f`, quoteColumns(metadataColumns), table)
+	where, params := partitionsWhere(partitions, namespace)
+	if where != "" {
+		query += " WHERE " + where
+	}
+
+	var orderBy []string
+	for _, s := range []struct {
+		field []string
+		order SortOrder
+	}{{lo.Sort.PrimaryField, lo.Sort.PrimaryOrder}, {lo.Sort.SecondaryField, lo.Sort.SecondaryOrder}} {
+		if len(s.field) == 0 {
+			continue
+		}
+		column := db.Sanitize(strings.Join(s.field, "."))
+		if !slices.Contains(columns, column) {
+			return nil, 0, "", fmt.Errorf("column is invalid [%s]: %w", column, InvalidColumnErr)
+		}
+		direction := "ASC"
+		if s.order == DESC {
+			direction = "DESC"
+		}
+		orderBy = append(orderBy, fmt.Sprintf(`f."%s" %s`, column, direction))
+	}
+	if len(orderBy) == 0 {
+		if slices.Contains(columns, "metadata.namespace") {
+			orderBy = append(orderBy, `f."metadata.namespace" ASC`)
+		}
+		orderBy = append(orderBy, `f."metadata.name" ASC`)
+	}
+	query += " ORDER BY " + strings.Join(orderBy, ", ")
+
+	countQuery := fmt.Sprintf("SELECT COUNT(*) FROM (%s)", query)
+	countParams := params[:len(params):len(params)]
+	limit := lo.Pagination.PageSize
+	if limit == 0 || (lo.ChunkSize > 0 && lo.ChunkSize < limit) {
+		limit = lo.ChunkSize
+	}
+	if limit > 0 {
+		query += " LIMIT ?"
+		params = append(params, limit)
+	}
+	offset := 0
+	if lo.Resume != "" {
+		if offset, err = strconv.Atoi(lo.Resume); err != nil {
+			return nil, 0, "", err
+		}
+	}
+	if lo.Pagination.Page >= 1 {
+		offset += lo.Pagination.PageSize * (lo.Pagination.Page - 1)
+	}
+	if offset > 0 {
+		query += " OFFSET ?"
+		params = append(params, offset)
+	}
+
+	// the list and its count are read in the same transaction, so that they agree
+	tx, err := conn.BeginTx(ctx, &sql.TxOptions{ReadOnly: true})
+	if err != nil {
+		return nil, 0, "", err
+	}
+	defer tx.Rollback()
+	items, err := readMetadata(ctx, tx, query, params, metadataColumns)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	total := len(items)
+	if limit > 0 || offset > 0 {
+		if err := tx.QueryRowContext(ctx, countQuery, countParams...).Scan(&total); err != nil {
+			return nil, 0, "", err
+		}
+	}
+	continueToken := ""
+	if limit > 0 && offset+len(items) < total {
+		continueToken = strconv.Itoa(offset + limit)
+	}
+	return &unstructured.UnstructuredList{Items: items}, total, continueToken, nil
+}
+
+// StateCount is the number of objects of a namespace, empty for types which aren't namespaced, in an error or
+// transitioning state, or neither
+type StateCount struct {
+	Namespace     string
+	Project       string
+	Error         bool
+	Transitioning bool
+	Count         int
+}
+
+// CountStates returns the number of objects of a type belonging to any of the partitions by namespace, project of the
+// namespace and state, counted by grouping the rows of the fields table, and the highest resource version among them.
+// It returns an error if the state or resource version of objects aren't indexed for the type, or if its table, or
+// that of namespaces, doesn't exist yet.
+func (m *MetadataLister) CountStates(ctx context.Context, gvk schema.GroupVersionKind, partitions []partition.Partition) ([]StateCount, int, error) {
+	conn, err := m.connection()
+	if err != nil {
+		return nil, 0, err
+	}
+	table := db.TypeTable(gvk) + "_fields"
+	columns, err := db.TableColumns(ctx, conn, table)
+	if err != nil {
+		return nil, 0, err
+	}
+	for _, column := range stateCountColumns {
+		if !slices.Contains(columns, column) {
+			return nil, 0, fmt.Errorf("column is invalid [%s]: %w", column, InvalidColumnErr)
+		}
+	}
+	namespace, project := "''", "''"
+	if slices.Contains(columns, "metadata.namespace") {
+		if _, err := db.TableColumns(ctx, conn, partition.NamespaceFieldsTable); err != nil {
+			return nil, 0, err
+		}
+		namespace, project = `f."metadata.namespace"`, partition.NamespaceProject
+	}
+
+	query := fmt.Sprintf(`SELECT %s, %s, f."metadata.state.error", f."metadata.state.transitioning", COUNT(*),
+    MAX(CAST(f."metadata.resourceVersion" AS INTEGER)) FROM "%s" f`, namespace, project, table)
+	where, params := partitionsWhere(partitions, "")
+	if where != "" {
+		query += "\n  WHERE " + where
+	}
+	query += "\n  GROUP BY 1, 2, 3, 4"
+	rows, err := conn.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, 0, &db.QueryError{QueryString: query, Err: err}
+	}
+	defer rows.Close()
+
+	var counts []StateCount
+	revision := 0
+	for rows.Next() {
+		var count StateCount
+		var stateError, transitioning sql.NullString
+		var maxRevision sql.NullInt64
+		if err := rows.Scan(&count.Namespace, &count.Project, &stateError, &transitioning, &count.Count, &maxRevision); err != nil {
+			return nil, 0, err
+		}
+		count.Error = stateError.String == "true"
+		count.Transitioning = transitioning.String == "true"
+		counts = append(counts, count)
+		revision = max(revision, int(maxRevision.Int64))
+	}
+	return counts, revision, rows.Err()
+}
+
+// partitionsWhere returns the conditions of the objects belonging to the namespace, if any, and to any of the
+// partitions in the queries of the fields table, as f, and their params
+func partitionsWhere(partitions []partition.Partition, namespace string) (string, []any) {
+	var params []any
+	var whereClauses []string
+	if namespace != "" && namespace != "*" {
+		clause, namespaceParams := partition.NamespaceClause(namespace)
+		whereClauses = append(whereClauses, clause)
+		params = append(params, namespaceParams...)
+	}
+	var partitionClauses []string
+	for _, p := range partitions {
+		if p.Passthrough {
+			continue
+		}
+		var clauses []string
+		if clause, scopeParams, ok := partition.ScopeClause(p); ok {
+			clauses = append(clauses, clause)
+			params = append(params, scopeParams...)
+		}
+		if !p.All {
+			names := p.Names.UnsortedList()
+			sort.Strings(names)
+			if len(names) == 0 {
+				clauses = append(clauses, "FALSE")
+			} else {
+				clauses = append(clauses, fmt.Sprintf(`f."metadata.name" IN (?%s)`, strings.Repeat(", ?", len(names)-1)))
+				for _, name := range names {
+					params = append(params, name)
+				}
+			}
+		}
+		if len(clauses) > 0 {
+			partitionClauses = append(partitionClauses, strings.Join(clauses, " AND "))
+		}
+	}
+	if len(partitions) == 0 {
+		whereClauses = append(whereClauses, "FALSE")
+	}
+	if len(partitionClauses) > 0 {
+		whereClauses = append(whereClauses, "(("+strings.Join(partitionClauses, ") OR (")+"))")
+	}
+	return strings.Join(whereClauses, " AND "), params
+}
+
+// readMetadata returns the PartialObjectMetadata objects of the rows of the metadata columns of a query
+func readMetadata(ctx context.Context, tx *sql.Tx, query string, params []any, columns []string) ([]unstructured.Unstructured, error) {
+	rows, err := tx.QueryContext(ctx, query, params...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	items := []unstructured.Unstructured{}
+	values := make([]sql.NullString, len(columns))
+	dest := make([]any, len(columns))
+	for i := range values {
+		dest[i] = &values[i]
+	}
+	for rows.Next() {
+		if err := rows.Scan(dest...); err != nil {
+			return nil, err
+		}
+		metadata := map[string]interface{}{}
+		for i, column := range columns {
+			if !values[i].Valid {
+				continue
+			}
+			match := metadataColumnRegexp.FindStringSubmatch(column)
+			field, key := match[1], match[2]
+			if key == "" {
+				metadata[field] = values[i].String
+				continue
+			}
+			m, _ := metadata[field].(map[string]interface{})
+			if m == nil {
+				m = map[string]interface{}{}
+				metadata[field] = m
+			}
+			m[key] = values[i].String
+		}
+		items = append(items, unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "meta.k8s.io/v1",
+			"kind":       "PartialObjectMetadata",
+			"metadata":   metadata,
+		}})
+	}
+	return items, rows.Err()
+}
+
+func quoteColumns(columns []string) string {
+	quoted := make([]string, len(columns))
+	for i, column := range columns {
+		quoted[i] = `f."` + column + `"`
+	}
+	return strings.Join(quoted, ", ")
+}
+
+// connection returns the handle of the database, which can't write to it. Its connections aren't kept idle, so that
+// metadata is listed from the database recreated by the resets of the cache.
+func (m *MetadataLister) connection() (*sql.DB, error) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if m.conn != nil {
+		return m.conn, nil
+	}
+	conn, err := sql.Open("sqlite", "file:"+m.path+"?mode=rw&_pragma=query_only=1")
+	if err != nil {
+		return nil, err
+	}
+	conn.SetMaxIdleConns(0)
+	m.conn = conn
+	return conn, nil
+}
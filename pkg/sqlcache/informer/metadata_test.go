@@ -0,0 +1,202 @@
+package informer
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func newMetadataListerDB(t *testing.T) *MetadataLister {
+	path := filepath.Join(t.TempDir(), "metadata.db")
+	conn, err := sql.Open("sqlite", "file:"+path+"?mode=rwc&_pragma=journal_mode=wal")
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, stmt := range []string{
+		`CREATE TABLE "_v1_Pod_fields" (key TEXT NOT NULL PRIMARY KEY, "metadata.name" TEXT, "metadata.creationTimestamp" TEXT,
+			"metadata.namespace" TEXT, "metadata.labels[app]" TEXT, "spec.nodeName" TEXT)`,
+		`INSERT INTO "_v1_Pod_fields" VALUES ('a/pod1', 'pod1', '2024-01-01T00:00:00Z', 'a', 'web', 'node1')`,
+		`INSERT INTO "_v1_Pod_fields" VALUES ('a/pod2', 'pod2', '2024-01-02T00:00:00Z', 'a', NULL, 'node2')`,
+		`INSERT INTO "_v1_Pod_fields" VALUES ('b/pod3', 'pod3', '2024-01-03T00:00:00Z', 'b', 'db', 'node1')`,
+	} {
+		_, err := conn.Exec(stmt)
+		require.NoError(t, err)
+	}
+	m := NewMetadataLister(path)
+	return m
+}
+
+func partialObjectMetadata(metadata map[string]interface{}) map[string]interface{} {
+	return map[string]interface{}{
+		"apiVersion": "meta.k8s.io/v1",
+		"kind":       "PartialObjectMetadata",
+		"metadata":   metadata,
+	}
+}
+
+func TestMetadataListerListMetadata(t *testing.T) {
+	m := newMetadataListerDB(t)
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	pod1 := partialObjectMetadata(map[string]interface{}{
+		"name":              "pod1",
+		"namespace":         "a",
+		"creationTimestamp": "2024-01-01T00:00:00Z",
+		"labels":            map[string]interface{}{"app": "web"},
+	})
+	pod2 := partialObjectMetadata(map[string]interface{}{
+		"name":              "pod2",
+		"namespace":         "a",
+		"creationTimestamp": "2024-01-02T00:00:00Z",
+	})
+	pod3 := partialObjectMetadata(map[string]interface{}{
+		"name":              "pod3",
+		"namespace":         "b",
+		"creationTimestamp": "2024-01-03T00:00:00Z",
+		"labels":            map[string]interface{}{"app": "db"},
+	})
+	all := []partition.Partition{{Passthrough: true}}
+
+	tests := []struct {
+		name         string
+		opts         ListOptions
+		partitions   []partition.Partition
+		namespace    string
+		wantItems    []map[string]interface{}
+		wantTotal    int
+		wantContinue string
+		wantErr      error
+	}{
+		{
+			name:       "all",
+			partitions: all,
+			wantItems:  []map[string]interface{}{pod1, pod2, pod3},
+			wantTotal:  3,
+		},
+		{
+			name:       "namespace",
+			partitions: all,
+			namespace:  "b",
+			wantItems:  []map[string]interface{}{pod3},
+			wantTotal:  1,
+		},
+		{
+			name:       "partitions",
+			partitions: []partition.Partition{{Namespace: "a", Names: sets.New("pod2")}, {Namespace: "b", All: true}},
+			wantItems:  []map[string]interface{}{pod2, pod3},
+			wantTotal:  2,
+		},
+		{
+			name:       "no partitions",
+			partitions: []partition.Partition{},
+			wantItems:  []map[string]interface{}{},
+		},
+		{
+			name:       "sorted",
+			opts:       ListOptions{Sort: Sort{PrimaryField: []string{"spec", "nodeName"}, SecondaryField: []string{"metadata", "name"}, SecondaryOrder: DESC}},
+			partitions: all,
+			wantItems:  []map[string]interface{}{pod3, pod1, pod2},
+			wantTotal:  3,
+		},
+		{
+			name:         "paginated",
+			opts:         ListOptions{ChunkSize: 1, Resume: "1"},
+			partitions:   all,
+			wantItems:    []map[string]interface{}{pod2},
+			wantTotal:    3,
+			wantContinue: "2",
+		},
+		{
+			name:       "sorted by a column that doesn't exist",
+			opts:       ListOptions{Sort: Sort{PrimaryField: []string{"spec", "hostname"}}},
+			partitions: all,
+			wantErr:    InvalidColumnErr,
+		},
+		{
+			name:       "filtered",
+			opts:       ListOptions{Filters: []OrFilter{{Filters: []Filter{{Field: []string{"metadata", "name"}, Match: "pod1"}}}}},
+			partitions: all,
+			wantErr:    ErrMetadataFilters,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list, total, continueToken, err := m.ListMetadata(context.Background(), gvk, test.opts, test.partitions, test.namespace)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			items := []map[string]interface{}{}
+			for _, item := range list.Items {
+				items = append(items, item.Object)
+			}
+			assert.Equal(t, test.wantItems, items)
+			assert.Equal(t, test.wantTotal, total)
+			assert.Equal(t, test.wantContinue, continueToken)
+		})
+	}
+
+	_, _, _, err := m.ListMetadata(context.Background(), schema.GroupVersionKind{Version: "v1", Kind: "Secret"}, ListOptions{}, all, "")
+	assert.Error(t, err)
+}
+
+func TestMetadataListerCountStates(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "metadata.db")
+	conn, err := sql.Open("sqlite", "file:"+path+"?mode=rwc&_pragma=journal_mode=wal")
+	require.NoError(t, err)
+	defer conn.Close()
+	for _, stmt := range []string{
+		`CREATE TABLE "_v1_Pod_fields" (key TEXT NOT NULL PRIMARY KEY, "metadata.name" TEXT, "metadata.namespace" TEXT,
+			"metadata.state.error" TEXT, "metadata.state.transitioning" TEXT, "metadata.resourceVersion" TEXT)`,
+		`INSERT INTO "_v1_Pod_fields" VALUES ('a/pod1', 'pod1', 'a', 'false', 'false', '9')`,
+		`INSERT INTO "_v1_Pod_fields" VALUES ('a/pod2', 'pod2', 'a', 'true', 'false', '12')`,
+		`INSERT INTO "_v1_Pod_fields" VALUES ('a/pod3', 'pod3', 'a', 'false', 'false', '3')`,
+		`INSERT INTO "_v1_Pod_fields" VALUES ('b/pod1', 'pod1', 'b', 'false', 'true', '4')`,
+		`INSERT INTO "_v1_Pod_fields" VALUES ('c/pod1', 'pod1', 'c', NULL, NULL, '5')`,
+		`CREATE TABLE "_v1_Node_fields" (key TEXT NOT NULL PRIMARY KEY, "metadata.name" TEXT)`,
+	} {
+		_, err := conn.Exec(stmt)
+		require.NoError(t, err)
+	}
+	m := NewMetadataLister(path)
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	ctx := context.Background()
+
+	_, _, err = m.CountStates(ctx, gvk, []partition.Partition{{Passthrough: true}})
+	assert.Error(t, err, "namespaces aren't cached yet")
+
+	for _, stmt := range []string{
+		`CREATE TABLE "_v1_Namespace_fields" (key TEXT NOT NULL PRIMARY KEY, "metadata.name" TEXT, "metadata.labels[field.cattle.io/projectId]" TEXT)`,
+		`INSERT INTO "_v1_Namespace_fields" VALUES ('a', 'a', 'p-1')`,
+		`INSERT INTO "_v1_Namespace_fields" VALUES ('b', 'b', NULL)`,
+	} {
+		_, err := conn.Exec(stmt)
+		require.NoError(t, err)
+	}
+	counts, revision, err := m.CountStates(ctx, gvk, []partition.Partition{{Passthrough: true}})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []StateCount{
+		{Namespace: "a", Project: "p-1", Count: 2},
+		{Namespace: "a", Project: "p-1", Error: true, Count: 1},
+		{Namespace: "b", Transitioning: true, Count: 1},
+		{Namespace: "c", Count: 1},
+	}, counts)
+	assert.Equal(t, 12, revision)
+
+	counts, revision, err = m.CountStates(ctx, gvk, []partition.Partition{{Namespace: "a", Names: sets.New("pod1")}, {Namespace: "b", All: true}})
+	require.NoError(t, err)
+	assert.ElementsMatch(t, []StateCount{
+		{Namespace: "a", Project: "p-1", Count: 1},
+		{Namespace: "b", Transitioning: true, Count: 1},
+	}, counts)
+	assert.Equal(t, 9, revision)
+
+	_, _, err = m.CountStates(ctx, schema.GroupVersionKind{Version: "v1", Kind: "Node"}, []partition.Partition{{Passthrough: true}})
+	assert.ErrorIs(t, err, InvalidColumnErr, "states aren't indexed")
+}
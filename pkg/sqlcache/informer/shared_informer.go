@@ -0,0 +1,297 @@
+package informer
+
+import (
+	"fmt"
+	"sync"
+	"time"
+
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/client-go/tools/cache"
+	"k8s.io/client-go/tools/cache/synctrack"
+)
+
+// sharedInformer is a cache.SharedIndexInformer writing the objects it lists and watches to the indexer it is created
+// with, such as one storing them in SQLite, and notifying its handlers of them. Unlike the informers of client-go, it
+// never resyncs its handlers.
+type sharedInformer struct {
+	indexer       cache.Indexer
+	listerWatcher cache.ListerWatcher
+	objectType    runtime.Object
+
+	// startedLock guards the fields below it, which can't change once the informer is started
+	startedLock       sync.Mutex
+	started           bool
+	stopped           bool
+	controller        cache.Controller
+	transform         cache.TransformFunc
+	watchErrorHandler cache.WatchErrorHandler
+
+	// blockDeltas keeps deltas from being processed while a handler is added, so that it is notified of each object once
+	blockDeltas sync.Mutex
+	listeners   map[*listener]bool
+}
+
+// newSharedInformer returns an informer writing the objects listed and watched with lw to indexer
+func newSharedInformer(lw cache.ListerWatcher, exampleObject runtime.Object, indexer cache.Indexer) *sharedInformer {
+	return &sharedInformer{
+		indexer:       indexer,
+		listerWatcher: lw,
+		objectType:    exampleObject,
+		listeners:     map[*listener]bool{},
+	}
+}
+
+// Run lists and watches the objects until stopCh is closed, notifying the handlers of them
+func (s *sharedInformer) Run(stopCh <-chan struct{}) {
+	s.startedLock.Lock()
+	if s.started || s.stopped {
+		s.startedLock.Unlock()
+		return
+	}
+	fifo := cache.NewDeltaFIFOWithOptions(cache.DeltaFIFOOptions{
+		KnownObjects:          s.indexer,
+		EmitDeltaTypeReplaced: true,
+		Transformer:           s.transform,
+	})
+	s.controller = cache.New(&cache.Config{
+		Queue:             fifo,
+		ListerWatcher:     s.listerWatcher,
+		ObjectType:        s.objectType,
+		Process:           s.process,
+		WatchErrorHandler: s.watchErrorHandler,
+	})
+	s.started = true
+	s.blockDeltas.Lock()
+	for l := range s.listeners {
+		go l.run()
+	}
+	s.blockDeltas.Unlock()
+	s.startedLock.Unlock()
+
+	s.controller.Run(stopCh)
+
+	s.startedLock.Lock()
+	defer s.startedLock.Unlock()
+	s.stopped = true
+	s.blockDeltas.Lock()
+	defer s.blockDeltas.Unlock()
+	for l := range s.listeners {
+		l.stop()
+	}
+}
+
+// process writes the deltas popped from the queue to the indexer, from oldest to newest, and notifies the handlers
+func (s *sharedInformer) process(obj any, isInInitialList bool) error {
+	deltas, ok := obj.(cache.Deltas)
+	if !ok {
+		return fmt.Errorf("unexpected object of type %T in the queue", obj)
+	}
+	s.blockDeltas.Lock()
+	defer s.blockDeltas.Unlock()
+	for _, d := range deltas {
+		obj := d.Object
+		switch d.Type {
+		case cache.Sync, cache.Replaced, cache.Added, cache.Updated:
+			if old, exists, err := s.indexer.Get(obj); err == nil && exists {
+				if err := s.indexer.Update(obj); err != nil {
+					return err
+				}
+				s.notify(func(h cache.ResourceEventHandler) { h.OnUpdate(old, obj) }, false)
+			} else {
+				if err := s.indexer.Add(obj); err != nil {
+					return err
+				}
+				s.notify(func(h cache.ResourceEventHandler) { h.OnAdd(obj, isInInitialList) }, isInInitialList)
+			}
+		case cache.Deleted:
+			if err := s.indexer.Delete(obj); err != nil {
+				return err
+			}
+			s.notify(func(h cache.ResourceEventHandler) { h.OnDelete(obj) }, false)
+		}
+	}
+	return nil
+}
+
+// notify queues a notification for each handler. It must be called with blockDeltas held.
+func (s *sharedInformer) notify(n func(cache.ResourceEventHandler), isInInitialList bool) {
+	for l := range s.listeners {
+		l.add(n, isInInitialList)
+	}
+}
+
+// AddEventHandler adds a handler, notified of the objects already in the indexer as added in the initial list
+func (s *sharedInformer) AddEventHandler(handler cache.ResourceEventHandler) (cache.ResourceEventHandlerRegistration, error) {
+	s.startedLock.Lock()
+	defer s.startedLock.Unlock()
+	if s.stopped {
+		return nil, fmt.Errorf("handler %v was not added to shared informer because it has stopped already", handler)
+	}
+
+	s.blockDeltas.Lock()
+	defer s.blockDeltas.Unlock()
+	l := newListener(handler, s.HasSynced)
+	s.listeners[l] = true
+	for _, obj := range s.indexer.List() {
+		l.add(func(h cache.ResourceEventHandler) { h.OnAdd(obj, true) }, true)
+	}
+	if s.started {
+		go l.run()
+	}
+	return l, nil
+}
+
+// AddEventHandlerWithResyncPeriod adds a handler, which is never resynced whatever resyncPeriod is
+func (s *sharedInformer) AddEventHandlerWithResyncPeriod(handler cache.ResourceEventHandler, _ time.Duration) (cache.ResourceEventHandlerRegistration, error) {
+	return s.AddEventHandler(handler)
+}
+
+// RemoveEventHandler stops notifying the handler of a registration returned by AddEventHandler
+func (s *sharedInformer) RemoveEventHandler(handle cache.ResourceEventHandlerRegistration) error {
+	l, ok := handle.(*listener)
+	if !ok {
+		return fmt.Errorf("invalid registration of type %T", handle)
+	}
+	s.blockDeltas.Lock()
+	defer s.blockDeltas.Unlock()
+	if s.listeners[l] {
+		delete(s.listeners, l)
+		l.stop()
+	}
+	return nil
+}
+
+func (s *sharedInformer) GetStore() cache.Store {
+	return s.indexer
+}
+
+func (s *sharedInformer) GetIndexer() cache.Indexer {
+	return s.indexer
+}
+
+// GetController returns the controller listing and watching the objects, nil until the informer is run
+func (s *sharedInformer) GetController() cache.Controller {
+	s.startedLock.Lock()
+	defer s.startedLock.Unlock()
+	return s.controller
+}
+
+func (s *sharedInformer) HasSynced() bool {
+	s.startedLock.Lock()
+	defer s.startedLock.Unlock()
+	return s.controller != nil && s.controller.HasSynced()
+}
+
+func (s *sharedInformer) LastSyncResourceVersion() string {
+	s.startedLock.Lock()
+	defer s.startedLock.Unlock()
+	if s.controller == nil {
+		return ""
+	}
+	return s.controller.LastSyncResourceVersion()
+}
+
+func (s *sharedInformer) IsStopped() bool {
+	s.startedLock.Lock()
+	defer s.startedLock.Unlock()
+	return s.stopped
+}
+
+// SetWatchErrorHandler sets the handler of the errors of the watches. It fails once the informer is started.
+func (s *sharedInformer) SetWatchErrorHandler(handler cache.WatchErrorHandler) error {
+	s.startedLock.Lock()
+	defer s.startedLock.Unlock()
+	if s.started {
+		return fmt.Errorf("informer has already started")
+	}
+	s.watchErrorHandler = handler
+	return nil
+}
+
+// SetTransform sets the func transforming the objects before they are written. It fails once the informer is started.
+func (s *sharedInformer) SetTransform(handler cache.TransformFunc) error {
+	s.startedLock.Lock()
+	defer s.startedLock.Unlock()
+	if s.started {
+		return fmt.Errorf("informer has already started")
+	}
+	s.transform = handler
+	return nil
+}
+
+// AddIndexers adds indexers to the indexer. It fails once the informer is started.
+func (s *sharedInformer) AddIndexers(indexers cache.Indexers) error {
+	s.startedLock.Lock()
+	defer s.startedLock.Unlock()
+	if s.started {
+		return fmt.Errorf("informer has already started")
+	}
+	return s.indexer.AddIndexers(indexers)
+}
+
+// listener notifies a handler on a goroutine of its own, in the order the notifications are added
+type listener struct {
+	handler     cache.ResourceEventHandler
+	syncTracker *synctrack.SingleFileTracker
+
+	lock          sync.Mutex
+	cond          *sync.Cond
+	notifications []func()
+	stopped       bool
+}
+
+func newListener(handler cache.ResourceEventHandler, hasSynced func() bool) *listener {
+	l := &listener{
+		handler:     handler,
+		syncTracker: &synctrack.SingleFileTracker{UpstreamHasSynced: hasSynced},
+	}
+	l.cond = sync.NewCond(&l.lock)
+	return l
+}
+
+// add queues a notification, tracked until it is delivered if it is part of the initial list
+func (l *listener) add(n func(cache.ResourceEventHandler), isInInitialList bool) {
+	if isInInitialList {
+		l.syncTracker.Start()
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.notifications = append(l.notifications, func() {
+		n(l.handler)
+		if isInInitialList {
+			l.syncTracker.Finished()
+		}
+	})
+	l.cond.Signal()
+}
+
+// run delivers the notifications until the listener is stopped
+func (l *listener) run() {
+	for {
+		l.lock.Lock()
+		for len(l.notifications) == 0 && !l.stopped {
+			l.cond.Wait()
+		}
+		if l.stopped {
+			l.lock.Unlock()
+			return
+		}
+		n := l.notifications[0]
+		l.notifications[0] = nil
+		l.notifications = l.notifications[1:]
+		l.lock.Unlock()
+		n()
+	}
+}
+
+func (l *listener) stop() {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	l.stopped = true
+	l.cond.Broadcast()
+}
+
+// HasSynced returns true once the informer is synced and the handler was notified of the objects of the initial list
+func (l *listener) HasSynced() bool {
+	return l.syncTracker.HasSynced()
+}
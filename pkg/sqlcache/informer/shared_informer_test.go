@@ -0,0 +1,106 @@
+package informer
+
+import (
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	v1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/client-go/tools/cache"
+	fcache "k8s.io/client-go/tools/cache/testing"
+)
+
+// recorder records the names of the objects a handler is notified of, by event
+type recorder struct {
+	lock   sync.Mutex
+	events []string
+}
+
+func (r *recorder) record(event string, obj any) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	r.events = append(r.events, event+" "+obj.(*v1.Pod).Name)
+}
+
+func (r *recorder) get() []string {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	return append([]string(nil), r.events...)
+}
+
+func (r *recorder) handler() cache.ResourceEventHandler {
+	return cache.ResourceEventHandlerFuncs{
+		AddFunc:    func(obj any) { r.record("add", obj) },
+		UpdateFunc: func(_, obj any) { r.record("update", obj) },
+		DeleteFunc: func(obj any) { r.record("delete", obj) },
+	}
+}
+
+func pod(name, resourceVersion string) *v1.Pod {
+	return &v1.Pod{ObjectMeta: metav1.ObjectMeta{Namespace: "default", Name: name, ResourceVersion: resourceVersion}}
+}
+
+func TestSharedInformer(t *testing.T) {
+	source := fcache.NewFakeControllerSource()
+	source.Add(pod("a", "1"))
+	indexer := cache.NewIndexer(cache.DeletionHandlingMetaNamespaceKeyFunc, cache.Indexers{})
+	informer := newSharedInformer(source, &v1.Pod{}, indexer)
+	require.NoError(t, informer.SetTransform(func(obj any) (any, error) {
+		p := obj.(*v1.Pod).DeepCopy()
+		p.Labels = map[string]string{"transformed": "true"}
+		return p, nil
+	}))
+
+	before := &recorder{}
+	beforeReg, err := informer.AddEventHandler(before.handler())
+	require.NoError(t, err)
+	assert.False(t, beforeReg.HasSynced())
+
+	stop := make(chan struct{})
+	done := make(chan struct{})
+	go func() {
+		informer.Run(stop)
+		close(done)
+	}()
+	require.True(t, cache.WaitForCacheSync(stop, beforeReg.HasSynced))
+	assert.Equal(t, []string{"add a"}, before.get())
+
+	// objects are written to the indexer transformed
+	obj, exists, err := indexer.GetByKey("default/a")
+	require.NoError(t, err)
+	require.True(t, exists)
+	assert.Equal(t, "true", obj.(*v1.Pod).Labels["transformed"])
+
+	// handlers added once started are notified of the objects already in the indexer
+	after := &recorder{}
+	afterReg, err := informer.AddEventHandler(after.handler())
+	require.NoError(t, err)
+	require.True(t, cache.WaitForCacheSync(stop, afterReg.HasSynced))
+	assert.Equal(t, []string{"add a"}, after.get())
+
+	require.NoError(t, informer.RemoveEventHandler(afterReg))
+	source.Modify(pod("a", "2"))
+	source.Add(pod("b", "3"))
+	source.Delete(pod("a", "4"))
+	// the deltas of an object are processed together, those of different objects in any order
+	assert.Eventually(t, func() bool {
+		events := before.get()
+		return assert.ObjectsAreEqual([]string{"add a", "update a", "delete a", "add b"}, events) ||
+			assert.ObjectsAreEqual([]string{"add a", "add b", "update a", "delete a"}, events)
+	}, 5*time.Second, 10*time.Millisecond)
+	assert.Equal(t, []string{"add a"}, after.get())
+	assert.ElementsMatch(t, []string{"default/b"}, indexer.ListKeys())
+	assert.NotEmpty(t, informer.LastSyncResourceVersion())
+
+	// informers can't be set up once started, nor given handlers once stopped
+	assert.Error(t, informer.SetTransform(nil))
+	assert.Error(t, informer.SetWatchErrorHandler(nil))
+	close(stop)
+	<-done
+	assert.True(t, informer.IsStopped())
+	_, err = informer.AddEventHandler(before.handler())
+	assert.Error(t, err)
+}
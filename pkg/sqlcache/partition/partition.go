@@ -0,0 +1,97 @@
+// Package partition represents listing parameters. They can be used to specify which namespaces a caller would like
+// included in a response, or which specific objects they are looking for.
+//
+// Partitions also represent projects, the sets of namespaces whose field.cattle.io/projectId label is the ID of the
+// project. Partitions of projects limit objects to the namespaces of the project with a subquery of the fields table of
+// namespaces, rather than with the names of the namespaces.
+package partition
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+const (
+	// ProjectIDLabel is the label of namespaces holding the ID of their project
+	ProjectIDLabel = "field.cattle.io/projectId"
+
+	// namespaceColumn is the column of the fields table of namespaced types holding the namespace of objects, whose
+	// alias is f in the queries of lists
+	namespaceColumn = `f."metadata.namespace"`
+)
+
+var (
+	// ProjectField is the field of namespaces holding the ID of their project
+	ProjectField = []string{"metadata", "labels[" + ProjectIDLabel + "]"}
+
+	// namespaceGVK is the type of namespaces, from whose fields table the namespaces of projects are read
+	namespaceGVK = schema.GroupVersionKind{Version: "v1", Kind: "Namespace"}
+
+	// NamespaceFieldsTable is the fields table of namespaces, holding their project
+	NamespaceFieldsTable = db.TypeTable(namespaceGVK) + "_fields"
+
+	// projectNamespaces is the query of the names of the namespaces of a project, from the fields table of namespaces,
+	// whose column of the project label is indexed for namespaces
+	projectNamespaces = fmt.Sprintf(`SELECT "metadata.name" FROM "%s" WHERE "%s" = ?`,
+		NamespaceFieldsTable, db.Sanitize(strings.Join(ProjectField, ".")))
+
+	// NamespaceProject is the ID of the project of the namespace of objects in the query of a list, empty if it isn't
+	// in a project, read from the fields table of namespaces
+	NamespaceProject = fmt.Sprintf(`COALESCE((SELECT "%s" FROM "%s" WHERE "metadata.name" = %s), '')`,
+		db.Sanitize(strings.Join(ProjectField, ".")), NamespaceFieldsTable, namespaceColumn)
+)
+
+// Partition represents filtering of a request's results
+type Partition struct {
+	// if true, do not apply any filtering, return all results. Overrides all other fields
+	Passthrough bool
+
+	// if non-empty, only resources in the specified namespaces will be returned
+	Namespace string
+
+	// if non-empty, only resources in the namespaces of the specified project will be returned. Overrides Namespace
+	Project string
+
+	// if true, return all results, while still honoring Namespace. Overrides Names
+	All bool
+
+	// if non-empty, only resources with matching names will be returned
+	Names sets.Set[string]
+}
+
+// Project returns the partition of all objects in the namespaces of a project
+func Project(projectID string) Partition {
+	return Partition{Project: projectID, All: true}
+}
+
+// HasProjects returns true if any of the partitions is that of a project
+func HasProjects(partitions []Partition) bool {
+	for _, p := range partitions {
+		if p.Project != "" && !p.Passthrough {
+			return true
+		}
+	}
+	return false
+}
+
+// NamespaceClause returns the condition of the objects of a namespace in the query of a list, and its params
+func NamespaceClause(namespace string) (string, []any) {
+	return namespaceColumn + " = ?", []any{namespace}
+}
+
+// ScopeClause returns the condition of the objects in the namespaces of the project of a partition, or in its
+// namespace, in the query of a list, and its params. It returns false if the partition isn't limited to namespaces.
+func ScopeClause(p Partition) (string, []any, bool) {
+	if p.Project != "" {
+		return fmt.Sprintf("%s IN (%s)", namespaceColumn, projectNamespaces), []any{p.Project}, true
+	}
+	if p.Namespace != "" && p.Namespace != "*" {
+		clause, params := NamespaceClause(p.Namespace)
+		return clause, params, true
+	}
+	return "", nil, false
+}
@@ -0,0 +1,32 @@
+package partition
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestProject(t *testing.T) {
+	p := Project("p-1")
+	assert.True(t, p.All)
+	assert.Equal(t, "p-1", p.Project)
+	assert.Empty(t, p.Namespace)
+
+	assert.True(t, HasProjects([]Partition{{Namespace: "default"}, p}))
+	assert.False(t, HasProjects([]Partition{{Namespace: "default"}, {Passthrough: true}}))
+}
+
+func TestScopeClause(t *testing.T) {
+	clause, params, ok := ScopeClause(Partition{Namespace: "default"})
+	assert.True(t, ok)
+	assert.Equal(t, `f."metadata.namespace" = ?`, clause)
+	assert.Equal(t, []any{"default"}, params)
+
+	clause, params, ok = ScopeClause(Partition{Project: "p-1", Namespace: "default"})
+	assert.True(t, ok)
+	assert.Equal(t, `f."metadata.namespace" IN (SELECT "metadata.name" FROM "_v1_Namespace_fields" WHERE "metadata.labels[field.cattle.io/projectId]" = ?)`, clause)
+	assert.Equal(t, []any{"p-1"}, params)
+
+	_, _, ok = ScopeClause(Partition{Namespace: "*", All: true})
+	assert.False(t, ok)
+}
@@ -0,0 +1,254 @@
+// Package fallback provides a store which lists objects from a fallback store, typically the Kubernetes API server,
+// while the SQL cache of their type keeps failing, and rebuilds the cache in the background.
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/metrics"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+)
+
+const (
+	// DefaultFailureThreshold is the number of consecutive cache failures after which lists of a type fall back
+	DefaultFailureThreshold = 3
+	// DefaultRetryInterval is how long lists of a type fall back before the cache is tried again
+	DefaultRetryInterval = 30 * time.Second
+)
+
+// ErrCacheUnavailable is returned by lists falling back with params only the SQL cache serves
+var ErrCacheUnavailable = validation.ErrorCode{Code: "CacheUnavailable", Status: http.StatusServiceUnavailable}
+
+var (
+	// cacheOnlyParams are the params of lists only the SQL cache serves. Lists falling back with them are refused, as
+	// the fallback store would ignore them and return other objects than those asked for.
+	cacheOnlyParams = []string{"changesSince", "countOnly", "distinct", "groupBy", "groupLimit", "includeDeleted",
+		"maxPerNamespace", "ownedBy", "project", "revisionMatch"}
+	// ignoredParams are the params of lists only the SQL cache serves which only add to the objects listed. Lists
+	// falling back with them are served without them, with a warning.
+	ignoredParams = []string{"include"}
+)
+
+// State is the state of the circuit breaker of a type
+type State string
+
+const (
+	// Closed types are listed from the cache
+	Closed State = "closed"
+	// Open types are listed from the fallback store
+	Open State = "open"
+	// HalfOpen types are listed from the cache once, to check whether it recovered
+	HalfOpen State = "half-open"
+)
+
+// Rebuilder rebuilds the cache after repeated failures
+type Rebuilder func() error
+
+// Store lists objects from its cache store, unless the cache of their type failed FailureThreshold times in a row, in
+// which case they are listed from the fallback store for RetryInterval, after which the cache is tried again. Other
+// operations are always served by the cache store, which only reads lists from its cache.
+type Store struct {
+	types.Store
+	fallback types.Store
+	rebuild  Rebuilder
+
+	FailureThreshold int
+	RetryInterval    time.Duration
+
+	lock       sync.Mutex
+	breakers   map[string]*breaker
+	rebuilding bool
+	now        func() time.Time
+}
+
+type breaker struct {
+	state    State
+	failures int
+	openedAt time.Time
+}
+
+// NewStore returns a Store listing objects from cache, falling back to fallback. rebuild is called in the background
+// when a type starts falling back, it is optional.
+func NewStore(cache, fallback types.Store, rebuild Rebuilder) *Store {
+	return &Store{
+		Store:            cache,
+		fallback:         fallback,
+		rebuild:          rebuild,
+		FailureThreshold: DefaultFailureThreshold,
+		RetryInterval:    DefaultRetryInterval,
+		breakers:         map[string]*breaker{},
+		now:              time.Now,
+	}
+}
+
+// List lists objects from the cache, or from the fallback store while the cache of the schema's type is failing.
+func (s *Store) List(apiOp *types.APIRequest, schema *types.APISchema) (types.APIObjectList, error) {
+	if !s.useCache(schema.ID) {
+		return s.fallbackList(apiOp, schema)
+	}
+	list, err := s.Store.List(apiOp, schema)
+	if isCacheFailure(err) {
+		if s.failed(schema.ID, err) {
+			return s.fallbackList(apiOp, schema)
+		}
+		return list, err
+	}
+	if errors.Is(err, context.Canceled) {
+		// a canceled request tells nothing about the cache
+		s.aborted(schema.ID)
+		return list, err
+	}
+	s.succeeded(schema.ID)
+	return list, err
+}
+
+// fallbackList lists objects from the fallback store, refusing lists with params only the cache serves
+func (s *Store) fallbackList(apiOp *types.APIRequest, schema *types.APISchema) (types.APIObjectList, error) {
+	var query map[string][]string
+	if apiOp.Request != nil {
+		query = apiOp.Request.URL.Query()
+	}
+	if params := present(query, cacheOnlyParams); len(params) > 0 {
+		return types.APIObjectList{}, apierror.NewAPIError(ErrCacheUnavailable,
+			fmt.Sprintf("the SQL cache of %s is unavailable, and lists with %s can't be served without it", schema.ID, strings.Join(params, ", ")))
+	}
+	list, err := s.fallback.List(apiOp, schema)
+	if err != nil {
+		return list, err
+	}
+	if params := present(query, ignoredParams); len(params) > 0 {
+		list.Warnings = append(list.Warnings, types.Warning{
+			Code:  299,
+			Agent: "steve",
+			Text:  fmt.Sprintf("the SQL cache of %s is unavailable, %s ignored", schema.ID, strings.Join(params, ", ")),
+		})
+	}
+	return list, nil
+}
+
+// present returns the params set in query
+func present(query map[string][]string, params []string) []string {
+	var result []string
+	for _, param := range params {
+		if _, ok := query[param]; ok {
+			result = append(result, param)
+		}
+	}
+	return result
+}
+
+// State returns the state of the circuit breaker of a type
+func (s *Store) State(schemaID string) State {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if b, ok := s.breakers[schemaID]; ok {
+		return b.state
+	}
+	return Closed
+}
+
+// useCache returns whether a type must be listed from the cache, moving open breakers to half-open after RetryInterval
+func (s *Store) useCache(schemaID string) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	b, ok := s.breakers[schemaID]
+	if !ok {
+		return true
+	}
+	switch b.state {
+	case Open:
+		if s.now().Sub(b.openedAt) < s.RetryInterval {
+			return false
+		}
+		// a single request checks whether the cache recovered, the others keep falling back meanwhile
+		b.state = HalfOpen
+		logrus.Infof("Retrying the SQL cache of %s", schemaID)
+		return true
+	case HalfOpen:
+		return false
+	}
+	return true
+}
+
+// failed records a cache failure, returning whether the request should fall back
+func (s *Store) failed(schemaID string, err error) bool {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	b, ok := s.breakers[schemaID]
+	if !ok {
+		b = &breaker{state: Closed}
+		s.breakers[schemaID] = b
+	}
+	b.failures++
+	if b.state == Closed && b.failures < s.FailureThreshold {
+		return false
+	}
+	if b.state != Open {
+		logrus.Warnf("SQL cache of %s failed %d times in a row, listing from the Kubernetes API server: %v", schemaID, b.failures, err)
+		metrics.RecordCacheFallback(schemaID, true)
+	}
+	b.state = Open
+	b.openedAt = s.now()
+	s.rebuildLocked()
+	return true
+}
+
+// aborted records a request which was canceled before the cache answered it, which neither succeeded nor failed. The
+// breaker of a type whose retry was canceled is opened again, so that the next request retries it.
+func (s *Store) aborted(schemaID string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if b, ok := s.breakers[schemaID]; ok && b.state == HalfOpen {
+		b.state = Open
+	}
+}
+
+func (s *Store) succeeded(schemaID string) {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	b, ok := s.breakers[schemaID]
+	if !ok {
+		return
+	}
+	if b.state != Closed {
+		logrus.Infof("SQL cache of %s recovered", schemaID)
+		metrics.RecordCacheFallback(schemaID, false)
+	}
+	delete(s.breakers, schemaID)
+}
+
+// rebuildLocked rebuilds the cache in the background, unless it is already being rebuilt
+func (s *Store) rebuildLocked() {
+	if s.rebuild == nil || s.rebuilding {
+		return
+	}
+	s.rebuilding = true
+	go func() {
+		logrus.Infof("Rebuilding the SQL cache")
+		if err := s.rebuild(); err != nil {
+			logrus.Errorf("Failed to rebuild the SQL cache: %v", err)
+		}
+		s.lock.Lock()
+		defer s.lock.Unlock()
+		s.rebuilding = false
+	}()
+}
+
+// isCacheFailure returns whether err is a failure of the cache, rather than an error of the request such as an
+// invalid filter, or a request which was canceled
+func isCacheFailure(err error) bool {
+	if err == nil || errors.Is(err, context.Canceled) {
+		return false
+	}
+	var apiError *apierror.APIError
+	return !errors.As(err, &apiError)
+}
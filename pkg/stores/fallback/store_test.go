@@ -0,0 +1,211 @@
+package fallback
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	types.Store
+	name  string
+	err   error
+	lists int
+}
+
+func (f *fakeStore) List(apiOp *types.APIRequest, schema *types.APISchema) (types.APIObjectList, error) {
+	f.lists++
+	if f.err != nil {
+		return types.APIObjectList{}, f.err
+	}
+	return types.APIObjectList{Revision: f.name}, nil
+}
+
+func TestStore(t *testing.T) {
+	cache := &fakeStore{name: "cache"}
+	apiServer := &fakeStore{name: "apiserver"}
+	rebuilt := make(chan struct{}, 10)
+	store := NewStore(cache, apiServer, func() error {
+		rebuilt <- struct{}{}
+		return nil
+	})
+	now := time.Unix(0, 0)
+	store.now = func() time.Time { return now }
+	pods := &types.APISchema{Schema: &schemas.Schema{ID: "pod"}}
+	secrets := &types.APISchema{Schema: &schemas.Schema{ID: "secret"}}
+	list := func(schema *types.APISchema) (string, error) {
+		result, err := store.List(&types.APIRequest{}, schema)
+		return result.Revision, err
+	}
+
+	// errors of requests are not failures of the cache
+	cache.err = apierror.NewAPIError(validation.InvalidBodyContent, "invalid filter")
+	for i := 0; i < 5; i++ {
+		_, err := list(pods)
+		assert.Error(t, err)
+	}
+	cache.err = context.Canceled
+	_, err := list(pods)
+	assert.Error(t, err)
+	assert.Equal(t, Closed, store.State("pod"))
+
+	// failures below the threshold are returned
+	cache.err = errors.New("database disk image is malformed")
+	for i := 0; i < DefaultFailureThreshold-1; i++ {
+		_, err := list(pods)
+		assert.Error(t, err)
+	}
+	assert.Equal(t, Closed, store.State("pod"))
+
+	// the failure reaching the threshold falls back and rebuilds the cache
+	revision, err := list(pods)
+	require.NoError(t, err)
+	assert.Equal(t, "apiserver", revision)
+	assert.Equal(t, Open, store.State("pod"))
+	select {
+	case <-rebuilt:
+	case <-time.After(5 * time.Second):
+		t.Fatal("cache was not rebuilt")
+	}
+
+	// other types still use the cache
+	cache.err = nil
+	revision, err = list(secrets)
+	require.NoError(t, err)
+	assert.Equal(t, "cache", revision)
+
+	// the cache isn't tried again before the retry interval
+	cacheLists := cache.lists
+	revision, err = list(pods)
+	require.NoError(t, err)
+	assert.Equal(t, "apiserver", revision)
+	assert.Equal(t, cacheLists, cache.lists)
+
+	// a failed retry falls back again
+	now = now.Add(DefaultRetryInterval)
+	cache.err = errors.New("disk full")
+	revision, err = list(pods)
+	require.NoError(t, err)
+	assert.Equal(t, "apiserver", revision)
+	assert.Equal(t, cacheLists+1, cache.lists)
+	assert.Equal(t, Open, store.State("pod"))
+
+	// a successful retry closes the breaker
+	now = now.Add(DefaultRetryInterval)
+	cache.err = nil
+	revision, err = list(pods)
+	require.NoError(t, err)
+	assert.Equal(t, "cache", revision)
+	assert.Equal(t, Closed, store.State("pod"))
+}
+
+func TestStoreHalfOpen(t *testing.T) {
+	cache := &fakeStore{name: "cache", err: errors.New("disk full")}
+	apiServer := &fakeStore{name: "apiserver"}
+	store := NewStore(cache, apiServer, nil)
+	store.FailureThreshold = 1
+	now := time.Unix(0, 0)
+	store.now = func() time.Time { return now }
+	pods := &types.APISchema{Schema: &schemas.Schema{ID: "pod"}}
+
+	_, err := store.List(&types.APIRequest{}, pods)
+	require.NoError(t, err)
+	require.Equal(t, Open, store.State("pod"))
+
+	// while a request checks the cache, the others fall back
+	now = now.Add(DefaultRetryInterval)
+	assert.True(t, store.useCache("pod"))
+	assert.Equal(t, HalfOpen, store.State("pod"))
+	assert.False(t, store.useCache("pod"))
+}
+
+func TestStoreCanceledRetry(t *testing.T) {
+	cache := &fakeStore{name: "cache", err: errors.New("disk full")}
+	store := NewStore(cache, &fakeStore{name: "apiserver"}, nil)
+	store.FailureThreshold = 1
+	now := time.Unix(0, 0)
+	store.now = func() time.Time { return now }
+	pods := &types.APISchema{Schema: &schemas.Schema{ID: "pod"}}
+
+	_, err := store.List(&types.APIRequest{}, pods)
+	require.NoError(t, err)
+	require.Equal(t, Open, store.State("pod"))
+
+	// a retry which is canceled doesn't close the breaker, the next request retries instead
+	now = now.Add(DefaultRetryInterval)
+	cache.err = fmt.Errorf("listing: %w", context.Canceled)
+	_, err = store.List(&types.APIRequest{}, pods)
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, Open, store.State("pod"))
+
+	cacheLists := cache.lists
+	cache.err = nil
+	result, err := store.List(&types.APIRequest{}, pods)
+	require.NoError(t, err)
+	assert.Equal(t, "cache", result.Revision)
+	assert.Equal(t, cacheLists+1, cache.lists)
+	assert.Equal(t, Closed, store.State("pod"))
+}
+
+func TestStoreCacheOnlyParams(t *testing.T) {
+	store := NewStore(&fakeStore{name: "cache", err: errors.New("disk full")}, &fakeStore{name: "apiserver"}, nil)
+	store.FailureThreshold = 1
+	pods := &types.APISchema{Schema: &schemas.Schema{ID: "pod"}}
+	list := func(query string) (types.APIObjectList, error) {
+		return store.List(&types.APIRequest{Request: httptest.NewRequest(http.MethodGet, "/v1/pods?"+query, nil)}, pods)
+	}
+
+	// lists the fallback store would answer with other objects are refused
+	_, err := list("countOnly=true&ownedBy=ReplicaSet/default/web")
+	var apiError *apierror.APIError
+	require.ErrorAs(t, err, &apiError)
+	assert.Equal(t, ErrCacheUnavailable, apiError.Code)
+	assert.Contains(t, apiError.Message, "countOnly, ownedBy")
+
+	// params only adding to the objects are ignored with a warning
+	result, err := list("include=events")
+	require.NoError(t, err)
+	assert.Equal(t, "apiserver", result.Revision)
+	require.Len(t, result.Warnings, 1)
+	assert.Contains(t, result.Warnings[0].Text, "include ignored")
+
+	result, err = list("filter=metadata.name=web")
+	require.NoError(t, err)
+	assert.Empty(t, result.Warnings)
+}
+
+func TestStoreRebuildsOnce(t *testing.T) {
+	cache := &fakeStore{name: "cache", err: errors.New("disk full")}
+	release := make(chan struct{})
+	var lock sync.Mutex
+	rebuilds := 0
+	store := NewStore(cache, &fakeStore{name: "apiserver"}, func() error {
+		lock.Lock()
+		rebuilds++
+		lock.Unlock()
+		<-release
+		return nil
+	})
+	store.FailureThreshold = 1
+
+	for _, id := range []string{"pod", "secret", "configmap"} {
+		_, err := store.List(&types.APIRequest{}, &types.APISchema{Schema: &schemas.Schema{ID: id}})
+		require.NoError(t, err)
+	}
+	close(release)
+	lock.Lock()
+	defer lock.Unlock()
+	assert.LessOrEqual(t, rebuilds, 1)
+}
@@ -4,6 +4,7 @@ import (
 	"time"
 
 	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
 	"github.com/rancher/steve/pkg/metrics"
 )
 
@@ -22,6 +23,7 @@ func (s *Store) ByID(apiOp *types.APIRequest, schema *types.APISchema, id string
 	storeStart := time.Now()
 	apiObject, err := s.Store.ByID(apiOp, schema, id)
 	m.RecordProxyStoreResponseTime(err, float64(time.Since(storeStart).Milliseconds()))
+	recordRequest(apiOp, storeStart, err, false)
 	return apiObject, err
 }
 
@@ -30,6 +32,7 @@ func (s *Store) List(apiOp *types.APIRequest, schema *types.APISchema) (types.AP
 	storeStart := time.Now()
 	apiObjectList, err := s.Store.List(apiOp, schema)
 	m.RecordProxyStoreResponseTime(err, float64(time.Since(storeStart).Milliseconds()))
+	recordRequest(apiOp, storeStart, err, true)
 	return apiObjectList, err
 }
 
@@ -38,6 +41,7 @@ func (s *Store) Create(apiOp *types.APIRequest, schema *types.APISchema, data ty
 	storeStart := time.Now()
 	apiObject, err := s.Store.Create(apiOp, schema, data)
 	m.RecordProxyStoreResponseTime(err, float64(time.Since(storeStart).Milliseconds()))
+	recordRequest(apiOp, storeStart, err, false)
 	return apiObject, err
 }
 
@@ -46,6 +50,7 @@ func (s *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, data ty
 	storeStart := time.Now()
 	apiObject, err := s.Store.Update(apiOp, schema, data, id)
 	m.RecordProxyStoreResponseTime(err, float64(time.Since(storeStart).Milliseconds()))
+	recordRequest(apiOp, storeStart, err, false)
 	return apiObject, err
 }
 
@@ -54,6 +59,7 @@ func (s *Store) Delete(apiOp *types.APIRequest, schema *types.APISchema, id stri
 	storeStart := time.Now()
 	apiObject, err := s.Store.Delete(apiOp, schema, id)
 	m.RecordProxyStoreResponseTime(err, float64(time.Since(storeStart).Milliseconds()))
+	recordRequest(apiOp, storeStart, err, false)
 	return apiObject, err
 }
 
@@ -62,5 +68,40 @@ func (s *Store) Watch(apiOp *types.APIRequest, schema *types.APISchema, w types.
 	storeStart := time.Now()
 	apiEvent, err := s.Store.Watch(apiOp, schema, w)
 	m.RecordProxyStoreResponseTime(err, float64(time.Since(storeStart).Milliseconds()))
-	return apiEvent, err
+	if err != nil || apiEvent == nil {
+		return apiEvent, err
+	}
+	return watchWithStats(schema, apiEvent), nil
+}
+
+// recordRequest records the request of a user to the store, with its query if it lists objects
+func recordRequest(apiOp *types.APIRequest, start time.Time, err error, list bool) {
+	req := metrics.Request{
+		Resource: apiOp.Schema.ID,
+		Method:   apiOp.Method,
+		Duration: time.Since(start),
+		Err:      err,
+	}
+	if apiOp.Request != nil {
+		req.User = apiOp.GetUser()
+		if list {
+			req.Query = apiOp.Request.URL.Query()
+		}
+	}
+	metrics.Requests.Record(req)
+}
+
+// watchWithStats forwards the events of a watch, recording its statistics
+func watchWithStats(schema *types.APISchema, events chan types.APIEvent) chan types.APIEvent {
+	result := make(chan types.APIEvent)
+	metrics.Watches.Started(schema.ID, attributes.GVK(schema))
+	go func() {
+		defer close(result)
+		defer metrics.Watches.Stopped(schema.ID)
+		for event := range events {
+			metrics.Watches.Delivered(schema.ID, len(events))
+			result <- event
+		}
+	}()
+	return result
 }
@@ -0,0 +1,36 @@
+package proxy
+
+import (
+	"fmt"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// DeleteOptions returns the options of a DELETE request, decoded from its query parameters: propagationPolicy,
+// gracePeriodSeconds, dryRun, and the uid and resourceVersion preconditions, which fail the delete with a conflict if
+// the object was replaced or changed since it was read.
+func DeleteOptions(apiOp *types.APIRequest) (metav1.DeleteOptions, error) {
+	opts := metav1.DeleteOptions{}
+	if err := decodeParams(apiOp, &opts); err != nil {
+		return opts, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("invalid delete options: %v", err))
+	}
+	if policy := opts.PropagationPolicy; policy != nil {
+		switch *policy {
+		case metav1.DeletePropagationOrphan, metav1.DeletePropagationBackground, metav1.DeletePropagationForeground:
+		default:
+			return opts, apierror.NewFieldAPIError(validation.InvalidOption, "propagationPolicy",
+				fmt.Sprintf("propagationPolicy must be %s, %s or %s", metav1.DeletePropagationOrphan,
+					metav1.DeletePropagationBackground, metav1.DeletePropagationForeground))
+		}
+		if opts.OrphanDependents != nil {
+			return opts, apierror.NewAPIError(validation.InvalidOption, "orphanDependents and propagationPolicy are mutually exclusive")
+		}
+	}
+	if opts.GracePeriodSeconds != nil && *opts.GracePeriodSeconds < 0 {
+		return opts, apierror.NewFieldAPIError(validation.InvalidOption, "gracePeriodSeconds", "gracePeriodSeconds must not be negative")
+	}
+	return opts, nil
+}
@@ -0,0 +1,77 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apitypes "k8s.io/apimachinery/pkg/types"
+)
+
+func TestDeleteOptions(t *testing.T) {
+	foreground := metav1.DeletePropagationForeground
+	gracePeriod := int64(0)
+	uid := apitypes.UID("0a2c1d52-6b5e-4f4e-9b3a-7c1f2e8d9a10")
+	resourceVersion := "42"
+	tests := []struct {
+		name      string
+		query     string
+		want      metav1.DeleteOptions
+		wantError bool
+	}{
+		{
+			name: "defaults",
+		},
+		{
+			name:  "cascading delete",
+			query: "?propagationPolicy=Foreground&gracePeriodSeconds=0",
+			want: metav1.DeleteOptions{
+				PropagationPolicy:  &foreground,
+				GracePeriodSeconds: &gracePeriod,
+			},
+		},
+		{
+			name:  "preconditions",
+			query: "?uid=" + string(uid) + "&resourceVersion=42",
+			want: metav1.DeleteOptions{
+				Preconditions: &metav1.Preconditions{UID: &uid, ResourceVersion: &resourceVersion},
+			},
+		},
+		{
+			name:      "unknown propagation policy",
+			query:     "?propagationPolicy=Cascade",
+			wantError: true,
+		},
+		{
+			name:      "orphanDependents with a propagation policy",
+			query:     "?propagationPolicy=Orphan&orphanDependents=true",
+			wantError: true,
+		},
+		{
+			name:      "negative grace period",
+			query:     "?gracePeriodSeconds=-1",
+			wantError: true,
+		},
+		{
+			name:      "invalid grace period",
+			query:     "?gracePeriodSeconds=soon",
+			wantError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodDelete, "/v1/apps.deployments/default/web"+test.query, nil)
+			opts, err := DeleteOptions(&types.APIRequest{Request: req})
+			if test.wantError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.want, opts)
+		})
+	}
+}
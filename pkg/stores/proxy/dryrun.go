@@ -0,0 +1,14 @@
+package proxy
+
+import (
+	"github.com/rancher/apiserver/pkg/types"
+)
+
+// IsDryRun returns whether a create, update, patch or delete request sets the dryRun query parameter, which has
+// Kubernetes validate and admit the change, returning the resulting object, without persisting it
+func IsDryRun(apiOp *types.APIRequest) bool {
+	if apiOp.Request == nil {
+		return false
+	}
+	return len(apiOp.Request.URL.Query()["dryRun"]) > 0
+}
@@ -0,0 +1,29 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIsDryRun(t *testing.T) {
+	tests := []struct {
+		name  string
+		query string
+		want  bool
+	}{
+		{name: "no dry run", query: "", want: false},
+		{name: "dry run", query: "?dryRun=All", want: true},
+		{name: "other params", query: "?fieldManager=ui", want: false},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPut, "/v1/secrets/default/s"+test.query, nil)
+			assert.Equal(t, test.want, IsDryRun(&types.APIRequest{Request: req}))
+		})
+	}
+	assert.False(t, IsDryRun(&types.APIRequest{}), "requests without an HTTP request aren't dry runs")
+}
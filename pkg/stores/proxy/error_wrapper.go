@@ -20,41 +20,42 @@ func NewErrorStore(s types.Store) *ErrorStore {
 // ByID looks up a single object by its ID.
 func (e *ErrorStore) ByID(apiOp *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
 	data, err := e.Store.ByID(apiOp, schema, id)
-	return data, translateError(err)
+	return data, TranslateError(err)
 }
 
 // List returns a list of resources.
 func (e *ErrorStore) List(apiOp *types.APIRequest, schema *types.APISchema) (types.APIObjectList, error) {
 	data, err := e.Store.List(apiOp, schema)
-	return data, translateError(err)
+	return data, TranslateError(err)
 }
 
 // Create creates a single object in the store.
 func (e *ErrorStore) Create(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject) (types.APIObject, error) {
 	data, err := e.Store.Create(apiOp, schema, data)
-	return data, translateError(err)
+	return data, TranslateError(err)
 }
 
 // Update updates a single object in the store.
 func (e *ErrorStore) Update(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject, id string) (types.APIObject, error) {
 	data, err := e.Store.Update(apiOp, schema, data, id)
-	return data, translateError(err)
+	return data, TranslateError(err)
 }
 
 // Delete deletes an object from a store.
 func (e *ErrorStore) Delete(apiOp *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
 	data, err := e.Store.Delete(apiOp, schema, id)
-	return data, translateError(err)
+	return data, TranslateError(err)
 
 }
 
 // Watch returns a channel of events for a list or resource.
 func (e *ErrorStore) Watch(apiOp *types.APIRequest, schema *types.APISchema, wr types.WatchRequest) (chan types.APIEvent, error) {
 	data, err := e.Store.Watch(apiOp, schema, wr)
-	return data, translateError(err)
+	return data, TranslateError(err)
 }
 
-func translateError(err error) error {
+// TranslateError translates Kubernetes API status errors into APIErrors, leaving other errors untouched
+func TranslateError(err error) error {
 	if apiError, ok := err.(errors.APIStatus); ok {
 		status := apiError.Status()
 		return apierror.NewAPIError(validation.ErrorCode{
@@ -0,0 +1,69 @@
+package proxy
+
+import (
+	"encoding/json"
+	"fmt"
+	"mime"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	apitypes "k8s.io/apimachinery/pkg/types"
+)
+
+// DefaultFieldManager is the field manager of server-side applies which don't set one, unless stores are created with
+// another one
+const DefaultFieldManager = "steve"
+
+// patchTypes are the patch types accepted by PATCH requests, by content type
+var patchTypes = map[string]apitypes.PatchType{
+	string(apitypes.JSONPatchType):           apitypes.JSONPatchType,
+	string(apitypes.MergePatchType):          apitypes.MergePatchType,
+	string(apitypes.StrategicMergePatchType): apitypes.StrategicMergePatchType,
+	string(apitypes.ApplyPatchType):          apitypes.ApplyPatchType,
+}
+
+// Patch returns the type, body and options of the patch of a PATCH request. The type is that of the content type of
+// the request, strategic merge patch if it isn't a patch type. Options are decoded from the query parameters, such as
+// fieldManager, force and dryRun. Server-side applies default to fieldManager, or DefaultFieldManager if it is empty,
+// and force can only be set for them, to take over the fields managed by other field managers instead of failing with
+// a conflict.
+func Patch(apiOp *types.APIRequest, body []byte, fieldManager string) (apitypes.PatchType, []byte, metav1.PatchOptions, error) {
+	pType := apitypes.StrategicMergePatchType
+	if mediaType, _, err := mime.ParseMediaType(apiOp.Request.Header.Get("content-type")); err == nil {
+		if t, ok := patchTypes[mediaType]; ok {
+			pType = t
+		}
+	}
+
+	opts := metav1.PatchOptions{}
+	if err := decodeParams(apiOp, &opts); err != nil {
+		return "", nil, opts, err
+	}
+	if pType == apitypes.ApplyPatchType {
+		if opts.FieldManager == "" {
+			opts.FieldManager = fieldManager
+			if opts.FieldManager == "" {
+				opts.FieldManager = DefaultFieldManager
+			}
+		}
+	} else if opts.Force != nil {
+		return "", nil, opts, apierror.NewAPIError(validation.InvalidOption,
+			fmt.Sprintf("force is only supported by server-side apply, with content type %s", apitypes.ApplyPatchType))
+	}
+
+	if pType == apitypes.StrategicMergePatchType || pType == apitypes.MergePatchType {
+		data := map[string]interface{}{}
+		if err := json.Unmarshal(body, &data); err != nil {
+			return "", nil, opts, err
+		}
+		data = moveFromUnderscore(data)
+		var err error
+		body, err = json.Marshal(data)
+		if err != nil {
+			return "", nil, opts, err
+		}
+	}
+	return pType, body, opts, nil
+}
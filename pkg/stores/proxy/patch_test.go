@@ -0,0 +1,104 @@
+package proxy
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apitypes "k8s.io/apimachinery/pkg/types"
+)
+
+func TestPatch(t *testing.T) {
+	force := true
+	tests := []struct {
+		name             string
+		contentType      string
+		query            string
+		body             string
+		defaultManager   string
+		wantType         apitypes.PatchType
+		wantBody         string
+		wantFieldManager string
+		wantForce        *bool
+		wantError        bool
+	}{
+		{
+			name:     "strategic merge patch by default",
+			body:     `{"_type": "Opaque", "data": {"a": "b"}}`,
+			wantType: apitypes.StrategicMergePatchType,
+			wantBody: `{"type": "Opaque", "data": {"a": "b"}}`,
+		},
+		{
+			name:        "json patch",
+			contentType: "application/json-patch+json",
+			body:        `[{"op": "remove", "path": "/data/a"}]`,
+			wantType:    apitypes.JSONPatchType,
+			wantBody:    `[{"op": "remove", "path": "/data/a"}]`,
+		},
+		{
+			name:        "merge patch",
+			contentType: "application/merge-patch+json; charset=utf-8",
+			body:        `{"data": {"a": null}}`,
+			wantType:    apitypes.MergePatchType,
+			wantBody:    `{"data": {"a": null}}`,
+		},
+		{
+			name:             "server-side apply with the default field manager",
+			contentType:      "application/apply-patch+yaml",
+			body:             "data:\n  a: b\n",
+			wantType:         apitypes.ApplyPatchType,
+			wantBody:         "data:\n  a: b\n",
+			wantFieldManager: DefaultFieldManager,
+		},
+		{
+			name:             "server-side apply with a configured default field manager",
+			contentType:      "application/apply-patch+yaml",
+			body:             "data:\n  a: b\n",
+			defaultManager:   "rancher",
+			wantType:         apitypes.ApplyPatchType,
+			wantBody:         "data:\n  a: b\n",
+			wantFieldManager: "rancher",
+		},
+		{
+			name:             "forced server-side apply with a field manager",
+			contentType:      "application/apply-patch+yaml",
+			query:            "?fieldManager=ui&force=true",
+			body:             "data:\n  a: b\n",
+			wantType:         apitypes.ApplyPatchType,
+			wantBody:         "data:\n  a: b\n",
+			wantFieldManager: "ui",
+			wantForce:        &force,
+		},
+		{
+			name:      "force without server-side apply",
+			query:     "?force=true",
+			body:      `{"data": {"a": "b"}}`,
+			wantError: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodPatch, "/v1/secrets/default/s"+test.query, nil)
+			if test.contentType != "" {
+				req.Header.Set("Content-Type", test.contentType)
+			}
+			pType, body, opts, err := Patch(&types.APIRequest{Request: req}, []byte(test.body), test.defaultManager)
+			if test.wantError {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.wantType, pType)
+			if pType == apitypes.ApplyPatchType {
+				assert.Equal(t, test.wantBody, string(body), "applied manifests are sent as they are")
+			} else {
+				assert.JSONEq(t, test.wantBody, string(body))
+			}
+			assert.Equal(t, test.wantFieldManager, opts.FieldManager)
+			assert.Equal(t, test.wantForce, opts.Force)
+		})
+	}
+}
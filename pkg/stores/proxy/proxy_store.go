@@ -3,7 +3,6 @@ package proxy
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -19,7 +18,6 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
-	apitypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
@@ -37,6 +35,7 @@ import (
 	"github.com/rancher/steve/pkg/attributes"
 	metricsStore "github.com/rancher/steve/pkg/stores/metrics"
 	"github.com/rancher/steve/pkg/stores/partition"
+	"github.com/rancher/steve/pkg/tracing"
 )
 
 const (
@@ -90,10 +89,12 @@ type RelationshipNotifier interface {
 type Store struct {
 	clientGetter ClientGetter
 	notifier     RelationshipNotifier
+	fieldManager string
 }
 
-// NewProxyStore returns a wrapped types.Store.
-func NewProxyStore(clientGetter ClientGetter, notifier RelationshipNotifier, lookup accesscontrol.AccessSetLookup, namespaceCache corecontrollers.NamespaceCache) types.Store {
+// NewProxyStore returns a wrapped types.Store. Server-side applies which don't set a field manager are made with
+// fieldManager, or DefaultFieldManager if it is empty.
+func NewProxyStore(clientGetter ClientGetter, notifier RelationshipNotifier, lookup accesscontrol.AccessSetLookup, namespaceCache corecontrollers.NamespaceCache, fieldManager string) types.Store {
 	return &ErrorStore{
 		Store: &unformatterStore{
 			Store: &WatchRefresh{
@@ -102,6 +103,7 @@ func NewProxyStore(clientGetter ClientGetter, notifier RelationshipNotifier, loo
 						proxyStore: &Store{
 							clientGetter: clientGetter,
 							notifier:     notifier,
+							fieldManager: fieldManager,
 						},
 					},
 					lookup,
@@ -115,6 +117,9 @@ func NewProxyStore(clientGetter ClientGetter, notifier RelationshipNotifier, loo
 
 // ByID looks up a single object by its ID.
 func (s *Store) ByID(apiOp *types.APIRequest, schema *types.APISchema, id string) (*unstructured.Unstructured, []types.Warning, error) {
+	apiOp, span := tracing.StartStore(apiOp, "proxy.ByID", schema)
+	defer span.End()
+
 	return s.byID(apiOp, schema, apiOp.Namespace, id)
 }
 
@@ -241,6 +246,9 @@ func (s *Store) ByNames(apiOp *types.APIRequest, schema *types.APISchema, names
 
 // List returns an unstructured list of resources.
 func (s *Store) List(apiOp *types.APIRequest, schema *types.APISchema) (*unstructured.UnstructuredList, []types.Warning, error) {
+	apiOp, span := tracing.StartStore(apiOp, "proxy.List", schema)
+	defer span.End()
+
 	buffer := WarningBuffer{}
 	client, err := s.clientGetter.TableClient(apiOp, schema, apiOp.Namespace, &buffer)
 	if err != nil {
@@ -418,6 +426,9 @@ func (s *Store) watch(apiOp *types.APIRequest, schema *types.APISchema, w types.
 
 // Create creates a single object in the store.
 func (s *Store) Create(apiOp *types.APIRequest, schema *types.APISchema, params types.APIObject) (*unstructured.Unstructured, []types.Warning, error) {
+	apiOp, span := tracing.StartStore(apiOp, "proxy.Create", schema)
+	defer span.End()
+
 	var (
 		resp *unstructured.Unstructured
 	)
@@ -466,6 +477,9 @@ func (s *Store) Create(apiOp *types.APIRequest, schema *types.APISchema, params
 
 // Update updates a single object in the store.
 func (s *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, params types.APIObject, id string) (*unstructured.Unstructured, []types.Warning, error) {
+	apiOp, span := tracing.StartStore(apiOp, "proxy.Update", schema)
+	defer span.End()
+
 	var (
 		err   error
 		input = params.Data()
@@ -484,28 +498,11 @@ func (s *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, params
 			return nil, nil, err
 		}
 
-		pType := apitypes.StrategicMergePatchType
-		if apiOp.Request.Header.Get("content-type") == string(apitypes.JSONPatchType) {
-			pType = apitypes.JSONPatchType
-		}
-
-		opts := metav1.PatchOptions{}
-		if err := decodeParams(apiOp, &opts); err != nil {
+		pType, bytes, opts, err := Patch(apiOp, bytes, s.fieldManager)
+		if err != nil {
 			return nil, nil, err
 		}
 
-		if pType == apitypes.StrategicMergePatchType {
-			data := map[string]interface{}{}
-			if err := json.Unmarshal(bytes, &data); err != nil {
-				return nil, nil, err
-			}
-			data = moveFromUnderscore(data)
-			bytes, err = json.Marshal(data)
-			if err != nil {
-				return nil, nil, err
-			}
-		}
-
 		resp, err := k8sClient.Patch(apiOp, id, pType, bytes, opts)
 		if err != nil {
 			return nil, nil, err
@@ -527,7 +524,7 @@ func (s *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, params
 		return nil, nil, err
 	}
 
-	resp, err := k8sClient.Update(apiOp, &unstructured.Unstructured{Object: moveFromUnderscore(input)}, metav1.UpdateOptions{})
+	resp, err := k8sClient.Update(apiOp, &unstructured.Unstructured{Object: moveFromUnderscore(input)}, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -536,11 +533,14 @@ func (s *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, params
 	return resp, buffer, nil
 }
 
-// Delete deletes an object from a store.
+// Delete deletes an object from a store. Dry-run deletes return the object, which isn't deleted.
 func (s *Store) Delete(apiOp *types.APIRequest, schema *types.APISchema, id string) (*unstructured.Unstructured, []types.Warning, error) {
-	opts := metav1.DeleteOptions{}
-	if err := decodeParams(apiOp, &opts); err != nil {
-		return nil, nil, nil
+	apiOp, span := tracing.StartStore(apiOp, "proxy.Delete", schema)
+	defer span.End()
+
+	opts, err := DeleteOptions(apiOp)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	buffer := WarningBuffer{}
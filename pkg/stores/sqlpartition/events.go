@@ -0,0 +1,116 @@
+package sqlpartition
+
+import (
+	"net/url"
+	"sort"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	includeParam = "include"
+	// eventsField is the field of listed objects holding their recent events, when they are included with
+	// include=events. It is a top level field, so that include=events combined with other included fields keeps it.
+	eventsField = "events"
+	// eventsPerObject is the number of events included with each object, the most recent first
+	eventsPerObject = 5
+	// eventsBatchSize bounds the number of objects whose events are listed in a single query
+	eventsBatchSize = 500
+)
+
+// includesEvents returns whether a list request includes the recent events of the listed objects
+func includesEvents(apiOp *types.APIRequest) bool {
+	if apiOp.Request == nil {
+		return false
+	}
+	for _, include := range apiOp.Request.URL.Query()[includeParam] {
+		if include == eventsField {
+			return true
+		}
+	}
+	return false
+}
+
+// addEvents adds the most recent events the user can list of each object to its events field. Events are listed from
+// the cache by the UID of the object they involve, in batches.
+func (s *Store) addEvents(apiOp *types.APIRequest, schema *types.APISchema, items []unstructured.Unstructured) error {
+	eventSchema := apiOp.Schemas.LookupSchema("event")
+	if eventSchema == nil || schema.ID == eventSchema.ID || len(items) == 0 {
+		return nil
+	}
+	eventOp := apiOp.Clone()
+	eventOp.Schema = eventSchema
+	eventOp.Type = eventSchema.ID
+	eventOp.Namespace = ""
+	eventOp.Name = ""
+	partitions, err := s.Partitioner.All(eventOp, eventSchema, "list", "")
+	if err != nil {
+		return err
+	}
+
+	eventsByUID := map[string][]map[string]interface{}{}
+	for start := 0; start < len(items); start += eventsBatchSize {
+		end := min(start+eventsBatchSize, len(items))
+		uids := make([]string, 0, end-start)
+		for _, item := range items[start:end] {
+			if uid := string(item.GetUID()); uid != "" {
+				uids = append(uids, uid)
+			}
+		}
+		if len(uids) == 0 {
+			continue
+		}
+		eventOp.Request = apiOp.Request.Clone(apiOp.Context())
+		eventOp.Request.URL.RawQuery = url.Values{
+			"filter": []string{"involvedObject.uid in (" + strings.Join(uids, ",") + ")"},
+		}.Encode()
+		events, _, _, err := s.Partitioner.Store().ListByPartitions(eventOp, eventSchema, partitions)
+		if err != nil {
+			return err
+		}
+		for _, event := range events {
+			uid, _, _ := unstructured.NestedString(event.Object, "involvedObject", "uid")
+			eventsByUID[uid] = append(eventsByUID[uid], summarizeEvent(event.Object))
+		}
+	}
+
+	for i := range items {
+		events := eventsByUID[string(items[i].GetUID())]
+		sort.SliceStable(events, func(a, b int) bool {
+			return events[a]["lastTimestamp"].(string) > events[b]["lastTimestamp"].(string)
+		})
+		if len(events) > eventsPerObject {
+			events = events[:eventsPerObject]
+		}
+		result := make([]interface{}, 0, len(events))
+		for _, event := range events {
+			result = append(result, event)
+		}
+		items[i].Object[eventsField] = result
+	}
+	return nil
+}
+
+// summarizeEvent returns the fields of an event shown with the objects it involves. lastTimestamp falls back to the
+// time of events which were only recorded with the events.k8s.io API, or which occurred once.
+func summarizeEvent(event map[string]interface{}) map[string]interface{} {
+	obj := data.Object(event)
+	lastTimestamp := obj.String("lastTimestamp")
+	if lastTimestamp == "" {
+		lastTimestamp = obj.String("eventTime")
+	}
+	if lastTimestamp == "" {
+		lastTimestamp = obj.String("metadata", "creationTimestamp")
+	}
+	count, _, _ := unstructured.NestedInt64(event, "count")
+	return map[string]interface{}{
+		"type":          obj.String("type"),
+		"reason":        obj.String("reason"),
+		"message":       obj.String("message"),
+		"count":         count,
+		"lastTimestamp": lastTimestamp,
+	}
+}
@@ -0,0 +1,74 @@
+package sqlpartition
+
+import (
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestListIncludesEvents(t *testing.T) {
+	p := NewMockPartitioner(gomock.NewController(t))
+	us := NewMockUnstructuredStore(gomock.NewController(t))
+	s := Store{
+		Partitioner: p,
+	}
+	podSchema := &types.APISchema{Schema: &schemas.Schema{ID: "pod"}}
+	eventSchema := &types.APISchema{Schema: &schemas.Schema{ID: "event"}}
+	apiSchemas := types.EmptyAPISchemas()
+	apiSchemas.MustAddSchema(*podSchema)
+	apiSchemas.MustAddSchema(*eventSchema)
+	req := &types.APIRequest{
+		Request: httptest.NewRequest("GET", "/v1/pods?include=events", nil),
+		Schemas: apiSchemas,
+	}
+	pod := func(name, uid string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"metadata": map[string]interface{}{"name": name, "namespace": "default", "uid": uid},
+		}}
+	}
+	event := func(uid, reason, lastTimestamp string) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"involvedObject": map[string]interface{}{"uid": uid},
+			"type":           "Warning",
+			"reason":         reason,
+			"message":        reason + " happened",
+			"count":          int64(2),
+			"lastTimestamp":  lastTimestamp,
+		}}
+	}
+	events := []unstructured.Unstructured{
+		event("1", "Pulled", "2024-01-01T00:00:00Z"),
+		event("1", "BackOff", "2024-01-02T00:00:00Z"),
+	}
+	for i := 0; i < eventsPerObject; i++ {
+		events = append(events, event("2", "Old", "2023-01-01T00:00:00Z"))
+	}
+	partitions := []partition.Partition{{All: true}}
+
+	p.EXPECT().All(req, podSchema, "list", "").Return(partitions, nil)
+	p.EXPECT().All(gomock.Any(), apiSchemas.LookupSchema("event"), "list", "").Return(partitions, nil)
+	p.EXPECT().Store().Return(us).Times(2)
+	us.EXPECT().ListByPartitions(req, podSchema, partitions).Return([]unstructured.Unstructured{pod("web", "1"), pod("db", "2"), pod("quiet", "3")}, 3, "", nil)
+	us.EXPECT().ListByPartitions(gomock.Any(), apiSchemas.LookupSchema("event"), partitions).DoAndReturn(
+		func(apiOp *types.APIRequest, _ *types.APISchema, _ []partition.Partition) ([]unstructured.Unstructured, int, string, error) {
+			assert.Equal(t, []string{"involvedObject.uid in (1,2,3)"}, apiOp.Request.URL.Query()["filter"])
+			return events, len(events), "", nil
+		})
+
+	list, err := s.List(req, podSchema)
+	require.NoError(t, err)
+	require.Len(t, list.Objects, 3)
+	assert.Equal(t, []interface{}{
+		map[string]interface{}{"type": "Warning", "reason": "BackOff", "message": "BackOff happened", "count": int64(2), "lastTimestamp": "2024-01-02T00:00:00Z"},
+		map[string]interface{}{"type": "Warning", "reason": "Pulled", "message": "Pulled happened", "count": int64(2), "lastTimestamp": "2024-01-01T00:00:00Z"},
+	}, list.Objects[0].Data()[eventsField])
+	assert.Len(t, list.Objects[1].Data()[eventsField], eventsPerObject)
+	assert.Equal(t, []interface{}{}, list.Objects[2].Data()[eventsField])
+}
@@ -0,0 +1,29 @@
+package listprocessor
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+)
+
+const changesSinceParam = "changesSince"
+
+// ParseChangesSince returns the revision set by the changesSince query param, which lists the changes made to objects
+// since that revision instead of the objects, and whether it is set. Revisions must be numeric to be compared.
+func ParseChangesSince(apiOp *types.APIRequest) (uint64, bool, error) {
+	if apiOp.Request == nil {
+		return 0, false, nil
+	}
+	query := apiOp.Request.URL.Query()
+	if !query.Has(changesSinceParam) {
+		return 0, false, nil
+	}
+	since, err := strconv.ParseUint(query.Get(changesSinceParam), 10, 64)
+	if err != nil {
+		return 0, true, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("%s must be numeric", changesSinceParam))
+	}
+	return since, true, nil
+}
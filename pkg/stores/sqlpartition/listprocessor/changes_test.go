@@ -0,0 +1,58 @@
+package listprocessor
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseChangesSince(t *testing.T) {
+	tests := []struct {
+		description string
+		query       string
+		wantSince   uint64
+		wantOK      bool
+		wantErr     bool
+	}{
+		{
+			description: "ParseChangesSince() without changesSince should return false.",
+		},
+		{
+			description: "ParseChangesSince() with a numeric changesSince should return it.",
+			query:       "changesSince=42",
+			wantSince:   42,
+			wantOK:      true,
+		},
+		{
+			description: "ParseChangesSince() with changesSince 0 should return all the changes kept.",
+			query:       "changesSince=0",
+			wantOK:      true,
+		},
+		{
+			description: "ParseChangesSince() with a non numeric changesSince should return an error.",
+			query:       "changesSince=abc",
+			wantOK:      true,
+			wantErr:     true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			req := &types.APIRequest{
+				Request: &http.Request{
+					URL: &url.URL{RawQuery: test.query},
+				},
+			}
+			since, ok, err := ParseChangesSince(req)
+			if test.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.Equal(t, test.wantSince, since)
+			assert.Equal(t, test.wantOK, ok)
+		})
+	}
+}
@@ -0,0 +1,78 @@
+package listprocessor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const distinctParam = "distinct"
+
+// DistinctValue is a value of a field and the number of objects having it
+type DistinctValue struct {
+	Value string `json:"value"`
+	Count int    `json:"count"`
+}
+
+// DistinctCache is implemented by caches counting the distinct values of a field themselves, rather than listing the
+// objects for Distinct to count them
+type DistinctCache interface {
+	// DistinctByOptions returns the distinct values of a field among the objects matching the filters of lo and
+	// belonging to any of the partitions and to namespace, if set, ordered as Distinct orders them
+	DistinctByOptions(ctx context.Context, lo informer.ListOptions, field []string, partitions []partition.Partition, namespace string) ([]DistinctValue, error)
+}
+
+// ParseDistinct returns the fields listed in the distinct query param of a request, e.g.
+// 'distinct=spec.nodeName,metadata.labels[app]'.
+func ParseDistinct(apiOp *types.APIRequest) [][]string {
+	var result [][]string
+	for _, field := range strings.Split(apiOp.Request.URL.Query().Get(distinctParam), ",") {
+		if field = strings.TrimSpace(field); field != "" {
+			result = append(result, SplitField(field))
+		}
+	}
+	return result
+}
+
+// Distinct returns, for each of the fields, the distinct values found in items and how many items have each of them,
+// most common values first. Items where a field is missing or not a scalar value are not counted for that field.
+func Distinct(items []unstructured.Unstructured, fields [][]string) map[string][]DistinctValue {
+	result := make(map[string][]DistinctValue, len(fields))
+	for _, field := range fields {
+		counts := map[string]int{}
+		for _, item := range items {
+			value, ok := fieldValue(item.Object, field)
+			if !ok {
+				continue
+			}
+			switch value.(type) {
+			case string, bool, int, int64, float64:
+				counts[fmt.Sprint(value)]++
+			}
+		}
+
+		values := make([]DistinctValue, 0, len(counts))
+		for value, count := range counts {
+			values = append(values, DistinctValue{Value: value, Count: count})
+		}
+		sort.Slice(values, func(i, j int) bool {
+			if values[i].Count != values[j].Count {
+				return values[i].Count > values[j].Count
+			}
+			return values[i].Value < values[j].Value
+		})
+		result[DistinctKey(field)] = values
+	}
+	return result
+}
+
+// DistinctKey returns the key of the values of a field in the result of Distinct
+func DistinctKey(field []string) string {
+	return strings.Join(field, ".")
+}
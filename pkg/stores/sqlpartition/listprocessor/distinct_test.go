@@ -0,0 +1,66 @@
+package listprocessor
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseDistinct(t *testing.T) {
+	req := &types.APIRequest{
+		Request: &http.Request{
+			URL: &url.URL{RawQuery: "distinct=spec.nodeName,+metadata.labels[app.kubernetes.io/name],"},
+		},
+	}
+	assert.Equal(t, [][]string{{"spec", "nodeName"}, {"metadata", "labels[app.kubernetes.io/name]"}}, ParseDistinct(req))
+
+	req.Request.URL.RawQuery = ""
+	assert.Nil(t, ParseDistinct(req))
+}
+
+func TestDistinct(t *testing.T) {
+	newItem := func(node, app string, ready bool) unstructured.Unstructured {
+		obj := map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"labels": map[string]interface{}{},
+				"fields": []interface{}{"name", ready},
+			},
+			"spec": map[string]interface{}{},
+		}
+		if node != "" {
+			obj["spec"].(map[string]interface{})["nodeName"] = node
+		}
+		if app != "" {
+			obj["metadata"].(map[string]interface{})["labels"].(map[string]interface{})["app"] = app
+		}
+		return unstructured.Unstructured{Object: obj}
+	}
+	items := []unstructured.Unstructured{
+		newItem("node1", "web", true),
+		newItem("node2", "web", false),
+		newItem("node2", "db", true),
+		newItem("", "", true),
+	}
+
+	expected := map[string][]DistinctValue{
+		"spec.nodeName": {
+			{Value: "node2", Count: 2},
+			{Value: "node1", Count: 1},
+		},
+		"metadata.labels[app]": {
+			{Value: "web", Count: 2},
+			{Value: "db", Count: 1},
+		},
+		"metadata.fields[1]": {
+			{Value: "true", Count: 3},
+			{Value: "false", Count: 1},
+		},
+		"status.phase": {},
+	}
+	fields := [][]string{{"spec", "nodeName"}, {"metadata", "labels[app]"}, {"metadata", "fields[1]"}, {"status", "phase"}}
+	assert.Equal(t, expected, Distinct(items, fields))
+}
@@ -0,0 +1,48 @@
+package listprocessor
+
+import (
+	"fmt"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apimachinery/pkg/fields"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+const fieldSelectorParam = "fieldSelector"
+
+// parseFieldSelector translates a Kubernetes field selector (e.g. "spec.nodeName=foo,status.phase!=Running") into
+// filters for the SQL cache. Each requirement of the selector must hold, so every one of them becomes its own OrFilter.
+// Fields are matched exactly against the indexed column of the same name; selectors on fields that are not indexed
+// are rejected by the cache when the query is executed.
+func parseFieldSelector(selector string) ([]informer.OrFilter, error) {
+	parsed, err := fields.ParseSelector(selector)
+	if err != nil {
+		return nil, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("invalid %s [%s]: %v", fieldSelectorParam, selector, err))
+	}
+
+	var filters []informer.OrFilter
+	for _, req := range parsed.Requirements() {
+		var op informer.Op
+		switch req.Operator {
+		case selection.Equals, selection.DoubleEquals:
+			op = informer.Eq
+		case selection.NotEquals:
+			op = informer.NotEq
+		default:
+			return nil, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("unsupported operator [%s] in %s", req.Operator, fieldSelectorParam))
+		}
+		filters = append(filters, informer.OrFilter{
+			Filters: []informer.Filter{
+				{
+					Field:   SplitField(req.Field),
+					Match:   req.Value,
+					Op:      op,
+					Partial: false,
+				},
+			},
+		})
+	}
+	return filters, nil
+}
@@ -0,0 +1,77 @@
+package listprocessor
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	groupByParam    = "groupBy"
+	groupLimitParam = "groupLimit"
+)
+
+// parseGroupBy returns the sort grouping results by the groupBy query param. Any sort field requested alongside
+// becomes the secondary sort within groups.
+func parseGroupBy(groupBy string, sort informer.Sort) (informer.Sort, error) {
+	if len(sort.SecondaryField) > 0 {
+		return sort, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("%s cannot be combined with more than one sort field", groupByParam))
+	}
+	result := informer.Sort{
+		SecondaryField: sort.PrimaryField,
+		SecondaryOrder: sort.PrimaryOrder,
+	}
+	if groupBy[0] == '-' {
+		result.PrimaryOrder = informer.DESC
+		groupBy = groupBy[1:]
+	}
+	result.PrimaryField = SplitField(groupBy)
+	return result, nil
+}
+
+// ParseGroupLimit returns the maximum number of objects to return per group, as set by the groupLimit query param, or
+// 0 if unlimited. Objects are grouped by the field set by the groupBy query param.
+func ParseGroupLimit(apiOp *types.APIRequest) (int, error) {
+	q := apiOp.Request.URL.Query()
+	groupLimit := q.Get(groupLimitParam)
+	if groupLimit == "" {
+		return 0, nil
+	}
+	if q.Get(groupByParam) == "" {
+		return 0, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("%s requires %s", groupLimitParam, groupByParam))
+	}
+	limit, err := strconv.Atoi(groupLimit)
+	if err != nil || limit < 1 {
+		return 0, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("%s must be a positive integer", groupLimitParam))
+	}
+	return limit, nil
+}
+
+// LimitGroups returns at most limit items per group, items being grouped by the value of field and sorted by it.
+func LimitGroups(items []unstructured.Unstructured, field []string, limit int) []unstructured.Unstructured {
+	if limit <= 0 {
+		return items
+	}
+
+	var result []unstructured.Unstructured
+	var group interface{}
+	count := 0
+	for i, item := range items {
+		value, _ := fieldValue(item.Object, field)
+		if i == 0 || !reflect.DeepEqual(value, group) {
+			group = value
+			count = 0
+		}
+		count++
+		if count <= limit {
+			result = append(result, item)
+		}
+	}
+	return result
+}
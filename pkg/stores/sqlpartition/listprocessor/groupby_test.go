@@ -0,0 +1,76 @@
+package listprocessor
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseGroupLimit(t *testing.T) {
+	tests := []struct {
+		description string
+		query       string
+		expected    int
+		errExpected bool
+	}{
+		{
+			description: "ParseGroupLimit() without a group limit should return 0.",
+			query:       "groupBy=metadata.namespace",
+		},
+		{
+			description: "ParseGroupLimit() with a group limit should return it.",
+			query:       "groupBy=metadata.namespace&groupLimit=5",
+			expected:    5,
+		},
+		{
+			description: "ParseGroupLimit() with a group limit but no groupBy should return an error.",
+			query:       "groupLimit=5",
+			errExpected: true,
+		},
+		{
+			description: "ParseGroupLimit() with an invalid group limit should return an error.",
+			query:       "groupBy=metadata.namespace&groupLimit=0",
+			errExpected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			req := &types.APIRequest{
+				Request: &http.Request{
+					URL: &url.URL{RawQuery: test.query},
+				},
+			}
+			limit, err := ParseGroupLimit(req)
+			if test.errExpected {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, limit)
+		})
+	}
+}
+
+func TestLimitGroups(t *testing.T) {
+	var items []unstructured.Unstructured
+	for _, namespace := range []string{"a", "a", "a", "b", "c", "c"} {
+		items = append(items, unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"metadata": map[string]interface{}{
+					"namespace": namespace,
+				},
+			},
+		})
+	}
+
+	var namespaces []string
+	for _, item := range LimitGroups(items, []string{"metadata", "namespace"}, 2) {
+		namespaces = append(namespaces, item.GetNamespace())
+	}
+	assert.Equal(t, []string{"a", "a", "b", "c", "c"}, namespaces)
+	assert.Equal(t, items, LimitGroups(items, []string{"metadata", "namespace"}, 0))
+}
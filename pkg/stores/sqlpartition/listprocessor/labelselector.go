@@ -0,0 +1,70 @@
+package listprocessor
+
+import (
+	"fmt"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/apimachinery/pkg/selection"
+)
+
+const labelSelectorParam = "labelSelector"
+
+// parseLabelSelector translates a Kubernetes label selector (e.g. "app=web,tier in (front,back),!canary") into filters
+// for the SQL cache, following the semantics of Kubernetes. Each requirement of the selector must hold, so every one of
+// them becomes its own OrFilter, except for notin sets which exclude each of their values with an OrFilter per value.
+// Labels are matched exactly, against the labels the cache keeps for each object whether they are indexed or not, so
+// that a missing label is told from an empty one. Comparisons of labels with > and < are range filters, which compare
+// labels as numbers.
+func parseLabelSelector(selector string) ([]informer.OrFilter, error) {
+	requirements, err := labelRequirements(selector)
+	if err != nil {
+		return nil, err
+	}
+
+	var filters []informer.OrFilter
+	for _, req := range requirements {
+		field := labelField(req.Key())
+		values := req.Values().List()
+		switch req.Operator() {
+		case selection.Equals, selection.DoubleEquals, selection.In:
+			orFilter := informer.OrFilter{}
+			for _, value := range values {
+				orFilter.Filters = append(orFilter.Filters, informer.Filter{Field: field, Match: value, Op: informer.Eq})
+			}
+			filters = append(filters, orFilter)
+		case selection.NotEquals, selection.NotIn:
+			// objects without the label match too
+			for _, value := range values {
+				filters = append(filters, informer.OrFilter{Filters: []informer.Filter{{Field: field, Match: value, Op: informer.NotEq}}})
+			}
+		case selection.Exists:
+			filters = append(filters, informer.OrFilter{Filters: []informer.Filter{{Field: field, Op: informer.Exists}}})
+		case selection.DoesNotExist:
+			filters = append(filters, informer.OrFilter{Filters: []informer.Filter{{Field: field, Op: informer.NotExists}}})
+		case selection.GreaterThan:
+			filters = append(filters, informer.OrFilter{Filters: []informer.Filter{{Field: field, Match: values[0], Op: Gt}}})
+		case selection.LessThan:
+			filters = append(filters, informer.OrFilter{Filters: []informer.Filter{{Field: field, Match: values[0], Op: Lt}}})
+		}
+	}
+	return filters, nil
+}
+
+func labelRequirements(selector string) (labels.Requirements, error) {
+	if selector == "" {
+		return nil, nil
+	}
+	parsed, err := labels.Parse(selector)
+	if err != nil {
+		return nil, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("invalid %s [%s]: %v", labelSelectorParam, selector, err))
+	}
+	requirements, _ := parsed.Requirements()
+	return requirements, nil
+}
+
+func labelField(key string) []string {
+	return SplitField("metadata.labels[" + key + "]")
+}
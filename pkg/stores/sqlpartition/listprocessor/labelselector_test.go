@@ -0,0 +1,94 @@
+package listprocessor
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseQueryLabelSelector(t *testing.T) {
+	label := func(key string) []string {
+		return []string{"metadata", "labels[" + key + "]"}
+	}
+	tests := []struct {
+		description     string
+		query           url.Values
+		expectedFilters []informer.OrFilter
+		errExpected     bool
+	}{
+		{
+			description: "ParseQuery() with a labelSelector param should include one exact match filter per equality.",
+			query:       url.Values{"labelSelector": {"app=web,tier==front,app.kubernetes.io/name!=db"}},
+			expectedFilters: []informer.OrFilter{
+				{Filters: []informer.Filter{{Field: label("app"), Match: "web", Op: informer.Eq}}},
+				{Filters: []informer.Filter{{Field: label("app.kubernetes.io/name"), Match: "db", Op: informer.NotEq}}},
+				{Filters: []informer.Filter{{Field: label("tier"), Match: "front", Op: informer.Eq}}},
+			},
+		},
+		{
+			description: "ParseQuery() with set-based requirements in a labelSelector param should OR the values of" +
+				" an in set and exclude each value of a notin set.",
+			query: url.Values{"labelSelector": {"tier in (front,back),env notin (dev,test)"}},
+			expectedFilters: []informer.OrFilter{
+				{Filters: []informer.Filter{{Field: label("env"), Match: "dev", Op: informer.NotEq}}},
+				{Filters: []informer.Filter{{Field: label("env"), Match: "test", Op: informer.NotEq}}},
+				{Filters: []informer.Filter{
+					{Field: label("tier"), Match: "back", Op: informer.Eq},
+					{Field: label("tier"), Match: "front", Op: informer.Eq},
+				}},
+			},
+		},
+		{
+			description: "ParseQuery() with existence requirements in a labelSelector param should match set and" +
+				" unset labels.",
+			query: url.Values{"labelSelector": {"app,!canary"}},
+			expectedFilters: []informer.OrFilter{
+				{Filters: []informer.Filter{{Field: label("app"), Op: informer.Exists}}},
+				{Filters: []informer.Filter{{Field: label("canary"), Op: informer.NotExists}}},
+			},
+		},
+		{
+			description: "ParseQuery() with comparisons in a labelSelector param should return range filters.",
+			query:       url.Values{"labelSelector": {"app=web,replicas>2,priority<10"}},
+			expectedFilters: []informer.OrFilter{
+				{Filters: []informer.Filter{{Field: label("app"), Match: "web", Op: informer.Eq}}},
+				{Filters: []informer.Filter{{Field: label("priority"), Match: "10", Op: Lt}}},
+				{Filters: []informer.Filter{{Field: label("replicas"), Match: "2", Op: Gt}}},
+			},
+		},
+		{
+			description: "ParseQuery() with a labelSelector param and a filter param should AND both.",
+			query:       url.Values{"labelSelector": {"app=web"}, "filter": {"metadata.name=foo"}},
+			expectedFilters: []informer.OrFilter{
+				{Filters: []informer.Filter{{Field: []string{"metadata", "name"}, Match: "foo", Partial: true}}},
+				{Filters: []informer.Filter{{Field: label("app"), Match: "web", Op: informer.Eq}}},
+			},
+		},
+		{
+			description: "ParseQuery() with a malformed labelSelector param should return an error.",
+			query:       url.Values{"labelSelector": {"app in (web"}},
+			errExpected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			req := &types.APIRequest{
+				Request: &http.Request{
+					URL: &url.URL{RawQuery: test.query.Encode()},
+				},
+			}
+			opts, err := ParseQuery(req, ParseOptions{})
+			if test.errExpected {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedFilters, opts.Filters)
+		})
+	}
+}
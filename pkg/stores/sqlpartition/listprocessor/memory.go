@@ -0,0 +1,160 @@
+package listprocessor
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const includeDeletedParam = "includeDeleted"
+
+// ParseIncludeDeleted returns true if the request asks for recently deleted objects to be listed along with existing
+// ones.
+func ParseIncludeDeleted(apiOp *types.APIRequest) bool {
+	return apiOp.Request.URL.Query().Get(includeDeletedParam) == "true"
+}
+
+// MatchesFilters returns true if obj matches the filters the same way the SQL cache would: at least one filter of
+// each OrFilter must match, values are matched case-insensitively and compared by range filters as the types of their
+// columns.
+func MatchesFilters(obj unstructured.Unstructured, filters []informer.OrFilter, types ColumnTypes) bool {
+	for _, orFilter := range filters {
+		if len(orFilter.Filters) == 0 {
+			continue
+		}
+		matches := false
+		for _, filter := range orFilter.Filters {
+			if matchesFilter(obj, filter, types) {
+				matches = true
+				break
+			}
+		}
+		if !matches {
+			return false
+		}
+	}
+	return true
+}
+
+func matchesFilter(obj unstructured.Unstructured, filter informer.Filter, types ColumnTypes) bool {
+	if IsRangeFilter(filter) {
+		return matchesRange(obj, filter, types)
+	}
+	// the cache tells missing labels from empty ones, while other missing fields are empty values
+	_, isLabel := informer.LabelKey(filter.Field)
+	present := StringValue(obj, filter.Field) != ""
+	if isLabel {
+		_, present = fieldValue(obj.Object, filter.Field)
+	}
+	switch filter.Op {
+	case informer.Exists:
+		return present
+	case informer.NotExists:
+		return !present
+	}
+	if isLabel && !present {
+		return filter.Op == informer.NotEq
+	}
+	value := strings.ToLower(StringValue(obj, filter.Field))
+	match := strings.ToLower(filter.Match)
+	var result bool
+	if filter.Partial {
+		result = strings.Contains(value, match)
+	} else {
+		result = value == match
+	}
+	if filter.Op == informer.NotEq {
+		return !result
+	}
+	return result
+}
+
+// FilterItems returns the items matching the filters, as MatchesFilters matches them.
+func FilterItems(items []unstructured.Unstructured, filters []informer.OrFilter, types ColumnTypes) []unstructured.Unstructured {
+	if len(filters) == 0 {
+		return items
+	}
+	var filtered []unstructured.Unstructured
+	for _, item := range items {
+		if MatchesFilters(item, filters, types) {
+			filtered = append(filtered, item)
+		}
+	}
+	return filtered
+}
+
+// SortItems sorts items in place the same way the SQL cache would according to sortOpts, by namespace and name if
+// sortOpts is empty. Values are compared as the types of their columns, values which aren't of the type of a number
+// or date column sorting first.
+func SortItems(items []unstructured.Unstructured, sortOpts informer.Sort, types ColumnTypes) {
+	type sortKey struct {
+		field []string
+		order informer.SortOrder
+	}
+	var keys []sortKey
+	if len(sortOpts.PrimaryField) > 0 {
+		keys = append(keys, sortKey{field: sortOpts.PrimaryField, order: sortOpts.PrimaryOrder})
+	}
+	if len(sortOpts.SecondaryField) > 0 {
+		keys = append(keys, sortKey{field: sortOpts.SecondaryField, order: sortOpts.SecondaryOrder})
+	}
+	if len(keys) == 0 {
+		keys = []sortKey{{field: []string{"metadata", "namespace"}}, {field: []string{"metadata", "name"}}}
+	}
+
+	sort.SliceStable(items, func(i, j int) bool {
+		for _, key := range keys {
+			result := CompareForSort(types.Of(key.field), StringValue(items[i], key.field), StringValue(items[j], key.field))
+			if result == 0 {
+				continue
+			}
+			if key.order == informer.DESC {
+				return result > 0
+			}
+			return result < 0
+		}
+		// objects sorting equally are sorted by their key, unique for each object, as the SQL cache sorts them
+		return objectKey(items[i]) < objectKey(items[j])
+	})
+}
+
+// CompareForSort compares the values of a field as the SQL cache sorts them, as the type of its column, values which
+// aren't of it sorting first. Missing fields have empty values.
+func CompareForSort(typ ColumnType, a, b string) int {
+	if typ == TextColumn {
+		return strings.Compare(a, b)
+	}
+	_, aOK := compareAs(typ, a, a)
+	_, bOK := compareAs(typ, b, b)
+	switch {
+	case aOK && bOK:
+		result, _ := compareAs(typ, a, b)
+		return result
+	case aOK:
+		return 1
+	case bOK:
+		return -1
+	}
+	return strings.Compare(a, b)
+}
+
+// objectKey returns the key of an object in the SQL cache, its namespace and name
+func objectKey(obj unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
+
+// StringValue returns the value of a field as stored by the SQL cache, empty if missing
+func StringValue(obj unstructured.Unstructured, field []string) string {
+	value, ok := fieldValue(obj.Object, field)
+	if !ok || value == nil {
+		return ""
+	}
+	return fmt.Sprint(value)
+}
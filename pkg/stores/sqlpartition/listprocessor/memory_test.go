@@ -0,0 +1,116 @@
+package listprocessor
+
+import (
+	"testing"
+
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func newMemoryTestItem(namespace, name, app string) unstructured.Unstructured {
+	item := unstructured.Unstructured{Object: map[string]interface{}{}}
+	item.SetNamespace(namespace)
+	item.SetName(name)
+	if app != "" {
+		item.SetLabels(map[string]string{"app": app})
+	}
+	return item
+}
+
+func TestMatchesFilters(t *testing.T) {
+	item := newMemoryTestItem("default", "Web-1", "web")
+	tests := []struct {
+		description string
+		filters     []informer.OrFilter
+		expected    bool
+	}{
+		{
+			description: "no filters match everything",
+			expected:    true,
+		},
+		{
+			description: "partial matches are case-insensitive substrings",
+			filters:     []informer.OrFilter{{Filters: []informer.Filter{{Field: []string{"metadata", "name"}, Match: "web", Partial: true}}}},
+			expected:    true,
+		},
+		{
+			description: "exact matches need the whole value",
+			filters:     []informer.OrFilter{{Filters: []informer.Filter{{Field: []string{"metadata", "name"}, Match: "web"}}}},
+			expected:    false,
+		},
+		{
+			description: "one filter of an or filter must match",
+			filters: []informer.OrFilter{{Filters: []informer.Filter{
+				{Field: []string{"metadata", "name"}, Match: "db", Partial: true},
+				{Field: []string{"metadata", "labels[app]"}, Match: "web"},
+			}}},
+			expected: true,
+		},
+		{
+			description: "all or filters must match",
+			filters: []informer.OrFilter{
+				{Filters: []informer.Filter{{Field: []string{"metadata", "namespace"}, Match: "default"}}},
+				{Filters: []informer.Filter{{Field: []string{"metadata", "labels[app]"}, Match: "web", Op: informer.NotEq}}},
+			},
+			expected: false,
+		},
+		{
+			description: "missing fields are empty",
+			filters:     []informer.OrFilter{{Filters: []informer.Filter{{Field: []string{"spec", "nodeName"}, Match: ""}}}},
+			expected:    true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			assert.Equal(t, test.expected, MatchesFilters(item, test.filters, nil))
+		})
+	}
+}
+
+func TestSortItems(t *testing.T) {
+	newItems := func() []unstructured.Unstructured {
+		return []unstructured.Unstructured{
+			newMemoryTestItem("b", "x", "web"),
+			newMemoryTestItem("a", "y", "db"),
+			newMemoryTestItem("a", "x", "web"),
+		}
+	}
+	names := func(items []unstructured.Unstructured) []string {
+		var result []string
+		for _, item := range items {
+			result = append(result, item.GetNamespace()+"/"+item.GetName())
+		}
+		return result
+	}
+
+	items := newItems()
+	SortItems(items, informer.Sort{}, nil)
+	assert.Equal(t, []string{"a/x", "a/y", "b/x"}, names(items))
+
+	items = newItems()
+	SortItems(items, informer.Sort{
+		PrimaryField:   []string{"metadata", "labels[app]"},
+		PrimaryOrder:   informer.DESC,
+		SecondaryField: []string{"metadata", "namespace"},
+	}, nil)
+	assert.Equal(t, []string{"a/x", "b/x", "a/y"}, names(items))
+
+	// objects sorting equally are sorted by key, regardless of their order
+	items = newItems()
+	SortItems(items, informer.Sort{PrimaryField: []string{"metadata", "name"}}, nil)
+	assert.Equal(t, []string{"a/x", "b/x", "a/y"}, names(items))
+
+	items = []unstructured.Unstructured{
+		{Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "a"}, "value": int64(9)}},
+		{Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "b"}, "value": int64(10)}},
+		{Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "c"}}},
+	}
+	sortOpts := informer.Sort{PrimaryField: []string{"value"}, PrimaryOrder: informer.DESC}
+	// values which aren't numbers sort first, and therefore last in descending order
+	SortItems(items, sortOpts, ColumnTypes{"value": NumberColumn})
+	assert.Equal(t, []string{"/b", "/a", "/c"}, names(items))
+	// values of columns without a type are compared as text
+	SortItems(items, sortOpts, nil)
+	assert.Equal(t, []string{"/a", "/b", "/c"}, names(items))
+}
@@ -0,0 +1,49 @@
+package listprocessor
+
+import (
+	"mime"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const (
+	partialObjectMetadataList    = "PartialObjectMetadataList"
+	partialObjectMetadataGroup   = "meta.k8s.io"
+	partialObjectMetadataVersion = "v1"
+)
+
+// ParseMetadataOnly returns true if the request only asks for the metadata of objects, as PartialObjectMetadata
+// objects, which it does as clients of Kubernetes do, accepting
+// application/json;as=PartialObjectMetadataList;g=meta.k8s.io;v=v1.
+func ParseMetadataOnly(apiOp *types.APIRequest) bool {
+	for _, accept := range strings.Split(apiOp.Request.Header.Get("Accept"), ",") {
+		_, params, err := mime.ParseMediaType(accept)
+		if err != nil {
+			continue
+		}
+		if params["as"] == partialObjectMetadataList && params["g"] == partialObjectMetadataGroup &&
+			params["v"] == partialObjectMetadataVersion {
+			return true
+		}
+	}
+	return false
+}
+
+// ToPartialObjectMetadata returns the metadata of items, as PartialObjectMetadata objects.
+func ToPartialObjectMetadata(items []unstructured.Unstructured) []unstructured.Unstructured {
+	result := make([]unstructured.Unstructured, 0, len(items))
+	for _, item := range items {
+		metadata, _, _ := unstructured.NestedFieldNoCopy(item.Object, "metadata")
+		if metadata == nil {
+			metadata = map[string]interface{}{}
+		}
+		result = append(result, unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": partialObjectMetadataGroup + "/" + partialObjectMetadataVersion,
+			"kind":       "PartialObjectMetadata",
+			"metadata":   metadata,
+		}})
+	}
+	return result
+}
@@ -0,0 +1,87 @@
+package listprocessor
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseMetadataOnly(t *testing.T) {
+	tests := []struct {
+		description string
+		accept      string
+		expected    bool
+	}{
+		{
+			description: "ParseMetadataOnly() without an Accept header should return false.",
+		},
+		{
+			description: "ParseMetadataOnly() accepting JSON should return false.",
+			accept:      "application/json",
+		},
+		{
+			description: "ParseMetadataOnly() accepting a PartialObjectMetadataList should return true.",
+			accept:      "application/json;as=PartialObjectMetadataList;g=meta.k8s.io;v=v1",
+			expected:    true,
+		},
+		{
+			description: "ParseMetadataOnly() accepting a PartialObjectMetadataList or JSON should return true.",
+			accept:      "application/json;as=PartialObjectMetadataList;g=meta.k8s.io;v=v1, application/json",
+			expected:    true,
+		},
+		{
+			description: "ParseMetadataOnly() accepting a Table should return false.",
+			accept:      "application/json;as=Table;g=meta.k8s.io;v=v1",
+		},
+		{
+			description: "ParseMetadataOnly() accepting a PartialObjectMetadataList of another version should return false.",
+			accept:      "application/json;as=PartialObjectMetadataList;g=meta.k8s.io;v=v1beta1",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			req := &types.APIRequest{
+				Request: &http.Request{
+					Header: http.Header{},
+				},
+			}
+			if test.accept != "" {
+				req.Request.Header.Set("Accept", test.accept)
+			}
+			assert.Equal(t, test.expected, ParseMetadataOnly(req))
+		})
+	}
+}
+
+func TestToPartialObjectMetadata(t *testing.T) {
+	items := []unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Pod",
+			"metadata": map[string]interface{}{
+				"name":   "pod1",
+				"labels": map[string]interface{}{"app": "web"},
+			},
+			"spec": map[string]interface{}{"nodeName": "node1"},
+		}},
+		{Object: map[string]interface{}{}},
+	}
+	assert.Equal(t, []unstructured.Unstructured{
+		{Object: map[string]interface{}{
+			"apiVersion": "meta.k8s.io/v1",
+			"kind":       "PartialObjectMetadata",
+			"metadata": map[string]interface{}{
+				"name":   "pod1",
+				"labels": map[string]interface{}{"app": "web"},
+			},
+		}},
+		{Object: map[string]interface{}{
+			"apiVersion": "meta.k8s.io/v1",
+			"kind":       "PartialObjectMetadata",
+			"metadata":   map[string]interface{}{},
+		}},
+	}, ToPartialObjectMetadata(items))
+}
@@ -0,0 +1,46 @@
+package listprocessor
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+const maxPerNamespaceParam = "maxPerNamespace"
+
+// ParseMaxPerNamespace returns the maximum number of objects to return per namespace, as set by the maxPerNamespace
+// query param, or 0 if unlimited.
+func ParseMaxPerNamespace(apiOp *types.APIRequest) (int, error) {
+	maxPerNamespace := apiOp.Request.URL.Query().Get(maxPerNamespaceParam)
+	if maxPerNamespace == "" {
+		return 0, nil
+	}
+	limit, err := strconv.Atoi(maxPerNamespace)
+	if err != nil || limit < 1 {
+		return 0, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("%s must be a positive integer", maxPerNamespaceParam))
+	}
+	return limit, nil
+}
+
+// LimitPerNamespace returns at most limit items per namespace, keeping the first ones of each namespace in the order
+// of items.
+func LimitPerNamespace(items []unstructured.Unstructured, limit int) []unstructured.Unstructured {
+	if limit <= 0 {
+		return items
+	}
+
+	var result []unstructured.Unstructured
+	counts := map[string]int{}
+	for _, item := range items {
+		namespace := item.GetNamespace()
+		counts[namespace]++
+		if counts[namespace] <= limit {
+			result = append(result, item)
+		}
+	}
+	return result
+}
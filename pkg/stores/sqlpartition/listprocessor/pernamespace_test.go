@@ -0,0 +1,72 @@
+package listprocessor
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseMaxPerNamespace(t *testing.T) {
+	tests := []struct {
+		description string
+		query       string
+		expected    int
+		errExpected bool
+	}{
+		{
+			description: "ParseMaxPerNamespace() without a limit should return 0.",
+		},
+		{
+			description: "ParseMaxPerNamespace() with a limit should return it.",
+			query:       "maxPerNamespace=3",
+			expected:    3,
+		},
+		{
+			description: "ParseMaxPerNamespace() with a negative limit should return an error.",
+			query:       "maxPerNamespace=-1",
+			errExpected: true,
+		},
+		{
+			description: "ParseMaxPerNamespace() with a non-numeric limit should return an error.",
+			query:       "maxPerNamespace=all",
+			errExpected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			req := &types.APIRequest{
+				Request: &http.Request{
+					URL: &url.URL{RawQuery: test.query},
+				},
+			}
+			limit, err := ParseMaxPerNamespace(req)
+			if test.errExpected {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, limit)
+		})
+	}
+}
+
+func TestLimitPerNamespace(t *testing.T) {
+	var items []unstructured.Unstructured
+	for i, namespace := range []string{"a", "b", "a", "a", "c", "b", "a"} {
+		item := unstructured.Unstructured{Object: map[string]interface{}{}}
+		item.SetNamespace(namespace)
+		item.SetName(string(rune('0' + i)))
+		items = append(items, item)
+	}
+
+	var names []string
+	for _, item := range LimitPerNamespace(items, 2) {
+		names = append(names, item.GetNamespace()+"/"+item.GetName())
+	}
+	assert.Equal(t, []string{"a/0", "b/1", "a/2", "c/4", "b/5"}, names)
+	assert.Equal(t, items, LimitPerNamespace(items, 0))
+}
@@ -10,8 +10,11 @@ import (
 
 	"github.com/rancher/apiserver/pkg/apierror"
 	"github.com/rancher/apiserver/pkg/types"
-	"github.com/rancher/lasso/pkg/cache/sql/informer"
-	"github.com/rancher/lasso/pkg/cache/sql/partition"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/resources/virtual/conditions"
+	"github.com/rancher/steve/pkg/resources/virtual/owners"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
 	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -25,14 +28,22 @@ const (
 	pageSizeParam           = "pagesize"
 	pageParam               = "page"
 	revisionParam           = "revision"
+	ownedByParam            = "ownedBy"
+	countOnlyParam          = "countOnly"
 	projectsOrNamespacesVar = "projectsornamespaces"
 	projectIDFieldLabel     = "field.cattle.io/projectId"
 
-	orOp  = ","
-	notOp = "!"
+	orOp    = ","
+	notOp   = "!"
+	inOp    = "in"
+	notInOp = "notin"
 )
 
-var opReg = regexp.MustCompile(`[!]?=`)
+var (
+	opReg     = regexp.MustCompile(`[!]?=`)
+	setReg    = regexp.MustCompile(`^\s*([^\s=!]+)\s+(in|notin)\s*\((.*)\)\s*$`)
+	existsReg = regexp.MustCompile(`^(!?)([^\s=!<>()]+)$`)
+)
 
 // ListOptions represents the query parameters that may be included in a list request.
 type ListOptions struct {
@@ -53,8 +64,25 @@ type Cache interface {
 	ListByOptions(ctx context.Context, lo informer.ListOptions, partitions []partition.Partition, namespace string) (*unstructured.UnstructuredList, int, string, error)
 }
 
+// CountCache is implemented by caches counting the objects of a list themselves, without reading them
+type CountCache interface {
+	// CountByOptions returns the number of objects ListByOptions lists with the same arguments, before pagination
+	CountByOptions(ctx context.Context, lo informer.ListOptions, partitions []partition.Partition, namespace string) (int, error)
+}
+
+// ParseOptions configures how ParseQuery parses the query params of a request.
+type ParseOptions struct {
+	// NamespaceCache lists the namespaces of the projects of the projectsornamespaces query param
+	NamespaceCache Cache
+	// DefaultSort is the sort of requests without a sort query param whose schema has no defaultSort attribute, in
+	// the format of the sort query param
+	DefaultSort string
+	// ColumnTypes are the types the values of range filters are checked against
+	ColumnTypes ColumnTypes
+}
+
 // ParseQuery parses the query params of a request and returns a ListOptions.
-func ParseQuery(apiOp *types.APIRequest, namespaceCache Cache) (informer.ListOptions, error) {
+func ParseQuery(apiOp *types.APIRequest, options ParseOptions) (informer.ListOptions, error) {
 	opts := informer.ListOptions{}
 
 	opts.ChunkSize = getLimit(apiOp)
@@ -66,9 +94,44 @@ func ParseQuery(apiOp *types.APIRequest, namespaceCache Cache) (informer.ListOpt
 	filterParams := q[filterParam]
 	filterOpts := []informer.OrFilter{}
 	for _, filters := range filterParams {
-		orFilters := strings.Split(filters, orOp)
+		orFilters := splitOrFilters(filters)
+		if len(orFilters) == 1 {
+			if field, values, ok := parseSetFilter(orFilters[0], notInOp); ok {
+				if len(values) == 0 {
+					return opts, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("filter [%s] has an empty set", filters))
+				}
+				// a negated set must exclude every value, so each value becomes its own ANDed filter
+				for _, value := range values {
+					filterOpts = append(filterOpts, informer.OrFilter{Filters: []informer.Filter{{Field: field, Match: value, Op: informer.NotEq, Partial: false}}})
+				}
+				continue
+			}
+		}
 		orFilter := informer.OrFilter{}
 		for _, filter := range orFilters {
+			if field, values, ok := parseSetFilter(filter, inOp); ok {
+				if len(values) == 0 {
+					return opts, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("filter [%s] has an empty set", filters))
+				}
+				for _, value := range values {
+					orFilter.Filters = append(orFilter.Filters, informer.Filter{Field: field, Match: value, Op: informer.Eq, Partial: false})
+				}
+				continue
+			}
+			if _, _, ok := parseSetFilter(filter, notInOp); ok {
+				return opts, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("filter [%s] cannot combine %s with other filters", filters, notInOp))
+			}
+			if rangeFilter, ok := parseRangeFilter(filter); ok {
+				if err := validateRangeFilter(rangeFilter, options.ColumnTypes); err != nil {
+					return opts, err
+				}
+				orFilter.Filters = append(orFilter.Filters, rangeFilter)
+				continue
+			}
+			if existsFilter, ok := parseExistsFilter(filter); ok {
+				orFilter.Filters = append(orFilter.Filters, existsFilter)
+				continue
+			}
 			var op informer.Op
 			if strings.Contains(filter, "!=") {
 				op = "!="
@@ -79,23 +142,53 @@ func ParseQuery(apiOp *types.APIRequest, namespaceCache Cache) (informer.ListOpt
 			}
 			usePartialMatch := !(strings.HasPrefix(filter[1], `'`) && strings.HasSuffix(filter[1], `'`))
 			value := strings.TrimSuffix(strings.TrimPrefix(filter[1], "'"), "'")
-			orFilter.Filters = append(orFilter.Filters, informer.Filter{Field: strings.Split(filter[0], "."), Match: value, Op: op, Partial: usePartialMatch})
+			orFilter.Filters = append(orFilter.Filters, owners.Filter(informer.Filter{Field: SplitField(filter[0]), Match: value, Op: op, Partial: usePartialMatch}))
 		}
 		filterOpts = append(filterOpts, orFilter)
 	}
+	if fieldSelector := q.Get(fieldSelectorParam); fieldSelector != "" {
+		fieldSelectorFilters, err := parseFieldSelector(fieldSelector)
+		if err != nil {
+			return opts, err
+		}
+		filterOpts = append(filterOpts, fieldSelectorFilters...)
+	}
+	if labelSelector := q.Get(labelSelectorParam); labelSelector != "" {
+		labelSelectorFilters, err := parseLabelSelector(labelSelector)
+		if err != nil {
+			return opts, err
+		}
+		filterOpts = append(filterOpts, labelSelectorFilters...)
+	}
+	if ownedBy := q.Get(ownedByParam); ownedBy != "" {
+		ownedByFilters, err := owners.OwnedBy(ownedBy)
+		if err != nil {
+			return opts, err
+		}
+		filterOpts = append(filterOpts, ownedByFilters...)
+	}
 	opts.Filters = filterOpts
 
 	sortOpts := informer.Sort{}
 	sortKeys := q.Get(sortParam)
+	if sortKeys == "" {
+		sortKeys = attributes.DefaultSort(apiOp.Schema)
+	}
+	if sortKeys == "" {
+		sortKeys = options.DefaultSort
+	}
 	if sortKeys != "" {
-		sortParts := strings.SplitN(sortKeys, ",", 2)
+		sortParts := strings.Split(sortKeys, ",")
+		if len(sortParts) > 2 {
+			return opts, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("sort [%s] has more than two fields", sortKeys))
+		}
 		primaryField := sortParts[0]
 		if primaryField != "" && primaryField[0] == '-' {
 			sortOpts.PrimaryOrder = informer.DESC
 			primaryField = primaryField[1:]
 		}
 		if primaryField != "" {
-			sortOpts.PrimaryField = strings.Split(primaryField, ".")
+			sortOpts.PrimaryField = SplitField(primaryField)
 		}
 		if len(sortParts) > 1 {
 			secondaryField := sortParts[1]
@@ -104,10 +197,23 @@ func ParseQuery(apiOp *types.APIRequest, namespaceCache Cache) (informer.ListOpt
 				secondaryField = secondaryField[1:]
 			}
 			if secondaryField != "" {
-				sortOpts.SecondaryField = strings.Split(secondaryField, ".")
+				sortOpts.SecondaryField = SplitField(secondaryField)
 			}
 		}
 	}
+	if groupBy := q.Get(groupByParam); groupBy != "" {
+		var err error
+		sortOpts, err = parseGroupBy(groupBy, sortOpts)
+		if err != nil {
+			return opts, err
+		}
+	}
+	if _, err := ParseGroupLimit(apiOp); err != nil {
+		return opts, err
+	}
+	if _, err := ParseMaxPerNamespace(apiOp); err != nil {
+		return opts, err
+	}
 	opts.Sort = sortOpts
 
 	var err error
@@ -131,7 +237,7 @@ func ParseQuery(apiOp *types.APIRequest, namespaceCache Cache) (informer.ListOpt
 		}
 	}
 	if projectsOrNamespaces != "" {
-		projOrNSFilters, err := parseNamespaceOrProjectFilters(apiOp.Context(), projectsOrNamespaces, op, namespaceCache)
+		projOrNSFilters, err := parseNamespaceOrProjectFilters(apiOp.Context(), projectsOrNamespaces, op, options.NamespaceCache)
 		if err != nil {
 			return opts, err
 		}
@@ -150,6 +256,85 @@ func ParseQuery(apiOp *types.APIRequest, namespaceCache Cache) (informer.ListOpt
 	return opts, nil
 }
 
+// parseExistsFilter parses a filter testing whether a field is set, such as "spec.podIP", or unset, such as
+// "!spec.podIP". Labels are set if objects have them, even empty. The cache stores other missing fields as empty values,
+// so they are considered set if they are not empty.
+func parseExistsFilter(filter string) (informer.Filter, bool) {
+	matches := existsReg.FindStringSubmatch(filter)
+	if matches == nil {
+		return informer.Filter{}, false
+	}
+	op := informer.Exists
+	if matches[1] == notOp {
+		op = informer.NotExists
+	}
+	return informer.Filter{Field: SplitField(matches[2]), Op: op, Partial: false}, true
+}
+
+// splitOrFilters splits the value of a filter parameter on the OR operator, ignoring separators that are part of a
+// set such as "metadata.name in (a,b)".
+func splitOrFilters(filters string) []string {
+	var result []string
+	depth, start := 0, 0
+	for i, c := range filters {
+		switch c {
+		case '(':
+			depth++
+		case ')':
+			if depth > 0 {
+				depth--
+			}
+		case ',':
+			if depth == 0 {
+				result = append(result, filters[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(result, filters[start:])
+}
+
+// parseSetFilter parses a set membership filter like "metadata.name in (a,b,c)" using the given set operator.
+// Values are matched exactly, surrounding single quotes are optional.
+func parseSetFilter(filter, setOp string) ([]string, []string, bool) {
+	matches := setReg.FindStringSubmatch(filter)
+	if matches == nil || matches[2] != setOp {
+		return nil, nil, false
+	}
+	var values []string
+	for _, value := range strings.Split(matches[3], orOp) {
+		value = strings.TrimSpace(value)
+		value = strings.TrimSuffix(strings.TrimPrefix(value, "'"), "'")
+		if value == "" {
+			continue
+		}
+		values = append(values, value)
+	}
+	return SplitField(matches[1]), values, true
+}
+
+// SplitField splits a field in dot notation into its subfields. Dots inside brackets are kept, so label and
+// annotation keys like "metadata.labels[field.cattle.io/projectId]" are preserved. Fields of conditions like
+// "status.conditions[Ready].status" are mapped to the virtual fields indexing them.
+func SplitField(field string) []string {
+	var result []string
+	inBrackets, start := false, 0
+	for i, c := range field {
+		switch c {
+		case '[':
+			inBrackets = true
+		case ']':
+			inBrackets = false
+		case '.':
+			if !inBrackets {
+				result = append(result, field[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return conditions.Field(append(result, field[start:]))
+}
+
 // getLimit extracts the limit parameter from the request or sets a default of 100000.
 // The default limit can be explicitly disabled by setting it to zero or negative.
 // If the default is accepted, clients must be aware that the list may be incomplete, and use the "continue" token to get the next chunk of results.
@@ -162,6 +347,11 @@ func getLimit(apiOp *types.APIRequest) int {
 	return limit
 }
 
+// ParseCountOnly returns true if the request only asks for the number of matching objects, not the objects themselves.
+func ParseCountOnly(apiOp *types.APIRequest) bool {
+	return apiOp.Request.URL.Query().Get(countOnlyParam) == "true"
+}
+
 func parseNamespaceOrProjectFilters(ctx context.Context, projOrNS string, op informer.Op, namespaceInformer Cache) ([]informer.Filter, error) {
 	var filters []informer.Filter
 	for _, pn := range strings.Split(projOrNS, ",") {
@@ -8,8 +8,10 @@ import (
 	"testing"
 
 	"github.com/rancher/apiserver/pkg/types"
-	"github.com/rancher/lasso/pkg/cache/sql/informer"
-	"github.com/rancher/lasso/pkg/cache/sql/partition"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
 	"github.com/stretchr/testify/assert"
 	"go.uber.org/mock/gomock"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
@@ -23,9 +25,13 @@ func TestParseQuery(t *testing.T) {
 		setupNSCache func() Cache
 		nsc          Cache
 		req          *types.APIRequest
+		defaultSort  string
 		expectedLO   informer.ListOptions
 		errExpected  bool
 	}
+	defaultSortSchema := &types.APISchema{Schema: &schemas.Schema{}}
+	attributes.SetDefaultSort(defaultSortSchema, "metadata.namespace,metadata.name")
+
 	var tests []testCase
 	tests = append(tests, testCase{
 		description: "ParseQuery() with no errors returned should returned no errors. Should have proper defaults set.",
@@ -411,6 +417,193 @@ func TestParseQuery(t *testing.T) {
 			return nil
 		},
 	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with two label sort params should set both as primary and secondary fields.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "sort=metadata.labels[app.kubernetes.io/name],-metadata.labels[tier]&pagesize=10"},
+			},
+		},
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Sort: informer.Sort{
+				PrimaryField:   []string{"metadata", "labels[app.kubernetes.io/name]"},
+				PrimaryOrder:   informer.ASC,
+				SecondaryField: []string{"metadata", "labels[tier]"},
+				SecondaryOrder: informer.DESC,
+			},
+			Filters: make([]informer.OrFilter, 0),
+			Pagination: informer.Pagination{
+				PageSize: 10,
+				Page:     1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with one sort param and a page size should only set the primary field.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "sort=-metadata.labels[tier]&pagesize=10&page=2"},
+			},
+		},
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Sort: informer.Sort{
+				PrimaryField: []string{"metadata", "labels[tier]"},
+				PrimaryOrder: informer.DESC,
+			},
+			Filters: make([]informer.OrFilter, 0),
+			Pagination: informer.Pagination{
+				PageSize: 10,
+				Page:     2,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with groupBy should sort by the group first, then by the requested sort.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "groupBy=metadata.namespace&sort=-metadata.creationTimestamp"},
+			},
+		},
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Sort: informer.Sort{
+				PrimaryField:   []string{"metadata", "namespace"},
+				PrimaryOrder:   informer.ASC,
+				SecondaryField: []string{"metadata", "creationTimestamp"},
+				SecondaryOrder: informer.DESC,
+			},
+			Filters: make([]informer.OrFilter, 0),
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with groupBy and no sort should only sort by the group.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "groupBy=-metadata.labels[tier]&groupLimit=3"},
+			},
+		},
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Sort: informer.Sort{
+				PrimaryField: []string{"metadata", "labels[tier]"},
+				PrimaryOrder: informer.DESC,
+			},
+			Filters: make([]informer.OrFilter, 0),
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with groupBy and two sort params should return an error.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "groupBy=metadata.namespace&sort=metadata.name,metadata.creationTimestamp"},
+			},
+		},
+		errExpected: true,
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() without a sort param should use the default sort.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: ""},
+			},
+		},
+		defaultSort: "-metadata.creationTimestamp",
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Sort: informer.Sort{
+				PrimaryField: []string{"metadata", "creationTimestamp"},
+				PrimaryOrder: informer.DESC,
+			},
+			Filters: make([]informer.OrFilter, 0),
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() without a sort param should prefer the defaultSort attribute of the schema to the default sort.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: ""},
+			},
+			Schema: defaultSortSchema,
+		},
+		defaultSort: "-metadata.creationTimestamp",
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Sort: informer.Sort{
+				PrimaryField:   []string{"metadata", "namespace"},
+				SecondaryField: []string{"metadata", "name"},
+			},
+			Filters: make([]informer.OrFilter, 0),
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with a sort param should ignore the default sort.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "sort=id"},
+			},
+		},
+		defaultSort: "-metadata.creationTimestamp",
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Sort: informer.Sort{
+				PrimaryField: []string{"id"},
+			},
+			Filters: make([]informer.OrFilter, 0),
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with more than two sort params should return an error.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "sort=metadata.labels[a],metadata.labels[b],metadata.name"},
+			},
+		},
+		errExpected: true,
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
 	tests = append(tests, testCase{
 		description: "ParseQuery() with no errors returned should returned no errors. If continue params is given, resume" +
 			" should be set with assigned value.",
@@ -509,11 +702,455 @@ func TestParseQuery(t *testing.T) {
 			return nil
 		},
 	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with an in filter should include a single or filter with an exact match per value.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "filter=metadata.name+in+(a,'b',c)"},
+			},
+		},
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Filters: []informer.OrFilter{
+				{
+					Filters: []informer.Filter{
+						{
+							Field:   []string{"metadata", "name"},
+							Match:   "a",
+							Op:      informer.Eq,
+							Partial: false,
+						},
+						{
+							Field:   []string{"metadata", "name"},
+							Match:   "b",
+							Op:      informer.Eq,
+							Partial: false,
+						},
+						{
+							Field:   []string{"metadata", "name"},
+							Match:   "c",
+							Op:      informer.Eq,
+							Partial: false,
+						},
+					},
+				},
+			},
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with an in filter on a label combined with another filter should OR all of them.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "filter=metadata.labels[field.cattle.io/projectId]+in+(p1,p2),metadata.name=foo"},
+			},
+		},
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Filters: []informer.OrFilter{
+				{
+					Filters: []informer.Filter{
+						{
+							Field:   []string{"metadata", "labels[field.cattle.io/projectId]"},
+							Match:   "p1",
+							Op:      informer.Eq,
+							Partial: false,
+						},
+						{
+							Field:   []string{"metadata", "labels[field.cattle.io/projectId]"},
+							Match:   "p2",
+							Op:      informer.Eq,
+							Partial: false,
+						},
+						{
+							Field:   []string{"metadata", "name"},
+							Match:   "foo",
+							Op:      "",
+							Partial: true,
+						},
+					},
+				},
+			},
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with a notin filter should include an or filter per excluded value.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "filter=metadata.namespace+notin+(a,b)"},
+			},
+		},
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Filters: []informer.OrFilter{
+				{
+					Filters: []informer.Filter{
+						{
+							Field:   []string{"metadata", "namespace"},
+							Match:   "a",
+							Op:      informer.NotEq,
+							Partial: false,
+						},
+					},
+				},
+				{
+					Filters: []informer.Filter{
+						{
+							Field:   []string{"metadata", "namespace"},
+							Match:   "b",
+							Op:      informer.NotEq,
+							Partial: false,
+						},
+					},
+				},
+			},
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with a notin filter ORed with another filter should return an error.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "filter=metadata.namespace+notin+(a,b),metadata.name=foo"},
+			},
+		},
+		errExpected: true,
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with exists and not exists filters should match set and unset fields.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "filter=status.podIP&filter=!metadata.labels[app]"},
+			},
+		},
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Filters: []informer.OrFilter{
+				{
+					Filters: []informer.Filter{
+						{
+							Field:   []string{"status", "podIP"},
+							Match:   "",
+							Op:      informer.Exists,
+							Partial: false,
+						},
+					},
+				},
+				{
+					Filters: []informer.Filter{
+						{
+							Field:   []string{"metadata", "labels[app]"},
+							Match:   "",
+							Op:      informer.NotExists,
+							Partial: false,
+						},
+					},
+				},
+			},
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with an exists filter ORed with another filter should include both in one or filter.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "filter=!spec.nodeName,metadata.name=foo"},
+			},
+		},
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Filters: []informer.OrFilter{
+				{
+					Filters: []informer.Filter{
+						{
+							Field:   []string{"spec", "nodeName"},
+							Match:   "",
+							Op:      informer.NotExists,
+							Partial: false,
+						},
+						{
+							Field:   []string{"metadata", "name"},
+							Match:   "foo",
+							Partial: true,
+						},
+					},
+				},
+			},
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with an empty in filter should return an error.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "filter=metadata.name+in+()"},
+			},
+		},
+		errExpected: true,
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with a range filter param should include it in the list options.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "filter=spec.replicas>=2"},
+			},
+		},
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Filters: []informer.OrFilter{
+				{Filters: []informer.Filter{{Field: []string{"spec", "replicas"}, Match: "2", Op: Gte}}},
+			},
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with a range filter ORed with another filter should OR them.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "filter=spec.replicas>2,metadata.name=foo"},
+			},
+		},
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Filters: []informer.OrFilter{
+				{Filters: []informer.Filter{
+					{Field: []string{"spec", "replicas"}, Match: "2", Op: Gt},
+					{Field: []string{"metadata", "name"}, Match: "foo", Partial: true},
+				}},
+			},
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with a fieldSelector param should include one exact match filter per requirement.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "fieldSelector=spec.nodeName=foo,status.phase!=Running"},
+			},
+		},
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Filters: []informer.OrFilter{
+				{
+					Filters: []informer.Filter{
+						{
+							Field:   []string{"spec", "nodeName"},
+							Match:   "foo",
+							Op:      informer.Eq,
+							Partial: false,
+						},
+					},
+				},
+				{
+					Filters: []informer.Filter{
+						{
+							Field:   []string{"status", "phase"},
+							Match:   "Running",
+							Op:      informer.NotEq,
+							Partial: false,
+						},
+					},
+				},
+			},
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with a fieldSelector param and a filter param should AND both.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "filter=a=c&fieldSelector=metadata.name==foo"},
+			},
+		},
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Filters: []informer.OrFilter{
+				{
+					Filters: []informer.Filter{
+						{
+							Field:   []string{"a"},
+							Match:   "c",
+							Op:      "",
+							Partial: true,
+						},
+					},
+				},
+				{
+					Filters: []informer.Filter{
+						{
+							Field:   []string{"metadata", "name"},
+							Match:   "foo",
+							Op:      informer.Eq,
+							Partial: false,
+						},
+					},
+				},
+			},
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with fields of conditions should map them to the virtual fields indexing them.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "filter=status.conditions[Ready].status=False&sort=-status.conditions[Ready].lastTransitionTime"},
+			},
+		},
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Sort: informer.Sort{
+				PrimaryField: []string{"metadata", "conditions[Ready]", "lastTransitionTime"},
+				PrimaryOrder: informer.DESC,
+			},
+			Filters: []informer.OrFilter{
+				{
+					Filters: []informer.Filter{
+						{
+							Field:   []string{"metadata", "conditions[Ready]", "status"},
+							Match:   "False",
+							Op:      "",
+							Partial: true,
+						},
+					},
+				},
+			},
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with an exact filter on the uid of owners and an ownedBy param should filter on the virtual fields indexing owners.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "filter=metadata.ownerReferences.uid='1234'&ownedBy=ReplicaSet/default/web-abc"},
+			},
+		},
+		expectedLO: informer.ListOptions{
+			ChunkSize: defaultLimit,
+			Filters: []informer.OrFilter{
+				{
+					Filters: []informer.Filter{
+						{
+							Field:   []string{"metadata", "owners", "uids"},
+							Match:   "|1234|",
+							Op:      "",
+							Partial: true,
+						},
+					},
+				},
+				{
+					Filters: []informer.Filter{
+						{
+							Field:   []string{"metadata", "owners", "names"},
+							Match:   "|ReplicaSet/web-abc|",
+							Op:      informer.Eq,
+							Partial: true,
+						},
+					},
+				},
+				{
+					Filters: []informer.Filter{
+						{
+							Field: []string{"metadata", "namespace"},
+							Match: "default",
+							Op:    informer.Eq,
+						},
+					},
+				},
+			},
+			Pagination: informer.Pagination{
+				Page: 1,
+			},
+		},
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with a malformed ownedBy param should return an error.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "ownedBy=web"},
+			},
+		},
+		errExpected: true,
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
+	tests = append(tests, testCase{
+		description: "ParseQuery() with a malformed fieldSelector param should return an error.",
+		req: &types.APIRequest{
+			Request: &http.Request{
+				URL: &url.URL{RawQuery: "fieldSelector=spec.nodeName"},
+			},
+		},
+		errExpected: true,
+		setupNSCache: func() Cache {
+			return nil
+		},
+	})
 	t.Parallel()
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) {
 			test.nsc = test.setupNSCache()
-			lo, err := ParseQuery(test.req, test.nsc)
+			lo, err := ParseQuery(test.req, ParseOptions{NamespaceCache: test.nsc, DefaultSort: test.defaultSort})
 			if test.errExpected {
 				assert.NotNil(t, err)
 				return
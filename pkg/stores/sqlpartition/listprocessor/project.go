@@ -0,0 +1,48 @@
+package listprocessor
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/types"
+)
+
+const projectParam = "project"
+
+// ParseProject returns the ID of the project whose namespaces a list, or watch, is limited to, as set by the project
+// query param, or empty if it isn't limited to a project.
+func ParseProject(apiOp *types.APIRequest) string {
+	if apiOp.Request == nil {
+		return ""
+	}
+	return apiOp.Request.URL.Query().Get(projectParam)
+}
+
+// WatchProject returns the ID of the project set by the project query param of the selector of a watch, if it is made
+// of query params as ParseWatchFilters parses them, or empty otherwise.
+func WatchProject(selector string) string {
+	query, ok := strings.CutPrefix(selector, watchFiltersPrefix)
+	if !ok {
+		return ""
+	}
+	values, err := url.ParseQuery(query)
+	if err != nil {
+		return ""
+	}
+	return values.Get(projectParam)
+}
+
+// WithProject returns a copy of a request limited to the namespaces of a project, as if it had the project query param
+func WithProject(apiOp *types.APIRequest, projectID string) *types.APIRequest {
+	apiOp = apiOp.Clone()
+	if apiOp.Request == nil {
+		apiOp.Request = &http.Request{URL: &url.URL{}}
+	} else {
+		apiOp.Request = apiOp.Request.Clone(apiOp.Request.Context())
+	}
+	query := apiOp.Request.URL.Query()
+	query.Set(projectParam, projectID)
+	apiOp.Request.URL.RawQuery = query.Encode()
+	return apiOp
+}
@@ -0,0 +1,31 @@
+package listprocessor
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseProject(t *testing.T) {
+	apiOp := &types.APIRequest{Request: httptest.NewRequest(http.MethodGet, "/v1/pods?project=p-1", nil)}
+	assert.Equal(t, "p-1", ParseProject(apiOp))
+	assert.Empty(t, ParseProject(&types.APIRequest{}))
+}
+
+func TestWatchProject(t *testing.T) {
+	assert.Equal(t, "p-1", WatchProject("?project=p-1&filter=spec.nodeName=node1"))
+	assert.Empty(t, WatchProject("?filter=spec.nodeName=node1"))
+	assert.Empty(t, WatchProject("project=p-1"), "label selectors aren't query params")
+}
+
+func TestWithProject(t *testing.T) {
+	apiOp := &types.APIRequest{Request: httptest.NewRequest(http.MethodGet, "/v1/pods?limit=10", nil)}
+	limited := WithProject(apiOp, "p-1")
+	assert.Equal(t, "p-1", ParseProject(limited))
+	assert.Equal(t, "10", limited.Request.URL.Query().Get(limitParam))
+	assert.Empty(t, ParseProject(apiOp), "the request is copied")
+	assert.Equal(t, "p-1", ParseProject(WithProject(&types.APIRequest{}, "p-1")))
+}
@@ -0,0 +1,216 @@
+package listprocessor
+
+import (
+	"cmp"
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// Gt, Gte, Lt and Lte are the ops of range filters, which compare a field with a value, e.g. 'spec.replicas>2'
+const (
+	Gt  informer.Op = ">"
+	Gte informer.Op = ">="
+	Lt  informer.Op = "<"
+	Lte informer.Op = "<="
+)
+
+var rangeReg = regexp.MustCompile(`^([^<>=!]+)(>=|<=|>|<)(.+)$`)
+
+// dateLayouts are the layouts used to compare values as points in time, from most to least specific
+var dateLayouts = []string{time.RFC3339, "2006-01-02"}
+
+// ColumnType is the type the values of a column are compared as by range filters and sorts
+type ColumnType string
+
+const (
+	// TextColumn values are compared as strings, it is the type of columns without a declared one
+	TextColumn ColumnType = ""
+	// NumberColumn values are compared as numbers
+	NumberColumn ColumnType = "number"
+	// DateColumn values are compared as points in time, in the RFC 3339 format or dates
+	DateColumn ColumnType = "date"
+)
+
+var typeNames = map[ColumnType]string{TextColumn: "text", NumberColumn: "numbers", DateColumn: "dates"}
+
+// ColumnTypes are the declared types of the columns of a type, by field in the format of the filter query param, such
+// as "spec.replicas".
+type ColumnTypes map[string]ColumnType
+
+// Of returns the type of the column of a field
+func (c ColumnTypes) Of(field []string) ColumnType {
+	return c[strings.Join(field, ".")]
+}
+
+// RangeType returns the type the values of a field are compared as by range filters: its declared type, or numbers for
+// labels without one, since label selectors only compare labels with integers.
+func (c ColumnTypes) RangeType(field []string) ColumnType {
+	if typ := c.Of(field); typ != TextColumn {
+		return typ
+	}
+	if len(field) == 2 && field[0] == "metadata" && strings.HasPrefix(field[1], "labels[") {
+		return NumberColumn
+	}
+	return TextColumn
+}
+
+// IsRangeFilter returns whether a filter compares a field with a value rather than matching it
+func IsRangeFilter(filter informer.Filter) bool {
+	switch filter.Op {
+	case Gt, Gte, Lt, Lte:
+		return true
+	}
+	return false
+}
+
+func parseRangeFilter(filter string) (informer.Filter, bool) {
+	matches := rangeReg.FindStringSubmatch(filter)
+	if matches == nil {
+		return informer.Filter{}, false
+	}
+	return informer.Filter{
+		Field: SplitField(matches[1]),
+		Op:    informer.Op(matches[2]),
+		Match: strings.TrimSuffix(strings.TrimPrefix(matches[3], "'"), "'"),
+	}, true
+}
+
+// validateRangeFilter returns an error if the value of a range filter isn't of the type its field is compared as
+func validateRangeFilter(filter informer.Filter, types ColumnTypes) error {
+	typ := types.RangeType(filter.Field)
+	if _, ok := compareAs(typ, filter.Match, filter.Match); !ok {
+		return apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("filter [%s%s%s] compares %s with a value which isn't one",
+			strings.Join(filter.Field, "."), filter.Op, filter.Match, typeNames[typ]))
+	}
+	return nil
+}
+
+// matchesRange returns true if the field of the object satisfies the comparison of a range filter, both sides being
+// compared as the type of the field. Objects missing the field, or whose value isn't of the type, never match.
+func matchesRange(obj unstructured.Unstructured, filter informer.Filter, types ColumnTypes) bool {
+	result, ok := compareAs(types.RangeType(filter.Field), StringValue(obj, filter.Field), filter.Match)
+	if !ok {
+		return false
+	}
+	switch filter.Op {
+	case Gt:
+		return result > 0
+	case Gte:
+		return result >= 0
+	case Lt:
+		return result < 0
+	case Lte:
+		return result <= 0
+	}
+	return false
+}
+
+// Paginate returns the page of items selected by lo, the total number of items, and a continue token if there are
+// more items after the page, the same way the SQL cache does.
+func Paginate(items []unstructured.Unstructured, lo informer.ListOptions) ([]unstructured.Unstructured, int, string, error) {
+	total := len(items)
+
+	limit := lo.Pagination.PageSize
+	if limit == 0 || (lo.ChunkSize > 0 && lo.ChunkSize < limit) {
+		limit = lo.ChunkSize
+	}
+	offset := 0
+	if lo.Resume != "" {
+		var err error
+		offset, err = strconv.Atoi(lo.Resume)
+		if err != nil {
+			return nil, 0, "", err
+		}
+	}
+	if lo.Pagination.Page >= 1 {
+		offset += lo.Pagination.PageSize * (lo.Pagination.Page - 1)
+	}
+
+	if offset > total {
+		offset = total
+	}
+	end := total
+	if limit > 0 && offset+limit < total {
+		end = offset + limit
+	}
+	page := items[offset:end]
+
+	continueToken := ""
+	if limit > 0 && offset+len(page) < total {
+		continueToken = strconv.Itoa(offset + limit)
+	}
+	return page, total, continueToken, nil
+}
+
+// compareAs returns -1, 0 or 1 depending on whether a is less than, equal to or greater than b, compared as values of
+// a type, or false if either of them isn't one. Empty values aren't of any type.
+func compareAs(typ ColumnType, a, b string) (int, bool) {
+	if a == "" || b == "" {
+		return 0, false
+	}
+	switch typ {
+	case NumberColumn:
+		aNum, aErr := strconv.ParseFloat(a, 64)
+		bNum, bErr := strconv.ParseFloat(b, 64)
+		if aErr != nil || bErr != nil {
+			return 0, false
+		}
+		return cmp.Compare(aNum, bNum), true
+	case DateColumn:
+		aTime, aOK := parseDate(a)
+		bTime, bOK := parseDate(b)
+		if !aOK || !bOK {
+			return 0, false
+		}
+		return aTime.Compare(bTime), true
+	}
+	return strings.Compare(a, b), true
+}
+
+func parseDate(value string) (time.Time, bool) {
+	for _, layout := range dateLayouts {
+		if t, err := time.Parse(layout, value); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+// fieldValue returns the value of a field of an object, supporting subfields indexed by key like 'labels[app]'
+func fieldValue(obj map[string]interface{}, field []string) (interface{}, bool) {
+	var current interface{} = obj
+	for _, subField := range field {
+		keys := []string{subField}
+		if i := strings.Index(subField, "["); i > 0 && strings.HasSuffix(subField, "]") {
+			keys = []string{subField[:i], subField[i+1 : len(subField)-1]}
+		}
+		for _, key := range keys {
+			switch typed := current.(type) {
+			case map[string]interface{}:
+				var ok bool
+				current, ok = typed[key]
+				if !ok {
+					return nil, false
+				}
+			case []interface{}:
+				// e.g. metadata.fields[0]
+				index, err := strconv.Atoi(key)
+				if err != nil || index < 0 || index >= len(typed) {
+					return nil, false
+				}
+				current = typed[index]
+			default:
+				return nil, false
+			}
+		}
+	}
+	return current, true
+}
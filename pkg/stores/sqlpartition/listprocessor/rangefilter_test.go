@@ -0,0 +1,205 @@
+package listprocessor
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseQueryRangeFilters(t *testing.T) {
+	columnTypes := ColumnTypes{"spec.replicas": NumberColumn, "metadata.creationTimestamp": DateColumn}
+	tests := []struct {
+		description string
+		query       string
+		expected    []informer.OrFilter
+		errExpected bool
+	}{
+		{
+			description: "ParseQuery() with range filters should return them as filters.",
+			query:       "filter=spec.replicas>2&filter=metadata.creationTimestamp<='2024-01-01'&filter=spec.nodeName>=node2",
+			expected: []informer.OrFilter{
+				{Filters: []informer.Filter{{Field: []string{"spec", "replicas"}, Op: Gt, Match: "2"}}},
+				{Filters: []informer.Filter{{Field: []string{"metadata", "creationTimestamp"}, Op: Lte, Match: "2024-01-01"}}},
+				{Filters: []informer.Filter{{Field: []string{"spec", "nodeName"}, Op: Gte, Match: "node2"}}},
+			},
+		},
+		{
+			description: "ParseQuery() with a range filter ORed with other filters should OR them.",
+			query:       "filter=spec.replicas<1,metadata.name=web",
+			expected: []informer.OrFilter{
+				{Filters: []informer.Filter{
+					{Field: []string{"spec", "replicas"}, Op: Lt, Match: "1"},
+					{Field: []string{"metadata", "name"}, Match: "web", Partial: true},
+				}},
+			},
+		},
+		{
+			description: "ParseQuery() with a range filter comparing a number column with text should return an error.",
+			query:       "filter=spec.replicas>two",
+			errExpected: true,
+		},
+		{
+			description: "ParseQuery() with a range filter comparing a date column with text should return an error.",
+			query:       "filter=metadata.creationTimestamp>yesterday",
+			errExpected: true,
+		},
+		{
+			description: "ParseQuery() with a range filter comparing a label with text should return an error.",
+			query:       "filter=metadata.labels[version]>v2",
+			errExpected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			req := &types.APIRequest{
+				Request: &http.Request{
+					URL: &url.URL{RawQuery: test.query},
+				},
+			}
+			opts, err := ParseQuery(req, ParseOptions{ColumnTypes: columnTypes})
+			if test.errExpected {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, opts.Filters)
+		})
+	}
+}
+
+func TestMatchesRangeFilters(t *testing.T) {
+	obj := unstructured.Unstructured{
+		Object: map[string]interface{}{
+			"metadata": map[string]interface{}{
+				"name":              "web",
+				"creationTimestamp": "2024-03-01T10:00:00Z",
+				"labels": map[string]interface{}{
+					"tier":    "b",
+					"version": "10",
+				},
+			},
+			"spec": map[string]interface{}{
+				"replicas": int64(10),
+				"size":     "10",
+			},
+		},
+	}
+	columnTypes := ColumnTypes{"spec.replicas": NumberColumn, "metadata.creationTimestamp": DateColumn}
+	tests := []struct {
+		description string
+		filter      informer.Filter
+		expected    bool
+	}{
+		{
+			description: "number columns are compared numerically",
+			filter:      informer.Filter{Field: []string{"spec", "replicas"}, Op: Gt, Match: "9"},
+			expected:    true,
+		},
+		{
+			description: "numbers equal to the value do not match a strict comparison",
+			filter:      informer.Filter{Field: []string{"spec", "replicas"}, Op: Lt, Match: "10"},
+			expected:    false,
+		},
+		{
+			description: "numbers equal to the value match an inclusive comparison",
+			filter:      informer.Filter{Field: []string{"spec", "replicas"}, Op: Lte, Match: "10"},
+			expected:    true,
+		},
+		{
+			description: "date columns are compared with dates",
+			filter:      informer.Filter{Field: []string{"metadata", "creationTimestamp"}, Op: Gt, Match: "2024-01-01"},
+			expected:    true,
+		},
+		{
+			description: "columns without a type are compared as text",
+			filter:      informer.Filter{Field: []string{"spec", "size"}, Op: Gt, Match: "9"},
+			expected:    false,
+		},
+		{
+			description: "labels are compared as numbers",
+			filter:      informer.Filter{Field: []string{"metadata", "labels[version]"}, Op: Gt, Match: "9"},
+			expected:    true,
+		},
+		{
+			description: "labels which aren't numbers never match",
+			filter:      informer.Filter{Field: []string{"metadata", "labels[tier]"}, Op: Lt, Match: "9"},
+			expected:    false,
+		},
+		{
+			description: "missing fields never match",
+			filter:      informer.Filter{Field: []string{"status", "readyReplicas"}, Op: Lt, Match: "1"},
+			expected:    false,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			assert.Equal(t, test.expected, MatchesFilters(obj, []informer.OrFilter{{Filters: []informer.Filter{test.filter}}}, columnTypes))
+		})
+	}
+}
+
+func TestPaginateFiltered(t *testing.T) {
+	var items []unstructured.Unstructured
+	for i := int64(1); i <= 5; i++ {
+		items = append(items, unstructured.Unstructured{
+			Object: map[string]interface{}{
+				"spec": map[string]interface{}{
+					"replicas": i,
+				},
+			},
+		})
+	}
+	filters := []informer.OrFilter{{Filters: []informer.Filter{{Field: []string{"spec", "replicas"}, Op: Gt, Match: "1"}}}}
+	columnTypes := ColumnTypes{"spec.replicas": NumberColumn}
+
+	tests := []struct {
+		description      string
+		lo               informer.ListOptions
+		expectedReplicas []int64
+		expectedTotal    int
+		expectedContinue string
+	}{
+		{
+			description:      "Paginate() without pagination should return all matching items.",
+			lo:               informer.ListOptions{Pagination: informer.Pagination{Page: 1}},
+			expectedReplicas: []int64{2, 3, 4, 5},
+			expectedTotal:    4,
+		},
+		{
+			description:      "Paginate() with a chunk size should return a continue token.",
+			lo:               informer.ListOptions{ChunkSize: 3, Pagination: informer.Pagination{Page: 1}},
+			expectedReplicas: []int64{2, 3, 4},
+			expectedTotal:    4,
+			expectedContinue: "3",
+		},
+		{
+			description:      "Paginate() with a page should skip previous pages.",
+			lo:               informer.ListOptions{Pagination: informer.Pagination{PageSize: 3, Page: 2}},
+			expectedReplicas: []int64{5},
+			expectedTotal:    4,
+		},
+		{
+			description:   "Paginate() with a page out of bounds should return no items.",
+			lo:            informer.ListOptions{Pagination: informer.Pagination{PageSize: 3, Page: 3}},
+			expectedTotal: 4,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			page, total, continueToken, err := Paginate(FilterItems(items, filters, columnTypes), test.lo)
+			assert.Nil(t, err)
+			var replicas []int64
+			for _, item := range page {
+				replicas = append(replicas, item.Object["spec"].(map[string]interface{})["replicas"].(int64))
+			}
+			assert.Equal(t, test.expectedReplicas, replicas)
+			assert.Equal(t, test.expectedTotal, total)
+			assert.Equal(t, test.expectedContinue, continueToken)
+		})
+	}
+}
@@ -0,0 +1,63 @@
+package listprocessor
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+)
+
+const revisionMatchParam = "revisionMatch"
+
+const (
+	// RevisionMatchNotOlderThan lists objects at least as recent as the requested revision, the default
+	RevisionMatchNotOlderThan = "NotOlderThan"
+	// RevisionMatchExact lists objects at exactly the requested revision
+	RevisionMatchExact = "Exact"
+)
+
+// RevisionOptions are the revision a list must be served at, as set by the revision and revisionMatch query params,
+// which follow the resourceVersion and resourceVersionMatch params of Kubernetes.
+type RevisionOptions struct {
+	// Revision is 0 if any revision will do
+	Revision uint64
+	Match    string
+}
+
+// ParseRevision returns the revision a list must be served at. Revisions must be numeric, as those of Kubernetes
+// backed by etcd, to be compared, and a revision of 0 is any revision, as in Kubernetes.
+func ParseRevision(apiOp *types.APIRequest) (RevisionOptions, error) {
+	query := apiOp.Request.URL.Query()
+	revision := query.Get(revisionParam)
+	match := query.Get(revisionMatchParam)
+	switch match {
+	case "", RevisionMatchNotOlderThan, RevisionMatchExact:
+	default:
+		return RevisionOptions{}, apierror.NewAPIError(validation.InvalidOption,
+			fmt.Sprintf("%s must be %s or %s", revisionMatchParam, RevisionMatchNotOlderThan, RevisionMatchExact))
+	}
+	if revision == "" {
+		if match != "" {
+			return RevisionOptions{}, apierror.NewAPIError(validation.InvalidOption,
+				fmt.Sprintf("%s requires %s", revisionMatchParam, revisionParam))
+		}
+		return RevisionOptions{}, nil
+	}
+	parsed, err := strconv.ParseUint(revision, 10, 64)
+	if err != nil {
+		return RevisionOptions{}, apierror.NewAPIError(validation.InvalidFormat, fmt.Sprintf("%s must be numeric", revisionParam))
+	}
+	if parsed == 0 && match == RevisionMatchExact {
+		return RevisionOptions{}, apierror.NewAPIError(validation.InvalidOption,
+			fmt.Sprintf("%s %s requires a %s other than 0", revisionMatchParam, RevisionMatchExact, revisionParam))
+	}
+	if parsed == 0 {
+		return RevisionOptions{}, nil
+	}
+	if match == "" {
+		match = RevisionMatchNotOlderThan
+	}
+	return RevisionOptions{Revision: parsed, Match: match}, nil
+}
@@ -0,0 +1,73 @@
+package listprocessor
+
+import (
+	"net/http"
+	"net/url"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseRevision(t *testing.T) {
+	tests := []struct {
+		description string
+		query       string
+		expected    RevisionOptions
+		errExpected bool
+	}{
+		{
+			description: "ParseRevision() without a revision should return any revision.",
+		},
+		{
+			description: "ParseRevision() with a revision should default to NotOlderThan.",
+			query:       "revision=42",
+			expected:    RevisionOptions{Revision: 42, Match: RevisionMatchNotOlderThan},
+		},
+		{
+			description: "ParseRevision() with an exact revision should return it.",
+			query:       "revision=42&revisionMatch=Exact",
+			expected:    RevisionOptions{Revision: 42, Match: RevisionMatchExact},
+		},
+		{
+			description: "ParseRevision() with a revision of 0 should return any revision.",
+			query:       "revision=0&revisionMatch=NotOlderThan",
+		},
+		{
+			description: "ParseRevision() with an exact revision of 0 should return an error.",
+			query:       "revision=0&revisionMatch=Exact",
+			errExpected: true,
+		},
+		{
+			description: "ParseRevision() with a non-numeric revision should return an error.",
+			query:       "revision=abc",
+			errExpected: true,
+		},
+		{
+			description: "ParseRevision() with an unknown match should return an error.",
+			query:       "revision=42&revisionMatch=Any",
+			errExpected: true,
+		},
+		{
+			description: "ParseRevision() with a match without revision should return an error.",
+			query:       "revisionMatch=Exact",
+			errExpected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			apiOp := &types.APIRequest{
+				Request: &http.Request{
+					URL: &url.URL{RawQuery: test.query},
+				},
+			}
+			options, err := ParseRevision(apiOp)
+			if test.errExpected {
+				assert.Error(t, err)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, test.expected, options)
+		})
+	}
+}
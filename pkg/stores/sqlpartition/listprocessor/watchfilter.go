@@ -0,0 +1,56 @@
+package listprocessor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// watchFiltersPrefix prefixes the selectors of watches made of the query params of lists, which label selectors can't
+// start with
+const watchFiltersPrefix = "?"
+
+// WatchFilters are the filters of a watch, which objects must match to be sent by it.
+type WatchFilters struct {
+	Filters []informer.OrFilter
+	// Types are the types the values of range filters are compared as
+	Types ColumnTypes
+}
+
+// ParseWatchFilters returns the filters of the selector of a watch, if it is made of the filter, fieldSelector,
+// labelSelector, ownedBy and projectsornamespaces query params of lists prefixed with "?", e.g.
+// "?filter=spec.nodeName=node1&labelSelector=app=web". It returns false for other selectors, which are label
+// selectors. The namespaces of projects are looked up with ctx, in the NamespaceCache of options.
+func ParseWatchFilters(ctx context.Context, selector string, options ParseOptions) (WatchFilters, bool, error) {
+	query, ok := strings.CutPrefix(selector, watchFiltersPrefix)
+	if !ok {
+		return WatchFilters{}, false, nil
+	}
+	if _, err := url.ParseQuery(query); err != nil {
+		return WatchFilters{}, false, fmt.Errorf("invalid watch filters [%s]: %w", selector, err)
+	}
+	apiOp := &types.APIRequest{
+		Request: (&http.Request{
+			URL: &url.URL{RawQuery: query},
+		}).WithContext(ctx),
+	}
+	opts, err := ParseQuery(apiOp, options)
+	if err != nil {
+		return WatchFilters{}, false, err
+	}
+	return WatchFilters{
+		Filters: opts.Filters,
+		Types:   options.ColumnTypes,
+	}, true, nil
+}
+
+// Matches returns true if the object matches all filters
+func (f WatchFilters) Matches(obj unstructured.Unstructured) bool {
+	return MatchesFilters(obj, f.Filters, f.Types)
+}
@@ -0,0 +1,78 @@
+package listprocessor
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestParseWatchFilters(t *testing.T) {
+	tests := []struct {
+		description string
+		selector    string
+		expected    WatchFilters
+		expectedOK  bool
+		errExpected bool
+	}{
+		{
+			description: "ParseWatchFilters() without a selector should return false.",
+		},
+		{
+			description: "ParseWatchFilters() with a label selector should return false.",
+			selector:    "app=web",
+		},
+		{
+			description: "ParseWatchFilters() with query params should return their filters.",
+			selector:    "?filter=spec.nodeName=node1&labelSelector=app=web&filter=spec.replicas>2",
+			expected: WatchFilters{
+				Filters: []informer.OrFilter{
+					{Filters: []informer.Filter{{Field: []string{"spec", "nodeName"}, Match: "node1", Partial: true}}},
+					{Filters: []informer.Filter{{Field: []string{"spec", "replicas"}, Match: "2", Op: Gt}}},
+					{Filters: []informer.Filter{{Field: []string{"metadata", "labels[app]"}, Match: "web", Op: informer.Eq}}},
+				},
+			},
+			expectedOK: true,
+		},
+		{
+			description: "ParseWatchFilters() with invalid query params should return an error.",
+			selector:    "?filter=%zz",
+			errExpected: true,
+		},
+		{
+			description: "ParseWatchFilters() with an invalid label selector should return an error.",
+			selector:    "?labelSelector=app in (web",
+			errExpected: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.description, func(t *testing.T) {
+			filters, ok, err := ParseWatchFilters(context.Background(), test.selector, ParseOptions{})
+			if test.errExpected {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.expectedOK, ok)
+			assert.Equal(t, test.expected, filters)
+		})
+	}
+}
+
+func TestWatchFiltersMatches(t *testing.T) {
+	filters, ok, err := ParseWatchFilters(context.Background(), "?filter=spec.nodeName=node1&filter=spec.replicas>2",
+		ParseOptions{ColumnTypes: ColumnTypes{"spec.replicas": NumberColumn}})
+	require.NoError(t, err)
+	require.True(t, ok)
+	obj := func(nodeName string, replicas int64) unstructured.Unstructured {
+		return unstructured.Unstructured{Object: map[string]interface{}{
+			"spec": map[string]interface{}{"nodeName": nodeName, "replicas": replicas},
+		}}
+	}
+	assert.True(t, filters.Matches(obj("node1", 3)))
+	assert.False(t, filters.Matches(obj("node2", 3)))
+	assert.False(t, filters.Matches(obj("node1", 1)))
+}
@@ -1,14 +1,18 @@
 package sqlpartition
 
 import (
+	"context"
 	"fmt"
 	"sort"
 
+	"github.com/rancher/apiserver/pkg/apierror"
 	"github.com/rancher/apiserver/pkg/types"
-	"github.com/rancher/lasso/pkg/cache/sql/partition"
 	"github.com/rancher/steve/pkg/accesscontrol"
 	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
 	"github.com/rancher/wrangler/v3/pkg/kv"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
 	"github.com/sirupsen/logrus"
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -33,6 +37,12 @@ type UnstructuredStore interface {
 	WatchByPartitions(apiOp *types.APIRequest, schema *types.APISchema, wr types.WatchRequest, partitions []partition.Partition) (chan watch.Event, error)
 }
 
+// ProjectNamespacer is implemented by stores which list the namespaces of projects, which the partitions of requesters
+// without access to all namespaces are limited to when lists and watches are limited to a project
+type ProjectNamespacer interface {
+	ProjectNamespaces(ctx context.Context, projectID string) (sets.Set[string], error)
+}
+
 // rbacPartitioner is an implementation of the sqlpartition.Partitioner interface.
 type rbacPartitioner struct {
 	proxyStore UnstructuredStore
@@ -51,6 +61,9 @@ func (p *rbacPartitioner) All(apiOp *types.APIRequest, schema *types.APISchema,
 			return partitions, nil
 		}
 		partitions, passthrough := generateAggregatePartitions(apiOp, schema, verb)
+		if projectID := listprocessor.ParseProject(apiOp); projectID != "" {
+			return p.projectPartitions(apiOp, schema, projectID, partitions, passthrough)
+		}
 		if passthrough {
 			return passthroughPartitions, nil
 		}
@@ -63,6 +76,49 @@ func (p *rbacPartitioner) All(apiOp *types.APIRequest, schema *types.APISchema,
 	}
 }
 
+// projectPartitions limits the partitions of a list, or watch, to the namespaces of a project. Requesters with access
+// to all namespaces get the partition of the project, whose namespaces are those of the project when objects are
+// listed, while the partitions of the namespaces requesters have access to are kept if they are in the project.
+func (p *rbacPartitioner) projectPartitions(apiOp *types.APIRequest, schema *types.APISchema, projectID string, partitions []partition.Partition, passthrough bool) ([]partition.Partition, error) {
+	if !attributes.Namespaced(schema) {
+		return nil, apierror.NewAPIError(validation.InvalidOption, fmt.Sprintf("%s isn't namespaced, and can't be limited to a project", schema.ID))
+	}
+	if passthrough {
+		if apiOp.Namespace == "" {
+			return []partition.Partition{partition.Project(projectID)}, nil
+		}
+		partitions = []partition.Partition{{Namespace: apiOp.Namespace, All: true}}
+	}
+
+	var namespaces sets.Set[string]
+	var result []partition.Partition
+	for _, part := range partitions {
+		if part.Namespace == "" || part.Namespace == accesscontrol.All {
+			// names granted in all namespaces are limited to those of the project
+			part.Namespace, part.Project = "", projectID
+			result = append(result, part)
+			continue
+		}
+		if namespaces == nil {
+			namespacer, ok := p.proxyStore.(ProjectNamespacer)
+			if !ok {
+				return nil, fmt.Errorf("the store of %s can't list the namespaces of projects", schema.ID)
+			}
+			var err error
+			if namespaces, err = namespacer.ProjectNamespaces(apiOp.Context(), projectID); err != nil {
+				return nil, err
+			}
+		}
+		if namespaces.Has(part.Namespace) {
+			result = append(result, part)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].Namespace < result[j].Namespace
+	})
+	return result, nil
+}
+
 // Store returns an Store suited to listing and watching resources by partition.
 func (p *rbacPartitioner) Store() UnstructuredStore {
 	return p.proxyStore
@@ -1,13 +1,16 @@
 package sqlpartition
 
 import (
+	"context"
+	"net/http"
+	"net/http/httptest"
 	"testing"
 
 	"go.uber.org/mock/gomock"
 
 	"github.com/rancher/apiserver/pkg/types"
-	"github.com/rancher/lasso/pkg/cache/sql/partition"
 	"github.com/rancher/steve/pkg/accesscontrol"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
 	"github.com/rancher/wrangler/v3/pkg/schemas"
 	"github.com/stretchr/testify/assert"
 	"k8s.io/apimachinery/pkg/util/sets"
@@ -873,3 +876,105 @@ func TestStore(t *testing.T) {
 	store := rp.Store()
 	assert.Equal(t, expectedStore, store)
 }
+
+// projectStore is an UnstructuredStore listing the namespaces of projects
+type projectStore struct {
+	*MockUnstructuredStore
+	namespaces map[string]sets.Set[string]
+}
+
+func (p projectStore) ProjectNamespaces(_ context.Context, projectID string) (sets.Set[string], error) {
+	return p.namespaces[projectID], nil
+}
+
+func TestProjectPartitions(t *testing.T) {
+	schema := func(access ...accesscontrol.Access) *types.APISchema {
+		return &types.APISchema{
+			Schema: &schemas.Schema{
+				ID: "foo",
+				Attributes: map[string]interface{}{
+					"namespaced": true,
+					"access":     accesscontrol.AccessListByVerb{"list": access},
+				},
+			},
+		}
+	}
+	apiOp := func(namespace string) *types.APIRequest {
+		return &types.APIRequest{
+			Namespace: namespace,
+			Request:   httptest.NewRequest(http.MethodGet, "/v1/foo?project=p-1", nil),
+		}
+	}
+
+	tests := []struct {
+		name           string
+		apiOp          *types.APIRequest
+		schema         *types.APISchema
+		wantPartitions []partition.Partition
+		wantErr        bool
+	}{
+		{
+			name:           "access to all namespaces gets the partition of the project",
+			apiOp:          apiOp(""),
+			schema:         schema(accesscontrol.Access{Namespace: "*", ResourceName: "*"}),
+			wantPartitions: []partition.Partition{partition.Project("p-1")},
+		},
+		{
+			name:   "names in all namespaces are limited to the project",
+			apiOp:  apiOp(""),
+			schema: schema(accesscontrol.Access{Namespace: "*", ResourceName: "r1"}),
+			wantPartitions: []partition.Partition{
+				{Project: "p-1", Names: sets.New("r1")},
+			},
+		},
+		{
+			name:  "namespaces outside of the project are dropped",
+			apiOp: apiOp(""),
+			schema: schema(
+				accesscontrol.Access{Namespace: "n1", ResourceName: "*"},
+				accesscontrol.Access{Namespace: "n2", ResourceName: "r1"},
+				accesscontrol.Access{Namespace: "n3", ResourceName: "*"},
+			),
+			wantPartitions: []partition.Partition{
+				{Namespace: "n1", All: true},
+				{Namespace: "n2", Names: sets.New("r1")},
+			},
+		},
+		{
+			name:           "requested namespace of the project",
+			apiOp:          apiOp("n1"),
+			schema:         schema(accesscontrol.Access{Namespace: "*", ResourceName: "*"}),
+			wantPartitions: []partition.Partition{{Namespace: "n1", All: true}},
+		},
+		{
+			name:   "requested namespace outside of the project",
+			apiOp:  apiOp("n3"),
+			schema: schema(accesscontrol.Access{Namespace: "*", ResourceName: "*"}),
+		},
+		{
+			name:  "types which aren't namespaced",
+			apiOp: apiOp(""),
+			schema: &types.APISchema{
+				Schema: &schemas.Schema{ID: "foo", Attributes: map[string]interface{}{
+					"access": accesscontrol.AccessListByVerb{"list": accesscontrol.AccessList{{Namespace: "*", ResourceName: "*"}}},
+				}},
+			},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			partitioner := rbacPartitioner{proxyStore: projectStore{
+				MockUnstructuredStore: NewMockUnstructuredStore(gomock.NewController(t)),
+				namespaces:            map[string]sets.Set[string]{"p-1": sets.New("n1", "n2")},
+			}}
+			gotPartitions, gotErr := partitioner.All(test.apiOp, test.schema, "list", "")
+			if test.wantErr {
+				assert.Error(t, gotErr)
+				return
+			}
+			assert.NoError(t, gotErr)
+			assert.Equal(t, test.wantPartitions, gotPartitions)
+		})
+	}
+}
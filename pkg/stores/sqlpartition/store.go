@@ -5,16 +5,23 @@ package sqlpartition
 
 import (
 	"context"
+	"fmt"
 
+	"github.com/rancher/apiserver/pkg/apierror"
 	"github.com/rancher/apiserver/pkg/types"
-	lassopartition "github.com/rancher/lasso/pkg/cache/sql/partition"
 	"github.com/rancher/steve/pkg/accesscontrol"
+	sqlcachepartition "github.com/rancher/steve/pkg/sqlcache/partition"
 	"github.com/rancher/steve/pkg/stores/partition"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"github.com/rancher/steve/pkg/tracing"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
 
 // Partitioner is an interface for interacting with partitions.
 type Partitioner interface {
-	All(apiOp *types.APIRequest, schema *types.APISchema, verb, id string) ([]lassopartition.Partition, error)
+	All(apiOp *types.APIRequest, schema *types.APISchema, verb, id string) ([]sqlcachepartition.Partition, error)
 	Store() UnstructuredStore
 }
 
@@ -51,6 +58,9 @@ func NewStore(store UnstructuredStore, asl accesscontrol.AccessSetLookup) *Store
 
 // Delete deletes an object from a store.
 func (s *Store) Delete(apiOp *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
+	apiOp, span := tracing.StartStore(apiOp, "sqlpartition.Delete", schema)
+	defer span.End()
+
 	target := s.Partitioner.Store()
 
 	obj, warnings, err := target.Delete(apiOp, schema, id)
@@ -62,6 +72,9 @@ func (s *Store) Delete(apiOp *types.APIRequest, schema *types.APISchema, id stri
 
 // ByID looks up a single object by its ID.
 func (s *Store) ByID(apiOp *types.APIRequest, schema *types.APISchema, id string) (types.APIObject, error) {
+	apiOp, span := tracing.StartStore(apiOp, "sqlpartition.ByID", schema)
+	defer span.End()
+
 	target := s.Partitioner.Store()
 
 	obj, warnings, err := target.ByID(apiOp, schema, id)
@@ -74,6 +87,9 @@ func (s *Store) ByID(apiOp *types.APIRequest, schema *types.APISchema, id string
 // List returns a list of objects across all applicable partitions.
 // If pagination parameters are used, it returns a segment of the list.
 func (s *Store) List(apiOp *types.APIRequest, schema *types.APISchema) (types.APIObjectList, error) {
+	apiOp, span := tracing.StartStore(apiOp, "sqlpartition.List", schema)
+	defer span.End()
+
 	var (
 		result types.APIObjectList
 	)
@@ -82,13 +98,35 @@ func (s *Store) List(apiOp *types.APIRequest, schema *types.APISchema) (types.AP
 	if err != nil {
 		return result, err
 	}
+	span.SetAttributes(attribute.Int("steve.partitions", len(partitions)))
 
 	store := s.Partitioner.Store()
 
-	list, total, continueToken, err := store.ListByPartitions(apiOp, schema, partitions)
+	if _, ok, err := listprocessor.ParseChangesSince(apiOp); err != nil {
+		return result, err
+	} else if ok {
+		return s.changes(apiOp, schema, store, partitions)
+	}
+
+	var (
+		list          []unstructured.Unstructured
+		total         int
+		continueToken string
+		revision      string
+	)
+	if revisionStore, ok := store.(RevisionStore); ok {
+		list, total, continueToken, revision, err = revisionStore.ListByPartitionsAtRevision(apiOp, schema, partitions)
+	} else {
+		list, total, continueToken, err = store.ListByPartitions(apiOp, schema, partitions)
+	}
 	if err != nil {
 		return result, err
 	}
+	if includesEvents(apiOp) {
+		if err := s.addEvents(apiOp, schema, list); err != nil {
+			return result, err
+		}
+	}
 
 	result.Count = total
 
@@ -98,13 +136,87 @@ func (s *Store) List(apiOp *types.APIRequest, schema *types.APISchema) (types.AP
 		result.Objects = append(result.Objects, partition.ToAPI(schema, item, nil, s.sqlReservedFields))
 	}
 
-	result.Revision = ""
+	result.Revision = revision
 	result.Continue = continueToken
 	return result, nil
 }
 
+// RevisionStore is implemented by stores able to list at a requested revision, and to return the revision a watch
+// of the listed objects can start from.
+type RevisionStore interface {
+	ListByPartitionsAtRevision(apiOp *types.APIRequest, schema *types.APISchema, partitions []sqlcachepartition.Partition) ([]unstructured.Unstructured, int, string, string, error)
+}
+
+// ChangeStore is implemented by stores keeping the changes made to objects, returning the objects changed since the
+// revision of the changesSince query param, and the revision the changes bring clients to.
+type ChangeStore interface {
+	ChangesByPartitions(apiOp *types.APIRequest, schema *types.APISchema, partitions []sqlcachepartition.Partition) ([]unstructured.Unstructured, string, error)
+}
+
+// changes returns the objects changed since the revision of the changesSince query param, as a list whose revision is
+// the one to request changes since next.
+func (s *Store) changes(apiOp *types.APIRequest, schema *types.APISchema, store UnstructuredStore, partitions []sqlcachepartition.Partition) (types.APIObjectList, error) {
+	var result types.APIObjectList
+	changeStore, ok := store.(ChangeStore)
+	if !ok {
+		return result, apierror.NewAPIError(validation.InvalidOption, "the changes of "+schema.ID+" aren't kept")
+	}
+	list, revision, err := changeStore.ChangesByPartitions(apiOp, schema, partitions)
+	if err != nil {
+		return result, err
+	}
+	for _, item := range list {
+		result.Objects = append(result.Objects, partition.ToAPI(schema, &item, nil, s.sqlReservedFields))
+	}
+	result.Count = len(list)
+	result.Revision = revision
+	return result, nil
+}
+
+// DistinctStore is implemented by stores able to count the distinct values of fields among listed objects.
+type DistinctStore interface {
+	DistinctByPartitions(apiOp *types.APIRequest, schema *types.APISchema, partitions []sqlcachepartition.Partition) (map[string][]listprocessor.DistinctValue, error)
+}
+
+// Distinct returns the distinct values of the fields requested with the distinct query param, among the objects the
+// user is allowed to list.
+func (s *Store) Distinct(apiOp *types.APIRequest, schema *types.APISchema) (map[string][]listprocessor.DistinctValue, error) {
+	store, ok := s.Partitioner.Store().(DistinctStore)
+	if !ok {
+		return nil, fmt.Errorf("store for %s does not support distinct values", schema.ID)
+	}
+
+	partitions, err := s.Partitioner.All(apiOp, schema, "list", "")
+	if err != nil {
+		return nil, err
+	}
+	return store.DistinctByPartitions(apiOp, schema, partitions)
+}
+
+// SampleStore is implemented by stores able to return a sample of the objects of a type.
+type SampleStore interface {
+	SampleByPartitions(apiOp *types.APIRequest, schema *types.APISchema, partitions []sqlcachepartition.Partition, limit int) ([]unstructured.Unstructured, error)
+}
+
+// Sample returns up to limit objects of a schema's type among the objects the user is allowed to list.
+func (s *Store) Sample(apiOp *types.APIRequest, schema *types.APISchema, limit int) ([]unstructured.Unstructured, error) {
+	store, ok := s.Partitioner.Store().(SampleStore)
+	if !ok {
+		return nil, fmt.Errorf("store for %s does not support samples", schema.ID)
+	}
+
+	partitions, err := s.Partitioner.All(apiOp, schema, "list", "")
+	if err != nil {
+		return nil, err
+	}
+	return store.SampleByPartitions(apiOp, schema, partitions, limit)
+}
+
 // Create creates a single object in the store.
 func (s *Store) Create(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject) (types.APIObject, error) {
+	apiOp, span := tracing.StartStore(apiOp, "sqlpartition.Create", schema)
+	defer span.End()
+
 	target := s.Partitioner.Store()
 
 	obj, warnings, err := target.Create(apiOp, schema, data)
@@ -116,6 +228,9 @@ func (s *Store) Create(apiOp *types.APIRequest, schema *types.APISchema, data ty
 
 // Update updates a single object in the store.
 func (s *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject, id string) (types.APIObject, error) {
+	apiOp, span := tracing.StartStore(apiOp, "sqlpartition.Update", schema)
+	defer span.End()
+
 	target := s.Partitioner.Store()
 
 	obj, warnings, err := target.Update(apiOp, schema, data, id)
@@ -127,6 +242,10 @@ func (s *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, data ty
 
 // Watch returns a channel of events for a list or resource.
 func (s *Store) Watch(apiOp *types.APIRequest, schema *types.APISchema, wr types.WatchRequest) (chan types.APIEvent, error) {
+	if projectID := listprocessor.WatchProject(wr.Selector); projectID != "" {
+		// watches made of query params are limited to their project as lists are
+		apiOp = listprocessor.WithProject(apiOp, projectID)
+	}
 	partitions, err := s.Partitioner.All(apiOp, schema, "watch", wr.ID)
 	if err != nil {
 		return nil, err
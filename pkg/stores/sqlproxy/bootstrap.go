@@ -0,0 +1,45 @@
+package sqlproxy
+
+import (
+	"context"
+
+	"github.com/sirupsen/logrus"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// Bootstrap lists the objects of types from a snapshot of the cache, with the resourceVersion their changes since the
+// snapshot are watched from. ok is false for the types which can't be bootstrapped, and for those bootstrapped already.
+type Bootstrap interface {
+	List(ctx context.Context, gvk schema.GroupVersionKind) (list *unstructured.UnstructuredList, ok bool, err error)
+}
+
+// bootstrapped returns the client of the informer of a type, which lists the objects of the snapshot the first time
+func (s *Store) bootstrapped(gvk schema.GroupVersionKind, client dynamic.ResourceInterface) dynamic.ResourceInterface {
+	if s.bootstrap == nil {
+		return client
+	}
+	return &bootstrapClient{ResourceInterface: client, gvk: gvk, bootstrap: s.bootstrap}
+}
+
+// bootstrapClient lists the objects of a type from a snapshot, and from the API server once they were listed. The
+// informer then watches the changes since the resourceVersion of the snapshot, and lists again from the API server if
+// it is too old to be watched from.
+type bootstrapClient struct {
+	dynamic.ResourceInterface
+	gvk       schema.GroupVersionKind
+	bootstrap Bootstrap
+}
+
+func (b *bootstrapClient) List(ctx context.Context, opts metav1.ListOptions) (*unstructured.UnstructuredList, error) {
+	list, ok, err := b.bootstrap.List(ctx, b.gvk)
+	if err != nil {
+		logrus.Warnf("failed to bootstrap the cache of %s from the snapshot, listing it from the API server: %v", b.gvk, err)
+	} else if ok {
+		logrus.Infof("bootstrapped the cache of %s with %d objects from the snapshot, watching changes since revision %s", b.gvk, len(list.Items), list.GetResourceVersion())
+		return list, nil
+	}
+	return b.ResourceInterface.List(ctx, opts)
+}
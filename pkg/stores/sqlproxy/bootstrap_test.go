@@ -0,0 +1,57 @@
+package sqlproxy
+
+import (
+	"context"
+	"fmt"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// fakeBootstrap bootstraps each type once from its list
+type fakeBootstrap map[schema.GroupVersionKind]*unstructured.UnstructuredList
+
+func (f fakeBootstrap) List(_ context.Context, gvk schema.GroupVersionKind) (*unstructured.UnstructuredList, bool, error) {
+	if gvk.Kind == "Broken" {
+		return nil, false, fmt.Errorf("broken snapshot")
+	}
+	list, ok := f[gvk]
+	delete(f, gvk)
+	return list, ok, nil
+}
+
+func TestBootstrapClient(t *testing.T) {
+	ctx := context.Background()
+	pods := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	snapshot := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{{Object: map[string]interface{}{"kind": "Pod"}}}}
+	snapshot.SetResourceVersion("10")
+	listed := &unstructured.UnstructuredList{}
+	listed.SetResourceVersion("20")
+
+	s := &Store{}
+	ri := NewMockResourceInterface(gomock.NewController(t))
+	assert.Equal(t, ri, s.bootstrapped(pods, ri), "stores without a bootstrap list from the API server")
+
+	s.bootstrap = fakeBootstrap{pods: snapshot}
+	client := s.bootstrapped(pods, ri)
+	list, err := client.List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, snapshot, list, "the first list is that of the snapshot")
+
+	// lists again, such as when the revision of the snapshot is too old to watch from, are from the API server
+	ri.EXPECT().List(ctx, metav1.ListOptions{ResourceVersion: "0"}).Return(listed, nil)
+	list, err = client.List(ctx, metav1.ListOptions{ResourceVersion: "0"})
+	require.NoError(t, err)
+	assert.Equal(t, listed, list)
+
+	// as are those of types which can't be bootstrapped
+	ri.EXPECT().List(ctx, metav1.ListOptions{}).Return(listed, nil)
+	list, err = s.bootstrapped(schema.GroupVersionKind{Version: "v1", Kind: "Broken"}, ri).List(ctx, metav1.ListOptions{})
+	require.NoError(t, err)
+	assert.Equal(t, listed, list)
+}
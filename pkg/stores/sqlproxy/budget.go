@@ -0,0 +1,184 @@
+package sqlproxy
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/metrics"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var tooManyRequests = validation.ErrorCode{
+	Code:   "TooManyRequests",
+	Status: http.StatusTooManyRequests,
+}
+
+// listBudget bounds the memory taken by the objects of lists read from the cache, per request and across concurrent
+// requests. The memory of a list is estimated from the number of objects it returns, counted by the cache, and from
+// the JSON size of a sample object of its type.
+type listBudget struct {
+	perRequest int64
+	global     int64
+
+	lock sync.Mutex
+	// inFlight is the estimated memory of the lists being read
+	inFlight int64
+	// sizes are the sizes of the last sample object of each schema
+	sizes map[string]int64
+}
+
+// newListBudget returns the memory budgets of lists read from the cache, in bytes of JSON, nil if both are disabled
+func newListBudget(perRequest, global int64) *listBudget {
+	if perRequest <= 0 && global <= 0 {
+		return nil
+	}
+	return &listBudget{
+		perRequest: perRequest,
+		global:     global,
+		sizes:      map[string]int64{},
+	}
+}
+
+// reserve estimates the memory of a list and reserves it in the global budget until release is called. It returns the
+// options to list with, limited to the objects fitting the per-request budget if the list can be paginated and isn't
+// already. Stores without budgets list with opts unchanged.
+func (b *listBudget) reserve(ctx context.Context, cache listprocessor.Cache, schema *types.APISchema, opts informer.ListOptions, partitions []partition.Partition, namespace string, paginatable bool) (informer.ListOptions, func(), error) {
+	if b == nil {
+		return opts, func() {}, nil
+	}
+
+	limit := pageLimit(opts)
+	size, known := b.size(schema.ID)
+	var estimate int64
+	if known && limit > 0 && (b.perRequest <= 0 || int64(limit)*size <= b.perRequest) {
+		// pages which fit the budget are not counted beforehand, their size is bounded by their limit
+		estimate = int64(limit) * size
+	} else {
+		var rows int
+		var err error
+		rows, size, err = b.probe(ctx, cache, schema, opts, partitions, namespace)
+		if err != nil {
+			return opts, nil, err
+		}
+		estimate = int64(rows) * size
+	}
+
+	if b.perRequest > 0 && estimate > b.perRequest {
+		paginated := opts.Pagination.PageSize > 0 || opts.Pagination.Page > 0
+		if !paginatable || paginated || size > b.perRequest {
+			metrics.RecordListBudgetExceeded(schema.ID, metrics.ListBudgetRejected)
+			return opts, nil, apierror.NewAPIError(validation.MaxLimitExceeded,
+				fmt.Sprintf("the list is estimated to take %d bytes, more than the %d bytes allowed, use a smaller limit or pagesize", estimate, b.perRequest))
+		}
+		metrics.RecordListBudgetExceeded(schema.ID, metrics.ListBudgetPaginated)
+		opts.ChunkSize = int(b.perRequest / size)
+		estimate = int64(opts.ChunkSize) * size
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	// a single list is never rejected by the global budget, so that lists over it can still be read one at a time
+	if b.global > 0 && b.inFlight > 0 && b.inFlight+estimate > b.global {
+		metrics.RecordListBudgetExceeded(schema.ID, metrics.ListBudgetThrottled)
+		return opts, nil, apierror.NewAPIError(tooManyRequests, "too many large lists are being read, retry later")
+	}
+	b.inFlight += estimate
+	return opts, func() {
+		b.lock.Lock()
+		defer b.lock.Unlock()
+		b.inFlight -= estimate
+	}, nil
+}
+
+// probe returns the number of objects a list would return, and the size of a sample object, reading a single object
+// from the cache
+func (b *listBudget) probe(ctx context.Context, cache listprocessor.Cache, schema *types.APISchema, opts informer.ListOptions, partitions []partition.Partition, namespace string) (int, int64, error) {
+	probeOpts := opts
+	probeOpts.ChunkSize = 1
+	probeOpts.Resume = ""
+	probeOpts.Pagination = informer.Pagination{}
+	probeOpts.Sort = informer.Sort{}
+	list, total, _, err := cache.ListByOptions(ctx, probeOpts, partitions, namespace)
+	if err != nil {
+		return 0, 0, err
+	}
+	size, _ := b.size(schema.ID)
+	if len(list.Items) > 0 {
+		size, err = sampleSize(list.Items[0])
+		if err != nil {
+			return 0, 0, err
+		}
+		b.lock.Lock()
+		b.sizes[schema.ID] = size
+		b.lock.Unlock()
+	}
+
+	offset, _ := strconv.Atoi(opts.Resume)
+	if opts.Pagination.Page > 1 {
+		offset += opts.Pagination.PageSize * (opts.Pagination.Page - 1)
+	}
+	rows := max(total-offset, 0)
+	if limit := pageLimit(opts); limit > 0 {
+		rows = min(rows, limit)
+	}
+	return rows, size, nil
+}
+
+// sizer returns how the memory of listed objects of a schema is estimated, the same way as that of lists: from the
+// size of the last sample object of the schema, or of the first listed object if none was sampled yet. Stores without
+// budgets always sample the first listed object.
+func (b *listBudget) sizer(schemaID string) func([]unstructured.Unstructured) int64 {
+	return func(items []unstructured.Unstructured) int64 {
+		if len(items) == 0 {
+			return 0
+		}
+		var size int64
+		var known bool
+		if b != nil {
+			size, known = b.size(schemaID)
+		}
+		if !known {
+			var err error
+			if size, err = sampleSize(items[0]); err != nil {
+				return 0
+			}
+		}
+		return int64(len(items)) * size
+	}
+}
+
+// sampleSize returns the size in bytes of JSON of a sample object
+func sampleSize(obj unstructured.Unstructured) (int64, error) {
+	data, err := json.Marshal(obj.Object)
+	if err != nil {
+		return 0, err
+	}
+	return int64(len(data)), nil
+}
+
+func (b *listBudget) size(schemaID string) (int64, bool) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	size, ok := b.sizes[schemaID]
+	return size, ok
+}
+
+// pageLimit returns the maximum number of objects a list returns, zero if it isn't limited, the same way the cache
+// does
+func pageLimit(opts informer.ListOptions) int {
+	limit := opts.Pagination.PageSize
+	if limit == 0 || (opts.ChunkSize > 0 && opts.ChunkSize < limit) {
+		limit = opts.ChunkSize
+	}
+	return max(limit, 0)
+}
@@ -0,0 +1,76 @@
+package sqlproxy
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestListBudget(t *testing.T) {
+	ctx := context.Background()
+	schema := &types.APISchema{Schema: &schemas.Schema{ID: "pod"}}
+	partitions := []partition.Partition{{Passthrough: true}}
+	sample := map[string]interface{}{"metadata": map[string]interface{}{"name": "web"}}
+	data, err := json.Marshal(sample)
+	require.NoError(t, err)
+	size := int64(len(data))
+	probeOpts := informer.ListOptions{ChunkSize: 1}
+	probe := func(cache *MockCache, total int) {
+		cache.EXPECT().ListByOptions(ctx, probeOpts, partitions, "").Return(
+			&unstructured.UnstructuredList{Items: []unstructured.Unstructured{{Object: sample}}}, total, "1", nil)
+	}
+	apiErrorCode := func(err error) validation.ErrorCode {
+		var apiError *apierror.APIError
+		require.True(t, errors.As(err, &apiError))
+		return apiError.Code
+	}
+
+	var s Store
+	opts := informer.ListOptions{ChunkSize: 100000}
+	result, release, err := s.listBudget.reserve(ctx, nil, schema, opts, partitions, "", true)
+	require.NoError(t, err)
+	release()
+	assert.Equal(t, opts, result, "lists are not bounded without budgets")
+
+	s.listBudget = newListBudget(10*size, 25*size)
+	cache := NewMockCache(gomock.NewController(t))
+	probe(cache, 1000)
+	result, release, err = s.listBudget.reserve(ctx, cache, schema, opts, partitions, "", true)
+	require.NoError(t, err)
+	assert.Equal(t, 10, result.ChunkSize, "lists which aren't paginated are limited to the objects fitting the budget")
+	release()
+
+	paginated := informer.ListOptions{ChunkSize: 100000, Pagination: informer.Pagination{PageSize: 500, Page: 1}}
+	probe(cache, 1000)
+	_, _, err = s.listBudget.reserve(ctx, cache, schema, paginated, partitions, "", true)
+	assert.Equal(t, validation.MaxLimitExceeded, apiErrorCode(err))
+	probe(cache, 1000)
+	_, _, err = s.listBudget.reserve(ctx, cache, schema, opts, partitions, "", false)
+	assert.Equal(t, validation.MaxLimitExceeded, apiErrorCode(err), "lists filtered after the cache can't be paginated")
+
+	// the size of the type is known, so pages fitting the budget are not counted
+	small := informer.ListOptions{ChunkSize: 100000, Pagination: informer.Pagination{PageSize: 10, Page: 1}}
+	_, release1, err := s.listBudget.reserve(ctx, cache, schema, small, partitions, "", true)
+	require.NoError(t, err)
+	_, release2, err := s.listBudget.reserve(ctx, cache, schema, small, partitions, "", true)
+	require.NoError(t, err)
+	_, _, err = s.listBudget.reserve(ctx, cache, schema, small, partitions, "", true)
+	assert.Equal(t, tooManyRequests, apiErrorCode(err), "concurrent lists are bounded by the global budget")
+	release1()
+	_, release3, err := s.listBudget.reserve(ctx, cache, schema, small, partitions, "", true)
+	require.NoError(t, err)
+	release2()
+	release3()
+}
@@ -0,0 +1,214 @@
+package sqlproxy
+
+import (
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+const (
+	// ChangeAdded, ChangeModified and ChangeDeleted are the values of the changeType field of the objects listed by
+	// the change feed
+	ChangeAdded    = "added"
+	ChangeModified = "modified"
+	ChangeDeleted  = "deleted"
+
+	changeTypeField = "changeType"
+)
+
+// changeFeedDelay is how long changes are held before they are listed. The informers deliver the changes of different
+// objects out of order when several changes of an object are queued, so that a change listed right away could be
+// followed by the delivery of an older one, which clients listing changes since the newer one would miss.
+var changeFeedDelay = time.Second
+
+// change is a change made to an object, at the revision of the object after the change, or of its deletion
+type change struct {
+	changeType string
+	revision   uint64
+	obj        *unstructured.Unstructured
+	received   time.Time
+}
+
+// changeLog records the changes made to the objects of a type since its cache was created, up to a number of changes
+type changeLog struct {
+	informer cache.SharedIndexInformer
+	now      func() time.Time
+
+	lock    sync.Mutex
+	size    int
+	changes []change
+	// from is the revision after which all the changes are kept, those made before it being unknown or dropped
+	from uint64
+}
+
+func (l *changeLog) add(changeType string, obj interface{}) {
+	if tombstone, ok := obj.(cache.DeletedFinalStateUnknown); ok {
+		obj = tombstone.Obj
+	}
+	u, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	revision, err := strconv.ParseUint(u.GetResourceVersion(), 10, 64)
+	if err != nil {
+		return
+	}
+
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if revision <= l.from {
+		return
+	}
+	// changes are kept in the order of their revisions
+	i := sort.Search(len(l.changes), func(i int) bool {
+		return l.changes[i].revision > revision
+	})
+	l.changes = slices.Insert(l.changes, i, change{changeType: changeType, revision: revision, obj: u, received: l.now()})
+	if len(l.changes) > l.size {
+		l.from = l.changes[0].revision
+		l.changes = l.changes[1:]
+	}
+}
+
+// since returns the changes made since a revision, and the revision they bring clients to, which is that of the last
+// change or the revision since which changes are kept. Changes since a revision older than the changes kept can't be
+// returned, changes since 0 returning all those kept.
+func (l *changeLog) since(revision uint64) ([]change, uint64, error) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if revision != 0 && revision < l.from {
+		return nil, 0, apierror.NewAPIError(ErrRevisionGone,
+			fmt.Sprintf("too old resource version: %d (%d), list the objects again", revision, l.from))
+	}
+	current := l.from
+	var result []change
+	for _, c := range l.changes {
+		if l.now().Sub(c.received) < changeFeedDelay {
+			// the following changes are listed once no older one can be delivered anymore
+			break
+		}
+		current = c.revision
+		if c.revision > revision {
+			result = append(result, c)
+		}
+	}
+	return result, current, nil
+}
+
+// changeFeed keeps the change logs of the types of the SQL cache
+type changeFeed struct {
+	size int
+
+	lock sync.Mutex
+	logs map[schema.GroupVersionKind]*changeLog
+}
+
+func newChangeFeed(size int) *changeFeed {
+	return &changeFeed{
+		size: size,
+		logs: map[schema.GroupVersionKind]*changeLog{},
+	}
+}
+
+// log returns the change log of a type, starting it on the informer of its cache if the type has none yet, or the
+// cache was recreated since.
+func (f *changeFeed) log(gvk schema.GroupVersionKind, inf cache.SharedIndexInformer) *changeLog {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if l, ok := f.logs[gvk]; ok && l.informer == inf {
+		return l
+	}
+	l := &changeLog{informer: inf, size: f.size, now: time.Now}
+	// the objects of the initial list aren't changes, those following it are
+	_, err := inf.AddEventHandler(cache.ResourceEventHandlerDetailedFuncs{
+		AddFunc: func(obj interface{}, isInInitialList bool) {
+			if !isInInitialList {
+				l.add(ChangeAdded, obj)
+			}
+		},
+		UpdateFunc: func(_, obj interface{}) {
+			l.add(ChangeModified, obj)
+		},
+		DeleteFunc: func(obj interface{}) {
+			l.add(ChangeDeleted, obj)
+		},
+	})
+	if err != nil {
+		logrus.Errorf("failed to record the changes of %s: %v", gvk, err)
+	}
+	// the changes made since the revision the informer reached are all received, possibly with a few before it
+	from, _ := strconv.ParseUint(inf.LastSyncResourceVersion(), 10, 64)
+	l.lock.Lock()
+	l.from = from
+	l.lock.Unlock()
+	f.logs[gvk] = l
+	return l
+}
+
+// changeLog returns the change log of the type of a cache, nil if changes aren't kept or the cache doesn't watch
+func (s *Store) changeLog(gvk schema.GroupVersionKind, lister informer.ByOptionsLister) *changeLog {
+	if s.changes == nil {
+		return nil
+	}
+	inf, ok := lister.(*informer.Informer)
+	if !ok || inf.SharedIndexInformer == nil {
+		return nil
+	}
+	return s.changes.log(gvk, inf.SharedIndexInformer)
+}
+
+// ChangesByPartitions returns the objects belonging to any of the partitions changed since the revision of the
+// changesSince query param, matching the filters of the request, oldest change first, and the revision the changes
+// bring clients to. The type of each change is set in the changeType field of its object.
+func (s *Store) ChangesByPartitions(apiOp *types.APIRequest, schema *types.APISchema, partitions []partition.Partition) ([]unstructured.Unstructured, string, error) {
+	since, _, err := listprocessor.ParseChangesSince(apiOp)
+	if err != nil {
+		return nil, "", err
+	}
+	columnTypes := s.columnTypes(schema)
+	opts, err := listprocessor.ParseQuery(apiOp, listprocessor.ParseOptions{NamespaceCache: s.namespaceCache, ColumnTypes: columnTypes})
+	if err != nil {
+		return nil, "", err
+	}
+	inf, err := s.cacheFor(apiOp, schema)
+	if err != nil {
+		return nil, "", err
+	}
+	log := s.changeLog(attributes.GVK(schema), inf.ByOptionsLister)
+	if log == nil {
+		return nil, "", apierror.NewAPIError(validation.InvalidOption, "the changes of "+schema.ID+" aren't kept")
+	}
+	changes, current, err := log.since(since)
+	if err != nil {
+		return nil, "", err
+	}
+	projects, err := s.projectNamespaces(apiOp.Context(), partitions)
+	if err != nil {
+		return nil, "", err
+	}
+	result := []unstructured.Unstructured{}
+	for _, c := range changes {
+		if !inPartitions(*c.obj, partitions, apiOp.Namespace, projects) || !listprocessor.MatchesFilters(*c.obj, opts.Filters, columnTypes) {
+			continue
+		}
+		obj := c.obj.DeepCopy()
+		obj.Object[changeTypeField] = c.changeType
+		result = append(result, *obj)
+	}
+	return result, strconv.FormatUint(current, 10), nil
+}
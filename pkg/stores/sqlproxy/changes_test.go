@@ -0,0 +1,128 @@
+package sqlproxy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+func changedPod(name, revision string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"metadata": map[string]interface{}{
+			"name":            name,
+			"namespace":       "default",
+			"resourceVersion": revision,
+		},
+	}}
+}
+
+func TestChangeFeed(t *testing.T) {
+	defer func(delay time.Duration) { changeFeedDelay = delay }(changeFeedDelay)
+	changeFeedDelay = 0
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	watcher := watch.NewFake()
+	inf := cache.NewSharedIndexInformer(&cache.ListWatch{
+		ListFunc: func(metav1.ListOptions) (runtime.Object, error) {
+			list := &unstructured.UnstructuredList{Items: []unstructured.Unstructured{*changedPod("pod1", "5")}}
+			list.SetResourceVersion("10")
+			return list, nil
+		},
+		WatchFunc: func(metav1.ListOptions) (watch.Interface, error) {
+			return watcher, nil
+		},
+	}, &unstructured.Unstructured{}, 0, cache.Indexers{})
+	go inf.Run(ctx.Done())
+	require.True(t, cache.WaitForCacheSync(ctx.Done(), inf.HasSynced))
+
+	feed := newChangeFeed(3)
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	log := feed.log(gvk, inf)
+	assert.Same(t, log, feed.log(gvk, inf), "the log of a type is started once")
+
+	watcher.Add(changedPod("pod2", "11"))
+	watcher.Modify(changedPod("pod1", "12"))
+	watcher.Delete(changedPod("pod2", "13"))
+	require.Eventually(t, func() bool {
+		_, current, err := log.since(10)
+		return err == nil && current == 13
+	}, 5*time.Second, 10*time.Millisecond)
+
+	describe := func(changes []change) []string {
+		result := []string{}
+		for _, c := range changes {
+			result = append(result, c.changeType+" "+c.obj.GetName()+" "+c.obj.GetResourceVersion())
+		}
+		return result
+	}
+	changes, current, err := log.since(10)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(13), current)
+	assert.Equal(t, []string{"added pod2 11", "modified pod1 12", "deleted pod2 13"}, describe(changes),
+		"the initial list isn't a change, and changes are ordered by revision")
+
+	changes, current, err = log.since(12)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(13), current)
+	assert.Equal(t, []string{"deleted pod2 13"}, describe(changes))
+
+	changes, current, err = log.since(13)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(13), current)
+	assert.Empty(t, changes)
+
+	// the oldest change is dropped beyond the size of the log
+	watcher.Add(changedPod("pod3", "14"))
+	require.Eventually(t, func() bool {
+		_, current, _ := log.since(0)
+		return current == 14
+	}, 5*time.Second, 10*time.Millisecond)
+	_, _, err = log.since(10)
+	var apiErr *apierror.APIError
+	require.ErrorAs(t, err, &apiErr)
+	assert.Equal(t, ErrRevisionGone, apiErr.Code)
+	changes, _, err = log.since(11)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"modified pod1 12", "deleted pod2 13", "added pod3 14"}, describe(changes))
+	changes, _, err = log.since(0)
+	require.NoError(t, err)
+	assert.Len(t, changes, 3, "changes since 0 are all those kept")
+}
+
+func TestChangeLogDelay(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	log := &changeLog{size: 10, from: 10, now: func() time.Time { return now }}
+	log.add(ChangeAdded, changedPod("pod1", "11"))
+	log.add(ChangeDeleted, changedPod("pod2", "13"))
+	now = now.Add(changeFeedDelay / 2)
+	// an older change delivered late, and one older than the changes kept
+	log.add(ChangeModified, changedPod("pod3", "12"))
+	log.add(ChangeModified, changedPod("pod4", "9"))
+	now = now.Add(changeFeedDelay / 2)
+
+	changes, current, err := log.since(10)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(11), current, "changes are held until older ones can't be delivered anymore")
+	assert.Len(t, changes, 1)
+
+	now = now.Add(changeFeedDelay)
+	changes, current, err = log.since(11)
+	require.NoError(t, err)
+	assert.Equal(t, uint64(13), current)
+	require.Len(t, changes, 2)
+	assert.Equal(t, "pod3", changes[0].obj.GetName())
+	assert.Equal(t, "pod2", changes[1].obj.GetName())
+}
@@ -0,0 +1,81 @@
+package sqlproxy
+
+import (
+	"fmt"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+// HardeningMode controls whether list results are verified against the partitions the requester has access to, as a
+// defense against bugs in the generation of SQL queries.
+type HardeningMode string
+
+const (
+	// HardeningOff does not verify list results
+	HardeningOff HardeningMode = ""
+	// HardeningLog logs any object returned outside of the requester's partitions
+	HardeningLog HardeningMode = "log"
+	// HardeningBlock logs any object returned outside of the requester's partitions and fails the request
+	HardeningBlock HardeningMode = "block"
+)
+
+// ParseHardeningMode returns the HardeningMode corresponding to mode, or an error if it is not a known mode.
+func ParseHardeningMode(mode string) (HardeningMode, error) {
+	switch HardeningMode(mode) {
+	case HardeningOff, HardeningLog, HardeningBlock:
+		return HardeningMode(mode), nil
+	}
+	return HardeningOff, fmt.Errorf("unknown hardening mode %q, expected one of %q, %q or %q", mode, HardeningOff, HardeningLog, HardeningBlock)
+}
+
+// verifyPartitions checks that every item belongs to one of the partitions and to namespace, if set, according to
+// the hardening mode.
+func (s *Store) verifyPartitions(apiOp *types.APIRequest, schema *types.APISchema, items []unstructured.Unstructured, partitions []partition.Partition) error {
+	if s.hardeningMode == HardeningOff {
+		return nil
+	}
+
+	projects, err := s.projectNamespaces(apiOp.Context(), partitions)
+	if err != nil {
+		return err
+	}
+	mismatches := 0
+	for _, item := range items {
+		if inPartitions(item, partitions, apiOp.Namespace, projects) {
+			continue
+		}
+		mismatches++
+		logrus.Errorf("sql cache returned %s %s/%s outside of the requester's partitions", schema.ID, item.GetNamespace(), item.GetName())
+	}
+
+	if mismatches > 0 && s.hardeningMode == HardeningBlock {
+		return apierror.NewAPIError(validation.ServerError, fmt.Sprintf("list of %s failed partition verification", schema.ID))
+	}
+	return nil
+}
+
+// inPartitions returns true if item matches at least one of the partitions, and namespace if set. The namespaces of the
+// projects of partitions are those of projects.
+func inPartitions(item unstructured.Unstructured, partitions []partition.Partition, namespace string, projects map[string]sets.Set[string]) bool {
+	if namespace != "" && namespace != "*" && item.GetNamespace() != namespace {
+		return false
+	}
+	for _, p := range partitions {
+		if p.Passthrough {
+			return true
+		}
+		if !inScope(item.GetNamespace(), p, projects) {
+			continue
+		}
+		if p.All || p.Names.Has(item.GetName()) {
+			return true
+		}
+	}
+	return false
+}
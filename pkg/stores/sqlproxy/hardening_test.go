@@ -0,0 +1,152 @@
+package sqlproxy
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func newHardeningTestItem(namespace, name string) unstructured.Unstructured {
+	item := unstructured.Unstructured{Object: map[string]interface{}{}}
+	item.SetNamespace(namespace)
+	item.SetName(name)
+	return item
+}
+
+func TestInPartitions(t *testing.T) {
+	tests := []struct {
+		name       string
+		item       unstructured.Unstructured
+		partitions []partition.Partition
+		namespace  string
+		expected   bool
+	}{
+		{
+			name:       "passthrough partition matches everything",
+			item:       newHardeningTestItem("ns1", "a"),
+			partitions: []partition.Partition{{Passthrough: true}},
+			expected:   true,
+		},
+		{
+			name:       "namespace partition matches items of its namespace",
+			item:       newHardeningTestItem("ns1", "a"),
+			partitions: []partition.Partition{{Namespace: "ns2", All: true}, {Namespace: "ns1", All: true}},
+			expected:   true,
+		},
+		{
+			name:       "namespace partition does not match items of other namespaces",
+			item:       newHardeningTestItem("ns3", "a"),
+			partitions: []partition.Partition{{Namespace: "ns1", All: true}},
+			expected:   false,
+		},
+		{
+			name:       "names partition matches listed names only",
+			item:       newHardeningTestItem("ns1", "b"),
+			partitions: []partition.Partition{{Namespace: "ns1", Names: sets.New("a")}},
+			expected:   false,
+		},
+		{
+			name:       "requested namespace is enforced",
+			item:       newHardeningTestItem("ns1", "a"),
+			partitions: []partition.Partition{{Passthrough: true}},
+			namespace:  "ns2",
+			expected:   false,
+		},
+		{
+			name:     "no partitions matches nothing",
+			item:     newHardeningTestItem("ns1", "a"),
+			expected: false,
+		},
+		{
+			name:       "project partition matches items of the namespaces of the project",
+			item:       newHardeningTestItem("ns1", "a"),
+			partitions: []partition.Partition{partition.Project("p-1")},
+			expected:   true,
+		},
+		{
+			name:       "project partition does not match items of other namespaces",
+			item:       newHardeningTestItem("ns3", "a"),
+			partitions: []partition.Partition{partition.Project("p-1")},
+			expected:   false,
+		},
+		{
+			name:       "project partition of names matches listed names only",
+			item:       newHardeningTestItem("ns2", "b"),
+			partitions: []partition.Partition{{Project: "p-1", Names: sets.New("a")}},
+			expected:   false,
+		},
+	}
+	projects := map[string]sets.Set[string]{"p-1": sets.New("ns1", "ns2")}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.expected, inPartitions(test.item, test.partitions, test.namespace, projects))
+		})
+	}
+}
+
+func TestVerifyPartitions(t *testing.T) {
+	apiOp := &types.APIRequest{Request: &http.Request{}}
+	schema := &types.APISchema{Schema: &schemas.Schema{ID: "pods"}}
+	partitions := []partition.Partition{{Namespace: "ns1", All: true}}
+	leaked := []unstructured.Unstructured{newHardeningTestItem("ns1", "a"), newHardeningTestItem("ns2", "b")}
+
+	tests := []struct {
+		name        string
+		mode        HardeningMode
+		items       []unstructured.Unstructured
+		errExpected bool
+	}{
+		{
+			name:  "off mode does not verify",
+			mode:  HardeningOff,
+			items: leaked,
+		},
+		{
+			name:  "log mode does not fail the request",
+			mode:  HardeningLog,
+			items: leaked,
+		},
+		{
+			name:        "block mode fails the request on mismatches",
+			mode:        HardeningBlock,
+			items:       leaked,
+			errExpected: true,
+		},
+		{
+			name:  "block mode accepts matching items",
+			mode:  HardeningBlock,
+			items: leaked[:1],
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			s := &Store{}
+			s.hardeningMode = test.mode
+			err := s.verifyPartitions(apiOp, schema, test.items, partitions)
+			if test.errExpected {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestParseHardeningMode(t *testing.T) {
+	mode, err := ParseHardeningMode("block")
+	assert.NoError(t, err)
+	assert.Equal(t, HardeningBlock, mode)
+
+	mode, err = ParseHardeningMode("")
+	assert.NoError(t, err)
+	assert.Equal(t, HardeningOff, mode)
+
+	_, err = ParseHardeningMode("strict")
+	assert.Error(t, err)
+}
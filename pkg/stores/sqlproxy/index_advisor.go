@@ -0,0 +1,100 @@
+package sqlproxy
+
+import (
+	"strings"
+	"sync"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/metrics"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/sirupsen/logrus"
+)
+
+// maxUnindexedFields bounds the number of unindexed fields counted per schema, as they come from requests
+const maxUnindexedFields = 100
+
+// alwaysIndexedFields are the fields the SQL cache indexes for all types, besides those it is given
+var alwaysIndexedFields = []string{"metadata.name", "metadata.creationTimestamp", "metadata.namespace"}
+
+// indexAdvisor counts the fields lists filter or sort on which aren't indexed in the cache, as candidates to be indexed
+type indexAdvisor struct {
+	lock   sync.Mutex
+	counts map[string]map[string]int
+}
+
+func newIndexAdvisor() *indexAdvisor {
+	return &indexAdvisor{
+		counts: map[string]map[string]int{},
+	}
+}
+
+// record counts the unindexed fields of the filters and sort of a list of a schema. Stores which weren't created with
+// NewProxyStore have no advisor.
+func (a *indexAdvisor) record(schema *types.APISchema, indexedFields [][]string, opts informer.ListOptions) {
+	if a == nil {
+		return
+	}
+	indexed := map[string]bool{}
+	for _, field := range alwaysIndexedFields {
+		indexed[field] = true
+	}
+	for _, field := range indexedFields {
+		indexed[strings.Join(field, ".")] = true
+	}
+
+	var unindexed []string
+	add := func(field []string) {
+		name := strings.Join(field, ".")
+		if len(field) > 0 && !indexed[name] {
+			indexed[name] = true
+			unindexed = append(unindexed, name)
+		}
+	}
+	for _, orFilter := range opts.Filters {
+		for _, filter := range orFilter.Filters {
+			// labels are filtered on in the labels table, whether their column is indexed or not
+			if _, ok := informer.LabelKey(filter.Field); !ok {
+				add(filter.Field)
+			}
+		}
+	}
+	add(opts.Sort.PrimaryField)
+	add(opts.Sort.SecondaryField)
+	if len(unindexed) == 0 {
+		return
+	}
+
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	counts, ok := a.counts[schema.ID]
+	if !ok {
+		counts = map[string]int{}
+		a.counts[schema.ID] = counts
+	}
+	for _, field := range unindexed {
+		count, ok := counts[field]
+		if !ok && len(counts) >= maxUnindexedFields {
+			continue
+		}
+		if !ok {
+			logrus.Warnf("Lists of %s filter or sort on %s, which isn't indexed in the SQL cache of %s", schema.ID, field, attributes.GVK(schema))
+		}
+		counts[field] = count + 1
+		metrics.RecordUnindexedField(schema.ID, field)
+	}
+}
+
+// fields returns how many times lists of a schema filtered or sorted on each unindexed field
+func (a *indexAdvisor) fields(schemaID string) map[string]int {
+	if a == nil {
+		return nil
+	}
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	result := make(map[string]int, len(a.counts[schemaID]))
+	for field, count := range a.counts[schemaID] {
+		result[field] = count
+	}
+	return result
+}
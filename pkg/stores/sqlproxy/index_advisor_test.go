@@ -0,0 +1,82 @@
+package sqlproxy
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestIndexAdvisorRecord(t *testing.T) {
+	schema := &types.APISchema{Schema: &schemas.Schema{ID: "pods"}}
+	indexed := [][]string{{"spec", "nodeName"}, {"metadata", "labels[app]"}}
+	tests := []struct {
+		name     string
+		opts     []informer.ListOptions
+		expected map[string]int
+	}{
+		{
+			name: "indexed fields are not counted",
+			opts: []informer.ListOptions{{
+				Filters: []informer.OrFilter{{Filters: []informer.Filter{
+					{Field: []string{"spec", "nodeName"}},
+					{Field: []string{"metadata", "labels[app]"}},
+					{Field: []string{"metadata", "namespace"}},
+				}}},
+				Sort: informer.Sort{PrimaryField: []string{"metadata", "name"}},
+			}},
+			expected: map[string]int{},
+		},
+		{
+			name: "unindexed filters and sorts are counted once per list",
+			opts: []informer.ListOptions{
+				{
+					Filters: []informer.OrFilter{
+						{Filters: []informer.Filter{{Field: []string{"spec", "hostname"}}, {Field: []string{"spec", "hostname"}}}},
+						{Filters: []informer.Filter{{Field: []string{"metadata", "labels[tier]"}}}},
+					},
+					Sort: informer.Sort{PrimaryField: []string{"spec", "priority"}, SecondaryField: []string{"spec", "hostname"}},
+				},
+				{
+					// any label can be filtered on, but only indexed ones sorted on
+					Filters: []informer.OrFilter{{Filters: []informer.Filter{{Field: []string{"spec", "hostname"}}}}},
+					Sort:    informer.Sort{PrimaryField: []string{"metadata", "labels[tier]"}},
+				},
+			},
+			expected: map[string]int{
+				"spec.hostname":         2,
+				"metadata.labels[tier]": 1,
+				"spec.priority":         1,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			a := newIndexAdvisor()
+			for _, opts := range test.opts {
+				a.record(schema, indexed, opts)
+			}
+			assert.Equal(t, test.expected, a.fields("pods"))
+			assert.Empty(t, a.fields("nodes"))
+		})
+	}
+}
+
+func TestIndexAdvisorBounded(t *testing.T) {
+	schema := &types.APISchema{Schema: &schemas.Schema{ID: "pods"}}
+	a := newIndexAdvisor()
+	for i := 0; i < maxUnindexedFields+10; i++ {
+		a.record(schema, nil, informer.ListOptions{Sort: informer.Sort{PrimaryField: []string{"spec", fmt.Sprintf("field%d", i)}}})
+	}
+	a.record(schema, nil, informer.ListOptions{Sort: informer.Sort{PrimaryField: []string{"spec", "field0"}}})
+	fields := a.fields("pods")
+	assert.Len(t, fields, maxUnindexedFields)
+	assert.Equal(t, 2, fields["spec.field0"])
+
+	var nilAdvisor *indexAdvisor
+	nilAdvisor.record(schema, nil, informer.ListOptions{Sort: informer.Sort{PrimaryField: []string{"spec", "field0"}}})
+	assert.Nil(t, nilAdvisor.fields("pods"))
+}
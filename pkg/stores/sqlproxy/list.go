@@ -0,0 +1,280 @@
+package sqlproxy
+
+import (
+	"errors"
+	"slices"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/resources/virtual/problems"
+	"github.com/rancher/steve/pkg/sqlcache/factory"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// listPlan is how a list is served: the options the cache lists objects with, and what is done with them in memory
+// afterwards
+type listPlan struct {
+	// opts are the options of the request, cacheOpts those the cache lists objects with
+	opts      informer.ListOptions
+	cacheOpts informer.ListOptions
+
+	revisionOpts listprocessor.RevisionOptions
+	columnTypes  listprocessor.ColumnTypes
+	inf          factory.Cache
+	listCache    listprocessor.Cache
+	partitions   []partition.Partition
+
+	// memoryFilters, deleted objects, limits and in-memory sorts are applied on the objects listed by the cache, which
+	// are then paginated. cacheLimits are applied by the cache instead.
+	memoryFilters []informer.OrFilter
+	deleted       []unstructured.Unstructured
+	limits        listLimits
+	cacheLimits   listLimits
+	sortsInMemory bool
+	postProcess   bool
+
+	countOnly          bool
+	metadataOnly       bool
+	metadataFromFields bool
+	usageFields        [][]string
+	warningEvents      *warningEvents
+}
+
+// planList parses the list options of a request, and plans which of them the cache applies and which are applied in
+// memory
+func (s *Store) planList(apiOp *types.APIRequest, schema *types.APISchema, partitions []partition.Partition) (*listPlan, error) {
+	parseOptions := s.parseOptions(schema)
+	opts, err := listprocessor.ParseQuery(apiOp, parseOptions)
+	if err != nil {
+		return nil, err
+	}
+	revisionOpts, err := listprocessor.ParseRevision(apiOp)
+	if err != nil {
+		return nil, err
+	}
+	s.indexAdvisor.record(schema, slices.Concat(s.IndexedFields(schema), [][]string{problems.EventsField}), opts)
+	inf, err := s.cacheFor(apiOp, schema)
+	if err != nil {
+		return nil, err
+	}
+
+	listCache, queryable := s.listCache(inf, schema)
+	projects, err := s.projectNamespaces(apiOp.Context(), partitions)
+	if err != nil {
+		return nil, err
+	}
+	if !queryable {
+		partitions = expandProjects(partitions, projects)
+	}
+	plan := &listPlan{
+		opts:         opts,
+		revisionOpts: revisionOpts,
+		columnTypes:  parseOptions.ColumnTypes,
+		inf:          inf,
+		listCache:    listCache,
+		partitions:   partitions,
+		countOnly:    listprocessor.ParseCountOnly(apiOp),
+		metadataOnly: listprocessor.ParseMetadataOnly(apiOp),
+		usageFields:  s.usageFields(schema),
+	}
+
+	// filters and sorts on fields which aren't in the cache, such as events, range filters and sorts on numbers unless
+	// the cache is a queryCache, and deleted objects are applied on the cache's results, which therefore need to be
+	// paginated afterwards, as are group and namespace limits then. RFC 3339 timestamps sort as text as they do as dates.
+	memoryFields := s.memoryFields(schema)
+	var cacheFilters []informer.OrFilter
+	cacheFilters, plan.memoryFilters = splitFilters(opts.Filters, memoryFields, queryable)
+	if refersToField(opts, problems.EventsField) {
+		if err := s.startWarningEvents(apiOp); err != nil {
+			return nil, err
+		}
+	}
+	plan.warningEvents = s.warningEventCounts()
+	if plan.limits, err = parseLimits(apiOp, schema); err != nil {
+		return nil, err
+	}
+	plan.deleted = s.deletedObjects(apiOp, schema, partitions, projects, opts)
+	plan.sortsInMemory = sortsInMemory(opts.Sort, memoryFields, queryable, plan.columnTypes)
+
+	// group and namespace limits are applied by the query of the queryCache, unless objects are sorted or filtered in
+	// memory
+	if q, ok := listCache.(queryCache); ok && len(plan.memoryFilters) == 0 && len(plan.deleted) == 0 && !plan.sortsInMemory {
+		q.limits, plan.cacheLimits, plan.limits = plan.limits, plan.limits, listLimits{}
+		plan.listCache = q
+	}
+	plan.postProcess = len(plan.memoryFilters) > 0 || plan.limits != (listLimits{}) || len(plan.deleted) > 0 || plan.sortsInMemory
+	plan.cacheOpts = plan.cacheOptions(cacheFilters)
+
+	// lists of metadata without filters only read the fields table of the cache, rather than whole objects
+	plan.metadataFromFields = plan.metadataOnly && s.metadataLister != nil && len(plan.cacheOpts.Filters) == 0 && !plan.postProcess && plan.cacheLimits == (listLimits{})
+	return plan, nil
+}
+
+// parseLimits returns the group and namespace limits of a list
+func parseLimits(apiOp *types.APIRequest, schema *types.APISchema) (listLimits, error) {
+	groupLimit, err := listprocessor.ParseGroupLimit(apiOp)
+	if err != nil {
+		return listLimits{}, err
+	}
+	maxPerNamespace, err := listprocessor.ParseMaxPerNamespace(apiOp)
+	if err != nil {
+		return listLimits{}, err
+	}
+	if maxPerNamespace > 0 && !attributes.Namespaced(schema) {
+		return listLimits{}, apierror.NewAPIError(validation.InvalidOption, "maxPerNamespace is only supported for namespaced types")
+	}
+	return listLimits{GroupLimit: groupLimit, MaxPerNamespace: maxPerNamespace}, nil
+}
+
+// sortsInMemory returns whether a sort is applied in memory rather than by the cache: sorts on fields which aren't in
+// the cache, and on numbers unless the cache is queryable
+func sortsInMemory(sort informer.Sort, memoryFields [][]string, queryable bool, columnTypes listprocessor.ColumnTypes) bool {
+	for _, field := range [][]string{sort.PrimaryField, sort.SecondaryField} {
+		if isOneOf(field, memoryFields) || (!queryable && columnTypes.Of(field) == listprocessor.NumberColumn) {
+			return true
+		}
+	}
+	return false
+}
+
+// cacheOptions returns the options the cache lists objects with: all the objects matching cacheFilters when they are
+// processed in memory afterwards, and a single unsorted object when they are only counted
+func (p *listPlan) cacheOptions(cacheFilters []informer.OrFilter) informer.ListOptions {
+	cacheOpts := p.opts
+	cacheOpts.Filters = cacheFilters
+	if p.postProcess {
+		cacheOpts.ChunkSize = 0
+		cacheOpts.Resume = ""
+		cacheOpts.Pagination = informer.Pagination{}
+	}
+	if p.sortsInMemory {
+		cacheOpts.Sort = informer.Sort{}
+	}
+	if p.countsInCache() {
+		// caches which can't count objects without listing them only count all matching objects when the list is
+		// paginated, so a single unsorted object is read, unless the sort groups objects
+		cacheOpts.ChunkSize = 1
+		cacheOpts.Resume = ""
+		cacheOpts.Pagination = informer.Pagination{}
+		if p.cacheLimits.GroupLimit == 0 {
+			cacheOpts.Sort = informer.Sort{}
+		}
+	}
+	return cacheOpts
+}
+
+// countsInCache returns whether the objects of a list are only counted, by the cache
+func (p *listPlan) countsInCache() bool {
+	return p.countOnly && !p.postProcess
+}
+
+// reserveBudget reserves the memory of a list in the budget, if any, limiting the options of the cache to the objects
+// fitting it if the list can be paginated. Counts, which only read a single object, and lists of metadata from the
+// fields table aren't bounded.
+func (s *Store) reserveBudget(apiOp *types.APIRequest, schema *types.APISchema, plan *listPlan) (func(), error) {
+	if plan.countsInCache() || plan.metadataFromFields {
+		return func() {}, nil
+	}
+	cacheOpts, release, err := s.listBudget.reserve(apiOp.Context(), s.timed(plan.listCache), schema, plan.cacheOpts, plan.partitions, apiOp.Namespace, !plan.postProcess)
+	if err != nil {
+		return nil, invalidColumnError(err)
+	}
+	plan.cacheOpts = cacheOpts
+	return release, nil
+}
+
+// cacheOf returns the cache a list reads objects from: the fields table for lists of metadata from it, the cache of
+// objects otherwise, checking the revision of the cache when it reports one
+func (s *Store) cacheOf(schema *types.APISchema, plan *listPlan, r revisioner) listprocessor.Cache {
+	gvk := attributes.GVK(schema)
+	var cache listprocessor.Cache = tracedCache{cache: s.timed(plan.listCache), gvk: gvk}
+	if plan.metadataFromFields {
+		cache = tracedCache{cache: s.timed(metadataCache{lister: s.metadataLister, gvk: gvk}), gvk: gvk}
+	}
+	if r != nil {
+		cache = revisionedCache{cache: cache, revisioner: r}
+	}
+	return cache
+}
+
+// resultsOf returns the cached results a list can be served from, none for lists of a revision, which cached results
+// may be older than, and lists of metadata from the fields table, since cached results hold whole objects
+func (s *Store) resultsOf(plan *listPlan) *resultCache {
+	if plan.revisionOpts.Revision > 0 || plan.metadataFromFields {
+		return nil
+	}
+	return s.resultCache
+}
+
+// listFromCache lists the objects of a plan from the cache, or its cached results, checking that the cache didn't move
+// past the revision of lists of an exact revision
+func (s *Store) listFromCache(apiOp *types.APIRequest, schema *types.APISchema, plan *listPlan, r revisioner) (*unstructured.UnstructuredList, int, string, error) {
+	list, total, continueToken, err := s.resultsOf(plan).list(apiOp.Context(), attributes.GVK(schema), s.cacheOf(schema, plan, r), plan.cacheOpts, plan.cacheLimits, plan.partitions, apiOp.Namespace, s.listBudget.sizer(schema.ID))
+	if err != nil {
+		return nil, 0, "", invalidColumnError(err)
+	}
+	if plan.revisionOpts.Match == listprocessor.RevisionMatchExact {
+		if err := checkRevision(r, plan.revisionOpts); err != nil {
+			return nil, 0, "", err
+		}
+	}
+	return list, total, continueToken, nil
+}
+
+// addVirtualFields sets the virtual fields which aren't stored in the cache on the objects listed, their usage and
+// warning events, unless they are only the metadata of objects
+func (s *Store) addVirtualFields(schema *types.APISchema, plan *listPlan, items []unstructured.Unstructured) {
+	if plan.metadataFromFields {
+		return
+	}
+	if len(plan.usageFields) > 0 {
+		s.usage.Add(attributes.GVK(schema), items)
+	}
+	if plan.warningEvents != nil {
+		plan.warningEvents.add(items)
+	}
+}
+
+// processInMemory adds deleted objects to the objects listed by the cache, sorts, filters and limits them in memory,
+// and paginates them, or only counts them for lists only counting objects
+func (p *listPlan) processInMemory(items []unstructured.Unstructured) ([]unstructured.Unstructured, int, string, error) {
+	if len(p.deleted) > 0 {
+		items = append(items, p.deleted...)
+	}
+	if p.sortsInMemory || len(p.deleted) > 0 {
+		listprocessor.SortItems(items, p.opts.Sort, p.columnTypes)
+	}
+	items = listprocessor.FilterItems(items, p.memoryFilters, p.columnTypes)
+	items = listprocessor.LimitGroups(items, p.opts.Sort.PrimaryField, p.limits.GroupLimit)
+	items = listprocessor.LimitPerNamespace(items, p.limits.MaxPerNamespace)
+	if p.countOnly {
+		return nil, len(items), "", nil
+	}
+	items, total, continueToken, err := listprocessor.Paginate(items, p.opts)
+	if err != nil {
+		return nil, 0, "", apierror.NewAPIError(validation.InvalidFormat, err.Error())
+	}
+	return p.metadata(items), total, continueToken, nil
+}
+
+// metadata returns only the metadata of objects for lists of metadata, unless they were read from the fields table
+func (p *listPlan) metadata(items []unstructured.Unstructured) []unstructured.Unstructured {
+	if p.metadataOnly && !p.metadataFromFields {
+		return listprocessor.ToPartialObjectMetadata(items)
+	}
+	return items
+}
+
+// invalidColumnError returns errors of filters or sorts on fields which aren't columns of the cache as errors of the
+// request
+func invalidColumnError(err error) error {
+	if errors.Is(err, informer.InvalidColumnErr) {
+		return apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+	}
+	return err
+}
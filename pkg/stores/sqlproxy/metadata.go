@@ -0,0 +1,26 @@
+package sqlproxy
+
+import (
+	"context"
+
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// MetadataLister lists the metadata of the objects of the cache, as PartialObjectMetadata objects, without reading the
+// objects themselves. It doesn't support filters.
+type MetadataLister interface {
+	ListMetadata(ctx context.Context, gvk schema.GroupVersionKind, lo informer.ListOptions, partitions []partition.Partition, namespace string) (*unstructured.UnstructuredList, int, string, error)
+}
+
+// metadataCache lists the metadata of the objects of a type
+type metadataCache struct {
+	lister MetadataLister
+	gvk    schema.GroupVersionKind
+}
+
+func (m metadataCache) ListByOptions(ctx context.Context, lo informer.ListOptions, partitions []partition.Partition, namespace string) (*unstructured.UnstructuredList, int, string, error) {
+	return m.lister.ListMetadata(ctx, m.gvk, lo, partitions, namespace)
+}
@@ -0,0 +1,145 @@
+package sqlproxy
+
+import (
+	"fmt"
+	"slices"
+	"time"
+
+	"github.com/rancher/steve/pkg/clustercache"
+	"github.com/rancher/steve/pkg/resources/virtual/annotations"
+	"github.com/rancher/steve/pkg/resources/virtual/computed"
+	"github.com/rancher/steve/pkg/resources/virtual/conditions"
+	"github.com/rancher/steve/pkg/resources/virtual/derived"
+	"github.com/rancher/steve/pkg/resources/virtual/identity"
+	"github.com/rancher/steve/pkg/resources/virtual/ingest"
+	"github.com/rancher/steve/pkg/resources/virtual/owners"
+	"github.com/rancher/steve/pkg/resources/virtual/problems"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Options are the optional features of a Store. The zero value disables all of them.
+type Options struct {
+	// AnnotationColumns promotes annotations of specific types into indexed fields
+	AnnotationColumns *annotations.Columns
+	// ComputedFields indexes values derived from the objects of specific types
+	ComputedFields *computed.Fields
+	// Conditions sets the conditions of objects of all types by condition type, which are indexed for the types
+	// opting into ExtraConditions
+	Conditions *conditions.Conditions
+	// DerivedFields applies the rules of embedders deriving virtual fields, indexing the fields they declare
+	DerivedFields *derived.Registry
+	// IndexedExtras are the types the extra virtual fields of objects are indexed for
+	IndexedExtras IndexedExtras
+
+	// HardeningMode sets whether list results are verified against the requester's partitions
+	HardeningMode HardeningMode
+	// DefaultSort is the sort applied to lists of types without a default sort attribute when the request has no sort
+	// param, in the format of the sort query param
+	DefaultSort string
+	// ListBudget is the memory lists read from the cache can take, in bytes of JSON. Lists estimated to exceed it are
+	// paginated if they aren't already, or rejected otherwise. Zero disables it.
+	ListBudget int64
+	// GlobalListBudget is the memory the lists being read from the cache can take at once, in bytes of JSON. Lists are
+	// rejected while it is exceeded. Zero disables it.
+	GlobalListBudget int64
+	// ResultCacheTTL is how long the results of identical lists are cached, or until objects of their type change in
+	// ClusterCache, which it requires. Results are not cached if it is zero.
+	ResultCacheTTL time.Duration
+	// ResultCacheSize bounds the memory of the cached results of lists, estimated the same way as that of lists, unless
+	// it is zero
+	ResultCacheSize int64
+	// ClusterCache notifies the changes of objects invalidating the cached results of lists
+	ClusterCache clustercache.ClusterCache
+	// QueryTimeout is how long each query of the cache, by lists, counts, distinct values and the lookups of their
+	// namespaces, can run before it is interrupted and fails with ErrQueryTimeout. Queries are also interrupted when
+	// their request is canceled, such as when its client disconnects. Queries are only bounded by their request if it
+	// is zero.
+	QueryTimeout time.Duration
+	// MetadataLister lists the metadata of objects, for lists only asking for the metadata of objects without filters.
+	// Other lists of metadata read whole objects and return their metadata.
+	MetadataLister MetadataLister
+	// ChangeFeedSize is how many changes are kept for each type from the creation of its cache, which are listed with
+	// the changesSince query param. Changes aren't kept if it is zero.
+	ChangeFeedSize int
+	// IngestTransformers are applied to objects as they are stored in the cache, after their virtual fields are set
+	IngestTransformers *ingest.Transformers
+	// MaxObjectSize is the size in bytes of JSON above which objects are stored in the cache as a stub, only keeping
+	// their metadata and indexed fields, so that lists can still filter and sort them, and marked with their size in
+	// metadata.truncated.size. Lists return the stubs, while objects got by ID are read whole from Kubernetes. Objects
+	// are stored whole if it is zero.
+	MaxObjectSize int64
+	// FieldManager is the field manager of server-side applies which don't set one with the fieldManager query param.
+	// It is proxy.DefaultFieldManager if empty.
+	FieldManager string
+
+	// Tombstones are the recently deleted objects, listed when requests set the includeDeleted param
+	Tombstones Tombstones
+	// Usage is the source of the resource usage of objects, which is indexed in the cache to be listed, sorted and
+	// filtered on
+	Usage Usage
+	// Replicas are the replicas of mirrored types, whose objects are listed from them rather than the API server.
+	// Mirrored objects are only listed: they can't be got by ID or watched, since those requests go to the API server.
+	Replicas Replicas
+	// Bootstrap starts the caches of types from the objects of a snapshot of the cache, such as one taken by another
+	// replica, only watching the changes made since the snapshot rather than listing every object from the API server.
+	// Caches are listed from the API server if their changes can't be watched from the snapshot anymore, and after
+	// resets.
+	Bootstrap Bootstrap
+}
+
+// Extra is a group of virtual fields set on the objects of all types, which are only indexed for the types opting into
+// it, so that the tables of other types don't grow with them. Lists of other types filter and sort on them in memory.
+type Extra string
+
+const (
+	// ExtraOwners are the UIDs and names of the owners of objects, which the ownedBy param and filters on owner
+	// references match
+	ExtraOwners Extra = "owners"
+	// ExtraProblems are the counts of the error and warning conditions of objects
+	ExtraProblems Extra = "problems"
+	// ExtraApplication are the application identities of objects, which applications are listed by
+	ExtraApplication Extra = "application"
+	// ExtraConditions are the conditions of the configured condition types of objects
+	ExtraConditions Extra = "conditions"
+)
+
+// Extras are all the extras, in the order their fields are indexed
+var Extras = []Extra{ExtraConditions, ExtraOwners, ExtraProblems, ExtraApplication}
+
+// ParseExtra returns the Extra of the given name, or an error if it is not a known extra
+func ParseExtra(name string) (Extra, error) {
+	if extra := Extra(name); slices.Contains(Extras, extra) {
+		return extra, nil
+	}
+	return "", fmt.Errorf("unknown extra %q, expected one of %q", name, Extras)
+}
+
+// IndexedExtras are the extras indexed for each type. The extras of the zero GroupVersionKind are indexed for all
+// types.
+type IndexedExtras map[schema.GroupVersionKind][]Extra
+
+// Has returns true if the extra is indexed for the type
+func (e IndexedExtras) Has(gvk schema.GroupVersionKind, extra Extra) bool {
+	return slices.Contains(e[gvk], extra) || slices.Contains(e[schema.GroupVersionKind{}], extra)
+}
+
+// extraFields returns the fields of the extras of a type, those which are indexed, or those which aren't
+func (s *Store) extraFields(gvk schema.GroupVersionKind, indexed bool) [][]string {
+	var fields [][]string
+	for _, extra := range Extras {
+		if s.indexedExtras.Has(gvk, extra) != indexed {
+			continue
+		}
+		switch extra {
+		case ExtraConditions:
+			fields = append(fields, s.conditions.Fields()...)
+		case ExtraOwners:
+			fields = append(fields, owners.Fields...)
+		case ExtraProblems:
+			fields = append(fields, problems.Fields...)
+		case ExtraApplication:
+			fields = append(fields, identity.Fields...)
+		}
+	}
+	return fields
+}
@@ -0,0 +1,55 @@
+package sqlproxy
+
+import (
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/resources/virtual/conditions"
+	"github.com/rancher/steve/pkg/resources/virtual/owners"
+	"github.com/rancher/steve/pkg/resources/virtual/problems"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestIndexedExtras(t *testing.T) {
+	podGVK := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	replicaSetGVK := schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "ReplicaSet"}
+	schemaOf := func(gvk schema.GroupVersionKind) *types.APISchema {
+		s := &types.APISchema{Schema: &schemas.Schema{}}
+		attributes.SetGVK(s, gvk)
+		return s
+	}
+	indexedConditions, err := conditions.New([]string{"Ready"})
+	require.NoError(t, err)
+	s := &Store{
+		conditions: indexedConditions,
+		indexedExtras: IndexedExtras{
+			podGVK:                    {ExtraOwners},
+			schema.GroupVersionKind{}: {ExtraProblems},
+		},
+	}
+
+	// extras are indexed for the types opting into them, and filtered and sorted on in memory for the others
+	pods, replicaSets := schemaOf(podGVK), schemaOf(replicaSetGVK)
+	assert.Subset(t, s.IndexedFields(pods), owners.Fields)
+	assert.Subset(t, s.IndexedFields(pods), problems.Fields)
+	assert.NotContains(t, s.IndexedFields(pods), indexedConditions.Fields()[0])
+	assert.Subset(t, s.memoryFields(pods), indexedConditions.Fields())
+	assert.NotContains(t, s.IndexedFields(replicaSets), owners.UIDsField)
+	assert.Subset(t, s.IndexedFields(replicaSets), problems.Fields)
+	assert.Subset(t, s.memoryFields(replicaSets), owners.Fields)
+	assert.NotContains(t, s.memoryFields(replicaSets), problems.ErrorsField)
+
+	// no extra is indexed by default
+	assert.Subset(t, (&Store{}).memoryFields(pods), owners.Fields)
+	assert.NotContains(t, (&Store{}).IndexedFields(pods), owners.UIDsField)
+
+	extra, err := ParseExtra("application")
+	require.NoError(t, err)
+	assert.Equal(t, ExtraApplication, extra)
+	_, err = ParseExtra("labels")
+	assert.Error(t, err)
+}
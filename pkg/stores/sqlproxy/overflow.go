@@ -0,0 +1,87 @@
+package sqlproxy
+
+import (
+	"encoding/json"
+	"maps"
+	"strings"
+
+	"github.com/rancher/steve/pkg/resources/virtual/ingest"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+// capSize returns transformFunc storing the objects larger than the max object size as stubs keeping fields
+func (s *Store) capSize(transformFunc cache.TransformFunc, fields [][]string) cache.TransformFunc {
+	maxSize := s.maxObjectSize
+	if maxSize <= 0 {
+		return transformFunc
+	}
+	return func(raw interface{}) (interface{}, error) {
+		transformed, err := transformFunc(raw)
+		if err != nil {
+			return nil, err
+		}
+		obj, ok := transformed.(*unstructured.Unstructured)
+		if !ok {
+			return transformed, nil
+		}
+		encoded, err := json.Marshal(obj.Object)
+		if err != nil {
+			return nil, err
+		}
+		if int64(len(encoded)) <= maxSize {
+			return obj, nil
+		}
+		return overflowStub(obj, fields, int64(len(encoded))), nil
+	}
+}
+
+// overflowStub returns the stub of an object of the given size, with its metadata, id and fields, marked as truncated
+func overflowStub(obj *unstructured.Unstructured, fields [][]string, size int64) *unstructured.Unstructured {
+	stub := map[string]interface{}{}
+	for _, name := range []string{"apiVersion", "kind", "id", "metadata"} {
+		if value, ok := obj.Object[name]; ok {
+			stub[name] = value
+		}
+	}
+	// the maps stripped are copied, not to modify the object
+	if metadata, ok := stub["metadata"].(map[string]interface{}); ok {
+		metadata = maps.Clone(metadata)
+		if annotations, ok := metadata["annotations"].(map[string]interface{}); ok {
+			metadata["annotations"] = maps.Clone(annotations)
+		}
+		stub["metadata"] = metadata
+	}
+	for _, field := range fields {
+		copyField(stub, obj.Object, field)
+	}
+	result := &unstructured.Unstructured{Object: stub}
+	unstructured.RemoveNestedField(stub, "metadata", "managedFields")
+	unstructured.RemoveNestedField(stub, "metadata", "annotations", ingest.LastAppliedAnnotation)
+	_ = unstructured.SetNestedField(stub, size, "metadata", "truncated", "size")
+	return result
+}
+
+// copyField copies the value at path from src to dst. Values under lists are copied with their whole list, and
+// subscripted names such as labels[app] with their whole map.
+func copyField(dst, src map[string]interface{}, path []string) {
+	if len(path) == 0 {
+		return
+	}
+	name, _, _ := strings.Cut(path[0], "[")
+	value, ok := src[name]
+	if !ok {
+		return
+	}
+	child, isMap := value.(map[string]interface{})
+	if len(path) == 1 || !isMap {
+		dst[name] = value
+		return
+	}
+	dstChild, ok := dst[name].(map[string]interface{})
+	if !ok {
+		dstChild = map[string]interface{}{}
+		dst[name] = dstChild
+	}
+	copyField(dstChild, child, path[1:])
+}
@@ -0,0 +1,88 @@
+package sqlproxy
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/rancher/steve/pkg/resources/virtual/ingest"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+func TestCapSize(t *testing.T) {
+	identity := func(obj interface{}) (interface{}, error) { return obj, nil }
+	fields := [][]string{
+		{"metadata", "name"},
+		{"type"},
+		{"spec", "selector", "app"},
+		{"status", "conditions", "type"},
+		{"metadata", "fields[2]"},
+	}
+	newObj := func(data string) *unstructured.Unstructured {
+		return &unstructured.Unstructured{Object: map[string]interface{}{
+			"apiVersion": "v1",
+			"kind":       "Secret",
+			"id":         "default/release",
+			"metadata": map[string]interface{}{
+				"name":          "release",
+				"namespace":     "default",
+				"labels":        map[string]interface{}{"owner": "helm"},
+				"managedFields": []interface{}{map[string]interface{}{"manager": "helm"}},
+				"annotations": map[string]interface{}{
+					ingest.LastAppliedAnnotation: "{}",
+					"note":                       "kept",
+				},
+				"fields": []interface{}{"release", "Opaque", int64(1)},
+			},
+			"type": "helm.sh/release.v1",
+			"data": map[string]interface{}{"release": data},
+			"spec": map[string]interface{}{
+				"selector": map[string]interface{}{"app": "web"},
+				"other":    "dropped",
+			},
+			"status": map[string]interface{}{
+				"conditions": []interface{}{map[string]interface{}{"type": "Ready", "status": "True"}},
+			},
+		}}
+	}
+
+	s := &Store{}
+	assert.NotNil(t, s.capSize(identity, fields))
+	obj, err := s.capSize(identity, fields)(newObj(strings.Repeat("x", 10000)))
+	require.NoError(t, err)
+	_, found, _ := unstructured.NestedMap(obj.(*unstructured.Unstructured).Object, "data")
+	assert.True(t, found, "objects are stored whole without a max size")
+
+	s.maxObjectSize = 2000
+	small := newObj("small")
+	obj, err = s.capSize(identity, fields)(small)
+	require.NoError(t, err)
+	assert.Equal(t, small, obj)
+
+	large := newObj(strings.Repeat("x", 10000))
+	obj, err = s.capSize(identity, fields)(large)
+	require.NoError(t, err)
+	stub := obj.(*unstructured.Unstructured)
+	assert.Equal(t, "release", stub.GetName())
+	assert.Equal(t, "default", stub.GetNamespace())
+	assert.Equal(t, "default/release", stub.Object["id"])
+	assert.Equal(t, "Secret", stub.GetKind())
+	assert.Equal(t, map[string]string{"owner": "helm"}, stub.GetLabels())
+	assert.Equal(t, map[string]string{"note": "kept"}, stub.GetAnnotations())
+	assert.Empty(t, stub.GetManagedFields())
+	assert.Equal(t, "helm.sh/release.v1", stub.Object["type"])
+	assert.Equal(t, map[string]interface{}{"selector": map[string]interface{}{"app": "web"}}, stub.Object["spec"])
+	assert.Equal(t, large.Object["status"], stub.Object["status"])
+	fieldValues, _, _ := unstructured.NestedSlice(stub.Object, "metadata", "fields")
+	assert.Equal(t, []interface{}{"release", "Opaque", int64(1)}, fieldValues)
+	assert.NotContains(t, stub.Object, "data")
+	size, found, _ := unstructured.NestedInt64(stub.Object, "metadata", "truncated", "size")
+	assert.True(t, found)
+	assert.Greater(t, size, int64(10000))
+
+	// the original object is not modified
+	assert.NotEmpty(t, large.GetManagedFields())
+	assert.Contains(t, large.GetAnnotations(), ingest.LastAppliedAnnotation)
+	assert.NotContains(t, large.Object["metadata"], "truncated")
+}
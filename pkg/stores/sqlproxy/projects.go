@@ -0,0 +1,139 @@
+package sqlproxy
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+// ProjectNamespaces returns the names of the namespaces of a project, those whose field.cattle.io/projectId label is
+// its ID, from the namespace cache
+func (s *Store) ProjectNamespaces(ctx context.Context, projectID string) (sets.Set[string], error) {
+	list, err := s.listNamespaces(ctx, informer.Filter{Field: partition.ProjectField, Match: projectID, Op: informer.Eq})
+	if err != nil {
+		return nil, err
+	}
+	namespaces := sets.New[string]()
+	for _, ns := range list {
+		// ListByOptions matches values by pattern, which IDs could be
+		if ns.GetLabels()[partition.ProjectIDLabel] == projectID {
+			namespaces.Insert(ns.GetName())
+		}
+	}
+	return namespaces, nil
+}
+
+// namespaceProject returns the ID of the project of a namespace, empty if it has none or doesn't exist
+func (s *Store) namespaceProject(ctx context.Context, namespace string) (string, error) {
+	list, err := s.listNamespaces(ctx, informer.Filter{Field: []string{"metadata", "name"}, Match: namespace, Op: informer.Eq})
+	if err != nil {
+		return "", err
+	}
+	for _, ns := range list {
+		if ns.GetName() == namespace {
+			return ns.GetLabels()[partition.ProjectIDLabel], nil
+		}
+	}
+	return "", nil
+}
+
+func (s *Store) listNamespaces(ctx context.Context, filter informer.Filter) ([]unstructured.Unstructured, error) {
+	if s.namespaceCache == nil {
+		return nil, fmt.Errorf("the namespace cache, which the namespaces of projects are read from, isn't initialized")
+	}
+	list, _, _, err := s.namespaceCache.ListByOptions(ctx, informer.ListOptions{
+		Filters: []informer.OrFilter{{Filters: []informer.Filter{filter}}},
+	}, []partition.Partition{{Passthrough: true}}, "")
+	if err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// projectNamespaces returns the namespaces of the projects of partitions by project, nil if none of the partitions is
+// that of a project
+func (s *Store) projectNamespaces(ctx context.Context, partitions []partition.Partition) (map[string]sets.Set[string], error) {
+	if !partition.HasProjects(partitions) {
+		return nil, nil
+	}
+	projects := map[string]sets.Set[string]{}
+	for _, p := range partitions {
+		if p.Project == "" || p.Passthrough {
+			continue
+		}
+		if _, ok := projects[p.Project]; ok {
+			continue
+		}
+		namespaces, err := s.ProjectNamespaces(ctx, p.Project)
+		if err != nil {
+			return nil, fmt.Errorf("listing the namespaces of project %s: %w", p.Project, err)
+		}
+		projects[p.Project] = namespaces
+	}
+	return projects, nil
+}
+
+// expandProjects returns the partitions with those of projects replaced by a partition for each namespace of the
+// project, for caches which can't limit objects to the namespaces of projects on their own, such as ListByOptions. Projects
+// without namespaces have no partition, as none of their objects belong to them.
+func expandProjects(partitions []partition.Partition, projects map[string]sets.Set[string]) []partition.Partition {
+	if projects == nil {
+		return partitions
+	}
+	var result []partition.Partition
+	for _, p := range partitions {
+		if p.Project == "" || p.Passthrough {
+			result = append(result, p)
+			continue
+		}
+		for _, namespace := range sets.List(projects[p.Project]) {
+			result = append(result, partition.Partition{Namespace: namespace, All: p.All, Names: p.Names})
+		}
+	}
+	return result
+}
+
+// inScope returns true if an object of itemNamespace is in the namespaces of the project of a partition, or in its
+// namespace, if it is limited to any
+func inScope(itemNamespace string, p partition.Partition, projects map[string]sets.Set[string]) bool {
+	if p.Project != "" {
+		return projects[p.Project].Has(itemNamespace)
+	}
+	return p.Namespace == "" || p.Namespace == "*" || p.Namespace == itemNamespace
+}
+
+// watchProject returns the events of a watch of all namespaces for the objects in the namespaces of a project. The
+// project of the namespace of each object is looked up when its event is received, so that namespaces moving in and
+// out of the project during the watch are followed.
+func (s *Store) watchProject(ctx context.Context, projectID string, events chan watch.Event) chan watch.Event {
+	result := make(chan watch.Event)
+	go func() {
+		defer close(result)
+		for event := range events {
+			if event.Type != watch.Error {
+				m, err := meta.Accessor(event.Object)
+				if err != nil {
+					logrus.Debugf("watch of project %s cannot process unexpected object: %s", projectID, err)
+					continue
+				}
+				project, err := s.namespaceProject(ctx, m.GetNamespace())
+				if err != nil {
+					logrus.Debugf("watch of project %s failed to get the project of namespace %s: %s", projectID, m.GetNamespace(), err)
+					continue
+				}
+				if project != projectID {
+					continue
+				}
+			}
+			result <- event
+		}
+	}()
+	return result
+}
@@ -4,15 +4,16 @@ package sqlproxy
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
 	"net/http"
 	"os"
+	"slices"
 	"strconv"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/pkg/errors"
 	"github.com/sirupsen/logrus"
@@ -22,7 +23,6 @@ import (
 	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/runtime/schema"
-	apitypes "k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/sets"
 	"k8s.io/apimachinery/pkg/watch"
 	"k8s.io/client-go/dynamic"
@@ -32,9 +32,10 @@ import (
 
 	"github.com/rancher/apiserver/pkg/apierror"
 	"github.com/rancher/apiserver/pkg/types"
-	"github.com/rancher/lasso/pkg/cache/sql/informer"
-	"github.com/rancher/lasso/pkg/cache/sql/informer/factory"
-	"github.com/rancher/lasso/pkg/cache/sql/partition"
+	"github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/rancher/steve/pkg/sqlcache/factory"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
 	"github.com/rancher/wrangler/v3/pkg/data"
 	"github.com/rancher/wrangler/v3/pkg/schemas"
 	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
@@ -44,8 +45,16 @@ import (
 	controllerschema "github.com/rancher/steve/pkg/controllers/schema"
 	"github.com/rancher/steve/pkg/resources/common"
 	"github.com/rancher/steve/pkg/resources/virtual"
+	"github.com/rancher/steve/pkg/resources/virtual/annotations"
 	virtualCommon "github.com/rancher/steve/pkg/resources/virtual/common"
+	"github.com/rancher/steve/pkg/resources/virtual/computed"
+	"github.com/rancher/steve/pkg/resources/virtual/conditions"
+	"github.com/rancher/steve/pkg/resources/virtual/derived"
+	"github.com/rancher/steve/pkg/resources/virtual/ingest"
+	"github.com/rancher/steve/pkg/resources/virtual/problems"
+	"github.com/rancher/steve/pkg/schema/table"
 	metricsStore "github.com/rancher/steve/pkg/stores/metrics"
+	"github.com/rancher/steve/pkg/stores/proxy"
 	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
 	"github.com/rancher/steve/pkg/stores/sqlproxy/tablelistconvert"
 )
@@ -149,9 +158,14 @@ var (
 			{"metadata", "annotations[storageclass.kubernetes.io/is-default-class]"},
 		},
 	}
+	// commonIndexFields are indexed for all types. The state and resource version of objects are counted by the
+	// count schema from the fields table.
 	commonIndexFields = [][]string{
 		{`id`},
 		{`metadata`, `state`, `name`},
+		{`metadata`, `state`, `error`},
+		{`metadata`, `state`, `transitioning`},
+		{`metadata`, `resourceVersion`},
 	}
 	baseNSSchema = types.APISchema{
 		Schema: &schemas.Schema{
@@ -219,14 +233,65 @@ type TransformBuilder interface {
 }
 
 type Store struct {
-	clientGetter     ClientGetter
-	notifier         RelationshipNotifier
-	cacheFactory     CacheFactory
-	cfInitializer    CacheFactoryInitializer
-	namespaceCache   Cache
-	lock             sync.Mutex
-	columnSetter     SchemaColumnSetter
-	transformBuilder TransformBuilder
+	clientGetter      ClientGetter
+	notifier          RelationshipNotifier
+	cacheFactory      CacheFactory
+	cfInitializer     CacheFactoryInitializer
+	namespaceCache    Cache
+	lock              sync.Mutex
+	columnSetter      SchemaColumnSetter
+	transformBuilder  TransformBuilder
+	annotationColumns *annotations.Columns
+	computedFields    *computed.Fields
+	conditions        *conditions.Conditions
+	derivedFields     *derived.Registry
+	indexedExtras     IndexedExtras
+	hardeningMode     HardeningMode
+	defaultSort       string
+	tombstones        Tombstones
+	usage             Usage
+	listBudget        *listBudget
+	resultCache       *resultCache
+	indexAdvisor      *indexAdvisor
+	metadataLister    MetadataLister
+	changes           *changeFeed
+	replicas          Replicas
+	bootstrap         Bootstrap
+	ingest            *ingest.Transformers
+	maxObjectSize     int64
+	queryTimeout      time.Duration
+	fieldManager      string
+
+	// warningEvents counts the warning events of objects once the cache of events is created
+	warningEventsLock sync.Mutex
+	warningEvents     *warningEvents
+	// countEventsAtStart creates the cache of events whenever the store is reset
+	countEventsAtStart bool
+
+	// syncedGVKs are the types whose cache was synced since the last reset
+	syncedLock sync.Mutex
+	syncedGVKs map[schema.GroupVersionKind]bool
+}
+
+// Tombstones lists recently deleted objects
+type Tombstones interface {
+	List(gvk schema.GroupVersionKind) []unstructured.Unstructured
+}
+
+// Replicas are the replicas of types mirrored from other Steves, whose caches list and watch them in place of the API
+// server
+type Replicas interface {
+	// Client returns the client of the replica of a type, if it is mirrored
+	Client(gvk schema.GroupVersionKind) (dynamic.ResourceInterface, bool)
+}
+
+// Usage adds the resource usage of objects to them. Usage fields are indexed, objects being given their usage when
+// they are written to the cache, and the columns of usage being updated as it changes.
+type Usage interface {
+	// Fields returns the usage fields of objects of a type, if any
+	Fields(gvk schema.GroupVersionKind) [][]string
+	// Add sets the usage fields of objects of a type, those of objects written to the cache or listed
+	Add(gvk schema.GroupVersionKind, items []unstructured.Unstructured)
 }
 
 type CacheFactoryInitializer func() (CacheFactory, error)
@@ -236,13 +301,42 @@ type CacheFactory interface {
 	Reset() error
 }
 
-// NewProxyStore returns a Store implemented directly on top of kubernetes.
-func NewProxyStore(c SchemaColumnSetter, clientGetter ClientGetter, notifier RelationshipNotifier, scache virtualCommon.SummaryCache, factory CacheFactory) (*Store, error) {
+// NewProxyStore returns a Store implemented directly on top of kubernetes, with the optional features of opts. ctx
+// bounds the handlers the store registers, such as those invalidating the cached results of lists.
+func NewProxyStore(ctx context.Context, c SchemaColumnSetter, clientGetter ClientGetter, notifier RelationshipNotifier, scache virtualCommon.SummaryCache, factory CacheFactory, opts Options) (*Store, error) {
 	store := &Store{
-		clientGetter:     clientGetter,
-		notifier:         notifier,
-		columnSetter:     c,
-		transformBuilder: virtual.NewTransformBuilder(scache),
+		clientGetter:      clientGetter,
+		notifier:          notifier,
+		columnSetter:      c,
+		transformBuilder:  virtual.NewTransformBuilder(scache, opts.AnnotationColumns, opts.ComputedFields, opts.Conditions, opts.DerivedFields),
+		annotationColumns: opts.AnnotationColumns,
+		computedFields:    opts.ComputedFields,
+		conditions:        opts.Conditions,
+		derivedFields:     opts.DerivedFields,
+		indexedExtras:     opts.IndexedExtras,
+		hardeningMode:     opts.HardeningMode,
+		defaultSort:       opts.DefaultSort,
+		tombstones:        opts.Tombstones,
+		usage:             opts.Usage,
+		listBudget:        newListBudget(opts.ListBudget, opts.GlobalListBudget),
+		indexAdvisor:      newIndexAdvisor(),
+		metadataLister:    opts.MetadataLister,
+		replicas:          opts.Replicas,
+		bootstrap:         opts.Bootstrap,
+		ingest:            opts.IngestTransformers,
+		maxObjectSize:     opts.MaxObjectSize,
+		queryTimeout:      opts.QueryTimeout,
+		fieldManager:      opts.FieldManager,
+	}
+	if opts.ChangeFeedSize > 0 {
+		store.changes = newChangeFeed(opts.ChangeFeedSize)
+	}
+	if opts.ResultCacheTTL > 0 {
+		if opts.ClusterCache == nil {
+			return nil, fmt.Errorf("caching the results of lists requires a cluster cache to invalidate them")
+		}
+		store.resultCache = newResultCache(opts.ResultCacheTTL, opts.ResultCacheSize)
+		store.resultCache.invalidateOn(ctx, opts.ClusterCache)
 	}
 
 	if factory == nil {
@@ -260,26 +354,32 @@ func NewProxyStore(c SchemaColumnSetter, clientGetter ClientGetter, notifier Rel
 	return store, nil
 }
 
-// Reset locks the store, resets the underlying cache factory, and warm the namespace cache.
+// Reset locks the store, resets the underlying cache factory, and warm the namespace cache, as well as the event cache
+// if warning events are counted from the start.
 func (s *Store) Reset() error {
 	s.lock.Lock()
 	defer s.lock.Unlock()
 	if err := s.cacheFactory.Reset(); err != nil {
 		return err
 	}
+	s.resetSynced()
+	s.resetWarningEvents()
 
 	if err := s.initializeNamespaceCache(); err != nil {
 		return err
 	}
+	if s.countEventsAtStart {
+		return s.initializeEventCache()
+	}
 	return nil
 }
 
 func defaultInitializeCacheFactory() (CacheFactory, error) {
-	informerFactory, err := factory.NewCacheFactory()
+	dbClient, err := db.NewPooledClient(db.InformerObjectCacheDBPath, db.DefaultReadConnections)
 	if err != nil {
 		return nil, err
 	}
-	return informerFactory, nil
+	return factory.NewCacheFactory(dbClient), nil
 }
 
 // initializeNamespaceCache warms up the namespace cache as it is needed to process queries using options related to
@@ -300,14 +400,11 @@ func (s *Store) initializeNamespaceCache() error {
 	}
 
 	gvk := attributes.GVK(&nsSchema)
-	// get fields from schema's columns
-	fields := getFieldsFromSchema(&nsSchema)
-
-	// get any type-specific fields that steve is interested in
-	fields = append(fields, getFieldForGVK(gvk)...)
+	// get fields from schema's columns and any type-specific fields that steve is interested in
+	fields := s.IndexedFields(&nsSchema)
 
 	// get the type-specifc transform func
-	transformFunc := s.transformBuilder.GetTransformFunc(gvk)
+	transformFunc := s.transformFunc(gvk)
 
 	// get the ns informer
 	nsInformer, err := s.cacheFactory.CacheFor(fields, transformFunc, &tablelistconvert.Client{ResourceInterface: client}, attributes.GVK(&nsSchema), false, true)
@@ -319,6 +416,26 @@ func (s *Store) initializeNamespaceCache() error {
 	return nil
 }
 
+// IndexedFields returns all fields of a schema that are indexed in the cache: the schema's columns, the fields common to
+// all types, the type-specific fields, any configured annotation columns, computed fields and derived fields, and the
+// extras the type opts into.
+func (s *Store) IndexedFields(schema *types.APISchema) [][]string {
+	gvk := attributes.GVK(schema)
+	fields := getFieldsFromSchema(schema)
+	fields = append(fields, getFieldForGVK(gvk)...)
+	fields = append(fields, s.annotationColumns.Fields(gvk)...)
+	fields = append(fields, s.computedFields.Fields(gvk)...)
+	fields = append(fields, s.derivedFields.Fields(gvk)...)
+	fields = append(fields, s.usageFields(schema)...)
+	return append(fields, s.extraFields(gvk, true)...)
+}
+
+// UnindexedFields returns how many times lists of a schema filtered or sorted on each field which isn't indexed in the
+// cache, and which is therefore a candidate to be indexed.
+func (s *Store) UnindexedFields(schema *types.APISchema) map[string]int {
+	return s.indexAdvisor.fields(schema.ID)
+}
+
 func getFieldForGVK(gvk schema.GroupVersionKind) [][]string {
 	fields := [][]string{}
 	fields = append(fields, commonIndexFields...)
@@ -333,23 +450,59 @@ func gvkKey(group, version, kind string) string {
 	return group + "_" + version + "_" + kind
 }
 
-// getFieldsFromSchema converts object field names from types.APISchema's format into lasso's
+// getFieldsFromSchema converts object field names from types.APISchema's format into the SQL cache's
 // cache.sql.informer's slice format (e.g. "metadata.resourceVersion" is ["metadata", "resourceVersion"])
 func getFieldsFromSchema(schema *types.APISchema) [][]string {
 	var fields [][]string
-	columns := attributes.Columns(schema)
-	if columns == nil {
-		return nil
+	switch colDefs := attributes.Columns(schema).(type) {
+	case []common.ColumnDefinition:
+		for _, colDef := range colDefs {
+			field := strings.TrimPrefix(colDef.Field, "$.")
+			fields = append(fields, listprocessor.SplitField(field))
+		}
+	case []table.Column:
+		for i, column := range colDefs {
+			fields = append(fields, tableColumnField(i, column))
+		}
 	}
-	colDefs, ok := columns.([]common.ColumnDefinition)
-	if !ok {
-		return nil
+	return fields
+}
+
+// tableColumnField returns the field holding the value of the i-th column of a CRD. Columns registered by embedders
+// have the path of a field prefixed with "$.", while the additionalPrinterColumns of the CRD have a JSONPath
+// evaluated by the API server into the cells of the table of a custom resource, which start with its name.
+func tableColumnField(i int, column table.Column) []string {
+	if field, ok := strings.CutPrefix(column.Field, "$."); ok {
+		return listprocessor.SplitField(field)
 	}
-	for _, colDef := range colDefs {
-		field := strings.TrimPrefix(colDef.Field, "$.")
-		fields = append(fields, strings.Split(field, "."))
+	return []string{"metadata", fmt.Sprintf("fields[%d]", i+1)}
+}
+
+// columnTypes returns the types the columns of a schema's type are compared as by range filters and sorts
+func (s *Store) columnTypes(schema *types.APISchema) listprocessor.ColumnTypes {
+	return ColumnTypes(schema, s.usageFields(schema))
+}
+
+// ColumnTypes returns the types the columns of a schema's type are compared as by range filters and sorts: those
+// declared by the columns of a CRD, and numbers for problems and the usage fields of the type. The date columns of
+// CRDs only have a type when registered by embedders, as the API server renders the others as ages.
+func ColumnTypes(schema *types.APISchema, usageFields [][]string) listprocessor.ColumnTypes {
+	columnTypes := listprocessor.ColumnTypes{"metadata.creationTimestamp": listprocessor.DateColumn}
+	if columns, ok := attributes.Columns(schema).([]table.Column); ok {
+		for i, column := range columns {
+			field := strings.Join(tableColumnField(i, column), ".")
+			switch {
+			case column.Type == "integer" || column.Type == "number":
+				columnTypes[field] = listprocessor.NumberColumn
+			case column.Type == "date" && strings.HasPrefix(column.Field, "$."):
+				columnTypes[field] = listprocessor.DateColumn
+			}
+		}
 	}
-	return fields
+	for _, field := range slices.Concat(usageFields, problems.Fields, [][]string{problems.EventsField}) {
+		columnTypes[strings.Join(field, ".")] = listprocessor.NumberColumn
+	}
+	return columnTypes
 }
 
 // ByID looks up a single object by its ID.
@@ -362,6 +515,9 @@ func decodeParams(apiOp *types.APIRequest, target runtime.Object) error {
 }
 
 func (s *Store) byID(apiOp *types.APIRequest, schema *types.APISchema, namespace, id string) (*unstructured.Unstructured, []types.Warning, error) {
+	if err := s.checkNotMirrored(schema); err != nil {
+		return nil, nil, err
+	}
 	buffer := WarningBuffer{}
 	k8sClient, err := metricsStore.Wrap(s.clientGetter.TableClient(apiOp, schema, namespace, &buffer))
 	if err != nil {
@@ -458,12 +614,40 @@ func (s *Store) listAndWatch(apiOp *types.APIRequest, client dynamic.ResourceInt
 			timeout = int64(userSetTimeout)
 		}
 	}
+	// watches filtered with the query params of lists are filtered by steve, others with a label selector by Kubernetes
+	selector := w.Selector
+	var filtered *filteredWatch
+	if filters, ok, err := listprocessor.ParseWatchFilters(apiOp.Context(), w.Selector, s.parseOptions(schema)); err != nil {
+		returnErr(errors.Wrapf(err, "stopping watch for %s: %v", schema.ID, err), result)
+		return
+	} else if ok {
+		selector = ""
+		if filtered, err = s.newFilteredWatch(apiOp, schema, filters); err != nil {
+			returnErr(errors.Wrapf(err, "stopping watch for %s: %v", schema.ID, err), result)
+			return
+		}
+	}
+	// events of filtered watches are handled one at a time, as they change which objects match
+	var filterLock sync.Mutex
+	send := func(event watch.Event) {
+		if filtered != nil {
+			filterLock.Lock()
+			var ok bool
+			event, ok = filtered.event(event)
+			filterLock.Unlock()
+			if !ok {
+				return
+			}
+		}
+		result <- event
+	}
+
 	k8sClient, _ := metricsStore.Wrap(client, nil)
 	watcher, err := k8sClient.Watch(apiOp, metav1.ListOptions{
 		Watch:           true,
 		TimeoutSeconds:  &timeout,
 		ResourceVersion: rev,
-		LabelSelector:   w.Selector,
+		LabelSelector:   selector,
 	})
 	if err != nil {
 		returnErr(errors.Wrapf(err, "stopping watch for %s: %v", schema.ID, err), result)
@@ -485,7 +669,7 @@ func (s *Store) listAndWatch(apiOp *types.APIRequest, client dynamic.ResourceInt
 				obj, _, err := s.byID(apiOp, schema, rel.Namespace, rel.Name)
 				if err == nil {
 					rowToObject(obj)
-					result <- watch.Event{Type: watch.Modified, Object: obj}
+					send(watch.Event{Type: watch.Modified, Object: obj})
 				} else {
 					returnErr(errors.Wrapf(err, "notifier watch error: %v", err), result)
 				}
@@ -507,7 +691,7 @@ func (s *Store) listAndWatch(apiOp *types.APIRequest, client dynamic.ResourceInt
 			if unstr, ok := event.Object.(*unstructured.Unstructured); ok {
 				rowToObject(unstr)
 			}
-			result <- event
+			send(event)
 		}
 		return fmt.Errorf("closed")
 	})
@@ -564,6 +748,9 @@ func (s *Store) WatchNames(apiOp *types.APIRequest, schema *types.APISchema, w t
 
 // Watch returns a channel of events for a list or resource.
 func (s *Store) Watch(apiOp *types.APIRequest, schema *types.APISchema, w types.WatchRequest) (chan watch.Event, error) {
+	if err := s.checkNotMirrored(schema); err != nil {
+		return nil, err
+	}
 	buffer := &WarningBuffer{}
 	client, err := s.clientGetter.TableClientForWatch(apiOp, schema, apiOp.Namespace, buffer)
 	if err != nil {
@@ -650,28 +837,11 @@ func (s *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, params
 			return nil, nil, err
 		}
 
-		pType := apitypes.StrategicMergePatchType
-		if apiOp.Request.Header.Get("content-type") == string(apitypes.JSONPatchType) {
-			pType = apitypes.JSONPatchType
-		}
-
-		opts := metav1.PatchOptions{}
-		if err := decodeParams(apiOp, &opts); err != nil {
+		pType, bytes, opts, err := proxy.Patch(apiOp, bytes, s.fieldManager)
+		if err != nil {
 			return nil, nil, err
 		}
 
-		if pType == apitypes.StrategicMergePatchType {
-			data := map[string]interface{}{}
-			if err := json.Unmarshal(bytes, &data); err != nil {
-				return nil, nil, err
-			}
-			data = moveFromUnderscore(data)
-			bytes, err = json.Marshal(data)
-			if err != nil {
-				return nil, nil, err
-			}
-		}
-
 		resp, err := k8sClient.Patch(apiOp, id, pType, bytes, opts)
 		if err != nil {
 			return nil, nil, err
@@ -693,7 +863,7 @@ func (s *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, params
 		return nil, nil, err
 	}
 
-	resp, err := k8sClient.Update(apiOp, &unstructured.Unstructured{Object: moveFromUnderscore(input)}, metav1.UpdateOptions{})
+	resp, err := k8sClient.Update(apiOp, &unstructured.Unstructured{Object: moveFromUnderscore(input)}, opts)
 	if err != nil {
 		return nil, nil, err
 	}
@@ -702,11 +872,11 @@ func (s *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, params
 	return resp, buffer, nil
 }
 
-// Delete deletes an object from a store.
+// Delete deletes an object from a store. Dry-run deletes return the object, which isn't deleted.
 func (s *Store) Delete(apiOp *types.APIRequest, schema *types.APISchema, id string) (*unstructured.Unstructured, []types.Warning, error) {
-	opts := metav1.DeleteOptions{}
-	if err := decodeParams(apiOp, &opts); err != nil {
-		return nil, nil, nil
+	opts, err := proxy.DeleteOptions(apiOp)
+	if err != nil {
+		return nil, nil, err
 	}
 
 	buffer := WarningBuffer{}
@@ -735,35 +905,315 @@ func (s *Store) Delete(apiOp *types.APIRequest, schema *types.APISchema, id stri
 //   - a continue token, if there are more pages after the returned one
 //   - an error instead of all of the above if anything went wrong
 func (s *Store) ListByPartitions(apiOp *types.APIRequest, schema *types.APISchema, partitions []partition.Partition) ([]unstructured.Unstructured, int, string, error) {
-	opts, err := listprocessor.ParseQuery(apiOp, s.namespaceCache)
+	list, total, continueToken, _, err := s.ListByPartitionsAtRevision(apiOp, schema, partitions)
+	return list, total, continueToken, err
+}
+
+// ListByPartitionsAtRevision lists as ListByPartitions does, at the revision requested with the revision and
+// revisionMatch query params, if any, and also returns the revision of the cache the objects are at least as recent
+// as, from which a watch misses no event. It is empty if the cache doesn't report its revision.
+func (s *Store) ListByPartitionsAtRevision(apiOp *types.APIRequest, schema *types.APISchema, partitions []partition.Partition) ([]unstructured.Unstructured, int, string, string, error) {
+	plan, err := s.planList(apiOp, schema, partitions)
 	if err != nil {
-		return nil, 0, "", err
+		return nil, 0, "", "", err
 	}
-	// warnings from inside the informer are discarded
-	buffer := WarningBuffer{}
-	client, err := s.clientGetter.TableAdminClient(apiOp, schema, "", &buffer)
+	release, err := s.reserveBudget(apiOp, schema, plan)
 	if err != nil {
-		return nil, 0, "", err
+		return nil, 0, "", "", err
+	}
+	defer release()
+
+	revisioner := cacheRevisioner(plan.inf.ByOptionsLister)
+	if err := waitForRevision(apiOp.Context(), revisioner, plan.revisionOpts); err != nil {
+		return nil, 0, "", "", err
+	}
+	if counter, ok := plan.listCache.(listprocessor.CountCache); ok && plan.countsInCache() {
+		return s.countByOptions(apiOp, counter, revisioner, plan.revisionOpts, plan.cacheOpts, plan.partitions)
+	}
+	list, total, continueToken, err := s.listFromCache(apiOp, schema, plan, revisioner)
+	if err != nil {
+		return nil, 0, "", "", err
+	}
+	if err := s.verifyPartitions(apiOp, schema, list.Items, plan.partitions); err != nil {
+		return nil, 0, "", "", err
+	}
+	if plan.countsInCache() {
+		return nil, total, "", list.GetResourceVersion(), nil
+	}
+	s.addVirtualFields(schema, plan, list.Items)
+
+	items := list.Items
+	if plan.postProcess {
+		items, total, continueToken, err = plan.processInMemory(items)
+		if err != nil {
+			return nil, 0, "", "", err
+		}
+	} else {
+		items = plan.metadata(items)
+	}
+	return items, total, continueToken, list.GetResourceVersion(), nil
+}
+
+// countByOptions returns the number of objects listed with opts, counted by the cache without reading them, and the
+// revision of the cache they are counted at
+func (s *Store) countByOptions(apiOp *types.APIRequest, counter listprocessor.CountCache, r revisioner, revisionOpts listprocessor.RevisionOptions, opts informer.ListOptions, partitions []partition.Partition) ([]unstructured.Unstructured, int, string, string, error) {
+	revision := ""
+	if r != nil {
+		revision = r.LastSyncResourceVersion()
+	}
+	total, err := counter.CountByOptions(apiOp.Context(), opts, partitions, apiOp.Namespace)
+	if err != nil {
+		return nil, 0, "", "", invalidColumnError(err)
+	}
+	if revisionOpts.Match == listprocessor.RevisionMatchExact {
+		if err := checkRevision(r, revisionOpts); err != nil {
+			return nil, 0, "", "", err
+		}
+	}
+	return nil, total, "", revision, nil
+}
+
+// DistinctByPartitions returns the distinct values, and their number of occurrences, of the fields requested with the
+// distinct query param, among the objects belonging to any of the partitions and matching the request's filters.
+func (s *Store) DistinctByPartitions(apiOp *types.APIRequest, schema *types.APISchema, partitions []partition.Partition) (map[string][]listprocessor.DistinctValue, error) {
+	fields := listprocessor.ParseDistinct(apiOp)
+	if len(fields) == 0 {
+		return nil, apierror.NewAPIError(validation.MissingRequired, "distinct requires at least one field")
+	}
+	parseOptions := s.parseOptions(schema)
+	opts, err := listprocessor.ParseQuery(apiOp, parseOptions)
+	if err != nil {
+		return nil, err
+	}
+	inf, err := s.cacheFor(apiOp, schema)
+	if err != nil {
+		return nil, err
 	}
-	gvk := attributes.GVK(schema)
-	fields := getFieldsFromSchema(schema)
-	fields = append(fields, getFieldForGVK(gvk)...)
-	transformFunc := s.transformBuilder.GetTransformFunc(gvk)
 
-	inf, err := s.cacheFactory.CacheFor(fields, transformFunc, &tablelistconvert.Client{ResourceInterface: client}, attributes.GVK(schema), attributes.Namespaced(schema), controllerschema.IsListWatchable(schema))
+	// values are counted over all matching objects, regardless of pagination
+	opts.ChunkSize = 0
+	opts.Resume = ""
+	opts.Pagination = informer.Pagination{}
+	listCache, queryable := s.listCache(inf, schema)
+	if !queryable {
+		projects, err := s.projectNamespaces(apiOp.Context(), partitions)
+		if err != nil {
+			return nil, err
+		}
+		partitions = expandProjects(partitions, projects)
+	}
+	memoryFields := s.memoryFields(schema)
+	var memoryFilters []informer.OrFilter
+	opts.Filters, memoryFilters = splitFilters(opts.Filters, memoryFields, queryable)
+
+	// the values of indexed fields are counted by the SQL cache, unless objects are filtered in memory or verified
+	// against the partitions, which needs them listed
+	result := make(map[string][]listprocessor.DistinctValue, len(fields))
+	listedFields := fields
+	if distinctCache, ok := listCache.(listprocessor.DistinctCache); ok && len(memoryFilters) == 0 && s.hardeningMode == HardeningOff {
+		listedFields = nil
+		for _, field := range fields {
+			if isOneOf(field, memoryFields) {
+				listedFields = append(listedFields, field)
+				continue
+			}
+			values, err := distinctCache.DistinctByOptions(apiOp.Context(), opts, field, partitions, apiOp.Namespace)
+			if errors.Is(err, informer.InvalidColumnErr) {
+				listedFields = append(listedFields, field)
+				continue
+			}
+			if err != nil {
+				return nil, err
+			}
+			result[listprocessor.DistinctKey(field)] = values
+		}
+		if len(listedFields) == 0 {
+			return result, nil
+		}
+	}
+
+	traced := tracedCache{cache: s.timed(listCache), gvk: attributes.GVK(schema)}
+	list, _, _, err := traced.ListByOptions(apiOp.Context(), opts, partitions, apiOp.Namespace)
 	if err != nil {
-		return nil, 0, "", err
+		return nil, invalidColumnError(err)
+	}
+	if err := s.verifyPartitions(apiOp, schema, list.Items, partitions); err != nil {
+		return nil, err
 	}
 
-	list, total, continueToken, err := inf.ListByOptions(apiOp.Context(), opts, partitions, apiOp.Namespace)
+	items := listprocessor.FilterItems(list.Items, memoryFilters, parseOptions.ColumnTypes)
+	for key, values := range listprocessor.Distinct(items, listedFields) {
+		result[key] = values
+	}
+	return result, nil
+}
+
+// SampleByPartitions returns up to limit objects of a schema's type from the cache, among the objects belonging to any
+// of the partitions.
+func (s *Store) SampleByPartitions(apiOp *types.APIRequest, schema *types.APISchema, partitions []partition.Partition, limit int) ([]unstructured.Unstructured, error) {
+	inf, err := s.cacheFor(apiOp, schema)
 	if err != nil {
-		if errors.Is(err, informer.InvalidColumnErr) {
-			return nil, 0, "", apierror.NewAPIError(validation.InvalidBodyContent, err.Error())
+		return nil, err
+	}
+	// samples are read with ListByOptions, which doesn't limit objects to projects
+	projects, err := s.projectNamespaces(apiOp.Context(), partitions)
+	if err != nil {
+		return nil, err
+	}
+	list, _, _, err := s.timed(inf).ListByOptions(apiOp.Context(), informer.ListOptions{ChunkSize: limit}, expandProjects(partitions, projects), apiOp.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.verifyPartitions(apiOp, schema, list.Items, partitions); err != nil {
+		return nil, err
+	}
+	return list.Items, nil
+}
+
+// usageFields returns the usage fields of a schema's type, if any
+func (s *Store) usageFields(schema *types.APISchema) [][]string {
+	if s.usage == nil {
+		return nil
+	}
+	return s.usage.Fields(attributes.GVK(schema))
+}
+
+func isOneOf(field []string, fields [][]string) bool {
+	if len(field) == 0 {
+		return false
+	}
+	for _, f := range fields {
+		if slices.Equal(field, f) {
+			return true
 		}
-		return nil, 0, "", err
 	}
+	return false
+}
 
-	return list.Items, total, continueToken, nil
+// deletedObjects returns the recently deleted objects to list along with existing ones, if requested
+func (s *Store) deletedObjects(apiOp *types.APIRequest, schema *types.APISchema, partitions []partition.Partition, projects map[string]sets.Set[string], opts informer.ListOptions) []unstructured.Unstructured {
+	if s.tombstones == nil || !listprocessor.ParseIncludeDeleted(apiOp) {
+		return nil
+	}
+	columnTypes := s.columnTypes(schema)
+	var result []unstructured.Unstructured
+	for _, obj := range s.tombstones.List(attributes.GVK(schema)) {
+		if inPartitions(obj, partitions, apiOp.Namespace, projects) && listprocessor.MatchesFilters(obj, opts.Filters, columnTypes) {
+			result = append(result, obj)
+		}
+	}
+	return result
+}
+
+// parseOptions returns the options the query params of lists of a schema's type are parsed with
+func (s *Store) parseOptions(schema *types.APISchema) listprocessor.ParseOptions {
+	return listprocessor.ParseOptions{
+		NamespaceCache: s.timed(s.namespaceCache),
+		DefaultSort:    s.defaultSort,
+		ColumnTypes:    s.columnTypes(schema),
+	}
+}
+
+// transformFunc returns the func setting the virtual fields of objects of a type, then applying its ingest
+// transformers
+func (s *Store) transformFunc(gvk schema.GroupVersionKind) cache.TransformFunc {
+	transformFunc := s.usageTransform(gvk, s.transformBuilder.GetTransformFunc(gvk))
+	ingestTransform := s.ingest.TransformFunc(gvk)
+	if ingestTransform == nil {
+		return transformFunc
+	}
+	return func(raw interface{}) (interface{}, error) {
+		transformed, err := transformFunc(raw)
+		if err != nil {
+			return nil, err
+		}
+		// signals such as the final state of deleted objects are stored as they are
+		obj, ok := transformed.(*unstructured.Unstructured)
+		if !ok {
+			return transformed, nil
+		}
+		return ingestTransform(obj)
+	}
+}
+
+// usageTransform returns transformFunc, then setting the usage of objects of a type with usage fields, so that their
+// columns are written with objects
+func (s *Store) usageTransform(gvk schema.GroupVersionKind, transformFunc cache.TransformFunc) cache.TransformFunc {
+	if s.usage == nil || len(s.usage.Fields(gvk)) == 0 {
+		return transformFunc
+	}
+	return func(raw interface{}) (interface{}, error) {
+		transformed, err := transformFunc(raw)
+		if err != nil {
+			return nil, err
+		}
+		if obj, ok := transformed.(*unstructured.Unstructured); ok {
+			s.usage.Add(gvk, []unstructured.Unstructured{{Object: obj.Object}})
+		}
+		return transformed, nil
+	}
+}
+
+// replicaClient returns the client of the replica of a schema's type, if it is mirrored
+func (s *Store) replicaClient(schema *types.APISchema) (dynamic.ResourceInterface, bool) {
+	if s.replicas == nil {
+		return nil, false
+	}
+	return s.replicas.Client(attributes.GVK(schema))
+}
+
+func (s *Store) checkNotMirrored(schema *types.APISchema) error {
+	if _, ok := s.replicaClient(schema); ok {
+		return apierror.NewAPIError(validation.MethodNotAllowed, fmt.Sprintf("objects of mirrored type [%s] can only be listed", schema.ID))
+	}
+	return nil
+}
+
+// cacheFor returns the cache for a schema's type, creating it if needed
+func (s *Store) cacheFor(apiOp *types.APIRequest, schema *types.APISchema) (factory.Cache, error) {
+	gvk := attributes.GVK(schema)
+	watchable := controllerschema.IsListWatchable(schema)
+	// the objects of mirrored types are those of their replica, which has no table columns
+	client, mirrored := s.replicaClient(schema)
+	if mirrored {
+		watchable = true
+	} else {
+		// warnings from inside the informer are discarded
+		buffer := WarningBuffer{}
+		tableClient, err := s.clientGetter.TableAdminClient(apiOp, schema, "", &buffer)
+		if err != nil {
+			return factory.Cache{}, err
+		}
+		client = &tablelistconvert.Client{ResourceInterface: tableClient}
+	}
+	fields := s.IndexedFields(schema)
+	transformFunc := s.capSize(s.transformFunc(gvk), fields)
+	if s.synced(gvk) {
+		c, err := s.cacheFactory.CacheFor(fields, transformFunc, client, gvk, attributes.Namespaced(schema), watchable)
+		if err == nil {
+			s.changeLog(gvk, c.ByOptionsLister)
+			s.countWarningEvents(gvk, c.ByOptionsLister)
+		}
+		return c, err
+	}
+
+	// the cache of the type is created and synced by the first list, whose progress is logged
+	if !mirrored {
+		client = s.bootstrapped(gvk, client)
+	}
+	progress := newSyncProgress(gvk)
+	done := make(chan struct{})
+	defer close(done)
+	go progress.report(done)
+	c, err := s.cacheFactory.CacheFor(fields, progress.transform(transformFunc), client, gvk, attributes.Namespaced(schema), watchable)
+	if err != nil {
+		return c, err
+	}
+	s.setSynced(gvk)
+	// changes are kept from the first list of the type
+	s.changeLog(gvk, c.ByOptionsLister)
+	s.countWarningEvents(gvk, c.ByOptionsLister)
+	return c, nil
 }
 
 // WatchByPartitions returns a channel of events for a list or resource belonging to any of the specified partitions
@@ -802,14 +1252,24 @@ func (s *Store) WatchByPartitions(apiOp *types.APIRequest, schema *types.APISche
 }
 
 // watchByPartition returns a channel of events for a list or resource belonging to a specified partition
-func (s *Store) watchByPartition(partition partition.Partition, apiOp *types.APIRequest, schema *types.APISchema, wr types.WatchRequest) (chan watch.Event, error) {
-	if partition.Passthrough {
+func (s *Store) watchByPartition(p partition.Partition, apiOp *types.APIRequest, schema *types.APISchema, wr types.WatchRequest) (chan watch.Event, error) {
+	if p.Passthrough {
 		return s.Watch(apiOp, schema, wr)
 	}
 
-	apiOp.Namespace = partition.Namespace
-	if partition.All {
+	if projectID := p.Project; projectID != "" {
+		// the namespaces of the project are watched as all namespaces are, the project of each object being looked up
+		p.Project = ""
+		events, err := s.watchByPartition(p, apiOp, schema, wr)
+		if err != nil {
+			return nil, err
+		}
+		return s.watchProject(apiOp.Context(), projectID, events), nil
+	}
+
+	apiOp.Namespace = p.Namespace
+	if p.All {
 		return s.Watch(apiOp, schema, wr)
 	}
-	return s.WatchNames(apiOp, schema, wr, partition.Names)
+	return s.WatchNames(apiOp, schema, wr, p.Names)
 }
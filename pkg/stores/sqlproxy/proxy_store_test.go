@@ -12,11 +12,13 @@ import (
 	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 
-	"github.com/rancher/lasso/pkg/cache/sql/informer"
-	"github.com/rancher/lasso/pkg/cache/sql/informer/factory"
-	"github.com/rancher/lasso/pkg/cache/sql/partition"
 	"github.com/rancher/steve/pkg/attributes"
 	"github.com/rancher/steve/pkg/resources/common"
+	"github.com/rancher/steve/pkg/resources/virtual/ingest"
+	"github.com/rancher/steve/pkg/schema/table"
+	"github.com/rancher/steve/pkg/sqlcache/factory"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
 	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
 	"github.com/rancher/steve/pkg/stores/sqlproxy/tablelistconvert"
 	"go.uber.org/mock/gomock"
@@ -42,7 +44,7 @@ import (
 )
 
 //go:generate mockgen --build_flags=--mod=mod -package sqlproxy -destination ./proxy_mocks_test.go github.com/rancher/steve/pkg/stores/sqlproxy Cache,ClientGetter,CacheFactory,SchemaColumnSetter,RelationshipNotifier,TransformBuilder
-//go:generate mockgen --build_flags=--mod=mod -package sqlproxy -destination ./sql_informer_mocks_test.go github.com/rancher/lasso/pkg/cache/sql/informer ByOptionsLister
+//go:generate mockgen --build_flags=--mod=mod -package sqlproxy -destination ./sql_informer_mocks_test.go github.com/rancher/steve/pkg/sqlcache/informer ByOptionsLister
 //go:generate mockgen --build_flags=--mod=mod -package sqlproxy -destination ./dynamic_mocks_test.go k8s.io/client-go/dynamic ResourceInterface
 
 var c *watch.FakeWatcher
@@ -82,9 +84,9 @@ func TestNewProxyStore(t *testing.T) {
 			nsSchema := baseNSSchema
 			scc.EXPECT().SetColumns(context.Background(), &nsSchema).Return(nil)
 			cg.EXPECT().TableAdminClient(nil, &nsSchema, "", &WarningBuffer{}).Return(ri, nil)
-			cf.EXPECT().CacheFor([][]string{{`id`}, {`metadata`, `state`, `name`}, {"metadata", "labels[field.cattle.io/projectId]"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(&nsSchema), false, true).Return(c, nil)
+			cf.EXPECT().CacheFor([][]string{{`id`}, {`metadata`, `state`, `name`}, {`metadata`, `state`, `error`}, {`metadata`, `state`, `transitioning`}, {`metadata`, `resourceVersion`}, {"metadata", "labels[field.cattle.io/projectId]"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(&nsSchema), false, true).Return(c, nil)
 
-			s, err := NewProxyStore(scc, cg, rn, nil, cf)
+			s, err := NewProxyStore(context.Background(), scc, cg, rn, nil, cf, Options{})
 			assert.Nil(t, err)
 			assert.Equal(t, scc, s.columnSetter)
 			assert.Equal(t, cg, s.clientGetter)
@@ -105,7 +107,7 @@ func TestNewProxyStore(t *testing.T) {
 			nsSchema := baseNSSchema
 			scc.EXPECT().SetColumns(context.Background(), &nsSchema).Return(fmt.Errorf("error"))
 
-			s, err := NewProxyStore(scc, cg, rn, nil, cf)
+			s, err := NewProxyStore(context.Background(), scc, cg, rn, nil, cf, Options{})
 			assert.Nil(t, err)
 			assert.Equal(t, scc, s.columnSetter)
 			assert.Equal(t, cg, s.clientGetter)
@@ -127,7 +129,7 @@ func TestNewProxyStore(t *testing.T) {
 			scc.EXPECT().SetColumns(context.Background(), &nsSchema).Return(nil)
 			cg.EXPECT().TableAdminClient(nil, &nsSchema, "", &WarningBuffer{}).Return(nil, fmt.Errorf("error"))
 
-			s, err := NewProxyStore(scc, cg, rn, nil, cf)
+			s, err := NewProxyStore(context.Background(), scc, cg, rn, nil, cf, Options{})
 			assert.Nil(t, err)
 			assert.Equal(t, scc, s.columnSetter)
 			assert.Equal(t, cg, s.clientGetter)
@@ -149,9 +151,9 @@ func TestNewProxyStore(t *testing.T) {
 			nsSchema := baseNSSchema
 			scc.EXPECT().SetColumns(context.Background(), &nsSchema).Return(nil)
 			cg.EXPECT().TableAdminClient(nil, &nsSchema, "", &WarningBuffer{}).Return(ri, nil)
-			cf.EXPECT().CacheFor([][]string{{`id`}, {`metadata`, `state`, `name`}, {"metadata", "labels[field.cattle.io/projectId]"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(&nsSchema), false, true).Return(factory.Cache{}, fmt.Errorf("error"))
+			cf.EXPECT().CacheFor([][]string{{`id`}, {`metadata`, `state`, `name`}, {`metadata`, `state`, `error`}, {`metadata`, `state`, `transitioning`}, {`metadata`, `resourceVersion`}, {"metadata", "labels[field.cattle.io/projectId]"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(&nsSchema), false, true).Return(factory.Cache{}, fmt.Errorf("error"))
 
-			s, err := NewProxyStore(scc, cg, rn, nil, cf)
+			s, err := NewProxyStore(context.Background(), scc, cg, rn, nil, cf, Options{})
 			assert.Nil(t, err)
 			assert.Equal(t, scc, s.columnSetter)
 			assert.Equal(t, cg, s.clientGetter)
@@ -237,11 +239,11 @@ func TestListByPartitions(t *testing.T) {
 			// ListByPartitions copies point so we need some original record of items to ensure as asserting listToReturn's
 			// items is equal to the list returned by ListByParititons doesn't ensure no mutation happened
 			copy(listToReturn.Items, expectedItems)
-			opts, err := listprocessor.ParseQuery(req, nil)
+			opts, err := listprocessor.ParseQuery(req, listprocessor.ParseOptions{})
 			assert.Nil(t, err)
 			cg.EXPECT().TableAdminClient(req, schema, "", &WarningBuffer{}).Return(ri, nil)
 			// This tests that fields are being extracted from schema columns and the type specific fields map
-			cf.EXPECT().CacheFor([][]string{{"some", "field"}, {`id`}, {`metadata`, `state`, `name`}, {"gvk", "specific", "fields"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(schema), attributes.Namespaced(schema), true).Return(c, nil)
+			cf.EXPECT().CacheFor([][]string{{"some", "field"}, {`id`}, {`metadata`, `state`, `name`}, {`metadata`, `state`, `error`}, {`metadata`, `state`, `transitioning`}, {`metadata`, `resourceVersion`}, {"gvk", "specific", "fields"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(schema), attributes.Namespaced(schema), true).Return(c, nil)
 			tb.EXPECT().GetTransformFunc(attributes.GVK(schema)).Return(func(obj interface{}) (interface{}, error) { return obj, nil })
 			bloi.EXPECT().ListByOptions(req.Context(), opts, partitions, req.Namespace).Return(listToReturn, len(listToReturn.Items), "", nil)
 			list, total, contToken, err := s.ListByPartitions(req, schema, partitions)
@@ -310,7 +312,7 @@ func TestListByPartitions(t *testing.T) {
 			copy(listToReturn.Items, expectedItems)
 
 			nsi.EXPECT().ListByOptions(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(nil, 0, "", fmt.Errorf("error")).Times(2)
-			_, err := listprocessor.ParseQuery(req, nsi)
+			_, err := listprocessor.ParseQuery(req, listprocessor.ParseOptions{NamespaceCache: nsi})
 			assert.NotNil(t, err)
 
 			_, _, _, err = s.ListByPartitions(req, schema, partitions)
@@ -375,7 +377,7 @@ func TestListByPartitions(t *testing.T) {
 			// ListByPartitions copies point so we need some original record of items to ensure as asserting listToReturn's
 			// items is equal to the list returned by ListByParititons doesn't ensure no mutation happened
 			copy(listToReturn.Items, expectedItems)
-			_, err := listprocessor.ParseQuery(req, nil)
+			_, err := listprocessor.ParseQuery(req, listprocessor.ParseOptions{})
 			assert.Nil(t, err)
 			cg.EXPECT().TableAdminClient(req, schema, "", &WarningBuffer{}).Return(nil, fmt.Errorf("error"))
 
@@ -448,13 +450,13 @@ func TestListByPartitions(t *testing.T) {
 			// ListByPartitions copies point so we need some original record of items to ensure as asserting listToReturn's
 			// items is equal to the list returned by ListByParititons doesn't ensure no mutation happened
 			copy(listToReturn.Items, expectedItems)
-			opts, err := listprocessor.ParseQuery(req, nil)
+			opts, err := listprocessor.ParseQuery(req, listprocessor.ParseOptions{})
 			assert.Nil(t, err)
 			cg.EXPECT().TableAdminClient(req, schema, "", &WarningBuffer{}).Return(ri, nil)
 
 			// This tests that fields are being extracted from schema columns and the type specific fields map
 			// note also the watchable bool is expected to be false
-			cf.EXPECT().CacheFor([][]string{{"some", "field"}, {`id`}, {`metadata`, `state`, `name`}, {"gvk", "specific", "fields"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(schema), attributes.Namespaced(schema), false).Return(c, nil)
+			cf.EXPECT().CacheFor([][]string{{"some", "field"}, {`id`}, {`metadata`, `state`, `name`}, {`metadata`, `state`, `error`}, {`metadata`, `state`, `transitioning`}, {`metadata`, `resourceVersion`}, {"gvk", "specific", "fields"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(schema), attributes.Namespaced(schema), false).Return(c, nil)
 
 			tb.EXPECT().GetTransformFunc(attributes.GVK(schema)).Return(func(obj interface{}) (interface{}, error) { return obj, nil })
 			bloi.EXPECT().ListByOptions(req.Context(), opts, partitions, req.Namespace).Return(listToReturn, len(listToReturn.Items), "", nil)
@@ -523,12 +525,12 @@ func TestListByPartitions(t *testing.T) {
 			// ListByPartitions copies point so we need some original record of items to ensure as asserting listToReturn's
 			// items is equal to the list returned by ListByParititons doesn't ensure no mutation happened
 			copy(listToReturn.Items, expectedItems)
-			_, err := listprocessor.ParseQuery(req, nil)
+			_, err := listprocessor.ParseQuery(req, listprocessor.ParseOptions{})
 			assert.Nil(t, err)
 			cg.EXPECT().TableAdminClient(req, schema, "", &WarningBuffer{}).Return(ri, nil)
 			// This tests that fields are being extracted from schema columns and the type specific fields map
 			tb.EXPECT().GetTransformFunc(attributes.GVK(schema)).Return(func(obj interface{}) (interface{}, error) { return obj, nil })
-			cf.EXPECT().CacheFor([][]string{{"some", "field"}, {`id`}, {`metadata`, `state`, `name`}, {"gvk", "specific", "fields"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(schema), attributes.Namespaced(schema), true).Return(factory.Cache{}, fmt.Errorf("error"))
+			cf.EXPECT().CacheFor([][]string{{"some", "field"}, {`id`}, {`metadata`, `state`, `name`}, {`metadata`, `state`, `error`}, {`metadata`, `state`, `transitioning`}, {`metadata`, `resourceVersion`}, {"gvk", "specific", "fields"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(schema), attributes.Namespaced(schema), true).Return(factory.Cache{}, fmt.Errorf("error"))
 
 			_, _, _, err = s.ListByPartitions(req, schema, partitions)
 			assert.NotNil(t, err)
@@ -599,11 +601,11 @@ func TestListByPartitions(t *testing.T) {
 			// ListByPartitions copies point so we need some original record of items to ensure as asserting listToReturn's
 			// items is equal to the list returned by ListByParititons doesn't ensure no mutation happened
 			copy(listToReturn.Items, expectedItems)
-			opts, err := listprocessor.ParseQuery(req, nil)
+			opts, err := listprocessor.ParseQuery(req, listprocessor.ParseOptions{})
 			assert.Nil(t, err)
 			cg.EXPECT().TableAdminClient(req, schema, "", &WarningBuffer{}).Return(ri, nil)
 			// This tests that fields are being extracted from schema columns and the type specific fields map
-			cf.EXPECT().CacheFor([][]string{{"some", "field"}, {`id`}, {`metadata`, `state`, `name`}, {"gvk", "specific", "fields"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(schema), attributes.Namespaced(schema), true).Return(c, nil)
+			cf.EXPECT().CacheFor([][]string{{"some", "field"}, {`id`}, {`metadata`, `state`, `name`}, {`metadata`, `state`, `error`}, {`metadata`, `state`, `transitioning`}, {`metadata`, `resourceVersion`}, {"gvk", "specific", "fields"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(schema), attributes.Namespaced(schema), true).Return(c, nil)
 			bloi.EXPECT().ListByOptions(req.Context(), opts, partitions, req.Namespace).Return(nil, 0, "", fmt.Errorf("error"))
 			tb.EXPECT().GetTransformFunc(attributes.GVK(schema)).Return(func(obj interface{}) (interface{}, error) { return obj, nil })
 
@@ -611,6 +613,126 @@ func TestListByPartitions(t *testing.T) {
 			assert.NotNil(t, err)
 		},
 	})
+	tests = append(tests, testCase{
+		description: "client ListByPartitions() with countOnly should only read one object from the cache and return" +
+			" the total without objects.",
+		test: func(t *testing.T) {
+			cg := NewMockClientGetter(gomock.NewController(t))
+			cf := NewMockCacheFactory(gomock.NewController(t))
+			ri := NewMockResourceInterface(gomock.NewController(t))
+			bloi := NewMockByOptionsLister(gomock.NewController(t))
+			tb := NewMockTransformBuilder(gomock.NewController(t))
+			c := factory.Cache{
+				ByOptionsLister: &informer.Informer{
+					ByOptionsLister: bloi,
+				},
+			}
+			s := &Store{
+				clientGetter:     cg,
+				cacheFactory:     cf,
+				transformBuilder: tb,
+			}
+			partitions := []partition.Partition{{Passthrough: true}}
+			req := &types.APIRequest{
+				Request: &http.Request{
+					URL: &url.URL{RawQuery: "countOnly=true&sort=metadata.name&pagesize=10&page=2"},
+				},
+			}
+			schema := &types.APISchema{
+				Schema: &schemas.Schema{Attributes: map[string]interface{}{
+					"verbs": []string{"list", "watch"},
+				}},
+			}
+			attributes.SetGVK(schema, schema2.GroupVersionKind{Group: "some", Version: "test", Kind: "gvk"})
+			listToReturn := &unstructured.UnstructuredList{
+				Items: []unstructured.Unstructured{{Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "fuji"}}}},
+			}
+			opts, err := listprocessor.ParseQuery(req, listprocessor.ParseOptions{})
+			assert.Nil(t, err)
+			opts.ChunkSize = 1
+			opts.Sort = informer.Sort{}
+			opts.Pagination = informer.Pagination{}
+			cg.EXPECT().TableAdminClient(req, schema, "", &WarningBuffer{}).Return(ri, nil)
+			cf.EXPECT().CacheFor(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(c, nil)
+			tb.EXPECT().GetTransformFunc(attributes.GVK(schema)).Return(func(obj interface{}) (interface{}, error) { return obj, nil })
+			bloi.EXPECT().ListByOptions(req.Context(), opts, partitions, req.Namespace).Return(listToReturn, 42, "1", nil)
+
+			list, total, contToken, err := s.ListByPartitions(req, schema, partitions)
+			assert.Nil(t, err)
+			assert.Empty(t, list)
+			assert.Equal(t, 42, total)
+			assert.Equal(t, "", contToken)
+		},
+	})
+	tests = append(tests, testCase{
+		description: "client ListByPartitions() only asking for metadata should list it with the metadata lister, or" +
+			" return the metadata of listed objects if the list has filters.",
+		test: func(t *testing.T) {
+			for _, query := range []string{"", "filter=metadata.name=fuji"} {
+				cg := NewMockClientGetter(gomock.NewController(t))
+				cf := NewMockCacheFactory(gomock.NewController(t))
+				ri := NewMockResourceInterface(gomock.NewController(t))
+				bloi := NewMockByOptionsLister(gomock.NewController(t))
+				tb := NewMockTransformBuilder(gomock.NewController(t))
+				c := factory.Cache{
+					ByOptionsLister: &informer.Informer{
+						ByOptionsLister: bloi,
+					},
+				}
+				metadata := &fakeMetadataLister{}
+				s := &Store{
+					clientGetter:     cg,
+					cacheFactory:     cf,
+					transformBuilder: tb,
+				}
+				s.metadataLister = metadata
+				partitions := []partition.Partition{{Passthrough: true}}
+				req := &types.APIRequest{
+					Request: &http.Request{
+						URL:    &url.URL{RawQuery: query},
+						Header: http.Header{"Accept": []string{"application/json;as=PartialObjectMetadataList;g=meta.k8s.io;v=v1"}},
+					},
+				}
+				schema := &types.APISchema{
+					Schema: &schemas.Schema{Attributes: map[string]interface{}{
+						"verbs": []string{"list", "watch"},
+					}},
+				}
+				attributes.SetGVK(schema, schema2.GroupVersionKind{Group: "some", Version: "test", Kind: "gvk"})
+				listToReturn := &unstructured.UnstructuredList{
+					Items: []unstructured.Unstructured{{Object: map[string]interface{}{
+						"kind":     "apple",
+						"metadata": map[string]interface{}{"name": "fuji"},
+						"data":     map[string]interface{}{"color": "pink"},
+					}}},
+				}
+				opts, err := listprocessor.ParseQuery(req, listprocessor.ParseOptions{})
+				assert.Nil(t, err)
+				cg.EXPECT().TableAdminClient(req, schema, "", &WarningBuffer{}).Return(ri, nil)
+				cf.EXPECT().CacheFor(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).Return(c, nil)
+				tb.EXPECT().GetTransformFunc(attributes.GVK(schema)).Return(func(obj interface{}) (interface{}, error) { return obj, nil })
+				if query == "" {
+					metadata.list = listprocessor.ToPartialObjectMetadata(listToReturn.Items)
+				} else {
+					bloi.EXPECT().ListByOptions(req.Context(), opts, partitions, req.Namespace).Return(listToReturn, 1, "", nil)
+				}
+
+				list, total, _, err := s.ListByPartitions(req, schema, partitions)
+				assert.Nil(t, err)
+				assert.Equal(t, []unstructured.Unstructured{{Object: map[string]interface{}{
+					"apiVersion": "meta.k8s.io/v1",
+					"kind":       "PartialObjectMetadata",
+					"metadata":   map[string]interface{}{"name": "fuji"},
+				}}}, list)
+				assert.Equal(t, 1, total)
+				if query == "" {
+					assert.Equal(t, []informer.ListOptions{opts}, metadata.opts)
+				} else {
+					assert.Empty(t, metadata.opts)
+				}
+			}
+		},
+	})
 	t.Parallel()
 	for _, test := range tests {
 		t.Run(test.description, func(t *testing.T) { test.test(t) })
@@ -645,13 +767,45 @@ func TestReset(t *testing.T) {
 			cf.EXPECT().Reset().Return(nil)
 			cs.EXPECT().SetColumns(gomock.Any(), gomock.Any()).Return(nil)
 			cg.EXPECT().TableAdminClient(nil, &nsSchema, "", &WarningBuffer{}).Return(ri, nil)
-			cf.EXPECT().CacheFor([][]string{{`id`}, {`metadata`, `state`, `name`}, {"metadata", "labels[field.cattle.io/projectId]"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(&nsSchema), false, true).Return(nsc2, nil)
+			cf.EXPECT().CacheFor([][]string{{`id`}, {`metadata`, `state`, `name`}, {`metadata`, `state`, `error`}, {`metadata`, `state`, `transitioning`}, {`metadata`, `resourceVersion`}, {"metadata", "labels[field.cattle.io/projectId]"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(&nsSchema), false, true).Return(nsc2, nil)
 			tb.EXPECT().GetTransformFunc(attributes.GVK(&nsSchema)).Return(func(obj interface{}) (interface{}, error) { return obj, nil })
 			err := s.Reset()
 			assert.Nil(t, err)
 			assert.Equal(t, nsc2, s.namespaceCache)
 		},
 	})
+	tests = append(tests, testCase{
+		description: "client Reset() with warning events counted from the start should recreate the event cache and count its events.",
+		test: func(t *testing.T) {
+			cg := NewMockClientGetter(gomock.NewController(t))
+			cf := NewMockCacheFactory(gomock.NewController(t))
+			cs := NewMockSchemaColumnSetter(gomock.NewController(t))
+			ri := NewMockResourceInterface(gomock.NewController(t))
+			tb := NewMockTransformBuilder(gomock.NewController(t))
+			old := &handlerInformer{}
+			events := &handlerInformer{}
+			s := &Store{
+				clientGetter:       cg,
+				cacheFactory:       cf,
+				columnSetter:       cs,
+				transformBuilder:   tb,
+				countEventsAtStart: true,
+				warningEvents:      newWarningEvents(old),
+			}
+			cf.EXPECT().Reset().Return(nil)
+			cs.EXPECT().SetColumns(gomock.Any(), gomock.Any()).Return(nil).Times(2)
+			cg.EXPECT().TableAdminClient(nil, gomock.Any(), "", &WarningBuffer{}).Return(ri, nil).Times(2)
+			tb.EXPECT().GetTransformFunc(gomock.Any()).Return(func(obj interface{}) (interface{}, error) { return obj, nil }).Times(2)
+			nsSchema := baseNSSchema
+			cf.EXPECT().CacheFor(gomock.Any(), gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(&nsSchema), false, true).Return(factory.Cache{}, nil)
+			cf.EXPECT().CacheFor(gomock.Any(), gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, eventGVK, true, true).Return(factory.Cache{ByOptionsLister: &informer.Informer{SharedIndexInformer: events}}, nil)
+			err := s.Reset()
+			assert.Nil(t, err)
+			assert.Equal(t, 0, old.handlers, "events of the reset cache aren't counted anymore")
+			assert.Equal(t, 1, events.handlers)
+			assert.True(t, s.synced(eventGVK))
+		},
+	})
 	tests = append(tests, testCase{
 		description: "client Reset() with cache factory Reset() error returned, should return an error.",
 		test: func(t *testing.T) {
@@ -748,7 +902,7 @@ func TestReset(t *testing.T) {
 			cf.EXPECT().Reset().Return(nil)
 			cs.EXPECT().SetColumns(gomock.Any(), gomock.Any()).Return(nil)
 			cg.EXPECT().TableAdminClient(nil, &nsSchema, "", &WarningBuffer{}).Return(ri, nil)
-			cf.EXPECT().CacheFor([][]string{{`id`}, {`metadata`, `state`, `name`}, {"metadata", "labels[field.cattle.io/projectId]"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(&nsSchema), false, true).Return(factory.Cache{}, fmt.Errorf("error"))
+			cf.EXPECT().CacheFor([][]string{{`id`}, {`metadata`, `state`, `name`}, {`metadata`, `state`, `error`}, {`metadata`, `state`, `transitioning`}, {`metadata`, `resourceVersion`}, {"metadata", "labels[field.cattle.io/projectId]"}}, gomock.Any(), &tablelistconvert.Client{ResourceInterface: ri}, attributes.GVK(&nsSchema), false, true).Return(factory.Cache{}, fmt.Errorf("error"))
 			tb.EXPECT().GetTransformFunc(attributes.GVK(&nsSchema)).Return(func(obj interface{}) (interface{}, error) { return obj, nil })
 			err := s.Reset()
 			assert.NotNil(t, err)
@@ -1545,3 +1699,110 @@ func TestUpdate(t *testing.T) {
 		})
 	}
 }
+
+func TestGetFieldsFromSchema(t *testing.T) {
+	schema := &types.APISchema{Schema: &schemas.Schema{}}
+	assert.Nil(t, getFieldsFromSchema(schema))
+
+	attributes.SetColumns(schema, []common.ColumnDefinition{{Field: "$.metadata.fields[0]"}, {Field: "$.metadata.fields[1]"}})
+	assert.Equal(t, [][]string{{"metadata", "fields[0]"}, {"metadata", "fields[1]"}}, getFieldsFromSchema(schema))
+	assert.Equal(t, listprocessor.DateColumn, (&Store{}).columnTypes(schema).Of([]string{"metadata", "creationTimestamp"}))
+	assert.Equal(t, listprocessor.TextColumn, (&Store{}).columnTypes(schema).Of([]string{"metadata", "fields[1]"}))
+
+	// the cells of the additionalPrinterColumns of CRDs follow the name of objects
+	attributes.SetColumns(schema, []table.Column{
+		{Name: "Ready", Field: ".status.ready", Type: "string"},
+		{Name: "Replicas", Field: ".spec.replicas", Type: "integer"},
+		{Name: "Age", Field: ".metadata.creationTimestamp", Type: "date"},
+	})
+	assert.Equal(t, [][]string{{"metadata", "fields[1]"}, {"metadata", "fields[2]"}, {"metadata", "fields[3]"}}, getFieldsFromSchema(schema))
+	columnTypes := (&Store{}).columnTypes(schema)
+	assert.Equal(t, listprocessor.NumberColumn, columnTypes.Of([]string{"metadata", "fields[2]"}))
+	assert.Equal(t, listprocessor.TextColumn, columnTypes.Of([]string{"metadata", "fields[3]"}), "the API server renders dates as ages")
+
+	// columns registered by embedders hold the path of their field
+	attributes.SetColumns(schema, []table.Column{
+		{Name: "Replicas", Field: ".spec.replicas", Type: "integer"},
+		{Name: "Version", Field: "$.metadata.labels[app.kubernetes.io/version]", Type: "string"},
+		{Name: "Ratio", Field: "$.status.computed.ratio", Type: "number"},
+		{Name: "Started", Field: "$.status.startTime", Type: "date"},
+	})
+	assert.Equal(t, [][]string{{"metadata", "fields[1]"}, {"metadata", "labels[app.kubernetes.io/version]"}, {"status", "computed", "ratio"}, {"status", "startTime"}}, getFieldsFromSchema(schema))
+	columnTypes = (&Store{}).columnTypes(schema)
+	assert.Equal(t, listprocessor.NumberColumn, columnTypes.Of([]string{"metadata", "fields[1]"}))
+	assert.Equal(t, listprocessor.TextColumn, columnTypes.Of([]string{"metadata", "labels[app.kubernetes.io/version]"}))
+	assert.Equal(t, listprocessor.NumberColumn, columnTypes.Of([]string{"status", "computed", "ratio"}))
+	assert.Equal(t, listprocessor.DateColumn, columnTypes.Of([]string{"status", "startTime"}))
+	assert.Equal(t, listprocessor.NumberColumn, columnTypes.Of([]string{"metadata", "problems", "errors"}))
+}
+
+type fakeMetadataLister struct {
+	list []unstructured.Unstructured
+	opts []informer.ListOptions
+}
+
+func (f *fakeMetadataLister) ListMetadata(_ context.Context, _ schema2.GroupVersionKind, lo informer.ListOptions, _ []partition.Partition, _ string) (*unstructured.UnstructuredList, int, string, error) {
+	f.opts = append(f.opts, lo)
+	return &unstructured.UnstructuredList{Items: f.list}, len(f.list), "", nil
+}
+
+type fakeReplicas map[schema2.GroupVersionKind]dynamic.ResourceInterface
+
+func (f fakeReplicas) Client(gvk schema2.GroupVersionKind) (dynamic.ResourceInterface, bool) {
+	client, ok := f[gvk]
+	return client, ok
+}
+
+func TestMirroredTypes(t *testing.T) {
+	cg := NewMockClientGetter(gomock.NewController(t))
+	cf := NewMockCacheFactory(gomock.NewController(t))
+	tb := NewMockTransformBuilder(gomock.NewController(t))
+	replica := NewMockResourceInterface(gomock.NewController(t))
+	gvk := schema2.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}
+	s := &Store{
+		clientGetter:     cg,
+		cacheFactory:     cf,
+		transformBuilder: tb,
+	}
+	s.replicas = fakeReplicas{gvk: replica}
+	schema := &types.APISchema{
+		Schema: &schemas.Schema{ID: "apps.deployment", Attributes: map[string]interface{}{"verbs": []string{"list"}}},
+	}
+	attributes.SetGVK(schema, gvk)
+	req := &types.APIRequest{Request: &http.Request{URL: &url.URL{}}}
+
+	// the cache lists and watches the replica itself, rather than a table client of the API server
+	tb.EXPECT().GetTransformFunc(gvk).Return(func(obj interface{}) (interface{}, error) { return obj, nil })
+	cf.EXPECT().CacheFor(gomock.Any(), gomock.Any(), replica, gvk, false, true).Return(factory.Cache{ByOptionsLister: &informer.Informer{}}, nil)
+	_, err := s.cacheFor(req, schema)
+	assert.NoError(t, err)
+
+	_, _, err = s.ByID(req, schema, "east:a")
+	assert.Error(t, err)
+	_, err = s.Watch(req, schema, types.WatchRequest{})
+	assert.Error(t, err)
+}
+
+func TestIngestTransformers(t *testing.T) {
+	tb := NewMockTransformBuilder(gomock.NewController(t))
+	gvk := schema2.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	tb.EXPECT().GetTransformFunc(gvk).Return(func(obj interface{}) (interface{}, error) { return obj, nil }).Times(2)
+	s := &Store{transformBuilder: tb}
+
+	pod := func() *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{}
+		obj.SetName("pod")
+		obj.SetManagedFields([]metav1.ManagedFieldsEntry{{Manager: "kubectl"}})
+		return obj
+	}
+	obj, err := s.transformFunc(gvk)(pod())
+	assert.NoError(t, err)
+	assert.NotEmpty(t, obj.(*unstructured.Unstructured).GetManagedFields())
+
+	transformers, err := ingest.New(ingest.StripManagedFields)
+	assert.NoError(t, err)
+	s.ingest = transformers
+	obj, err = s.transformFunc(gvk)(pod())
+	assert.NoError(t, err)
+	assert.Empty(t, obj.(*unstructured.Unstructured).GetManagedFields())
+}
@@ -0,0 +1,569 @@
+package sqlproxy
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"reflect"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/resources/virtual/problems"
+	sqlcachedb "github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/rancher/steve/pkg/sqlcache/factory"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// keyColumn is the column of the fields table holding the key of objects, their namespace and name, which is unique
+const keyColumn = "key"
+
+// queryIndexer is implemented by the ListOptionIndexer of the SQL cache. Queries are run through it, so that objects are
+// read and decrypted the same way as by its own ListByOptions.
+type queryIndexer interface {
+	Prepare(stmt string) (*sql.Stmt, error)
+	CloseStmt(closable sqlcachedb.Closable) error
+	BeginTx(ctx context.Context, forWriting bool) (sqlcachedb.TXClient, error)
+	ReadObjects(rows sqlcachedb.Rows, typ reflect.Type, shouldDecrypt bool) ([]any, error)
+	ReadInt(rows sqlcachedb.Rows) (int, error)
+	GetName() string
+	GetType() reflect.Type
+	GetShouldEncrypt() bool
+}
+
+// queryCache lists the objects of a type with its own query of the tables of the SQL cache, rather than with ListByOptions,
+// which can only sort by two fields. Objects are sorted by their key in last resort, so that objects sorting equally
+// keep their order across pages.
+type queryCache struct {
+	indexer queryIndexer
+	// columns are the columns of the fields table of the type, which can be filtered and sorted on
+	columns    []string
+	namespaced bool
+	// types are the types the values of columns are compared as by range filters and sorts
+	types listprocessor.ColumnTypes
+	// limits are applied by the queries of lists
+	limits listLimits
+}
+
+// listLimits limit the number of objects listed per group, then per namespace. Objects are grouped by the primary
+// sort field, as the groupBy query param sets it.
+type listLimits struct {
+	GroupLimit      int
+	MaxPerNamespace int
+}
+
+// listCache returns the cache lists of a schema's type are read from, its queryCache if the cache is the SQL cache, in
+// which case it also returns true
+func (s *Store) listCache(inf factory.Cache, schema *types.APISchema) (listprocessor.Cache, bool) {
+	if q, ok := s.queryCacheFor(inf, schema); ok {
+		return q, true
+	}
+	return inf, false
+}
+
+// memoryFields returns the fields of a schema's type which aren't in the cache, or aren't indexed, and which lists
+// therefore filter and sort on in memory
+func (s *Store) memoryFields(schema *types.APISchema) [][]string {
+	return slices.Concat([][]string{problems.EventsField}, s.extraFields(attributes.GVK(schema), false))
+}
+
+// splitFilters splits filters into those the cache applies and those applied on its results: filters on fields which
+// aren't in the cache, and range filters unless the cache is a queryCache, since ListByOptions only matches values by pattern
+func splitFilters(filters []informer.OrFilter, memoryFields [][]string, queryable bool) ([]informer.OrFilter, []informer.OrFilter) {
+	cached := filters[:0:0]
+	var inMemory []informer.OrFilter
+	for _, orFilter := range filters {
+		if slices.ContainsFunc(orFilter.Filters, func(filter informer.Filter) bool {
+			return isOneOf(filter.Field, memoryFields) || (!queryable && listprocessor.IsRangeFilter(filter))
+		}) {
+			inMemory = append(inMemory, orFilter)
+		} else {
+			cached = append(cached, orFilter)
+		}
+	}
+	return cached, inMemory
+}
+
+// queryCacheFor returns the queryCache of the cache of a schema's type, or false if the cache isn't the SQL cache
+func (s *Store) queryCacheFor(inf factory.Cache, schema *types.APISchema) (queryCache, bool) {
+	lister := inf.ByOptionsLister
+	if i, ok := lister.(*informer.Informer); ok {
+		lister = i.ByOptionsLister
+	}
+	indexer, ok := lister.(queryIndexer)
+	if !ok {
+		return queryCache{}, false
+	}
+	// the SQL cache always indexes these columns, before the fields of the type
+	columns := []string{"metadata.name", "metadata.creationTimestamp"}
+	if attributes.Namespaced(schema) {
+		columns = append(columns, "metadata.namespace")
+	}
+	for _, field := range s.IndexedFields(schema) {
+		columns = append(columns, columnName(field))
+	}
+	return queryCache{
+		indexer:    indexer,
+		columns:    columns,
+		namespaced: attributes.Namespaced(schema),
+		types:      s.columnTypes(schema),
+	}, true
+}
+
+// columnName returns the column of the fields table holding a field, as the ListOptionIndexer names it
+func columnName(field []string) string {
+	return sqlcachedb.Sanitize(strings.Join(field, "."))
+}
+
+// column returns the quoted column of the fields table holding a field, or an error if the field isn't indexed
+func (q queryCache) column(field []string) (string, error) {
+	name := columnName(field)
+	if !slices.Contains(q.columns, name) {
+		return "", fmt.Errorf("column is invalid [%s]: %w", name, informer.InvalidColumnErr)
+	}
+	return fmt.Sprintf(`f."%s"`, name), nil
+}
+
+// from returns the from clause joining the objects table of the type, as o, with its fields table, as f
+func (q queryCache) from() string {
+	table := sqlcachedb.Sanitize(q.indexer.GetName())
+	return fmt.Sprintf(`FROM "%s" o JOIN "%s_fields" f ON o.key = f.key`, table, table)
+}
+
+func (q queryCache) ListByOptions(ctx context.Context, lo informer.ListOptions, partitions []partition.Partition, namespace string) (*unstructured.UnstructuredList, int, string, error) {
+	query, countQuery, params, err := q.queries(lo, partitions, namespace)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	countParams := params[:len(params):len(params)]
+
+	limit := lo.Pagination.PageSize
+	if limit == 0 || (lo.ChunkSize > 0 && lo.ChunkSize < limit) {
+		limit = lo.ChunkSize
+	}
+	if limit > 0 {
+		query += "\n  LIMIT ?"
+		params = append(params, limit)
+	}
+	offset := 0
+	if lo.Resume != "" {
+		if offset, err = strconv.Atoi(lo.Resume); err != nil {
+			return nil, 0, "", err
+		}
+	}
+	if lo.Pagination.Page >= 1 {
+		offset += lo.Pagination.PageSize * (lo.Pagination.Page - 1)
+	}
+	if offset > 0 {
+		if limit <= 0 {
+			// SQLite only supports offsets after a limit, a negative one being none
+			query += "\n  LIMIT -1"
+		}
+		query += "\n  OFFSET ?"
+		params = append(params, offset)
+	}
+	logrus.Debugf("sqlproxy list query: %s, params: %v", query, params)
+
+	items, total, err := q.run(ctx, query, params, countQuery, countParams, limit > 0 || offset > 0)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	continueToken := ""
+	if limit > 0 && offset+len(items) < total {
+		continueToken = strconv.Itoa(offset + limit)
+	}
+	list := &unstructured.UnstructuredList{Items: make([]unstructured.Unstructured, 0, len(items))}
+	for _, item := range items {
+		list.Items = append(list.Items, *item.(*unstructured.Unstructured))
+	}
+	return list, total, continueToken, nil
+}
+
+// CountByOptions returns the number of objects ListByOptions lists with lo, before pagination, with the count query only
+func (q queryCache) CountByOptions(ctx context.Context, lo informer.ListOptions, partitions []partition.Partition, namespace string) (int, error) {
+	_, countQuery, params, err := q.queries(lo, partitions, namespace)
+	if err != nil {
+		return 0, err
+	}
+	logrus.Debugf("sqlproxy count query: %s, params: %v", countQuery, params)
+
+	stmt, err := q.indexer.Prepare(countQuery)
+	if err != nil {
+		return 0, err
+	}
+	defer q.indexer.CloseStmt(stmt)
+	tx, err := q.indexer.BeginTx(ctx, false)
+	if err != nil {
+		return 0, err
+	}
+	rows, err := tx.Stmt(stmt).QueryContext(ctx, params...)
+	if err != nil {
+		return 0, cancelTx(tx, &sqlcachedb.QueryError{QueryString: countQuery, Err: err})
+	}
+	total, err := q.indexer.ReadInt(rows)
+	if err != nil {
+		return 0, cancelTx(tx, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return 0, err
+	}
+	return total, nil
+}
+
+// queries returns the query of the objects listed with lo, sorted but not paginated, the query of their number, and
+// the params of both
+func (q queryCache) queries(lo informer.ListOptions, partitions []partition.Partition, namespace string) (string, string, []any, error) {
+	from := q.from()
+	where, params, err := q.where(lo.Filters, partitions, namespace)
+	if err != nil {
+		return "", "", nil, err
+	}
+	if where != "" {
+		from += "\n  WHERE " + where
+	}
+	orderBy, err := q.orderBy(lo.Sort)
+	if err != nil {
+		return "", "", nil, err
+	}
+
+	if q.limits == (listLimits{}) {
+		// the count doesn't depend on the order of objects, nor reads them
+		return fmt.Sprintf("SELECT o.object, o.objectnonce, o.dekid %s\n  ORDER BY %s", from, orderBy), "SELECT COUNT(*) " + from, params, nil
+	}
+	limited, params, err := q.limitedQuery(from, params, orderBy, lo.Sort)
+	if err != nil {
+		return "", "", nil, err
+	}
+	return fmt.Sprintf("SELECT object, objectnonce, dekid FROM (%s)\n  ORDER BY list_row", limited), fmt.Sprintf("SELECT COUNT(*) FROM (%s)", limited), params, nil
+}
+
+// DistinctByOptions returns the distinct values of a field among the objects matching the filters of lo and belonging
+// to any of the partitions and to namespace, if set, counted by grouping the rows of the fields table by the column of
+// the field. Objects where the field is empty aren't counted, as the fields table doesn't tell them from those missing it.
+func (q queryCache) DistinctByOptions(ctx context.Context, lo informer.ListOptions, field []string, partitions []partition.Partition, namespace string) ([]listprocessor.DistinctValue, error) {
+	column, err := q.column(field)
+	if err != nil {
+		return nil, err
+	}
+	where, params, err := q.where(lo.Filters, partitions, namespace)
+	if err != nil {
+		return nil, err
+	}
+	clauses := []string{column + " IS NOT NULL", column + " != ''"}
+	if where != "" {
+		clauses = append([]string{where}, clauses...)
+	}
+	query := fmt.Sprintf("SELECT %s, COUNT(*) %s\n  WHERE %s\n  GROUP BY %s\n  ORDER BY COUNT(*) DESC, %s ASC",
+		column, q.from(), strings.Join(clauses, " AND "), column, column)
+	logrus.Debugf("sqlproxy distinct query: %s, params: %v", query, params)
+
+	stmt, err := q.indexer.Prepare(query)
+	if err != nil {
+		return nil, err
+	}
+	defer q.indexer.CloseStmt(stmt)
+	tx, err := q.indexer.BeginTx(ctx, false)
+	if err != nil {
+		return nil, err
+	}
+	rows, err := tx.Stmt(stmt).QueryContext(ctx, params...)
+	if err != nil {
+		return nil, cancelTx(tx, &sqlcachedb.QueryError{QueryString: query, Err: err})
+	}
+	values, err := readDistinct(rows)
+	if err != nil {
+		return nil, cancelTx(tx, err)
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// readDistinct reads the values and counts of the rows of a distinct query, which it closes
+func readDistinct(rows sqlcachedb.Rows) ([]listprocessor.DistinctValue, error) {
+	defer rows.Close()
+	values := []listprocessor.DistinctValue{}
+	for rows.Next() {
+		var value listprocessor.DistinctValue
+		if err := rows.Scan(&value.Value, &value.Count); err != nil {
+			return nil, err
+		}
+		values = append(values, value)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return values, nil
+}
+
+// limitedQuery returns the query of the objects selected by from, keeping at most the limit of objects per group, then
+// per namespace among those, and its params. Objects are numbered in the order of orderBy within their group and
+// namespace by window functions, and within the list as list_row, which the list is then sorted by.
+func (q queryCache) limitedQuery(from string, params []any, orderBy string, sortOpts informer.Sort) (string, []any, error) {
+	query := fmt.Sprintf(`SELECT o.object, o.objectnonce, o.dekid, f."metadata.namespace" AS namespace,
+    ROW_NUMBER() OVER (ORDER BY %s) AS list_row`, orderBy)
+	if !q.namespaced {
+		if q.limits.MaxPerNamespace > 0 {
+			return "", nil, fmt.Errorf("objects of types which aren't namespaced can't be limited per namespace")
+		}
+		query = fmt.Sprintf(`SELECT o.object, o.objectnonce, o.dekid,
+    ROW_NUMBER() OVER (ORDER BY %s) AS list_row`, orderBy)
+	}
+	if q.limits.GroupLimit > 0 {
+		if len(sortOpts.PrimaryField) == 0 {
+			return "", nil, fmt.Errorf("objects can only be limited per group when grouped")
+		}
+		column, err := q.column(sortOpts.PrimaryField)
+		if err != nil {
+			return "", nil, err
+		}
+		query += fmt.Sprintf(",\n    ROW_NUMBER() OVER (PARTITION BY %s ORDER BY %s) AS group_row\n  %s", column, orderBy, from)
+		query = fmt.Sprintf("SELECT * FROM (%s)\n  WHERE group_row <= ?", query)
+		params = append(params, q.limits.GroupLimit)
+	} else {
+		query += "\n  " + from
+	}
+	if q.limits.MaxPerNamespace > 0 {
+		// objects are numbered within their namespace among those kept in their group
+		query = fmt.Sprintf("SELECT *, ROW_NUMBER() OVER (PARTITION BY namespace ORDER BY list_row) AS namespace_row FROM (%s)", query)
+		query = fmt.Sprintf("SELECT * FROM (%s)\n  WHERE namespace_row <= ?", query)
+		params = append(params, q.limits.MaxPerNamespace)
+	}
+	return query, params, nil
+}
+
+// run returns the objects of a query and, if count is true, the number of objects of the count query, both read in
+// the same transaction so that they agree. The number of objects read is returned otherwise.
+func (q queryCache) run(ctx context.Context, query string, params []any, countQuery string, countParams []any, count bool) ([]any, int, error) {
+	stmt, err := q.indexer.Prepare(query)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer q.indexer.CloseStmt(stmt)
+	tx, err := q.indexer.BeginTx(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	rows, err := tx.Stmt(stmt).QueryContext(ctx, params...)
+	if err != nil {
+		return nil, 0, cancelTx(tx, &sqlcachedb.QueryError{QueryString: query, Err: err})
+	}
+	items, err := q.indexer.ReadObjects(rows, q.indexer.GetType(), q.indexer.GetShouldEncrypt())
+	if err != nil {
+		return nil, 0, cancelTx(tx, err)
+	}
+	total := len(items)
+	if count {
+		countStmt, err := q.indexer.Prepare(countQuery)
+		if err != nil {
+			return nil, 0, cancelTx(tx, err)
+		}
+		defer q.indexer.CloseStmt(countStmt)
+		rows, err := tx.Stmt(countStmt).QueryContext(ctx, countParams...)
+		if err != nil {
+			return nil, 0, cancelTx(tx, &sqlcachedb.QueryError{QueryString: countQuery, Err: err})
+		}
+		if total, err = q.indexer.ReadInt(rows); err != nil {
+			return nil, 0, cancelTx(tx, err)
+		}
+	}
+	if err := tx.Commit(); err != nil {
+		return nil, 0, err
+	}
+	return items, total, nil
+}
+
+func cancelTx(tx sqlcachedb.TXClient, err error) error {
+	if cerr := tx.Cancel(); cerr != nil {
+		return fmt.Errorf("failed to cancel transaction (%v) after error: %w", cerr, err)
+	}
+	return err
+}
+
+// where returns the conditions of the objects matching the filters and belonging to the namespace, if any, and to any
+// of the partitions, and their params. Partitions of projects are those of the namespaces of the project.
+func (q queryCache) where(filters []informer.OrFilter, partitions []partition.Partition, namespace string) (string, []any, error) {
+	var clauses []string
+	var params []any
+	for _, orFilter := range filters {
+		var orClauses []string
+		for _, filter := range orFilter.Filters {
+			clause, filterParams, err := q.filter(filter)
+			if err != nil {
+				return "", nil, err
+			}
+			orClauses = append(orClauses, clause)
+			params = append(params, filterParams...)
+		}
+		if len(orClauses) > 0 {
+			clauses = append(clauses, "("+strings.Join(orClauses, " OR ")+")")
+		}
+	}
+
+	if namespace != "" && namespace != "*" {
+		clause, namespaceParams := partition.NamespaceClause(namespace)
+		clauses = append(clauses, clause)
+		params = append(params, namespaceParams...)
+	}
+
+	var partitionClauses []string
+	for _, p := range partitions {
+		if p.Passthrough {
+			continue
+		}
+		var pClauses []string
+		if clause, scopeParams, ok := partition.ScopeClause(p); ok {
+			pClauses = append(pClauses, clause)
+			params = append(params, scopeParams...)
+		}
+		if !p.All {
+			names := p.Names.UnsortedList()
+			sort.Strings(names)
+			if len(names) == 0 {
+				pClauses = append(pClauses, "FALSE")
+			} else {
+				pClauses = append(pClauses, fmt.Sprintf(`f."metadata.name" IN (?%s)`, strings.Repeat(", ?", len(names)-1)))
+				for _, name := range names {
+					params = append(params, name)
+				}
+			}
+		}
+		if len(pClauses) > 0 {
+			partitionClauses = append(partitionClauses, strings.Join(pClauses, " AND "))
+		}
+	}
+	if len(partitions) == 0 {
+		clauses = append(clauses, "FALSE")
+	}
+	if len(partitionClauses) > 0 {
+		clauses = append(clauses, "(("+strings.Join(partitionClauses, ") OR (")+"))")
+	}
+	return strings.Join(clauses, " AND "), params, nil
+}
+
+// filter returns the condition of a filter and its params. Values are matched by pattern as ListByOptions matches them, and
+// compared by range filters as the type of their column. Labels are looked up in the labels table, so that any label
+// can be filtered on, whether its column is indexed or not.
+func (q queryCache) filter(filter informer.Filter) (string, []any, error) {
+	if label, ok := informer.LabelKey(filter.Field); ok {
+		return q.labelFilter(label, filter)
+	}
+	column, err := q.column(filter.Field)
+	if err != nil {
+		return "", nil, err
+	}
+	switch {
+	case listprocessor.IsRangeFilter(filter):
+		return rangeFilter(column, q.types.RangeType(filter.Field), filter)
+	case filter.Op == informer.Exists:
+		return column + " != ''", nil, nil
+	case filter.Op == informer.NotExists:
+		return column + " = ''", nil, nil
+	}
+	op := "LIKE"
+	if filter.Op == informer.NotEq {
+		op = "NOT LIKE"
+	}
+	return fmt.Sprintf(`%s %s ? ESCAPE '\'`, column, op), []any{likePattern(filter)}, nil
+}
+
+// labelFilter returns the condition of a filter on a label and its params, matching objects which have the label with
+// a matching value, or, for NotEq filters, which don't
+func (q queryCache) labelFilter(label string, filter informer.Filter) (string, []any, error) {
+	if listprocessor.IsRangeFilter(filter) {
+		cond, params, err := rangeFilter("l.value", q.types.RangeType(filter.Field), filter)
+		if err != nil {
+			return "", nil, err
+		}
+		clause, labelParams := informer.LabelFilter(q.indexer.GetName(), label, filter.Op, cond, params...)
+		return clause, labelParams, nil
+	}
+	clause, params := informer.LabelFilter(q.indexer.GetName(), label, filter.Op, `l.value LIKE ? ESCAPE '\'`, likePattern(filter))
+	return clause, params, nil
+}
+
+// likePattern returns the pattern a filter matches values with
+func likePattern(filter informer.Filter) string {
+	match := filter.Match
+	// backslashes are escaped first, not to escape the escapes of the other characters
+	match = strings.ReplaceAll(match, `\`, `\\`)
+	match = strings.ReplaceAll(match, `_`, `\_`)
+	match = strings.ReplaceAll(match, `%`, `\%`)
+	if filter.Partial {
+		match = "%" + match + "%"
+	}
+	return match
+}
+
+// rangeFilter returns the condition of a range filter on a column and its params. Values which aren't of the type of
+// the column, including empty ones, never match, as listprocessor.MatchesFilters matches them.
+func rangeFilter(column string, typ listprocessor.ColumnType, filter informer.Filter) (string, []any, error) {
+	switch typ {
+	case listprocessor.NumberColumn:
+		value, err := strconv.ParseFloat(filter.Match, 64)
+		if err != nil {
+			return "", nil, fmt.Errorf("filter on %s compares numbers with [%s]: %w", column, filter.Match, err)
+		}
+		return fmt.Sprintf("%s %s ?", numberValue(column), filter.Op), []any{value}, nil
+	case listprocessor.DateColumn:
+		return fmt.Sprintf("%s %s julianday(?)", dateValue(column), filter.Op), []any{filter.Match}, nil
+	}
+	return fmt.Sprintf("(%s != '' AND %s %s ?)", column, column, filter.Op), []any{filter.Match}, nil
+}
+
+// numberValue returns the value of a column as a number, NULL if it isn't one. SQLite casts any text to a number,
+// so the characters of the text are checked first.
+func numberValue(column string) string {
+	return fmt.Sprintf("(CASE WHEN %s GLOB '*[0-9]*' AND %s NOT GLOB '*[^0-9.eE+-]*' THEN CAST(%s AS REAL) END)", column, column, column)
+}
+
+// dateValue returns the value of a column as a point in time, NULL if it isn't one
+func dateValue(column string) string {
+	return fmt.Sprintf("julianday(%s)", column)
+}
+
+// orderBy returns the order of objects: that of the sort, by namespace and name if it is empty, then by key. Values
+// are sorted as the type of their column, those which aren't of it first.
+func (q queryCache) orderBy(sortOpts informer.Sort) (string, error) {
+	keys := []struct {
+		field []string
+		order informer.SortOrder
+	}{{sortOpts.PrimaryField, sortOpts.PrimaryOrder}, {sortOpts.SecondaryField, sortOpts.SecondaryOrder}}
+	var orderBy []string
+	for _, key := range keys {
+		if len(key.field) == 0 {
+			continue
+		}
+		column, err := q.column(key.field)
+		if err != nil {
+			return "", err
+		}
+		switch q.types.Of(key.field) {
+		case listprocessor.NumberColumn:
+			column = numberValue(column)
+		case listprocessor.DateColumn:
+			column = dateValue(column)
+		}
+		direction := "ASC"
+		if key.order == informer.DESC {
+			direction = "DESC"
+		}
+		orderBy = append(orderBy, column+" "+direction)
+	}
+	if len(orderBy) == 0 {
+		if q.namespaced {
+			orderBy = append(orderBy, `f."metadata.namespace" ASC`)
+		}
+		orderBy = append(orderBy, `f."metadata.name" ASC`)
+	}
+	orderBy = append(orderBy, "f."+keyColumn+" ASC")
+	return strings.Join(orderBy, ", "), nil
+}
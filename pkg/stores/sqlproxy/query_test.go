@@ -0,0 +1,834 @@
+package sqlproxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	sqlcachedb "github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/rancher/steve/pkg/sqlcache/factory"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	sqlcachestore "github.com/rancher/steve/pkg/sqlcache/store"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/client-go/tools/cache"
+)
+
+var podGVK = schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+
+func podSchema() *types.APISchema {
+	s := &types.APISchema{Schema: &schemas.Schema{ID: "pod"}}
+	attributes.SetGVK(s, podGVK)
+	attributes.SetNamespaced(s, true)
+	return s
+}
+
+func newPod(namespace, name, app, nodeName string) *unstructured.Unstructured {
+	pod := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Pod",
+		"id":         namespace + "/" + name,
+		"metadata": map[string]interface{}{
+			"name":              name,
+			"namespace":         namespace,
+			"creationTimestamp": "2024-01-01T00:00:00Z",
+		},
+		"spec": map[string]interface{}{"nodeName": nodeName},
+	}}
+	if app != "" {
+		pod.SetLabels(map[string]string{"app": app})
+	}
+	return pod
+}
+
+// newTestQueryCache returns the queryCache of a SQL cache of pods holding objs, indexing the fields the store
+// indexes for pods, the app label and spec.priority, a number
+func newTestQueryCache(t *testing.T, objs ...*unstructured.Unstructured) queryCache {
+	client, err := sqlcachedb.NewPooledClient(filepath.Join(t.TempDir(), "cache.db"), 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	store, err := sqlcachestore.NewStore(&unstructured.Unstructured{}, cache.DeletionHandlingMetaNamespaceKeyFunc, client, false, "_v1_Pod")
+	require.NoError(t, err)
+
+	s := &Store{}
+	schema := podSchema()
+	fields := append(s.IndexedFields(schema), []string{"metadata", "labels[app]"}, []string{"spec", "priority"})
+	indexer, err := informer.NewListOptionIndexer(fields, store, true)
+	require.NoError(t, err)
+	for _, obj := range objs {
+		require.NoError(t, indexer.Add(obj))
+	}
+
+	q, ok := s.queryCacheFor(factory.Cache{ByOptionsLister: &informer.Informer{ByOptionsLister: indexer}}, schema)
+	require.True(t, ok)
+	q.columns = append(q.columns, "metadata.labels[app]", "spec.priority")
+	q.types["spec.priority"] = listprocessor.NumberColumn
+	return q
+}
+
+func names(list *unstructured.UnstructuredList) []string {
+	var result []string
+	for _, item := range list.Items {
+		result = append(result, item.GetNamespace()+"/"+item.GetName())
+	}
+	return result
+}
+
+func TestQueryCacheFor(t *testing.T) {
+	s := &Store{}
+	_, ok := s.queryCacheFor(factory.Cache{ByOptionsLister: failingCache{}}, podSchema())
+	assert.False(t, ok, "caches other than the SQL cache can't be queried")
+}
+
+func TestQueryCacheListByOptions(t *testing.T) {
+	q := newTestQueryCache(t,
+		newPod("b", "pod1", "web", "node1"),
+		newPod("a", "pod2", "web", "node2"),
+		newPod("a", "pod3", "db", "node1"),
+		newPod("a", "pod1", "web", "node1"),
+		newPod("c", "pod4", "", "node2"),
+	)
+	all := []partition.Partition{{Passthrough: true}}
+
+	tests := []struct {
+		name         string
+		opts         informer.ListOptions
+		partitions   []partition.Partition
+		namespace    string
+		wantNames    []string
+		wantTotal    int
+		wantContinue string
+		wantErr      error
+	}{
+		{
+			name:       "sorted by namespace and name by default",
+			partitions: all,
+			wantNames:  []string{"a/pod1", "a/pod2", "a/pod3", "b/pod1", "c/pod4"},
+			wantTotal:  5,
+		},
+		{
+			name:       "objects sorting equally are sorted by key",
+			opts:       informer.ListOptions{Sort: informer.Sort{PrimaryField: []string{"metadata", "name"}}},
+			partitions: all,
+			wantNames:  []string{"a/pod1", "b/pod1", "a/pod2", "a/pod3", "c/pod4"},
+			wantTotal:  5,
+		},
+		{
+			name: "objects sorting equally by both sort fields are sorted by key",
+			opts: informer.ListOptions{Sort: informer.Sort{
+				PrimaryField:   []string{"metadata", "labels[app]"},
+				PrimaryOrder:   informer.DESC,
+				SecondaryField: []string{"spec", "nodeName"},
+			}},
+			partitions: all,
+			wantNames:  []string{"a/pod1", "b/pod1", "a/pod2", "a/pod3", "c/pod4"},
+			wantTotal:  5,
+		},
+		{
+			name: "pages of a label sort",
+			opts: informer.ListOptions{
+				Sort:       informer.Sort{PrimaryField: []string{"metadata", "labels[app]"}},
+				Pagination: informer.Pagination{PageSize: 2, Page: 2},
+			},
+			partitions:   all,
+			wantNames:    []string{"a/pod1", "a/pod2"},
+			wantTotal:    5,
+			wantContinue: "4",
+		},
+		{
+			name: "filters are ANDed, and their filters ORed",
+			opts: informer.ListOptions{Filters: []informer.OrFilter{
+				{Filters: []informer.Filter{{Field: []string{"spec", "nodeName"}, Match: "node1"}}},
+				{Filters: []informer.Filter{
+					{Field: []string{"metadata", "labels[app]"}, Match: "db"},
+					{Field: []string{"metadata", "namespace"}, Match: "b"},
+				}},
+			}},
+			partitions: all,
+			wantNames:  []string{"a/pod3", "b/pod1"},
+			wantTotal:  2,
+		},
+		{
+			name: "partial and negated filters",
+			opts: informer.ListOptions{Filters: []informer.OrFilter{
+				{Filters: []informer.Filter{{Field: []string{"metadata", "name"}, Match: "od1", Partial: true}}},
+				{Filters: []informer.Filter{{Field: []string{"metadata", "namespace"}, Match: "b", Op: informer.NotEq}}},
+			}},
+			partitions: all,
+			wantNames:  []string{"a/pod1"},
+			wantTotal:  1,
+		},
+		{
+			name: "patterns are matched literally",
+			opts: informer.ListOptions{Filters: []informer.OrFilter{
+				{Filters: []informer.Filter{{Field: []string{"metadata", "name"}, Match: "pod_", Partial: true}}},
+			}},
+			partitions: all,
+			wantTotal:  0,
+		},
+		{
+			name:       "namespace and partitions",
+			partitions: []partition.Partition{{Namespace: "a", Names: sets.New("pod1", "pod3")}, {Namespace: "c", All: true}},
+			wantNames:  []string{"a/pod1", "a/pod3", "c/pod4"},
+			wantTotal:  3,
+		},
+		{
+			name:      "no partitions",
+			wantTotal: 0,
+		},
+		{
+			name:       "request namespace",
+			partitions: all,
+			namespace:  "a",
+			wantNames:  []string{"a/pod1", "a/pod2", "a/pod3"},
+			wantTotal:  3,
+		},
+		{
+			name:         "continued without page size",
+			opts:         informer.ListOptions{ChunkSize: 2, Resume: "2"},
+			partitions:   all,
+			wantNames:    []string{"a/pod3", "b/pod1"},
+			wantTotal:    5,
+			wantContinue: "4",
+		},
+		{
+			name:       "unindexed sort field",
+			opts:       informer.ListOptions{Sort: informer.Sort{PrimaryField: []string{"spec", "hostname"}}},
+			partitions: all,
+			wantErr:    informer.InvalidColumnErr,
+		},
+		{
+			name: "unindexed filter field",
+			opts: informer.ListOptions{Filters: []informer.OrFilter{
+				{Filters: []informer.Filter{{Field: []string{"spec", "hostname"}, Match: "x"}}},
+			}},
+			partitions: all,
+			wantErr:    informer.InvalidColumnErr,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list, total, continueToken, err := q.ListByOptions(context.Background(), test.opts, test.partitions, test.namespace)
+			if test.wantErr != nil {
+				assert.ErrorIs(t, err, test.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.wantNames, names(list))
+			assert.Equal(t, test.wantTotal, total)
+			assert.Equal(t, test.wantContinue, continueToken)
+		})
+	}
+}
+
+func TestQueryCacheRangeFilters(t *testing.T) {
+	pod := func(name, nodeName, priority, created string) *unstructured.Unstructured {
+		obj := newPod("a", name, "", nodeName)
+		obj.Object["spec"].(map[string]interface{})["priority"] = priority
+		obj.SetCreationTimestamp(metav1.NewTime(mustParseTime(t, created)))
+		return obj
+	}
+	q := newTestQueryCache(t,
+		pod("pod1", "node1", "9", "2024-01-01T00:00:00Z"),
+		pod("pod2", "node2", "10", "2024-03-01T00:00:00Z"),
+		pod("pod3", "node1", "", "2024-01-15T00:00:00Z"),
+		pod("pod4", "node2", "high", "2024-02-15T12:00:00Z"),
+	)
+	all := []partition.Partition{{Passthrough: true}}
+	rangeFilter := func(field string, op informer.Op, value string) informer.Filter {
+		return informer.Filter{Field: listprocessor.SplitField(field), Op: op, Match: value}
+	}
+
+	tests := []struct {
+		name      string
+		opts      informer.ListOptions
+		wantNames []string
+	}{
+		{
+			name: "numbers are compared as numbers",
+			opts: informer.ListOptions{Filters: []informer.OrFilter{
+				{Filters: []informer.Filter{rangeFilter("spec.priority", listprocessor.Gt, "9")}},
+			}},
+			wantNames: []string{"a/pod2"},
+		},
+		{
+			name: "values which aren't numbers never match",
+			opts: informer.ListOptions{Filters: []informer.OrFilter{
+				{Filters: []informer.Filter{rangeFilter("spec.priority", listprocessor.Lte, "100")}},
+			}},
+			wantNames: []string{"a/pod1", "a/pod2"},
+		},
+		{
+			name: "dates are compared as points in time",
+			opts: informer.ListOptions{Filters: []informer.OrFilter{
+				{Filters: []informer.Filter{rangeFilter("metadata.creationTimestamp", listprocessor.Gte, "2024-02-15")}},
+				{Filters: []informer.Filter{rangeFilter("metadata.creationTimestamp", listprocessor.Lt, "2024-03-01T00:00:00Z")}},
+			}},
+			wantNames: []string{"a/pod4"},
+		},
+		{
+			name: "columns without a type are compared as text",
+			opts: informer.ListOptions{Filters: []informer.OrFilter{
+				{Filters: []informer.Filter{rangeFilter("spec.nodeName", listprocessor.Gt, "node1")}},
+			}},
+			wantNames: []string{"a/pod2", "a/pod4"},
+		},
+		{
+			name: "range filters ORed with other filters",
+			opts: informer.ListOptions{Filters: []informer.OrFilter{{Filters: []informer.Filter{
+				rangeFilter("spec.priority", listprocessor.Gte, "10"),
+				{Field: []string{"metadata", "name"}, Match: "pod3"},
+			}}}},
+			wantNames: []string{"a/pod2", "a/pod3"},
+		},
+		{
+			name:      "numbers are sorted as numbers, after values which aren't numbers",
+			opts:      informer.ListOptions{Sort: informer.Sort{PrimaryField: []string{"spec", "priority"}}},
+			wantNames: []string{"a/pod3", "a/pod4", "a/pod1", "a/pod2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list, _, _, err := q.ListByOptions(context.Background(), test.opts, all, "")
+			require.NoError(t, err)
+			assert.Equal(t, test.wantNames, names(list))
+
+			// the objects matching the filters in memory are the same
+			var matching []string
+			for _, obj := range []string{"pod1", "pod2", "pod3", "pod4"} {
+				list, _, _, err := q.ListByOptions(context.Background(), informer.ListOptions{Filters: []informer.OrFilter{
+					{Filters: []informer.Filter{{Field: []string{"metadata", "name"}, Match: obj}}},
+				}}, all, "")
+				require.NoError(t, err)
+				if listprocessor.MatchesFilters(list.Items[0], test.opts.Filters, q.types) {
+					matching = append(matching, "a/"+obj)
+				}
+			}
+			if len(test.opts.Filters) > 0 {
+				assert.Equal(t, test.wantNames, matching)
+			}
+		})
+	}
+}
+
+func TestQueryCacheLabels(t *testing.T) {
+	pod := func(name string, labels map[string]string) *unstructured.Unstructured {
+		obj := newPod("a", name, "", "node1")
+		obj.SetLabels(labels)
+		return obj
+	}
+	objs := []*unstructured.Unstructured{
+		pod("pod1", map[string]string{"app": "web", "tier": "front", "replicas": "3"}),
+		pod("pod2", map[string]string{"app": "", "tier": "back", "replicas": "10"}),
+		pod("pod3", nil),
+	}
+	q := newTestQueryCache(t, objs...)
+	all := []partition.Partition{{Passthrough: true}}
+	label := func(key string, op informer.Op, value string) informer.OrFilter {
+		return informer.OrFilter{Filters: []informer.Filter{{Field: []string{"metadata", "labels[" + key + "]"}, Op: op, Match: value}}}
+	}
+
+	tests := []struct {
+		name      string
+		filters   []informer.OrFilter
+		wantNames []string
+	}{
+		{
+			name:      "existing labels, even empty",
+			filters:   []informer.OrFilter{label("app", informer.Exists, "")},
+			wantNames: []string{"a/pod1", "a/pod2"},
+		},
+		{
+			name:      "missing labels",
+			filters:   []informer.OrFilter{label("app", informer.NotExists, "")},
+			wantNames: []string{"a/pod3"},
+		},
+		{
+			name:      "empty labels aren't missing ones",
+			filters:   []informer.OrFilter{label("app", informer.Eq, "")},
+			wantNames: []string{"a/pod2"},
+		},
+		{
+			name:      "labels which aren't indexed",
+			filters:   []informer.OrFilter{label("tier", informer.Eq, "front")},
+			wantNames: []string{"a/pod1"},
+		},
+		{
+			name:      "objects without a label don't have any value of it",
+			filters:   []informer.OrFilter{label("tier", informer.NotEq, "front")},
+			wantNames: []string{"a/pod2", "a/pod3"},
+		},
+		{
+			name:      "labels compared as numbers",
+			filters:   []informer.OrFilter{label("replicas", listprocessor.Gt, "5")},
+			wantNames: []string{"a/pod2"},
+		},
+		{
+			name:      "labels and fields",
+			filters:   []informer.OrFilter{label("tier", informer.Exists, ""), {Filters: []informer.Filter{{Field: []string{"metadata", "name"}, Match: "pod2"}}}},
+			wantNames: []string{"a/pod2"},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list, total, _, err := q.ListByOptions(context.Background(), informer.ListOptions{Filters: test.filters}, all, "")
+			require.NoError(t, err)
+			assert.Equal(t, test.wantNames, names(list))
+			assert.Equal(t, len(test.wantNames), total)
+
+			// the objects matching the filters in memory are the same
+			var matching []string
+			for _, obj := range objs {
+				if listprocessor.MatchesFilters(*obj, test.filters, q.types) {
+					matching = append(matching, "a/"+obj.GetName())
+				}
+			}
+			assert.Equal(t, test.wantNames, matching)
+		})
+	}
+
+	// labels are updated and deleted with their objects
+	indexer := q.indexer.(*informer.ListOptionIndexer)
+	require.NoError(t, indexer.Update(pod("pod1", map[string]string{"tier": "back"})))
+	require.NoError(t, indexer.Delete(objs[1]))
+	list, _, _, err := q.ListByOptions(context.Background(), informer.ListOptions{Filters: []informer.OrFilter{label("tier", informer.Eq, "back")}}, all, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/pod1"}, names(list))
+	list, _, _, err = q.ListByOptions(context.Background(), informer.ListOptions{Filters: []informer.OrFilter{label("app", informer.Exists, "")}}, all, "")
+	require.NoError(t, err)
+	assert.Empty(t, names(list))
+}
+
+func TestQueryCacheSetFilters(t *testing.T) {
+	q := newTestQueryCache(t,
+		newPod("a", "pod1", "web", "node1"),
+		newPod("a", "pod2", "db", "node2"),
+		newPod("a", "pod3", "", "node3"),
+	)
+	all := []partition.Partition{{Passthrough: true}}
+	list := func(filter string) ([]string, error) {
+		apiOp := &types.APIRequest{Request: &http.Request{URL: &url.URL{RawQuery: url.Values{"filter": {filter}}.Encode()}}}
+		opts, err := listprocessor.ParseQuery(apiOp, listprocessor.ParseOptions{})
+		require.NoError(t, err)
+		list, _, _, err := q.ListByOptions(context.Background(), opts, all, "")
+		if err != nil {
+			return nil, err
+		}
+		return names(list), nil
+	}
+
+	// sets work on any label, whether it is indexed or not
+	got, err := list("metadata.labels[app] in (web,db)")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/pod1", "a/pod2"}, got)
+	got, err = list("metadata.labels[app] notin (web)")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/pod2", "a/pod3"}, got)
+	got, err = list("metadata.labels[tier] notin (front)")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/pod1", "a/pod2", "a/pod3"}, got)
+
+	// other fields must be indexed, the error naming them
+	_, err = list("spec.hostname in (node1)")
+	assert.ErrorIs(t, err, informer.InvalidColumnErr)
+	assert.ErrorContains(t, err, "[spec.hostname]")
+}
+
+// fakeUsage is the CPU usage of pods by name
+type fakeUsage map[string]int64
+
+func (f fakeUsage) Fields(schema.GroupVersionKind) [][]string {
+	return [][]string{{"status", "usage", "cpu"}}
+}
+
+func (f fakeUsage) Add(_ schema.GroupVersionKind, items []unstructured.Unstructured) {
+	for _, item := range items {
+		if cpu, ok := f[item.GetName()]; ok {
+			_ = unstructured.SetNestedField(item.Object, cpu, "status", "usage", "cpu")
+		}
+	}
+}
+
+func TestQueryCacheUsage(t *testing.T) {
+	client, err := sqlcachedb.NewPooledClient(filepath.Join(t.TempDir(), "cache.db"), 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { client.Close() })
+	store, err := sqlcachestore.NewStore(&unstructured.Unstructured{}, cache.DeletionHandlingMetaNamespaceKeyFunc, client, false, "_v1_Pod")
+	require.NoError(t, err)
+
+	// usage is indexed, and set on objects as they are written to the cache
+	s := &Store{usage: fakeUsage{"pod1": 250, "pod2": 1500}}
+	schema := podSchema()
+	indexer, err := informer.NewListOptionIndexer(s.IndexedFields(schema), store, true)
+	require.NoError(t, err)
+	transform := s.usageTransform(podGVK, func(obj interface{}) (interface{}, error) { return obj, nil })
+	for _, name := range []string{"pod1", "pod2", "pod3"} {
+		obj, err := transform(newPod("a", name, "", "node1"))
+		require.NoError(t, err)
+		require.NoError(t, indexer.Add(obj))
+	}
+	assert.NotContains(t, s.memoryFields(schema), []string{"status", "usage", "cpu"})
+	q, ok := s.queryCacheFor(factory.Cache{ByOptionsLister: &informer.Informer{ByOptionsLister: indexer}}, schema)
+	require.True(t, ok)
+
+	// lists sort and paginate on usage in SQL, objects without usage sorting first
+	opts := informer.ListOptions{
+		Sort:       informer.Sort{PrimaryField: []string{"status", "usage", "cpu"}, PrimaryOrder: informer.DESC},
+		Pagination: informer.Pagination{PageSize: 2, Page: 1},
+	}
+	list, total, _, err := q.ListByOptions(context.Background(), opts, []partition.Partition{{Passthrough: true}}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/pod2", "a/pod1"}, names(list))
+	assert.Equal(t, 3, total)
+
+	opts = informer.ListOptions{Filters: []informer.OrFilter{
+		{Filters: []informer.Filter{{Field: []string{"status", "usage", "cpu"}, Op: listprocessor.Gt, Match: "1000"}}},
+	}}
+	list, total, _, err = q.ListByOptions(context.Background(), opts, []partition.Partition{{Passthrough: true}}, "")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a/pod2"}, names(list))
+	assert.Equal(t, 1, total)
+}
+
+func mustParseTime(t *testing.T, value string) time.Time {
+	parsed, err := time.Parse(time.RFC3339, value)
+	require.NoError(t, err)
+	return parsed
+}
+
+func TestQueryCacheLimits(t *testing.T) {
+	pods := []*unstructured.Unstructured{
+		newPod("b", "pod1", "web", "node1"),
+		newPod("a", "pod2", "web", "node2"),
+		newPod("a", "pod3", "db", "node1"),
+		newPod("a", "pod1", "web", "node1"),
+		newPod("c", "pod4", "", "node2"),
+	}
+	all := []partition.Partition{{Passthrough: true}}
+	byApp := informer.Sort{PrimaryField: []string{"metadata", "labels[app]"}}
+
+	tests := []struct {
+		name         string
+		limits       listLimits
+		opts         informer.ListOptions
+		wantNames    []string
+		wantTotal    int
+		wantContinue string
+		wantErr      bool
+	}{
+		{
+			name:      "first objects of each group",
+			limits:    listLimits{GroupLimit: 1},
+			opts:      informer.ListOptions{Sort: byApp},
+			wantNames: []string{"c/pod4", "a/pod3", "a/pod1"},
+			wantTotal: 3,
+		},
+		{
+			name:   "groups sorted in reverse",
+			limits: listLimits{GroupLimit: 2},
+			opts: informer.ListOptions{Sort: informer.Sort{
+				PrimaryField:   []string{"metadata", "labels[app]"},
+				PrimaryOrder:   informer.DESC,
+				SecondaryField: []string{"metadata", "namespace"},
+				SecondaryOrder: informer.DESC,
+			}},
+			wantNames: []string{"b/pod1", "a/pod1", "a/pod3", "c/pod4"},
+			wantTotal: 4,
+		},
+		{
+			name:   "pages of the limited objects",
+			limits: listLimits{GroupLimit: 2},
+			opts: informer.ListOptions{
+				Sort:       byApp,
+				Pagination: informer.Pagination{PageSize: 2, Page: 2},
+			},
+			wantNames: []string{"a/pod1", "a/pod2"},
+			wantTotal: 4,
+		},
+		{
+			name:   "filtered objects",
+			limits: listLimits{GroupLimit: 1},
+			opts: informer.ListOptions{
+				Sort: byApp,
+				Filters: []informer.OrFilter{
+					{Filters: []informer.Filter{{Field: []string{"metadata", "namespace"}, Match: "a", Op: informer.NotEq}}},
+				},
+			},
+			wantNames: []string{"c/pod4", "b/pod1"},
+			wantTotal: 2,
+		},
+		{
+			name:      "first objects of each namespace",
+			limits:    listLimits{MaxPerNamespace: 1},
+			wantNames: []string{"a/pod1", "b/pod1", "c/pod4"},
+			wantTotal: 3,
+		},
+		{
+			name:      "first objects of each namespace in the sort order",
+			limits:    listLimits{MaxPerNamespace: 2},
+			opts:      informer.ListOptions{Sort: informer.Sort{PrimaryField: []string{"metadata", "name"}, PrimaryOrder: informer.DESC}},
+			wantNames: []string{"c/pod4", "a/pod3", "a/pod2", "b/pod1"},
+			wantTotal: 4,
+		},
+		{
+			// a/pod2 is the second object of namespace a among those kept in their group, but the third among all
+			name:      "objects of each namespace among those kept in their group",
+			limits:    listLimits{GroupLimit: 1, MaxPerNamespace: 2},
+			opts:      informer.ListOptions{Sort: informer.Sort{PrimaryField: []string{"spec", "nodeName"}}},
+			wantNames: []string{"a/pod1", "a/pod2"},
+			wantTotal: 2,
+		},
+		{
+			name:    "objects which aren't grouped",
+			limits:  listLimits{GroupLimit: 1},
+			wantErr: true,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			q := newTestQueryCache(t, pods...)
+			q.limits = test.limits
+			list, total, continueToken, err := q.ListByOptions(context.Background(), test.opts, all, "")
+			if test.wantErr {
+				assert.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, test.wantNames, names(list))
+			assert.Equal(t, test.wantTotal, total)
+			assert.Equal(t, test.wantContinue, continueToken)
+
+			total, err = q.CountByOptions(context.Background(), test.opts, all, "")
+			require.NoError(t, err)
+			assert.Equal(t, test.wantTotal, total, "count query")
+		})
+	}
+}
+
+// TestQueryCacheCounts checks that the total of lists is exact, and that their pages add up to the whole list, for all
+// combinations of label sorts, namespace filters as the projectsornamespaces query param sets them, partitions and
+// pagination
+func TestQueryCacheCounts(t *testing.T) {
+	var pods []*unstructured.Unstructured
+	for i, namespace := range []string{"a", "b", "c"} {
+		for j := 0; j < 7; j++ {
+			app := []string{"web", "db", ""}[(i+j)%3]
+			pods = append(pods, newPod(namespace, fmt.Sprintf("pod%d", j), app, fmt.Sprintf("node%d", j%2)))
+		}
+	}
+	q := newTestQueryCache(t, pods...)
+
+	app := []string{"metadata", "labels[app]"}
+	namespace := []string{"metadata", "namespace"}
+	sorts := map[string]informer.Sort{
+		"unsorted":        {},
+		"label":           {PrimaryField: app},
+		"label and field": {PrimaryField: app, PrimaryOrder: informer.DESC, SecondaryField: []string{"spec", "nodeName"}},
+	}
+	filters := map[string][]informer.OrFilter{
+		"all namespaces": nil,
+		"projectsornamespaces": {{Filters: []informer.Filter{
+			{Field: namespace, Match: "a", Op: informer.Eq},
+			{Field: namespace, Match: "c", Op: informer.Eq},
+		}}},
+		"projectsornamespaces!": {{Filters: []informer.Filter{{Field: namespace, Match: "b", Op: informer.NotEq}}}},
+		"projectsornamespaces and label": {
+			{Filters: []informer.Filter{{Field: namespace, Match: "b", Op: informer.NotEq}}},
+			{Filters: []informer.Filter{{Field: app, Match: "web", Op: informer.Eq}}},
+		},
+	}
+	partitions := map[string][]partition.Partition{
+		"all":         {{Passthrough: true}},
+		"some access": {{Namespace: "a", Names: sets.New("pod1", "pod2", "pod3")}, {Namespace: "c", All: true}},
+	}
+
+	for sortName, sortOpts := range sorts {
+		for filterName, orFilters := range filters {
+			for partitionName, partitions := range partitions {
+				t.Run(sortName+"/"+filterName+"/"+partitionName, func(t *testing.T) {
+					opts := informer.ListOptions{Sort: sortOpts, Filters: orFilters}
+					list, total, continueToken, err := q.ListByOptions(context.Background(), opts, partitions, "")
+					require.NoError(t, err)
+					all := names(list)
+					assert.Equal(t, len(all), total)
+					assert.Empty(t, continueToken)
+					count, err := q.CountByOptions(context.Background(), opts, partitions, "")
+					require.NoError(t, err)
+					assert.Equal(t, len(all), count, "count query")
+
+					for _, pageSize := range []int{1, 2, 5, 100} {
+						var pages []string
+						for page := 1; ; page++ {
+							opts.Pagination = informer.Pagination{PageSize: pageSize, Page: page}
+							list, total, _, err := q.ListByOptions(context.Background(), opts, partitions, "")
+							require.NoError(t, err)
+							assert.Equal(t, len(all), total, "total of page %d of %d", page, pageSize)
+							if len(list.Items) == 0 {
+								break
+							}
+							pages = append(pages, names(list)...)
+						}
+						assert.Equal(t, all, pages, "pages of %d", pageSize)
+
+						var chunks []string
+						opts.Pagination = informer.Pagination{}
+						opts.ChunkSize, opts.Resume = pageSize, ""
+						for {
+							list, total, continueToken, err := q.ListByOptions(context.Background(), opts, partitions, "")
+							require.NoError(t, err)
+							assert.Equal(t, len(all), total, "total of chunks of %d", pageSize)
+							chunks = append(chunks, names(list)...)
+							if continueToken == "" {
+								break
+							}
+							opts.Resume = continueToken
+						}
+						assert.Equal(t, all, chunks, "chunks of %d", pageSize)
+						opts.ChunkSize, opts.Resume = 0, ""
+					}
+				})
+			}
+		}
+	}
+}
+
+// TestQueryCacheProjects tests that partitions of projects list the objects of the namespaces of the project, as the
+// fields table of namespaces has them, and that they are counted as such
+func TestQueryCacheProjects(t *testing.T) {
+	q := newTestQueryCache(t,
+		newPod("a", "pod1", "web", "node1"),
+		newPod("a", "pod2", "db", "node1"),
+		newPod("b", "pod1", "web", "node2"),
+		newPod("c", "pod1", "web", "node2"),
+	)
+	tx, err := q.indexer.BeginTx(context.Background(), true)
+	require.NoError(t, err)
+	require.NoError(t, tx.Exec(`CREATE TABLE "_v1_Namespace_fields" (key TEXT PRIMARY KEY, "metadata.name" TEXT, "metadata.labels[field.cattle.io/projectId]" TEXT)`))
+	for _, ns := range [][]string{{"a", "p-1"}, {"b", "p-2"}, {"c", "p-1"}, {"d", "p-1"}} {
+		require.NoError(t, tx.Exec(`INSERT INTO "_v1_Namespace_fields" VALUES (?, ?, ?)`, ns[0], ns[0], ns[1]))
+	}
+	require.NoError(t, tx.Commit())
+
+	tests := []struct {
+		name       string
+		partitions []partition.Partition
+		namespace  string
+		wantNames  []string
+	}{
+		{
+			name:       "project",
+			partitions: []partition.Partition{partition.Project("p-1")},
+			wantNames:  []string{"a/pod1", "a/pod2", "c/pod1"},
+		},
+		{
+			name:       "names of a project",
+			partitions: []partition.Partition{{Project: "p-1", Names: sets.New("pod1")}},
+			wantNames:  []string{"a/pod1", "c/pod1"},
+		},
+		{
+			name:       "projects and namespaces",
+			partitions: []partition.Partition{partition.Project("p-2"), {Namespace: "c", All: true}},
+			wantNames:  []string{"b/pod1", "c/pod1"},
+		},
+		{
+			name:       "namespace of a project",
+			partitions: []partition.Partition{partition.Project("p-1")},
+			namespace:  "c",
+			wantNames:  []string{"c/pod1"},
+		},
+		{
+			name:       "project without namespaces",
+			partitions: []partition.Partition{partition.Project("p-3")},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list, total, _, err := q.ListByOptions(context.Background(), informer.ListOptions{}, test.partitions, test.namespace)
+			require.NoError(t, err)
+			assert.Equal(t, test.wantNames, names(list))
+			assert.Equal(t, len(test.wantNames), total)
+
+			_, total, _, err = q.ListByOptions(context.Background(), informer.ListOptions{ChunkSize: 1}, test.partitions, test.namespace)
+			require.NoError(t, err)
+			assert.Equal(t, len(test.wantNames), total, "count")
+			total, err = q.CountByOptions(context.Background(), informer.ListOptions{}, test.partitions, test.namespace)
+			require.NoError(t, err)
+			assert.Equal(t, len(test.wantNames), total, "count query")
+		})
+	}
+}
+
+// TestQueryCacheDistinct tests that the values counted by the query of the queryCache are those listprocessor.Distinct
+// counts among the listed objects, except for empty values
+func TestQueryCacheDistinct(t *testing.T) {
+	q := newTestQueryCache(t,
+		newPod("a", "pod1", "web", "node1"),
+		newPod("a", "pod2", "db", "node1"),
+		newPod("a", "pod3", "web", "node2"),
+		newPod("b", "pod1", "web", "node2"),
+		newPod("b", "pod2", "", "node2"),
+		newPod("c", "pod1", "db", "node3"),
+	)
+	app := []string{"metadata", "labels[app]"}
+	nodeName := []string{"spec", "nodeName"}
+
+	tests := []struct {
+		name       string
+		opts       informer.ListOptions
+		partitions []partition.Partition
+		namespace  string
+	}{
+		{
+			name:       "all",
+			partitions: []partition.Partition{{Passthrough: true}},
+		},
+		{
+			name:       "filtered",
+			opts:       informer.ListOptions{Filters: []informer.OrFilter{{Filters: []informer.Filter{{Field: app, Match: "web", Op: informer.Eq}}}}},
+			partitions: []partition.Partition{{Passthrough: true}},
+		},
+		{
+			name:       "partitions",
+			partitions: []partition.Partition{{Namespace: "a", Names: sets.New("pod1", "pod2")}, {Namespace: "c", All: true}},
+		},
+		{
+			name:       "namespace",
+			partitions: []partition.Partition{{Passthrough: true}},
+			namespace:  "b",
+		},
+		{
+			name: "no partitions",
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			list, _, _, err := q.ListByOptions(context.Background(), test.opts, test.partitions, test.namespace)
+			require.NoError(t, err)
+			want := listprocessor.Distinct(list.Items, [][]string{app, nodeName})
+			for _, field := range [][]string{app, nodeName} {
+				got, err := q.DistinctByOptions(context.Background(), test.opts, field, test.partitions, test.namespace)
+				require.NoError(t, err)
+				assert.Equal(t, slices.DeleteFunc(want[listprocessor.DistinctKey(field)], func(v listprocessor.DistinctValue) bool {
+					return v.Value == ""
+				}), got, "values of %s", listprocessor.DistinctKey(field))
+			}
+		})
+	}
+
+	_, err := q.DistinctByOptions(context.Background(), informer.ListOptions{}, []string{"spec", "hostname"}, []partition.Partition{{Passthrough: true}}, "")
+	assert.ErrorIs(t, err, informer.InvalidColumnErr)
+}
@@ -0,0 +1,202 @@
+package sqlproxy
+
+import (
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/rancher/steve/pkg/clustercache"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+const (
+	// DefaultResultTTL is how long the results of identical lists are cached by default, short enough for clients
+	// refreshing a view not to notice
+	DefaultResultTTL = 2 * time.Second
+	// DefaultResultCacheSize is the estimated memory, in bytes of JSON, the cached results of lists take at most by
+	// default
+	DefaultResultCacheSize = 64 << 20
+	// maxCachedResults bounds the number of list results cached at once
+	maxCachedResults = 1000
+)
+
+// resultCache caches the results of lists of the cache for a short time, so that identical lists, such as those of
+// the same view refreshed by many clients, are only run once. Results are keyed by the options and the partitions of
+// lists, which are the same for users with the same access, and are dropped when objects of their type change.
+type resultCache struct {
+	ttl time.Duration
+	// maxBytes bounds the estimated memory of the cached results, which isn't bounded if it is zero
+	maxBytes int64
+	now      func() time.Time
+
+	lock sync.Mutex
+	// results are cached by type, then by key
+	results map[schema.GroupVersionKind]map[string]cachedResult
+	size    int
+	// bytes is the estimated memory of the cached results
+	bytes int64
+	// generations are incremented whenever objects of a type change, so that lists which started before a change
+	// aren't cached
+	generations map[schema.GroupVersionKind]uint64
+}
+
+type cachedResult struct {
+	expires       time.Time
+	items         []unstructured.Unstructured
+	total         int
+	continueToken string
+	revision      string
+	bytes         int64
+}
+
+// resultKey is what identifies identical lists
+type resultKey struct {
+	GVK        schema.GroupVersionKind
+	Namespace  string
+	Options    informer.ListOptions
+	Limits     listLimits
+	Partitions []partition.Partition
+}
+
+func newResultCache(ttl time.Duration, maxBytes int64) *resultCache {
+	return &resultCache{
+		ttl:         ttl,
+		maxBytes:    max(maxBytes, 0),
+		now:         time.Now,
+		results:     map[schema.GroupVersionKind]map[string]cachedResult{},
+		generations: map[schema.GroupVersionKind]uint64{},
+	}
+}
+
+// invalidateOn drops the cached results of lists of a type whenever objects of the type change in clusterCache
+func (c *resultCache) invalidateOn(ctx context.Context, clusterCache clustercache.ClusterCache) {
+	clusterCache.OnAdd(ctx, c.onChange)
+	clusterCache.OnChange(ctx, func(gvk schema.GroupVersionKind, key string, obj, _ runtime.Object) error {
+		return c.onChange(gvk, key, obj)
+	})
+	clusterCache.OnRemove(ctx, c.onChange)
+}
+
+// list returns the result of a list of the cache, cached if an identical list was run recently. The limits are those
+// the cache applies, if any, and sizeOf estimates the memory of listed objects. Stores without a result cache always
+// list from the cache.
+func (c *resultCache) list(ctx context.Context, gvk schema.GroupVersionKind, cache listprocessor.Cache, opts informer.ListOptions, limits listLimits, partitions []partition.Partition, namespace string, sizeOf func([]unstructured.Unstructured) int64) (*unstructured.UnstructuredList, int, string, error) {
+	if c == nil {
+		return cache.ListByOptions(ctx, opts, partitions, namespace)
+	}
+	data, err := json.Marshal(resultKey{
+		GVK:        gvk,
+		Namespace:  namespace,
+		Options:    opts,
+		Limits:     limits,
+		Partitions: partitions,
+	})
+	if err != nil {
+		return cache.ListByOptions(ctx, opts, partitions, namespace)
+	}
+	key := string(data)
+
+	c.lock.Lock()
+	result, ok := c.results[gvk][key]
+	generation := c.generations[gvk]
+	c.lock.Unlock()
+	if ok && c.now().Before(result.expires) {
+		list := &unstructured.UnstructuredList{Items: copyItems(result.items)}
+		list.SetResourceVersion(result.revision)
+		return list, result.total, result.continueToken, nil
+	}
+
+	list, total, continueToken, err := cache.ListByOptions(ctx, opts, partitions, namespace)
+	if err != nil {
+		return list, total, continueToken, err
+	}
+
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.generations[gvk] != generation {
+		return list, total, continueToken, nil
+	}
+	now := c.now()
+	bytes := sizeOf(list.Items)
+	if previous, ok := c.results[gvk][key]; ok {
+		// the expired result is replaced
+		c.drop(gvk, key, previous)
+	}
+	if !c.fits(bytes) {
+		c.expire(now)
+		if !c.fits(bytes) {
+			return list, total, continueToken, nil
+		}
+	}
+	if c.results[gvk] == nil {
+		c.results[gvk] = map[string]cachedResult{}
+	}
+	// listed objects are modified by the callers of ListByPartitions, cached objects are therefore copies
+	c.results[gvk][key] = cachedResult{
+		expires:       now.Add(c.ttl),
+		items:         copyItems(list.Items),
+		total:         total,
+		continueToken: continueToken,
+		revision:      list.GetResourceVersion(),
+		bytes:         bytes,
+	}
+	c.size++
+	c.bytes += bytes
+	return list, total, continueToken, nil
+}
+
+// fits returns whether a result of the given estimated memory can be cached along the cached results, c.lock being
+// held
+func (c *resultCache) fits(bytes int64) bool {
+	if c.size >= maxCachedResults {
+		return false
+	}
+	return c.maxBytes == 0 || c.bytes+bytes <= c.maxBytes
+}
+
+// drop forgets a cached result, c.lock being held
+func (c *resultCache) drop(gvk schema.GroupVersionKind, key string, result cachedResult) {
+	delete(c.results[gvk], key)
+	c.size--
+	c.bytes -= result.bytes
+}
+
+// onChange drops the cached results of the type of a changed object
+func (c *resultCache) onChange(gvk schema.GroupVersionKind, _ string, _ runtime.Object) error {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.generations[gvk]++
+	for key, result := range c.results[gvk] {
+		c.drop(gvk, key, result)
+	}
+	delete(c.results, gvk)
+	return nil
+}
+
+// expire drops the expired results
+func (c *resultCache) expire(now time.Time) {
+	for gvk, results := range c.results {
+		for key, result := range results {
+			if !now.Before(result.expires) {
+				c.drop(gvk, key, result)
+			}
+		}
+		if len(results) == 0 {
+			delete(c.results, gvk)
+		}
+	}
+}
+
+func copyItems(items []unstructured.Unstructured) []unstructured.Unstructured {
+	result := make([]unstructured.Unstructured, len(items))
+	for i := range items {
+		items[i].DeepCopyInto(&result[i])
+	}
+	return result
+}
@@ -0,0 +1,127 @@
+package sqlproxy
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/util/sets"
+)
+
+func TestResultCache(t *testing.T) {
+	ctx := context.Background()
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "Node"}
+	opts := informer.ListOptions{ChunkSize: 100, Sort: informer.Sort{PrimaryField: []string{"metadata", "name"}}}
+	partitions := []partition.Partition{{Passthrough: true}}
+	node := unstructured.Unstructured{Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "node1"}}}
+	now := time.Now()
+	c := newResultCache(time.Second, 0)
+	c.now = func() time.Time { return now }
+	sizeOf := (*listBudget)(nil).sizer("node")
+	cache := NewMockCache(gomock.NewController(t))
+	expectList := func(opts informer.ListOptions, partitions []partition.Partition) {
+		cache.EXPECT().ListByOptions(ctx, opts, partitions, "").Return(
+			&unstructured.UnstructuredList{Items: []unstructured.Unstructured{*node.DeepCopy()}}, 1, "", nil)
+	}
+
+	expectList(opts, partitions)
+	list, total, _, err := c.list(ctx, gvk, cache, opts, listLimits{}, partitions, "", sizeOf)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	// callers modify listed objects, which mustn't change cached results
+	list.Items[0].Object["events"] = []interface{}{}
+	list, total, _, err = c.list(ctx, gvk, cache, opts, listLimits{}, partitions, "", sizeOf)
+	require.NoError(t, err)
+	assert.Equal(t, 1, total)
+	assert.Equal(t, []unstructured.Unstructured{node}, list.Items)
+
+	// lists of users with other access are not shared
+	namespaced := []partition.Partition{{Namespace: "default", Names: sets.New("node1")}}
+	expectList(opts, namespaced)
+	_, _, _, err = c.list(ctx, gvk, cache, opts, listLimits{}, namespaced, "", sizeOf)
+	require.NoError(t, err)
+
+	// nor lists limiting the objects of groups differently
+	expectList(opts, partitions)
+	_, _, _, err = c.list(ctx, gvk, cache, opts, listLimits{GroupLimit: 1}, partitions, "", sizeOf)
+	require.NoError(t, err)
+
+	// changes of objects of the type drop the results
+	require.NoError(t, c.onChange(gvk, "node1", nil))
+	expectList(opts, partitions)
+	_, _, _, err = c.list(ctx, gvk, cache, opts, listLimits{}, partitions, "", sizeOf)
+	require.NoError(t, err)
+	_, _, _, err = c.list(ctx, gvk, cache, opts, listLimits{}, partitions, "", sizeOf)
+	require.NoError(t, err)
+
+	// results expire
+	now = now.Add(time.Second)
+	expectList(opts, partitions)
+	_, _, _, err = c.list(ctx, gvk, cache, opts, listLimits{}, partitions, "", sizeOf)
+	require.NoError(t, err)
+
+	// stores without a result cache always list from the cache
+	var noCache *resultCache
+	expectList(opts, partitions)
+	_, _, _, err = noCache.list(ctx, gvk, cache, opts, listLimits{}, partitions, "", sizeOf)
+	require.NoError(t, err)
+}
+
+func TestResultCacheSize(t *testing.T) {
+	ctx := context.Background()
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "Node"}
+	partitions := []partition.Partition{{Passthrough: true}}
+	now := time.Now()
+	// results are estimated to take 100 bytes per object, as the objects of lists
+	budget := &listBudget{sizes: map[string]int64{"node": 100}}
+	c := newResultCache(time.Second, 250)
+	c.now = func() time.Time { return now }
+	cache := NewMockCache(gomock.NewController(t))
+	list := func(name string, objects int) {
+		opts := informer.ListOptions{Filters: []informer.OrFilter{{Filters: []informer.Filter{{Field: []string{"metadata", "name"}, Match: name}}}}}
+		cache.EXPECT().ListByOptions(ctx, opts, partitions, "").Return(
+			&unstructured.UnstructuredList{Items: make([]unstructured.Unstructured, objects)}, objects, "", nil).MaxTimes(1)
+		_, _, _, err := c.list(ctx, gvk, cache, opts, listLimits{}, partitions, "", budget.sizer("node"))
+		require.NoError(t, err)
+	}
+
+	list("small", 1)
+	list("medium", 1)
+	assert.Equal(t, int64(200), c.bytes)
+	// results which don't fit along the cached results aren't cached
+	list("large", 2)
+	assert.Equal(t, 2, c.size)
+	assert.Equal(t, int64(200), c.bytes)
+	// nor results larger than the cache
+	list("huge", 3)
+	assert.Equal(t, 2, c.size)
+
+	// expired results make room for new ones
+	now = now.Add(time.Second)
+	list("large", 2)
+	assert.Equal(t, 1, c.size)
+	assert.Equal(t, int64(200), c.bytes)
+
+	require.NoError(t, c.onChange(gvk, "node1", nil))
+	assert.Equal(t, 0, c.size)
+	assert.Equal(t, int64(0), c.bytes)
+}
+
+func TestListBudgetSizer(t *testing.T) {
+	node := unstructured.Unstructured{Object: map[string]interface{}{"kind": "Node"}}
+	items := []unstructured.Unstructured{node, node, node}
+	// stores without budgets sample the first listed object
+	assert.Equal(t, int64(3*len(`{"kind":"Node"}`)), (*listBudget)(nil).sizer("node")(items))
+	// budgets reuse the size of the last sample object of the schema
+	budget := &listBudget{sizes: map[string]int64{"node": 100}}
+	assert.Equal(t, int64(300), budget.sizer("node")(items))
+	assert.Equal(t, int64(3*len(`{"kind":"Node"}`)), budget.sizer("pod")(items))
+	assert.Equal(t, int64(0), budget.sizer("node")(nil))
+}
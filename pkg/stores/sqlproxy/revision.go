@@ -0,0 +1,124 @@
+package sqlproxy
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+var (
+	// revisionWaitTimeout is how long lists wait for the cache to catch up with the revision they must not be older
+	// than, as long as the watch cache of Kubernetes waits
+	revisionWaitTimeout = 3 * time.Second
+	// revisionPollInterval is how often the revision of the cache is checked while waiting
+	revisionPollInterval = 50 * time.Millisecond
+)
+
+var (
+	// ErrRevisionTooLarge is returned when the cache doesn't reach the requested revision in time, as Kubernetes does
+	ErrRevisionTooLarge = validation.ErrorCode{Code: "Timeout", Status: http.StatusGatewayTimeout}
+	// ErrRevisionGone is returned when the requested revision isn't the current one of the cache, which keeps no history
+	ErrRevisionGone = validation.ErrorCode{Code: "Gone", Status: http.StatusGone}
+)
+
+// revisioner is implemented by the informers of the cache, whose revision is the resourceVersion of the last list or
+// watch event written to the cache
+type revisioner interface {
+	LastSyncResourceVersion() string
+}
+
+// revisionedCache sets the revision of the cache, read before listing, on the lists of the cache, so that the listed
+// objects are at least as recent as it and a watch started from it misses no event
+type revisionedCache struct {
+	cache      listprocessor.Cache
+	revisioner revisioner
+}
+
+func (r revisionedCache) ListByOptions(ctx context.Context, lo informer.ListOptions, partitions []partition.Partition, namespace string) (*unstructured.UnstructuredList, int, string, error) {
+	revision := r.revisioner.LastSyncResourceVersion()
+	list, total, continueToken, err := r.cache.ListByOptions(ctx, lo, partitions, namespace)
+	if err == nil {
+		list.SetResourceVersion(revision)
+	}
+	return list, total, continueToken, err
+}
+
+// cacheRevisioner returns the revisioner of the informer of a cache, or nil if it doesn't report its revision
+func cacheRevisioner(lister informer.ByOptionsLister) revisioner {
+	if inf, ok := lister.(*informer.Informer); ok && inf.SharedIndexInformer == nil {
+		// informers which don't watch have no revision
+		return nil
+	}
+	r, _ := lister.(revisioner)
+	return r
+}
+
+// waitForRevision waits for the cache to reach the requested revision, if any. Lists not older than a revision wait
+// for the cache to catch up, up to revisionWaitTimeout, while exact lists fail right away unless the cache is at
+// exactly that revision.
+func waitForRevision(ctx context.Context, r revisioner, opts listprocessor.RevisionOptions) error {
+	if opts.Revision == 0 {
+		return nil
+	}
+	if r == nil {
+		return apierror.NewAPIError(validation.InvalidOption, "the cache of this type doesn't report its revision")
+	}
+	if opts.Match == listprocessor.RevisionMatchExact {
+		return checkRevision(r, opts)
+	}
+	current, err := currentRevision(r)
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, revisionWaitTimeout)
+	defer cancel()
+	ticker := time.NewTicker(revisionPollInterval)
+	defer ticker.Stop()
+	for current < opts.Revision {
+		select {
+		case <-ctx.Done():
+			return apierror.NewAPIError(ErrRevisionTooLarge,
+				fmt.Sprintf("Too large resource version: %d, current: %d", opts.Revision, current))
+		case <-ticker.C:
+		}
+		if current, err = currentRevision(r); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkRevision fails unless the cache is at exactly the requested revision
+func checkRevision(r revisioner, opts listprocessor.RevisionOptions) error {
+	current, err := currentRevision(r)
+	if err != nil {
+		return err
+	}
+	if current != opts.Revision {
+		return apierror.NewAPIError(ErrRevisionGone,
+			fmt.Sprintf("revision %d is not available, the cache is at revision %d and keeps no history", opts.Revision, current))
+	}
+	return nil
+}
+
+func currentRevision(r revisioner) (uint64, error) {
+	revision := r.LastSyncResourceVersion()
+	if revision == "" {
+		return 0, nil
+	}
+	current, err := strconv.ParseUint(revision, 10, 64)
+	if err != nil {
+		return 0, apierror.NewAPIError(validation.ServerError, fmt.Sprintf("revision %q of the cache is not numeric", revision))
+	}
+	return current, nil
+}
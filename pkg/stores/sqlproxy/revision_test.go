@@ -0,0 +1,149 @@
+package sqlproxy
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.uber.org/mock/gomock"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+type fakeRevisioner struct {
+	revision atomic.Value
+}
+
+func newFakeRevisioner(revision string) *fakeRevisioner {
+	r := &fakeRevisioner{}
+	r.revision.Store(revision)
+	return r
+}
+
+func (f *fakeRevisioner) LastSyncResourceVersion() string {
+	return f.revision.Load().(string)
+}
+
+func TestWaitForRevision(t *testing.T) {
+	defer func(timeout, interval time.Duration) {
+		revisionWaitTimeout, revisionPollInterval = timeout, interval
+	}(revisionWaitTimeout, revisionPollInterval)
+	revisionWaitTimeout = 100 * time.Millisecond
+	revisionPollInterval = time.Millisecond
+
+	tests := []struct {
+		name       string
+		revision   string
+		catchUp    string
+		opts       listprocessor.RevisionOptions
+		noRevision bool
+		wantStatus int
+	}{
+		{
+			name:     "any revision",
+			revision: "10",
+		},
+		{
+			name:       "any revision of a cache without revisions",
+			noRevision: true,
+		},
+		{
+			name:     "not older than an older revision",
+			revision: "10",
+			opts:     listprocessor.RevisionOptions{Revision: 5, Match: listprocessor.RevisionMatchNotOlderThan},
+		},
+		{
+			name:     "not older than the current revision",
+			revision: "10",
+			opts:     listprocessor.RevisionOptions{Revision: 10, Match: listprocessor.RevisionMatchNotOlderThan},
+		},
+		{
+			name:     "not older than a revision the cache catches up with",
+			revision: "10",
+			catchUp:  "12",
+			opts:     listprocessor.RevisionOptions{Revision: 12, Match: listprocessor.RevisionMatchNotOlderThan},
+		},
+		{
+			name:       "not older than a revision the cache doesn't reach",
+			revision:   "10",
+			opts:       listprocessor.RevisionOptions{Revision: 12, Match: listprocessor.RevisionMatchNotOlderThan},
+			wantStatus: 504,
+		},
+		{
+			name:     "exactly the current revision",
+			revision: "10",
+			opts:     listprocessor.RevisionOptions{Revision: 10, Match: listprocessor.RevisionMatchExact},
+		},
+		{
+			name:       "exactly an older revision",
+			revision:   "10",
+			opts:       listprocessor.RevisionOptions{Revision: 5, Match: listprocessor.RevisionMatchExact},
+			wantStatus: 410,
+		},
+		{
+			name:       "a revision of a cache without revisions",
+			noRevision: true,
+			opts:       listprocessor.RevisionOptions{Revision: 5, Match: listprocessor.RevisionMatchNotOlderThan},
+			wantStatus: 422,
+		},
+		{
+			name:       "a revision of a cache with a non numeric revision",
+			revision:   "abc",
+			opts:       listprocessor.RevisionOptions{Revision: 5, Match: listprocessor.RevisionMatchNotOlderThan},
+			wantStatus: 500,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			var r revisioner
+			if !test.noRevision {
+				fake := newFakeRevisioner(test.revision)
+				if test.catchUp != "" {
+					time.AfterFunc(10*time.Millisecond, func() { fake.revision.Store(test.catchUp) })
+				}
+				r = fake
+			}
+			err := waitForRevision(context.Background(), r, test.opts)
+			if test.wantStatus == 0 {
+				assert.NoError(t, err)
+				return
+			}
+			var apiErr *apierror.APIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, test.wantStatus, apiErr.Code.Status)
+		})
+	}
+}
+
+func TestRevisionedCache(t *testing.T) {
+	ctx := context.Background()
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "Node"}
+	opts := informer.ListOptions{ChunkSize: 100}
+	partitions := []partition.Partition{{Passthrough: true}}
+	r := newFakeRevisioner("10")
+	mockCache := NewMockCache(gomock.NewController(t))
+	mockCache.EXPECT().ListByOptions(ctx, opts, partitions, "").DoAndReturn(
+		func(context.Context, informer.ListOptions, []partition.Partition, string) (*unstructured.UnstructuredList, int, string, error) {
+			// events written while listing are in the list, and seen again by watches from the revision
+			r.revision.Store("11")
+			return &unstructured.UnstructuredList{}, 0, "", nil
+		})
+	cache := revisionedCache{cache: mockCache, revisioner: r}
+	c := newResultCache(time.Second, 0)
+	sizeOf := (*listBudget)(nil).sizer("pod")
+
+	list, _, _, err := c.list(ctx, gvk, cache, opts, listLimits{}, partitions, "", sizeOf)
+	require.NoError(t, err)
+	assert.Equal(t, "10", list.GetResourceVersion())
+	// cached results are at the revision they were listed at
+	list, _, _, err = c.list(ctx, gvk, cache, opts, listLimits{}, partitions, "", sizeOf)
+	require.NoError(t, err)
+	assert.Equal(t, "10", list.GetResourceVersion())
+}
@@ -1,9 +1,9 @@
 // Code generated by MockGen. DO NOT EDIT.
-// Source: github.com/rancher/lasso/pkg/cache/sql/informer (interfaces: ByOptionsLister)
+// Source: github.com/rancher/steve/pkg/sqlcache/informer (interfaces: ByOptionsLister)
 //
 // Generated by this command:
 //
-//	mockgen --build_flags=--mod=mod -package sqlproxy -destination ./sql_informer_mocks_test.go github.com/rancher/lasso/pkg/cache/sql/informer ByOptionsLister
+//	mockgen --build_flags=--mod=mod -package sqlproxy -destination ./sql_informer_mocks_test.go github.com/rancher/steve/pkg/sqlcache/informer ByOptionsLister
 //
 
 // Package sqlproxy is a generated GoMock package.
@@ -13,8 +13,8 @@ import (
 	context "context"
 	reflect "reflect"
 
-	informer "github.com/rancher/lasso/pkg/cache/sql/informer"
-	partition "github.com/rancher/lasso/pkg/cache/sql/partition"
+	informer "github.com/rancher/steve/pkg/sqlcache/informer"
+	partition "github.com/rancher/steve/pkg/sqlcache/partition"
 	gomock "go.uber.org/mock/gomock"
 	unstructured "k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
 )
@@ -0,0 +1,83 @@
+package sqlproxy
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+// syncProgressInterval is how often the progress of the initial sync of the cache of a type is logged, syncs shorter
+// than it not being logged
+var syncProgressInterval = 10 * time.Second
+
+// syncProgress counts the objects added to the cache of a type during its initial sync, which is otherwise silent and
+// can take minutes for types with tens of thousands of objects. Objects are counted as they are transformed, before
+// being written.
+type syncProgress struct {
+	gvk     schema.GroupVersionKind
+	started time.Time
+	objects atomic.Int64
+}
+
+func newSyncProgress(gvk schema.GroupVersionKind) *syncProgress {
+	return &syncProgress{
+		gvk:     gvk,
+		started: time.Now(),
+	}
+}
+
+// transform counts the objects transformed by transformFunc
+func (p *syncProgress) transform(transformFunc cache.TransformFunc) cache.TransformFunc {
+	return func(obj interface{}) (interface{}, error) {
+		p.objects.Add(1)
+		if transformFunc == nil {
+			return obj, nil
+		}
+		return transformFunc(obj)
+	}
+}
+
+// report logs the number of objects added to the cache every syncProgressInterval until done is closed. Caches which
+// already exist are not synced, so nothing is logged for them.
+func (p *syncProgress) report(done <-chan struct{}) {
+	ticker := time.NewTicker(syncProgressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			if elapsed := time.Since(p.started); elapsed >= syncProgressInterval && p.objects.Load() > 0 {
+				logrus.Infof("Cached %d objects of %s in %s", p.objects.Load(), p.gvk, elapsed.Round(time.Second))
+			}
+			return
+		case <-ticker.C:
+			if objects := p.objects.Load(); objects > 0 {
+				logrus.Infof("Caching objects of %s: %d so far, %.0f per second", p.gvk, objects, float64(objects)/time.Since(p.started).Seconds())
+			}
+		}
+	}
+}
+
+func (s *Store) synced(gvk schema.GroupVersionKind) bool {
+	s.syncedLock.Lock()
+	defer s.syncedLock.Unlock()
+	return s.syncedGVKs[gvk]
+}
+
+func (s *Store) setSynced(gvk schema.GroupVersionKind) {
+	s.syncedLock.Lock()
+	defer s.syncedLock.Unlock()
+	if s.syncedGVKs == nil {
+		s.syncedGVKs = map[schema.GroupVersionKind]bool{}
+	}
+	s.syncedGVKs[gvk] = true
+}
+
+// resetSynced forgets the synced types, whose caches are recreated after a reset
+func (s *Store) resetSynced() {
+	s.syncedLock.Lock()
+	defer s.syncedLock.Unlock()
+	s.syncedGVKs = nil
+}
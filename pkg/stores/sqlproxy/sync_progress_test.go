@@ -0,0 +1,32 @@
+package sqlproxy
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func TestSyncProgress(t *testing.T) {
+	gvk := schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	progress := newSyncProgress(gvk)
+
+	identity := progress.transform(nil)
+	obj, err := identity("pod")
+	assert.NoError(t, err)
+	assert.Equal(t, "pod", obj)
+	transform := progress.transform(func(obj interface{}) (interface{}, error) {
+		return obj.(string) + " transformed", nil
+	})
+	obj, err = transform("pod")
+	assert.NoError(t, err)
+	assert.Equal(t, "pod transformed", obj)
+	assert.Equal(t, int64(2), progress.objects.Load())
+
+	var s Store
+	assert.False(t, s.synced(gvk))
+	s.setSynced(gvk)
+	assert.True(t, s.synced(gvk))
+	s.resetSynced()
+	assert.False(t, s.synced(gvk), "caches are synced again after a reset")
+}
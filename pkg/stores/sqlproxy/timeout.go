@@ -0,0 +1,60 @@
+package sqlproxy
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"github.com/rancher/wrangler/v3/pkg/schemas/validation"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+)
+
+// DefaultQueryTimeout is how long queries of the cache can run by default, well over the time lists of tens of
+// thousands of objects take
+const DefaultQueryTimeout = time.Minute
+
+// ErrQueryTimeout is returned when a query of the cache exceeds the query timeout or the deadline of its request
+var ErrQueryTimeout = validation.ErrorCode{Code: "QueryTimeout", Status: http.StatusGatewayTimeout}
+
+// timed returns cache with its queries bounded by the query timeout
+func (s *Store) timed(cache listprocessor.Cache) listprocessor.Cache {
+	return timedCache{cache: cache, timeout: s.queryTimeout}
+}
+
+// timedCache runs the queries of a cache with a timeout, reporting the queries interrupted by it or by the deadline of
+// their request as ErrQueryTimeout, and those interrupted by their request being canceled as context.Canceled
+type timedCache struct {
+	cache   listprocessor.Cache
+	timeout time.Duration
+}
+
+func (t timedCache) ListByOptions(ctx context.Context, lo informer.ListOptions, partitions []partition.Partition, namespace string) (*unstructured.UnstructuredList, int, string, error) {
+	queryCtx := ctx
+	if t.timeout > 0 {
+		var cancel context.CancelFunc
+		queryCtx, cancel = context.WithTimeout(ctx, t.timeout)
+		defer cancel()
+	}
+	list, total, continueToken, err := t.cache.ListByOptions(queryCtx, lo, partitions, namespace)
+	if err == nil {
+		return list, total, continueToken, nil
+	}
+	// interrupted queries fail with driver errors, their cause is that of their context
+	switch {
+	case errors.Is(ctx.Err(), context.Canceled):
+		return nil, 0, "", fmt.Errorf("query canceled: %w", ctx.Err())
+	case errors.Is(queryCtx.Err(), context.DeadlineExceeded):
+		message := "query exceeded the deadline of the request"
+		if ctx.Err() == nil {
+			message = fmt.Sprintf("query exceeded the timeout of %s", t.timeout)
+		}
+		return nil, 0, "", apierror.NewAPIError(ErrQueryTimeout, message)
+	}
+	return nil, 0, "", err
+}
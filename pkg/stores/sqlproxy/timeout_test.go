@@ -0,0 +1,101 @@
+package sqlproxy
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/rancher/apiserver/pkg/apierror"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	_ "modernc.org/sqlite"
+)
+
+// sqliteCache runs a query which only ends when it is interrupted, as a label join over many objects would
+type sqliteCache struct {
+	db *sql.DB
+}
+
+func (c sqliteCache) ListByOptions(ctx context.Context, _ informer.ListOptions, _ []partition.Partition, _ string) (*unstructured.UnstructuredList, int, string, error) {
+	var count int
+	err := c.db.QueryRowContext(ctx, "WITH RECURSIVE c(x) AS (SELECT 1 UNION ALL SELECT x+1 FROM c) SELECT count(*) FROM c").Scan(&count)
+	if err != nil {
+		return nil, 0, "", err
+	}
+	return &unstructured.UnstructuredList{}, count, "", nil
+}
+
+// failingCache fails its queries with err, if any
+type failingCache struct {
+	err error
+}
+
+func (c failingCache) ListByOptions(context.Context, informer.ListOptions, []partition.Partition, string) (*unstructured.UnstructuredList, int, string, error) {
+	return nil, 0, "", c.err
+}
+
+func newSQLiteCache(t *testing.T) sqliteCache {
+	db, err := sql.Open("sqlite", "file::memory:")
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	return sqliteCache{db: db}
+}
+
+func assertQueryTimeout(t *testing.T, err error) {
+	t.Helper()
+	var apiErr *apierror.APIError
+	require.True(t, errors.As(err, &apiErr), "expected an API error, got %v", err)
+	assert.Equal(t, ErrQueryTimeout, apiErr.Code)
+	assert.Equal(t, http.StatusGatewayTimeout, apiErr.Code.Status)
+}
+
+func TestQueryTimeout(t *testing.T) {
+	s := &Store{}
+	s.queryTimeout = 50 * time.Millisecond
+
+	start := time.Now()
+	_, _, _, err := s.timed(newSQLiteCache(t)).ListByOptions(context.Background(), informer.ListOptions{}, nil, "")
+	assertQueryTimeout(t, err)
+	assert.Contains(t, err.Error(), "timeout of 50ms")
+	assert.Less(t, time.Since(start), 5*time.Second, "the query is interrupted")
+}
+
+func TestQueryRequestDeadline(t *testing.T) {
+	s := &Store{}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	_, _, _, err := s.timed(newSQLiteCache(t)).ListByOptions(ctx, informer.ListOptions{}, nil, "")
+	assertQueryTimeout(t, err)
+	assert.Contains(t, err.Error(), "deadline of the request")
+}
+
+func TestQueryCanceled(t *testing.T) {
+	s := &Store{}
+	s.queryTimeout = time.Minute
+	ctx, cancel := context.WithCancel(context.Background())
+	// the client disconnects while the query runs
+	time.AfterFunc(50*time.Millisecond, cancel)
+
+	start := time.Now()
+	_, _, _, err := s.timed(newSQLiteCache(t)).ListByOptions(ctx, informer.ListOptions{}, nil, "")
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Less(t, time.Since(start), 5*time.Second, "the query is interrupted")
+}
+
+func TestQueryErrors(t *testing.T) {
+	s := &Store{}
+	s.queryTimeout = time.Minute
+	_, _, _, err := s.timed(failingCache{err: informer.InvalidColumnErr}).ListByOptions(context.Background(), informer.ListOptions{}, nil, "")
+	assert.ErrorIs(t, err, informer.InvalidColumnErr, "other errors are returned as is")
+
+	s.queryTimeout = 0
+	_, _, _, err = s.timed(failingCache{}).ListByOptions(context.Background(), informer.ListOptions{}, nil, "")
+	assert.NoError(t, err)
+}
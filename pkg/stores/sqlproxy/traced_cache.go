@@ -0,0 +1,35 @@
+package sqlproxy
+
+import (
+	"context"
+
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"github.com/rancher/steve/pkg/tracing"
+	"go.opentelemetry.io/otel/attribute"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// tracedCache traces the queries of the cache of a type with a span each
+type tracedCache struct {
+	cache listprocessor.Cache
+	gvk   schema.GroupVersionKind
+}
+
+func (t tracedCache) ListByOptions(ctx context.Context, lo informer.ListOptions, partitions []partition.Partition, namespace string) (*unstructured.UnstructuredList, int, string, error) {
+	ctx, span := tracing.Start(ctx, "sqlcache.ListByOptions",
+		attribute.String("steve.gvk", t.gvk.String()),
+		attribute.String("steve.namespace", namespace),
+		attribute.Int("steve.partitions", len(partitions)),
+		attribute.Int("steve.filters", len(lo.Filters)),
+		attribute.Int("steve.chunkSize", lo.ChunkSize),
+	)
+	list, total, continueToken, err := t.cache.ListByOptions(ctx, lo, partitions, namespace)
+	if err == nil {
+		span.SetAttributes(attribute.Int("steve.total", total), attribute.Int("steve.items", len(list.Items)))
+	}
+	tracing.End(span, err)
+	return list, total, continueToken, err
+}
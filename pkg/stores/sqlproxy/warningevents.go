@@ -0,0 +1,219 @@
+package sqlproxy
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"sync"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/resources/virtual/problems"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/wrangler/v3/pkg/data"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/sirupsen/logrus"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/tools/cache"
+)
+
+// eventGVK is the type of the events counted in the problems of the objects they involve
+var eventGVK = schema.GroupVersionKind{Version: "v1", Kind: "Event"}
+
+// baseEventSchema is the schema of the cache of events created by CountWarningEvents
+var baseEventSchema = types.APISchema{
+	Schema: &schemas.Schema{
+		ID: "event",
+		Attributes: map[string]interface{}{
+			"group":      "",
+			"version":    "v1",
+			"kind":       "Event",
+			"resource":   "events",
+			"namespaced": true,
+			"verbs":      []string{"list", "watch"},
+		},
+	},
+}
+
+// warningEvents counts the warning events involving each object, by the UID of the object, from the cache of events
+type warningEvents struct {
+	informer     cache.SharedIndexInformer
+	registration cache.ResourceEventHandlerRegistration
+
+	lock sync.RWMutex
+	// uids are the UIDs of the objects involved in the warning events, by the key of the event
+	uids   map[string]string
+	counts map[string]int
+}
+
+func newWarningEvents(inf cache.SharedIndexInformer) *warningEvents {
+	w := &warningEvents{
+		informer: inf,
+		uids:     map[string]string{},
+		counts:   map[string]int{},
+	}
+	registration, err := inf.AddEventHandler(cache.ResourceEventHandlerFuncs{
+		AddFunc: w.set,
+		UpdateFunc: func(_, obj interface{}) {
+			w.set(obj)
+		},
+		DeleteFunc: w.remove,
+	})
+	if err != nil {
+		logrus.Errorf("failed to count the warning events of objects: %v", err)
+	}
+	w.registration = registration
+	return w
+}
+
+// stop removes the handler counting the events of the informer
+func (w *warningEvents) stop() {
+	if w.registration == nil {
+		return
+	}
+	if err := w.informer.RemoveEventHandler(w.registration); err != nil {
+		logrus.Debugf("failed to stop counting the warning events of objects: %v", err)
+	}
+}
+
+func (w *warningEvents) set(obj interface{}) {
+	event, ok := obj.(*unstructured.Unstructured)
+	if !ok {
+		return
+	}
+	key, err := cache.MetaNamespaceKeyFunc(event)
+	if err != nil {
+		return
+	}
+	// the type field of events is copied to _type by the transform of the cache
+	eventType := data.Object(event.Object).String("_type")
+	if eventType == "" {
+		eventType = data.Object(event.Object).String("type")
+	}
+	uid := data.Object(event.Object).String("involvedObject", "uid")
+
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.unset(key)
+	if eventType == "Warning" && uid != "" {
+		w.uids[key] = uid
+		w.counts[uid]++
+	}
+}
+
+func (w *warningEvents) remove(obj interface{}) {
+	key, err := cache.DeletionHandlingMetaNamespaceKeyFunc(obj)
+	if err != nil {
+		return
+	}
+	w.lock.Lock()
+	defer w.lock.Unlock()
+	w.unset(key)
+}
+
+// unset forgets an event, w.lock being held
+func (w *warningEvents) unset(key string) {
+	uid, ok := w.uids[key]
+	if !ok {
+		return
+	}
+	delete(w.uids, key)
+	if w.counts[uid]--; w.counts[uid] <= 0 {
+		delete(w.counts, uid)
+	}
+}
+
+// add sets the number of warning events involving each listed object
+func (w *warningEvents) add(items []unstructured.Unstructured) {
+	w.lock.RLock()
+	defer w.lock.RUnlock()
+	for i := range items {
+		count := w.counts[string(items[i].GetUID())]
+		data.PutValue(items[i].Object, strconv.Itoa(count), problems.EventsField...)
+	}
+}
+
+// warningEventCounts returns the counts of warning events, nil until the cache of events is created
+func (s *Store) warningEventCounts() *warningEvents {
+	s.warningEventsLock.Lock()
+	defer s.warningEventsLock.Unlock()
+	return s.warningEvents
+}
+
+// countWarningEvents starts counting the warning events of the cache of events, unless they are counted already from
+// the informer of the cache
+func (s *Store) countWarningEvents(gvk schema.GroupVersionKind, lister informer.ByOptionsLister) {
+	if gvk != eventGVK {
+		return
+	}
+	inf, ok := lister.(*informer.Informer)
+	if !ok || inf.SharedIndexInformer == nil {
+		return
+	}
+	s.warningEventsLock.Lock()
+	defer s.warningEventsLock.Unlock()
+	if s.warningEvents == nil || s.warningEvents.informer != inf.SharedIndexInformer {
+		if s.warningEvents != nil {
+			s.warningEvents.stop()
+		}
+		s.warningEvents = newWarningEvents(inf.SharedIndexInformer)
+	}
+}
+
+// resetWarningEvents stops counting the warning events of the cache of events, which is reset
+func (s *Store) resetWarningEvents() {
+	s.warningEventsLock.Lock()
+	defer s.warningEventsLock.Unlock()
+	if s.warningEvents != nil {
+		s.warningEvents.stop()
+		s.warningEvents = nil
+	}
+}
+
+// CountWarningEvents creates the cache of events when the store starts and whenever it is reset, so that the warning
+// events of objects are counted from then on rather than from the first list sorting or filtering on them.
+func (s *Store) CountWarningEvents() error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.countEventsAtStart = true
+	return s.initializeEventCache()
+}
+
+// initializeEventCache creates the cache of events, whose warning events are then counted
+func (s *Store) initializeEventCache() error {
+	eventSchema := baseEventSchema
+	if err := s.columnSetter.SetColumns(context.Background(), &eventSchema); err != nil {
+		return fmt.Errorf("failed to set columns for proxy stores event informer: %w", err)
+	}
+	_, err := s.cacheFor(nil, &eventSchema)
+	return err
+}
+
+// startWarningEvents creates the cache of events, whose warning events are then counted, if it doesn't exist yet, such
+// as when CountWarningEvents isn't used or failed
+func (s *Store) startWarningEvents(apiOp *types.APIRequest) error {
+	if s.warningEventCounts() != nil || apiOp.Schemas == nil {
+		return nil
+	}
+	eventSchema := apiOp.Schemas.LookupSchema("event")
+	if eventSchema == nil {
+		return nil
+	}
+	_, err := s.cacheFor(apiOp, eventSchema)
+	return err
+}
+
+// refersToField returns whether a list sorts or filters on a field
+func refersToField(opts informer.ListOptions, field []string) bool {
+	if isOneOf(field, [][]string{opts.Sort.PrimaryField, opts.Sort.SecondaryField}) {
+		return true
+	}
+	for _, orFilter := range opts.Filters {
+		for _, filter := range orFilter.Filters {
+			if isOneOf(field, [][]string{filter.Field}) {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package sqlproxy
+
+import (
+	"testing"
+
+	"github.com/rancher/steve/pkg/resources/virtual/problems"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/client-go/tools/cache"
+)
+
+func newEvent(name, eventType, uid string) *unstructured.Unstructured {
+	return &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "Event",
+		"metadata": map[string]interface{}{
+			"name":      name,
+			"namespace": "default",
+		},
+		"_type": eventType,
+		"involvedObject": map[string]interface{}{
+			"uid": uid,
+		},
+	}}
+}
+
+func TestWarningEvents(t *testing.T) {
+	w := &warningEvents{uids: map[string]string{}, counts: map[string]int{}}
+	w.set(newEvent("backoff", "Warning", "pod1"))
+	w.set(newEvent("failed", "Warning", "pod1"))
+	w.set(newEvent("pulled", "Normal", "pod1"))
+	w.set(newEvent("unhealthy", "Warning", "pod2"))
+	// events are counted once however many times they are updated
+	w.set(newEvent("backoff", "Warning", "pod1"))
+	// events which aren't warnings anymore aren't counted
+	w.set(newEvent("unhealthy", "Normal", "pod2"))
+	w.remove(cache.DeletedFinalStateUnknown{Key: "default/failed", Obj: newEvent("failed", "Warning", "pod1")})
+
+	items := []unstructured.Unstructured{{}, {}, {}}
+	items[0].SetUID("pod1")
+	items[1].SetUID("pod2")
+	items[2].SetUID("pod3")
+	w.add(items)
+	var counts []string
+	for _, item := range items {
+		count, _, _ := unstructured.NestedString(item.Object, problems.EventsField...)
+		counts = append(counts, count)
+	}
+	assert.Equal(t, []string{"1", "0", "0"}, counts)
+	assert.Len(t, w.uids, 1)
+	assert.Len(t, w.counts, 1, "objects without warning events are forgotten")
+}
+
+// handlerInformer records the event handlers added to an informer
+type handlerInformer struct {
+	cache.SharedIndexInformer
+	handlers int
+}
+
+func (h *handlerInformer) AddEventHandler(cache.ResourceEventHandler) (cache.ResourceEventHandlerRegistration, error) {
+	h.handlers++
+	return h, nil
+}
+
+func (h *handlerInformer) RemoveEventHandler(cache.ResourceEventHandlerRegistration) error {
+	h.handlers--
+	return nil
+}
+
+func (h *handlerInformer) HasSynced() bool {
+	return true
+}
+
+func TestCountWarningEvents(t *testing.T) {
+	s := &Store{}
+	first := &handlerInformer{}
+	s.countWarningEvents(eventGVK, &informer.Informer{SharedIndexInformer: first})
+	s.countWarningEvents(eventGVK, &informer.Informer{SharedIndexInformer: first})
+	assert.Equal(t, 1, first.handlers, "events are counted once per informer")
+
+	// the informer of the cache is replaced once the cache is reset
+	second := &handlerInformer{}
+	s.countWarningEvents(eventGVK, &informer.Informer{SharedIndexInformer: second})
+	assert.Equal(t, 0, first.handlers, "events of the replaced informer aren't counted anymore")
+	assert.Equal(t, 1, second.handlers)
+	assert.Equal(t, cache.SharedIndexInformer(second), s.warningEventCounts().informer)
+
+	s.resetWarningEvents()
+	assert.Equal(t, 0, second.handlers)
+	assert.Nil(t, s.warningEventCounts())
+}
+
+func TestRefersToField(t *testing.T) {
+	tests := []struct {
+		name string
+		opts informer.ListOptions
+		want bool
+	}{
+		{
+			name: "sort",
+			opts: informer.ListOptions{Sort: informer.Sort{PrimaryField: []string{"metadata", "name"}, SecondaryField: problems.EventsField}},
+			want: true,
+		},
+		{
+			name: "range filter ORed with another filter",
+			opts: informer.ListOptions{Filters: []informer.OrFilter{{Filters: []informer.Filter{
+				{Field: []string{"metadata", "name"}, Match: "web"},
+				{Field: problems.EventsField, Op: listprocessor.Gt, Match: "0"},
+			}}}},
+			want: true,
+		},
+		{
+			name: "other fields",
+			opts: informer.ListOptions{
+				Sort:    informer.Sort{PrimaryField: problems.ErrorsField},
+				Filters: []informer.OrFilter{{Filters: []informer.Filter{{Field: problems.WarningsField, Op: listprocessor.Gt, Match: "0"}}}},
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			assert.Equal(t, test.want, refersToField(test.opts, problems.EventsField))
+		})
+	}
+}
@@ -0,0 +1,104 @@
+package sqlproxy
+
+import (
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/attributes"
+	"github.com/rancher/steve/pkg/sqlcache/informer"
+	"github.com/rancher/steve/pkg/sqlcache/partition"
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+	"k8s.io/client-go/tools/cache"
+)
+
+// filteredWatch tracks which objects of a watch match its filters, so that objects which start or stop matching them
+// are sent as added or deleted, as Kubernetes does for the label selectors of watches, rather than as modified
+type filteredWatch struct {
+	filters   listprocessor.WatchFilters
+	transform cache.TransformFunc
+	// matching are the keys of the objects matching the filters as of the last event
+	matching sets.Set[string]
+}
+
+// newFilteredWatch returns the filteredWatch of a watch of a schema's type, whose objects matching the filters when it
+// starts are those of the cache
+func (s *Store) newFilteredWatch(apiOp *types.APIRequest, schema *types.APISchema, filters listprocessor.WatchFilters) (*filteredWatch, error) {
+	inf, err := s.cacheFor(apiOp, schema)
+	if err != nil {
+		return nil, err
+	}
+	// the cache only applies the filters it can, the objects it returns are then matched against all of them
+	listCache, queryable := s.listCache(inf, schema)
+	cacheFilters, _ := splitFilters(filters.Filters, s.memoryFields(schema), queryable)
+	list, _, _, err := s.timed(listCache).ListByOptions(apiOp.Context(), informer.ListOptions{Filters: cacheFilters},
+		[]partition.Partition{{Passthrough: true}}, apiOp.Namespace)
+	if err != nil {
+		return nil, err
+	}
+	f := &filteredWatch{
+		filters:   filters,
+		transform: s.transformBuilder.GetTransformFunc(attributes.GVK(schema)),
+		matching:  sets.New[string](),
+	}
+	for _, item := range list.Items {
+		if filters.Matches(item) {
+			f.matching.Insert(watchKey(&item))
+		}
+	}
+	return f, nil
+}
+
+// event returns the event to send for an event of the watch, if any: events of objects matching the filters are
+// sent, as added if they didn't match before, and objects which stop matching them are sent as deleted.
+func (f *filteredWatch) event(event watch.Event) (watch.Event, bool) {
+	obj, ok := event.Object.(*unstructured.Unstructured)
+	if !ok {
+		return event, true
+	}
+	key := watchKey(obj)
+	matched := f.matching.Has(key)
+	if event.Type == watch.Deleted {
+		f.matching.Delete(key)
+		return event, matched
+	}
+	if event.Type != watch.Added && event.Type != watch.Modified {
+		return event, true
+	}
+
+	matches := f.matches(obj)
+	switch {
+	case matches && !matched:
+		f.matching.Insert(key)
+		event.Type = watch.Added
+	case !matches && matched:
+		f.matching.Delete(key)
+		event.Type = watch.Deleted
+	case !matches:
+		return event, false
+	}
+	return event, true
+}
+
+// matches returns true if the object matches the filters, once transformed as the objects of the cache are, so that
+// the fields the cache adds can be filtered on
+func (f *filteredWatch) matches(obj *unstructured.Unstructured) bool {
+	transformed := obj.DeepCopy()
+	if f.transform != nil {
+		result, err := f.transform(transformed)
+		if err != nil {
+			return false
+		}
+		if u, ok := result.(*unstructured.Unstructured); ok {
+			transformed = u
+		}
+	}
+	return f.filters.Matches(*transformed)
+}
+
+func watchKey(obj *unstructured.Unstructured) string {
+	if obj.GetNamespace() == "" {
+		return obj.GetName()
+	}
+	return obj.GetNamespace() + "/" + obj.GetName()
+}
@@ -0,0 +1,96 @@
+package sqlproxy
+
+import (
+	"context"
+	"testing"
+
+	"github.com/rancher/steve/pkg/stores/sqlpartition/listprocessor"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/util/sets"
+	"k8s.io/apimachinery/pkg/watch"
+)
+
+func TestFilteredWatchEvent(t *testing.T) {
+	filters, ok, err := listprocessor.ParseWatchFilters(context.Background(), "?filter=metadata.labels[app]='web'&filter=metadata.state.name=active", listprocessor.ParseOptions{})
+	require.NoError(t, err)
+	require.True(t, ok)
+	pod := func(name, app string) *unstructured.Unstructured {
+		obj := &unstructured.Unstructured{Object: map[string]interface{}{}}
+		obj.SetNamespace("default")
+		obj.SetName(name)
+		obj.SetLabels(map[string]string{"app": app})
+		return obj
+	}
+	f := &filteredWatch{
+		filters: filters,
+		// the fields added by the transforms of the cache can be filtered on
+		transform: func(obj interface{}) (interface{}, error) {
+			u := obj.(*unstructured.Unstructured)
+			return u, unstructured.SetNestedField(u.Object, "active", "metadata", "state", "name")
+		},
+		matching: sets.New("default/web-1"),
+	}
+
+	tests := []struct {
+		name     string
+		event    watch.Event
+		expected watch.EventType
+		dropped  bool
+	}{
+		{
+			name:     "a matching object is modified",
+			event:    watch.Event{Type: watch.Modified, Object: pod("web-1", "web")},
+			expected: watch.Modified,
+		},
+		{
+			name:     "an object starts matching",
+			event:    watch.Event{Type: watch.Modified, Object: pod("web-2", "web")},
+			expected: watch.Added,
+		},
+		{
+			name:     "an object stops matching",
+			event:    watch.Event{Type: watch.Modified, Object: pod("web-1", "db")},
+			expected: watch.Deleted,
+		},
+		{
+			name:    "an object which doesn't match is modified",
+			event:   watch.Event{Type: watch.Modified, Object: pod("web-1", "db")},
+			dropped: true,
+		},
+		{
+			name:    "an object which doesn't match is added",
+			event:   watch.Event{Type: watch.Added, Object: pod("db-1", "db")},
+			dropped: true,
+		},
+		{
+			name:    "an object which doesn't match is deleted",
+			event:   watch.Event{Type: watch.Deleted, Object: pod("db-1", "db")},
+			dropped: true,
+		},
+		{
+			name:     "a matching object is deleted",
+			event:    watch.Event{Type: watch.Deleted, Object: pod("web-2", "web")},
+			expected: watch.Deleted,
+		},
+		{
+			name:     "bookmarks are sent",
+			event:    watch.Event{Type: watch.Bookmark, Object: pod("", "")},
+			expected: watch.Bookmark,
+		},
+	}
+	for _, test := range tests {
+		event, ok := f.event(test.event)
+		if test.dropped {
+			assert.False(t, ok, test.name)
+			continue
+		}
+		assert.True(t, ok, test.name)
+		assert.Equal(t, test.expected, event.Type, test.name)
+		// events are sent with the objects of Kubernetes, not transformed ones
+		_, found, _ := unstructured.NestedString(event.Object.(*unstructured.Unstructured).Object, "metadata", "state", "name")
+		assert.False(t, found, test.name)
+	}
+	assert.Empty(t, f.matching)
+}
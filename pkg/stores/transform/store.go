@@ -0,0 +1,117 @@
+// Package transform provides a store wrapper running interceptors registered by embedders, which can mutate objects
+// before they are written and post-process list results.
+package transform
+
+import (
+	"sync"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/steve/pkg/schema"
+)
+
+// Interceptor mutates objects on the store path of every schema. Either function may be nil. Interceptors can check
+// the schema to only act on some types, and have access to the request, e.g. to get the user.
+type Interceptor struct {
+	// Write is called with the object of create and update requests before it is written
+	Write func(apiOp *types.APIRequest, schema *types.APISchema, obj types.APIObject) (types.APIObject, error)
+	// List is called with list results before they are returned
+	List func(apiOp *types.APIRequest, schema *types.APISchema, list types.APIObjectList) (types.APIObjectList, error)
+}
+
+// Interceptors is the set of registered interceptors, which are run in the order they were added
+type Interceptors struct {
+	lock         sync.RWMutex
+	interceptors []Interceptor
+}
+
+// Add registers interceptors. They apply to requests made after they are added.
+func (i *Interceptors) Add(interceptors ...Interceptor) {
+	i.lock.Lock()
+	defer i.lock.Unlock()
+	i.interceptors = append(i.interceptors, interceptors...)
+}
+
+func (i *Interceptors) list() []Interceptor {
+	i.lock.RLock()
+	defer i.lock.RUnlock()
+	return i.interceptors
+}
+
+// Template returns a schema template wrapping the store of every schema to run the interceptors
+func Template(interceptors *Interceptors) schema.Template {
+	return schema.Template{
+		Customize: func(apiSchema *types.APISchema) {
+			if apiSchema.Store == nil {
+				return
+			}
+			if _, ok := apiSchema.Store.(*Store); ok {
+				return
+			}
+			apiSchema.Store = NewStore(apiSchema.Store, interceptors)
+		},
+	}
+}
+
+// Store runs interceptors around the calls to the wrapped store
+type Store struct {
+	types.Store
+	interceptors *Interceptors
+}
+
+// NewStore returns a store running interceptors around the calls to s
+func NewStore(s types.Store, interceptors *Interceptors) *Store {
+	return &Store{
+		Store:        s,
+		interceptors: interceptors,
+	}
+}
+
+// List returns a list of resources, post-processed by the interceptors.
+func (s *Store) List(apiOp *types.APIRequest, schema *types.APISchema) (types.APIObjectList, error) {
+	list, err := s.Store.List(apiOp, schema)
+	if err != nil {
+		return list, err
+	}
+	for _, interceptor := range s.interceptors.list() {
+		if interceptor.List == nil {
+			continue
+		}
+		list, err = interceptor.List(apiOp, schema, list)
+		if err != nil {
+			return types.APIObjectList{}, err
+		}
+	}
+	return list, nil
+}
+
+// Create creates a single object in the store, after it is mutated by the interceptors.
+func (s *Store) Create(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject) (types.APIObject, error) {
+	data, err := s.write(apiOp, schema, data)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	return s.Store.Create(apiOp, schema, data)
+}
+
+// Update updates a single object in the store, after it is mutated by the interceptors.
+func (s *Store) Update(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject, id string) (types.APIObject, error) {
+	data, err := s.write(apiOp, schema, data)
+	if err != nil {
+		return types.APIObject{}, err
+	}
+	return s.Store.Update(apiOp, schema, data, id)
+}
+
+func (s *Store) write(apiOp *types.APIRequest, schema *types.APISchema, data types.APIObject) (types.APIObject, error) {
+	var err error
+	for _, interceptor := range s.interceptors.list() {
+		if interceptor.Write == nil {
+			continue
+		}
+		data, err = interceptor.Write(apiOp, schema, data)
+		if err != nil {
+			return types.APIObject{}, err
+		}
+	}
+	return data, nil
+}
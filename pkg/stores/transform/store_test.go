@@ -0,0 +1,97 @@
+package transform
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+type testStore struct {
+	types.Store
+	written []types.APIObject
+}
+
+func (t *testStore) List(_ *types.APIRequest, _ *types.APISchema) (types.APIObjectList, error) {
+	return types.APIObjectList{Objects: []types.APIObject{
+		{ID: "a", Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "a"}}},
+		{ID: "b", Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "b"}}},
+	}}, nil
+}
+
+func (t *testStore) Create(_ *types.APIRequest, _ *types.APISchema, data types.APIObject) (types.APIObject, error) {
+	t.written = append(t.written, data)
+	return data, nil
+}
+
+func (t *testStore) Update(_ *types.APIRequest, _ *types.APISchema, data types.APIObject, _ string) (types.APIObject, error) {
+	t.written = append(t.written, data)
+	return data, nil
+}
+
+func annotate(key, value string) Interceptor {
+	return Interceptor{
+		Write: func(_ *types.APIRequest, _ *types.APISchema, obj types.APIObject) (types.APIObject, error) {
+			obj.Data().SetNested(value, "metadata", "annotations", key)
+			return obj, nil
+		},
+	}
+}
+
+func TestStore(t *testing.T) {
+	interceptors := &Interceptors{}
+	backing := &testStore{}
+	store := NewStore(backing, interceptors)
+	schema := &types.APISchema{Schema: &schemas.Schema{ID: "configmap"}}
+	apiOp := &types.APIRequest{}
+
+	obj := types.APIObject{Object: map[string]interface{}{"metadata": map[string]interface{}{"name": "a"}}}
+	_, err := store.Create(apiOp, schema, obj)
+	require.NoError(t, err)
+	assert.Nil(t, backing.written[0].Data().Map("metadata").Map("annotations"), "nothing is changed without interceptors")
+
+	interceptors.Add(annotate("first", "1"), annotate("second", "2"), Interceptor{
+		List: func(_ *types.APIRequest, _ *types.APISchema, list types.APIObjectList) (types.APIObjectList, error) {
+			list.Objects = list.Objects[1:]
+			return list, nil
+		},
+	})
+
+	_, err = store.Update(apiOp, schema, obj, "a")
+	require.NoError(t, err)
+	assert.Equal(t, map[string]interface{}{"first": "1", "second": "2"}, map[string]interface{}(backing.written[1].Data().Map("metadata").Map("annotations")))
+
+	list, err := store.List(apiOp, schema)
+	require.NoError(t, err)
+	require.Len(t, list.Objects, 1)
+	assert.Equal(t, "b", list.Objects[0].ID)
+
+	interceptors.Add(Interceptor{
+		Write: func(_ *types.APIRequest, _ *types.APISchema, obj types.APIObject) (types.APIObject, error) {
+			return obj, fmt.Errorf("denied")
+		},
+	})
+	_, err = store.Create(apiOp, schema, obj)
+	assert.EqualError(t, err, "denied")
+	assert.Len(t, backing.written, 2, "objects are not written if an interceptor fails")
+}
+
+func TestTemplate(t *testing.T) {
+	interceptors := &Interceptors{}
+	template := Template(interceptors)
+
+	schema := &types.APISchema{Schema: &schemas.Schema{ID: "configmap"}, Store: &testStore{}}
+	template.Customize(schema)
+	wrapped, ok := schema.Store.(*Store)
+	require.True(t, ok)
+
+	template.Customize(schema)
+	assert.Same(t, wrapped, schema.Store, "stores are only wrapped once")
+
+	noStore := &types.APISchema{Schema: &schemas.Schema{ID: "count"}}
+	template.Customize(noStore)
+	assert.Nil(t, noStore.Store)
+}
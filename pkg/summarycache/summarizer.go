@@ -0,0 +1,42 @@
+package summarycache
+
+import (
+	"github.com/rancher/wrangler/v3/pkg/summary"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// Summarizer summarizes objects: it computes their state name, whether they are transitioning or in error, their
+// messages and relationships, which are added to the metadata of objects served by steve and used for counts.
+type Summarizer interface {
+	Summarize(obj runtime.Object) *summary.SummarizedObject
+}
+
+// SummarizerFunc is a function implementing Summarizer
+type SummarizerFunc func(obj runtime.Object) *summary.SummarizedObject
+
+// Summarize calls f(obj)
+func (f SummarizerFunc) Summarize(obj runtime.Object) *summary.SummarizedObject {
+	return f(obj)
+}
+
+// DefaultSummarizer summarizes objects with the rules of wrangler's summary package
+var DefaultSummarizer Summarizer = SummarizerFunc(summary.Summarized)
+
+// TypeSummarizer summarizes objects with the summarizer of their group and kind, so that the rules of some types can
+// be customized. Objects of other types are summarized by Default, or by DefaultSummarizer if Default is nil.
+type TypeSummarizer struct {
+	Default Summarizer
+	Types   map[schema.GroupKind]Summarizer
+}
+
+// Summarize summarizes obj with the summarizer of its type
+func (t *TypeSummarizer) Summarize(obj runtime.Object) *summary.SummarizedObject {
+	if summarizer, ok := t.Types[obj.GetObjectKind().GroupVersionKind().GroupKind()]; ok {
+		return summarizer.Summarize(obj)
+	}
+	if t.Default != nil {
+		return t.Default.Summarize(obj)
+	}
+	return DefaultSummarizer.Summarize(obj)
+}
@@ -0,0 +1,77 @@
+package summarycache
+
+import (
+	"testing"
+
+	"github.com/rancher/wrangler/v3/pkg/summary"
+	"github.com/stretchr/testify/assert"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+func stateSummarizer(state string) Summarizer {
+	return SummarizerFunc(func(obj runtime.Object) *summary.SummarizedObject {
+		summarized := summary.Summarized(obj)
+		summarized.State = state
+		return summarized
+	})
+}
+
+func TestTypeSummarizer(t *testing.T) {
+	widget := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "example.io/v1",
+		"kind":       "Widget",
+		"metadata": map[string]interface{}{
+			"name": "widget",
+		},
+	}}
+	configMap := &unstructured.Unstructured{Object: map[string]interface{}{
+		"apiVersion": "v1",
+		"kind":       "ConfigMap",
+		"metadata": map[string]interface{}{
+			"name": "config",
+		},
+	}}
+
+	tests := []struct {
+		name       string
+		summarizer *TypeSummarizer
+		obj        runtime.Object
+		wantState  string
+	}{
+		{
+			name: "type summarizer",
+			summarizer: &TypeSummarizer{
+				Types: map[schema.GroupKind]Summarizer{
+					{Group: "example.io", Kind: "Widget"}: stateSummarizer("spinning"),
+				},
+			},
+			obj:       widget,
+			wantState: "spinning",
+		},
+		{
+			name: "default summarizer",
+			summarizer: &TypeSummarizer{
+				Default: stateSummarizer("fallback"),
+				Types: map[schema.GroupKind]Summarizer{
+					{Group: "example.io", Kind: "Widget"}: stateSummarizer("spinning"),
+				},
+			},
+			obj:       configMap,
+			wantState: "fallback",
+		},
+		{
+			name:       "wrangler summarizer without default",
+			summarizer: &TypeSummarizer{},
+			obj:        configMap,
+			wantState:  summary.Summarized(configMap).State,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			summarized := test.summarizer.Summarize(test.obj)
+			assert.Equal(t, test.wantState, summarized.State)
+		})
+	}
+}
@@ -47,15 +47,21 @@ type SummaryCache struct {
 	cache        cache.ThreadSafeStore
 	schemas      *schema.Collection
 	clusterCache clustercache.ClusterCache
+	summarizer   Summarizer
 	cbs          map[int]chan *summary.Relationship
 }
 
-func New(schemas *schema.Collection, clusterCache clustercache.ClusterCache) *SummaryCache {
+// New returns a summary cache summarizing objects with summarizer, or with DefaultSummarizer if summarizer is nil
+func New(schemas *schema.Collection, clusterCache clustercache.ClusterCache, summarizer Summarizer) *SummaryCache {
+	if summarizer == nil {
+		summarizer = DefaultSummarizer
+	}
 	indexers := cache.Indexers{}
 	s := &SummaryCache{
 		cache:        cache.NewThreadSafeStore(indexers, cache.Indices{}),
 		schemas:      schemas,
 		clusterCache: clusterCache,
+		summarizer:   summarizer,
 		cbs:          map[int]chan *summary.Relationship{},
 	}
 	indexers[relationshipIndex] = s.relationshipIndexer
@@ -106,7 +112,7 @@ func (s *SummaryCache) SummaryAndRelationship(obj runtime.Object) (*summary.Summ
 	defer s.RUnlock()
 
 	key := toKey(obj)
-	summarized := summary.Summarized(obj)
+	summarized := s.summarizer.Summarize(obj)
 
 	relObjs, err := s.cache.ByIndex(relationshipIndex, key)
 	if err != nil {
@@ -179,7 +185,7 @@ func (s *SummaryCache) toRel(ns string, rel *summary.Relationship) Relationship
 	}
 
 	if rel.Inbound {
-		return addObject(Relationship{
+		return s.addObject(Relationship{
 			FromID:   id,
 			FromType: converter.GVKToSchemaID(runtimeschema.FromAPIVersionAndKind(rel.APIVersion, rel.Kind)),
 			Rel:      rel.Type,
@@ -191,7 +197,7 @@ func (s *SummaryCache) toRel(ns string, rel *summary.Relationship) Relationship
 		toNS = ns
 	}
 
-	return addObject(Relationship{
+	return s.addObject(Relationship{
 		ToID:        id,
 		ToType:      converter.GVKToSchemaID(runtimeschema.FromAPIVersionAndKind(rel.APIVersion, rel.Kind)),
 		Rel:         rel.Type,
@@ -200,7 +206,7 @@ func (s *SummaryCache) toRel(ns string, rel *summary.Relationship) Relationship
 	}, obj)
 }
 
-func addObject(rel Relationship, obj interface{}) Relationship {
+func (s *SummaryCache) addObject(rel Relationship, obj interface{}) Relationship {
 	if obj == nil {
 		return rel
 	}
@@ -210,7 +216,7 @@ func addObject(rel Relationship, obj interface{}) Relationship {
 		return rel
 	}
 
-	summarized := summary.Summarized(ro)
+	summarized := s.summarizer.Summarize(ro)
 	rel.State = summarized.State
 	rel.Error = summarized.Error
 	rel.Message = strings.Join(summarized.Message, "; ")
@@ -267,7 +273,7 @@ func (s *SummaryCache) Change(newObj, oldObj runtime.Object) {
 func (s *SummaryCache) process(obj runtime.Object) (*summary.SummarizedObject, []*summary.Relationship) {
 	var (
 		rels    []*summary.Relationship
-		summary = summary.Summarized(obj)
+		summary = s.summarizer.Summarize(obj)
 	)
 
 	for _, rel := range summary.Relationships {
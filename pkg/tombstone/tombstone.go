@@ -0,0 +1,114 @@
+// Package tombstone keeps metadata-only copies of recently deleted objects, so that lists can show them.
+package tombstone
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/rancher/steve/pkg/clustercache"
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+)
+
+// maxPerType bounds the number of tombstones kept for each type, the oldest ones being dropped first
+const maxPerType = 1000
+
+// Tombstones records the objects deleted within the retention window
+type Tombstones struct {
+	lock      sync.Mutex
+	retention time.Duration
+	byGVK     map[schema.GroupVersionKind][]tombstone
+	now       func() time.Time
+}
+
+type tombstone struct {
+	deletedAt time.Time
+	obj       unstructured.Unstructured
+}
+
+// New returns Tombstones keeping deleted objects for the retention duration.
+func New(retention time.Duration) *Tombstones {
+	return &Tombstones{
+		retention: retention,
+		byGVK:     map[schema.GroupVersionKind][]tombstone{},
+		now:       time.Now,
+	}
+}
+
+// Start records the objects removed from the cluster cache.
+func (t *Tombstones) Start(ctx context.Context, clusterCache clustercache.ClusterCache) {
+	clusterCache.OnRemove(ctx, t.OnRemove)
+}
+
+// OnRemove records a tombstone for obj.
+func (t *Tombstones) OnRemove(gvk schema.GroupVersionKind, _ string, obj runtime.Object) error {
+	m, err := meta.Accessor(obj)
+	if err != nil {
+		return nil
+	}
+
+	deletedAt := t.now()
+	result := unstructured.Unstructured{Object: map[string]interface{}{}}
+	result.SetAPIVersion(gvk.GroupVersion().String())
+	result.SetKind(gvk.Kind)
+	result.SetNamespace(m.GetNamespace())
+	result.SetName(m.GetName())
+	result.SetUID(m.GetUID())
+	result.SetLabels(m.GetLabels())
+	result.SetCreationTimestamp(m.GetCreationTimestamp())
+	result.SetDeletionTimestamp(&metav1.Time{Time: deletedAt})
+	id := m.GetName()
+	if m.GetNamespace() != "" {
+		id = m.GetNamespace() + "/" + id
+	}
+	result.Object["id"] = id
+	_ = unstructured.SetNestedMap(result.Object, map[string]interface{}{
+		"name":          "deleted",
+		"error":         false,
+		"transitioning": false,
+	}, "metadata", "state")
+
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	tombstones := append(t.prune(gvk), tombstone{deletedAt: deletedAt, obj: result})
+	if len(tombstones) > maxPerType {
+		tombstones = tombstones[len(tombstones)-maxPerType:]
+	}
+	t.byGVK[gvk] = tombstones
+	return nil
+}
+
+// List returns copies of the tombstones of the objects of a type deleted within the retention window, oldest first.
+func (t *Tombstones) List(gvk schema.GroupVersionKind) []unstructured.Unstructured {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+
+	tombstones := t.prune(gvk)
+	result := make([]unstructured.Unstructured, 0, len(tombstones))
+	for _, tombstone := range tombstones {
+		result = append(result, *tombstone.obj.DeepCopy())
+	}
+	return result
+}
+
+// prune drops the tombstones of a type which are older than the retention window, and returns the remaining ones.
+// It must be called with the lock held.
+func (t *Tombstones) prune(gvk schema.GroupVersionKind) []tombstone {
+	tombstones := t.byGVK[gvk]
+	cutoff := t.now().Add(-t.retention)
+	i := 0
+	for i < len(tombstones) && tombstones[i].deletedAt.Before(cutoff) {
+		i++
+	}
+	tombstones = tombstones[i:]
+	if len(tombstones) == 0 {
+		delete(t.byGVK, gvk)
+		return nil
+	}
+	t.byGVK[gvk] = tombstones
+	return tombstones
+}
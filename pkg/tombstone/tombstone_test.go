@@ -0,0 +1,66 @@
+package tombstone
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	corev1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+var podGVK = schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+
+func newPod(name string) *corev1.Pod {
+	return &corev1.Pod{
+		ObjectMeta: metav1.ObjectMeta{
+			Namespace: "default",
+			Name:      name,
+			UID:       types.UID("uid-" + name),
+			Labels:    map[string]string{"app": "web"},
+		},
+		Spec: corev1.PodSpec{NodeName: "node1"},
+	}
+}
+
+func TestTombstones(t *testing.T) {
+	now := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	tombstones := New(time.Hour)
+	tombstones.now = func() time.Time { return now }
+
+	assert.NoError(t, tombstones.OnRemove(podGVK, "default/a", newPod("a")))
+	now = now.Add(30 * time.Minute)
+	assert.NoError(t, tombstones.OnRemove(podGVK, "default/b", newPod("b")))
+
+	list := tombstones.List(podGVK)
+	assert.Len(t, list, 2)
+	obj := list[0]
+	assert.Equal(t, "v1", obj.GetAPIVersion())
+	assert.Equal(t, "Pod", obj.GetKind())
+	assert.Equal(t, "default/a", obj.Object["id"])
+	assert.Equal(t, map[string]string{"app": "web"}, obj.GetLabels())
+	assert.Equal(t, time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC), obj.GetDeletionTimestamp().Time.UTC())
+	assert.NotContains(t, obj.Object, "spec", "tombstones should only keep metadata")
+	assert.Equal(t, "deleted", obj.Object["metadata"].(map[string]interface{})["state"].(map[string]interface{})["name"])
+
+	// tombstones expire after the retention window
+	now = now.Add(45 * time.Minute)
+	list = tombstones.List(podGVK)
+	assert.Len(t, list, 1)
+	assert.Equal(t, "b", list[0].GetName())
+
+	assert.Empty(t, tombstones.List(schema.GroupVersionKind{Version: "v1", Kind: "Secret"}))
+}
+
+func TestTombstonesLimit(t *testing.T) {
+	tombstones := New(time.Hour)
+	for i := 0; i < maxPerType+10; i++ {
+		assert.NoError(t, tombstones.OnRemove(podGVK, "", newPod(fmt.Sprintf("pod%d", i))))
+	}
+	list := tombstones.List(podGVK)
+	assert.Len(t, list, maxPerType)
+	assert.Equal(t, "pod10", list[0].GetName())
+}
@@ -0,0 +1,142 @@
+// Package tracing instruments steve with OpenTelemetry spans, from the HTTP requests it serves to the queries of the
+// SQL cache and the requests it sends to Kubernetes, so that slow requests can be traced through their steps.
+//
+// Spans are created with the global tracer provider, which doesn't record anything unless it is set, either by
+// Config.Setup or by programs embedding steve.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const (
+	instrumentationName = "github.com/rancher/steve"
+
+	// ExporterOTLP exports spans with the OTLP gRPC protocol
+	ExporterOTLP = "otlp"
+)
+
+// Config configures the export of spans
+type Config struct {
+	// Exporter is the exporter of spans, only ExporterOTLP is supported. Tracing is disabled if it is empty
+	Exporter string
+	// Endpoint is the host and port of the OTLP collector, localhost:4317 by default
+	Endpoint string
+	// Insecure disables TLS with the collector
+	Insecure bool
+	// SampleRatio is the ratio of the traces started by steve which are sampled. Traces continued from clients are
+	// sampled if the client sampled them
+	SampleRatio float64
+	// ServiceName is the service.name of the spans, steve by default
+	ServiceName string
+}
+
+// Enabled returns whether spans are exported
+func (c *Config) Enabled() bool {
+	return c.Exporter != ""
+}
+
+// Setup sets the global tracer provider and propagator, so that spans are exported and trace contexts are propagated
+// with the W3C Trace Context and Baggage headers. The returned function flushes the spans and stops the exporter.
+func (c *Config) Setup(ctx context.Context) (func(context.Context) error, error) {
+	if c.Exporter != ExporterOTLP {
+		return nil, fmt.Errorf("unsupported tracing exporter %q, expected %s", c.Exporter, ExporterOTLP)
+	}
+	var opts []otlptracegrpc.Option
+	if c.Endpoint != "" {
+		opts = append(opts, otlptracegrpc.WithEndpoint(c.Endpoint))
+	}
+	if c.Insecure {
+		opts = append(opts, otlptracegrpc.WithInsecure())
+	}
+	exporter, err := otlptracegrpc.New(ctx, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	serviceName := c.ServiceName
+	if serviceName == "" {
+		serviceName = "steve"
+	}
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(c.SampleRatio))),
+		sdktrace.WithResource(resource.NewSchemaless(attribute.String("service.name", serviceName))),
+	)
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return provider.Shutdown, nil
+}
+
+// Start starts a span, child of the span of ctx if any. The context is returned unchanged if the span isn't recorded,
+// as is the case when tracing is disabled, since its children wouldn't be recorded either.
+func Start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	spanCtx, span := otel.Tracer(instrumentationName).Start(ctx, name, trace.WithAttributes(attrs...))
+	if !span.IsRecording() {
+		return ctx, span
+	}
+	return spanCtx, span
+}
+
+// StartStore starts the span of an operation of a store on objects of the schema, returning the request with the
+// context of the span so that the spans of the requests sent to Kubernetes are its children
+func StartStore(apiOp *types.APIRequest, name string, schema *types.APISchema) (*types.APIRequest, trace.Span) {
+	attrs := []attribute.KeyValue{attribute.String("steve.namespace", apiOp.Namespace)}
+	if schema != nil {
+		attrs = append(attrs, attribute.String("steve.schema", schema.ID))
+	}
+	if apiOp.Request == nil {
+		_, span := Start(context.Background(), name, attrs...)
+		return apiOp, span
+	}
+	ctx, span := Start(apiOp.Context(), name, attrs...)
+	if !span.IsRecording() {
+		return apiOp, span
+	}
+	return apiOp.WithContext(ctx), span
+}
+
+// End records the error, if any, on the span and ends it
+func End(span trace.Span, err error) {
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+	span.End()
+}
+
+// Handler traces the requests served by next, continuing the traces propagated by clients
+func Handler(next http.Handler) http.Handler {
+	return otelhttp.NewHandler(next, "steve", otelhttp.WithSpanNameFormatter(func(_ string, req *http.Request) string {
+		return req.Method + " " + route(req.URL.Path)
+	}))
+}
+
+// WrapTransport traces the requests sent with rt, propagating their trace context
+func WrapTransport(rt http.RoundTripper) http.RoundTripper {
+	return otelhttp.NewTransport(rt)
+}
+
+// route returns the part of the path naming the spans of requests, without the namespaces and names of objects to
+// keep the number of span names low: the type of /v1 requests and the first segment of other paths
+func route(path string) string {
+	parts := strings.Split(strings.TrimPrefix(path, "/"), "/")
+	if parts[0] == "v1" && len(parts) > 1 && parts[1] != "" {
+		return "/v1/" + parts[1]
+	}
+	return "/" + parts[0]
+}
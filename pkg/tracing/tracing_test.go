@@ -0,0 +1,65 @@
+package tracing
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/rancher/apiserver/pkg/types"
+	"github.com/rancher/wrangler/v3/pkg/schemas"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace/noop"
+)
+
+func TestRoute(t *testing.T) {
+	tests := []struct {
+		path string
+		want string
+	}{
+		{path: "/v1/apps.deployments/default/web", want: "/v1/apps.deployments"},
+		{path: "/v1/pods", want: "/v1/pods"},
+		{path: "/v1", want: "/v1"},
+		{path: "/api/v1/namespaces/default/pods", want: "/api"},
+		{path: "/", want: "/"},
+	}
+	for _, test := range tests {
+		assert.Equal(t, test.want, route(test.path), test.path)
+	}
+}
+
+func TestHandler(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+	defer otel.SetTracerProvider(noop.NewTracerProvider())
+
+	handler := Handler(http.HandlerFunc(func(rw http.ResponseWriter, req *http.Request) {
+		apiOp := &types.APIRequest{Request: req, Namespace: "default"}
+		apiOp, span := StartStore(apiOp, "proxy.List", &types.APISchema{Schema: &schemas.Schema{ID: "pod"}})
+		_, child := Start(apiOp.Context(), "sqlcache.ListByOptions")
+		End(child, errors.New("database is locked"))
+		span.End()
+	}))
+	req := httptest.NewRequest(http.MethodGet, "/v1/pods/default", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	handler.ServeHTTP(httptest.NewRecorder(), req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 3)
+	query, store, server := spans[0], spans[1], spans[2]
+	assert.Equal(t, "GET /v1/pods", server.Name())
+	assert.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", server.SpanContext().TraceID().String(), "the trace of the client is continued")
+	assert.Equal(t, server.SpanContext().SpanID(), store.Parent().SpanID())
+	assert.Equal(t, store.SpanContext().SpanID(), query.Parent().SpanID())
+	assert.Equal(t, codes.Error, query.Status().Code)
+	assert.Contains(t, store.Attributes(), attribute.String("steve.schema", "pod"))
+}
@@ -0,0 +1,240 @@
+// Package usage scrapes the CPU and memory usage of pods and nodes from the resource metrics API (metrics.k8s.io),
+// served by metrics-server, so that lists of pods and nodes can show, sort and filter on their actual usage.
+//
+// Usage is written to the columns of the fields tables of pods and nodes in the SQL cache, which index it like other
+// fields, so that lists sort, filter and paginate on it in SQL. Objects are given their usage when they are written
+// to the cache, and the columns of all of them are updated on each scrape.
+package usage
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	sqlcachedb "github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/sirupsen/logrus"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic"
+)
+
+// DefaultInterval is how often usage is scraped by default, metrics-server itself scraping the kubelets every 15s
+const DefaultInterval = 30 * time.Second
+
+var (
+	// CPUField is the virtual field holding the CPU usage of pods and nodes, in millicores
+	CPUField = []string{"status", "usage", "cpu"}
+	// MemoryField is the virtual field holding the memory usage of pods and nodes, in bytes
+	MemoryField = []string{"status", "usage", "memory"}
+
+	podGVK  = schema.GroupVersionKind{Version: "v1", Kind: "Pod"}
+	nodeGVK = schema.GroupVersionKind{Version: "v1", Kind: "Node"}
+
+	podMetricsGVR  = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "pods"}
+	nodeMetricsGVR = schema.GroupVersionResource{Group: "metrics.k8s.io", Version: "v1beta1", Resource: "nodes"}
+)
+
+// Usage is the resource usage of a pod, the sum of the usage of its containers, or of a node
+type Usage struct {
+	// CPU is in millicores
+	CPU int64
+	// Memory is in bytes
+	Memory int64
+}
+
+// DB is the database of the SQL cache, whose fields tables usage is written to
+type DB interface {
+	BeginTx(ctx context.Context, forWriting bool) (sqlcachedb.TXClient, error)
+}
+
+// Scraper keeps the last usage of pods and nodes scraped from the resource metrics API
+type Scraper struct {
+	client dynamic.Interface
+	db     DB
+
+	lock sync.RWMutex
+	// pods are keyed by namespace/name, nodes by name
+	pods  map[string]Usage
+	nodes map[string]Usage
+	// available is false while the resource metrics API isn't served
+	available bool
+}
+
+// NewScraper returns a Scraper reading the resource metrics API with client, and writing usage to db if it isn't nil
+func NewScraper(client dynamic.Interface, db DB) *Scraper {
+	return &Scraper{
+		client: client,
+		db:     db,
+		pods:   map[string]Usage{},
+		nodes:  map[string]Usage{},
+	}
+}
+
+// Run scrapes the usage of pods and nodes every interval until ctx is done
+func (s *Scraper) Run(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		if err := s.Scrape(ctx); err != nil {
+			logrus.Errorf("Failed to scrape the usage of pods and nodes: %v", err)
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+// Scrape reads the current usage of pods and nodes. The previous usage is dropped if the resource metrics API isn't
+// served, for example because metrics-server isn't installed or is down, which isn't an error.
+func (s *Scraper) Scrape(ctx context.Context) error {
+	podMetrics, err := s.client.Resource(podMetricsGVR).List(ctx, metav1.ListOptions{})
+	if isUnavailable(err) {
+		s.unavailable()
+		return s.write(ctx)
+	}
+	if err != nil {
+		return err
+	}
+	nodeMetrics, err := s.client.Resource(nodeMetricsGVR).List(ctx, metav1.ListOptions{})
+	if isUnavailable(err) {
+		s.unavailable()
+		return s.write(ctx)
+	}
+	if err != nil {
+		return err
+	}
+
+	pods := make(map[string]Usage, len(podMetrics.Items))
+	for _, item := range podMetrics.Items {
+		var total Usage
+		containers, _, _ := unstructured.NestedSlice(item.Object, "containers")
+		for _, container := range containers {
+			if m, ok := container.(map[string]interface{}); ok {
+				usage := toUsage(m)
+				total.CPU += usage.CPU
+				total.Memory += usage.Memory
+			}
+		}
+		pods[item.GetNamespace()+"/"+item.GetName()] = total
+	}
+	nodes := make(map[string]Usage, len(nodeMetrics.Items))
+	for _, item := range nodeMetrics.Items {
+		nodes[item.GetName()] = toUsage(item.Object)
+	}
+
+	s.lock.Lock()
+	if !s.available {
+		logrus.Infof("Scraping the usage of pods and nodes from the resource metrics API")
+	}
+	s.pods, s.nodes, s.available = pods, nodes, true
+	s.lock.Unlock()
+	return s.write(ctx)
+}
+
+// write writes the current usage of pods and nodes to the columns of their fields tables, emptying those of objects
+// whose usage isn't known. Types whose cache wasn't created yet are skipped.
+func (s *Scraper) write(ctx context.Context) error {
+	if s.db == nil {
+		return nil
+	}
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	if err := s.writeType(ctx, podGVK, s.pods); err != nil {
+		return err
+	}
+	return s.writeType(ctx, nodeGVK, s.nodes)
+}
+
+func (s *Scraper) writeType(ctx context.Context, gvk schema.GroupVersionKind, byKey map[string]Usage) error {
+	table := sqlcachedb.TypeTable(gvk) + "_fields"
+	cpu, memory := strings.Join(CPUField, "."), strings.Join(MemoryField, ".")
+	tx, err := s.db.BeginTx(ctx, true)
+	if err != nil {
+		return err
+	}
+	// the transaction is rolled back by the failed statement
+	err = tx.Exec(fmt.Sprintf(`UPDATE "%s" SET "%s" = '', "%s" = ''`, table, cpu, memory))
+	if err != nil && strings.Contains(err.Error(), "no such table") {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("clearing the usage of %s: %w", gvk.Kind, err)
+	}
+	update := fmt.Sprintf(`UPDATE "%s" SET "%s" = ?, "%s" = ? WHERE key = ?`, table, cpu, memory)
+	for key, usage := range byKey {
+		if err := tx.Exec(update, fmt.Sprint(usage.CPU), fmt.Sprint(usage.Memory), key); err != nil {
+			return fmt.Errorf("writing the usage of %s: %w", gvk.Kind, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// isUnavailable returns whether err means that the resource metrics API isn't registered, or that its service is down
+func isUnavailable(err error) bool {
+	return apierrors.IsNotFound(err) || apierrors.IsServiceUnavailable(err)
+}
+
+func (s *Scraper) unavailable() {
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if s.available {
+		logrus.Infof("The resource metrics API is no longer served, the usage of pods and nodes is unknown")
+	}
+	s.pods, s.nodes, s.available = map[string]Usage{}, map[string]Usage{}, false
+}
+
+// toUsage parses the usage field of the metrics of a container or node, ignoring invalid quantities
+func toUsage(obj map[string]interface{}) Usage {
+	var result Usage
+	if cpu, ok, _ := unstructured.NestedString(obj, "usage", "cpu"); ok {
+		if q, err := resource.ParseQuantity(cpu); err == nil {
+			result.CPU = q.MilliValue()
+		}
+	}
+	if memory, ok, _ := unstructured.NestedString(obj, "usage", "memory"); ok {
+		if q, err := resource.ParseQuantity(memory); err == nil {
+			result.Memory = q.Value()
+		}
+	}
+	return result
+}
+
+// Fields returns the usage fields of objects of a type, none for types other than pods and nodes
+func (s *Scraper) Fields(gvk schema.GroupVersionKind) [][]string {
+	if gvk != podGVK && gvk != nodeGVK {
+		return nil
+	}
+	return [][]string{CPUField, MemoryField}
+}
+
+// Add sets the usage fields of pods or nodes whose usage is known, and removes those of the others
+func (s *Scraper) Add(gvk schema.GroupVersionKind, items []unstructured.Unstructured) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	byKey := s.pods
+	if gvk == nodeGVK {
+		byKey = s.nodes
+	} else if gvk != podGVK {
+		return
+	}
+	for i := range items {
+		key := items[i].GetName()
+		if namespace := items[i].GetNamespace(); namespace != "" {
+			key = namespace + "/" + key
+		}
+		usage, ok := byKey[key]
+		if !ok {
+			unstructured.RemoveNestedField(items[i].Object, CPUField[:len(CPUField)-1]...)
+			continue
+		}
+		_ = unstructured.SetNestedField(items[i].Object, usage.CPU, CPUField...)
+		_ = unstructured.SetNestedField(items[i].Object, usage.Memory, MemoryField...)
+	}
+}
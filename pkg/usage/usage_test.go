@@ -0,0 +1,136 @@
+package usage
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"testing"
+
+	sqlcachedb "github.com/rancher/steve/pkg/sqlcache/db"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+	"k8s.io/client-go/dynamic/fake"
+	clienttesting "k8s.io/client-go/testing"
+)
+
+func metrics(kind, namespace, name string, fields map[string]interface{}) *unstructured.Unstructured {
+	obj := &unstructured.Unstructured{Object: fields}
+	obj.SetAPIVersion("metrics.k8s.io/v1beta1")
+	obj.SetKind(kind)
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func usageOf(cpu, memory string) map[string]interface{} {
+	return map[string]interface{}{"cpu": cpu, "memory": memory}
+}
+
+func item(namespace, name string) unstructured.Unstructured {
+	obj := unstructured.Unstructured{Object: map[string]interface{}{}}
+	obj.SetNamespace(namespace)
+	obj.SetName(name)
+	return obj
+}
+
+func TestScrape(t *testing.T) {
+	listKinds := map[schema.GroupVersionResource]string{
+		podMetricsGVR:  "PodMetricsList",
+		nodeMetricsGVR: "NodeMetricsList",
+	}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	ctx := context.Background()
+	_, err := client.Resource(podMetricsGVR).Namespace("default").Create(ctx, metrics("PodMetrics", "default", "web", map[string]interface{}{
+		"containers": []interface{}{
+			map[string]interface{}{"name": "app", "usage": usageOf("250m", "128Mi")},
+			map[string]interface{}{"name": "sidecar", "usage": usageOf("1500u", "1Ki")},
+		},
+	}), metav1.CreateOptions{})
+	require.NoError(t, err)
+	_, err = client.Resource(nodeMetricsGVR).Create(ctx, metrics("NodeMetrics", "", "node1", map[string]interface{}{
+		"usage": usageOf("2", "1Gi"),
+	}), metav1.CreateOptions{})
+	require.NoError(t, err)
+	scraper := NewScraper(client, nil)
+	require.NoError(t, scraper.Scrape(ctx))
+
+	pods := []unstructured.Unstructured{item("default", "web"), item("default", "new")}
+	scraper.Add(podGVK, pods)
+	// container usage is summed, 1500u being rounded up to 2m
+	cpu, _, _ := unstructured.NestedInt64(pods[0].Object, CPUField...)
+	assert.Equal(t, int64(252), cpu)
+	memory, _, _ := unstructured.NestedInt64(pods[0].Object, MemoryField...)
+	assert.Equal(t, int64(128*1024*1024+1024), memory)
+	// the usage of pods which weren't scraped yet is unknown
+	assert.NotContains(t, pods[1].Object, "status")
+
+	nodes := []unstructured.Unstructured{item("", "node1")}
+	scraper.Add(nodeGVK, nodes)
+	assert.Equal(t, map[string]interface{}{"cpu": int64(2000), "memory": int64(1024 * 1024 * 1024)}, nodes[0].Object["status"].(map[string]interface{})["usage"])
+
+	// usage is dropped when metrics-server is uninstalled
+	client.PrependReactor("list", "pods", func(clienttesting.Action) (bool, runtime.Object, error) {
+		return true, nil, apierrors.NewNotFound(podMetricsGVR.GroupResource(), "")
+	})
+	require.NoError(t, scraper.Scrape(ctx))
+	pods = []unstructured.Unstructured{item("default", "web")}
+	scraper.Add(podGVK, pods)
+	assert.NotContains(t, pods[0].Object, "status")
+
+	deployments := []unstructured.Unstructured{item("default", "web")}
+	scraper.Add(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}, deployments)
+	assert.NotContains(t, deployments[0].Object, "status")
+}
+
+func TestFields(t *testing.T) {
+	scraper := NewScraper(nil, nil)
+	assert.Equal(t, [][]string{CPUField, MemoryField}, scraper.Fields(podGVK))
+	assert.Equal(t, [][]string{CPUField, MemoryField}, scraper.Fields(nodeGVK))
+	assert.Empty(t, scraper.Fields(schema.GroupVersionKind{Group: "apps", Version: "v1", Kind: "Deployment"}))
+}
+
+func TestWrite(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "cache.db")
+	db, err := sqlcachedb.NewPooledClient(path, 0)
+	require.NoError(t, err)
+	t.Cleanup(func() { db.Close() })
+	ctx := context.Background()
+	// the cache of nodes wasn't created, which isn't an error
+	tx, err := db.BeginTx(ctx, true)
+	require.NoError(t, err)
+	require.NoError(t, tx.Exec(`CREATE TABLE "_v1_Pod_fields" (key TEXT PRIMARY KEY, "status.usage.cpu" TEXT, "status.usage.memory" TEXT)`))
+	require.NoError(t, tx.Exec(`INSERT INTO "_v1_Pod_fields" VALUES ('default/web', '', ''), ('default/old', '5', '5')`))
+	require.NoError(t, tx.Commit())
+
+	listKinds := map[schema.GroupVersionResource]string{
+		podMetricsGVR:  "PodMetricsList",
+		nodeMetricsGVR: "NodeMetricsList",
+	}
+	client := fake.NewSimpleDynamicClientWithCustomListKinds(runtime.NewScheme(), listKinds)
+	_, err = client.Resource(podMetricsGVR).Namespace("default").Create(ctx, metrics("PodMetrics", "default", "web", map[string]interface{}{
+		"containers": []interface{}{map[string]interface{}{"name": "app", "usage": usageOf("250m", "1Ki")}},
+	}), metav1.CreateOptions{})
+	require.NoError(t, err)
+	require.NoError(t, NewScraper(client, db).Scrape(ctx))
+
+	// usage is read in SQL, objects whose usage isn't known having none
+	conn, err := sql.Open("sqlite", path)
+	require.NoError(t, err)
+	defer conn.Close()
+	rows, err := conn.QueryContext(ctx, `SELECT key, "status.usage.cpu", "status.usage.memory" FROM "_v1_Pod_fields" ORDER BY key`)
+	require.NoError(t, err)
+	defer rows.Close()
+	var got [][3]string
+	for rows.Next() {
+		var row [3]string
+		require.NoError(t, rows.Scan(&row[0], &row[1], &row[2]))
+		got = append(got, row)
+	}
+	require.NoError(t, rows.Err())
+	assert.Equal(t, [][3]string{{"default/old", "", ""}, {"default/web", "250", "1024"}}, got)
+}